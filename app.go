@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	_ "embed"
 	"fmt"
 	"image"
@@ -14,7 +13,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -27,15 +25,26 @@ import (
 	"imagery-desktop/internal/cache"
 	"imagery-desktop/internal/common"
 	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/customsource"
 	"imagery-desktop/internal/downloads"
+	customDownloader "imagery-desktop/internal/downloads/custom"
 	"imagery-desktop/internal/downloads/esri"
 	geDownloader "imagery-desktop/internal/downloads/googleearth"
 	esriClient "imagery-desktop/internal/esri"
 	"imagery-desktop/internal/googleearth"
 	"imagery-desktop/internal/handlers/tileserver"
 	"imagery-desktop/internal/imagery"
+	"imagery-desktop/internal/naip"
+	"imagery-desktop/internal/netproxy"
+	"imagery-desktop/internal/providers"
 	"imagery-desktop/internal/ratelimit"
+	"imagery-desktop/internal/singleinstance"
 	"imagery-desktop/internal/taskqueue"
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/internal/updater"
+	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/internal/utils/units"
 	"imagery-desktop/internal/video"
 
 	_ "golang.org/x/image/tiff" // Register TIFF decoder for GeoTIFF loading
@@ -52,6 +61,8 @@ var (
 	PostHogKey  string
 	PostHogHost string
 	AppVersion  string = "0.0.0-dev"
+
+	UpdatePublicKey string // Base64 ed25519 key release checksums are signed with ("" = updates are not installed)
 )
 
 // ImagerySource represents the source of imagery
@@ -89,6 +100,7 @@ type DownloadProgress struct {
 	Status      string `json:"status"`
 	CurrentDate int    `json:"currentDate"`
 	TotalDates  int    `json:"totalDates"`
+	OperationID string `json:"operationId,omitempty"` // Operation the progress belongs to (see ListOperations)
 }
 
 // GEDateInfo contains Google Earth historical date information (duplicated for Wails bindings)
@@ -96,23 +108,28 @@ type GEDateInfo struct {
 	Date    string `json:"date"`
 	HexDate string `json:"hexDate"`
 	Epoch   int    `json:"epoch"`
+	Source  string `json:"source,omitempty"` // Per-date source for mixed-source timelapses
 }
 
 // GEAvailableDate represents an available Google Earth historical date (duplicated for Wails bindings)
 type GEAvailableDate struct {
-	Date    string `json:"date"`
-	Epoch   int    `json:"epoch"`
-	HexDate string `json:"hexDate"`
+	Date     string  `json:"date"`
+	Epoch    int     `json:"epoch"`
+	HexDate  string  `json:"hexDate"`
+	Coverage float64 `json:"coverage,omitempty"` // Percentage of sampled tiles with this date
 }
 
 // Conversion helpers between app types and downloads package types
 
+// toDownloadsBBox also normalizes longitudes from a wrapped map view (e.g. 170..190)
+// into [-180, 180], with West > East for boxes crossing the antimeridian
 func (b BoundingBox) toDownloadsBBox() downloads.BoundingBox {
+	west, east := tilemath.NormalizeLonRange(b.West, b.East)
 	return downloads.BoundingBox{
 		South: b.South,
-		West:  b.West,
+		West:  west,
 		North: b.North,
-		East:  b.East,
+		East:  east,
 	}
 }
 
@@ -121,6 +138,7 @@ func (d GEDateInfo) toDownloadsDateInfo() downloads.GEDateInfo {
 		Date:    d.Date,
 		HexDate: d.HexDate,
 		Epoch:   d.Epoch,
+		Source:  d.Source,
 	}
 }
 
@@ -160,6 +178,20 @@ type TileInfo struct {
 	ZoomLevel  int     `json:"zoomLevel"`
 	Resolution float64 `json:"resolution"` // meters per pixel
 	EstSizeMB  float64 `json:"estSizeMB"`
+
+	ResolutionLabel string `json:"resolutionLabel"` // Resolution in the user's unit system, e.g. "0.60 m/px"
+}
+
+// ZoomSuggestion is the zoom level chosen for a source to meet a target resolution
+type ZoomSuggestion struct {
+	Source           string  `json:"source"`
+	Zoom             int     `json:"zoom"`
+	Resolution       float64 `json:"resolution"`       // Actual meters per pixel at the bbox center
+	TargetResolution float64 `json:"targetResolution"` // Requested meters per pixel
+	TileCount        int     `json:"tileCount"`
+	Clamped          bool    `json:"clamped"` // True if the provider's max zoom can't reach the target
+
+	ResolutionLabel string `json:"resolutionLabel"` // Resolution in the user's unit system
 }
 
 // App struct
@@ -168,42 +200,80 @@ type App struct {
 	geClient          *googleearth.Client
 	esriClient        *esriClient.Client
 	tileCache         *cache.PersistentTileCache // Changed to PersistentTileCache
+	epochCache        *googleearth.EpochCache    // Learned working epochs for historical GE tiles
+	packetCache       *googleearth.PacketCache   // Quadtree/TimeMachine packets shared by all GE fetches
 	downloader        *imagery.TileDownloader
 	esriDownloader    *esri.Downloader        // Esri-specific downloader
 	geDownloader      *geDownloader.Downloader // Google Earth downloader
+	customClient      *customsource.Client         // User-configured XYZ/TMS/WMTS/WMS sources
+	customDownloader  *customDownloader.Downloader // Custom source downloader
+	naipClient        *naip.Client                 // USGS NAIP catalog (years per area)
+	providers         *providers.Registry          // Every imagery source behind the ImageryProvider interface
 	downloadPath      string
 	tileServer        *tileserver.Server // Tile server for serving decrypted Google Earth tiles
 	settings          *config.UserSettings
+	settingsLoadError string // Why the settings file could not be read at startup ("" = loaded)
 	mu                sync.Mutex
 	devMode           bool // Enable verbose logging in dev mode only
 	phClient          posthog.Client
-	inRangeDownload   bool // Track if we're downloading a date range (suppress per-tile progress)
-	currentDateIndex  int  // Current date being processed in range download
-	totalDatesInRange int  // Total dates in range download
 	taskQueue         *taskqueue.QueueManager // Task queue for background exports
 
-	// Task queue progress tracking
-	currentTaskID     string                          // Current task ID when running in queue mode
-	taskProgressChan  chan<- taskqueue.TaskProgress   // Channel to forward progress to task worker
-	taskOutputPath    string                          // Output directory for current task
-
 	// Folder open tracking (to avoid opening duplicate windows on Windows)
 	lastOpenedFolders map[string]time.Time // Map of folder path -> last opened time
 	folderOpenMu      sync.Mutex           // Mutex for folder open tracking
 
+	// In-flight manual downloads, waited for on close (see app_shutdown.go)
+	downloadsCtx    context.Context    // Manual downloads run in this; cancelled if they outlive the grace period
+	cancelDownloads context.CancelFunc // Cancels downloadsCtx
+	downloadsWg     sync.WaitGroup     // Counts running manual downloads
+	downloadsMu     sync.Mutex         // Guards closing
+	closing         bool               // Window is closing: refuse new downloads
+
+	// Manual downloads and exports by operation ID (see app_operations.go)
+	operations   map[string]*operationState
+	operationsMu sync.Mutex
+
+	// Tile failure report of the latest download run (see app_report.go)
+	lastReport   *downloads.ReportRecorder
+	lastReportMu sync.Mutex
+
+	// Single-instance lock; later launches are handed to handleSecondLaunch (nil if unavailable)
+	instance *singleinstance.Lock
+
+	// Auto-update (see app_update.go)
+	updater         *updater.Updater
+	availableUpdate *updater.Release // Found by the last CheckForUpdate
+	relaunchPath    string           // Installed update to start once shut down
+	updateMu        sync.Mutex       // Guards the update fields
+
+	// Independent map sessions for split-view comparison (see app_sessions.go)
+	mapSessions map[string]*MapSession // Map of session ID -> session state
+	sessionsMu  sync.Mutex             // Mutex for map sessions
+
 	// Rate limit handling
 	rateLimitHandler *ratelimit.Handler // Rate limit detection and retry
 
 	// Video export manager
 	videoManager *video.Manager // Handles timelapse video export
+
+	// Background cache warming for date slider scrubbing (see app_prefetch.go)
+	cancelPrefetch context.CancelFunc // Stops the running prefetch (nil if none)
+	prefetchMu     sync.Mutex         // Guards cancelPrefetch
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	// Load user settings
 	settings, err := config.LoadSettings()
+	settingsLoadError := ""
 	if err != nil {
 		log.Printf("Failed to load settings, using defaults: %v", err)
+		settingsLoadError = err.Error()
+		// Keep the unreadable file for the user to fix instead of overwriting it on the next save
+		if aside, asideErr := config.SetAsideSettingsFile(); asideErr == nil {
+			log.Printf("Unreadable settings moved to %s", aside)
+			settingsLoadError += fmt.Sprintf(" (file kept as %s)", aside)
+		}
 		settings = config.DefaultSettings()
 	}
 	log.Printf("Settings loaded from: %s", config.GetSettingsPath())
@@ -215,11 +285,32 @@ func NewApp() *App {
 		log.Printf("Failed to initialize tile cache: %v", err)
 		tileCache = nil // Continue without cache
 	} else {
+		tileCache.SetMemoryLimit(settings.CacheMemoryMB)
 		entries, sizeBytes, maxBytes := tileCache.Stats()
 		log.Printf("Tile cache initialized at %s (%d tiles, %.2f MB / %.2f MB, TTL %d days)",
 			cachePath, entries, float64(sizeBytes)/1024/1024, float64(maxBytes)/1024/1024, settings.CacheTTLDays)
 	}
 
+	// Initialize learned-epoch cache (stored alongside tile cache index)
+	epochCachePath := filepath.Join(cachePath, "epoch_cache.json")
+	epochCache, err := googleearth.NewEpochCache(epochCachePath)
+	if err != nil {
+		log.Printf("Failed to initialize epoch cache: %v", err)
+		epochCache = nil // Continue without learned epochs
+	} else {
+		log.Printf("Epoch cache initialized at %s (%d learned regions)", epochCachePath, epochCache.Len())
+	}
+
+	// Initialize quadtree packet cache (shared by the tile server and downloaders via geClient)
+	packetCachePath := filepath.Join(cachePath, "packets")
+	packetCache, err := googleearth.NewPacketCache(packetCachePath, googleearth.DefaultPacketCacheTTL)
+	if err != nil {
+		log.Printf("Failed to initialize packet cache: %v", err)
+		packetCache = nil // Continue fetching every packet
+	} else {
+		log.Printf("Packet cache initialized at %s", packetCachePath)
+	}
+
 	// Initialize rate limit handler
 	rateLimitHandler := ratelimit.NewHandler(nil) // Use default retry strategy
 	rateLimitHandler.SetAutoRetry(settings.AutoRetryOnRateLimit)
@@ -228,11 +319,27 @@ func NewApp() *App {
 	// Initialize unified downloader (pass nil for now, will update cache calls separately)
 	downloader := imagery.NewTileDownloader(downloads.DefaultWorkers, nil)
 
+	// Apply proxy settings to all outbound HTTP transports
+	if err := netproxy.Configure(settings.ProxyURL, settings.ProxyUsername, settings.ProxyPassword, settings.ProxyBypass); err != nil {
+		log.Printf("Invalid proxy settings, using system proxy: %v", err)
+	} else if u, err := netproxy.Parse(settings.ProxyURL); err == nil {
+		log.Printf("Using proxy %s", u.Redacted())
+	}
+	if err := netproxy.ConfigureTLS(settings.CACertFile, settings.TLSInsecureSkipVerify); err != nil {
+		log.Printf("Failed to load CA certificate file, using system roots: %v", err)
+	} else if settings.CACertFile != "" {
+		log.Printf("Trusting additional CA certificates from %s", settings.CACertFile)
+	}
+	if settings.TLSInsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is DISABLED for all imagery requests")
+	}
+
 	// Initialize PostHog
 	var phClient posthog.Client
 	if PostHogKey != "" {
 		phConfig := posthog.Config{
-			Endpoint: PostHogHost,
+			Endpoint:  PostHogHost,
+			Transport: netproxy.NewTransport(),
 		}
 		client, err := posthog.NewWithConfig(PostHogKey, phConfig)
 		if err != nil {
@@ -257,14 +364,24 @@ func NewApp() *App {
 		geClient:          googleearth.NewClient(),
 		esriClient:        esriClientInstance,
 		tileCache:         tileCache,
+		epochCache:        epochCache,
+		packetCache:       packetCache,
 		downloader:        downloader,
 		downloadPath:      settings.DownloadPath,
 		settings:          settings,
+		settingsLoadError: settingsLoadError,
 		phClient:          phClient,
 		taskQueue:         taskQueue,
 		lastOpenedFolders: make(map[string]time.Time),
+		mapSessions:       make(map[string]*MapSession),
+		operations:        make(map[string]*operationState),
 		rateLimitHandler:  rateLimitHandler,
 	}
+	if err := config.ValidateProviderHeaders(settings.ProviderHeaders); err != nil {
+		log.Printf("Ignoring invalid provider header overrides: %v", err)
+	} else {
+		app.applyProviderHeaders(settings)
+	}
 
 	// Initialize Esri downloader with app callbacks
 	app.esriDownloader = esri.NewDownloader(
@@ -277,6 +394,44 @@ func NewApp() *App {
 		app.TrackEvent,
 		downloads.DefaultWorkers,
 	)
+	app.esriDownloader.SetMaxGeoTIFFDimension(settings.MaxGeoTIFFDimension)
+	app.esriDownloader.SetBuildOverviews(settings.GeoTIFFOverviews)
+	app.esriDownloader.SetSavePNGCopies(settings.SavePNGSidecars)
+	app.esriDownloader.SetSampleGrid(settings.EsriSampleGrid)
+	tileOutput := downloads.TileOutput{Format: settings.TileFormat, Quality: settings.TileJPEGQuality}
+	app.esriDownloader.SetTileOutput(tileOutput)
+
+	if packetCache != nil {
+		app.geClient.SetPacketCache(packetCache)
+	}
+
+	// Initialize custom tile sources and their downloader
+	app.customClient = customsource.NewClient()
+	app.customClient.SetSources(settings.CustomSources)
+	app.customClient.SetAPIKeys(settings.MapboxAccessToken, settings.MapTilerAPIKey)
+	naipSource := naip.Source()
+	app.customClient.SetBuiltin(common.ProviderNAIP, &naipSource)
+	app.naipClient = naip.NewClient()
+
+	// Register every source behind the common provider interface
+	app.providers = providers.NewRegistry(app.customClient)
+	app.providers.Register(providers.NewEsriProvider(esriClientInstance, app.esriDownloader))
+	app.providers.Register(providers.NewGoogleEarthProvider(app.geClient))
+	app.providers.Register(providers.NewNAIPProvider(app.customClient, app.naipClient))
+
+	app.customDownloader = customDownloader.NewDownloader(
+		app.providers,
+		tileCache,
+		settings.DownloadPath,
+		app.emitDownloadProgressFromDownloads,
+		app.emitLog,
+		app.TrackEvent,
+		downloads.DefaultWorkers,
+	)
+	app.customDownloader.SetMaxGeoTIFFDimension(settings.MaxGeoTIFFDimension)
+	app.customDownloader.SetBuildOverviews(settings.GeoTIFFOverviews)
+	app.customDownloader.SetSavePNGCopies(settings.SavePNGSidecars)
+	app.customDownloader.SetTileOutput(tileOutput)
 
 	// Set up rate limit callbacks (will be called when rate limits are detected)
 	rateLimitHandler.SetOnRateLimit(func(event ratelimit.RateLimitEvent) {
@@ -296,15 +451,7 @@ func NewApp() *App {
 	app.videoManager = video.NewManager(video.Config{
 		DownloadPath: settings.DownloadPath,
 		DateFontData: dateFontData,
-		ProgressCallback: func(current, total, percent int, status string) {
-			// Convert video progress to download progress format
-			app.emitDownloadProgress(DownloadProgress{
-				Downloaded: current,
-				Total:      total,
-				Percent:    percent,
-				Status:     status,
-			})
-		},
+		ProgressCallback: app.emitDownloadProgressFromDownloads,
 		LogCallback: app.emitLog,
 		ImageLoader: app.loadGeoTIFFImage,
 		LogoLoader:  app.loadLogoImage,
@@ -326,6 +473,25 @@ func NewApp() *App {
 			}
 		},
 	})
+	if ffmpegArgs, err := video.SplitArgs(settings.FFmpegExtraArgs); err != nil {
+		log.Printf("Ignoring extra FFmpeg arguments: %v", err)
+	} else {
+		app.videoManager.SetFFmpegOptions(time.Duration(settings.FFmpegTimeoutMinutes)*time.Minute, ffmpegArgs)
+	}
+	if layout, err := naming.OverlayDateLayout(settings.OverlayDateFormat); err != nil {
+		log.Printf("Ignoring overlay date format: %v", err)
+	} else {
+		app.videoManager.SetOverlayDateLayout(layout)
+	}
+	app.videoManager.SetOverlayFallbackFonts(settings.OverlayFallbackFonts)
+	app.videoManager.SetUnitSystem(settings.UnitSystem)
+	naming.SetFilenameDateFormat(settings.FilenameDateFormat)
+	if err := app.taskQueue.SetBulkWindow(taskqueue.Window{Start: settings.BulkWindowStart, End: settings.BulkWindowEnd}); err != nil {
+		log.Printf("Ignoring bulk task window: %v", err)
+	}
+
+	// Recovered panics are written to crash reports (and optionally reported)
+	app.configureCrashReporting(settings)
 
 	return app
 }
@@ -333,6 +499,10 @@ func NewApp() *App {
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.downloadsCtx, a.cancelDownloads = context.WithCancel(ctx)
+	if a.instance != nil {
+		a.instance.OnLaunch(a.handleSecondLaunch)
+	}
 
 	// Create download directory if it doesn't exist
 	os.MkdirAll(a.downloadPath, 0755)
@@ -354,6 +524,9 @@ func (a *App) startup(ctx context.Context) {
 		}
 	}()
 
+	// Check folders, FFmpeg and provider reachability in background, logging what fails
+	go a.logSelfTest(ctx)
+
 	// Load Esri layers for tile server caching
 	esriLayers, err := a.esriClient.GetLayers()
 	if err != nil {
@@ -363,6 +536,21 @@ func (a *App) startup(ctx context.Context) {
 
 	// Initialize and start local tile server
 	a.tileServer = tileserver.NewServer(ctx, a.geClient, a.esriClient, esriLayers, a.tileCache, a.devMode)
+	if a.epochCache != nil {
+		a.tileServer.SetEpochCache(a.epochCache)
+	}
+	a.tileServer.SetProviderRegistry(a.providers)
+	a.tileServer.SetWebPPreview(a.settings.PreviewWebP, a.settings.PreviewWebPQuality)
+	if resampling, err := googleearth.ParseResampling(a.settings.ReprojectionQuality); err == nil {
+		a.tileServer.SetResampling(resampling)
+	}
+	tileOutput := downloads.TileOutput{Format: a.settings.TileFormat, Quality: a.settings.TileJPEGQuality}
+	a.tileServer.SetFallbackTileOutput(tileOutput)
+	a.tileServer.SetMetricsEndpoint(a.settings.MetricsEndpoint)
+	a.tileServer.SetAdvancedEpochs(a.settings.AdvancedEpochs)
+	if err := a.tileServer.SetListenAddress(a.settings.TileServerAddress); err != nil {
+		log.Printf("Ignoring tile server address: %v", err)
+	}
 	go func() {
 		if err := a.tileServer.Start(); err != nil {
 			wailsRuntime.LogError(ctx, fmt.Sprintf("Failed to start tile server: %v", err))
@@ -379,12 +567,17 @@ func (a *App) startup(ctx context.Context) {
 		RateLimitHandler:  a.rateLimitHandler,
 		TrackEventCallback: a.TrackEvent,
 		MaxWorkers:        downloads.DefaultWorkers,
+		MaxGeoTIFFDimension: a.settings.MaxGeoTIFFDimension,
+		BuildOverviews:      a.settings.GeoTIFFOverviews,
+		SavePNGCopies:       a.settings.SavePNGSidecars,
+		NativeCRS:           a.settings.GENativeCRS,
 		TileServer:        a.tileServer,
 	})
 	if err != nil {
 		wailsRuntime.LogError(ctx, fmt.Sprintf("Failed to initialize Google Earth downloader: %v", err))
 	} else {
 		a.geDownloader = geDownloaderInstance
+		a.geDownloader.SetTileOutput(tileOutput)
 		wailsRuntime.LogInfo(ctx, "Google Earth downloader initialized")
 	}
 
@@ -431,6 +624,39 @@ func (a *App) startup(ctx context.Context) {
 		},
 	)
 
+	// Stream per-task log lines to the UI
+	a.taskQueue.TaskLog().SetLineCallback(func(taskID, line string) {
+		wailsRuntime.EventsEmit(ctx, "task-log", map[string]interface{}{
+			"taskId": taskID,
+			"line":   line,
+		})
+	})
+
+	// Tasks left running by a crash are marked interrupted on load; resume them or ask the user
+	if interrupted := a.taskQueue.InterruptedTasks(); len(interrupted) > 0 {
+		if a.settings.AutoResumeTasks {
+			resumed := a.taskQueue.ResumeInterruptedTasks()
+			log.Printf("[TaskQueue] Auto-resuming %d interrupted task(s)", resumed)
+			if err := a.taskQueue.StartQueue(); err != nil {
+				log.Printf("[TaskQueue] Failed to start queue: %v", err)
+			}
+		} else {
+			wailsRuntime.EventsEmit(ctx, "tasks-interrupted", interrupted)
+		}
+	}
+
+	// Apply retention rules to old exports and the tile cache in the background
+	a.startJanitor(ctx)
+
+	// Queue exports for AOI files dropped into the watch folder
+	a.startWatchFolder(ctx)
+
+	// Check for a newer release in the background
+	a.initUpdater()
+
+	// Queue exports from a deep link the app was launched with
+	a.handleLaunchArgs(os.Args[1:])
+
 	// Track app start
 	a.TrackEvent("app_started", map[string]interface{}{
 		"version": a.GetAppVersion(),
@@ -482,11 +708,62 @@ func (a *App) GetTileInfo(bbox BoundingBox, zoom int) TileInfo {
 	resolution := googleearth.ResolutionAtZoom(zoom, centerLat)
 
 	return TileInfo{
-		TileCount:  tileCount,
-		ZoomLevel:  zoom,
-		Resolution: resolution,
-		EstSizeMB:  estSizeMB,
+		TileCount:       tileCount,
+		ZoomLevel:       zoom,
+		Resolution:      resolution,
+		EstSizeMB:       estSizeMB,
+		ResolutionLabel: units.FormatResolution(resolution, a.unitSystem()),
+	}
+}
+
+// SuggestZoomForResolution picks a zoom level per source for a target ground resolution
+// Pass metersPerPixel > 0 to target a resolution directly, or 0 with outputWidth/outputHeight
+// to derive the resolution from the desired output dimensions
+func (a *App) SuggestZoomForResolution(bbox BoundingBox, metersPerPixel float64, outputWidth, outputHeight int) ([]ZoomSuggestion, error) {
+	target := metersPerPixel
+	if target <= 0 {
+		var err error
+		target, err = downloads.ResolutionForOutputSize(bbox.toDownloadsBBox(), outputWidth, outputHeight)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive resolution from output size: %w", err)
+		}
+	}
+
+	centerLat := (bbox.South + bbox.North) / 2
+	sources := a.providers.All()
+	unitSystem := a.unitSystem()
+
+	suggestions := make([]ZoomSuggestion, 0, len(sources))
+	for _, source := range sources {
+		minZoom, maxZoom := source.ZoomRange()
+		zoom, err := downloads.ZoomForResolutionInRange(target, centerLat, minZoom, maxZoom)
+		if err != nil {
+			return nil, err
+		}
+
+		resolution := googleearth.ResolutionAtZoom(zoom, centerLat)
+
+		var tileCount int
+		if source.TileScheme() == providers.SchemeGoogleEarth {
+			tiles, _ := googleearth.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+			tileCount = len(tiles)
+		} else {
+			tiles, _ := esriClient.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+			tileCount = len(tiles)
+		}
+
+		suggestions = append(suggestions, ZoomSuggestion{
+			Source:           source.ID(),
+			Zoom:             zoom,
+			Resolution:       resolution,
+			TargetResolution: target,
+			TileCount:        tileCount,
+			Clamped:          resolution > target,
+			ResolutionLabel:  units.FormatResolution(resolution, unitSystem),
+		})
 	}
+
+	return suggestions, nil
 }
 
 // GetEsriWaybackDatesForArea returns available Esri Wayback dates for a specific area
@@ -509,36 +786,28 @@ func (a *App) GetEsriWaybackDatesForArea(bbox BoundingBox, zoom int) ([]Availabl
 }
 
 // GetAvailableDatesForArea returns available imagery dates for a specific area
+// Samples a grid of tiles across the bbox so changes anywhere in the AOI are found
 // Returns LayerDate (not CaptureDate) since download functions need the layer date to find tiles
 func (a *App) GetAvailableDatesForArea(bbox BoundingBox, zoom int) ([]AvailableDate, error) {
-	// Get center tile
-	centerLat := (bbox.South + bbox.North) / 2
-	centerLon := (bbox.West + bbox.East) / 2
+	return a.listProviderDates(common.ProviderEsriWayback, bbox, zoom)
+}
 
-	tile, err := esriClient.GetTileForWgs84(centerLat, centerLon, zoom)
+// listProviderDates lists a registered provider's dates for an area
+func (a *App) listProviderDates(provider string, bbox BoundingBox, zoom int) ([]AvailableDate, error) {
+	p, err := a.providers.Get(provider)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get available dates from Esri
-	datedTiles, err := a.esriClient.GetAvailableDates(tile)
+	infos, err := p.ListDates(bbox.toDownloadsBBox(), zoom)
 	if err != nil {
 		return nil, err
 	}
 
-	seen := make(map[string]bool)
-	var dates []AvailableDate
-
-	for _, dt := range datedTiles {
-		// Use LayerDate (Esri Wayback layer date) not CaptureDate
-		// LayerDate is needed by download functions to find the correct layer
-		dateStr := dt.LayerDate.Format("2006-01-02")
-		if !seen[dateStr] {
-			seen[dateStr] = true
-			dates = append(dates, AvailableDate{
-				Date:   dateStr,
-				Source: string(SourceEsriWayback),
-			})
+	dates := make([]AvailableDate, len(infos))
+	for i, info := range infos {
+		dates[i] = AvailableDate{
+			Date:   info.Date,
+			Source: provider,
 		}
 	}
 
@@ -569,7 +838,7 @@ func (a *App) GetDownloadPath() string {
 func (a *App) SelectDownloadFolder() (string, error) {
 	path, err := wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
 		Title:            "Select Download Folder",
-		DefaultDirectory: a.downloadPath,
+		DefaultDirectory: a.GetDownloadPath(),
 	})
 	if err != nil {
 		return "", err
@@ -582,34 +851,31 @@ func (a *App) SelectDownloadFolder() (string, error) {
 	return path, nil
 }
 
+// SelectAudioFile opens a file picker for a timelapse music track (empty when cancelled)
+func (a *App) SelectAudioFile() (string, error) {
+	return wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Select Audio Track",
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "Audio (*.mp3;*.aac;*.m4a;*.wav)", Pattern: "*.mp3;*.aac;*.m4a;*.wav"},
+		},
+	})
+}
+
 // emitLog sends a log message to the frontend (only in dev mode)
 func (a *App) emitLog(message string) {
+	// Keep user-facing messages in the running task's log
+	if a.taskQueue != nil && a.taskQueue.TaskLog().Active() {
+		log.Printf("[Task] %s", message)
+	}
 	if a.devMode {
 		wailsRuntime.EventsEmit(a.ctx, "log", message)
 	}
 }
 
-// emitDownloadProgress emits download progress and forwards to task queue if active
+// emitDownloadProgress emits download progress to the frontend.
+// Queue tasks receive their own progress through their download operation (see ExecuteExportTask).
 func (a *App) emitDownloadProgress(progress DownloadProgress) {
-	// Always emit the download-progress event for any listeners
 	wailsRuntime.EventsEmit(a.ctx, "download-progress", progress)
-
-	// If we're running in task queue context, also forward to task progress
-	if a.currentTaskID != "" && a.taskProgressChan != nil {
-		taskProgress := taskqueue.TaskProgress{
-			CurrentPhase:   progress.Status,
-			TotalDates:     progress.TotalDates,
-			CurrentDate:    progress.CurrentDate,
-			TilesTotal:     progress.Total,
-			TilesCompleted: progress.Downloaded,
-			Percent:        progress.Percent,
-		}
-		// Non-blocking send
-		select {
-		case a.taskProgressChan <- taskProgress:
-		default:
-		}
-	}
 }
 
 // emitDownloadProgressFromDownloads is a wrapper that converts downloads.DownloadProgress to app DownloadProgress
@@ -622,6 +888,7 @@ func (a *App) emitDownloadProgressFromDownloads(progress downloads.DownloadProgr
 		Status:      progress.Status,
 		CurrentDate: progress.CurrentDate,
 		TotalDates:  progress.TotalDates,
+		OperationID: progress.OperationID,
 	})
 }
 
@@ -641,112 +908,6 @@ func (a *App) findLayerForDate(date string) (*esriClient.Layer, error) {
 	return nil, fmt.Errorf("no layer found for date: %s", date)
 }
 
-// isBlankTile checks if a tile is blank/uniform (white, black, or single color)
-// This happens when imagery isn't available at the requested zoom level for older dates
-func isBlankTile(data []byte) bool {
-	if len(data) < 100 {
-		return true // Too small to be a real image
-	}
-
-	// Decode image to check pixel uniformity
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		log.Printf("[isBlankTile] Failed to decode image: %v", err)
-		return false // Can't decode, assume it's valid
-	}
-
-	bounds := img.Bounds()
-	if bounds.Dx() < 10 || bounds.Dy() < 10 {
-		return true // Too small
-	}
-
-	// Sample many pixels across the image
-	sampleCount := 0
-	whiteCount := 0
-	blackCount := 0
-	totalR, totalG, totalB := uint64(0), uint64(0), uint64(0)
-
-	// Sample a grid of points
-	stepX := bounds.Dx() / 8
-	stepY := bounds.Dy() / 8
-	if stepX < 1 {
-		stepX = 1
-	}
-	if stepY < 1 {
-		stepY = 1
-	}
-
-	for y := bounds.Min.Y + stepY; y < bounds.Max.Y-stepY; y += stepY {
-		for x := bounds.Min.X + stepX; x < bounds.Max.X-stepX; x += stepX {
-			r, g, b, _ := img.At(x, y).RGBA()
-			totalR += uint64(r)
-			totalG += uint64(g)
-			totalB += uint64(b)
-			sampleCount++
-
-			// Check for white (RGBA values are 0-65535)
-			if r > 63000 && g > 63000 && b > 63000 {
-				whiteCount++
-			}
-			// Check for black
-			if r < 2500 && g < 2500 && b < 2500 {
-				blackCount++
-			}
-		}
-	}
-
-	if sampleCount == 0 {
-		return false
-	}
-
-	// If more than 90% of samples are white or black, it's blank
-	whitePercent := (whiteCount * 100) / sampleCount
-	blackPercent := (blackCount * 100) / sampleCount
-
-	if whitePercent > 90 {
-		log.Printf("[isBlankTile] Detected blank tile: %d%% white pixels", whitePercent)
-		return true
-	}
-	if blackPercent > 90 {
-		log.Printf("[isBlankTile] Detected blank tile: %d%% black pixels", blackPercent)
-		return true
-	}
-
-	// Also check for very low color variance (uniform gray/beige)
-	avgR := totalR / uint64(sampleCount)
-	avgG := totalG / uint64(sampleCount)
-	avgB := totalB / uint64(sampleCount)
-
-	// Calculate variance
-	varR, varG, varB := uint64(0), uint64(0), uint64(0)
-	for y := bounds.Min.Y + stepY; y < bounds.Max.Y-stepY; y += stepY {
-		for x := bounds.Min.X + stepX; x < bounds.Max.X-stepX; x += stepX {
-			r, g, b, _ := img.At(x, y).RGBA()
-			varR += absDiff64(uint64(r), avgR) * absDiff64(uint64(r), avgR)
-			varG += absDiff64(uint64(g), avgG) * absDiff64(uint64(g), avgG)
-			varB += absDiff64(uint64(b), avgB) * absDiff64(uint64(b), avgB)
-		}
-	}
-
-	// Very low variance indicates uniform/blank image
-	avgVariance := (varR + varG + varB) / (3 * uint64(sampleCount))
-	// Threshold: variance of ~1000^2 = 1000000 is considered "uniform"
-	if avgVariance < 2000000 {
-		log.Printf("[isBlankTile] Detected blank tile: low variance %d, avg RGB: %d,%d,%d", avgVariance, avgR/257, avgG/257, avgB/257)
-		return true
-	}
-
-	return false
-}
-
-// absDiff64 returns absolute difference between two uint64 values
-func absDiff64(a, b uint64) uint64 {
-	if a > b {
-		return a - b
-	}
-	return b - a
-}
-
 // tileResult holds the result of a tile download
 type tileResult struct {
 	tile *esriClient.EsriTile
@@ -756,22 +917,23 @@ type tileResult struct {
 
 // DownloadEsriImagery downloads Esri Wayback imagery for a bounding box as georeferenced image
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-func (a *App) DownloadEsriImagery(bbox BoundingBox, zoom int, date string, format string) error {
-	// Set up callbacks for the downloader
-	a.esriDownloader.SetRangeDownloadState(a.inRangeDownload, a.currentDateIndex, a.totalDatesInRange)
-
+func (a *App) DownloadEsriImagery(bbox BoundingBox, zoom int, date string, format string) (err error) {
+	defer crash.Recover("DownloadEsriImagery", &err)
+	ctx, done, err := a.beginDownload("DownloadEsriImagery")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	// Use the esri downloader (convert bbox to downloads.BoundingBox)
-	err := a.esriDownloader.DownloadImagery(a.ctx, bbox.toDownloadsBBox(), zoom, date, format)
+	err = a.esriDownloader.DownloadImagery(ctx, bbox.toDownloadsBBox(), zoom, date, format)
 	if err != nil {
 		return err
 	}
 
-	// Auto-open download folder (only if not running in task queue)
-	if a.currentTaskID == "" {
-		a.emitLog("Opening download folder...")
-		if err := a.OpenDownloadFolder(); err != nil {
-			log.Printf("Failed to open download folder: %v", err)
-		}
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
 	}
 
 	return nil
@@ -873,23 +1035,27 @@ func (a *App) saveAsGeoTIFFWithMetadata(img image.Image, outputPath string, orig
 
 // DownloadGoogleEarthImagery downloads Google Earth imagery for a bounding box
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-func (a *App) DownloadGoogleEarthImagery(bbox BoundingBox, zoom int, format string) error {
+func (a *App) DownloadGoogleEarthImagery(bbox BoundingBox, zoom int, format string) (err error) {
+	defer crash.Recover("DownloadGoogleEarthImagery", &err)
+	ctx, done, err := a.beginDownload("DownloadGoogleEarthImagery")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	if a.geDownloader == nil {
 		return fmt.Errorf("Google Earth downloader not initialized")
 	}
 
 	// Use the Google Earth downloader (convert bbox to downloads.BoundingBox)
-	err := a.geDownloader.DownloadImagery(bbox.toDownloadsBBox(), zoom, format)
+	err = a.geDownloader.DownloadImagery(ctx, bbox.toDownloadsBBox(), zoom, format)
 	if err != nil {
 		return err
 	}
 
-	// Auto-open download folder (only if not running in task queue)
-	if a.currentTaskID == "" {
-		a.emitLog("Opening download folder...")
-		if err := a.OpenDownloadFolder(); err != nil {
-			log.Printf("Failed to open download folder: %v", err)
-		}
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
 	}
 
 	return nil
@@ -897,20 +1063,24 @@ func (a *App) DownloadGoogleEarthImagery(bbox BoundingBox, zoom int, format stri
 
 // DownloadEsriImageryRange downloads Esri Wayback imagery for multiple dates (bulk download)
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-// This function deduplicates by checking the center tile - dates with identical imagery are skipped
-func (a *App) DownloadEsriImageryRange(bbox BoundingBox, zoom int, dates []string, format string) error {
+// This function deduplicates by hashing sample tiles across the AOI - dates with identical imagery are skipped
+func (a *App) DownloadEsriImageryRange(bbox BoundingBox, zoom int, dates []string, format string) (err error) {
+	defer crash.Recover("DownloadEsriImageryRange", &err)
+	ctx, done, err := a.beginDownload("DownloadEsriImageryRange")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	// Use the esri downloader (convert bbox to downloads.BoundingBox)
-	err := a.esriDownloader.DownloadImageryRange(a.ctx, bbox.toDownloadsBBox(), zoom, dates, format)
+	err = a.esriDownloader.DownloadImageryRange(ctx, bbox.toDownloadsBBox(), zoom, dates, format)
 	if err != nil {
 		return err
 	}
 
-	// Auto-open download folder (only if not running in task queue)
-	if a.currentTaskID == "" {
-		a.emitLog("Opening download folder...")
-		if err := a.OpenDownloadFolder(); err != nil {
-			log.Printf("Failed to open download folder: %v", err)
-		}
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
 	}
 
 	return nil
@@ -918,7 +1088,7 @@ func (a *App) DownloadEsriImageryRange(bbox BoundingBox, zoom int, dates []strin
 
 // OpenDownloadFolder opens the download folder in the system file manager
 func (a *App) OpenDownloadFolder() error {
-	return a.OpenFolder(a.downloadPath)
+	return a.OpenFolder(a.GetDownloadPath())
 }
 
 // OpenFolder opens a specific folder in the OS file explorer
@@ -968,6 +1138,24 @@ func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's show time!", name)
 }
 
+// GetTileServerToken returns the access token the tile server requires from clients on other
+// machines (when the tile server address setting serves them), as "Authorization: Bearer
+// <token>" or a "token" query parameter
+func (a *App) GetTileServerToken() (string, error) {
+	if a.tileServer == nil {
+		return "", fmt.Errorf("tile server not started")
+	}
+	return a.tileServer.Token(), nil
+}
+
+// RegenerateTileServerToken replaces the tile server access token, revoking the old one
+func (a *App) RegenerateTileServerToken() (string, error) {
+	if a.tileServer == nil {
+		return "", fmt.Errorf("tile server not started")
+	}
+	return a.tileServer.RegenerateToken()
+}
+
 // GetEsriTileURL returns the tile URL template for a given date (for map preview)
 // Routes through backend tile server for caching, matching Google Earth pattern
 func (a *App) GetEsriTileURL(date string) (string, error) {
@@ -1012,155 +1200,41 @@ func (a *App) GetGoogleEarthTileURL(date string) (string, error) {
 // GetGoogleEarthDatesForArea returns available historical imagery dates for a specific area
 // This samples multiple tiles across the viewport to ensure returned dates are available
 // at the current zoom level and location - critical for zoom levels 17-19 where date
-// availability varies significantly between tiles
+// availability varies significantly between tiles. The sample grid grows with the area.
 func (a *App) GetGoogleEarthDatesForArea(bbox BoundingBox, zoom int) ([]GEAvailableDate, error) {
-	a.emitLog(fmt.Sprintf("Fetching Google Earth historical dates for zoom %d...", zoom))
-
-	// IMPORTANT: Sample at zoom 16 to get stable, reliable epoch values
-	// At zoom 17-19, the protobuf reports newer epochs (like 359) that don't have actual tiles
-	// Zoom 16 provides epochs (like 358) that work across ALL zoom levels including 17-19
-	// This is critical for 2025+ dates where high zoom epochs in protobuf are incorrect
-	sampleZoom := 16
-	if zoom < 16 {
-		sampleZoom = zoom // Use requested zoom if it's lower than 16
-	}
-	log.Printf("[GEDates] Sampling at zoom %d for epoch stability (requested zoom: %d)", sampleZoom, zoom)
-
-	// Sample multiple tiles across the viewport for better date coverage
-	// At high zoom levels (17-19), different tiles have different available dates
-	samplePoints := []struct{ lat, lon float64 }{
-		{(bbox.South + bbox.North) / 2, (bbox.West + bbox.East) / 2},                        // Center
-		{bbox.North - (bbox.North-bbox.South)*0.25, bbox.West + (bbox.East-bbox.West)*0.25}, // NW quadrant
-		{bbox.North - (bbox.North-bbox.South)*0.25, bbox.East - (bbox.East-bbox.West)*0.25}, // NE quadrant
-		{bbox.South + (bbox.North-bbox.South)*0.25, bbox.West + (bbox.East-bbox.West)*0.25}, // SW quadrant
-		{bbox.South + (bbox.North-bbox.South)*0.25, bbox.East - (bbox.East-bbox.West)*0.25}, // SE quadrant
-	}
-
-	// Collect dates from all sample tiles
-	allDatesMap := make(map[string]map[string]GEAvailableDate) // hexDate -> tileID -> date info
-	tileSampleCount := 0
-
-	for i, point := range samplePoints {
-		tile, err := googleearth.GetTileForCoord(point.lat, point.lon, sampleZoom)
-		if err != nil {
-			log.Printf("[GEDates] Failed to get tile %d: %v", i, err)
-			continue
-		}
-
-		log.Printf("[GEDates] Sampling tile %d/%d: %s at zoom %d", i+1, len(samplePoints), tile.Path, sampleZoom)
-
-		datedTiles, err := a.geClient.GetAvailableDates(tile)
-		if err != nil {
-			log.Printf("[GEDates] Failed to get dates for tile %s: %v", tile.Path, err)
-			continue
-		}
+	return a.scanGoogleEarthDates(bbox, zoom, providers.DateScanOptions{})
+}
 
-		tileSampleCount++
-		tileID := tile.Path
+// GetGoogleEarthDatesForAreaThorough samples every stride-th tile of the area (every tile for
+// stride 0 or 1) and returns every date found with the percentage of the area it covers,
+// including dates that only cover part of a large area
+func (a *App) GetGoogleEarthDatesForAreaThorough(bbox BoundingBox, zoom int, stride int) ([]GEAvailableDate, error) {
+	return a.scanGoogleEarthDates(bbox, zoom, providers.DateScanOptions{Thorough: true, Stride: stride})
+}
 
-		// Add this tile's dates to the map
-		for _, dt := range datedTiles {
-			if allDatesMap[dt.HexDate] == nil {
-				allDatesMap[dt.HexDate] = make(map[string]GEAvailableDate)
-			}
-			allDatesMap[dt.HexDate][tileID] = GEAvailableDate{
-				Date:    dt.Date.Format("2006-01-02"),
-				Epoch:   dt.Epoch,
-				HexDate: dt.HexDate,
-			}
-		}
-	}
+// scanGoogleEarthDates lists the Google Earth dates of an area with the given sampling
+func (a *App) scanGoogleEarthDates(bbox BoundingBox, zoom int, opts providers.DateScanOptions) ([]GEAvailableDate, error) {
+	a.emitLog(fmt.Sprintf("Fetching Google Earth historical dates for zoom %d...", zoom))
 
-	if tileSampleCount == 0 {
-		return nil, fmt.Errorf("failed to sample any tiles in the area")
+	provider, err := a.providers.Get(common.ProviderGoogleEarth)
+	if err != nil {
+		return nil, err
 	}
-
-	// Filter to dates that appear in at least 60% of sampled tiles
-	// This ensures good coverage while allowing for some tile variation
-	minTileCount := int(float64(tileSampleCount) * 0.6)
-	if minTileCount < 1 {
-		minTileCount = 1
+	geProvider, ok := provider.(*providers.GoogleEarthProvider)
+	if !ok {
+		return nil, fmt.Errorf("Google Earth provider not available")
 	}
-
-	var dates []GEAvailableDate
-	seen := make(map[string]bool)
-
-	for hexDate, tilesWithDate := range allDatesMap {
-		if len(tilesWithDate) >= minTileCount {
-			// Find the most common epoch for this date across all tiles
-			// Different tiles may report different epochs for the same date
-			epochCounts := make(map[int]int)
-			var sampleDateInfo GEAvailableDate
-
-			for _, dateInfo := range tilesWithDate {
-				epochCounts[dateInfo.Epoch]++
-				sampleDateInfo = dateInfo // Keep one for the date string
-			}
-
-			// Use the most frequently occurring epoch
-			bestEpoch := sampleDateInfo.Epoch
-			maxCount := 0
-			for epoch, count := range epochCounts {
-				if count > maxCount {
-					maxCount = count
-					bestEpoch = epoch
-				}
-			}
-
-			if !seen[sampleDateInfo.Date] {
-				seen[sampleDateInfo.Date] = true
-				dates = append(dates, GEAvailableDate{
-					Date:    sampleDateInfo.Date,
-					Epoch:   bestEpoch, // Use most common epoch
-					HexDate: hexDate,
-				})
-				log.Printf("[GEDates] Date %s (hex: %s, epoch: %d) available in %d/%d tiles (epoch used by %d tiles)",
-					sampleDateInfo.Date, hexDate, bestEpoch, len(tilesWithDate), tileSampleCount, maxCount)
-			}
-		}
+	infos, err := geProvider.ScanDates(bbox.toDownloadsBBox(), zoom, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(dates) == 0 {
-		a.emitLog("No common dates found across sampled tiles - showing all available dates")
-		// Fallback: show all dates if filtering is too strict
-		for hexDate, tilesWithDate := range allDatesMap {
-			// Find most common epoch even in fallback
-			epochCounts := make(map[int]int)
-			var sampleDateInfo GEAvailableDate
-
-			for _, dateInfo := range tilesWithDate {
-				epochCounts[dateInfo.Epoch]++
-				sampleDateInfo = dateInfo
-			}
-
-			bestEpoch := sampleDateInfo.Epoch
-			maxCount := 0
-			for epoch, count := range epochCounts {
-				if count > maxCount {
-					maxCount = count
-					bestEpoch = epoch
-				}
-			}
-
-			if !seen[sampleDateInfo.Date] {
-				seen[sampleDateInfo.Date] = true
-				dates = append(dates, GEAvailableDate{
-					Date:    sampleDateInfo.Date,
-					Epoch:   bestEpoch,
-					HexDate: hexDate,
-				})
-				log.Printf("[GEDates] Fallback: Date %s (hex: %s, epoch: %d) from %d tiles",
-					sampleDateInfo.Date, hexDate, bestEpoch, len(tilesWithDate))
-			}
-		}
+	dates := make([]GEAvailableDate, len(infos))
+	for i, info := range infos {
+		dates[i] = GEAvailableDate{Date: info.Date, Epoch: info.Epoch, HexDate: info.HexDate, Coverage: info.Coverage}
 	}
 
-	// Sort dates newest first so index 0 is the latest
-	sort.Slice(dates, func(i, j int) bool {
-		return dates[i].Date > dates[j].Date
-	})
-
-	a.emitLog(fmt.Sprintf("Found %d dates available across viewport (sampled at zoom %d, requested zoom %d)", len(dates), sampleZoom, zoom))
+	a.emitLog(fmt.Sprintf("Found %d dates available across viewport", len(dates)))
 	return dates, nil
 }
 
@@ -1181,13 +1255,19 @@ func (a *App) GetGoogleEarthHistoricalTileURL(date string, hexDate string, epoch
 // DownloadGoogleEarthHistoricalImagery downloads historical Google Earth imagery for a bounding box
 // Note: epoch parameter kept for API compatibility but the correct epoch is looked up per-tile
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-func (a *App) DownloadGoogleEarthHistoricalImagery(bbox BoundingBox, zoom int, hexDate string, epoch int, dateStr string, format string) error {
+func (a *App) DownloadGoogleEarthHistoricalImagery(bbox BoundingBox, zoom int, hexDate string, epoch int, dateStr string, format string) (err error) {
+	defer crash.Recover("DownloadGoogleEarthHistoricalImagery", &err)
+	ctx, done, err := a.beginDownload("DownloadGoogleEarthHistoricalImagery")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	if a.geDownloader == nil {
 		return fmt.Errorf("Google Earth downloader not initialized")
 	}
 
 	// Use the Google Earth downloader (convert bbox to downloads.BoundingBox)
-	err := a.geDownloader.DownloadHistoricalImagery(bbox.toDownloadsBBox(), zoom, hexDate, epoch, dateStr, format)
+	err = a.geDownloader.DownloadHistoricalImagery(ctx, bbox.toDownloadsBBox(), zoom, hexDate, epoch, dateStr, format)
 	if err != nil {
 		return err
 	}
@@ -1201,6 +1281,33 @@ func (a *App) DownloadGoogleEarthHistoricalImagery(bbox BoundingBox, zoom int, h
 	return nil
 }
 
+// DownloadGoogleEarthTerrain downloads Google Earth terrain for a bounding box and saves it as a DEM GeoTIFF
+// crs: "EPSG:4326" (default) or "EPSG:3857". Returns the path of the saved DEM.
+func (a *App) DownloadGoogleEarthTerrain(bbox BoundingBox, zoom int, crs string) (path string, err error) {
+	defer crash.Recover("DownloadGoogleEarthTerrain", &err)
+	ctx, done, err := a.beginDownload("DownloadGoogleEarthTerrain")
+	if err != nil {
+		return "", err
+	}
+	defer done(&err)
+	if a.geDownloader == nil {
+		return "", fmt.Errorf("Google Earth downloader not initialized")
+	}
+
+	path, err = a.geDownloader.DownloadTerrain(ctx, bbox.toDownloadsBBox(), zoom, crs)
+	if err != nil {
+		return "", err
+	}
+
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
+	}
+
+	return path, nil
+}
+
 // VideoExportOptions contains options for timelapse video export
 type VideoExportOptions struct {
 	// Dimensions
@@ -1210,14 +1317,21 @@ type VideoExportOptions struct {
 	Presets []string `json:"presets,omitempty"` // Multiple presets for batch export
 
 	// Crop position (0.0-1.0, where 0.5 is center)
-	CropX float64 `json:"cropX"` // 0=left, 0.5=center, 1=right
-	CropY float64 `json:"cropY"` // 0=top, 0.5=center, 1=bottom
+	CropX    float64 `json:"cropX"`    // 0=left, 0.5=center, 1=right
+	CropY    float64 `json:"cropY"`    // 0=top, 0.5=center, 1=bottom
+	AutoCrop bool    `json:"autoCrop"` // Center the crop on the most-changed region (overrides CropX/CropY)
 
 	// Spotlight area (relative coordinates 0-1 in bbox)
 	SpotlightEnabled   bool    `json:"spotlightEnabled"`
 	SpotlightCenterLat float64 `json:"spotlightCenterLat"`
 	SpotlightCenterLon float64 `json:"spotlightCenterLon"`
 	SpotlightRadiusKm  float64 `json:"spotlightRadiusKm"`
+	SpotlightUnit      string  `json:"spotlightUnit,omitempty"` // Unit of the spotlight radii: "km" (default) or "mi"
+
+	// Spotlight shape and extra regions
+	SpotlightShape   string                  `json:"spotlightShape"`            // "rectangle" (default), "rounded", "circle", "ellipse"
+	SpotlightFeather int                     `json:"spotlightFeather"`          // Edge fade into the grayed area, in output pixels
+	ExtraSpotlights  []video.SpotlightRegion `json:"extraSpotlights,omitempty"` // Further regions highlighted in place
 
 	// Overlay
 	OverlayOpacity float64 `json:"overlayOpacity"` // 0.0 to 1.0
@@ -1225,7 +1339,12 @@ type VideoExportOptions struct {
 	// Date overlay
 	ShowDateOverlay bool    `json:"showDateOverlay"`
 	DateFontSize    float64 `json:"dateFontSize"`
-	DatePosition    string  `json:"datePosition"` // "top-left", "top-right", "bottom-left", "bottom-right"
+	DatePosition    string  `json:"datePosition"`              // "top-left", "top-right", "bottom-left", "bottom-right"
+	ShowTimelineBar bool    `json:"showTimelineBar"`           // Year counter and progress bar along the bottom
+	Title           string  `json:"title,omitempty"`           // Location title drawn at the top (any script)
+	Subtitles       bool    `json:"subtitles,omitempty"`       // Also write the dates as a .srt subtitle sidecar
+	ShowInfoOverlay bool    `json:"showInfoOverlay,omitempty"` // Center coordinates, resolution and source in a corner
+	InfoPosition    string  `json:"infoPosition,omitempty"`    // "top-left" (default), "top-right", "bottom-left", "bottom-right"
 
 	// Logo overlay
 	ShowLogo     bool   `json:"showLogo"`
@@ -1233,41 +1352,125 @@ type VideoExportOptions struct {
 
 	// Video settings
 	FrameDelay   float64 `json:"frameDelay"`   // Seconds between frames
-	OutputFormat string  `json:"outputFormat"` // "mp4", "gif"
+	OutputFormat string  `json:"outputFormat"` // "mp4", "gif", "apng", "webp", "images"
+	ImageFormat  string  `json:"imageFormat"`  // "png" or "jpeg" frames for the "images" format
 	Quality      int     `json:"quality"`      // 0-100
+	AudioPath    string  `json:"audioPath"`    // Music file muxed into MP4 exports (looped/trimmed, faded out)
+
+	// Per-frame timing (e.g. hold the most recent date longer)
+	FrameDurations map[string]float64 `json:"frameDurations,omitempty"` // Seconds per date (YYYY-MM-DD), overriding FrameDelay
+
+	// Duplicate frame removal
+	DedupeFrames    bool `json:"dedupeFrames"`              // Drop consecutive frames with near-identical imagery
+	DedupeThreshold *int `json:"dedupeThreshold,omitempty"` // Max perceptual hash distance (0-64) treated as identical (0 = exact only, unset = default)
+
+	// Frame registration
+	StabilizeFrames bool `json:"stabilizeFrames"` // Align frames to the first by phase correlation to remove jitter
+
+	// GIF size control
+	GIFScale     float64 `json:"gifScale"`     // Downscale GIF frames (0-1, 0 = full size)
+	GIFMaxSizeMB float64 `json:"gifMaxSizeMB"` // Shrink frames until the GIF fits (0 = no limit)
+}
+
+// toVideoDates converts dates to the video package's type
+func toVideoDates(dates []GEDateInfo) []video.DateInfo {
+	videoDates := make([]video.DateInfo, len(dates))
+	for i, d := range dates {
+		videoDates[i] = video.DateInfo{
+			Date:    d.Date,
+			HexDate: d.HexDate,
+			Epoch:   d.Epoch,
+			Source:  d.Source,
+		}
+	}
+	return videoDates
+}
+
+// toTimelapseOptions converts the options to the video package's type
+func (o VideoExportOptions) toTimelapseOptions() video.TimelapseOptions {
+	return video.TimelapseOptions{
+		Width:              o.Width,
+		Height:             o.Height,
+		Preset:             o.Preset,
+		Presets:            o.Presets,
+		CropX:              o.CropX,
+		CropY:              o.CropY,
+		AutoCrop:           o.AutoCrop,
+		SpotlightEnabled:   o.SpotlightEnabled,
+		SpotlightCenterLat: o.SpotlightCenterLat,
+		SpotlightCenterLon: o.SpotlightCenterLon,
+		SpotlightRadiusKm:  o.SpotlightRadiusKm,
+		SpotlightUnit:      o.SpotlightUnit,
+		SpotlightShape:     o.SpotlightShape,
+		SpotlightFeather:   o.SpotlightFeather,
+		ExtraSpotlights:    o.ExtraSpotlights,
+		OverlayOpacity:     o.OverlayOpacity,
+		ShowDateOverlay:    o.ShowDateOverlay,
+		ShowTimelineBar:    o.ShowTimelineBar,
+		Title:              o.Title,
+		Subtitles:          o.Subtitles,
+		ShowInfoOverlay:    o.ShowInfoOverlay,
+		InfoPosition:       o.InfoPosition,
+		DateFontSize:       o.DateFontSize,
+		DatePosition:       o.DatePosition,
+		ShowLogo:           o.ShowLogo,
+		LogoPosition:       o.LogoPosition,
+		FrameDelay:         o.FrameDelay,
+		FrameDurations:     o.FrameDurations,
+		OutputFormat:       o.OutputFormat,
+		Quality:            o.Quality,
+		AudioPath:          o.AudioPath,
+		ImageFormat:        o.ImageFormat,
+		DedupeFrames:       o.DedupeFrames,
+		DedupeThreshold:    o.DedupeThreshold,
+		StabilizeFrames:    o.StabilizeFrames,
+		GIFScale:           o.GIFScale,
+		GIFMaxSizeMB:       o.GIFMaxSizeMB,
+	}
 }
 
 // DownloadGoogleEarthHistoricalImageryRange downloads multiple historical Google Earth imagery dates
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-func (a *App) DownloadGoogleEarthHistoricalImageryRange(bbox BoundingBox, zoom int, dates []GEDateInfo, format string) error {
+func (a *App) DownloadGoogleEarthHistoricalImageryRange(bbox BoundingBox, zoom int, dates []GEDateInfo, format string) (err error) {
+	defer crash.Recover("DownloadGoogleEarthHistoricalImageryRange", &err)
+	ctx, done, err := a.beginDownload("DownloadGoogleEarthHistoricalImageryRange")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	if a.geDownloader == nil {
 		return fmt.Errorf("Google Earth downloader not initialized")
 	}
 
 	// Use the Google Earth downloader (convert bbox and dates to downloads types)
-	err := a.geDownloader.DownloadHistoricalImageryRange(bbox.toDownloadsBBox(), zoom, convertGEDateInfoSlice(dates), format, nil)
+	err = a.geDownloader.DownloadHistoricalImageryRange(ctx, bbox.toDownloadsBBox(), zoom, convertGEDateInfoSlice(dates), format)
 	if err != nil {
 		return err
 	}
 
-	// Auto-open download folder (only if not running in task queue)
-	if a.currentTaskID == "" {
-		a.emitLog("Opening download folder...")
-		if err := a.OpenDownloadFolder(); err != nil {
-			log.Printf("Failed to open download folder: %v", err)
-		}
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
 	}
 
 	return nil
 }
 
 // ExportTimelapseVideo exports a timelapse video from a range of downloaded imagery
-func (a *App) ExportTimelapseVideo(bbox BoundingBox, zoom int, dates []GEDateInfo, source string, videoOpts VideoExportOptions) error {
-	return a.exportTimelapseVideoInternal(bbox, zoom, dates, source, videoOpts, true)
+func (a *App) ExportTimelapseVideo(bbox BoundingBox, zoom int, dates []GEDateInfo, source string, videoOpts VideoExportOptions) (err error) {
+	defer crash.Recover("ExportTimelapseVideo", &err)
+	ctx, done, err := a.beginDownload("ExportTimelapseVideo")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+	return a.exportTimelapseVideoInternal(ctx, bbox, zoom, dates, source, videoOpts, true)
 }
 
-// exportTimelapseVideoInternal is the internal implementation with option to skip opening folder
-func (a *App) exportTimelapseVideoInternal(bbox BoundingBox, zoom int, dates []GEDateInfo, source string, videoOpts VideoExportOptions, openFolder bool) error {
+// exportTimelapseVideoInternal is the internal implementation with option to skip opening folder.
+// Frames are read from and the video written to the output directory of the operation in ctx.
+func (a *App) exportTimelapseVideoInternal(ctx context.Context, bbox BoundingBox, zoom int, dates []GEDateInfo, source string, videoOpts VideoExportOptions, openFolder bool) error {
 	// Convert app types to video package types
 	videoBBox := video.BoundingBox{
 		South: bbox.South,
@@ -1276,61 +1479,36 @@ func (a *App) exportTimelapseVideoInternal(bbox BoundingBox, zoom int, dates []G
 		East:  bbox.East,
 	}
 
-	videoDates := make([]video.DateInfo, len(dates))
-	for i, d := range dates {
-		videoDates[i] = video.DateInfo{
-			Date:    d.Date,
-			HexDate: d.HexDate,
-			Epoch:   d.Epoch,
-		}
-	}
-
-	videoTimelapseOpts := video.TimelapseOptions{
-		Width:              videoOpts.Width,
-		Height:             videoOpts.Height,
-		Preset:             videoOpts.Preset,
-		Presets:            videoOpts.Presets,
-		CropX:              videoOpts.CropX,
-		CropY:              videoOpts.CropY,
-		SpotlightEnabled:   videoOpts.SpotlightEnabled,
-		SpotlightCenterLat: videoOpts.SpotlightCenterLat,
-		SpotlightCenterLon: videoOpts.SpotlightCenterLon,
-		SpotlightRadiusKm:  videoOpts.SpotlightRadiusKm,
-		OverlayOpacity:     videoOpts.OverlayOpacity,
-		ShowDateOverlay:    videoOpts.ShowDateOverlay,
-		DateFontSize:       videoOpts.DateFontSize,
-		DatePosition:       videoOpts.DatePosition,
-		ShowLogo:           videoOpts.ShowLogo,
-		LogoPosition:       videoOpts.LogoPosition,
-		FrameDelay:         videoOpts.FrameDelay,
-		OutputFormat:       videoOpts.OutputFormat,
-		Quality:            videoOpts.Quality,
-	}
+	videoDates := toVideoDates(dates)
+	videoTimelapseOpts := videoOpts.toTimelapseOptions()
 
 	// Use videoManager to export
-	var err error
-	if openFolder && a.currentTaskID == "" {
-		err = a.videoManager.ExportTimelapse(videoBBox, zoom, videoDates, source, videoTimelapseOpts)
-		// Auto-open download folder after export (only if not in task queue)
-		if err == nil {
-			if openErr := a.OpenDownloadFolder(); openErr != nil {
-				log.Printf("Failed to open download folder: %v", openErr)
-			}
+	err := a.videoManager.ExportTimelapse(ctx, videoBBox, zoom, videoDates, source, videoTimelapseOpts)
+	// Auto-open download folder after export (not for queue tasks)
+	if err == nil && openFolder {
+		if openErr := a.OpenDownloadFolder(); openErr != nil {
+			log.Printf("Failed to open download folder: %v", openErr)
 		}
-	} else {
-		err = a.videoManager.ExportTimelapseNoOpen(videoBBox, zoom, videoDates, source, videoTimelapseOpts)
 	}
 
 	return err
 }
 
 // ReExportVideo re-exports video from a completed task with new presets
-func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string) error {
+func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string) (err error) {
+	defer crash.Recover("ReExportVideo", &err)
+	ctx, done, err := a.beginDownload("ReExportVideo")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
 	log.Printf("[ReExport] Starting re-export for task %s with presets: %v, format: %s", taskID, presets, videoFormat)
 
 	// Validate video format
-	if videoFormat != "mp4" && videoFormat != "gif" {
-		return fmt.Errorf("invalid video format: %s (must be 'mp4' or 'gif')", videoFormat)
+	switch videoFormat {
+	case "mp4", "gif", "apng", "webp", "images":
+	default:
+		return fmt.Errorf("invalid video format: %s (must be 'mp4', 'gif', 'apng', 'webp' or 'images')", videoFormat)
 	}
 
 	// Get the task from the queue
@@ -1365,17 +1543,13 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 			Date:    d.Date,
 			HexDate: d.HexDate,
 			Epoch:   d.Epoch,
+			Source:  d.Source,
 		}
 	}
 
-	// Save original download path and update videoManager
-	originalDownloadPath := a.downloadPath
-	a.downloadPath = task.OutputPath
-	a.videoManager.SetDownloadPath(task.OutputPath)
-	defer func() {
-		a.downloadPath = originalDownloadPath
-		a.videoManager.SetDownloadPath(originalDownloadPath)
-	}()
+	// Render from and into the task's output folder, described like the task's exports
+	ctx = downloads.WithOutputDir(ctx, task.OutputPath, task.ID)
+	ctx = downloads.WithMetadata(ctx, task.ExportMetadata())
 
 	// Export for each preset
 	log.Printf("[ReExport] Starting export of %d preset(s): %v", len(presets), presets)
@@ -1387,7 +1561,7 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 	for i, presetID := range presets {
 		log.Printf("[ReExport] Exporting preset %d/%d: %s (format: %s)", i+1, len(presets), presetID, videoFormat)
 
-		a.emitDownloadProgress(DownloadProgress{
+		downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
 			Downloaded:  i,
 			Total:       len(presets),
 			Percent:     (i * 100) / len(presets),
@@ -1401,23 +1575,41 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 			Preset:             presetID,
 			CropX:              task.VideoOpts.CropX,
 			CropY:              task.VideoOpts.CropY,
+			AutoCrop:           task.VideoOpts.AutoCrop,
 			SpotlightEnabled:   task.VideoOpts.SpotlightEnabled,
 			SpotlightCenterLat: task.VideoOpts.SpotlightCenterLat,
 			SpotlightCenterLon: task.VideoOpts.SpotlightCenterLon,
 			SpotlightRadiusKm:  task.VideoOpts.SpotlightRadiusKm,
+			SpotlightUnit:      task.VideoOpts.SpotlightUnit,
+			SpotlightShape:     task.VideoOpts.SpotlightShape,
+			SpotlightFeather:   task.VideoOpts.SpotlightFeather,
+			ExtraSpotlights:    task.VideoOpts.ExtraSpotlights,
 			OverlayOpacity:     task.VideoOpts.OverlayOpacity,
 			ShowDateOverlay:    task.VideoOpts.ShowDateOverlay,
+			ShowTimelineBar:    task.VideoOpts.ShowTimelineBar,
+			Title:              task.VideoOpts.Title,
+			Subtitles:          task.VideoOpts.Subtitles,
+			ShowInfoOverlay:    task.VideoOpts.ShowInfoOverlay,
+			InfoPosition:       task.VideoOpts.InfoPosition,
 			DateFontSize:       task.VideoOpts.DateFontSize,
 			DatePosition:       task.VideoOpts.DatePosition,
 			ShowLogo:           task.VideoOpts.ShowLogo,
 			LogoPosition:       task.VideoOpts.LogoPosition,
 			FrameDelay:         task.VideoOpts.FrameDelay,
+			FrameDurations:     task.VideoOpts.FrameDurations,
 			OutputFormat:       videoFormat,
 			Quality:            task.VideoOpts.Quality,
+			AudioPath:          task.VideoOpts.AudioPath,
+			ImageFormat:        task.VideoOpts.ImageFormat,
+			DedupeFrames:       task.VideoOpts.DedupeFrames,
+			DedupeThreshold:    task.VideoOpts.DedupeThreshold,
+			StabilizeFrames:    task.VideoOpts.StabilizeFrames,
+			GIFScale:           task.VideoOpts.GIFScale,
+			GIFMaxSizeMB:       task.VideoOpts.GIFMaxSizeMB,
 		}
 
 		// Use video manager for export (no folder opening)
-		if err := a.videoManager.ExportTimelapseNoOpen(bbox, task.Zoom, dates, task.Source, videoOpts); err != nil {
+		if err := a.videoManager.ExportTimelapse(ctx, bbox, task.Zoom, dates, task.Source, videoOpts); err != nil {
 			log.Printf("[ReExport] Failed to export preset %s: %v", presetID, err)
 			a.emitLog(fmt.Sprintf("❌ Failed to export preset %s: %v", presetID, err))
 			failedPresets = append(failedPresets, presetID)
@@ -1428,9 +1620,9 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 		}
 	}
 
-	// Open download folder once at the end (only if at least one export succeeded)
+	// Open the task folder once at the end (only if at least one export succeeded)
 	if successCount > 0 {
-		if err := a.OpenDownloadFolder(); err != nil {
+		if err := a.OpenFolder(task.OutputPath); err != nil {
 			log.Printf("Failed to open download folder: %v", err)
 		}
 	}
@@ -1443,7 +1635,7 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 		a.emitLog(fmt.Sprintf("✅ All %d preset(s) re-exported successfully", successCount))
 	}
 
-	a.emitDownloadProgress(DownloadProgress{
+	downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
 		Downloaded:  len(presets),
 		Total:       len(presets),
 		Percent:     100,
@@ -1462,8 +1654,14 @@ func (a *App) ReExportVideo(taskID string, presets []string, videoFormat string)
 	return nil
 }
 
-// loadGeoTIFFImage loads an image from a GeoTIFF file
+// loadGeoTIFFImage loads a video frame from a GeoTIFF, a split export's VRT or a PNG
 func (a *App) loadGeoTIFFImage(path string) (image.Image, error) {
+	// GeoTIFFs (and split exports) are read directly, other images by the standard decoders
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tif", ".tiff", ".vrt":
+		return geotiff.LoadImage(path)
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -1534,45 +1732,87 @@ func (a *App) calculateSpotlightPixels(bbox BoundingBox, zoom int, centerLat, ce
 
 // TaskQueueExportTask is the frontend-facing export task structure
 type TaskQueueExportTask struct {
-	ID          string                        `json:"id"`
-	Name        string                        `json:"name"`
-	Status      string                        `json:"status"`
-	Priority    int                           `json:"priority"`
-	CreatedAt   string                        `json:"createdAt"`
-	StartedAt   string                        `json:"startedAt,omitempty"`
-	CompletedAt string                        `json:"completedAt,omitempty"`
-	Source      string                        `json:"source"`
-	BBox        BoundingBox                   `json:"bbox"`
-	Zoom        int                           `json:"zoom"`
-	Format      string                        `json:"format"`
-	Dates       []GEDateInfo                  `json:"dates"`
-	VideoExport bool                          `json:"videoExport"`
-	VideoOpts   *VideoExportOptions           `json:"videoOpts,omitempty"`
-	CropPreview *taskqueue.CropPreview        `json:"cropPreview,omitempty"`
-	Progress    taskqueue.TaskProgress        `json:"progress"`
-	Error       string                        `json:"error,omitempty"`
-	OutputPath  string                        `json:"outputPath,omitempty"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Status       string                 `json:"status"`
+	Priority     int                    `json:"priority"`
+	CreatedAt    string                 `json:"createdAt"`
+	StartedAt    string                 `json:"startedAt,omitempty"`
+	CompletedAt  string                 `json:"completedAt,omitempty"`
+	Kind         string                 `json:"kind,omitempty"`
+	DependsOn    []string               `json:"dependsOn,omitempty"`
+	InputPaths   []string               `json:"inputPaths,omitempty"`
+	MaxRetries   int                    `json:"maxRetries,omitempty"`
+	RetryDelay   int                    `json:"retryDelaySeconds,omitempty"` // Seconds between automatic retries
+	RetryCount   int                    `json:"retryCount,omitempty"`
+	NextRetryAt  string                 `json:"nextRetryAt,omitempty"`
+	Source       string                 `json:"source"`
+	BBox         BoundingBox            `json:"bbox"`
+	Zoom         int                    `json:"zoom"`
+	Format       string                 `json:"format"`
+	Dates        []GEDateInfo           `json:"dates"`
+	VideoExport  bool                   `json:"videoExport"`
+	VideoOpts    *VideoExportOptions    `json:"videoOpts,omitempty"`
+	IncludeDEM   bool                   `json:"includeDem"`
+	MinSuccess   float64                `json:"minSuccessRate,omitempty"` // Share of tiles (0-1) each date needs (0 = only warn)
+	Strict       bool                   `json:"strict,omitempty"`         // All-or-nothing: missing tiles fail and retry the task
+	Incremental  bool                   `json:"incremental,omitempty"`    // Esri dates only fetch tiles changed since the last archived date
+	Class        string                 `json:"priorityClass,omitempty"`  // "interactive" (default) or "bulk" (runs only within its window)
+	Window       *taskqueue.Window      `json:"window,omitempty"`         // Bulk window overriding the queue's (e.g. 22:00-07:00)
+	Project      string                 `json:"project,omitempty"`        // Free-text description written into the exports' metadata
+	Operator     string                 `json:"operator,omitempty"`
+	Notes        string                 `json:"notes,omitempty"`
+	Manifest     bool                   `json:"manifest,omitempty"`       // Write a checksummed (optionally signed) manifest of the outputs
+	ZipOutput    bool                   `json:"zipOutput,omitempty"`      // Package the output folder into a .zip when done
+	ZipNoTiles   bool                   `json:"zipExcludeTiles,omitempty"` // Leave tile pyramids out of the .zip
+	CropPreview  *taskqueue.CropPreview `json:"cropPreview,omitempty"`
+	Progress     taskqueue.TaskProgress `json:"progress"`
+	Error        string                 `json:"error,omitempty"`
+	OutputPath   string                 `json:"outputPath,omitempty"`
+	BytesWritten int64                  `json:"bytesWritten,omitempty"`
+	LogPath      string                 `json:"logPath,omitempty"`
 }
 
 // convertTaskToFrontend converts internal task to frontend format
 func convertTaskToFrontend(t *taskqueue.ExportTask) TaskQueueExportTask {
 	result := TaskQueueExportTask{
-		ID:          t.ID,
-		Name:        t.Name,
-		Status:      string(t.Status),
-		Priority:    t.Priority,
-		CreatedAt:   t.CreatedAt,   // Already a string (RFC3339)
-		StartedAt:   t.StartedAt,   // Already a string (RFC3339)
-		CompletedAt: t.CompletedAt, // Already a string (RFC3339)
-		Source:      t.Source,
-		BBox:        BoundingBox(t.BBox),
-		Zoom:        t.Zoom,
-		Format:      t.Format,
-		VideoExport: t.VideoExport,
-		CropPreview: t.CropPreview,
-		Progress:    t.Progress,
-		Error:       t.Error,
-		OutputPath:  t.OutputPath,
+		ID:           t.ID,
+		Name:         t.Name,
+		Status:       string(t.Status),
+		Priority:     t.Priority,
+		CreatedAt:    t.CreatedAt,   // Already a string (RFC3339)
+		StartedAt:    t.StartedAt,   // Already a string (RFC3339)
+		CompletedAt:  t.CompletedAt, // Already a string (RFC3339)
+		Kind:         t.Kind,
+		DependsOn:    t.DependsOn,
+		InputPaths:   t.InputPaths,
+		MaxRetries:   t.MaxRetries,
+		RetryDelay:   t.RetryDelaySeconds,
+		RetryCount:   t.RetryCount,
+		NextRetryAt:  t.NextRetryAt,
+		Source:       t.Source,
+		BBox:         BoundingBox(t.BBox),
+		Zoom:         t.Zoom,
+		Format:       t.Format,
+		VideoExport:  t.VideoExport,
+		IncludeDEM:   t.IncludeDEM,
+		MinSuccess:   t.MinSuccessRate,
+		Strict:       t.Strict,
+		Incremental:  t.Incremental,
+		Class:        t.PriorityClass,
+		Window:       t.Window,
+		Project:      t.Project,
+		Operator:     t.Operator,
+		Notes:        t.Notes,
+		Manifest:     t.Manifest,
+		ZipOutput:    t.ZipOutput,
+		ZipNoTiles:   t.ZipExcludeTiles,
+		CropPreview:  t.CropPreview,
+		Progress:     t.Progress,
+		Error:        t.Error,
+		OutputPath:   t.OutputPath,
+		BytesWritten: t.BytesWritten,
+		LogPath:      t.LogPath,
 	}
 
 	// Convert dates
@@ -1582,6 +1822,7 @@ func convertTaskToFrontend(t *taskqueue.ExportTask) TaskQueueExportTask {
 			Date:    d.Date,
 			HexDate: d.HexDate,
 			Epoch:   d.Epoch,
+			Source:  d.Source,
 		}
 	}
 
@@ -1593,27 +1834,117 @@ func convertTaskToFrontend(t *taskqueue.ExportTask) TaskQueueExportTask {
 			Preset:             t.VideoOpts.Preset,
 			CropX:              t.VideoOpts.CropX,
 			CropY:              t.VideoOpts.CropY,
+			AutoCrop:           t.VideoOpts.AutoCrop,
 			SpotlightEnabled:   t.VideoOpts.SpotlightEnabled,
 			SpotlightCenterLat: t.VideoOpts.SpotlightCenterLat,
 			SpotlightCenterLon: t.VideoOpts.SpotlightCenterLon,
 			SpotlightRadiusKm:  t.VideoOpts.SpotlightRadiusKm,
+			SpotlightUnit:      t.VideoOpts.SpotlightUnit,
+			SpotlightShape:     t.VideoOpts.SpotlightShape,
+			SpotlightFeather:   t.VideoOpts.SpotlightFeather,
+			ExtraSpotlights:    t.VideoOpts.ExtraSpotlights,
 			OverlayOpacity:     t.VideoOpts.OverlayOpacity,
 			ShowDateOverlay:    t.VideoOpts.ShowDateOverlay,
+			ShowTimelineBar:    t.VideoOpts.ShowTimelineBar,
+			Title:              t.VideoOpts.Title,
+			Subtitles:          t.VideoOpts.Subtitles,
+			ShowInfoOverlay:    t.VideoOpts.ShowInfoOverlay,
+			InfoPosition:       t.VideoOpts.InfoPosition,
 			DateFontSize:       t.VideoOpts.DateFontSize,
 			DatePosition:       t.VideoOpts.DatePosition,
 			ShowLogo:           t.VideoOpts.ShowLogo,
 			LogoPosition:       t.VideoOpts.LogoPosition,
 			FrameDelay:         t.VideoOpts.FrameDelay,
+			FrameDurations:     t.VideoOpts.FrameDurations,
 			OutputFormat:       t.VideoOpts.OutputFormat,
 			Quality:            t.VideoOpts.Quality,
+			AudioPath:          t.VideoOpts.AudioPath,
+			ImageFormat:        t.VideoOpts.ImageFormat,
+			DedupeFrames:       t.VideoOpts.DedupeFrames,
+			DedupeThreshold:    t.VideoOpts.DedupeThreshold,
+			StabilizeFrames:    t.VideoOpts.StabilizeFrames,
+			GIFScale:           t.VideoOpts.GIFScale,
+			GIFMaxSizeMB:       t.VideoOpts.GIFMaxSizeMB,
 		}
 	}
 
 	return result
 }
 
+// validateTaskExtent checks the task zoom against each source's zoom range and keeps
+// Web Mercator sources within ±85.05° (Google Earth tiles reach the poles)
+func (a *App) validateTaskExtent(taskData TaskQueueExportTask) error {
+	sources := []string{taskData.Source}
+	if taskData.Source == common.ProviderMixed {
+		sources = sources[:0]
+		for _, d := range taskData.Dates {
+			sources = append(sources, d.Source)
+		}
+	}
+
+	bbox := taskData.BBox.toDownloadsBBox()
+	checked := make(map[string]bool)
+	for _, id := range sources {
+		if checked[id] {
+			continue
+		}
+		checked[id] = true
+
+		provider, err := a.providers.Get(id)
+		if err != nil {
+			continue // Not an imagery provider (e.g. terrain); validated when the task runs
+		}
+		if minZoom, maxZoom := provider.ZoomRange(); taskData.Zoom < minZoom || taskData.Zoom > maxZoom {
+			return fmt.Errorf("zoom %d outside %d-%d for %s", taskData.Zoom, minZoom, maxZoom, provider.Name())
+		}
+		if provider.TileScheme() == providers.SchemeXYZ {
+			if err := bbox.ValidateWebMercator(provider.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // AddExportTask adds a new export task to the queue
 func (a *App) AddExportTask(taskData TaskQueueExportTask) (string, error) {
+	// Mixed-source tasks need a concrete source on every date
+	if taskData.Source == common.ProviderMixed {
+		for _, d := range taskData.Dates {
+			if d.Source != common.ProviderGoogleEarth && d.Source != common.ProviderEsriWayback {
+				return "", fmt.Errorf("date %s has invalid source %q for mixed-source task", d.Date, d.Source)
+			}
+		}
+	}
+
+	// Custom and API-key sources must be registered and serve the requested zoom and dates
+	if common.IsTemplateProvider(taskData.Source) {
+		source, err := a.customClient.Get(taskData.Source)
+		if err != nil {
+			return "", err
+		}
+		if err := customsource.ValidateZoom(source, taskData.Zoom); err != nil {
+			return "", err
+		}
+		known := make(map[string]bool)
+		for _, date := range customsource.Dates(source) {
+			known[date] = true
+		}
+		for _, d := range taskData.Dates {
+			if !known[d.Date] {
+				return "", fmt.Errorf("date %s is not a time value of %s", d.Date, source.Name)
+			}
+		}
+	}
+
+	// Reject zoom levels and extents the sources cannot serve before queueing
+	if err := a.validateTaskExtent(taskData); err != nil {
+		return "", err
+	}
+	if taskData.MinSuccess < 0 || taskData.MinSuccess > 1 {
+		return "", fmt.Errorf("minimum success rate must be between 0 and 1")
+	}
+
 	// Convert dates
 	dates := make([]taskqueue.GEDateInfo, len(taskData.Dates))
 	for i, d := range taskData.Dates {
@@ -1621,6 +1952,7 @@ func (a *App) AddExportTask(taskData TaskQueueExportTask) (string, error) {
 			Date:    d.Date,
 			HexDate: d.HexDate,
 			Epoch:   d.Epoch,
+			Source:  d.Source,
 		}
 	}
 
@@ -1635,7 +1967,23 @@ func (a *App) AddExportTask(taskData TaskQueueExportTask) (string, error) {
 
 	task.Format = taskData.Format
 	task.Priority = taskData.Priority
+	task.Kind = taskData.Kind
+	task.DependsOn = taskData.DependsOn
+	task.MaxRetries = taskData.MaxRetries
+	task.RetryDelaySeconds = taskData.RetryDelay
 	task.VideoExport = taskData.VideoExport
+	task.IncludeDEM = taskData.IncludeDEM
+	task.MinSuccessRate = taskData.MinSuccess
+	task.Strict = taskData.Strict
+	task.Incremental = taskData.Incremental
+	task.PriorityClass = taskData.Class
+	task.Window = taskData.Window
+	task.Project = taskData.Project
+	task.Operator = taskData.Operator
+	task.Notes = taskData.Notes
+	task.Manifest = taskData.Manifest
+	task.ZipOutput = taskData.ZipOutput
+	task.ZipExcludeTiles = taskData.ZipNoTiles
 	task.CropPreview = taskData.CropPreview
 
 	// Convert video options
@@ -1647,19 +1995,37 @@ func (a *App) AddExportTask(taskData TaskQueueExportTask) (string, error) {
 			Presets:            taskData.VideoOpts.Presets, // Multi-preset support
 			CropX:              taskData.VideoOpts.CropX,
 			CropY:              taskData.VideoOpts.CropY,
+			AutoCrop:           taskData.VideoOpts.AutoCrop,
 			SpotlightEnabled:   taskData.VideoOpts.SpotlightEnabled,
 			SpotlightCenterLat: taskData.VideoOpts.SpotlightCenterLat,
 			SpotlightCenterLon: taskData.VideoOpts.SpotlightCenterLon,
 			SpotlightRadiusKm:  taskData.VideoOpts.SpotlightRadiusKm,
+			SpotlightUnit:      taskData.VideoOpts.SpotlightUnit,
+			SpotlightShape:     taskData.VideoOpts.SpotlightShape,
+			SpotlightFeather:   taskData.VideoOpts.SpotlightFeather,
+			ExtraSpotlights:    taskData.VideoOpts.ExtraSpotlights,
 			OverlayOpacity:     taskData.VideoOpts.OverlayOpacity,
 			ShowDateOverlay:    taskData.VideoOpts.ShowDateOverlay,
+			ShowTimelineBar:    taskData.VideoOpts.ShowTimelineBar,
+			Title:              taskData.VideoOpts.Title,
+			Subtitles:          taskData.VideoOpts.Subtitles,
+			ShowInfoOverlay:    taskData.VideoOpts.ShowInfoOverlay,
+			InfoPosition:       taskData.VideoOpts.InfoPosition,
 			DateFontSize:       taskData.VideoOpts.DateFontSize,
 			DatePosition:       taskData.VideoOpts.DatePosition,
 			ShowLogo:           taskData.VideoOpts.ShowLogo,
 			LogoPosition:       taskData.VideoOpts.LogoPosition,
 			FrameDelay:         taskData.VideoOpts.FrameDelay,
+			FrameDurations:     taskData.VideoOpts.FrameDurations,
 			OutputFormat:       taskData.VideoOpts.OutputFormat,
 			Quality:            taskData.VideoOpts.Quality,
+			AudioPath:          taskData.VideoOpts.AudioPath,
+			ImageFormat:        taskData.VideoOpts.ImageFormat,
+			DedupeFrames:       taskData.VideoOpts.DedupeFrames,
+			DedupeThreshold:    taskData.VideoOpts.DedupeThreshold,
+			StabilizeFrames:    taskData.VideoOpts.StabilizeFrames,
+			GIFScale:           taskData.VideoOpts.GIFScale,
+			GIFMaxSizeMB:       taskData.VideoOpts.GIFMaxSizeMB,
 		}
 	}
 
@@ -1730,6 +2096,59 @@ func (a *App) GetTaskQueueStatus() taskqueue.QueueStatus {
 	return a.taskQueue.GetStatus()
 }
 
+// RequeueTask resets a finished task to pending so it runs again
+func (a *App) RequeueTask(id string) error {
+	return a.taskQueue.RequeueTask(id)
+}
+
+// RetryTask resets a failed or cancelled task to pending, keeping its partial output
+func (a *App) RetryTask(id string) error {
+	return a.taskQueue.RetryTask(id)
+}
+
+// GetTaskLog returns the last tail lines of a task's log (all lines if tail <= 0)
+func (a *App) GetTaskLog(id string, tail int) ([]string, error) {
+	task, err := a.taskQueue.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	path := task.LogPath
+	if path == "" {
+		path = a.taskQueue.TaskLog().ActivePath(id)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("task %s has no log yet", id)
+	}
+	return taskqueue.ReadTaskLog(path, tail)
+}
+
+// GetInterruptedTasks returns tasks cut off by a previous crash or forced quit
+func (a *App) GetInterruptedTasks() []TaskQueueExportTask {
+	tasks := a.taskQueue.InterruptedTasks()
+	result := make([]TaskQueueExportTask, len(tasks))
+	for i, t := range tasks {
+		result[i] = convertTaskToFrontend(t)
+	}
+	return result
+}
+
+// ResumeTask requeues an interrupted task, skipping the dates it already finished
+func (a *App) ResumeTask(id string) error {
+	return a.taskQueue.ResumeTask(id)
+}
+
+// ResumeInterruptedTasks requeues all interrupted tasks and starts the queue
+func (a *App) ResumeInterruptedTasks() error {
+	if a.taskQueue.ResumeInterruptedTasks() == 0 {
+		return nil
+	}
+	if a.taskQueue.GetStatus().IsRunning {
+		return nil
+	}
+	return a.taskQueue.StartQueue()
+}
+
 // ClearCompletedTasks removes all completed/failed/cancelled tasks
 func (a *App) ClearCompletedTasks() {
 	a.taskQueue.ClearCompleted()
@@ -1737,48 +2156,32 @@ func (a *App) ClearCompletedTasks() {
 
 // ExecuteExportTask implements the TaskExecutor interface
 // This is called by the queue worker to actually perform the export
-func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask, progressChan chan<- taskqueue.TaskProgress) error {
+func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask, progressChan chan<- taskqueue.TaskProgress) (err error) {
+	defer crash.Recover("ExecuteExportTask", &err)
 	log.Printf("[TaskQueue] Executing task: %s - %s", task.ID, task.Name)
 
-	// Set up task context for progress tracking
-	a.mu.Lock()
-	a.currentTaskID = task.ID
-	a.taskProgressChan = progressChan
 	// Create task-specific output directory
-	a.taskOutputPath = filepath.Join(a.downloadPath, task.ID)
-	if err := os.MkdirAll(a.taskOutputPath, 0755); err != nil {
-		a.mu.Unlock()
+	// Video-only tasks render from (and into) their dependency's output instead
+	taskOutputPath := filepath.Join(a.GetDownloadPath(), task.ID)
+	videoOnly := task.IsVideoOnly()
+	if videoOnly {
+		if len(task.InputPaths) == 0 {
+			return fmt.Errorf("video task has no completed dependency output")
+		}
+		taskOutputPath = task.InputPaths[0]
+	}
+	if err := os.MkdirAll(taskOutputPath, 0755); err != nil {
 		return fmt.Errorf("failed to create task output directory: %w", err)
 	}
-	// Save original download path to restore later
-	originalDownloadPath := a.downloadPath
-	a.downloadPath = a.taskOutputPath
-	a.mu.Unlock()
 
-	// Update downloaders and videoManager to use task-specific path
-	a.esriDownloader.SetDownloadPath(a.taskOutputPath)
-	if a.geDownloader != nil {
-		a.geDownloader.SetDownloadPath(a.taskOutputPath)
+	// Capture this task's log lines in its output directory (closed by the queue worker)
+	if err := a.taskQueue.TaskLog().Begin(task.ID, taskOutputPath); err != nil {
+		log.Printf("[TaskQueue] Failed to start task log: %v", err)
 	}
-	a.videoManager.SetDownloadPath(a.taskOutputPath)
 
-	// Ensure we clean up task context when done
+	// Set the output path on the task when done
 	defer func() {
-		a.mu.Lock()
-		a.currentTaskID = ""
-		a.taskProgressChan = nil
-		a.downloadPath = originalDownloadPath
-		// Set the output path on the task
-		task.OutputPath = a.taskOutputPath
-		a.taskOutputPath = ""
-		a.mu.Unlock()
-
-		// Restore downloaders and videoManager to original path
-		a.esriDownloader.SetDownloadPath(originalDownloadPath)
-		if a.geDownloader != nil {
-			a.geDownloader.SetDownloadPath(originalDownloadPath)
-		}
-		a.videoManager.SetDownloadPath(originalDownloadPath)
+		task.OutputPath = taskOutputPath
 	}()
 
 	// Convert types for internal use
@@ -1789,24 +2192,60 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 			Date:    d.Date,
 			HexDate: d.HexDate,
 			Epoch:   d.Epoch,
+			Source:  d.Source,
 		}
 	}
 
-	// Enable range download mode for proper progress tracking
-	a.inRangeDownload = true
-	a.totalDatesInRange = len(dates)
-	defer func() {
-		a.inRangeDownload = false
-	}()
+	// Esri releases lacking the task's zoom lower it for the whole task before downloading
+	if !videoOnly && (task.Source == common.ProviderEsriWayback || task.Source == common.ProviderMixed) {
+		a.clampTaskZoom(ctx, task)
+	}
+
+	// Everything this task downloads or renders runs as its own operation: files go to the
+	// task folder and progress to the task worker, so a manual download started meanwhile
+	// keeps its own folder and progress
+	rangeTracker := downloads.NewRangeTracker(len(dates))
+	report := downloads.NewReportRecorder(task.ID, task.ID)
+	defer a.saveDownloadReport(report, taskOutputPath) // No-op once saved before the manifest
+	ctx = downloads.WithOperation(ctx, &downloads.Operation{
+		ID:             task.ID,
+		OutputDir:      taskOutputPath,
+		TaskID:         task.ID,
+		Range:          rangeTracker,
+		MinSuccessRate: task.MinSuccessRate,
+		Strict:         task.Strict,
+		Metadata:       task.ExportMetadata(),
+		Report:         report,
+		OnProgress: func(progress downloads.DownloadProgress) {
+			taskProgress := taskqueue.TaskProgress{
+				CurrentPhase:   progress.Status,
+				TotalDates:     progress.TotalDates,
+				CurrentDate:    progress.CurrentDate,
+				TilesTotal:     progress.Total,
+				TilesCompleted: progress.Downloaded,
+				Percent:        progress.Percent,
+			}
+			// Non-blocking send
+			select {
+			case progressChan <- taskProgress:
+			default:
+			}
+		},
+	})
 
-	// For Esri: deduplicate by checking center tile hash
+	// For Esri: deduplicate by hashing a grid of sample tiles across the AOI
 	var esriSeenHashes map[string]string
-	var esriCenterTile *esriClient.EsriTile
-	if task.Source == common.ProviderEsriWayback {
+	var esriSampleTiles []*esriClient.EsriTile
+	if !videoOnly && (task.Source == common.ProviderEsriWayback || task.Source == common.ProviderMixed) {
 		esriSeenHashes = make(map[string]string)
-		centerLat := (bbox.South + bbox.North) / 2
-		centerLon := (bbox.West + bbox.East) / 2
-		esriCenterTile, _ = esriClient.GetTileForWgs84(centerLat, centerLon, task.Zoom)
+		esriSampleTiles, _ = a.esriDownloader.SampleTiles(bbox.toDownloadsBBox(), task.Zoom)
+	}
+
+	// Incremental Esri archives fetch each date's changes against the latest earlier
+	// date archived for this area, by a completed task or earlier in this one
+	var esriArchived []string
+	if task.Incremental {
+		esriArchived = a.archivedWaybackDates(task)
 	}
 
 	// Track progress
@@ -1814,7 +2253,17 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 	downloadedCount := 0
 	skippedCount := 0
 
-	for i, dateInfo := range dates {
+	// Dates written before an interruption or failed run are skipped
+	checkpointed := a.taskQueue.CheckpointedDates(task.ID)
+	resumedCount := 0
+
+	// Video-only tasks reuse imagery downloaded by their dependency
+	downloadDates := dates
+	if videoOnly {
+		downloadDates = nil
+	}
+
+	for i, dateInfo := range downloadDates {
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
@@ -1822,67 +2271,117 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 		default:
 		}
 
-		a.currentDateIndex = i + 1
+		rangeTracker.SetCurrentDate(i + 1)
+
+		// Download imagery based on source (mixed-source tasks carry the source per date)
+		source := task.Source
+		if source == common.ProviderMixed {
+			source = dateInfo.Source
+		}
+
+		checkpointKey := taskqueue.CheckpointKey(source, dateInfo.Date)
 
-		// Download imagery based on source
 		var err error
-		switch task.Source {
+		switch source {
 		case common.ProviderGoogleEarth:
-			err = a.DownloadGoogleEarthHistoricalImagery(bbox, task.Zoom, dateInfo.HexDate, dateInfo.Epoch, dateInfo.Date, task.Format)
+			if checkpointed[checkpointKey] {
+				resumedCount++
+				continue
+			}
+			if a.geDownloader == nil {
+				err = fmt.Errorf("Google Earth downloader not initialized")
+				break
+			}
+			err = a.geDownloader.DownloadHistoricalImagery(ctx, bbox.toDownloadsBBox(), task.Zoom, dateInfo.HexDate, dateInfo.Epoch, dateInfo.Date, task.Format)
 			if err == nil {
 				downloadedCount++
 			}
 		case common.ProviderEsriWayback:
-			// Deduplicate Esri downloads by checking center tile hash
+			// Deduplicate Esri downloads by hashing the sample tiles
 			// Also detect blank tiles (no coverage at this zoom level)
 			shouldDownload := true
-			if esriCenterTile != nil {
+			if len(esriSampleTiles) > 0 {
 				layer, layerErr := a.findLayerForDate(dateInfo.Date)
 				if layerErr == nil {
-					tileData, tileErr := a.esriClient.FetchTile(layer, esriCenterTile)
-					if tileErr == nil {
-						// Check if tile is blank (no coverage at this zoom level)
-						if isBlankTile(tileData) {
+					hashKey, blank, hashErr := a.esriDownloader.FingerprintArea(ctx, layer, esriSampleTiles)
+					if hashErr == nil {
+						if blank {
 							log.Printf("[TaskQueue] Esri date %s has no coverage at zoom %d, skipping", dateInfo.Date, task.Zoom)
 							skippedCount++
 							shouldDownload = false
+						} else if firstDate, seen := esriSeenHashes[hashKey]; seen {
+							// Duplicate imagery across every sample tile
+							log.Printf("[TaskQueue] Esri date %s has same imagery as %s, skipping", dateInfo.Date, firstDate)
+							skippedCount++
+							shouldDownload = false
 						} else {
-							// Check for duplicate imagery
-							hashKey := fmt.Sprintf("%x", sha256.Sum256(tileData))
-							if firstDate, seen := esriSeenHashes[hashKey]; seen {
-								log.Printf("[TaskQueue] Esri date %s has same imagery as %s, skipping", dateInfo.Date, firstDate)
-								skippedCount++
-								shouldDownload = false
-							} else {
-								esriSeenHashes[hashKey] = dateInfo.Date
-							}
+							esriSeenHashes[hashKey] = dateInfo.Date
 						}
 					}
 				}
 			}
 
+			if shouldDownload && checkpointed[checkpointKey] {
+				resumedCount++
+				shouldDownload = false
+				esriArchived = append(esriArchived, dateInfo.Date)
+			}
+
 			if shouldDownload {
-				err = a.DownloadEsriImagery(bbox, task.Zoom, dateInfo.Date, task.Format)
+				if baseDate := latestDateBefore(esriArchived, dateInfo.Date); task.Incremental && baseDate != "" {
+					_, err = a.esriDownloader.DownloadChangedTiles(ctx, bbox.toDownloadsBBox(), task.Zoom, baseDate, dateInfo.Date)
+				} else {
+					err = a.esriDownloader.DownloadImagery(ctx, bbox.toDownloadsBBox(), task.Zoom, dateInfo.Date, task.Format)
+				}
 				if err == nil {
 					downloadedCount++
+					esriArchived = append(esriArchived, dateInfo.Date)
 				}
 			}
 		default:
-			err = fmt.Errorf("unknown source: %s", task.Source)
+			if !common.IsTemplateProvider(source) {
+				err = fmt.Errorf("unknown source: %s", source)
+				break
+			}
+			if checkpointed[checkpointKey] {
+				resumedCount++
+				continue
+			}
+			err = a.downloadCustomSourceImagery(ctx, source, bbox, task.Zoom, dateInfo.Date, task.Format)
+			if err == nil {
+				downloadedCount++
+			}
 		}
 
-		if err != nil {
+		if err != nil && task.Strict {
+			// All-or-nothing: fail the task so it is retried, resuming after the checkpointed dates
+			return fmt.Errorf("strict export: date %s failed: %w", dateInfo.Date, err)
+		} else if err != nil {
 			log.Printf("[TaskQueue] Failed to download date %s: %v", dateInfo.Date, err)
 			// Continue with other dates, don't fail the entire task
+		} else if err := a.taskQueue.CheckpointDate(task.ID, checkpointKey); err != nil {
+			log.Printf("[TaskQueue] Failed to checkpoint date %s: %v", dateInfo.Date, err)
 		}
 	}
 
+	if resumedCount > 0 {
+		log.Printf("[TaskQueue] Resumed task %s: %d date(s) already downloaded", task.ID, resumedCount)
+	}
+
 	if skippedCount > 0 {
 		log.Printf("[TaskQueue] Downloaded %d unique dates, skipped %d duplicates", downloadedCount, skippedCount)
 	}
 
+	// Export a DEM alongside the imagery if requested (terrain is date-independent)
+	if task.IncludeDEM && !videoOnly && a.geDownloader != nil {
+		if _, err := a.geDownloader.DownloadTerrain(ctx, bbox.toDownloadsBBox(), task.Zoom, geDownloader.DEMCRSGeographic); err != nil {
+			log.Printf("[TaskQueue] Failed to export DEM: %v", err)
+			a.emitLog(fmt.Sprintf("⚠️ DEM export failed: %v", err))
+		}
+	}
+
 	// If video export is requested, do it after all imagery is downloaded
-	if task.VideoExport && task.VideoOpts != nil {
+	if (task.VideoExport || videoOnly) && task.VideoOpts != nil {
 		// Determine which presets to export
 		presetsToExport := task.VideoOpts.Presets
 		if len(presetsToExport) == 0 {
@@ -1897,7 +2396,7 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 		failedPresets := []string{}
 
 		for i, presetID := range presetsToExport {
-			a.emitDownloadProgress(DownloadProgress{
+			downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
 				Downloaded:  i,
 				Total:       len(presetsToExport),
 				Percent:     95 + (i * 5 / len(presetsToExport)),
@@ -1911,23 +2410,41 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 				Preset:             presetID,
 				CropX:              task.VideoOpts.CropX,
 				CropY:              task.VideoOpts.CropY,
+				AutoCrop:           task.VideoOpts.AutoCrop,
 				SpotlightEnabled:   task.VideoOpts.SpotlightEnabled,
 				SpotlightCenterLat: task.VideoOpts.SpotlightCenterLat,
 				SpotlightCenterLon: task.VideoOpts.SpotlightCenterLon,
 				SpotlightRadiusKm:  task.VideoOpts.SpotlightRadiusKm,
+				SpotlightUnit:      task.VideoOpts.SpotlightUnit,
+				SpotlightShape:     task.VideoOpts.SpotlightShape,
+				SpotlightFeather:   task.VideoOpts.SpotlightFeather,
+				ExtraSpotlights:    task.VideoOpts.ExtraSpotlights,
 				OverlayOpacity:     task.VideoOpts.OverlayOpacity,
 				ShowDateOverlay:    task.VideoOpts.ShowDateOverlay,
+				ShowTimelineBar:    task.VideoOpts.ShowTimelineBar,
+				Title:              task.VideoOpts.Title,
+				Subtitles:          task.VideoOpts.Subtitles,
+				ShowInfoOverlay:    task.VideoOpts.ShowInfoOverlay,
+				InfoPosition:       task.VideoOpts.InfoPosition,
 				DateFontSize:       task.VideoOpts.DateFontSize,
 				DatePosition:       task.VideoOpts.DatePosition,
 				ShowLogo:           task.VideoOpts.ShowLogo,
 				LogoPosition:       task.VideoOpts.LogoPosition,
 				FrameDelay:         task.VideoOpts.FrameDelay,
+				FrameDurations:     task.VideoOpts.FrameDurations,
 				OutputFormat:       task.VideoOpts.OutputFormat,
 				Quality:            task.VideoOpts.Quality,
+				AudioPath:          task.VideoOpts.AudioPath,
+				ImageFormat:        task.VideoOpts.ImageFormat,
+				DedupeFrames:       task.VideoOpts.DedupeFrames,
+				DedupeThreshold:    task.VideoOpts.DedupeThreshold,
+				StabilizeFrames:    task.VideoOpts.StabilizeFrames,
+				GIFScale:           task.VideoOpts.GIFScale,
+				GIFMaxSizeMB:       task.VideoOpts.GIFMaxSizeMB,
 			}
 
 			// Use internal function with openFolder=false to avoid opening folder multiple times
-			if err := a.exportTimelapseVideoInternal(bbox, task.Zoom, dates, task.Source, videoOpts, false); err != nil {
+			if err := a.exportTimelapseVideoInternal(ctx, bbox, task.Zoom, dates, task.Source, videoOpts, false); err != nil {
 				log.Printf("[TaskQueue] Failed to export preset %s: %v", presetID, err)
 				a.emitLog(fmt.Sprintf("❌ Failed to export preset %s: %v", presetID, err))
 				failedPresets = append(failedPresets, presetID)
@@ -1949,6 +2466,29 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 		}
 	}
 
+	// Save the tile failure report first, so the manifest and package carry it
+	a.saveDownloadReport(report, taskOutputPath)
+
+	// Record the outputs' hashes before packaging, so the package carries the manifest
+	if task.Manifest {
+		if _, err := a.writeTaskManifest(ctx, task, taskOutputPath); err != nil {
+			return fmt.Errorf("failed to write export manifest: %w", err)
+		}
+	}
+
+	// Package the output for sharing; a failure leaves the output folder as is
+	if task.ZipOutput {
+		if zipPath, err := a.packageOutput(ctx, taskOutputPath, task.ZipExcludeTiles); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("[TaskQueue] Failed to package task output: %v", err)
+			a.emitLog(fmt.Sprintf("⚠️ Failed to package task output: %v", err))
+		} else {
+			log.Printf("[TaskQueue] Packaged task output: %s", zipPath)
+		}
+	}
+
 	// Final progress update
 	progress := taskqueue.TaskProgress{
 		CurrentPhase:   "completed",
@@ -1964,6 +2504,34 @@ func (a *App) ExecuteExportTask(ctx context.Context, task *taskqueue.ExportTask,
 	return nil
 }
 
+// archivedWaybackDates returns the Esri Wayback dates archived by completed tasks covering
+// the same area and zoom as task
+func (a *App) archivedWaybackDates(task *taskqueue.ExportTask) []string {
+	var archived []string
+	for _, t := range a.taskQueue.GetAllTasks() {
+		if t.ID == task.ID || t.Status != taskqueue.TaskStatusCompleted || t.BBox != task.BBox || t.Zoom != task.Zoom {
+			continue
+		}
+		for _, d := range t.Dates {
+			if t.Source == common.ProviderEsriWayback || (t.Source == common.ProviderMixed && d.Source == common.ProviderEsriWayback) {
+				archived = append(archived, d.Date)
+			}
+		}
+	}
+	return archived
+}
+
+// latestDateBefore returns the latest of dates (YYYY-MM-DD) before date, or ""
+func latestDateBefore(dates []string, date string) string {
+	latest := ""
+	for _, d := range dates {
+		if d < date && d > latest {
+			latest = d
+		}
+	}
+	return latest
+}
+
 // loadLogoImage loads the embedded logo image for video overlays
 func (a *App) loadLogoImage() (image.Image, error) {
 	if len(logoImageData) == 0 {
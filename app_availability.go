@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Availability report (Wails-exported)
+// Inventories every Esri Wayback and Google Earth date of an area for archive planning
+
+// availabilityReportGrid is the number of points per side sampled for the report; denser
+// than the timeline's five points so coverage estimates hold for larger areas
+const availabilityReportGrid = 5
+
+// AvailabilityReport lists the imagery dates of an area across sources
+type AvailabilityReport struct {
+	GeneratedAt string                    `json:"generatedAt"` // RFC 3339
+	BBox        BoundingBox               `json:"bbox"`
+	Zoom        int                       `json:"zoom"`
+	Entries     []AvailabilityReportEntry `json:"entries"` // Newest first
+	Sources     []TimelineSourceSummary   `json:"sources"`
+}
+
+// AvailabilityReportEntry is one date of one source
+type AvailabilityReportEntry struct {
+	Source        string  `json:"source"`
+	Date          string  `json:"date"`                  // YYYY-MM-DD (layer date for Esri)
+	LayerID       int     `json:"layerId,omitempty"`     // Esri only: Wayback release number
+	CaptureDate   string  `json:"captureDate,omitempty"` // Esri only
+	Epoch         int     `json:"epoch,omitempty"`       // Google Earth only
+	HexDate       string  `json:"hexDate,omitempty"`     // Google Earth only
+	Coverage      float64 `json:"coverage"`              // Estimated share of the area with this date (0-1)
+	TilesWithDate int     `json:"tilesWithDate"`
+	SampledTiles  int     `json:"sampledTiles"`
+}
+
+// ExportAvailabilityReport samples Esri Wayback and Google Earth across bbox and writes every
+// date found with its Esri layer ID or Google Earth epoch/hexDate and estimated coverage.
+// The file format follows the chosen extension: .json writes JSON, anything else CSV.
+// Returns the path of the written report ("" if the user cancelled).
+func (a *App) ExportAvailabilityReport(bbox BoundingBox, zoom int) (path string, err error) {
+	defer crash.Recover("ExportAvailabilityReport", &err)
+
+	if err := downloads.ValidateCoordinates(bbox.toDownloadsBBox(), zoom); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+
+	path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:            "Save Availability Report",
+		DefaultDirectory: a.GetDownloadPath(),
+		DefaultFilename:  fmt.Sprintf("availability_z%d_%s.csv", zoom, time.Now().Format("20060102_150405")),
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "CSV Files (*.csv)", Pattern: "*.csv"},
+			{DisplayName: "JSON Files (*.json)", Pattern: "*.json"},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	report, err := a.buildAvailabilityReport(bbox, zoom)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = writeAvailabilityJSON(path, report)
+	} else {
+		err = writeAvailabilityCSV(path, report)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	a.emitLog(fmt.Sprintf("Availability report with %d dates saved to %s", len(report.Entries), filepath.Base(path)))
+	return path, nil
+}
+
+// buildAvailabilityReport samples both sources on the same grid of points
+func (a *App) buildAvailabilityReport(bbox BoundingBox, zoom int) (*AvailabilityReport, error) {
+	a.emitLog(fmt.Sprintf("Building availability report for zoom %d...", zoom))
+
+	points := availabilityReportPoints(bbox)
+	samples := make([]*timelineSample, 2)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer crash.Recover("AvailabilityReportEsri", nil)
+		samples[0] = a.sampleEsriTimeline(points, zoom)
+	}()
+	go func() {
+		defer wg.Done()
+		defer crash.Recover("AvailabilityReportGoogleEarth", nil)
+		samples[1] = a.sampleGoogleEarthTimeline(points, zoom)
+	}()
+	wg.Wait()
+
+	report := &AvailabilityReport{
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		BBox:        bbox,
+		Zoom:        zoom,
+		Entries:     []AvailabilityReportEntry{},
+	}
+	failed := 0
+	for _, s := range samples {
+		if s == nil { // Sampler panicked
+			failed++
+			continue
+		}
+		summary := TimelineSourceSummary{
+			Source:       string(s.source),
+			DateCount:    len(s.entries),
+			SampledTiles: s.sampled,
+		}
+		if s.err != nil {
+			summary.Error = s.err.Error()
+			failed++
+			log.Printf("[Availability] %s unavailable: %v", s.source, s.err)
+		}
+		report.Sources = append(report.Sources, summary)
+
+		for date, entry := range s.entries {
+			row := AvailabilityReportEntry{
+				Source:        entry.Source,
+				Date:          date,
+				CaptureDate:   entry.CaptureDate,
+				Epoch:         entry.Epoch,
+				HexDate:       entry.HexDate,
+				Coverage:      entry.Coverage,
+				TilesWithDate: entry.TilesWithDate,
+				SampledTiles:  entry.SampledTiles,
+			}
+			if s.source == SourceEsriWayback {
+				if layer, err := a.findLayerForDate(date); err == nil {
+					row.LayerID = layer.ID
+				}
+			}
+			report.Entries = append(report.Entries, row)
+		}
+	}
+	if failed == len(samples) {
+		return nil, fmt.Errorf("failed to query any imagery source for this area")
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Date != report.Entries[j].Date {
+			return report.Entries[i].Date > report.Entries[j].Date
+		}
+		return report.Entries[i].Source < report.Entries[j].Source
+	})
+	return report, nil
+}
+
+// availabilityReportPoints returns the centers of an availabilityReportGrid grid over bbox
+func availabilityReportPoints(bbox BoundingBox) []struct{ lat, lon float64 } {
+	lon := bbox.toDownloadsBBox().LonAt // Wraps across the antimeridian
+	points := make([]struct{ lat, lon float64 }, 0, availabilityReportGrid*availabilityReportGrid)
+	for row := 0; row < availabilityReportGrid; row++ {
+		lat := bbox.North - (bbox.North-bbox.South)*(float64(row)+0.5)/availabilityReportGrid
+		for col := 0; col < availabilityReportGrid; col++ {
+			points = append(points, struct{ lat, lon float64 }{lat, lon((float64(col) + 0.5) / availabilityReportGrid)})
+		}
+	}
+	return points
+}
+
+// writeAvailabilityJSON writes the report as indented JSON
+func writeAvailabilityJSON(path string, report *AvailabilityReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// writeAvailabilityCSV writes one row per source and date, coverage in percent
+func writeAvailabilityCSV(path string, report *AvailabilityReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"source", "date", "layer_id", "capture_date", "epoch", "hex_date", "coverage_pct", "tiles_with_date", "sampled_tiles"})
+	for _, e := range report.Entries {
+		layerID, epoch := "", ""
+		if e.LayerID != 0 {
+			layerID = strconv.Itoa(e.LayerID)
+		}
+		if e.Epoch != 0 {
+			epoch = strconv.Itoa(e.Epoch)
+		}
+		w.Write([]string{
+			e.Source,
+			e.Date,
+			layerID,
+			e.CaptureDate,
+			epoch,
+			e.HexDate,
+			strconv.FormatFloat(e.Coverage*100, 'f', 1, 64),
+			strconv.Itoa(e.TilesWithDate),
+			strconv.Itoa(e.SampledTiles),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
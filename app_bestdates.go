@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/downloads"
+	esriClient "imagery-desktop/internal/esri"
+	"imagery-desktop/internal/googleearth"
+)
+
+// Date selection strategies for range downloads
+const (
+	SelectionPerYear    = "per_year"    // One date per year, preferring summer
+	SelectionPerQuarter = "per_quarter" // One date per calendar quarter
+)
+
+// clearScoreThreshold is the quality score at or below which an in-season date is accepted
+// without scoring the rest of its period (keeps 200-release scans to a few requests per year)
+const clearScoreThreshold = 0.05
+
+// offSeasonPenalty is added to the quality score of dates outside the preferred months,
+// so a clear spring image still beats a cloudy or blank summer one
+const offSeasonPenalty = 0.2
+
+// BestDate is a date chosen for a period, with the score it was chosen on
+type BestDate struct {
+	DateInfo GEDateInfo `json:"dateInfo"`
+	Period   string     `json:"period"`   // "2019" or "2019-Q3"
+	Score    float64    `json:"score"`    // 0 (clear) to 1 (blank), averaged over sample tiles; -1 if unscored
+	InSeason bool       `json:"inSeason"` // Captured in the preferred (summer) months
+}
+
+// SelectBestDates picks one date per year or quarter from the dates available for an area,
+// so long timelapses can be queued without curating every release by hand.
+// Per-year selection prefers local summer (June-August, or December-February south of the
+// equator); within a period the date with the lowest cloud/blank score wins, then the latest.
+// Returned dates are oldest first; their DateInfo values can be queued with AddExportTask.
+func (a *App) SelectBestDates(bbox BoundingBox, zoom int, source string, strategy string) ([]BestDate, error) {
+	if strategy == "" {
+		strategy = SelectionPerYear
+	}
+	if strategy != SelectionPerYear && strategy != SelectionPerQuarter {
+		return nil, fmt.Errorf("invalid selection strategy: %s", strategy)
+	}
+
+	candidates, err := a.bestDateCandidates(bbox, zoom, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no %s dates available for this area", source)
+	}
+
+	centerLat := (bbox.South + bbox.North) / 2
+	summer := summerMonths(centerLat)
+
+	// Group candidates by period
+	byPeriod := make(map[string][]GEDateInfo)
+	for _, c := range candidates {
+		t, err := time.Parse("2006-01-02", c.Date)
+		if err != nil {
+			continue
+		}
+		period := fmt.Sprintf("%d", t.Year())
+		if strategy == SelectionPerQuarter {
+			period = fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+		}
+		byPeriod[period] = append(byPeriod[period], c)
+	}
+
+	periods := make([]string, 0, len(byPeriod))
+	for period := range byPeriod {
+		periods = append(periods, period)
+	}
+	sort.Strings(periods)
+
+	points := timelineSamplePoints(bbox)
+	inSeason := func(date string) bool {
+		if strategy != SelectionPerYear {
+			return true
+		}
+		t, _ := time.Parse("2006-01-02", date)
+		return summer[t.Month()]
+	}
+
+	var selected []BestDate
+	for i, period := range periods {
+		dates := byPeriod[period]
+		a.emitDownloadProgress(DownloadProgress{
+			Downloaded: i,
+			Total:      len(periods),
+			Percent:    i * 100 / len(periods),
+			Status:     fmt.Sprintf("Scoring dates for %s...", period),
+		})
+
+		// Score in-season dates first, newest first, so a clear summer date ends the scan early
+		sort.Slice(dates, func(x, y int) bool {
+			if inSeason(dates[x].Date) != inSeason(dates[y].Date) {
+				return inSeason(dates[x].Date)
+			}
+			return dates[x].Date > dates[y].Date
+		})
+
+		var best *BestDate
+		bestRank := 0.0
+		for _, d := range dates {
+			score, ok := a.scoreDate(source, d, points, zoom)
+			if !ok {
+				log.Printf("[BestDates] Could not fetch any sample tile for %s, leaving it unscored", d.Date)
+				continue
+			}
+			rank := score
+			season := inSeason(d.Date)
+			if !season {
+				rank += offSeasonPenalty
+			}
+			if best == nil || rank < bestRank {
+				best = &BestDate{DateInfo: d, Period: period, Score: score, InSeason: season}
+				bestRank = rank
+			}
+			if season && score <= clearScoreThreshold {
+				break
+			}
+		}
+
+		// A period none of whose dates could be scored keeps its preferred date unscored;
+		// only one whose scored dates are all blank has nothing worth a frame
+		if best == nil {
+			log.Printf("[BestDates] No date could be scored for %s, keeping %s unscored", period, dates[0].Date)
+			selected = append(selected, BestDate{DateInfo: dates[0], Period: period, Score: -1, InSeason: inSeason(dates[0].Date)})
+			continue
+		}
+		if best.Score >= 1 {
+			log.Printf("[BestDates] No usable imagery for %s, skipping", period)
+			continue
+		}
+		log.Printf("[BestDates] %s -> %s (score %.2f, in season: %v)", period, best.DateInfo.Date, best.Score, best.InSeason)
+		selected = append(selected, *best)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no usable imagery found for this area")
+	}
+
+	a.emitLog(fmt.Sprintf("Selected %d dates from %d candidates (%s)", len(selected), len(candidates), strategy))
+	return selected, nil
+}
+
+// bestDateCandidates lists the dates available for the area from one source
+func (a *App) bestDateCandidates(bbox BoundingBox, zoom int, source string) ([]GEDateInfo, error) {
+	switch source {
+	case string(SourceEsriWayback):
+		dates, err := a.GetAvailableDatesForArea(bbox, zoom)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]GEDateInfo, len(dates))
+		for i, d := range dates {
+			candidates[i] = GEDateInfo{Date: d.Date, Source: string(SourceEsriWayback)}
+		}
+		return candidates, nil
+	case string(SourceGoogleEarth):
+		dates, err := a.GetGoogleEarthDatesForArea(bbox, zoom)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]GEDateInfo, len(dates))
+		for i, d := range dates {
+			candidates[i] = GEDateInfo{Date: d.Date, HexDate: d.HexDate, Epoch: d.Epoch, Source: string(SourceGoogleEarth)}
+		}
+		return candidates, nil
+	default:
+		return nil, fmt.Errorf("unsupported source for date selection: %s", source)
+	}
+}
+
+// scoreDate averages the tile quality score over the sample points that could be fetched
+// and decoded; ok is false when none could, so a stale epoch or a network blip leaves the
+// date unscored instead of blank. Google Earth samples go through the tile server, with its
+// epoch fallback and tile cache, as downloads do.
+func (a *App) scoreDate(source string, date GEDateInfo, points []struct{ lat, lon float64 }, zoom int) (score float64, ok bool) {
+	var layer *esriClient.Layer
+	if source == string(SourceEsriWayback) {
+		var err error
+		if layer, err = a.findLayerForDate(date.Date); err != nil {
+			return 0, false
+		}
+	} else if a.tileServer == nil {
+		return 0, false
+	}
+
+	// Google Earth tiles are sampled at zoom 16 or lower for epoch stability (see GetGoogleEarthDatesForArea)
+	geZoom := zoom
+	if geZoom > 16 {
+		geZoom = 16
+	}
+
+	scores := make([]float64, len(points))
+	decoded := make([]bool, len(points))
+	var wg sync.WaitGroup
+	for i, point := range points {
+		wg.Add(1)
+		go func(i int, lat, lon float64) {
+			defer wg.Done()
+
+			var data []byte
+			var err error
+			if layer != nil {
+				var tile *esriClient.EsriTile
+				if tile, err = esriClient.GetTileForWgs84(lat, lon, zoom); err == nil {
+					data, err = a.esriClient.FetchTile(layer, tile)
+				}
+			} else {
+				var tile *googleearth.Tile
+				if tile, err = googleearth.GetTileForCoord(lat, lon, geZoom); err == nil {
+					data, _, err = a.tileServer.FetchHistoricalGETileWithZoomFallback(tile, date.Date, date.HexDate, 0)
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			if score, err := downloads.TileQualityScore(data); err == nil {
+				scores[i], decoded[i] = score, true
+			}
+		}(i, point.lat, point.lon)
+	}
+	wg.Wait()
+
+	total, count := 0.0, 0
+	for i, s := range scores {
+		if decoded[i] {
+			total += s
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// summerMonths returns the local summer months for a latitude
+func summerMonths(lat float64) map[time.Month]bool {
+	if lat < 0 {
+		return map[time.Month]bool{time.December: true, time.January: true, time.February: true}
+	}
+	return map[time.Month]bool{time.June: true, time.July: true, time.August: true}
+}
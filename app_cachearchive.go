@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/cache"
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/tilemath"
+)
+
+// CacheImportResult reports what ImportCacheArchive added to the tile cache
+type CacheImportResult struct {
+	Path     string `json:"path"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped"` // Already cached with a newer copy, or past the cache TTL
+}
+
+// ExportCacheArchive writes the tile cache, or only the tiles covering bbox when one is
+// given, to a zip archive chosen in a save dialog, so a machine without connectivity can
+// be seeded with ImportCacheArchive. Returns the archive's path ("" if the user cancelled).
+func (a *App) ExportCacheArchive(bbox *BoundingBox) (path string, err error) {
+	defer crash.Recover("ExportCacheArchive", &err)
+
+	if a.tileCache == nil {
+		return "", fmt.Errorf("tile cache is disabled")
+	}
+	var include func(*cache.TileMetadata) bool
+	if bbox != nil {
+		box := bbox.toDownloadsBBox()
+		if err := box.Validate(); err != nil {
+			return "", fmt.Errorf("invalid coordinates: %w", err)
+		}
+		include = func(meta *cache.TileMetadata) bool { return cachedTileInBBox(meta, box) }
+	}
+
+	path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:            "Export Tile Cache",
+		DefaultDirectory: a.GetDownloadPath(),
+		DefaultFilename:  fmt.Sprintf("imagery-desktop-cache_%s.zip", time.Now().Format("20060102")),
+		Filters:          []wailsRuntime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	ctx, done, err := a.beginDownload("ExportCacheArchive")
+	if err != nil {
+		return "", err
+	}
+	defer done(&err)
+
+	tmp := path + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp) // No-op once renamed
+
+	tiles, err := a.tileCache.ExportArchive(ctx, out, include, func(n, total int) {
+		downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
+			Downloaded: n,
+			Total:      total,
+			Percent:    n * 100 / total,
+			Status:     fmt.Sprintf("Exporting cached tiles (%d/%d)", n, total),
+		})
+	})
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	log.Printf("[Cache] Exported %d tiles to %s", tiles, path)
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Exported %d cached tile(s) to %s", tiles, path))
+	return path, nil
+}
+
+// ImportCacheArchive adds the tiles of an archive written by ExportCacheArchive, chosen
+// in an open dialog, to the tile cache. Returns nil if the user cancelled.
+func (a *App) ImportCacheArchive() (result *CacheImportResult, err error) {
+	defer crash.Recover("ImportCacheArchive", &err)
+
+	if a.tileCache == nil {
+		return nil, fmt.Errorf("tile cache is disabled")
+	}
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title:   "Import Tile Cache",
+		Filters: []wailsRuntime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	ctx, done, err := a.beginDownload("ImportCacheArchive")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	imported, skipped, err := a.tileCache.ImportArchive(ctx, path, func(n, total int) {
+		downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
+			Downloaded: n,
+			Total:      total,
+			Percent:    n * 100 / total,
+			Status:     fmt.Sprintf("Importing cached tiles (%d/%d)", n, total),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Cache] Imported %d tiles from %s (%d skipped)", imported, path, skipped)
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Imported %d cached tile(s), %d skipped", imported, skipped))
+	return &CacheImportResult{Path: path, Imported: imported, Skipped: skipped}, nil
+}
+
+// cachedTileInBBox reports whether a cached tile overlaps the area. Google Earth tiles are
+// Plate Carrée rows and columns; every other provider caches XYZ tiles.
+func cachedTileInBBox(meta *cache.TileMetadata, box downloads.BoundingBox) bool {
+	var minCol, minRow, maxCol, maxRow int
+	if meta.Provider == common.ProviderGoogleEarth {
+		minRow, minCol, maxRow, maxCol = tilemath.GERange(box.South, box.West, box.North, box.East, meta.Z)
+	} else {
+		minCol, minRow, maxCol, maxRow = tilemath.XYZRange(box.South, box.West, box.North, box.East, meta.Z)
+	}
+	if meta.Y < minRow || meta.Y > maxRow {
+		return false
+	}
+	// Ranges across the antimeridian continue past the last column
+	col, n := meta.X, tilemath.NumTiles(meta.Z)
+	return (col >= minCol && col <= maxCol) || (col+n >= minCol && col+n <= maxCol)
+}
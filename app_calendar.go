@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Acquisition calendar (Wails-exported)
+// Exports the imagery timeline of an area as an iCalendar file so imagery dates can be lined
+// up with ground photos and reports in any calendar app
+
+// ExportAvailabilityCalendar writes an .ics file with one all-day event per imagery date of
+// the area (see GetImageryTimeline), listing the sources, coverage and Esri capture dates.
+// Returns the path of the written calendar ("" if the user cancelled).
+func (a *App) ExportAvailabilityCalendar(bbox BoundingBox, zoom int) (path string, err error) {
+	defer crash.Recover("ExportAvailabilityCalendar", &err)
+
+	if err := downloads.ValidateCoordinates(bbox.toDownloadsBBox(), zoom); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+
+	path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:            "Save Imagery Calendar",
+		DefaultDirectory: a.GetDownloadPath(),
+		DefaultFilename:  fmt.Sprintf("imagery_dates_z%d_%s.ics", zoom, time.Now().Format("20060102_150405")),
+		Filters:          []wailsRuntime.FileFilter{{DisplayName: "iCalendar Files (*.ics)", Pattern: "*.ics"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	timeline, err := a.GetImageryTimeline(bbox, zoom)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(buildCalendar(timeline, bbox, time.Now())), 0644); err != nil {
+		return "", fmt.Errorf("failed to write calendar: %w", err)
+	}
+
+	a.emitLog(fmt.Sprintf("Calendar with %d imagery dates saved", len(timeline.Dates)))
+	return path, nil
+}
+
+// buildCalendar renders the timeline as an RFC 5545 calendar
+func buildCalendar(timeline *ImageryTimeline, bbox BoundingBox, now time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICSLine(s))
+		b.WriteString("\r\n")
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	area := fmt.Sprintf("%.5f,%.5f,%.5f,%.5f", bbox.South, bbox.West, bbox.North, bbox.East)
+	centerLat := (bbox.South + bbox.North) / 2
+	centerLon := bbox.toDownloadsBBox().LonAt(0.5)
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//walkthru.earth//Imagery Desktop//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + escapeICSText("Imagery dates "+area))
+
+	for _, td := range timeline.Dates {
+		day, err := time.Parse("2006-01-02", td.Date)
+		if err != nil {
+			continue
+		}
+
+		names := make([]string, len(td.Sources))
+		var details []string
+		for i, src := range td.Sources {
+			names[i] = common.ProviderDisplayName(src.Source)
+			detail := fmt.Sprintf("%s: %.0f%% of sampled tiles", names[i], src.Coverage*100)
+			if src.CaptureDate != "" && src.CaptureDate != td.Date {
+				detail += ", captured " + src.CaptureDate
+			}
+			if src.HexDate != "" {
+				detail += fmt.Sprintf(", epoch %d, hexDate %s", src.Epoch, src.HexDate)
+			}
+			details = append(details, detail)
+		}
+
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:%s-%s@imagery-desktop", day.Format("20060102"), strings.ReplaceAll(area, ",", "_")))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + day.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText("Imagery: "+strings.Join(names, ", ")))
+		line("DESCRIPTION:" + escapeICSText(strings.Join(details, "\n")+"\nArea (S,W,N,E): "+area))
+		line(fmt.Sprintf("GEO:%.6f;%.6f", centerLat, centerLon))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT value (RFC 5545 3.3.11)
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine folds a content line longer than 75 octets onto continuation lines starting
+// with a space, without splitting UTF-8 sequences (RFC 5545 3.1)
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	esriClient "imagery-desktop/internal/esri"
+	"imagery-desktop/internal/tilemath"
+)
+
+// Capture date mosaic (Wails-exported)
+// A Wayback release stitches imagery acquired on different dates; this maps which date
+// covers which part of an area
+
+const (
+	defaultCaptureDateGrid = 8
+	maxCaptureDateGrid     = 32
+	captureDateWorkers     = 10
+)
+
+// captureDatePalette colors capture dates from oldest to newest (viridis)
+var captureDatePalette = [][3]float64{
+	{0x44, 0x01, 0x54},
+	{0x3b, 0x52, 0x8b},
+	{0x21, 0x91, 0x8c},
+	{0x5e, 0xc9, 0x62},
+	{0xfd, 0xe7, 0x25},
+}
+
+// CaptureDateMosaic is a GeoJSON FeatureCollection of grid cells colored by capture date
+type CaptureDateMosaic struct {
+	Type         string              `json:"type"` // Always "FeatureCollection"
+	Features     []CaptureDateCell   `json:"features"`
+	Legend       []CaptureDateLegend `json:"legend"` // Distinct capture dates, oldest first
+	Release      int                 `json:"release"`
+	Grid         int                 `json:"grid"`         // Cells per side
+	CellsUnknown int                 `json:"cellsUnknown"` // Cells without metadata or whose query failed
+}
+
+// CaptureDateCell is a GeoJSON Feature for one grid cell
+type CaptureDateCell struct {
+	Type       string                    `json:"type"` // Always "Feature"
+	Geometry   GeoJSONPolygon            `json:"geometry"`
+	Properties CaptureDateCellProperties `json:"properties"`
+}
+
+// CaptureDateCellProperties holds the capture date of a cell and its display color
+type CaptureDateCellProperties struct {
+	Row         int    `json:"row"`
+	Col         int    `json:"col"`
+	CaptureDate string `json:"captureDate"` // YYYY-MM-DD
+	Color       string `json:"color"`       // #rrggbb
+}
+
+// CaptureDateLegend is one capture date of the mosaic
+type CaptureDateLegend struct {
+	CaptureDate string  `json:"captureDate"`
+	Color       string  `json:"color"`
+	Cells       int     `json:"cells"`
+	Coverage    float64 `json:"coverage"` // Fraction of the grid cells (0-1)
+}
+
+// GetCaptureDateMosaic queries the Esri metadata at the center of each cell of a grid x grid
+// grid over bbox and returns the cells as GeoJSON colored by acquisition date (oldest dark,
+// newest bright), for the Wayback release of date at zoom. grid 0 uses the default.
+func (a *App) GetCaptureDateMosaic(bbox BoundingBox, zoom int, date string, grid int) (mosaic *CaptureDateMosaic, err error) {
+	defer crash.Recover("GetCaptureDateMosaic", &err)
+
+	box := bbox.toDownloadsBBox()
+	if err := downloads.ValidateCoordinates(box, zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if grid <= 0 {
+		grid = defaultCaptureDateGrid
+	}
+	if grid > maxCaptureDateGrid {
+		return nil, fmt.Errorf("grid must be at most %d", maxCaptureDateGrid)
+	}
+	layer, err := a.findLayerForDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	type cellResult struct {
+		row, col int
+		date     time.Time
+		err      error
+	}
+	cellChan := make(chan [2]int, grid*grid)
+	resultChan := make(chan cellResult, grid*grid)
+
+	lonSpan := box.LonSpan()
+	cellCenter := func(row, col int) esriClient.WebMercator {
+		lat := bbox.North - (bbox.North-bbox.South)*(float64(row)+0.5)/float64(grid)
+		lon := tilemath.WrapLongitude(bbox.West + lonSpan*(float64(col)+0.5)/float64(grid))
+		return esriClient.Wgs84{Lat: lat, Lon: lon}.ToWebMercator()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < captureDateWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer crash.Recover("CaptureDateMosaic", nil)
+			for cell := range cellChan {
+				captureDate, err := a.esriClient.GetCaptureDate(layer, cellCenter(cell[0], cell[1]), zoom)
+				resultChan <- cellResult{row: cell[0], col: cell[1], date: captureDate, err: err}
+			}
+		}()
+	}
+	for row := 0; row < grid; row++ {
+		for col := 0; col < grid; col++ {
+			cellChan <- [2]int{row, col}
+		}
+	}
+	close(cellChan)
+	wg.Wait()
+	close(resultChan)
+
+	var cells []cellResult
+	var lastErr error
+	for result := range resultChan {
+		if result.err != nil {
+			lastErr = result.err
+		}
+		if result.err == nil && !result.date.IsZero() {
+			cells = append(cells, result)
+		}
+	}
+	if len(cells) == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("failed to query imagery metadata: %w", lastErr)
+		}
+		return nil, fmt.Errorf("no capture dates found for this area")
+	}
+	if lastErr != nil {
+		log.Printf("[CaptureDates] Some metadata queries failed: %v", lastErr)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].row != cells[j].row {
+			return cells[i].row < cells[j].row
+		}
+		return cells[i].col < cells[j].col
+	})
+
+	// Colors follow each date's position between the oldest and newest capture
+	earliest, latest := cells[0].date, cells[0].date
+	for _, cell := range cells {
+		if cell.date.Before(earliest) {
+			earliest = cell.date
+		}
+		if cell.date.After(latest) {
+			latest = cell.date
+		}
+	}
+
+	mosaic = &CaptureDateMosaic{
+		Type:         "FeatureCollection",
+		Features:     make([]CaptureDateCell, 0, len(cells)),
+		Release:      layer.ID,
+		Grid:         grid,
+		CellsUnknown: grid*grid - len(cells),
+	}
+	legend := make(map[string]*CaptureDateLegend)
+	cellHeight := (bbox.North - bbox.South) / float64(grid)
+	cellWidth := lonSpan / float64(grid)
+	for _, cell := range cells {
+		day := cell.date.UTC().Format("2006-01-02")
+		color := captureDateColor(cell.date, earliest, latest)
+
+		// Longitudes stay unwrapped so cells of an antimeridian-crossing area remain contiguous
+		north := bbox.North - cellHeight*float64(cell.row)
+		west := bbox.West + cellWidth*float64(cell.col)
+		mosaic.Features = append(mosaic.Features, CaptureDateCell{
+			Type:     "Feature",
+			Geometry: rectPolygon(north-cellHeight, west, north, west+cellWidth),
+			Properties: CaptureDateCellProperties{
+				Row:         cell.row,
+				Col:         cell.col,
+				CaptureDate: day,
+				Color:       color,
+			},
+		})
+
+		entry, ok := legend[day]
+		if !ok {
+			entry = &CaptureDateLegend{CaptureDate: day, Color: color}
+			legend[day] = entry
+		}
+		entry.Cells++
+	}
+
+	for _, entry := range legend {
+		entry.Coverage = float64(entry.Cells) / float64(grid*grid)
+		mosaic.Legend = append(mosaic.Legend, *entry)
+	}
+	sort.Slice(mosaic.Legend, func(i, j int) bool {
+		return mosaic.Legend[i].CaptureDate < mosaic.Legend[j].CaptureDate
+	})
+
+	return mosaic, nil
+}
+
+// captureDateColor interpolates the palette at date's position between earliest and latest
+func captureDateColor(date, earliest, latest time.Time) string {
+	t := 1.0
+	if span := latest.Sub(earliest); span > 0 {
+		t = float64(date.Sub(earliest)) / float64(span)
+	}
+
+	pos := t * float64(len(captureDatePalette)-1)
+	i := min(int(pos), len(captureDatePalette)-2)
+	f := pos - float64(i)
+	from, to := captureDatePalette[i], captureDatePalette[i+1]
+	return fmt.Sprintf("#%02x%02x%02x",
+		int(from[0]+(to[0]-from[0])*f+0.5),
+		int(from[1]+(to[1]-from[1])*f+0.5),
+		int(from[2]+(to[2]-from[2])*f+0.5))
+}
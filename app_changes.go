@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	esriClient "imagery-desktop/internal/esri"
+)
+
+// Wayback local changes (Wails-exported)
+// Shows which tiles of an area got new imagery in a Wayback release before downloading it
+
+const (
+	// maxFootprintTiles bounds the tilemap requests of one GetChangedTileFootprints call
+	maxFootprintTiles = 2048
+
+	// footprintWorkers is the number of concurrent tilemap requests
+	footprintWorkers = 10
+)
+
+// TileFootprintCollection is a GeoJSON FeatureCollection of tile footprints
+type TileFootprintCollection struct {
+	Type         string          `json:"type"` // Always "FeatureCollection"
+	Features     []TileFootprint `json:"features"`
+	TilesChecked int             `json:"tilesChecked"` // Tiles in the area whose tilemap was read
+	TilesFailed  int             `json:"tilesFailed"`  // Tiles whose tilemap request failed
+}
+
+// TileFootprint is a GeoJSON Feature outlining one tile
+type TileFootprint struct {
+	Type       string                  `json:"type"` // Always "Feature"
+	Geometry   GeoJSONPolygon          `json:"geometry"`
+	Properties TileFootprintProperties `json:"properties"`
+}
+
+// GeoJSONPolygon is a GeoJSON Polygon in WGS84 longitude/latitude
+type GeoJSONPolygon struct {
+	Type        string        `json:"type"` // Always "Polygon"
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+// rectPolygon returns the GeoJSON polygon of a longitude/latitude rectangle
+func rectPolygon(south, west, north, east float64) GeoJSONPolygon {
+	return GeoJSONPolygon{
+		Type: "Polygon",
+		// Counter-clockwise exterior ring, closed
+		Coordinates: [][][]float64{{
+			{west, south}, {east, south}, {east, north}, {west, north}, {west, south},
+		}},
+	}
+}
+
+// TileFootprintProperties identifies the tile and release of a footprint
+type TileFootprintProperties struct {
+	Z       int    `json:"z"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Release int    `json:"release"` // Wayback release number
+	Date    string `json:"date"`    // Release date (YYYY-MM-DD)
+}
+
+// GetChangedTileFootprints returns the footprints of the tiles in bbox at zoom whose imagery
+// changed in the Wayback release of date (a layer date from GetAvailableDatesForArea), as
+// GeoJSON for the preview map. Tiles the release only carries over from an earlier one are
+// left out.
+func (a *App) GetChangedTileFootprints(bbox BoundingBox, zoom int, date string) (footprints *TileFootprintCollection, err error) {
+	defer crash.Recover("GetChangedTileFootprints", &err)
+
+	if err := downloads.ValidateCoordinates(bbox.toDownloadsBBox(), zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	layer, err := a.findLayerForDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	tiles, err := esriClient.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiles) > maxFootprintTiles {
+		return nil, fmt.Errorf("area covers %d tiles at zoom %d (max %d); zoom out or select a smaller area", len(tiles), zoom, maxFootprintTiles)
+	}
+
+	type tileResult struct {
+		tile    *esriClient.EsriTile
+		changed bool
+		err     error
+	}
+	tileChan := make(chan *esriClient.EsriTile, len(tiles))
+	resultChan := make(chan tileResult, len(tiles))
+
+	var wg sync.WaitGroup
+	for i := 0; i < footprintWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer crash.Recover("ChangedTileFootprints", nil)
+			for tile := range tileChan {
+				_, changed, err := a.esriClient.TileChanged(layer, tile)
+				resultChan <- tileResult{tile: tile, changed: changed, err: err}
+			}
+		}()
+	}
+	for _, tile := range tiles {
+		tileChan <- tile
+	}
+	close(tileChan)
+	wg.Wait()
+	close(resultChan)
+
+	footprints = &TileFootprintCollection{Type: "FeatureCollection", Features: []TileFootprint{}}
+	var lastErr error
+	for result := range resultChan {
+		if result.err != nil {
+			footprints.TilesFailed++
+			lastErr = result.err
+			continue
+		}
+		footprints.TilesChecked++
+		if result.changed {
+			footprints.Features = append(footprints.Features, tileFootprint(result.tile, layer))
+		}
+	}
+	if footprints.TilesChecked == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to read Wayback tilemaps: %w", lastErr)
+	}
+	// Workers finish in any order; list footprints row by row
+	sort.Slice(footprints.Features, func(i, j int) bool {
+		pi, pj := footprints.Features[i].Properties, footprints.Features[j].Properties
+		if pi.Y != pj.Y {
+			return pi.Y < pj.Y
+		}
+		return pi.X < pj.X
+	})
+	if footprints.TilesFailed > 0 {
+		log.Printf("[Wayback] %d of %d tilemap requests failed: %v", footprints.TilesFailed, len(tiles), lastErr)
+	}
+
+	return footprints, nil
+}
+
+// tileFootprint returns the GeoJSON footprint of a tile in a Wayback release
+func tileFootprint(tile *esriClient.EsriTile, layer *esriClient.Layer) TileFootprint {
+	south, west, north, east := tile.Wgs84Bounds()
+	x, y, z := tile.ToXYZ()
+	return TileFootprint{
+		Type:     "Feature",
+		Geometry: rectPolygon(south, west, north, east),
+		Properties: TileFootprintProperties{
+			Z:       z,
+			X:       x,
+			Y:       y,
+			Release: layer.ID,
+			Date:    layer.Date.Format("2006-01-02"),
+		},
+	}
+}
+
+// ReleaseDiffCollection is a GeoJSON FeatureCollection of the tiles whose imagery differs
+// between two Wayback releases
+type ReleaseDiffCollection struct {
+	Type           string            `json:"type"` // Always "FeatureCollection"
+	Features       []ReleaseDiffTile `json:"features"`
+	TilesChecked   int               `json:"tilesChecked"`   // Tiles in the area compared in both releases
+	TilesFailed    int               `json:"tilesFailed"`    // Tiles whose tilemap request failed
+	TilesUnchanged int               `json:"tilesUnchanged"` // Tiles serving the same imagery in both releases
+}
+
+// ReleaseDiffTile is a GeoJSON Feature outlining one tile that differs between releases
+type ReleaseDiffTile struct {
+	Type       string                `json:"type"` // Always "Feature"
+	Geometry   GeoJSONPolygon        `json:"geometry"`
+	Properties ReleaseDiffProperties `json:"properties"`
+}
+
+// ReleaseDiffProperties identifies the tile and the releases its imagery comes from
+type ReleaseDiffProperties struct {
+	Z      int    `json:"z"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Change string `json:"change"` // "changed", "added" (no imagery in A) or "removed" (no imagery in B)
+
+	// Releases the tile's imagery actually comes from in A and B (0 = no imagery)
+	SourceA int `json:"sourceA"`
+	SourceB int `json:"sourceB"`
+}
+
+// CompareWaybackReleases returns the tiles in bbox at zoom whose imagery differs between
+// the Wayback releases of dateA and dateB (layer dates from GetAvailableDatesForArea), as
+// GeoJSON for the preview map. Only the tilemaps are read: a tile differs when its imagery
+// comes from a different release in each, so an incremental archive of B on top of A only
+// needs these tiles.
+func (a *App) CompareWaybackReleases(bbox BoundingBox, zoom int, dateA, dateB string) (diff *ReleaseDiffCollection, err error) {
+	defer crash.Recover("CompareWaybackReleases", &err)
+
+	if err := downloads.ValidateCoordinates(bbox.toDownloadsBBox(), zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	layerA, err := a.findLayerForDate(dateA)
+	if err != nil {
+		return nil, err
+	}
+	layerB, err := a.findLayerForDate(dateB)
+	if err != nil {
+		return nil, err
+	}
+
+	tiles, err := esriClient.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiles) > maxFootprintTiles {
+		return nil, fmt.Errorf("area covers %d tiles at zoom %d (max %d); zoom out or select a smaller area", len(tiles), zoom, maxFootprintTiles)
+	}
+
+	type tileResult struct {
+		tile             *esriClient.EsriTile
+		sourceA, sourceB int
+		err              error
+	}
+	tileChan := make(chan *esriClient.EsriTile, len(tiles))
+	resultChan := make(chan tileResult, len(tiles))
+
+	var wg sync.WaitGroup
+	for i := 0; i < footprintWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer crash.Recover("CompareWaybackReleases", nil)
+			for tile := range tileChan {
+				result := tileResult{tile: tile}
+				result.sourceA, _, result.err = a.esriClient.TileSourceRelease(layerA, tile)
+				if result.err == nil {
+					result.sourceB, _, result.err = a.esriClient.TileSourceRelease(layerB, tile)
+				}
+				resultChan <- result
+			}
+		}()
+	}
+	for _, tile := range tiles {
+		tileChan <- tile
+	}
+	close(tileChan)
+	wg.Wait()
+	close(resultChan)
+
+	diff = &ReleaseDiffCollection{Type: "FeatureCollection", Features: []ReleaseDiffTile{}}
+	var lastErr error
+	for result := range resultChan {
+		if result.err != nil {
+			diff.TilesFailed++
+			lastErr = result.err
+			continue
+		}
+		diff.TilesChecked++
+		if result.sourceA == result.sourceB {
+			diff.TilesUnchanged++
+			continue
+		}
+
+		change := "changed"
+		switch {
+		case result.sourceA == 0:
+			change = "added"
+		case result.sourceB == 0:
+			change = "removed"
+		}
+		south, west, north, east := result.tile.Wgs84Bounds()
+		x, y, z := result.tile.ToXYZ()
+		diff.Features = append(diff.Features, ReleaseDiffTile{
+			Type:     "Feature",
+			Geometry: rectPolygon(south, west, north, east),
+			Properties: ReleaseDiffProperties{
+				Z: z, X: x, Y: y,
+				Change:  change,
+				SourceA: result.sourceA,
+				SourceB: result.sourceB,
+			},
+		})
+	}
+	if diff.TilesChecked == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to read Wayback tilemaps: %w", lastErr)
+	}
+	// Workers finish in any order; list tiles row by row
+	sort.Slice(diff.Features, func(i, j int) bool {
+		pi, pj := diff.Features[i].Properties, diff.Features[j].Properties
+		if pi.Y != pj.Y {
+			return pi.Y < pj.Y
+		}
+		return pi.X < pj.X
+	})
+	if diff.TilesFailed > 0 {
+		log.Printf("[Wayback] %d of %d tilemap comparisons failed: %v", diff.TilesFailed, len(tiles), lastErr)
+	}
+
+	return diff, nil
+}
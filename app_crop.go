@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/pkg/geotiff"
+)
+
+// CropExport trims a GeoTIFF in the download folder to a new WGS84 bounding box and
+// writes it alongside the original as "<name>_crop.tif" with corrected tiepoints.
+// The crop snaps outward to whole pixels, so no resampling happens.
+func (a *App) CropExport(path string, bbox BoundingBox) (output string, err error) {
+	defer crash.Recover("CropExport", &err)
+
+	// Only touch files the app exported
+	rel, err := filepath.Rel(a.GetDownloadPath(), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not in the download folder", filepath.Base(path))
+	}
+
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+
+	img, geo, err := geotiff.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Crop extent in the file's CRS; the east edge is unwrapped past 180° when crossing the antimeridian
+	east := box.West + box.LonSpan()
+	var minX, minY, maxX, maxY float64
+	switch geo.EPSG {
+	case 4326:
+		minX, minY, maxX, maxY = box.West, box.South, east, box.North
+	case 3857, 0:
+		minX, minY = tilemath.LatLonToMeters(box.South, box.West)
+		maxX, maxY = tilemath.LatLonToMeters(box.North, east)
+	default:
+		return "", fmt.Errorf("cropping EPSG:%d GeoTIFFs is not supported", geo.EPSG)
+	}
+
+	// Exports crossing the antimeridian extend past +180°; shift the crop onto the same side
+	worldWidth := 360.0
+	if geo.EPSG != 4326 {
+		worldWidth = tilemath.Equator
+	}
+	if maxX < geo.OriginX {
+		minX, maxX = minX+worldWidth, maxX+worldWidth
+	}
+
+	pixelHeight := math.Abs(geo.PixelHeight)
+	rect := image.Rect(
+		int(math.Floor((minX-geo.OriginX)/geo.PixelWidth)),
+		int(math.Floor((geo.OriginY-maxY)/pixelHeight)),
+		int(math.Ceil((maxX-geo.OriginX)/geo.PixelWidth)),
+		int(math.Ceil((geo.OriginY-minY)/pixelHeight)),
+	).Add(img.Bounds().Min).Intersect(img.Bounds())
+	if rect.Empty() {
+		return "", fmt.Errorf("the area does not overlap %s", filepath.Base(path))
+	}
+
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return "", fmt.Errorf("%s uses an unsupported pixel layout", filepath.Base(path))
+	}
+	cropped := sub.SubImage(rect)
+
+	offset := rect.Min.Sub(img.Bounds().Min)
+	originX := geo.OriginX + float64(offset.X)*geo.PixelWidth
+	originY := geo.OriginY - float64(offset.Y)*pixelHeight
+
+	output = strings.TrimSuffix(path, filepath.Ext(path)) + "_crop.tif"
+	opts := &geotiff.EncodeOptions{Alpha: geotiff.HasTransparency(cropped), EPSG: geo.EPSG}
+	if err := geotiff.SaveAsGeoTIFFWithOptions(cropped, output, originX, originY, geo.PixelWidth, pixelHeight,
+		"", "", AppVersion, opts); err != nil {
+		return "", fmt.Errorf("failed to save cropped GeoTIFF: %w", err)
+	}
+
+	a.emitLog(fmt.Sprintf("Cropped %s to %dx%d px: %s", filepath.Base(path), rect.Dx(), rect.Dy(), filepath.Base(output)))
+	return output, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/customsource"
+	"imagery-desktop/internal/naip"
+)
+
+// ===================
+// Custom Tile Sources
+// ===================
+
+// CustomSourceInfo describes an enabled custom or API-key source for the map and task dialogs
+type CustomSourceInfo struct {
+	Provider    string   `json:"provider"` // Identifier used as task source, e.g. "custom_my_server" or "mapbox_satellite"
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Attribution string   `json:"attribution,omitempty"`
+	MinZoom     int      `json:"minZoom"`
+	MaxZoom     int      `json:"maxZoom"`
+	Dates       []string `json:"dates"` // Time values, or ["current"] for sources without a time dimension
+}
+
+// GetCustomSources returns the enabled custom sources and the API-key sources with a key set
+func (a *App) GetCustomSources() []CustomSourceInfo {
+	sources := a.customClient.Sources()
+	infos := make([]CustomSourceInfo, 0, len(sources))
+	for provider, source := range sources {
+		infos = append(infos, newCustomSourceInfo(provider, source))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// GetCustomSourceTileURL returns the tile URL template for a custom source (for map preview)
+// Tiles are routed through the backend tile server for caching and proxy support
+func (a *App) GetCustomSourceTileURL(provider string, date string) (string, error) {
+	if a.tileServer == nil || a.tileServer.GetTileServerURL() == "" {
+		return "", fmt.Errorf("tile server not started")
+	}
+	if _, err := a.customSourceDate(provider, date); err != nil {
+		return "", err
+	}
+
+	// Format: http://localhost:PORT/tiles/{provider}/{date}/{z}/{x}/{y}
+	return fmt.Sprintf("%s/tiles/%s/%s/{z}/{x}/{y}", a.tileServer.GetTileServerURL(), provider, date), nil
+}
+
+// DownloadCustomSourceImagery downloads a custom source for a bounding box as georeferenced image
+// format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
+func (a *App) DownloadCustomSourceImagery(provider string, bbox BoundingBox, zoom int, date string, format string) (err error) {
+	defer crash.Recover("DownloadCustomSourceImagery", &err)
+	ctx, done, err := a.beginDownload("DownloadCustomSourceImagery")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	if err := a.downloadCustomSourceImagery(ctx, provider, bbox, zoom, date, format); err != nil {
+		return err
+	}
+
+	// Auto-open download folder
+	a.emitLog("Opening download folder...")
+	if err := a.OpenDownloadFolder(); err != nil {
+		log.Printf("Failed to open download folder: %v", err)
+	}
+
+	return nil
+}
+
+// downloadCustomSourceImagery downloads one date of a custom source within the operation in ctx
+func (a *App) downloadCustomSourceImagery(ctx context.Context, provider string, bbox BoundingBox, zoom int, date string, format string) error {
+	if _, err := a.customSourceDate(provider, date); err != nil {
+		return err
+	}
+	return a.customDownloader.DownloadImagery(ctx, provider, bbox.toDownloadsBBox(), zoom, date, format)
+}
+
+// GetNAIPYearsForArea returns the NAIP acquisition years covering an area, newest first.
+// NAIP only covers the contiguous US; other areas return no dates.
+func (a *App) GetNAIPYearsForArea(bbox BoundingBox) ([]AvailableDate, error) {
+	return a.listProviderDates(common.ProviderNAIP, bbox, naip.MinZoom)
+}
+
+// customSourceDate checks that a provider is an enabled custom source serving date
+func (a *App) customSourceDate(provider, date string) (config.CustomSource, error) {
+	if !common.IsTemplateProvider(provider) {
+		return config.CustomSource{}, fmt.Errorf("not a custom or API-key source: %s", provider)
+	}
+	source, err := a.customClient.Get(provider)
+	if err != nil {
+		return config.CustomSource{}, err
+	}
+	for _, d := range customsource.Dates(source) {
+		if d == date {
+			return source, nil
+		}
+	}
+	return config.CustomSource{}, fmt.Errorf("%s has no time value %q", source.Name, date)
+}
+
+// newCustomSourceInfo converts a registered source for the frontend
+func newCustomSourceInfo(provider string, source config.CustomSource) CustomSourceInfo {
+	return CustomSourceInfo{
+		Provider:    provider,
+		Name:        source.Name,
+		Type:        source.Type,
+		Attribution: source.Attribution,
+		MinZoom:     source.MinZoom,
+		MaxZoom:     customsource.MaxZoom(source),
+		Dates:       customsource.Dates(source),
+	}
+}
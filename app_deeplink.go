@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+)
+
+// DeepLinkScheme is the custom URL scheme the app registers (see "protocols" in wails.json).
+// Web dashboards hand export jobs to the app with links such as
+//
+//	walkthru://export?bbox=-0.15,51.49,-0.11,51.52&source=esri_wayback&zoom=17&from=2019-01-01&to=2024-12-31&format=geotiff&name=London
+//
+// bbox is west,south,east,north in degrees. from and to (YYYY-MM-DD, both optional) bound
+// the dates queued: every date the source has for the area within the range. format
+// defaults to geotiff; name defaults to one built from the source and range.
+const DeepLinkScheme = "walkthru"
+
+// DeepLinkResult is emitted as "deep-link-task" once a link has been handled
+type DeepLinkResult struct {
+	URL    string `json:"url"`
+	TaskID string `json:"taskId,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Dates  int    `json:"dates"`
+	Error  string `json:"error,omitempty"`
+}
+
+// exportLink is a parsed walkthru://export link
+type exportLink struct {
+	Name   string
+	Source string
+	BBox   BoundingBox
+	Zoom   int
+	From   string // YYYY-MM-DD, "" = no lower bound
+	To     string // YYYY-MM-DD, "" = no upper bound
+	Latest int    // Keep only the newest N dates in the range (0 = all)
+	Format string
+}
+
+// handleLaunchArgs opens every deep link among a launch's command-line arguments
+// (Windows and Linux pass the URL as an argument, also when handed over by a second instance)
+func (a *App) handleLaunchArgs(args []string) {
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), DeepLinkScheme+":") {
+			a.openDeepLink(arg)
+		}
+	}
+}
+
+// openDeepLink queues the export described by a deep link in the background (listing the
+// source's dates can take a while) and reports the outcome to the frontend
+func (a *App) openDeepLink(rawURL string) {
+	go func() {
+		defer crash.Recover("DeepLink", nil)
+
+		result := DeepLinkResult{URL: rawURL}
+		taskID, name, dates, err := a.createTaskFromLink(rawURL)
+		if err != nil {
+			log.Printf("[DeepLink] Rejected %s: %v", rawURL, err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("[DeepLink] Queued task %s (%d dates) from %s", taskID, dates, rawURL)
+			a.emitLog(fmt.Sprintf("Queued '%s' (%d dates) from a link", name, dates))
+			result.TaskID, result.Name, result.Dates = taskID, name, dates
+		}
+
+		if a.ctx != nil {
+			wailsRuntime.EventsEmit(a.ctx, "deep-link-task", result)
+		}
+	}()
+}
+
+// createTaskFromLink resolves the dates of an export link and adds the task to the queue.
+// The queue is not started, so the user can review the task first.
+func (a *App) createTaskFromLink(rawURL string) (taskID, name string, dates int, err error) {
+	link, err := parseExportLink(rawURL)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return a.queueExportLink(link)
+}
+
+// queueExportLink lists the source's dates for the link's area, keeps those in its range
+// (only the newest Latest when set) and adds the export task to the queue
+func (a *App) queueExportLink(link exportLink) (taskID, name string, dates int, err error) {
+	provider, err := a.providers.Get(link.Source)
+	if err != nil {
+		return "", "", 0, err
+	}
+	infos, err := provider.ListDates(link.BBox.toDownloadsBBox(), link.Zoom)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to list dates: %w", err)
+	}
+
+	var taskDates []GEDateInfo
+	for _, info := range infos {
+		if (link.From != "" && info.Date < link.From) || (link.To != "" && info.Date > link.To) {
+			continue
+		}
+		taskDates = append(taskDates, GEDateInfo{Date: info.Date, HexDate: info.HexDate, Epoch: info.Epoch, Source: link.Source})
+	}
+	if len(taskDates) == 0 {
+		return "", "", 0, fmt.Errorf("%s has no imagery for this area between %s and %s",
+			provider.Name(), orDefault(link.From, "the first date"), orDefault(link.To, "today"))
+	}
+	if link.Latest > 0 && len(taskDates) > link.Latest {
+		taskDates = taskDates[:link.Latest] // Dates are listed newest first
+	}
+
+	name = link.Name
+	if name == "" {
+		name = fmt.Sprintf("%s %s to %s", provider.Name(), taskDates[len(taskDates)-1].Date, taskDates[0].Date)
+	}
+
+	taskID, err = a.AddExportTask(TaskQueueExportTask{
+		Name:   name,
+		Source: link.Source,
+		BBox:   link.BBox,
+		Zoom:   link.Zoom,
+		Dates:  taskDates,
+		Format: link.Format,
+	})
+	if err != nil {
+		return "", "", 0, err
+	}
+	return taskID, name, len(taskDates), nil
+}
+
+// parseExportLink parses and validates a walkthru://export link
+func parseExportLink(rawURL string) (exportLink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return exportLink{}, fmt.Errorf("invalid link: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, DeepLinkScheme) {
+		return exportLink{}, fmt.Errorf("not a %s:// link", DeepLinkScheme)
+	}
+	// walkthru://export puts the action in the host, walkthru:export in the opaque part
+	action := u.Host
+	if action == "" {
+		action = strings.TrimPrefix(u.Opaque, "//")
+	}
+	if action = strings.Trim(action, "/"); action != "export" {
+		return exportLink{}, fmt.Errorf("unsupported link action %q", action)
+	}
+
+	q := u.Query()
+	link := exportLink{
+		Name:   strings.TrimSpace(q.Get("name")),
+		Source: q.Get("source"),
+		From:   q.Get("from"),
+		To:     q.Get("to"),
+		Format: q.Get("format"),
+	}
+
+	parts := strings.Split(q.Get("bbox"), ",")
+	if len(parts) != 4 {
+		return exportLink{}, fmt.Errorf("bbox must be west,south,east,north")
+	}
+	var coords [4]float64
+	for i, p := range parts {
+		if coords[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return exportLink{}, fmt.Errorf("invalid bbox value %q", p)
+		}
+	}
+	link.BBox = BoundingBox{West: coords[0], South: coords[1], East: coords[2], North: coords[3]}
+	if err := link.BBox.toDownloadsBBox().Validate(); err != nil {
+		return exportLink{}, fmt.Errorf("invalid bbox: %w", err)
+	}
+
+	if link.Zoom, err = strconv.Atoi(q.Get("zoom")); err != nil {
+		return exportLink{}, fmt.Errorf("missing or invalid zoom")
+	}
+
+	if err := link.validate(); err != nil {
+		return exportLink{}, err
+	}
+	return link, nil
+}
+
+// validate checks the source, date range and format of an export, defaulting the format
+// to geotiff (the area and zoom are checked by the caller)
+func (link *exportLink) validate() error {
+	if link.Source == "" {
+		return fmt.Errorf("missing source")
+	}
+	if link.Source == common.ProviderMixed {
+		return fmt.Errorf("mixed-source exports cannot be created from a link or watch folder")
+	}
+
+	for _, d := range []string{link.From, link.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", d)
+		}
+	}
+	if link.From != "" && link.To != "" && link.From > link.To {
+		return fmt.Errorf("from date is after to date")
+	}
+	if link.Latest < 0 {
+		return fmt.Errorf("latest must not be negative")
+	}
+
+	switch link.Format {
+	case "":
+		link.Format = "geotiff"
+	case "tiles", "geotiff", "both":
+	default:
+		return fmt.Errorf("invalid format %q (must be 'tiles', 'geotiff' or 'both')", link.Format)
+	}
+	return nil
+}
+
+// orDefault returns s, or def if s is empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/metrics"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// appDataDir returns the app's data directory: ~/.walkthru-earth/imagery-desktop
+func appDataDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".walkthru-earth", "imagery-desktop")
+}
+
+// configureCrashReporting points crash reports at the app data directory and, when the
+// user has opted in, forwards an anonymized summary of each crash to PostHog
+func (a *App) configureCrashReporting(settings *config.UserSettings) {
+	var send func(props map[string]interface{})
+	if settings.SendCrashReports {
+		send = func(props map[string]interface{}) {
+			a.TrackEvent("app_crash", props)
+		}
+	}
+	crash.Configure(filepath.Join(appDataDir(), "crashes"), AppVersion, send)
+}
+
+// ExportDiagnosticsBundle zips the debug logs, crash reports and settings into a single
+// file for support tickets. Custom source URLs are stripped of query strings, which often
+// carry API keys, and proxy credentials are removed. Returns the path of the written bundle ("" if the user cancelled).
+func (a *App) ExportDiagnosticsBundle() (string, error) {
+	path, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:            "Save Diagnostics Bundle",
+		DefaultDirectory: a.GetDownloadPath(),
+		DefaultFilename:  fmt.Sprintf("imagery-desktop-diagnostics_%s.zip", time.Now().Format("20060102_150405")),
+		Filters:          []wailsRuntime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	if err := a.writeDiagnosticsBundle(path); err != nil {
+		return "", err
+	}
+
+	log.Printf("[Diagnostics] Bundle written to %s", path)
+	return path, nil
+}
+
+// writeDiagnosticsBundle writes the diagnostics zip to path
+func (a *App) writeDiagnosticsBundle(path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+
+	// Logs and crash reports
+	dataDir := appDataDir()
+	for _, dir := range []string{"logs", "crashes"} {
+		entries, err := os.ReadDir(filepath.Join(dataDir, dir))
+		if err != nil {
+			continue // Directory not created yet
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if err := addFileToZip(zw, filepath.Join(dataDir, dir, entry.Name()), dir+"/"+entry.Name()); err != nil {
+				log.Printf("[Diagnostics] Skipping %s: %v", entry.Name(), err)
+			}
+		}
+	}
+
+	// Settings, with credentials removed from custom source URLs, API keys and the proxy
+	a.mu.Lock()
+	settings := *a.settings
+	a.mu.Unlock()
+	settings.CustomSources = append([]config.CustomSource(nil), settings.CustomSources...)
+	for i := range settings.CustomSources {
+		settings.CustomSources[i].URL = redactURL(settings.CustomSources[i].URL)
+	}
+	settings.ProxyURL = redactURL(settings.ProxyURL)
+	if settings.ProxyPassword != "" {
+		settings.ProxyPassword = "REDACTED"
+	}
+	if settings.MapboxAccessToken != "" {
+		settings.MapboxAccessToken = "REDACTED"
+	}
+	if settings.MapTilerAPIKey != "" {
+		settings.MapTilerAPIKey = "REDACTED"
+	}
+	settingsData, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := addBytesToZip(zw, "settings.json", settingsData); err != nil {
+		return err
+	}
+
+	// Per-provider request counters, to spot a slow or failing source
+	statsData, err := json.MarshalIndent(metrics.Snapshot(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal network stats: %w", err)
+	}
+	if err := addBytesToZip(zw, "network-stats.json", statsData); err != nil {
+		return err
+	}
+
+	// Environment summary
+	info := fmt.Sprintf("Version: %s\nOS: %s/%s\nCreated: %s\n", AppVersion, runtime.GOOS, runtime.GOARCH, time.Now().Format(time.RFC3339))
+	if err := addBytesToZip(zw, "info.txt", []byte(info)); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+	return nil
+}
+
+// GetNetworkStats returns per-provider request counts, bytes, error rates, cache hit
+// ratios and average latency since startup (or the last ResetNetworkStats)
+func (a *App) GetNetworkStats() []metrics.ProviderStats {
+	return metrics.Snapshot()
+}
+
+// ResetNetworkStats clears the per-provider network counters
+func (a *App) ResetNetworkStats() {
+	metrics.Reset()
+}
+
+// addFileToZip copies a file into the archive under name
+func addFileToZip(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// addBytesToZip writes data into the archive under name
+func addBytesToZip(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// redactURL drops user info and query parameters from a URL template
+// (string-based, as templates like {z}/{x}/{y} don't round-trip through net/url)
+func redactURL(raw string) string {
+	if i := strings.Index(raw, "?"); i >= 0 {
+		raw = raw[:i] + "?REDACTED"
+	}
+	if i := strings.Index(raw, "://"); i >= 0 {
+		rest := raw[i+3:]
+		host := rest
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			host = rest[:slash]
+		}
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			raw = raw[:i+3] + rest[at+1:]
+		}
+	}
+	return raw
+}
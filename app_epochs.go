@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/tilemath"
+)
+
+// epochProbeSampleTiles caps the tiles requested per probed epoch: the corners and center
+// of the area
+const epochProbeSampleTiles = 5
+
+// EpochDiscovery reports which Google Earth epochs serve an area's tiles for a date
+type EpochDiscovery struct {
+	HexDate  string                   `json:"hexDate"`
+	MinEpoch int                      `json:"minEpoch"`
+	MaxEpoch int                      `json:"maxEpoch"`
+	Sampled  int                      `json:"sampled"` // Tiles requested per epoch
+	Epochs   []googleearth.EpochProbe `json:"epochs"`  // Epochs that served at least one sampled tile, newest first
+	Regions  int                      `json:"regions"` // Regions the epochs were recorded for
+}
+
+// DiscoverGoogleEarthEpochs probes the flatfile endpoint for a sample of the area's tiles
+// at each epoch from maxEpoch down to minEpoch and reports which epochs return tiles for
+// the date. The epochs found are recorded for the sampled regions, where historical
+// fetches fall back to them when advanced epochs are enabled in settings. With a zero
+// maxEpoch the TimeMachine database version is used, with a zero minEpoch the
+// googleearth.DefaultEpochProbeSpan epochs below it.
+func (a *App) DiscoverGoogleEarthEpochs(bbox BoundingBox, zoom int, date GEDateInfo, minEpoch, maxEpoch int) (result *EpochDiscovery, err error) {
+	defer crash.Recover("DiscoverGoogleEarthEpochs", &err)
+
+	if date.HexDate == "" {
+		return nil, fmt.Errorf("date %q has no hex date", date.Date)
+	}
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	tiles, err := epochProbeTiles(box, zoom)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxEpoch == 0 {
+		if maxEpoch, err = a.geClient.TimeMachineVersion(); err != nil {
+			return nil, err
+		}
+	}
+	if minEpoch == 0 {
+		minEpoch = max(1, maxEpoch-googleearth.DefaultEpochProbeSpan+1)
+	}
+
+	ctx, done, err := a.beginDownload("DiscoverGoogleEarthEpochs")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Probing Google Earth epochs %d-%d for %s (%d tiles each)...", minEpoch, maxEpoch, date.Date, len(tiles)))
+	probes, err := a.geClient.ProbeEpochs(ctx, tiles, date.HexDate, minEpoch, maxEpoch, func(n, total int) {
+		downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
+			Downloaded: n,
+			Total:      total,
+			Percent:    n * 100 / total,
+			Status:     fmt.Sprintf("Probing epochs (%d/%d)", n, total),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result = &EpochDiscovery{
+		HexDate:  date.HexDate,
+		MinEpoch: minEpoch,
+		MaxEpoch: maxEpoch,
+		Sampled:  len(tiles),
+		Epochs:   probes,
+	}
+	if a.epochCache != nil && len(probes) > 0 {
+		epochs := make([]int, len(probes))
+		for i, probe := range probes {
+			epochs[i] = probe.Epoch
+		}
+		regions := make(map[string]bool)
+		for _, tile := range tiles {
+			a.epochCache.RecordDiscovered(tile, epochs)
+			regions[tile.Path[:min(len(tile.Path), googleearth.EpochRegionPrefixLength)]] = true
+		}
+		result.Regions = len(regions)
+	}
+
+	log.Printf("[Epochs] %d of epochs %d-%d serve %s (hexDate %s)", len(probes), minEpoch, maxEpoch, date.Date, date.HexDate)
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Found %d epoch(s) serving %s", len(probes), date.Date))
+	return result, nil
+}
+
+// epochProbeTiles returns the Google Earth tiles at the corners and center of the area
+func epochProbeTiles(box downloads.BoundingBox, zoom int) ([]*googleearth.Tile, error) {
+	minRow, minCol, maxRow, maxCol := tilemath.GERange(box.South, box.West, box.North, box.East, zoom)
+	points := [epochProbeSampleTiles][2]int{
+		{(minRow + maxRow) / 2, (minCol + maxCol) / 2},
+		{minRow, minCol}, {minRow, maxCol}, {maxRow, minCol}, {maxRow, maxCol},
+	}
+
+	var tiles []*googleearth.Tile
+	seen := make(map[[2]int]bool)
+	for _, p := range points {
+		row, col := p[0], tilemath.WrapColumn(p[1], zoom)
+		if seen[[2]int{row, col}] {
+			continue
+		}
+		seen[[2]int{row, col}] = true
+		tile, err := googleearth.NewTileFromRowCol(row, col, zoom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tile at zoom %d: %w", zoom, err)
+		}
+		tiles = append(tiles, tile)
+	}
+	return tiles, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/internal/taskqueue"
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/internal/utils/diskspace"
+	"imagery-desktop/internal/video"
+)
+
+// VideoExportEstimate is the dry-run result of a video export
+type VideoExportEstimate struct {
+	SourceWidth  int              `json:"sourceWidth"` // Pixel size of the imagery for the area at the zoom
+	SourceHeight int              `json:"sourceHeight"`
+	Presets      []video.Estimate `json:"presets"` // One per preset exported
+}
+
+// EstimateVideoExport reports what exporting dates with opts would produce for each preset
+// (clip length, output resolution and the part of the imagery in view, frame count and
+// approximate file size) without downloading or encoding anything, so the user can adjust
+// the options before committing to a long encode
+func (a *App) EstimateVideoExport(bbox BoundingBox, zoom int, dates []GEDateInfo, opts VideoExportOptions) (estimate *VideoExportEstimate, err error) {
+	defer crash.Recover("EstimateVideoExport", &err)
+
+	box := bbox.toDownloadsBBox()
+	if err := downloads.ValidateCoordinates(box, zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no dates selected")
+	}
+	switch opts.OutputFormat {
+	case "mp4", "gif", "apng", "webp", "images":
+	default:
+		return nil, fmt.Errorf("invalid video format: %s (must be 'mp4', 'gif', 'apng', 'webp' or 'images')", opts.OutputFormat)
+	}
+
+	// Frames are the merged imagery of the area in Web Mercator pixels at the zoom
+	west, north := tilemath.LatLonToXYZFrac(box.North, box.West, zoom)
+	east, south := tilemath.LatLonToXYZFrac(box.South, box.West+box.LonSpan(), zoom)
+	sourceWidth := int(math.Round((east - west) * 256))
+	sourceHeight := int(math.Round((south - north) * 256))
+
+	return &VideoExportEstimate{
+		SourceWidth:  sourceWidth,
+		SourceHeight: sourceHeight,
+		Presets:      video.EstimateExport(sourceWidth, sourceHeight, toVideoDates(dates), opts.toTimelapseOptions()),
+	}, nil
+}
+
+const (
+	// Assumed until enough requests to a provider were measured this session
+	defaultTileBytes   = 20 * 1024
+	defaultTileLatency = 300 * time.Millisecond
+
+	// minMeasuredRequests is the number of requests to a provider trusted as a measurement
+	minMeasuredRequests = 20
+)
+
+// TaskEstimate is the dry-run result of a queued download task. Sizes and times are
+// estimates: Esri dates are deduplicated with the same sample tiles as the download, but
+// tiles already in the tile cache and incremental archives are not accounted for.
+type TaskEstimate struct {
+	TaskID string `json:"taskId"`
+
+	// Dates in the task, and those the download would skip
+	Dates          int      `json:"dates"`
+	DatesToFetch   int      `json:"datesToFetch"`
+	ResumedDates   int      `json:"resumedDates"`   // Written before an interruption (checkpointed)
+	DuplicateDates []string `json:"duplicateDates"` // Esri dates identical to an earlier one at every sample tile
+	BlankDates     []string `json:"blankDates"`     // Esri dates with no coverage at the zoom
+
+	TilesPerDate int   `json:"tilesPerDate"`
+	TotalTiles   int   `json:"totalTiles"`
+	TileBytes    int64 `json:"tileBytes"`    // Average bytes per tile
+	NetworkBytes int64 `json:"networkBytes"` // Tiles downloaded
+
+	// Wall time at the measured throughput: download workers divided by the mean latency
+	// of this session's requests to the provider
+	TilesPerSecond     float64 `json:"tilesPerSecond"`
+	ThroughputMeasured bool    `json:"throughputMeasured"` // False: defaults were assumed (no measurements yet)
+	EstimatedSeconds   float64 `json:"estimatedSeconds"`
+
+	// Disk needed by the outputs (tiles and/or GeoTIFFs with overviews and PNG copies),
+	// plus the tile cache growth, against the free space where the task writes
+	OutputBytes     int64  `json:"outputBytes"`
+	CacheBytes      int64  `json:"cacheBytes"`
+	OutputPath      string `json:"outputPath"`
+	FreeBytes       int64  `json:"freeBytes"` // -1 when unknown
+	EnoughDiskSpace bool   `json:"enoughDiskSpace"`
+}
+
+// EstimateTask reports what running the download task id would transfer, take and write
+// without downloading it, so week-long jobs can be sanity-checked before starting them.
+// Esri dates are fingerprinted with sample tiles to skip duplicates, as the download does.
+func (a *App) EstimateTask(id string) (estimate *TaskEstimate, err error) {
+	defer crash.Recover("EstimateTask", &err)
+
+	task, err := a.taskQueue.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	box := BoundingBox(task.BBox).toDownloadsBBox()
+	if err := downloads.ValidateCoordinates(box, task.Zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+
+	estimate = &TaskEstimate{
+		TaskID:         task.ID,
+		Dates:          len(task.Dates),
+		DuplicateDates: []string{},
+		BlankDates:     []string{},
+		OutputPath:     task.OutputPath,
+		FreeBytes:      -1,
+	}
+	if estimate.OutputPath == "" {
+		estimate.OutputPath = a.GetDownloadPath()
+	}
+	if task.IsVideoOnly() {
+		// Reuses the imagery of its dependency
+		estimate.EnoughDiskSpace = true
+		return estimate, nil
+	}
+
+	scheme := providers.SchemeXYZ
+	if task.Source == common.ProviderGoogleEarth {
+		scheme = providers.SchemeGoogleEarth
+	}
+	estimate.TilesPerDate = previewTileCount(scheme, box, task.Zoom)
+
+	// Dates the download would fetch, deduplicating Esri dates like ExecuteExportTask
+	checkpointed := a.taskQueue.CheckpointedDates(task.ID)
+	esriSeen := make(map[string]bool)
+	esriSamples, _ := a.esriDownloader.SampleTiles(box, task.Zoom)
+	fetchedBy := make(map[string]int) // Dates fetched per source
+	for _, d := range task.Dates {
+		source := task.Source
+		if source == common.ProviderMixed {
+			source = d.Source
+		}
+
+		if source == common.ProviderEsriWayback && len(esriSamples) > 0 {
+			if layer, err := a.findLayerForDate(d.Date); err == nil {
+				hash, blank, err := a.esriDownloader.FingerprintArea(a.ctx, layer, esriSamples)
+				switch {
+				case err != nil:
+				case blank:
+					estimate.BlankDates = append(estimate.BlankDates, d.Date)
+					continue
+				case esriSeen[hash]:
+					estimate.DuplicateDates = append(estimate.DuplicateDates, d.Date)
+					continue
+				default:
+					esriSeen[hash] = true
+				}
+			}
+		}
+		if checkpointed[taskqueue.CheckpointKey(source, d.Date)] {
+			estimate.ResumedDates++
+			continue
+		}
+		fetchedBy[source]++
+		estimate.DatesToFetch++
+	}
+	estimate.TotalTiles = estimate.TilesPerDate * estimate.DatesToFetch
+
+	// Bytes and time per source, from this session's requests when there are enough
+	measured := make(map[string]metrics.ProviderStats)
+	for _, s := range metrics.Snapshot() {
+		measured[s.Provider] = s
+	}
+	estimate.ThroughputMeasured = len(fetchedBy) > 0
+	for source, dates := range fetchedBy {
+		tiles := int64(estimate.TilesPerDate * dates)
+		tileBytes, latency := int64(defaultTileBytes), defaultTileLatency
+		stats, ok := measured[source]
+		if !ok && common.IsTemplateProvider(source) {
+			stats, ok = measured["custom"]
+		}
+		if ok && stats.Requests >= minMeasuredRequests {
+			tileBytes = stats.BytesReceived / stats.Requests
+			latency = time.Duration(stats.AvgLatencyMs * float64(time.Millisecond))
+		} else {
+			estimate.ThroughputMeasured = false
+		}
+		estimate.NetworkBytes += tiles * tileBytes
+		if latency > 0 {
+			estimate.EstimatedSeconds += float64(tiles) * latency.Seconds() / float64(downloads.DefaultWorkers)
+		}
+	}
+	if estimate.TotalTiles > 0 {
+		estimate.TileBytes = estimate.NetworkBytes / int64(estimate.TotalTiles)
+	}
+	if estimate.EstimatedSeconds > 0 {
+		estimate.TilesPerSecond = float64(estimate.TotalTiles) / estimate.EstimatedSeconds
+	}
+
+	// Outputs: the tiles as fetched and/or uncompressed RGB GeoTIFFs
+	a.mu.Lock()
+	overviews, pngCopies := a.settings.GeoTIFFOverviews, a.settings.SavePNGSidecars
+	a.mu.Unlock()
+	if task.Format != "geotiff" {
+		estimate.OutputBytes += estimate.NetworkBytes
+	}
+	if task.Format != "tiles" {
+		raster := int64(estimate.TotalTiles) * downloads.TileSize * downloads.TileSize * 3
+		estimate.OutputBytes += raster
+		if overviews {
+			estimate.OutputBytes += raster / 3 // 1/4 + 1/16 + ...
+		}
+		if pngCopies {
+			estimate.OutputBytes += raster / 2
+		}
+	}
+	if a.tileCache != nil {
+		estimate.CacheBytes = estimate.NetworkBytes
+	}
+
+	estimate.EnoughDiskSpace = true
+	if free, err := diskspace.Free(existingParent(estimate.OutputPath)); err == nil {
+		estimate.FreeBytes = int64(free)
+		estimate.EnoughDiskSpace = estimate.FreeBytes >= estimate.OutputBytes
+	}
+	return estimate, nil
+}
+
+// existingParent returns path, or its closest existing parent (folders not created yet)
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	esriClient "imagery-desktop/internal/esri"
+	"imagery-desktop/internal/googleearth"
+)
+
+// Provider health states
+const (
+	HealthReachable   = "reachable"
+	HealthRateLimited = "rate_limited" // Reachable, but throttling this client
+	HealthBlocked     = "blocked"      // Refused by a firewall or proxy (HTTP 403/407/451, reset connection, intercepted TLS)
+	HealthTimeout     = "timeout"
+	HealthUnreachable = "unreachable" // DNS failure or no route
+	HealthError       = "error"       // Answered with another unexpected status
+)
+
+// healthCheckTimeout bounds each provider check
+const healthCheckTimeout = 10 * time.Second
+
+// ProviderHealth is the result of checking one provider endpoint
+type ProviderHealth struct {
+	Provider   string `json:"provider"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Status     string `json:"status"` // See the Health* states
+	Reachable  bool   `json:"reachable"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+	Hint       string `json:"hint,omitempty"` // What to check when not reachable
+}
+
+// CheckProviderHealth requests the Esri Wayback capabilities, the Google Earth dbRoot and
+// the TimeMachine dbRoot concurrently through the configured proxy and TLS settings, and
+// reports each endpoint's latency and whether it is reachable or blocked. Meant for
+// diagnosing installs behind corporate firewalls.
+func (a *App) CheckProviderHealth() (results []ProviderHealth, err error) {
+	defer crash.Recover("CheckProviderHealth", &err)
+
+	checks := []struct {
+		provider, name, url string
+		ping                func(context.Context) (int, error)
+	}{
+		{common.ProviderEsriWayback, common.DisplayNameEsriWayback, esriClient.WayBackCapabilitiesURL, a.esriClient.PingCapabilities},
+		{common.ProviderGoogleEarth, common.DisplayNameGoogleEarth, googleearth.DatabaseURL, a.geClient.PingDatabase},
+		{common.ProviderGoogleEarth, common.DisplayNameGoogleEarth + " (historical)", googleearth.TimeMachineDatabaseURL, a.geClient.PingTimeMachineDatabase},
+	}
+
+	results = make([]ProviderHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			status, err := check.ping(ctx)
+			result := ProviderHealth{
+				Provider:   check.provider,
+				Name:       check.name,
+				URL:        check.url,
+				HTTPStatus: status,
+				LatencyMs:  time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			result.Status, result.Hint = classifyHealth(status, err, a.rateLimitHandler.IsRateLimited(check.provider))
+			result.Reachable = result.Status == HealthReachable || result.Status == HealthRateLimited
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		log.Printf("[Health] %s: %s (HTTP %d, %d ms) %s", r.Name, r.Status, r.HTTPStatus, r.LatencyMs, r.Error)
+	}
+	return results, nil
+}
+
+// classifyHealth maps a check's HTTP status or error to a health state and a hint.
+// Google answers rate limiting with 403 too, so a 403 counts as blocked only while the
+// provider is not known to be rate limited.
+func classifyHealth(status int, err error, rateLimited bool) (state, hint string) {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	switch {
+	case err == nil && status == http.StatusOK:
+		return HealthReachable, ""
+	case err == nil && (status == http.StatusTooManyRequests || (status == http.StatusForbidden && rateLimited)):
+		return HealthRateLimited, "The provider is throttling requests; wait before downloading again"
+	case err == nil && status == http.StatusProxyAuthRequired:
+		return HealthBlocked, "The proxy requires credentials; set them in the proxy settings"
+	case err == nil && (status == http.StatusForbidden || status == http.StatusUnavailableForLegalReasons):
+		return HealthBlocked, "The request was refused, likely by a firewall or web filter; ask for the host to be allowed"
+	case err == nil:
+		return HealthError, fmt.Sprintf("Unexpected HTTP %d", status)
+	case errors.As(err, &certErr), errors.As(err, &authorityErr), strings.Contains(err.Error(), "certificate"):
+		return HealthBlocked, "The TLS certificate is not trusted, likely a TLS-inspecting proxy; add its CA certificate in the network settings"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return HealthTimeout, "No answer in time; check the proxy settings or the firewall"
+	case errors.As(err, &dnsErr):
+		return HealthUnreachable, "The host name could not be resolved; check DNS or set a proxy"
+	case strings.Contains(err.Error(), "connection reset"), strings.Contains(err.Error(), "connection refused"):
+		return HealthBlocked, "The connection was refused or reset, likely by a firewall; ask for the host to be allowed or set a proxy"
+	}
+	return HealthUnreachable, "Check the network connection and proxy settings"
+}
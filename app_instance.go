@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/singleinstance"
+)
+
+// SecondInstanceLaunch is emitted to the frontend when the app is launched again while
+// running; the second process exits after handing over its arguments
+type SecondInstanceLaunch struct {
+	Args             []string `json:"args"`
+	WorkingDirectory string   `json:"workingDirectory"`
+}
+
+// handleSecondLaunch brings the window to the front, opens deep links among the arguments
+// of a second launch and forwards all of them to the frontend
+func (a *App) handleSecondLaunch(data singleinstance.LaunchData) {
+	log.Printf("Handling second launch (args: %v, dir: %s)", data.Args, data.WorkingDirectory)
+
+	wailsRuntime.WindowUnminimise(a.ctx)
+	wailsRuntime.WindowShow(a.ctx)
+	wailsRuntime.EventsEmit(a.ctx, "second-instance-launch", SecondInstanceLaunch{
+		Args:             data.Args,
+		WorkingDirectory: data.WorkingDirectory,
+	})
+	a.handleLaunchArgs(data.Args)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/taskqueue"
+)
+
+const (
+	// janitorStartDelay lets startup settle before the first retention pass
+	janitorStartDelay = 2 * time.Minute
+
+	// janitorInterval is how often retention rules are applied while the app runs
+	janitorInterval = 6 * time.Hour
+)
+
+// RetentionCandidate is a task output that the retention rules would delete
+type RetentionCandidate struct {
+	TaskID      string `json:"taskId"`
+	Name        string `json:"name"`
+	OutputPath  string `json:"outputPath"`
+	CompletedAt string `json:"completedAt"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	Reason      string `json:"reason"` // "age" or "aoi_limit"
+}
+
+// RetentionReport lists what a retention pass deleted (or, for a dry run, would delete)
+type RetentionReport struct {
+	DryRun     bool                 `json:"dryRun"`
+	Tasks      []RetentionCandidate `json:"tasks"`
+	TaskBytes  int64                `json:"taskBytes"`
+	CacheTiles int                  `json:"cacheTiles"` // Tiles past the cache TTL
+	CacheBytes int64                `json:"cacheBytes"`
+	Errors     []string             `json:"errors,omitempty"`
+}
+
+// PreviewRetention reports what the current retention rules would delete without deleting anything
+func (a *App) PreviewRetention() (report RetentionReport, err error) {
+	defer crash.Recover("PreviewRetention", &err)
+	return a.applyRetention(true), nil
+}
+
+// RunRetention applies the retention rules now instead of waiting for the background janitor
+func (a *App) RunRetention() (report RetentionReport, err error) {
+	defer crash.Recover("RunRetention", &err)
+	return a.applyRetention(false), nil
+}
+
+// startJanitor applies the retention rules shortly after startup and then periodically
+// until ctx is cancelled
+func (a *App) startJanitor(ctx context.Context) {
+	go func() {
+		defer crash.Recover("Janitor", nil)
+
+		timer := time.NewTimer(janitorStartDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			report := a.applyRetention(false)
+			if len(report.Tasks) > 0 || report.CacheTiles > 0 {
+				log.Printf("[Janitor] Removed %d task output(s) (%.1f MB) and %d expired cache tile(s) (%.1f MB)",
+					len(report.Tasks), float64(report.TaskBytes)/1024/1024,
+					report.CacheTiles, float64(report.CacheBytes)/1024/1024)
+			}
+			timer.Reset(janitorInterval)
+		}
+	}()
+}
+
+// applyRetention deletes (or with dryRun only lists) task outputs older than the max age,
+// outputs beyond the per-AOI limit, and cache tiles past their TTL
+func (a *App) applyRetention(dryRun bool) RetentionReport {
+	a.mu.Lock()
+	maxAgeDays := a.settings.RetentionMaxAgeDays
+	keepPerAOI := a.settings.RetentionKeepPerAOI
+	a.mu.Unlock()
+
+	report := RetentionReport{DryRun: dryRun, Tasks: []RetentionCandidate{}}
+
+	for _, c := range retentionCandidates(a.taskQueue.GetAllTasks(), maxAgeDays, keepPerAOI, time.Now()) {
+		if !dryRun {
+			task, err := a.taskQueue.GetTask(c.TaskID)
+			if err != nil {
+				continue // Deleted meanwhile
+			}
+			if _, err := a.removeTaskOutput(task); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", c.Name, err))
+				continue
+			}
+		}
+		report.Tasks = append(report.Tasks, c)
+		report.TaskBytes += c.SizeBytes
+	}
+
+	if a.tileCache != nil {
+		report.CacheTiles, report.CacheBytes = a.tileCache.PruneExpired(dryRun)
+	}
+	return report
+}
+
+// retentionCandidates selects the task outputs the retention rules remove. Only finished
+// tasks that own an existing output folder are considered, and outputs still needed by a
+// video task that has not run yet are kept.
+func retentionCandidates(tasks []*taskqueue.ExportTask, maxAgeDays, keepPerAOI int, now time.Time) []RetentionCandidate {
+	if maxAgeDays <= 0 && keepPerAOI <= 0 {
+		return nil
+	}
+
+	needed := make(map[string]bool)
+	for _, t := range tasks {
+		if t.Status != taskqueue.TaskStatusCompleted {
+			for _, dep := range t.DependsOn {
+				needed[dep] = true
+			}
+		}
+	}
+
+	type finished struct {
+		task      *taskqueue.ExportTask
+		completed time.Time
+	}
+	byAOI := make(map[string][]finished)
+	for _, t := range tasks {
+		switch t.Status {
+		case taskqueue.TaskStatusCompleted, taskqueue.TaskStatusFailed, taskqueue.TaskStatusCancelled:
+		default:
+			continue
+		}
+		if t.IsVideoOnly() || t.OutputPath == "" || needed[t.ID] {
+			continue
+		}
+		if _, err := os.Stat(t.OutputPath); err != nil {
+			continue // Already removed
+		}
+		completed, err := time.Parse(time.RFC3339, t.CompletedAt)
+		if err != nil {
+			continue
+		}
+		key := fmt.Sprintf("%.4f,%.4f,%.4f,%.4f", t.BBox.South, t.BBox.West, t.BBox.North, t.BBox.East)
+		byAOI[key] = append(byAOI[key], finished{t, completed})
+	}
+
+	var candidates []RetentionCandidate
+	for _, group := range byAOI {
+		// Newest first, so the per-AOI limit keeps the latest exports. Only completed
+		// exports count towards it; failed and cancelled ones are removed by age alone.
+		sort.Slice(group, func(i, j int) bool { return group[i].completed.After(group[j].completed) })
+		completedRank := 0
+		for _, f := range group {
+			rank := -1
+			if f.task.Status == taskqueue.TaskStatusCompleted {
+				rank = completedRank
+				completedRank++
+			}
+			reason := ""
+			switch {
+			case maxAgeDays > 0 && now.Sub(f.completed) > time.Duration(maxAgeDays)*24*time.Hour:
+				reason = "age"
+			case keepPerAOI > 0 && rank >= keepPerAOI:
+				reason = "aoi_limit"
+			default:
+				continue
+			}
+			candidates = append(candidates, RetentionCandidate{
+				TaskID:      f.task.ID,
+				Name:        f.task.Name,
+				OutputPath:  f.task.OutputPath,
+				CompletedAt: f.task.CompletedAt,
+				SizeBytes:   taskqueue.DirSize(f.task.OutputPath),
+				Reason:      reason,
+			})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].CompletedAt < candidates[j].CompletedAt })
+	return candidates
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/manifest"
+	"imagery-desktop/internal/taskqueue"
+)
+
+// WriteTaskManifest writes (or rewrites) the checksummed manifest of a finished task's
+// output folder, signed when a signing key is set, and returns its path
+func (a *App) WriteTaskManifest(taskID string) (path string, err error) {
+	defer crash.Recover("WriteTaskManifest", &err)
+
+	task, err := a.taskQueue.GetTask(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.Status == taskqueue.TaskStatusRunning {
+		return "", fmt.Errorf("task is still running")
+	}
+	root, err := a.taskOutputDir(taskID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, done, err := a.beginDownload("WriteTaskManifest")
+	if err != nil {
+		return "", err
+	}
+	defer done(&err)
+
+	return a.writeTaskManifest(ctx, task, root)
+}
+
+// VerifyTaskManifest rehashes a task's output files and checks them, and the manifest's
+// signature, against the manifest in its output folder. Signatures are trusted when made
+// with the signing key or one of the trusted manifest keys from settings.
+func (a *App) VerifyTaskManifest(taskID string) (result *manifest.Verification, err error) {
+	defer crash.Recover("VerifyTaskManifest", &err)
+
+	root, err := a.taskOutputDir(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Verify(root, a.trustedManifestKeys())
+}
+
+// trustedManifestKeys returns the fingerprints of the keys manifest signatures are trusted from
+func (a *App) trustedManifestKeys() []string {
+	a.mu.Lock()
+	keyPath := a.settings.ManifestSigningKey
+	trusted := append([]string(nil), a.settings.TrustedManifestKeys...)
+	a.mu.Unlock()
+
+	if keyPath != "" {
+		key, err := manifest.LoadSigningKey(keyPath)
+		if err != nil {
+			log.Printf("[Manifest] Cannot trust the signing key: %v", err)
+		} else {
+			trusted = append(trusted, manifest.Fingerprint(key.Public().(ed25519.PublicKey)))
+		}
+	}
+	return trusted
+}
+
+// writeTaskManifest hashes the files in root into a manifest of task's capture metadata
+// and writes it there, reporting progress per file
+func (a *App) writeTaskManifest(ctx context.Context, task *taskqueue.ExportTask, root string) (string, error) {
+	var key ed25519.PrivateKey
+	if keyPath := a.settings.ManifestSigningKey; keyPath != "" {
+		var err error
+		if key, err = manifest.LoadSigningKey(keyPath); err != nil {
+			return "", err
+		}
+	}
+
+	capture := manifest.Capture{
+		TaskID:   task.ID,
+		TaskName: task.Name,
+		Source:   task.Source,
+		Zoom:     task.Zoom,
+		BBox:     manifest.BoundingBox(task.BBox),
+		Dates:    make([]manifest.Date, len(task.Dates)),
+		Project:  task.Project,
+		Operator: task.Operator,
+		Notes:    task.Notes,
+	}
+	for i, d := range task.Dates {
+		capture.Dates[i] = manifest.Date{Date: d.Date, Source: d.Source, HexDate: d.HexDate, Epoch: d.Epoch}
+	}
+
+	m, err := manifest.Build(root, capture, AppVersion, func(done, total int) {
+		downloads.ReportProgress(ctx, a.emitDownloadProgressFromDownloads, downloads.DownloadProgress{
+			Downloaded: done,
+			Total:      total,
+			Percent:    done * 100 / total,
+			Status:     fmt.Sprintf("Hashing outputs (%d/%d)", done, total),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := manifest.Write(root, m, key); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(root, manifest.FileName)
+	signed := "unsigned"
+	if m.Signer != nil {
+		signed = "signed by key " + m.Signer.Fingerprint[:16]
+	}
+	log.Printf("[Manifest] Wrote %s (%d files, %s)", path, len(m.Files), signed)
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Export manifest written: %d file(s), %s", len(m.Files), signed))
+	return path, nil
+}
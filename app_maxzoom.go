@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/taskqueue"
+)
+
+// ZoomAvailability is the highest zoom an Esri Wayback release has imagery for in an area
+type ZoomAvailability struct {
+	Date     string  `json:"date"`
+	LayerID  int     `json:"layerId"`
+	MaxZoom  int     `json:"maxZoom"`
+	Coverage float64 `json:"coverage"` // Share of the sampled tiles with imagery at MaxZoom (0-1)
+}
+
+// GetMaxAvailableZoom probes the Wayback tilemap across bbox for the highest zoom the
+// release of date has imagery for, so a download can be planned below the zooms older
+// releases lack. Queued Esri tasks clamp their zoom to the lowest across their dates.
+func (a *App) GetMaxAvailableZoom(bbox BoundingBox, date string) (result *ZoomAvailability, err error) {
+	defer crash.Recover("GetMaxAvailableZoom", &err)
+
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	layer, err := a.findLayerForDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	zoom, coverage, err := a.esriDownloader.MaxAvailableZoom(a.ctx, box, layer, downloads.MaxZoomEsri)
+	if err != nil {
+		return nil, err
+	}
+	return &ZoomAvailability{Date: date, LayerID: layer.ID, MaxZoom: zoom, Coverage: coverage}, nil
+}
+
+// clampTaskZoom lowers the task's zoom, once before anything is downloaded, to the highest
+// zoom every Esri date it downloads has imagery for across the area, with a warning. The
+// whole task moves so its outputs, video frames and incremental bases share one zoom.
+// Dates whose probe fails keep the zoom and are left to the sample-tile blank check.
+func (a *App) clampTaskZoom(ctx context.Context, task *taskqueue.ExportTask) {
+	bbox := BoundingBox(task.BBox).toDownloadsBBox()
+	zoom, limitDate := task.Zoom, ""
+	for _, d := range task.Dates {
+		source := task.Source
+		if source == common.ProviderMixed {
+			source = d.Source
+		}
+		if source != common.ProviderEsriWayback {
+			continue
+		}
+		layer, err := a.findLayerForDate(d.Date)
+		if err != nil {
+			continue
+		}
+		maxZoom, _, err := a.esriDownloader.MaxAvailableZoom(ctx, bbox, layer, zoom)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && maxZoom < zoom {
+			zoom, limitDate = maxZoom, d.Date
+		}
+	}
+	if zoom == task.Zoom {
+		return
+	}
+	log.Printf("[TaskQueue] Esri date %s has no coverage above zoom %d, clamping task %s from zoom %d", limitDate, zoom, task.ID, task.Zoom)
+	a.emitLog(fmt.Sprintf("⚠️ Esri %s has no imagery above zoom %d here; downloading the task at zoom %d instead of %d", limitDate, zoom, zoom, task.Zoom))
+	task.Zoom = zoom
+}
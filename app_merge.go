@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/pkg/geotiff"
+)
+
+// mergeTolerance is the fraction of a pixel by which inputs may disagree on pixel size
+// or grid alignment and still be merged
+const mergeTolerance = 0.01
+
+// mergeInput is one export to be merged
+type mergeInput struct {
+	path string
+	geo  geotiff.Georeference
+}
+
+// MergeExports mosaics previously exported GeoTIFFs into one file. All inputs must share
+// a CRS and pixel size (the same provider scheme and zoom); each is placed by its
+// georeferencing, later files drawing over earlier ones where they overlap. The merged
+// file is written to the download folder, split into parts + VRT when too large.
+func (a *App) MergeExports(paths []string) (path string, err error) {
+	defer crash.Recover("MergeExports", &err)
+
+	if len(paths) < 2 {
+		return "", fmt.Errorf("select at least two GeoTIFF exports to merge")
+	}
+
+	// Read every georeference first so mismatched inputs fail before any decoding
+	inputs := make([]mergeInput, 0, len(paths))
+	for _, p := range paths {
+		geo, err := geotiff.ReadGeoreference(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", filepath.Base(p), err)
+		}
+		inputs = append(inputs, mergeInput{path: p, geo: geo})
+	}
+
+	ref := inputs[0].geo
+	minX, maxY := ref.OriginX, ref.OriginY
+	maxX := ref.OriginX + float64(ref.Width)*ref.PixelWidth
+	minY := ref.OriginY + float64(ref.Height)*ref.PixelHeight
+	for _, in := range inputs[1:] {
+		geo := in.geo
+		if geo.EPSG != ref.EPSG {
+			return "", fmt.Errorf("%s is in EPSG:%d but %s is in EPSG:%d", filepath.Base(in.path), geo.EPSG, filepath.Base(inputs[0].path), ref.EPSG)
+		}
+		if math.Abs(geo.PixelWidth-ref.PixelWidth) > ref.PixelWidth*mergeTolerance ||
+			math.Abs(geo.PixelHeight-ref.PixelHeight) > math.Abs(ref.PixelHeight)*mergeTolerance {
+			return "", fmt.Errorf("%s has a different resolution than %s (exports must share the same zoom)", filepath.Base(in.path), filepath.Base(inputs[0].path))
+		}
+		minX = math.Min(minX, geo.OriginX)
+		maxX = math.Max(maxX, geo.OriginX+float64(geo.Width)*geo.PixelWidth)
+		maxY = math.Max(maxY, geo.OriginY)
+		minY = math.Min(minY, geo.OriginY+float64(geo.Height)*geo.PixelHeight)
+	}
+
+	pixelWidth, pixelHeight := ref.PixelWidth, math.Abs(ref.PixelHeight)
+	width := int(math.Round((maxX - minX) / pixelWidth))
+	height := int(math.Round((maxY - minY) / pixelHeight))
+	a.emitLog(fmt.Sprintf("Merging %d exports into %dx%d px...", len(inputs), width, height))
+
+	// Transparent canvas so gaps between inputs stay masked
+	merged := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, in := range inputs {
+		img, geo, err := geotiff.Load(in.path)
+		if err != nil {
+			return "", err
+		}
+
+		offX := (geo.OriginX - minX) / pixelWidth
+		offY := (maxY - geo.OriginY) / pixelHeight
+		if math.Abs(offX-math.Round(offX)) > mergeTolerance || math.Abs(offY-math.Round(offY)) > mergeTolerance {
+			a.emitLog(fmt.Sprintf("Warning: %s is not aligned to the pixel grid, placing it at the nearest pixel", filepath.Base(in.path)))
+		}
+		offset := image.Pt(int(math.Round(offX)), int(math.Round(offY)))
+
+		bounds := img.Bounds()
+		draw.Draw(merged, bounds.Sub(bounds.Min).Add(offset), img, bounds.Min, draw.Over)
+	}
+
+	a.mu.Lock()
+	maxDim := a.settings.MaxGeoTIFFDimension
+	buildOverviews := a.settings.GeoTIFFOverviews
+	a.mu.Unlock()
+
+	path = filepath.Join(a.GetDownloadPath(), fmt.Sprintf("merged_%s.tif", time.Now().Format("2006-01-02_150405")))
+
+	alpha := geotiff.HasTransparency(merged)
+	bands := 3
+	if alpha {
+		bands = 4
+	}
+	epsg := ref.EPSG
+	if epsg == 0 {
+		epsg = 3857
+	}
+
+	paths, err = geotiff.SaveSplit(merged, path, minX, maxY, pixelWidth, pixelHeight, epsg, bands, maxDim,
+		func(part image.Image, partPath string, partOriginX, partOriginY float64) error {
+			opts := &geotiff.EncodeOptions{Alpha: alpha, EPSG: epsg}
+			if buildOverviews {
+				partBounds := part.Bounds()
+				opts.Overviews = geotiff.DefaultOverviewLevels(partBounds.Dx(), partBounds.Dy())
+			}
+			return geotiff.SaveAsGeoTIFFWithOptions(part, partPath, partOriginX, partOriginY, pixelWidth, pixelHeight,
+				"Merged exports", "", AppVersion, opts)
+		})
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to save merged GeoTIFF: %w", err)
+	}
+
+	a.emitLog(fmt.Sprintf("Merged %d exports: %s", len(inputs), filepath.Base(paths[0])))
+	return paths[0], nil
+}
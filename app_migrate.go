@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+)
+
+// MigrateDownloadFolder switches the download folder to newPath and rewrites every task's
+// output references to match. With moveFiles set the current folder's contents are moved
+// there first; otherwise the files are assumed to have been moved already (relink only).
+// A failure part way through moves files back and leaves the task records unchanged.
+// Returns the number of task records rewritten.
+func (a *App) MigrateDownloadFolder(newPath string, moveFiles bool) (relinked int, err error) {
+	defer crash.Recover("MigrateDownloadFolder", &err)
+
+	if status := a.taskQueue.GetStatus(); status.CurrentTaskID != "" || (status.IsRunning && !status.IsPaused) {
+		return 0, fmt.Errorf("pause the task queue and wait for the current task before moving the download folder")
+	}
+
+	oldPath := a.GetDownloadPath()
+	newPath, err = filepath.Abs(newPath)
+	if err != nil {
+		return 0, fmt.Errorf("invalid folder: %w", err)
+	}
+	if oldAbs, err := filepath.Abs(oldPath); err == nil {
+		oldPath = oldAbs
+	}
+	if newPath == oldPath {
+		return 0, fmt.Errorf("the download folder is already %s", newPath)
+	}
+	if isWithin(oldPath, newPath) || isWithin(newPath, oldPath) {
+		return 0, fmt.Errorf("the new folder cannot be inside the current one (or the other way round)")
+	}
+	if err := os.MkdirAll(newPath, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", newPath, err)
+	}
+
+	var moved []string
+	if moveFiles {
+		a.emitLog(fmt.Sprintf("Moving downloads from %s to %s...", oldPath, newPath))
+		if moved, err = moveFolderContents(oldPath, newPath); err != nil {
+			return 0, err
+		}
+	}
+
+	relinked, err = a.taskQueue.RelinkPaths(oldPath, newPath)
+	if err != nil {
+		restoreMoved(moved, newPath, oldPath)
+		return 0, err
+	}
+
+	a.mu.Lock()
+	settings := *a.settings
+	settings.DownloadPath = newPath
+	if err := config.SaveSettings(&settings); err != nil {
+		a.mu.Unlock()
+		// Put task records and files back so everything still points at the old folder
+		a.taskQueue.RelinkPaths(newPath, oldPath)
+		restoreMoved(moved, newPath, oldPath)
+		return 0, err
+	}
+	a.settings = &settings
+	a.downloadPath = newPath
+	a.mu.Unlock()
+
+	a.esriDownloader.SetDownloadPath(newPath)
+	a.customDownloader.SetDownloadPath(newPath)
+	if a.geDownloader != nil {
+		a.geDownloader.SetDownloadPath(newPath)
+	}
+	a.videoManager.SetDownloadPath(newPath)
+
+	a.emitLog(fmt.Sprintf("Download folder is now %s (%d item(s) moved, %d task(s) relinked)", newPath, len(moved), relinked))
+	return relinked, nil
+}
+
+// isWithin reports whether path is dir itself or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moveFolderContents moves every entry of src into dst and returns the moved names.
+// Nothing is moved if a name already exists in dst; if a move fails, the entries moved
+// so far are moved back.
+func moveFolderContents(src, dst string) ([]string, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	for _, e := range entries {
+		if _, err := os.Lstat(filepath.Join(dst, e.Name())); err == nil {
+			return nil, fmt.Errorf("%s already exists in %s", e.Name(), dst)
+		}
+	}
+
+	var moved []string
+	for _, e := range entries {
+		if err := movePath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			restoreMoved(moved, dst, src)
+			return nil, fmt.Errorf("failed to move %s: %w", e.Name(), err)
+		}
+		moved = append(moved, e.Name())
+	}
+	return moved, nil
+}
+
+// restoreMoved moves names back from dst to src after a failed migration
+func restoreMoved(names []string, dst, src string) {
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := movePath(filepath.Join(dst, names[i]), filepath.Join(src, names[i])); err != nil {
+			log.Printf("Failed to move %s back to %s: %v", names[i], src, err)
+		}
+	}
+}
+
+// movePath renames src to dst, falling back to copy-and-delete across volumes
+func movePath(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a file or directory tree, keeping file modes
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
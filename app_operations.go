@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/downloads"
+)
+
+// ==========
+// Operations
+// ==========
+
+// Every manual download or export runs as an operation with its own ID, carried by its
+// download context. Its progress, log and completion are emitted as typed events naming
+// the operation, so the UI can tell concurrent runs apart, and can be queried by ID.
+// The shared "download-progress" event carries the ID too; queue tasks report their
+// task ID as the operation ID.
+//
+// Events (payload OperationEvent):
+//   - "operation-started", "operation-progress", "operation-log", "operation-complete"
+//   - "operation:<id>" for every event of a subscribed operation (see SubscribeOperation)
+
+const (
+	// Operation states
+	OperationRunning   = "running"
+	OperationCompleted = "completed"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+
+	// maxOperationLog is the number of log lines kept per operation
+	maxOperationLog = 200
+
+	// maxFinishedOperations is the number of finished operations kept for queries
+	maxFinishedOperations = 50
+)
+
+// OperationStatus is the state of one operation
+type OperationStatus struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`  // Binding that started it, e.g. "DownloadEsriImageryRange"
+	State      string           `json:"state"` // "running", "completed", "failed" or "cancelled"
+	StartedAt  string           `json:"startedAt"`
+	FinishedAt string           `json:"finishedAt,omitempty"`
+	Progress   DownloadProgress `json:"progress"` // Latest progress
+	Error      string           `json:"error,omitempty"`
+	Log        []string         `json:"log"` // Latest log lines, oldest first
+}
+
+// OperationEvent is the payload of the operation events
+type OperationEvent struct {
+	OperationID string            `json:"operationId"`
+	Type        string            `json:"type"`               // "started", "progress", "log" or "complete"
+	Name        string            `json:"name,omitempty"`     // started
+	Progress    *DownloadProgress `json:"progress,omitempty"` // progress
+	Message     string            `json:"message,omitempty"`  // log
+	State       string            `json:"state,omitempty"`    // complete: "completed", "failed" or "cancelled"
+	Error       string            `json:"error,omitempty"`    // complete
+}
+
+// OperationSubscription is returned by SubscribeOperation
+type OperationSubscription struct {
+	Event  string          `json:"event"`  // Event name carrying the operation's events
+	Status OperationStatus `json:"status"` // State at subscription time
+}
+
+// operationState is an operation and whether the UI subscribed to its own event
+type operationState struct {
+	status     OperationStatus
+	subscribed bool
+}
+
+// operationEvent returns the event name carrying the events of operation id
+func operationEvent(id string) string {
+	return "operation:" + id
+}
+
+// startOperation registers an operation named name and returns a context carrying it
+// and the function to call with the operation's result when it ends
+func (a *App) startOperation(ctx context.Context, name string) (context.Context, func(error)) {
+	id := fmt.Sprintf("op_%d", time.Now().UnixNano())
+
+	a.operationsMu.Lock()
+	a.operations[id] = &operationState{status: OperationStatus{
+		ID:        id,
+		Name:      name,
+		State:     OperationRunning,
+		StartedAt: time.Now().Format(time.RFC3339),
+		Log:       []string{},
+	}}
+	a.operationsMu.Unlock()
+	a.emitOperationEvent("operation-started", OperationEvent{OperationID: id, Type: "started", Name: name})
+
+	report := downloads.NewReportRecorder(id, "")
+	ctx = downloads.WithOperation(ctx, &downloads.Operation{
+		ID:     id,
+		Report: report,
+		OnProgress: func(progress downloads.DownloadProgress) {
+			p := DownloadProgress{
+				Downloaded:  progress.Downloaded,
+				Total:       progress.Total,
+				Percent:     progress.Percent,
+				Status:      progress.Status,
+				CurrentDate: progress.CurrentDate,
+				TotalDates:  progress.TotalDates,
+				OperationID: id,
+			}
+			a.updateOperation(id, func(s *OperationStatus) { s.Progress = p })
+			a.emitOperationEvent("operation-progress", OperationEvent{OperationID: id, Type: "progress", Progress: &p})
+		},
+		OnLog: func(message string) {
+			a.updateOperation(id, func(s *OperationStatus) {
+				s.Log = append(s.Log, message)
+				if len(s.Log) > maxOperationLog {
+					s.Log = s.Log[len(s.Log)-maxOperationLog:]
+				}
+			})
+			a.emitOperationEvent("operation-log", OperationEvent{OperationID: id, Type: "log", Message: message})
+		},
+	})
+
+	return ctx, func(err error) {
+		a.saveDownloadReport(report, a.GetDownloadPath())
+
+		event := OperationEvent{OperationID: id, Type: "complete", State: OperationCompleted}
+		switch {
+		case errors.Is(err, context.Canceled):
+			event.State = OperationCancelled
+		case err != nil:
+			event.State = OperationFailed
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		a.updateOperation(id, func(s *OperationStatus) {
+			s.State, s.Error = event.State, event.Error
+			s.FinishedAt = time.Now().Format(time.RFC3339)
+		})
+		a.emitOperationEvent("operation-complete", event)
+		a.pruneOperations()
+	}
+}
+
+// updateOperation applies update to the status of operation id
+func (a *App) updateOperation(id string, update func(*OperationStatus)) {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+	if op, ok := a.operations[id]; ok {
+		update(&op.status)
+	}
+}
+
+// emitOperationEvent emits event as name, and on the operation's own event when subscribed
+func (a *App) emitOperationEvent(name string, event OperationEvent) {
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, name, event)
+
+	a.operationsMu.Lock()
+	op, ok := a.operations[event.OperationID]
+	subscribed := ok && op.subscribed
+	a.operationsMu.Unlock()
+	if subscribed {
+		wailsRuntime.EventsEmit(a.ctx, operationEvent(event.OperationID), event)
+	}
+}
+
+// pruneOperations forgets the oldest finished operations beyond maxFinishedOperations
+func (a *App) pruneOperations() {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+
+	var finished []*operationState
+	for _, op := range a.operations {
+		if op.status.State != OperationRunning {
+			finished = append(finished, op)
+		}
+	}
+	if len(finished) <= maxFinishedOperations {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].status.FinishedAt < finished[j].status.FinishedAt })
+	for _, op := range finished[:len(finished)-maxFinishedOperations] {
+		delete(a.operations, op.status.ID)
+	}
+}
+
+// ListOperations returns the running and recently finished operations, oldest first
+func (a *App) ListOperations() []OperationStatus {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+
+	ops := make([]OperationStatus, 0, len(a.operations))
+	for _, op := range a.operations {
+		ops = append(ops, copyOperationStatus(op.status))
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops
+}
+
+// GetOperation returns one operation
+func (a *App) GetOperation(id string) (OperationStatus, error) {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+
+	op, ok := a.operations[id]
+	if !ok {
+		return OperationStatus{}, fmt.Errorf("operation not found: %s", id)
+	}
+	return copyOperationStatus(op.status), nil
+}
+
+// SubscribeOperation starts emitting every event of operation id on its own event, whose
+// name is returned with the operation's current state
+func (a *App) SubscribeOperation(id string) (OperationSubscription, error) {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+
+	op, ok := a.operations[id]
+	if !ok {
+		return OperationSubscription{}, fmt.Errorf("operation not found: %s", id)
+	}
+	op.subscribed = true
+	return OperationSubscription{Event: operationEvent(id), Status: copyOperationStatus(op.status)}, nil
+}
+
+// UnsubscribeOperation stops emitting the events of operation id on its own event
+func (a *App) UnsubscribeOperation(id string) error {
+	a.operationsMu.Lock()
+	defer a.operationsMu.Unlock()
+
+	op, ok := a.operations[id]
+	if !ok {
+		return fmt.Errorf("operation not found: %s", id)
+	}
+	op.subscribed = false
+	return nil
+}
+
+func copyOperationStatus(s OperationStatus) OperationStatus {
+	s.Log = append([]string{}, s.Log...)
+	return s
+}
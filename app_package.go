@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/taskqueue"
+)
+
+// storedExtensions are already compressed, so they are stored in packages as is rather
+// than deflated again
+var storedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".webp": true, ".gif": true,
+	".mp4": true, ".mov": true, ".avi": true, ".zip": true, ".gz": true,
+}
+
+// PackageTaskOutput compresses a task's output folder into a single .zip next to it,
+// optionally leaving out tile pyramids, and returns the archive's path
+func (a *App) PackageTaskOutput(taskID string, excludeTiles bool) (path string, err error) {
+	defer crash.Recover("PackageTaskOutput", &err)
+
+	task, err := a.taskQueue.GetTask(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.Status == taskqueue.TaskStatusRunning {
+		return "", fmt.Errorf("task is still running")
+	}
+	root, err := a.taskOutputDir(taskID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, done, err := a.beginDownload("PackageTaskOutput")
+	if err != nil {
+		return "", err
+	}
+	defer done(&err)
+
+	path, err = a.packageOutput(ctx, root, excludeTiles)
+	if err != nil {
+		return "", err
+	}
+	if err := a.RevealFile(path); err != nil {
+		log.Printf("Failed to reveal package: %v", err)
+	}
+	return path, nil
+}
+
+// packageOutput zips root into root + ".zip", reporting progress by bytes compressed
+func (a *App) packageOutput(ctx context.Context, root string, excludeTiles bool) (string, error) {
+	type entry struct {
+		path, name string
+		size       int64
+	}
+	var entries []entry
+	var totalBytes int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if excludeTiles && strings.HasSuffix(d.Name(), "_tiles") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil // Removed while walking, or not a file
+		}
+		rel, _ := filepath.Rel(root, path)
+		entries = append(entries, entry{path: path, name: filepath.ToSlash(rel), size: info.Size()})
+		totalBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list task outputs: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("task output folder is empty")
+	}
+
+	dest := filepath.Clean(root) + ".zip"
+	tmp := dest + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", fmt.Errorf("failed to create package: %w", err)
+	}
+	defer os.Remove(tmp) // No-op once renamed
+
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Packaging %d file(s) (%.1f MB) into %s", len(entries), float64(totalBytes)/(1024*1024), filepath.Base(dest)))
+	progress := &packageProgress{ctx: ctx, emit: a.emitDownloadProgressFromDownloads, total: totalBytes, files: len(entries)}
+
+	zw := zip.NewWriter(out)
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			zw.Close()
+			out.Close()
+			return "", err
+		}
+		progress.file = i + 1
+		if err := addFileToPackage(zw, e.path, e.name, progress); err != nil {
+			zw.Close()
+			out.Close()
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return "", fmt.Errorf("failed to write package: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("failed to write package: %w", err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return "", fmt.Errorf("failed to save package: %w", err)
+	}
+
+	size := int64(0)
+	if info, err := os.Stat(dest); err == nil {
+		size = info.Size()
+	}
+	progress.report(totalBytes, "Package complete")
+	downloads.ReportLog(ctx, a.emitLog, fmt.Sprintf("Package saved: %s (%.1f MB)", dest, float64(size)/(1024*1024)))
+	return dest, nil
+}
+
+// addFileToPackage copies a file into the archive under name, deflating it unless it is
+// already compressed
+func addFileToPackage(zw *zip.Writer, path, name string, progress *packageProgress) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	if storedExtensions[strings.ToLower(filepath.Ext(name))] {
+		header.Method = zip.Store
+	}
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, io.TeeReader(f, progress)); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// packageProgress reports packaging progress as bytes are read, at most once per percent
+type packageProgress struct {
+	ctx         context.Context
+	emit        func(downloads.DownloadProgress)
+	total       int64
+	done        int64
+	file, files int
+	lastPercent int
+}
+
+func (p *packageProgress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if percent := p.percent(p.done); percent > p.lastPercent {
+		p.report(p.done, fmt.Sprintf("Packaging file %d/%d", p.file, p.files))
+	}
+	return len(b), nil
+}
+
+func (p *packageProgress) percent(done int64) int {
+	if p.total == 0 {
+		return 100
+	}
+	return int(done * 100 / p.total)
+}
+
+func (p *packageProgress) report(done int64, status string) {
+	p.lastPercent = p.percent(done)
+	downloads.ReportProgress(p.ctx, p.emit, downloads.DownloadProgress{
+		Downloaded: p.file,
+		Total:      p.files,
+		Percent:    p.lastPercent,
+		Status:     status,
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/internal/tilemath"
+)
+
+const (
+	// prefetchMaxTiles caps the tiles warmed per date, about a full-screen view
+	prefetchMaxTiles = 120
+
+	// prefetchWorkers bounds the concurrent prefetch requests, kept below the download
+	// workers so the visible map and running downloads are served first
+	prefetchWorkers = 4
+)
+
+// PrefetchDatesForView warms the tile cache with the Esri Wayback tiles of the visible
+// view for each date, in the order given, so dragging the date slider swaps imagery from
+// cache instead of the network. Runs in the background and returns at once; each call
+// cancels the previous prefetch, so an empty date list just stops it.
+func (a *App) PrefetchDatesForView(bbox BoundingBox, zoom int, dates []string) (err error) {
+	defer crash.Recover("PrefetchDatesForView", &err)
+
+	a.prefetchMu.Lock()
+	defer a.prefetchMu.Unlock()
+	if a.cancelPrefetch != nil {
+		a.cancelPrefetch()
+		a.cancelPrefetch = nil
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	if a.tileCache == nil {
+		return fmt.Errorf("tile cache is disabled")
+	}
+	provider, err := a.providers.Get(common.ProviderEsriWayback)
+	if err != nil {
+		return err
+	}
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if _, maxZoom := provider.ZoomRange(); zoom > maxZoom {
+		zoom = maxZoom
+	}
+	if tiles := previewTileCount(provider.TileScheme(), box, zoom); tiles > prefetchMaxTiles {
+		return fmt.Errorf("view covers %d tiles at zoom %d, more than the %d prefetched per date", tiles, zoom, prefetchMaxTiles)
+	}
+
+	ctx, cancel := context.WithCancel(a.downloadsCtx)
+	a.cancelPrefetch = cancel
+	go a.prefetchDates(ctx, provider, box, zoom, dates)
+	return nil
+}
+
+// prefetchDates fetches the tiles of box for each date into the tile cache until ctx is
+// cancelled. Tiles already cached cost no request.
+func (a *App) prefetchDates(ctx context.Context, provider providers.ImageryProvider, box downloads.BoundingBox, zoom int, dates []string) {
+	defer crash.Recover("PrefetchDatesForView", nil)
+
+	type tile struct{ x, y int }
+	var tiles []tile
+	minX, minY, maxX, maxY := tilemath.XYZRange(box.South, box.West, box.North, box.East, zoom)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			tiles = append(tiles, tile{x: tilemath.WrapColumn(x, zoom), y: y})
+		}
+	}
+
+	fetched, failed := 0, 0
+	for _, date := range dates {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, prefetchWorkers)
+		for _, t := range tiles {
+			if ctx.Err() != nil {
+				break
+			}
+			workers <- struct{}{}
+			wg.Add(1)
+			go func(t tile) {
+				defer wg.Done()
+				defer func() { <-workers }()
+				_, hit, err := a.tileCache.GetOrFetch(provider.ID(), zoom, t.x, t.y, date, func() ([]byte, error) {
+					return provider.FetchTile(zoom, t.x, t.y, providers.Date{Date: date})
+				})
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failed++
+					if a.devMode {
+						log.Printf("[Prefetch] Tile %d/%d/%d (date: %s) failed: %v", zoom, t.x, t.y, date, err)
+					}
+				} else if !hit {
+					fetched++
+				}
+			}(t)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	log.Printf("[Prefetch] Warmed %d date(s) at zoom %d: %d tile(s) fetched, %d failed", len(dates), zoom, fetched, failed)
+}
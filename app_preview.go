@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/pkg/geotiff"
+)
+
+const (
+	// previewMaxTiles caps the tiles fetched per preview (a 3x3 grid)
+	previewMaxTiles = 9
+
+	// previewSize is the longer side of a preview thumbnail in pixels
+	previewSize = 256
+)
+
+// previewBackground fills areas without imagery so blank dates are easy to spot
+var previewBackground = color.RGBA{R: 32, G: 32, B: 32, A: 255}
+
+// GetDatePreview renders a small thumbnail of the area for one date, so the date picker
+// can show what each date looks like without a full download.
+// The zoom is lowered until the area fits in a 3x3 tile grid; tiles go through the tile cache.
+// Returns a data URL ("data:image/jpeg;base64,...") ready for an <img> src.
+func (a *App) GetDatePreview(bbox BoundingBox, zoom int, source string, date GEDateInfo) (preview string, err error) {
+	defer crash.Recover("GetDatePreview", &err)
+
+	provider, err := a.providers.Get(source)
+	if err != nil {
+		return "", err
+	}
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+
+	minZoom, maxZoom := provider.ZoomRange()
+	z := zoom
+	if z > maxZoom {
+		z = maxZoom
+	}
+	for z > minZoom && previewTileCount(provider.TileScheme(), box, z) > previewMaxTiles {
+		z--
+	}
+
+	mosaic := a.renderAreaMosaic(provider, box, z, date)
+	if mosaic == nil {
+		return "", fmt.Errorf("no %s imagery for %s in this area", provider.Name(), date.Date)
+	}
+	img, crop := mosaic.image, mosaic.area
+
+	// Scale the area (not the whole tile grid) so its longer side is previewSize
+	scale := float64(previewSize) / math.Max(float64(crop.Dx()), float64(crop.Dy()))
+	width := int(math.Max(1, math.Round(float64(crop.Dx())*scale)))
+	height := int(math.Max(1, math.Round(float64(crop.Dy())*scale)))
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.Draw(thumb, thumb.Bounds(), &image.Uniform{C: previewBackground}, image.Point{}, xdraw.Src)
+	xdraw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, crop, xdraw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80}); err != nil {
+		return "", fmt.Errorf("failed to encode preview: %w", err)
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// areaMosaic is a north-up mosaic of the tiles covering an area, with its georeferencing
+type areaMosaic struct {
+	image *image.RGBA
+	area  image.Rectangle // Pixels covered by the requested area
+
+	// GeoTIFF georeferencing of the top-left corner and pixel size
+	originX, originY        float64
+	pixelWidth, pixelHeight float64
+	epsg                    int // 3857 for XYZ providers, 4326 for Google Earth (Plate Carrée)
+}
+
+// previewTileCount returns how many tiles cover the area at a zoom level
+func previewTileCount(scheme providers.TileScheme, bbox downloads.BoundingBox, zoom int) int {
+	if scheme == providers.SchemeGoogleEarth {
+		minRow, minCol, maxRow, maxCol := tilemath.GERange(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+		return (maxRow - minRow + 1) * (maxCol - minCol + 1)
+	}
+	minX, minY, maxX, maxY := tilemath.XYZRange(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+	return (maxX - minX + 1) * (maxY - minY + 1)
+}
+
+// renderAreaMosaic fetches the tiles covering the area in parallel and stitches them
+// north-up. Returns nil when no tile could be fetched.
+func (a *App) renderAreaMosaic(provider providers.ImageryProvider, bbox downloads.BoundingBox, zoom int, date GEDateInfo) *areaMosaic {
+	// Tile grid in image order (row 0 at the top) and the fractional position of the area
+	type gridTile struct{ x, y, col, row int } // x/y = position in the mosaic
+	var tiles []gridTile
+	var left, top, right, bottom float64
+	var cols, rows int
+	result := &areaMosaic{}
+	n := float64(tilemath.NumTiles(zoom))
+
+	geScheme := provider.TileScheme() == providers.SchemeGoogleEarth
+	if geScheme {
+		// GE rows count from the south, so the top of the image is the highest row
+		minRow, minCol, maxRow, maxCol := tilemath.GERange(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+		cols, rows = maxCol-minCol+1, maxRow-minRow+1
+		for row := maxRow; row >= minRow; row-- {
+			for col := minCol; col <= maxCol; col++ {
+				tiles = append(tiles, gridTile{x: col - minCol, y: maxRow - row, col: col, row: row})
+			}
+		}
+		northRow, westCol := tilemath.LatLonToGEFrac(bbox.North, bbox.West, zoom)
+		southRow, eastCol := tilemath.LatLonToGEFrac(bbox.South, bbox.East, zoom)
+		left, right = westCol-float64(minCol), eastCol-float64(minCol)
+		top, bottom = float64(maxRow+1)-northRow, float64(maxRow+1)-southRow
+
+		// GE tiles are Plate Carrée, so EPSG:4326 georeferencing is exact
+		result.originX = tilemath.GEToDegrees(float64(minCol), zoom)
+		result.originY = tilemath.GEToDegrees(float64(maxRow+1), zoom)
+		result.pixelWidth = 360 / (n * downloads.TileSize)
+		result.pixelHeight = -result.pixelWidth
+		result.epsg = 4326
+	} else {
+		minX, minY, maxX, maxY := tilemath.XYZRange(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+		cols, rows = maxX-minX+1, maxY-minY+1
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				tiles = append(tiles, gridTile{x: x - minX, y: y - minY, col: x, row: y})
+			}
+		}
+		westX, northY := tilemath.LatLonToXYZFrac(bbox.North, bbox.West, zoom)
+		eastX, southY := tilemath.LatLonToXYZFrac(bbox.South, bbox.East, zoom)
+		left, right = westX-float64(minX), eastX-float64(minX)
+		top, bottom = northY-float64(minY), southY-float64(minY)
+
+		result.originX, result.originY = tilemath.XYZToMeters(float64(minX), float64(minY), zoom)
+		result.pixelWidth = tilemath.Equator / (n * downloads.TileSize)
+		result.pixelHeight = -result.pixelWidth
+		result.epsg = 3857
+	}
+	if bbox.CrossesAntimeridian() {
+		right += float64(tilemath.NumTiles(zoom)) // East edge is measured on the far side of 180°
+	}
+
+	mosaic := image.NewRGBA(image.Rect(0, 0, cols*downloads.TileSize, rows*downloads.TileSize))
+	providerDate := providers.Date{Date: date.Date, HexDate: date.HexDate, Epoch: date.Epoch}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, downloads.DefaultWorkers)
+	fetched := 0
+	for _, t := range tiles {
+		wg.Add(1)
+		go func(t gridTile) {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			col := tilemath.WrapColumn(t.col, zoom)
+
+			data, err := a.fetchAreaTile(provider, zoom, col, t.row, providerDate)
+			if err != nil {
+				return
+			}
+			tileImg, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return
+			}
+
+			rect := image.Rect(t.x*downloads.TileSize, t.y*downloads.TileSize, (t.x+1)*downloads.TileSize, (t.y+1)*downloads.TileSize)
+			mu.Lock()
+			xdraw.Draw(mosaic, rect, tileImg, tileImg.Bounds().Min, xdraw.Src)
+			fetched++
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	if fetched == 0 {
+		return nil
+	}
+
+	crop := image.Rect(
+		int(math.Floor(left*downloads.TileSize)),
+		int(math.Floor(top*downloads.TileSize)),
+		int(math.Ceil(right*downloads.TileSize)),
+		int(math.Ceil(bottom*downloads.TileSize)),
+	).Intersect(mosaic.Bounds())
+	if crop.Empty() {
+		crop = mosaic.Bounds()
+	}
+
+	result.image = mosaic
+	result.area = crop
+	return result
+}
+
+// fetchAreaTile returns a tile from the tile cache, fetching and caching it on a miss
+func (a *App) fetchAreaTile(provider providers.ImageryProvider, z, x, y int, date providers.Date) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", provider.ID(), z, x, y, date.Date)
+	if data, found := a.tileCache.Get(cacheKey); found {
+		return data, nil
+	}
+
+	data, err := provider.FetchTile(z, x, y, date)
+	if err != nil {
+		return nil, err
+	}
+	// Current Google Earth imagery has no date; keep it out of the dated cache entries
+	if provider.ID() != common.ProviderGoogleEarth || date.HexDate != "" {
+		a.tileCache.Set(provider.ID(), z, x, y, date.Date, data)
+	}
+	return data, nil
+}
+
+// quicklookZoom is the default zoom of quicklook exports (about 10 m/px)
+const quicklookZoom = 14
+
+// quicklookMaxTiles caps the tiles fetched per quicklook (a 16x16 grid at most)
+const quicklookMaxTiles = 256
+
+// ExportQuicklook saves a fast, low-zoom GeoTIFF and PNG of the area for one date, so cloud
+// cover and coverage can be checked before queuing a full-resolution download.
+// Uses zoom 14, lowered for large areas; files go to the "quicklook" folder of the download
+// path so they are never mistaken for full exports. Returns the GeoTIFF path.
+func (a *App) ExportQuicklook(bbox BoundingBox, source string, date GEDateInfo) (path string, err error) {
+	defer crash.Recover("ExportQuicklook", &err)
+
+	provider, err := a.providers.Get(source)
+	if err != nil {
+		return "", err
+	}
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if provider.TileScheme() == providers.SchemeXYZ {
+		if err := box.ValidateWebMercator(provider.Name()); err != nil {
+			return "", err
+		}
+	}
+
+	minZoom, maxZoom := provider.ZoomRange()
+	z := quicklookZoom
+	if z > maxZoom {
+		z = maxZoom
+	}
+	for z > minZoom && previewTileCount(provider.TileScheme(), box, z) > quicklookMaxTiles {
+		z--
+	}
+
+	a.emitLog(fmt.Sprintf("Rendering %s quicklook for %s at zoom %d...", provider.Name(), date.Date, z))
+	mosaic := a.renderAreaMosaic(provider, box, z, date)
+	if mosaic == nil {
+		return "", fmt.Errorf("no %s imagery for %s in this area", provider.Name(), date.Date)
+	}
+
+	dir := filepath.Join(a.GetDownloadPath(), "quicklook")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create quicklook folder: %w", err)
+	}
+	path = filepath.Join(dir, naming.GenerateGeoTIFFFilename(provider.ID(), date.Date, box.South, box.West, box.North, box.East, z))
+
+	opts := &geotiff.EncodeOptions{Alpha: geotiff.HasTransparency(mosaic.image), EPSG: mosaic.epsg}
+	if err := geotiff.SaveAsGeoTIFFWithOptions(mosaic.image, path, mosaic.originX, mosaic.originY, mosaic.pixelWidth, mosaic.pixelHeight,
+		provider.Name(), date.Date, AppVersion, opts); err != nil {
+		return "", fmt.Errorf("failed to save quicklook: %w", err)
+	}
+
+	// PNG of just the area for a quick look in any image viewer
+	pngFile, err := os.Create(strings.TrimSuffix(path, ".tif") + ".png")
+	if err != nil {
+		return "", fmt.Errorf("failed to create quicklook PNG: %w", err)
+	}
+	defer pngFile.Close()
+	if err := png.Encode(pngFile, mosaic.image.SubImage(mosaic.area)); err != nil {
+		return "", fmt.Errorf("failed to encode quicklook PNG: %w", err)
+	}
+
+	a.emitLog(fmt.Sprintf("Quicklook saved: %s", filepath.Base(path)))
+	return path, nil
+}
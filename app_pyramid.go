@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/pkg/geotiff"
+)
+
+// pyramidTile is an XYZ tile address; X may run past the grid edge for exports
+// crossing the antimeridian and is wrapped when written
+type pyramidTile struct {
+	X, Y int
+}
+
+// GenerateTilePyramid slices a Web Mercator GeoTIFF export into a static XYZ tile
+// directory ("<name>_tiles/{z}/{x}/{y}.png") ready to host. maxZoom tiles are resampled
+// from the export; each lower zoom is built by downsampling the four child tiles.
+// Tiles without imagery are skipped. Returns the tile directory.
+func (a *App) GenerateTilePyramid(path string, minZoom, maxZoom int) (dir string, err error) {
+	defer crash.Recover("GenerateTilePyramid", &err)
+
+	img, geo, err := geotiff.Load(path)
+	if err != nil {
+		return "", err
+	}
+	if geo.EPSG != 3857 && geo.EPSG != 0 {
+		return "", fmt.Errorf("tile pyramids need a Web Mercator (EPSG:3857) export, %s is EPSG:%d", filepath.Base(path), geo.EPSG)
+	}
+
+	// Deepest zoom whose resolution the export still covers
+	nativeZoom := int(math.Round(math.Log2(tilemath.Equator / (tilemath.TileSize * geo.PixelWidth))))
+	if minZoom < 0 || minZoom > maxZoom {
+		return "", fmt.Errorf("invalid zoom range %d-%d", minZoom, maxZoom)
+	}
+	if maxZoom > nativeZoom {
+		return "", fmt.Errorf("max zoom %d exceeds the export's resolution (zoom %d)", maxZoom, nativeZoom)
+	}
+
+	dir = strings.TrimSuffix(path, filepath.Ext(path)) + "_tiles"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tile directory: %w", err)
+	}
+
+	a.emitLog(fmt.Sprintf("Generating tiles for %s at zoom %d-%d...", filepath.Base(path), minZoom, maxZoom))
+
+	tiles := sliceExport(img, geo, maxZoom)
+	total := 0
+	for z := maxZoom; ; z-- {
+		for tile, data := range tiles {
+			if err := savePyramidTile(dir, z, tile, data); err != nil {
+				return "", err
+			}
+		}
+		total += len(tiles)
+		a.emitLog(fmt.Sprintf("Zoom %d: %d tiles", z, len(tiles)))
+
+		if z == minZoom {
+			break
+		}
+		tiles = downsampleLevel(tiles)
+	}
+
+	a.emitLog(fmt.Sprintf("Tile pyramid saved (%d tiles): %s", total, dir))
+	return dir, nil
+}
+
+// sliceExport resamples the export into the XYZ tiles covering it at zoom
+func sliceExport(img image.Image, geo geotiff.Georeference, zoom int) map[pyramidTile]*image.RGBA {
+	n := float64(tilemath.NumTiles(zoom))
+	toTile := func(mx, my float64) (float64, float64) {
+		return (mx/tilemath.Equator + 0.5) * n, (0.5 - my/tilemath.Equator) * n
+	}
+
+	pixelHeight := math.Abs(geo.PixelHeight)
+	minX, minY := toTile(geo.OriginX, geo.OriginY)
+	maxX, maxY := toTile(geo.OriginX+float64(geo.Width)*geo.PixelWidth, geo.OriginY-float64(geo.Height)*pixelHeight)
+
+	resolution := tilemath.Equator / (n * tilemath.TileSize) // Meters per tile pixel
+	src := img.Bounds()
+	tiles := make(map[pyramidTile]*image.RGBA)
+	for y := int(math.Floor(minY)); y < int(math.Ceil(maxY)) && y < int(n); y++ {
+		if y < 0 {
+			continue
+		}
+		for x := int(math.Floor(minX)); x < int(math.Ceil(maxX)); x++ {
+			tileX, tileY := tilemath.XYZToMeters(float64(x), float64(y), zoom)
+
+			// Source pixel -> tile pixel
+			sx := geo.PixelWidth / resolution
+			sy := pixelHeight / resolution
+			s2d := f64.Aff3{
+				sx, 0, (geo.OriginX-tileX)/resolution - sx*float64(src.Min.X),
+				0, sy, (tileY-geo.OriginY)/resolution - sy*float64(src.Min.Y),
+			}
+
+			dst := image.NewRGBA(image.Rect(0, 0, tilemath.TileSize, tilemath.TileSize))
+			xdraw.ApproxBiLinear.Transform(dst, s2d, img, src, xdraw.Src, nil)
+			if !isEmptyTile(dst) {
+				tiles[pyramidTile{x, y}] = dst
+			}
+		}
+	}
+	return tiles
+}
+
+// downsampleLevel builds the next lower zoom by scaling each 2x2 block of child tiles
+func downsampleLevel(children map[pyramidTile]*image.RGBA) map[pyramidTile]*image.RGBA {
+	const size = tilemath.TileSize
+
+	mosaics := make(map[pyramidTile]*image.RGBA)
+	for child, data := range children {
+		parent := pyramidTile{child.X / 2, child.Y / 2}
+		mosaic, ok := mosaics[parent]
+		if !ok {
+			mosaic = image.NewRGBA(image.Rect(0, 0, size*2, size*2))
+			mosaics[parent] = mosaic
+		}
+		offset := image.Pt((child.X%2)*size, (child.Y%2)*size)
+		xdraw.Copy(mosaic, offset, data, data.Bounds(), xdraw.Src, nil)
+	}
+
+	parents := make(map[pyramidTile]*image.RGBA, len(mosaics))
+	for parent, mosaic := range mosaics {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), mosaic, mosaic.Bounds(), xdraw.Src, nil)
+		parents[parent] = dst
+	}
+	return parents
+}
+
+// savePyramidTile writes one tile as {dir}/{z}/{x}/{y}.png
+func savePyramidTile(dir string, z int, tile pyramidTile, img *image.RGBA) error {
+	xDir := filepath.Join(dir, strconv.Itoa(z), strconv.Itoa(tilemath.WrapColumn(tile.X, z)))
+	if err := os.MkdirAll(xDir, 0755); err != nil {
+		return fmt.Errorf("failed to create tile directory: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(xDir, strconv.Itoa(tile.Y)+".png"))
+	if err != nil {
+		return fmt.Errorf("failed to create tile: %w", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode tile %d/%d/%d: %w", z, tile.X, tile.Y, err)
+	}
+	return nil
+}
+
+// isEmptyTile reports whether a tile is fully transparent
+func isEmptyTile(img *image.RGBA) bool {
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+
 	"imagery-desktop/internal/ratelimit"
 )
 
@@ -52,6 +54,9 @@ type CacheStats struct {
 	SizeMB    float64 `json:"sizeMB"`
 	MaxMB     float64 `json:"maxMB"`
 	CachePath string  `json:"cachePath"`
+
+	MemoryEntries int     `json:"memoryEntries"` // Hot tiles held in the in-memory cache
+	MemoryMB      float64 `json:"memoryMB"`
 }
 
 // GetCacheStats returns current cache statistics
@@ -61,19 +66,32 @@ func (a *App) GetCacheStats() CacheStats {
 	}
 
 	entries, sizeBytes, maxBytes := a.tileCache.Stats()
+	memoryEntries, memoryBytes := a.tileCache.MemoryStats()
 
 	return CacheStats{
-		Entries:   entries,
-		SizeBytes: sizeBytes,
-		MaxBytes:  maxBytes,
-		SizeMB:    float64(sizeBytes) / 1024 / 1024,
-		MaxMB:     float64(maxBytes) / 1024 / 1024,
-		CachePath: a.tileCache.GetCachePath(),
+		Entries:       entries,
+		SizeBytes:     sizeBytes,
+		MaxBytes:      maxBytes,
+		SizeMB:        float64(sizeBytes) / 1024 / 1024,
+		MaxMB:         float64(maxBytes) / 1024 / 1024,
+		CachePath:     a.tileCache.GetCachePath(),
+		MemoryEntries: memoryEntries,
+		MemoryMB:      float64(memoryBytes) / 1024 / 1024,
 	}
 }
 
-// ClearCache removes all cached tiles
+// ClearCache removes all cached tiles, quadtree packets and learned epochs
 func (a *App) ClearCache() error {
+	if a.packetCache != nil {
+		if err := a.packetCache.Clear(); err != nil {
+			log.Printf("Failed to clear packet cache: %v", err)
+		}
+	}
+	if a.epochCache != nil {
+		if err := a.epochCache.Clear(); err != nil {
+			log.Printf("Failed to clear epoch cache: %v", err)
+		}
+	}
 	if a.tileCache != nil {
 		return a.tileCache.Clear()
 	}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+)
+
+// GetLastDownloadReport returns the tile failure report of the latest download run,
+// manual or queued: every tile that could not be exported with the reason (404, rate
+// limit, timeout, decode error, blank tile...), split into provider-side and network-side
+// failures. The same report is saved as download_report_<start>.json in the run's output
+// folder. Returns nil when nothing was downloaded yet.
+func (a *App) GetLastDownloadReport() (report *downloads.DownloadReport, err error) {
+	defer crash.Recover("GetLastDownloadReport", &err)
+
+	a.lastReportMu.Lock()
+	recorder := a.lastReport
+	a.lastReportMu.Unlock()
+	if recorder == nil {
+		return nil, nil
+	}
+	return recorder.Snapshot(), nil
+}
+
+// saveDownloadReport writes the report of a run that processed tiles into dir and makes
+// it the latest report, once. Runs without tiles (e.g. video-only exports) leave both as is.
+func (a *App) saveDownloadReport(recorder *downloads.ReportRecorder, dir string) {
+	if recorder.Tiles() == 0 || recorder.Path() != "" {
+		return
+	}
+	if path, err := recorder.Save(dir); err != nil {
+		log.Printf("[Report] Failed to save download report: %v", err)
+	} else {
+		log.Printf("[Report] Saved download report: %s", path)
+	}
+
+	a.lastReportMu.Lock()
+	a.lastReport = recorder
+	a.lastReportMu.Unlock()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/utils/diskspace"
+	"imagery-desktop/internal/video"
+)
+
+// Self-test check states, from best to worst
+const (
+	SelfTestPass = "pass"
+	SelfTestWarn = "warn"
+	SelfTestFail = "fail"
+)
+
+const (
+	// selfTestLowDiskBytes is the free space below which a volume is reported as low
+	selfTestLowDiskBytes = 1 << 30 // 1 GB
+
+	// selfTestMinDiskBytes is the free space below which downloads are bound to fail
+	selfTestMinDiskBytes = 100 << 20 // 100 MB
+)
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name   string `json:"name"`   // e.g. "Download folder", "FFmpeg", "Esri Wayback"
+	Status string `json:"status"` // "pass", "warn" or "fail"
+	Detail string `json:"detail"`
+}
+
+// SelfTestReport describes the environment the app runs in and whether everything
+// downloads and exports need works
+type SelfTestReport struct {
+	RanAt             string            `json:"ranAt"`
+	AppVersion        string            `json:"appVersion"`
+	OS                string            `json:"os"`
+	Arch              string            `json:"arch"`
+	CPUs              int               `json:"cpus"`
+	Status            string            `json:"status"` // Worst status of the checks
+	Checks            []SelfTestCheck   `json:"checks"`
+	DownloadPath      string            `json:"downloadPath"`
+	DownloadFreeBytes int64             `json:"downloadFreeBytes"` // -1 if unknown
+	CachePath         string            `json:"cachePath"`
+	CacheFreeBytes    int64             `json:"cacheFreeBytes"`      // -1 if unknown
+	FFmpeg            *video.FFmpegInfo `json:"ffmpeg,omitempty"`    // nil if FFmpeg was not found
+	Providers         []ProviderHealth  `json:"providers,omitempty"` // See CheckProviderHealth
+}
+
+// add appends a check and lowers the report's status to it when worse
+func (r *SelfTestReport) add(name, status, detail string) {
+	r.Checks = append(r.Checks, SelfTestCheck{Name: name, Status: status, Detail: detail})
+	if status == SelfTestFail || (status == SelfTestWarn && r.Status == SelfTestPass) {
+		r.Status = status
+	}
+}
+
+// RunSelfTest checks write access to the download and cache folders, their free disk
+// space, FFmpeg with the encoders exports use and the hardware encoders it was built with,
+// and the reachability of the imagery providers, for the settings screen
+func (a *App) RunSelfTest() (report *SelfTestReport, err error) {
+	defer crash.Recover("RunSelfTest", &err)
+	return a.runSelfTest(a.ctx), nil
+}
+
+func (a *App) runSelfTest(ctx context.Context) *SelfTestReport {
+	report := &SelfTestReport{
+		RanAt:        time.Now().Format(time.RFC3339),
+		AppVersion:   a.GetAppVersion(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		CPUs:         runtime.NumCPU(),
+		Status:       SelfTestPass,
+		Checks:       []SelfTestCheck{},
+		DownloadPath: a.GetDownloadPath(),
+	}
+	a.mu.Lock()
+	report.CachePath = config.GetCachePath(a.settings)
+	a.mu.Unlock()
+
+	// Providers are checked over the network meanwhile
+	providers := make(chan []ProviderHealth, 1)
+	go func() {
+		results, err := a.CheckProviderHealth()
+		if err != nil {
+			log.Printf("[SelfTest] Provider check failed: %v", err)
+		}
+		providers <- results
+	}()
+
+	report.DownloadFreeBytes = a.checkFolder(report, "Download folder", report.DownloadPath)
+	report.CacheFreeBytes = a.checkFolder(report, "Tile cache folder", report.CachePath)
+	if a.tileCache == nil {
+		report.add("Tile cache", SelfTestWarn, "The tile cache failed to open; tiles are fetched again on every download")
+	}
+
+	if info, err := video.InspectFFmpeg(ctx); err != nil {
+		report.add("FFmpeg", SelfTestWarn, fmt.Sprintf("%v; video exports are unavailable", err))
+	} else {
+		report.FFmpeg = info
+		report.add("FFmpeg", SelfTestPass, fmt.Sprintf("%s (%s)", info.Version, info.Path))
+		if len(info.MissingEncoders) > 0 {
+			report.add("Video codecs", SelfTestWarn, fmt.Sprintf("FFmpeg lacks %s; exports needing them will fail", strings.Join(info.MissingEncoders, ", ")))
+		} else {
+			report.add("Video codecs", SelfTestPass, strings.Join(video.RequiredEncoders, ", "))
+		}
+		if len(info.HardwareEncoders) > 0 {
+			report.add("GPU encoders", SelfTestPass, strings.Join(info.HardwareEncoders, ", "))
+		} else {
+			report.add("GPU encoders", SelfTestPass, "None; videos are encoded on the CPU")
+		}
+	}
+
+	report.Providers = <-providers
+	for _, p := range report.Providers {
+		detail := fmt.Sprintf("%s in %d ms", p.Status, p.LatencyMs)
+		if p.Hint != "" {
+			detail += ": " + p.Hint
+		}
+		switch p.Status {
+		case HealthReachable:
+			report.add(p.Name, SelfTestPass, detail)
+		case HealthRateLimited:
+			report.add(p.Name, SelfTestWarn, detail)
+		default:
+			report.add(p.Name, SelfTestFail, detail)
+		}
+	}
+	return report
+}
+
+// checkFolder adds checks for write access to dir (created if missing) and the free space
+// of its volume, and returns the free bytes (-1 if unknown)
+func (a *App) checkFolder(report *SelfTestReport, name, dir string) int64 {
+	if err := checkWritable(dir); err != nil {
+		report.add(name, SelfTestFail, fmt.Sprintf("%s is not writable: %v", dir, err))
+	} else {
+		report.add(name, SelfTestPass, dir)
+	}
+
+	free, err := diskspace.Free(existingParent(dir))
+	if err != nil {
+		report.add(name+" disk space", SelfTestWarn, fmt.Sprintf("Free space is unknown: %v", err))
+		return -1
+	}
+	detail := fmt.Sprintf("%.1f GB free", float64(free)/(1<<30))
+	switch {
+	case free < selfTestMinDiskBytes:
+		report.add(name+" disk space", SelfTestFail, detail)
+	case free < selfTestLowDiskBytes:
+		report.add(name+" disk space", SelfTestWarn, detail)
+	default:
+		report.add(name+" disk space", SelfTestPass, detail)
+	}
+	return int64(free)
+}
+
+// checkWritable creates dir if missing and writes and removes a file in it
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".selftest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("ok"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// logSelfTest runs the self-test at startup and logs the checks that did not pass
+func (a *App) logSelfTest(ctx context.Context) {
+	report := a.runSelfTest(ctx)
+	for _, check := range report.Checks {
+		if check.Status != SelfTestPass {
+			log.Printf("[SelfTest] %s: %s (%s)", check.Name, check.Status, check.Detail)
+		}
+	}
+	log.Printf("[SelfTest] Completed: %s (%d checks)", report.Status, len(report.Checks))
+}
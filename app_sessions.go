@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+)
+
+// ============
+// Map Sessions
+// ============
+
+// Map sessions hold independent map state (source, area, dates, selected date and its tile
+// URL) keyed by session ID, so split views can compare two areas or two dates side by side
+// without sharing state through the App.
+
+// MapSession is the state of one map view
+type MapSession struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Source       string       `json:"source"`         // Provider ID, e.g. "esri_wayback"
+	BBox         *BoundingBox `json:"bbox,omitempty"` // Selected area of interest
+	Zoom         int          `json:"zoom"`
+	Dates        []GEDateInfo `json:"dates"`                  // Dates available for the area, newest first
+	SelectedDate string       `json:"selectedDate,omitempty"` // YYYY-MM-DD
+	TileURL      string       `json:"tileUrl,omitempty"`      // Tile URL template for the selected date
+	CreatedAt    string       `json:"createdAt"`
+	UpdatedAt    string       `json:"updatedAt"`
+}
+
+// CreateMapSession starts a new map session showing source
+func (a *App) CreateMapSession(name string, source string) (MapSession, error) {
+	if _, err := a.providers.Get(source); err != nil {
+		return MapSession{}, err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	session := &MapSession{
+		ID:        fmt.Sprintf("session_%d", time.Now().UnixNano()),
+		Name:      name,
+		Source:    source,
+		Dates:     []GEDateInfo{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	a.sessionsMu.Lock()
+	a.mapSessions[session.ID] = session
+	result := copyMapSession(session)
+	a.sessionsMu.Unlock()
+
+	return result, nil
+}
+
+// GetMapSessions returns all open map sessions, oldest first
+func (a *App) GetMapSessions() []MapSession {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	sessions := make([]MapSession, 0, len(a.mapSessions))
+	for _, s := range a.mapSessions {
+		sessions = append(sessions, copyMapSession(s))
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions
+}
+
+// GetMapSession returns one map session
+func (a *App) GetMapSession(id string) (MapSession, error) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	s, ok := a.mapSessions[id]
+	if !ok {
+		return MapSession{}, fmt.Errorf("map session not found: %s", id)
+	}
+	return copyMapSession(s), nil
+}
+
+// CloseMapSession discards a map session
+func (a *App) CloseMapSession(id string) {
+	a.sessionsMu.Lock()
+	delete(a.mapSessions, id)
+	a.sessionsMu.Unlock()
+}
+
+// SetMapSessionSource switches a session to another provider. Its dates and selection are
+// cleared, since dates are per provider.
+func (a *App) SetMapSessionSource(id string, source string) (MapSession, error) {
+	if _, err := a.providers.Get(source); err != nil {
+		return MapSession{}, err
+	}
+	return a.updateMapSession(id, func(s *MapSession) error {
+		s.Source = source
+		s.Dates = []GEDateInfo{}
+		s.SelectedDate, s.TileURL = "", ""
+		return nil
+	})
+}
+
+// SetMapSessionArea sets a session's area of interest and zoom
+func (a *App) SetMapSessionArea(id string, bbox BoundingBox, zoom int) (MapSession, error) {
+	if err := bbox.toDownloadsBBox().Validate(); err != nil {
+		return MapSession{}, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	return a.updateMapSession(id, func(s *MapSession) error {
+		s.BBox = &bbox
+		s.Zoom = zoom
+		return nil
+	})
+}
+
+// RefreshMapSessionDates lists the dates available over a session's area and stores them
+// in the session. The previous selection is kept if it is still available.
+func (a *App) RefreshMapSessionDates(id string) (session MapSession, err error) {
+	defer crash.Recover("RefreshMapSessionDates", &err)
+
+	current, err := a.GetMapSession(id)
+	if err != nil {
+		return MapSession{}, err
+	}
+	if current.BBox == nil {
+		return MapSession{}, fmt.Errorf("map session has no area selected")
+	}
+
+	provider, err := a.providers.Get(current.Source)
+	if err != nil {
+		return MapSession{}, err
+	}
+	infos, err := provider.ListDates(current.BBox.toDownloadsBBox(), current.Zoom)
+	if err != nil {
+		return MapSession{}, err
+	}
+
+	dates := make([]GEDateInfo, len(infos))
+	for i, info := range infos {
+		dates[i] = GEDateInfo{Date: info.Date, HexDate: info.HexDate, Epoch: info.Epoch, Source: current.Source}
+	}
+
+	return a.updateMapSession(id, func(s *MapSession) error {
+		if s.Source != current.Source {
+			return fmt.Errorf("map session source changed while listing dates")
+		}
+		s.Dates = dates
+		if _, ok := findSessionDate(dates, s.SelectedDate); !ok {
+			s.SelectedDate, s.TileURL = "", ""
+		}
+		return nil
+	})
+}
+
+// SelectMapSessionDate selects one of a session's dates and resolves its tile URL template
+func (a *App) SelectMapSessionDate(id string, date string) (MapSession, error) {
+	current, err := a.GetMapSession(id)
+	if err != nil {
+		return MapSession{}, err
+	}
+
+	// Dates are only known once listed; otherwise the date is used as given
+	info, ok := findSessionDate(current.Dates, date)
+	if !ok {
+		if len(current.Dates) > 0 {
+			return MapSession{}, fmt.Errorf("%s is not available in this session", date)
+		}
+		info = GEDateInfo{Date: date, Source: current.Source}
+	}
+
+	tileURL, err := a.sessionTileURL(current.Source, info)
+	if err != nil {
+		return MapSession{}, err
+	}
+
+	return a.updateMapSession(id, func(s *MapSession) error {
+		s.SelectedDate = date
+		s.TileURL = tileURL
+		return nil
+	})
+}
+
+// sessionTileURL returns the tile server URL template for a provider's date
+func (a *App) sessionTileURL(source string, date GEDateInfo) (string, error) {
+	switch source {
+	case common.ProviderEsriWayback:
+		return a.GetEsriTileURL(date.Date)
+	case common.ProviderGoogleEarth:
+		if date.HexDate != "" {
+			return a.GetGoogleEarthHistoricalTileURL(date.Date, date.HexDate, date.Epoch)
+		}
+		return a.GetGoogleEarthTileURL(date.Date)
+	default:
+		return a.GetCustomSourceTileURL(source, date.Date)
+	}
+}
+
+// updateMapSession applies update to a session under the lock and emits the new state
+func (a *App) updateMapSession(id string, update func(*MapSession) error) (MapSession, error) {
+	a.sessionsMu.Lock()
+	s, ok := a.mapSessions[id]
+	if !ok {
+		a.sessionsMu.Unlock()
+		return MapSession{}, fmt.Errorf("map session not found: %s", id)
+	}
+	if err := update(s); err != nil {
+		a.sessionsMu.Unlock()
+		return MapSession{}, err
+	}
+	s.UpdatedAt = time.Now().Format(time.RFC3339)
+	result := copyMapSession(s)
+	a.sessionsMu.Unlock()
+
+	if a.ctx != nil {
+		wailsRuntime.EventsEmit(a.ctx, "map-session-updated", result)
+	}
+	return result, nil
+}
+
+// copyMapSession copies a session so callers never share its slices with the store
+func copyMapSession(s *MapSession) MapSession {
+	c := *s
+	c.Dates = append([]GEDateInfo{}, s.Dates...)
+	if s.BBox != nil {
+		bbox := *s.BBox
+		c.BBox = &bbox
+	}
+	return c
+}
+
+// findSessionDate looks up a date in a session's date list
+func findSessionDate(dates []GEDateInfo, date string) (GEDateInfo, bool) {
+	for _, d := range dates {
+		if d.Date == date {
+			return d, true
+		}
+	}
+	return GEDateInfo{}, false
+}
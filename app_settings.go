@@ -1,10 +1,31 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
 
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/common"
 	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/downloads/esri"
+	esriClient "imagery-desktop/internal/esri"
+	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/handlers/tileserver"
+	"imagery-desktop/internal/manifest"
+	"imagery-desktop/internal/netproxy"
+	"imagery-desktop/internal/taskqueue"
+	"imagery-desktop/internal/updater"
+	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/internal/utils/units"
+	"imagery-desktop/internal/video"
 	"imagery-desktop/internal/wmts"
 )
 
@@ -37,6 +58,93 @@ func (a *App) SaveSettings(settings *config.UserSettings) error {
 	if settings.CacheTTLDays <= 0 {
 		return fmt.Errorf("cache TTL must be positive")
 	}
+	if settings.CacheMemoryMB < 0 {
+		return fmt.Errorf("memory cache size cannot be negative")
+	}
+	if settings.RetentionMaxAgeDays < 0 || settings.RetentionKeepPerAOI < 0 {
+		return fmt.Errorf("retention limits cannot be negative")
+	}
+	if settings.MaxGeoTIFFDimension < 0 {
+		return fmt.Errorf("max GeoTIFF dimension cannot be negative")
+	}
+	if settings.EsriSampleGrid < 0 || settings.EsriSampleGrid > esri.MaxSampleGrid {
+		return fmt.Errorf("Esri sample grid must be between 0 and %d", esri.MaxSampleGrid)
+	}
+	if settings.PreviewWebPQuality < 0 || settings.PreviewWebPQuality > 100 {
+		return fmt.Errorf("WebP preview quality must be between 0 and 100")
+	}
+	tileOutput := downloads.TileOutput{Format: settings.TileFormat, Quality: settings.TileJPEGQuality}
+	if err := tileOutput.Validate(); err != nil {
+		return err
+	}
+	resampling, err := googleearth.ParseResampling(settings.ReprojectionQuality)
+	if err != nil {
+		return err
+	}
+	if err := units.Validate(settings.UnitSystem); err != nil {
+		return err
+	}
+	overlayDateLayout, err := naming.OverlayDateLayout(settings.OverlayDateFormat)
+	if err != nil {
+		return err
+	}
+	if err := naming.ValidateFilenameDateFormat(settings.FilenameDateFormat); err != nil {
+		return err
+	}
+	for _, path := range settings.OverlayFallbackFonts {
+		if err := video.CheckFontFile(path); err != nil {
+			return fmt.Errorf("overlay fallback font %s: %w", path, err)
+		}
+	}
+	bulkWindow := taskqueue.Window{Start: settings.BulkWindowStart, End: settings.BulkWindowEnd}
+	if err := bulkWindow.Validate(); err != nil {
+		return fmt.Errorf("bulk task %w", err)
+	}
+	if settings.ManifestSigningKey != "" {
+		if _, err := manifest.LoadSigningKey(settings.ManifestSigningKey); err != nil {
+			return err
+		}
+	}
+	if err := tileserver.ValidateListenAddress(settings.TileServerAddress); err != nil {
+		return err
+	}
+	for _, fingerprint := range settings.TrustedManifestKeys {
+		if err := manifest.ValidateFingerprint(fingerprint); err != nil {
+			return fmt.Errorf("trusted manifest key: %w", err)
+		}
+	}
+	if settings.FFmpegTimeoutMinutes < 0 {
+		return fmt.Errorf("FFmpeg timeout cannot be negative")
+	}
+	ffmpegArgs, err := video.SplitArgs(settings.FFmpegExtraArgs)
+	if err != nil {
+		return err
+	}
+	if settings.WatchFolder != "" {
+		if err := validateWatchFolder(settings.WatchFolder, settings.DownloadPath, settings.WatchFolderTemplate); err != nil {
+			return err
+		}
+	}
+	if !updater.ValidChannel(settings.UpdateChannel) {
+		return fmt.Errorf("update channel must be '%s' or '%s'", updater.ChannelStable, updater.ChannelBeta)
+	}
+
+	if settings.ProxyURL != "" {
+		if _, err := netproxy.Parse(settings.ProxyURL); err != nil {
+			return err
+		}
+	}
+	if err := config.ValidateProviderHeaders(settings.ProviderHeaders); err != nil {
+		return err
+	}
+
+	// Load the CA bundle before saving so a bad file is reported instead of persisted
+	if err := netproxy.ConfigureTLS(settings.CACertFile, settings.TLSInsecureSkipVerify); err != nil {
+		return err
+	}
+	if settings.TLSInsecureSkipVerify && !a.settings.TLSInsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification is DISABLED for all imagery requests")
+	}
 
 	// Save to disk
 	if err := config.SaveSettings(settings); err != nil {
@@ -46,18 +154,107 @@ func (a *App) SaveSettings(settings *config.UserSettings) error {
 	// Update app state
 	a.settings = settings
 	a.downloadPath = settings.DownloadPath
+	a.esriDownloader.SetMaxGeoTIFFDimension(settings.MaxGeoTIFFDimension)
+	a.esriDownloader.SetBuildOverviews(settings.GeoTIFFOverviews)
+	a.esriDownloader.SetSavePNGCopies(settings.SavePNGSidecars)
+	a.esriDownloader.SetSampleGrid(settings.EsriSampleGrid)
+	a.esriDownloader.SetTileOutput(tileOutput)
+	a.customDownloader.SetMaxGeoTIFFDimension(settings.MaxGeoTIFFDimension)
+	a.customDownloader.SetBuildOverviews(settings.GeoTIFFOverviews)
+	a.customDownloader.SetSavePNGCopies(settings.SavePNGSidecars)
+	a.customDownloader.SetTileOutput(tileOutput)
+	a.videoManager.SetFFmpegOptions(time.Duration(settings.FFmpegTimeoutMinutes)*time.Minute, ffmpegArgs)
+	a.videoManager.SetOverlayDateLayout(overlayDateLayout)
+	a.videoManager.SetOverlayFallbackFonts(settings.OverlayFallbackFonts)
+	a.videoManager.SetUnitSystem(settings.UnitSystem)
+	naming.SetFilenameDateFormat(settings.FilenameDateFormat)
+	a.taskQueue.SetBulkWindow(bulkWindow)
+	a.customClient.SetSources(settings.CustomSources)
+	a.customClient.SetAPIKeys(settings.MapboxAccessToken, settings.MapTilerAPIKey)
+	a.configureCrashReporting(settings)
+	if err := netproxy.Configure(settings.ProxyURL, settings.ProxyUsername, settings.ProxyPassword, settings.ProxyBypass); err != nil {
+		log.Printf("Failed to apply proxy settings: %v", err)
+	}
+	a.applyProviderHeaders(settings)
+	if a.geDownloader != nil {
+		a.geDownloader.SetMaxGeoTIFFDimension(settings.MaxGeoTIFFDimension)
+		a.geDownloader.SetBuildOverviews(settings.GeoTIFFOverviews)
+		a.geDownloader.SetSavePNGCopies(settings.SavePNGSidecars)
+		a.geDownloader.SetNativeCRS(settings.GENativeCRS)
+		a.geDownloader.SetTileOutput(tileOutput)
+	}
+
+	if a.tileCache != nil {
+		a.tileCache.SetTTL(settings.CacheTTLDays)
+		a.tileCache.SetMemoryLimit(settings.CacheMemoryMB)
+	}
+	if a.tileServer != nil {
+		a.tileServer.SetWebPPreview(settings.PreviewWebP, settings.PreviewWebPQuality)
+		a.tileServer.SetResampling(resampling)
+		a.tileServer.SetFallbackTileOutput(tileOutput)
+		a.tileServer.SetMetricsEndpoint(settings.MetricsEndpoint)
+		a.tileServer.SetAdvancedEpochs(settings.AdvancedEpochs)
+	}
 
-	// Note: Cache settings require app restart to take effect
-	log.Printf("Settings saved. Cache settings will apply on next restart.")
+	// Note: Cache location and size, and the tile server address, require app restart to take effect
+	log.Printf("Settings saved. Cache location and size and the tile server address will apply on next restart.")
 
 	return nil
 }
 
+// applyProviderHeaders pushes the per-provider header overrides to the imagery clients
+func (a *App) applyProviderHeaders(settings *config.UserSettings) {
+	a.esriClient.SetHeaderOverrides(settings.ProviderHeaders[common.ProviderEsriWayback])
+	a.geClient.SetHeaderOverrides(settings.ProviderHeaders[common.ProviderGoogleEarth])
+}
+
+// TestProxy checks that imagery servers are reachable through a proxy before it is saved
+func (a *App) TestProxy(proxyURL, username, password string) error {
+	u, err := netproxy.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		u.User = url.UserPassword(username, password)
+	}
+
+	client := &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: &http.Transport{Proxy: http.ProxyURL(u), TLSClientConfig: netproxy.TLSConfig()},
+	}
+	resp, err := client.Get(esriClient.WayBackCapabilitiesURL)
+	if err != nil {
+		return fmt.Errorf("proxy connection failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return fmt.Errorf("proxy rejected the credentials (HTTP 407)")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("imagery server returned HTTP %d through the proxy", resp.StatusCode)
+	}
+	return nil
+}
+
+// unitSystem returns the unit system resolutions and distances are formatted in
+func (a *App) unitSystem() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.settings.UnitSystem
+}
+
 // GetSettingsPath returns the OS-specific settings file path
 func (a *App) GetSettingsPath() string {
 	return config.GetSettingsPath()
 }
 
+// GetSettingsLoadError returns why the settings file could not be read at startup, so the
+// UI can tell the user defaults are in use ("" when it loaded)
+func (a *App) GetSettingsLoadError() string {
+	return a.settingsLoadError
+}
+
 // SaveMapPosition saves the current map position for session persistence
 // Called on app close or periodically to remember the last viewed location
 func (a *App) SaveMapPosition(lat, lon, zoom float64) error {
@@ -76,6 +273,80 @@ func (a *App) SaveMapPosition(lat, lon, zoom float64) error {
 	return nil
 }
 
+// ExportSettings writes the settings, custom sources and date filter presets to a JSON
+// bundle chosen in a save dialog, for ImportSettings on another workstation. API keys and
+// the proxy password are only included with includeSecrets. Returns the path of the
+// written bundle ("" if the user cancelled).
+func (a *App) ExportSettings(includeSecrets bool) (path string, err error) {
+	defer crash.Recover("ExportSettings", &err)
+
+	path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:            "Export Settings",
+		DefaultDirectory: a.GetDownloadPath(),
+		DefaultFilename:  fmt.Sprintf("imagery-desktop-settings_%s.json", time.Now().Format("20060102")),
+		Filters:          []wailsRuntime.FileFilter{{DisplayName: "Settings Bundles (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	a.mu.Lock()
+	bundle := config.NewBundle(a.settings, AppVersion, includeSecrets)
+	a.mu.Unlock()
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write settings bundle: %w", err)
+	}
+
+	log.Printf("Exported settings to %s (secrets included: %v)", path, includeSecrets)
+	return path, nil
+}
+
+// ImportSettings applies a settings bundle chosen in an open dialog. Local paths, map
+// state and secrets missing from the bundle are kept; custom sources and date filter
+// presets are merged by name. The result is validated and applied like SaveSettings.
+// Returns the new settings (nil if the user cancelled).
+func (a *App) ImportSettings() (settings *config.UserSettings, err error) {
+	defer crash.Recover("ImportSettings", &err)
+
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title:   "Import Settings",
+		Filters: []wailsRuntime.FileFilter{{DisplayName: "Settings Bundles (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings bundle: %w", err)
+	}
+	bundle, err := config.ParseBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundle.CustomSources {
+		if err := config.ValidateCustomSource(&bundle.CustomSources[i]); err != nil {
+			return nil, fmt.Errorf("custom source %q: %w", bundle.CustomSources[i].Name, err)
+		}
+	}
+
+	a.mu.Lock()
+	settings = bundle.Apply(a.settings)
+	a.mu.Unlock()
+
+	if err := a.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Imported settings from %s (%d custom sources, %d date filters)", path, len(bundle.CustomSources), len(bundle.DateFilterPatterns))
+	return a.GetSettings()
+}
+
 // ===================
 // Custom Sources
 // ===================
@@ -95,10 +366,14 @@ func (a *App) AddCustomSource(source config.CustomSource) error {
 		if existing.Name == source.Name {
 			return fmt.Errorf("source with name '%s' already exists", source.Name)
 		}
+		if common.CustomProviderID(existing.Name) == common.CustomProviderID(source.Name) {
+			return fmt.Errorf("source name '%s' is too similar to existing source '%s'", source.Name, existing.Name)
+		}
 	}
 
 	// Add to settings
 	a.settings.CustomSources = append(a.settings.CustomSources, source)
+	a.customClient.SetSources(a.settings.CustomSources)
 
 	// Save settings
 	if err := config.SaveSettings(a.settings); err != nil {
@@ -130,6 +405,7 @@ func (a *App) RemoveCustomSource(name string) error {
 	}
 
 	a.settings.CustomSources = newSources
+	a.customClient.SetSources(a.settings.CustomSources)
 
 	// Save settings
 	if err := config.SaveSettings(a.settings); err != nil {
@@ -163,6 +439,7 @@ func (a *App) UpdateCustomSource(name string, source config.CustomSource) error
 	if !found {
 		return fmt.Errorf("source '%s' not found", name)
 	}
+	a.customClient.SetSources(a.settings.CustomSources)
 
 	// Save settings
 	if err := config.SaveSettings(a.settings); err != nil {
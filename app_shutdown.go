@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"imagery-desktop/internal/crash"
+)
+
+const (
+	// shutdownGrace is how long closing the window waits for the running queue task and
+	// for manual downloads before cancelling them
+	shutdownGrace = 15 * time.Second
+
+	// shutdownServerTimeout bounds the wait for in-flight tile server requests
+	shutdownServerTimeout = 3 * time.Second
+)
+
+// beginDownload registers a manual download or export, started by the binding name, so
+// closing the window waits for it. It returns the context the download runs in (cancelled
+// if it outlives the shutdown grace period), carrying the download's operation (see
+// app_operations.go), and a function to call with the download's error when it ends.
+func (a *App) beginDownload(name string) (context.Context, func(*error), error) {
+	a.downloadsMu.Lock()
+	defer a.downloadsMu.Unlock()
+
+	if a.closing {
+		return nil, nil, fmt.Errorf("app is shutting down")
+	}
+	a.downloadsWg.Add(1)
+	ctx, finish := a.startOperation(a.downloadsCtx, name)
+	return ctx, func(err *error) {
+		finish(*err)
+		a.downloadsWg.Done()
+	}, nil
+}
+
+// beforeClose runs when the window is closed. It stops the queue (the running task gets
+// a grace period, then is interrupted so it resumes from its checkpoint), waits for manual
+// downloads, closes the tile server and flushes the tile cache index and the learned
+// epochs, so closing mid-export does not leave corrupt outputs behind, then starts an
+// installed update if there is one. The window always closes.
+func (a *App) beforeClose(ctx context.Context) (prevent bool) {
+	defer crash.Recover("BeforeClose", nil)
+	log.Printf("Shutting down...")
+
+	a.downloadsMu.Lock()
+	a.closing = true
+	a.downloadsMu.Unlock()
+
+	a.prefetchMu.Lock()
+	if a.cancelPrefetch != nil {
+		a.cancelPrefetch()
+	}
+	a.prefetchMu.Unlock()
+
+	if a.taskQueue != nil && !a.taskQueue.Shutdown(shutdownGrace) {
+		log.Printf("Task queue did not stop in time")
+	}
+
+	downloadsDone := make(chan struct{})
+	go func() {
+		a.downloadsWg.Wait()
+		close(downloadsDone)
+	}()
+	select {
+	case <-downloadsDone:
+	case <-time.After(shutdownGrace):
+		log.Printf("Cancelling downloads still running after %s", shutdownGrace)
+		if a.cancelDownloads != nil {
+			a.cancelDownloads()
+		}
+		select {
+		case <-downloadsDone:
+		case <-time.After(shutdownGrace):
+			log.Printf("Downloads did not stop in time")
+		}
+	}
+
+	if a.tileServer != nil {
+		serverCtx, cancel := context.WithTimeout(context.Background(), shutdownServerTimeout)
+		if err := a.tileServer.Shutdown(serverCtx); err != nil {
+			log.Printf("Failed to stop tile server: %v", err)
+		}
+		cancel()
+	}
+
+	if a.tileCache != nil {
+		if err := a.tileCache.Flush(); err != nil {
+			log.Printf("Failed to flush tile cache index: %v", err)
+		}
+	}
+	if a.epochCache != nil {
+		if err := a.epochCache.Flush(); err != nil {
+			log.Printf("Failed to save learned epochs: %v", err)
+		}
+	}
+
+	log.Printf("Shutdown complete")
+	a.relaunchAfterUpdate()
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/taskqueue"
+)
+
+// TaskStorageUsage is the disk usage of one task's output directory
+type TaskStorageUsage struct {
+	TaskID       string `json:"taskId"`
+	Name         string `json:"name"`
+	Source       string `json:"source"`
+	Status       string `json:"status"`
+	CompletedAt  string `json:"completedAt,omitempty"`
+	OutputPath   string `json:"outputPath"`
+	SizeBytes    int64  `json:"sizeBytes"`    // Currently on disk
+	BytesWritten int64  `json:"bytesWritten"` // Written when the task completed
+	Shared       bool   `json:"shared"`       // Video-only task writing into its dependency's folder
+}
+
+// StorageUsage summarizes disk used by exports and the tile cache
+type StorageUsage struct {
+	DownloadPath  string             `json:"downloadPath"`
+	DownloadBytes int64              `json:"downloadBytes"` // Whole download folder, including task folders
+	CachePath     string             `json:"cachePath"`
+	CacheBytes    int64              `json:"cacheBytes"`
+	Tasks         []TaskStorageUsage `json:"tasks"` // Largest first
+
+	// Historical bytes written by completed tasks, per provider (kept after deletion)
+	WrittenByProvider map[string]int64 `json:"writtenByProvider"`
+	WrittenTotal      int64            `json:"writtenTotal"`
+}
+
+// GetStorageUsage reports the size of the download folder and tile cache, a per-task
+// breakdown of output folders, and the running bytes-written totals per provider
+func (a *App) GetStorageUsage() (usage StorageUsage, err error) {
+	defer crash.Recover("GetStorageUsage", &err)
+
+	usage.DownloadPath = a.GetDownloadPath()
+	usage.DownloadBytes = taskqueue.DirSize(usage.DownloadPath)
+
+	if a.tileCache != nil {
+		_, usage.CacheBytes, _ = a.tileCache.Stats()
+		usage.CachePath = a.tileCache.GetCachePath()
+	}
+
+	usage.Tasks = []TaskStorageUsage{}
+	for _, task := range a.taskQueue.GetAllTasks() {
+		if task.OutputPath == "" {
+			continue
+		}
+		entry := TaskStorageUsage{
+			TaskID:       task.ID,
+			Name:         task.Name,
+			Source:       task.Source,
+			Status:       string(task.Status),
+			CompletedAt:  task.CompletedAt,
+			OutputPath:   task.OutputPath,
+			BytesWritten: task.BytesWritten,
+			Shared:       task.IsVideoOnly(),
+		}
+		if entry.Shared {
+			// The folder belongs to the dependency; only count what this task added
+			entry.SizeBytes = task.BytesWritten
+		} else {
+			entry.SizeBytes = taskqueue.DirSize(task.OutputPath)
+		}
+		usage.Tasks = append(usage.Tasks, entry)
+	}
+	sort.Slice(usage.Tasks, func(i, j int) bool { return usage.Tasks[i].SizeBytes > usage.Tasks[j].SizeBytes })
+
+	usage.WrittenByProvider, usage.WrittenTotal = a.taskQueue.Usage().Totals()
+	return usage, nil
+}
+
+// ClearTaskOutput deletes a task's output folder to free disk space. The task stays in
+// the queue history. Video-only tasks share their dependency's folder, so their outputs
+// must be deleted individually with DeleteTaskOutput.
+func (a *App) ClearTaskOutput(taskID string) (err error) {
+	defer crash.Recover("ClearTaskOutput", &err)
+
+	task, err := a.taskQueue.GetTask(taskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.Status == taskqueue.TaskStatusRunning {
+		return fmt.Errorf("task is still running")
+	}
+	size, err := a.removeTaskOutput(task)
+	if err != nil {
+		return err
+	}
+	a.emitLog(fmt.Sprintf("Cleared output of task %s (%.1f MB freed)", task.Name, float64(size)/1024/1024))
+	return nil
+}
+
+// removeTaskOutput deletes a task's output folder and returns the bytes freed. Only folders
+// owned by the task (not a video task's shared folder) inside the download folder are removed.
+func (a *App) removeTaskOutput(task *taskqueue.ExportTask) (int64, error) {
+	if task.IsVideoOnly() {
+		return 0, fmt.Errorf("video tasks share their source task's folder; delete individual outputs instead")
+	}
+	if task.OutputPath == "" {
+		return 0, fmt.Errorf("task has no output")
+	}
+
+	rel, err := filepath.Rel(a.GetDownloadPath(), task.OutputPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, fmt.Errorf("task output %s is not in the download folder", task.OutputPath)
+	}
+
+	size := taskqueue.DirSize(task.OutputPath)
+	if err := os.RemoveAll(task.OutputPath); err != nil {
+		return 0, fmt.Errorf("failed to delete task output: %w", err)
+	}
+	return size, nil
+}
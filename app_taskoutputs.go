@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/video"
+	"imagery-desktop/pkg/geotiff"
+)
+
+// Task output file types reported to the frontend
+const (
+	OutputTypeGeoTIFF = "geotiff"
+	OutputTypeVRT     = "vrt"
+	OutputTypePNG     = "png"
+	OutputTypeJPEG    = "jpeg"
+	OutputTypeTiles   = "tiles"
+	OutputTypeVideo   = "video"
+	OutputTypeOther   = "other"
+)
+
+// TaskOutputFile is one file (or tile directory) produced by a task
+type TaskOutputFile struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name"` // Path relative to the task output directory
+	Type     string    `json:"type"`
+	Size     int64     `json:"size"` // Bytes; the total of all tiles for tile directories
+	ModTime  time.Time `json:"modTime"`
+	Width    int       `json:"width,omitempty"`
+	Height   int       `json:"height,omitempty"`
+	Duration float64   `json:"duration,omitempty"` // Seconds, videos only
+	Tiles    int       `json:"tiles,omitempty"`    // Tile count, tile directories only
+}
+
+// GetTaskOutputs lists the files a task produced, with sizes, types and (where they can be
+// read) pixel dimensions and video durations. Tile pyramids are reported as one entry.
+func (a *App) GetTaskOutputs(taskID string) (outputs []TaskOutputFile, err error) {
+	defer crash.Recover("GetTaskOutputs", &err)
+
+	root, err := a.taskOutputDir(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs = []TaskOutputFile{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+
+		if d.IsDir() {
+			if strings.HasSuffix(d.Name(), "_tiles") {
+				outputs = append(outputs, tileDirOutput(path, rel))
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		outputs = append(outputs, describeOutput(path, rel, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task outputs: %w", err)
+	}
+
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	return outputs, nil
+}
+
+// DeleteTaskOutput removes one file (or tile directory) from a task's output directory
+func (a *App) DeleteTaskOutput(taskID string, path string) (err error) {
+	defer crash.Recover("DeleteTaskOutput", &err)
+
+	root, err := a.taskOutputDir(taskID)
+	if err != nil {
+		return err
+	}
+
+	// Only delete inside the task's own directory
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is not an output of this task", filepath.Base(path))
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", filepath.Base(path), err)
+	}
+	a.emitLog(fmt.Sprintf("Deleted task output: %s", rel))
+	return nil
+}
+
+// RevealFile shows a file selected in the OS file explorer
+func (a *App) RevealFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("file does not exist: %s", path)
+	}
+
+	var cmd *exec.Cmd
+	switch goruntime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	case "windows":
+		cmd = exec.Command("explorer", "/select,", path)
+	default: // Linux file managers have no common "select" flag; open the parent folder
+		return a.OpenFolder(filepath.Dir(path))
+	}
+	return cmd.Start()
+}
+
+// taskOutputDir returns the output directory of a task that has produced files
+func (a *App) taskOutputDir(taskID string) (string, error) {
+	task, err := a.taskQueue.GetTask(taskID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.OutputPath == "" {
+		return "", fmt.Errorf("task has no output yet")
+	}
+	if _, err := os.Stat(task.OutputPath); err != nil {
+		return "", fmt.Errorf("task output folder is missing: %s", task.OutputPath)
+	}
+	return task.OutputPath, nil
+}
+
+// describeOutput classifies a file by extension and reads its dimensions or duration
+func describeOutput(path, rel string, info fs.FileInfo) TaskOutputFile {
+	out := TaskOutputFile{Path: path, Name: rel, Type: OutputTypeOther, Size: info.Size(), ModTime: info.ModTime()}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tif", ".tiff":
+		out.Type = OutputTypeGeoTIFF
+		if geo, err := geotiff.ReadGeoreference(path); err == nil {
+			out.Width, out.Height = geo.Width, geo.Height
+		}
+	case ".vrt":
+		out.Type = OutputTypeVRT
+	case ".png":
+		out.Type = OutputTypePNG
+		out.Width, out.Height = imageSize(path)
+	case ".jpg", ".jpeg":
+		out.Type = OutputTypeJPEG
+		out.Width, out.Height = imageSize(path)
+	case ".mp4", ".mov", ".avi", ".gif", ".webp":
+		out.Type = OutputTypeVideo
+		if probe, err := video.ProbeVideo(path); err == nil {
+			out.Width, out.Height, out.Duration = probe.Width, probe.Height, probe.Duration
+		} else {
+			out.Width, out.Height = imageSize(path)
+		}
+	}
+	return out
+}
+
+// tileDirOutput summarizes a tile pyramid directory as a single entry
+func tileDirOutput(path, rel string) TaskOutputFile {
+	out := TaskOutputFile{Path: path, Name: rel, Type: OutputTypeTiles}
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			out.Size += info.Size()
+			out.Tiles++
+			if info.ModTime().After(out.ModTime) {
+				out.ModTime = info.ModTime()
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// imageSize reads an image's dimensions from its header; 0x0 when unreadable
+func imageSize(path string) (int, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
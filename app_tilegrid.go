@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/internal/tilemath"
+)
+
+// Tile grid preview (Wails-exported)
+// Shows the exact tiles a download would fetch, so the area can be trimmed before downloading
+
+// maxGridFeatures bounds the footprints returned by GetTileGridGeoJSON; larger grids
+// only report their counts
+const maxGridFeatures = 10000
+
+// TileGridCollection is a GeoJSON FeatureCollection of the tiles a download would fetch
+type TileGridCollection struct {
+	Type     string         `json:"type"` // Always "FeatureCollection"
+	Features []TileGridCell `json:"features"`
+
+	Source          string `json:"source"`
+	Zoom            int    `json:"zoom"`
+	Total           int    `json:"total"`           // Tiles that would be downloaded
+	Cols            int    `json:"cols"`            // Tiles per row
+	Rows            int    `json:"rows"`            // Tiles per column
+	FeaturesOmitted bool   `json:"featuresOmitted"` // More than maxGridFeatures tiles; only counts are set
+
+	// Share (0-1) of the outermost row or column that lies inside the area. An edge
+	// with a low share costs a whole row or column of tiles for a sliver of imagery.
+	EdgeCoverage TileGridEdges `json:"edgeCoverage"`
+}
+
+// TileGridEdges holds a value per edge of a tile grid
+type TileGridEdges struct {
+	North float64 `json:"north"`
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	East  float64 `json:"east"`
+}
+
+// TileGridCell is a GeoJSON Feature outlining one tile of the grid
+type TileGridCell struct {
+	Type       string             `json:"type"` // Always "Feature"
+	Geometry   GeoJSONPolygon     `json:"geometry"`
+	Properties TileGridProperties `json:"properties"`
+}
+
+// TileGridProperties identifies a tile in its source's grid and in the download grid
+type TileGridProperties struct {
+	Z   int `json:"z"`
+	X   int `json:"x"`   // Tile column in the source's grid
+	Y   int `json:"y"`   // Tile row in the source's grid (XYZ: from the north, Google Earth: from the south)
+	Col int `json:"col"` // Column in the download grid, 0 at the west edge
+	Row int `json:"row"` // Row in the download grid, 0 at the north edge
+}
+
+// GetTileGridGeoJSON returns the footprints of the tiles a download of bbox at zoom from
+// source (a provider ID) would fetch, with the grid size and how much of each edge row and
+// column lies inside the area, without requesting anything
+func (a *App) GetTileGridGeoJSON(bbox BoundingBox, zoom int, source string) (grid *TileGridCollection, err error) {
+	defer crash.Recover("GetTileGridGeoJSON", &err)
+
+	box := bbox.toDownloadsBBox()
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	provider, err := a.providers.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if minZoom, maxZoom := provider.ZoomRange(); zoom < minZoom || zoom > maxZoom {
+		return nil, fmt.Errorf("zoom %d outside %d-%d for %s", zoom, minZoom, maxZoom, provider.Name())
+	}
+
+	grid = &TileGridCollection{Type: "FeatureCollection", Features: []TileGridCell{}, Source: source, Zoom: zoom}
+	n := tilemath.NumTiles(zoom)
+
+	if provider.TileScheme() == providers.SchemeGoogleEarth {
+		minRow, minCol, maxRow, maxCol := tilemath.GERange(box.South, box.West, box.North, box.East, zoom)
+		grid.Cols, grid.Rows = maxCol-minCol+1, maxRow-minRow+1
+
+		southRow, westCol := tilemath.LatLonToGEFrac(box.South, box.West, zoom)
+		northRow, eastCol := tilemath.LatLonToGEFrac(box.North, box.East, zoom)
+		grid.EdgeCoverage.South, grid.EdgeCoverage.North = edgeCoverage(southRow, northRow)
+		grid.EdgeCoverage.West, grid.EdgeCoverage.East = edgeCoverage(westCol, unwrapFrac(westCol, eastCol, n))
+
+		if grid.Cols*grid.Rows <= maxGridFeatures {
+			for row := maxRow; row >= minRow; row-- {
+				for col := minCol; col <= maxCol; col++ {
+					x := tilemath.WrapColumn(col, zoom)
+					south, west, north, east := tilemath.GEBounds(row, x, zoom)
+					grid.Features = append(grid.Features, tileGridCell(south, west, north, east, zoom, x, row, col-minCol, maxRow-row))
+				}
+			}
+		}
+	} else {
+		if err := box.ValidateWebMercator(provider.Name()); err != nil {
+			return nil, err
+		}
+		minX, minY, maxX, maxY := tilemath.XYZRange(box.South, box.West, box.North, box.East, zoom)
+		grid.Cols, grid.Rows = maxX-minX+1, maxY-minY+1
+
+		westX, northY := tilemath.LatLonToXYZFrac(box.North, box.West, zoom)
+		eastX, southY := tilemath.LatLonToXYZFrac(box.South, box.East, zoom)
+		grid.EdgeCoverage.North, grid.EdgeCoverage.South = edgeCoverage(northY, southY)
+		grid.EdgeCoverage.West, grid.EdgeCoverage.East = edgeCoverage(westX, unwrapFrac(westX, eastX, n))
+
+		if grid.Cols*grid.Rows <= maxGridFeatures {
+			for y := minY; y <= maxY; y++ {
+				for col := minX; col <= maxX; col++ {
+					x := tilemath.WrapColumn(col, zoom)
+					south, west, north, east := tilemath.XYZBounds(x, y, zoom)
+					grid.Features = append(grid.Features, tileGridCell(south, west, north, east, zoom, x, y, col-minX, y-minY))
+				}
+			}
+		}
+	}
+
+	grid.Total = grid.Cols * grid.Rows
+	grid.FeaturesOmitted = grid.Total > maxGridFeatures
+	return grid, nil
+}
+
+// tileGridCell returns the GeoJSON footprint of a tile at grid position col, row
+func tileGridCell(south, west, north, east float64, z, x, y, col, row int) TileGridCell {
+	return TileGridCell{
+		Type:       "Feature",
+		Geometry:   rectPolygon(south, west, north, east),
+		Properties: TileGridProperties{Z: z, X: x, Y: y, Col: col, Row: row},
+	}
+}
+
+// unwrapFrac moves the east edge of an antimeridian-crossing range past the last column
+func unwrapFrac(west, east float64, n int) float64 {
+	if east < west {
+		return east + float64(n)
+	}
+	return east
+}
+
+// edgeCoverage returns the share of the first and last tile along an axis that the range
+// lo-hi (fractional tile coordinates, lo <= hi) covers
+func edgeCoverage(lo, hi float64) (first, last float64) {
+	first = math.Min(hi, math.Floor(lo)+1) - lo
+	last = hi - math.Max(lo, math.Floor(hi))
+	return math.Max(0, math.Min(1, first)), math.Max(0, math.Min(1, last))
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	esriClient "imagery-desktop/internal/esri"
+	"imagery-desktop/internal/googleearth"
+)
+
+// Imagery Timeline (Wails-exported)
+// Merges Esri Wayback and Google Earth historical dates into one normalized timeline
+
+// TimelineSourceEntry describes one source's imagery for a timeline date
+type TimelineSourceEntry struct {
+	Source        string  `json:"source"`
+	Coverage      float64 `json:"coverage"`              // Fraction of sampled tiles with this date (0-1)
+	TilesWithDate int     `json:"tilesWithDate"`         // Number of sampled tiles reporting this date
+	SampledTiles  int     `json:"sampledTiles"`          // Number of tiles successfully sampled for this source
+	CaptureDate   string  `json:"captureDate,omitempty"` // Esri only: actual capture date (Date is the layer date)
+	Epoch         int     `json:"epoch,omitempty"`       // Google Earth only: most common epoch
+	HexDate       string  `json:"hexDate,omitempty"`     // Google Earth only
+}
+
+// TimelineDate groups all sources that have imagery on a given date
+type TimelineDate struct {
+	Date    string                `json:"date"` // YYYY-MM-DD
+	Year    int                   `json:"year"`
+	Month   int                   `json:"month"`
+	Sources []TimelineSourceEntry `json:"sources"`
+}
+
+// TimelineSourceSummary summarizes a source's contribution to the timeline
+type TimelineSourceSummary struct {
+	Source       string `json:"source"`
+	DateCount    int    `json:"dateCount"`
+	SampledTiles int    `json:"sampledTiles"`
+	Error        string `json:"error,omitempty"` // Set when the source could not be queried
+}
+
+// ImageryTimeline is the unified date picker model for an area
+type ImageryTimeline struct {
+	Dates    []TimelineDate          `json:"dates"` // Newest first
+	Sources  []TimelineSourceSummary `json:"sources"`
+	Earliest string                  `json:"earliest,omitempty"`
+	Latest   string                  `json:"latest,omitempty"`
+}
+
+// timelineSample holds per-source results before merging
+type timelineSample struct {
+	source  ImagerySource
+	entries map[string]TimelineSourceEntry // date -> entry
+	sampled int
+	err     error
+}
+
+// GetImageryTimeline returns a merged timeline of Esri Wayback and Google Earth dates for an area
+// Each source samples the same points across the viewport; coverage is the fraction of sampled
+// tiles that report a date, so the frontend can de-emphasize dates that only cover part of the area
+func (a *App) GetImageryTimeline(bbox BoundingBox, zoom int) (*ImageryTimeline, error) {
+	a.emitLog(fmt.Sprintf("Building imagery timeline for zoom %d...", zoom))
+
+	points := timelineSamplePoints(bbox)
+
+	var wg sync.WaitGroup
+	samples := make([]*timelineSample, 2)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		samples[0] = a.sampleEsriTimeline(points, zoom)
+	}()
+	go func() {
+		defer wg.Done()
+		samples[1] = a.sampleGoogleEarthTimeline(points, zoom)
+	}()
+	wg.Wait()
+
+	timeline := &ImageryTimeline{}
+	byDate := make(map[string]*TimelineDate)
+	failed := 0
+
+	for _, s := range samples {
+		summary := TimelineSourceSummary{
+			Source:       string(s.source),
+			DateCount:    len(s.entries),
+			SampledTiles: s.sampled,
+		}
+		if s.err != nil {
+			summary.Error = s.err.Error()
+			failed++
+			log.Printf("[Timeline] %s unavailable: %v", s.source, s.err)
+		}
+		timeline.Sources = append(timeline.Sources, summary)
+
+		for date, entry := range s.entries {
+			td, exists := byDate[date]
+			if !exists {
+				td = &TimelineDate{Date: date}
+				fmt.Sscanf(date, "%d-%d", &td.Year, &td.Month)
+				byDate[date] = td
+			}
+			td.Sources = append(td.Sources, entry)
+		}
+	}
+
+	if failed == len(samples) {
+		return nil, fmt.Errorf("failed to query any imagery source for this area")
+	}
+
+	for _, td := range byDate {
+		// Keep source order stable within a date
+		sort.Slice(td.Sources, func(i, j int) bool {
+			return td.Sources[i].Source < td.Sources[j].Source
+		})
+		timeline.Dates = append(timeline.Dates, *td)
+	}
+
+	// Newest first, matching GetGoogleEarthDatesForArea
+	sort.Slice(timeline.Dates, func(i, j int) bool {
+		return timeline.Dates[i].Date > timeline.Dates[j].Date
+	})
+
+	if len(timeline.Dates) > 0 {
+		timeline.Latest = timeline.Dates[0].Date
+		timeline.Earliest = timeline.Dates[len(timeline.Dates)-1].Date
+	}
+
+	a.emitLog(fmt.Sprintf("Timeline has %d dates (%s to %s)", len(timeline.Dates), timeline.Earliest, timeline.Latest))
+	return timeline, nil
+}
+
+// timelineSamplePoints returns the center and quadrant centers of the bbox
+// Same pattern as GetGoogleEarthDatesForArea so both sources see the same locations
+func timelineSamplePoints(bbox BoundingBox) []struct{ lat, lon float64 } {
+	lon := bbox.toDownloadsBBox().LonAt // Wraps across the antimeridian
+	return []struct{ lat, lon float64 }{
+		{(bbox.South + bbox.North) / 2, lon(0.5)},              // Center
+		{bbox.North - (bbox.North-bbox.South)*0.25, lon(0.25)}, // NW quadrant
+		{bbox.North - (bbox.North-bbox.South)*0.25, lon(0.75)}, // NE quadrant
+		{bbox.South + (bbox.North-bbox.South)*0.25, lon(0.25)}, // SW quadrant
+		{bbox.South + (bbox.North-bbox.South)*0.25, lon(0.75)}, // SE quadrant
+	}
+}
+
+// sampleEsriTimeline collects Esri Wayback local-change dates at each sample point
+// Dates are keyed by LayerDate since downloads need the layer date to find tiles
+func (a *App) sampleEsriTimeline(points []struct{ lat, lon float64 }, zoom int) *timelineSample {
+	result := &timelineSample{source: SourceEsriWayback, entries: make(map[string]TimelineSourceEntry)}
+
+	counts := make(map[string]int)
+	captureDates := make(map[string]string)
+	seenTiles := make(map[string]bool)
+
+	for _, point := range points {
+		tile, err := esriClient.GetTileForWgs84(point.lat, point.lon, zoom)
+		if err != nil {
+			continue
+		}
+
+		// Small areas can map several sample points onto the same tile
+		tileKey := fmt.Sprintf("%d/%d/%d", tile.Level, tile.Column, tile.Row)
+		if seenTiles[tileKey] {
+			continue
+		}
+		seenTiles[tileKey] = true
+
+		datedTiles, err := a.esriClient.GetAvailableDates(tile)
+		if err != nil {
+			log.Printf("[Timeline] Esri dates failed for tile %s: %v", tileKey, err)
+			result.err = err
+			continue
+		}
+		result.sampled++
+
+		seen := make(map[string]bool)
+		for _, dt := range datedTiles {
+			date := dt.LayerDate.Format("2006-01-02")
+			if seen[date] {
+				continue
+			}
+			seen[date] = true
+			counts[date]++
+			if _, exists := captureDates[date]; !exists && !dt.CaptureDate.IsZero() {
+				captureDates[date] = dt.CaptureDate.Format("2006-01-02")
+			}
+		}
+	}
+
+	if result.sampled == 0 {
+		if result.err == nil {
+			result.err = fmt.Errorf("failed to sample any tiles in the area")
+		}
+		return result
+	}
+	result.err = nil
+
+	for date, count := range counts {
+		result.entries[date] = TimelineSourceEntry{
+			Source:        string(SourceEsriWayback),
+			Coverage:      float64(count) / float64(result.sampled),
+			TilesWithDate: count,
+			SampledTiles:  result.sampled,
+			CaptureDate:   captureDates[date],
+		}
+	}
+
+	return result
+}
+
+// sampleGoogleEarthTimeline collects Google Earth historical dates at each sample point
+// Samples at zoom 16 or lower for epoch stability (see GetGoogleEarthDatesForArea)
+func (a *App) sampleGoogleEarthTimeline(points []struct{ lat, lon float64 }, zoom int) *timelineSample {
+	result := &timelineSample{source: SourceGoogleEarth, entries: make(map[string]TimelineSourceEntry)}
+
+	sampleZoom := zoom
+	if sampleZoom > 16 {
+		sampleZoom = 16
+	}
+
+	type dateStats struct {
+		hexDate string
+		tiles   int
+		epochs  map[int]int
+	}
+	stats := make(map[string]*dateStats)
+	seenTiles := make(map[string]bool)
+
+	for _, point := range points {
+		tile, err := googleearth.GetTileForCoord(point.lat, point.lon, sampleZoom)
+		if err != nil {
+			continue
+		}
+		if seenTiles[tile.Path] {
+			continue
+		}
+		seenTiles[tile.Path] = true
+
+		datedTiles, err := a.geClient.GetAvailableDates(tile)
+		if err != nil {
+			log.Printf("[Timeline] Google Earth dates failed for tile %s: %v", tile.Path, err)
+			result.err = err
+			continue
+		}
+		result.sampled++
+
+		seen := make(map[string]bool)
+		for _, dt := range datedTiles {
+			date := dt.Date.Format("2006-01-02")
+			s, exists := stats[date]
+			if !exists {
+				s = &dateStats{hexDate: dt.HexDate, epochs: make(map[int]int)}
+				stats[date] = s
+			}
+			s.epochs[dt.Epoch]++
+			if !seen[date] {
+				seen[date] = true
+				s.tiles++
+			}
+		}
+	}
+
+	if result.sampled == 0 {
+		if result.err == nil {
+			result.err = fmt.Errorf("failed to sample any tiles in the area")
+		}
+		return result
+	}
+	result.err = nil
+
+	for date, s := range stats {
+		// Use the most frequently occurring epoch
+		bestEpoch, maxCount := 0, 0
+		for epoch, count := range s.epochs {
+			if count > maxCount || (count == maxCount && epoch > bestEpoch) {
+				maxCount = count
+				bestEpoch = epoch
+			}
+		}
+
+		result.entries[date] = TimelineSourceEntry{
+			Source:        string(SourceGoogleEarth),
+			Coverage:      float64(s.tiles) / float64(result.sampled),
+			TilesWithDate: s.tiles,
+			SampledTiles:  result.sampled,
+			Epoch:         bestEpoch,
+			HexDate:       s.hexDate,
+		}
+	}
+
+	return result
+}
+
+// Source preference options for cross-source date selection
+const (
+	PreferenceGoogleEarth = "google_earth" // Prefer Google Earth when it covers the area
+	PreferenceEsriWayback = "esri_wayback" // Prefer Esri Wayback when it covers the area
+	PreferenceCoverage    = "coverage"     // Pick whichever source has the best coverage
+)
+
+// minCrossSourceCoverage is the coverage a preferred-source date needs to win over the other source
+// Matches the 60% threshold used by GetGoogleEarthDatesForArea
+const minCrossSourceCoverage = 0.6
+
+// SelectCrossSourceDates picks the best available date per requested year from either source
+// The result can be passed to AddExportTask with source "mixed" (or ExportTimelapseVideo) to
+// build a single timelapse that mixes Esri Wayback and Google Earth imagery.
+// preference: "google_earth", "esri_wayback" or "coverage" (default)
+// Years without imagery from either source are skipped. Returned dates are oldest first.
+func (a *App) SelectCrossSourceDates(bbox BoundingBox, zoom int, years []int, preference string) ([]GEDateInfo, error) {
+	if len(years) == 0 {
+		return nil, fmt.Errorf("no years requested")
+	}
+	if preference == "" {
+		preference = PreferenceCoverage
+	}
+	if preference != PreferenceGoogleEarth && preference != PreferenceEsriWayback && preference != PreferenceCoverage {
+		return nil, fmt.Errorf("invalid source preference: %s", preference)
+	}
+
+	timeline, err := a.GetImageryTimeline(bbox, zoom)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group candidates by year
+	type candidate struct {
+		date  string
+		entry TimelineSourceEntry
+	}
+	byYear := make(map[int][]candidate)
+	for _, td := range timeline.Dates {
+		for _, entry := range td.Sources {
+			byYear[td.Year] = append(byYear[td.Year], candidate{date: td.Date, entry: entry})
+		}
+	}
+
+	// better reports whether x should be chosen over y
+	better := func(x, y candidate) bool {
+		if preference != PreferenceCoverage {
+			xPreferred := x.entry.Source == preference && x.entry.Coverage >= minCrossSourceCoverage
+			yPreferred := y.entry.Source == preference && y.entry.Coverage >= minCrossSourceCoverage
+			if xPreferred != yPreferred {
+				return xPreferred
+			}
+		}
+		if x.entry.Coverage != y.entry.Coverage {
+			return x.entry.Coverage > y.entry.Coverage
+		}
+		if x.entry.Source != y.entry.Source && preference != PreferenceCoverage {
+			return x.entry.Source == preference
+		}
+		// Latest date in the year shows the most recent state for that frame
+		return x.date > y.date
+	}
+
+	sortedYears := append([]int(nil), years...)
+	sort.Ints(sortedYears)
+
+	var selected []GEDateInfo
+	seenYears := make(map[int]bool)
+	for _, year := range sortedYears {
+		if seenYears[year] {
+			continue
+		}
+		seenYears[year] = true
+
+		candidates := byYear[year]
+		if len(candidates) == 0 {
+			log.Printf("[Timeline] No imagery for %d from either source, skipping", year)
+			continue
+		}
+
+		best := candidates[0]
+		for _, c := range candidates[1:] {
+			if better(c, best) {
+				best = c
+			}
+		}
+
+		selected = append(selected, GEDateInfo{
+			Date:    best.date,
+			HexDate: best.entry.HexDate,
+			Epoch:   best.entry.Epoch,
+			Source:  best.entry.Source,
+		})
+		log.Printf("[Timeline] %d -> %s from %s (coverage %.0f%%)", year, best.date, best.entry.Source, best.entry.Coverage*100)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no imagery available for the requested years")
+	}
+
+	a.emitLog(fmt.Sprintf("Selected %d dates across sources for %d requested years", len(selected), len(seenYears)))
+	return selected, nil
+}
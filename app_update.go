@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/updater"
+)
+
+// initUpdater sets up the updater, removes what a previous update left behind and, when
+// enabled in settings, checks for an update in the background. The frontend is told about
+// a newer release with an "update-available" event and asks the user before installing.
+func (a *App) initUpdater() {
+	u, err := updater.New(AppVersion, UpdatePublicKey, filepath.Join(appDataDir(), "updates"))
+	if err != nil {
+		log.Printf("[Updater] Disabled: %v", err)
+		return
+	}
+	a.updateMu.Lock()
+	a.updater = u
+	a.updateMu.Unlock()
+
+	u.CleanupPrevious()
+
+	if !a.settings.CheckForUpdates || u.IsDevBuild() {
+		return
+	}
+	go func() {
+		defer crash.Recover("UpdateCheck", nil)
+
+		release, err := a.CheckForUpdate()
+		if err != nil {
+			log.Printf("[Updater] Update check failed: %v", err)
+			return
+		}
+		if release != nil {
+			log.Printf("[Updater] Version %s is available", release.Version)
+			wailsRuntime.EventsEmit(a.ctx, "update-available", release)
+		}
+	}()
+}
+
+// CheckForUpdate returns the newest release on the update channel from settings when it
+// is newer than the running version, or nil when the app is up to date
+func (a *App) CheckForUpdate() (release *updater.Release, err error) {
+	defer crash.Recover("CheckForUpdate", &err)
+
+	a.updateMu.Lock()
+	u := a.updater
+	a.updateMu.Unlock()
+	if u == nil {
+		return nil, fmt.Errorf("updates are not available in this build")
+	}
+
+	a.mu.Lock()
+	channel := a.settings.UpdateChannel
+	a.mu.Unlock()
+
+	release, err = u.Check(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	a.updateMu.Lock()
+	a.availableUpdate = release
+	a.updateMu.Unlock()
+	return release, nil
+}
+
+// DownloadUpdate downloads and verifies the release found by the last CheckForUpdate.
+// Progress is emitted as "update-download-progress" and completion as "update-ready".
+func (a *App) DownloadUpdate() (err error) {
+	defer crash.Recover("DownloadUpdate", &err)
+
+	a.updateMu.Lock()
+	u, release := a.updater, a.availableUpdate
+	a.updateMu.Unlock()
+	if u == nil || release == nil {
+		return fmt.Errorf("no update available")
+	}
+
+	ctx, done, err := a.beginDownload("DownloadUpdate")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	a.emitLog(fmt.Sprintf("Downloading update %s...", release.Version))
+	if err := u.Download(ctx, release, func(p updater.Progress) {
+		wailsRuntime.EventsEmit(a.ctx, "update-download-progress", p)
+	}); err != nil {
+		a.emitLog(fmt.Sprintf("Update download failed: %v", err))
+		return err
+	}
+
+	a.emitLog(fmt.Sprintf("Update %s downloaded and verified", release.Version))
+	wailsRuntime.EventsEmit(a.ctx, "update-ready", release)
+	return nil
+}
+
+// InstallUpdate replaces the installation with the downloaded update and quits. The
+// usual shutdown runs first (the queue stops, downloads finish), then the new version
+// is started.
+func (a *App) InstallUpdate() (err error) {
+	defer crash.Recover("InstallUpdate", &err)
+
+	a.updateMu.Lock()
+	u := a.updater
+	a.updateMu.Unlock()
+	if u == nil || !u.Staged() {
+		return fmt.Errorf("no update has been downloaded")
+	}
+
+	launch, err := u.Install()
+	if err != nil {
+		return err
+	}
+	log.Printf("[Updater] Installed update, restarting from %s", launch)
+
+	a.updateMu.Lock()
+	a.relaunchPath = launch
+	a.updateMu.Unlock()
+
+	wailsRuntime.Quit(a.ctx)
+	return nil
+}
+
+// relaunchAfterUpdate starts the installed update at the end of shutdown. The
+// single-instance lock is released first so the new process does not hand its launch
+// back to this one.
+func (a *App) relaunchAfterUpdate() {
+	a.updateMu.Lock()
+	launch := a.relaunchPath
+	a.updateMu.Unlock()
+	if launch == "" {
+		return
+	}
+
+	if a.instance != nil {
+		a.instance.Close()
+	}
+	if err := updater.Relaunch(launch); err != nil {
+		log.Printf("[Updater] Failed to start the new version: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"imagery-desktop/internal/aoi"
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+)
+
+// Watch folder ingestion: GIS staff drop GeoJSON or KML AOI files into a folder and the
+// app queues an export for each, using the export template from settings
+
+const (
+	// watchFolderInterval is how often the watch folder is scanned
+	watchFolderInterval = 15 * time.Second
+
+	// watchFolderSettle skips files modified more recently, which may still be being written
+	watchFolderSettle = 5 * time.Second
+
+	// Subfolders files are moved to once handled
+	watchProcessedDir = "processed"
+	watchFailedDir    = "failed"
+)
+
+// WatchFolderResult is emitted as "watch-folder-task" for every file picked up
+type WatchFolderResult struct {
+	File      string `json:"file"`
+	TaskID    string `json:"taskId,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Dates     int    `json:"dates"`
+	Error     string `json:"error,omitempty"`
+	MoveError string `json:"moveError,omitempty"` // The file could not be moved out of the watch folder
+}
+
+// watchedFile identifies a version of a handled file that could not be moved away, so
+// it is not queued again until it changes
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// validateWatchFolder checks the watch folder settings, creating the folder if needed
+func validateWatchFolder(folder, downloadPath string, template config.ExportTemplate) error {
+	if !filepath.IsAbs(folder) {
+		return fmt.Errorf("watch folder must be an absolute path")
+	}
+	if filepath.Clean(folder) == filepath.Clean(downloadPath) {
+		return fmt.Errorf("watch folder must differ from the download folder")
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return fmt.Errorf("failed to create watch folder: %w", err)
+	}
+
+	link := templateLink(template)
+	if err := link.validate(); err != nil {
+		return fmt.Errorf("watch folder template: %w", err)
+	}
+	if template.Zoom < downloads.MinZoom || template.Zoom > downloads.MaxZoom {
+		return fmt.Errorf("watch folder template: zoom must be between %d and %d", downloads.MinZoom, downloads.MaxZoom)
+	}
+	return nil
+}
+
+// templateLink returns an export link with the options of template and no area
+func templateLink(template config.ExportTemplate) exportLink {
+	return exportLink{
+		Source: template.Source,
+		Zoom:   template.Zoom,
+		From:   template.From,
+		To:     template.To,
+		Latest: template.Latest,
+		Format: template.Format,
+	}
+}
+
+// startWatchFolder scans the watch folder from settings periodically until ctx is
+// cancelled (settings changes apply from the next scan)
+func (a *App) startWatchFolder(ctx context.Context) {
+	go func() {
+		defer crash.Recover("WatchFolder", nil)
+
+		ticker := time.NewTicker(watchFolderInterval)
+		defer ticker.Stop()
+		handled := make(map[string]watchedFile)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			a.mu.Lock()
+			folder := a.settings.WatchFolder
+			template := a.settings.WatchFolderTemplate
+			a.mu.Unlock()
+			if folder != "" {
+				a.scanWatchFolder(folder, template, handled)
+			}
+		}
+	}()
+}
+
+// scanWatchFolder queues an export for every settled AOI file in folder, moves each file
+// to processed/ or failed/, and starts the queue when anything was queued. Files that
+// could not be moved are remembered in handled and skipped until they change.
+func (a *App) scanWatchFolder(folder string, template config.ExportTemplate, handled map[string]watchedFile) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		log.Printf("[WatchFolder] Failed to read %s: %v", folder, err)
+		return
+	}
+
+	queued := 0
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !aoi.IsSupported(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < watchFolderSettle {
+			continue
+		}
+
+		path := filepath.Join(folder, entry.Name())
+		present[path] = true
+		version := watchedFile{modTime: info.ModTime(), size: info.Size()}
+		if seen, ok := handled[path]; ok && seen == version {
+			continue
+		}
+		delete(handled, path)
+
+		result := WatchFolderResult{File: entry.Name()}
+		dest := watchProcessedDir
+		taskID, name, dates, err := a.queueAOIFile(path, template)
+		if err != nil {
+			log.Printf("[WatchFolder] Rejected %s: %v", entry.Name(), err)
+			a.emitLog(fmt.Sprintf("⚠️ Watch folder: %s rejected: %v", entry.Name(), err))
+			result.Error = err.Error()
+			dest = watchFailedDir
+		} else {
+			log.Printf("[WatchFolder] Queued task %s (%d dates) from %s", taskID, dates, entry.Name())
+			a.emitLog(fmt.Sprintf("Queued '%s' (%d dates) from watch folder file %s", name, dates, entry.Name()))
+			result.TaskID, result.Name, result.Dates = taskID, name, dates
+			queued++
+		}
+
+		if err := moveWatchedFile(path, filepath.Join(folder, dest), result.Error); err != nil {
+			log.Printf("[WatchFolder] Failed to move %s: %v", entry.Name(), err)
+			a.emitLog(fmt.Sprintf("⚠️ Watch folder: could not move %s to %s/: %v", entry.Name(), dest, err))
+			result.MoveError = err.Error()
+			if _, statErr := os.Stat(path); statErr == nil {
+				// Left in place it would be queued again on every scan
+				handled[path] = version
+			}
+		}
+
+		if a.ctx != nil {
+			wailsRuntime.EventsEmit(a.ctx, "watch-folder-task", result)
+		}
+	}
+
+	// Forget files that were removed or renamed meanwhile
+	for path := range handled {
+		if !present[path] {
+			delete(handled, path)
+		}
+	}
+
+	if queued > 0 && !a.taskQueue.GetStatus().IsRunning {
+		if err := a.taskQueue.StartQueue(); err != nil {
+			log.Printf("[WatchFolder] Failed to start queue: %v", err)
+		}
+	}
+}
+
+// queueAOIFile reads the AOI in path and queues its export with the template's options.
+// The task is named after the AOI, or the file when the AOI has no name.
+func (a *App) queueAOIFile(path string, template config.ExportTemplate) (taskID, name string, dates int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", 0, err
+	}
+	area, err := aoi.Parse(path, data)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	link := templateLink(template)
+	link.Name = area.Name
+	if link.Name == "" {
+		link.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	link.BBox = BoundingBox(area.BBox)
+	if err := link.validate(); err != nil {
+		return "", "", 0, err
+	}
+	return a.queueExportLink(link)
+}
+
+// moveWatchedFile moves a handled file into dir, prefixing a timestamp when a file of that
+// name was handled before. A rejected file gets a .error.txt note with the reason.
+func moveWatchedFile(path, dir, reason string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(dest); err == nil {
+		dest = filepath.Join(dir, time.Now().Format("20060102-150405")+"_"+filepath.Base(path))
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return err
+	}
+	if reason != "" {
+		return os.WriteFile(dest+".error.txt", []byte(reason+"\n"), 0644)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package aoi reads areas of interest from GeoJSON and KML files, as exported by GIS tools,
+// and reduces them to the bounding box downloads work with.
+package aoi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"imagery-desktop/internal/downloads"
+)
+
+// AOI is an area of interest read from a file
+type AOI struct {
+	Name string                // Name given in the file ("" when it has none)
+	BBox downloads.BoundingBox // Extent of every coordinate in the file
+}
+
+// IsSupported reports whether path has an extension Parse can read
+func IsSupported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json", ".kml":
+		return true
+	}
+	return false
+}
+
+// Parse reads the AOI in data, a GeoJSON or KML file named path (the extension selects
+// the format)
+func Parse(path string, data []byte) (*AOI, error) {
+	var area *AOI
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		area, err = parseGeoJSON(data)
+	case ".kml":
+		area, err = parseKML(data)
+	default:
+		return nil, fmt.Errorf("unsupported AOI file %s (expected .geojson, .json or .kml)", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := area.BBox.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid AOI extent: %w", err)
+	}
+	if area.BBox.South == area.BBox.North || area.BBox.West == area.BBox.East {
+		return nil, fmt.Errorf("AOI has no area (a point or a line along one axis)")
+	}
+	return area, nil
+}
+
+// extent accumulates the bounding box of lon/lat positions
+type extent struct {
+	bbox downloads.BoundingBox
+	n    int
+}
+
+func (e *extent) add(lon, lat float64) {
+	if e.n == 0 {
+		e.bbox = downloads.BoundingBox{South: lat, West: lon, North: lat, East: lon}
+	} else {
+		e.bbox.South = math.Min(e.bbox.South, lat)
+		e.bbox.North = math.Max(e.bbox.North, lat)
+		e.bbox.West = math.Min(e.bbox.West, lon)
+		e.bbox.East = math.Max(e.bbox.East, lon)
+	}
+	e.n++
+}
+
+// geoJSONObject covers the members of every GeoJSON object type Parse reads
+type geoJSONObject struct {
+	Features    []geoJSONObject        `json:"features"`
+	Geometry    *geoJSONObject         `json:"geometry"`
+	Geometries  []geoJSONObject        `json:"geometries"`
+	Coordinates interface{}            `json:"coordinates"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+// parseGeoJSON reads a FeatureCollection, Feature or bare geometry
+func parseGeoJSON(data []byte) (*AOI, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	area := &AOI{}
+	var ext extent
+	var walk func(o *geoJSONObject)
+	walk = func(o *geoJSONObject) {
+		if area.Name == "" {
+			if name, ok := o.Properties["name"].(string); ok {
+				area.Name = strings.TrimSpace(name)
+			}
+		}
+		for i := range o.Features {
+			walk(&o.Features[i])
+		}
+		if o.Geometry != nil {
+			walk(o.Geometry)
+		}
+		for i := range o.Geometries {
+			walk(&o.Geometries[i])
+		}
+		addPositions(&ext, o.Coordinates)
+	}
+	walk(&obj)
+
+	if ext.n == 0 {
+		return nil, fmt.Errorf("GeoJSON has no coordinates")
+	}
+	area.BBox = ext.bbox
+	return area, nil
+}
+
+// addPositions adds every [lon, lat, ...] position nested in GeoJSON coordinates
+func addPositions(ext *extent, coords interface{}) {
+	arr, ok := coords.([]interface{})
+	if !ok || len(arr) == 0 {
+		return
+	}
+	if lon, ok := arr[0].(float64); ok {
+		if len(arr) >= 2 {
+			if lat, ok := arr[1].(float64); ok {
+				ext.add(lon, lat)
+			}
+		}
+		return
+	}
+	for _, c := range arr {
+		addPositions(ext, c)
+	}
+}
+
+// parseKML reads every <coordinates> element of a KML document; the first <name> names the AOI
+func parseKML(data []byte) (*AOI, error) {
+	area := &AOI{}
+	var ext extent
+	var element string
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			element = t.Name.Local
+		case xml.EndElement:
+			element = ""
+		case xml.CharData:
+			switch element {
+			case "name":
+				if area.Name == "" {
+					area.Name = strings.TrimSpace(string(t))
+				}
+			case "coordinates":
+				// Whitespace-separated lon,lat[,alt] tuples
+				for _, tuple := range strings.Fields(string(t)) {
+					parts := strings.Split(tuple, ",")
+					if len(parts) < 2 {
+						return nil, fmt.Errorf("invalid KML coordinate %q", tuple)
+					}
+					lon, errLon := strconv.ParseFloat(parts[0], 64)
+					lat, errLat := strconv.ParseFloat(parts[1], 64)
+					if errLon != nil || errLat != nil {
+						return nil, fmt.Errorf("invalid KML coordinate %q", tuple)
+					}
+					ext.add(lon, lat)
+				}
+			}
+		}
+	}
+
+	if ext.n == 0 {
+		return nil, fmt.Errorf("KML has no coordinates")
+	}
+	area.BBox = ext.bbox
+	return area, nil
+}
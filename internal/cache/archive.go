@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// archiveIndexName is the entry listing the tiles of a cache archive
+const archiveIndexName = "index.json"
+
+// archiveVersion is the cache archive layout written by ExportArchive
+const archiveVersion = 1
+
+// archiveIndex describes the tiles of a cache archive, stored under their cache paths
+type archiveIndex struct {
+	Version   int            `json:"version"`
+	CreatedAt string         `json:"createdAt"` // RFC 3339, UTC
+	Tiles     []TileMetadata `json:"tiles"`
+}
+
+// ExportArchive writes the cached tiles that include accepts (all tiles when nil) to w
+// as a zip archive for ImportArchive on another machine, calling onTile after each tile
+// with the number written so far and the total. Expired tiles are left out. Returns the
+// number of tiles written.
+func (c *PersistentTileCache) ExportArchive(ctx context.Context, w io.Writer, include func(*TileMetadata) bool, onTile func(done, total int)) (int, error) {
+	c.mu.RLock()
+	ttl := c.ttl
+	var tiles []TileMetadata
+	for _, meta := range c.metadata {
+		if ttl > 0 && time.Since(meta.CreateTime) > ttl {
+			continue
+		}
+		if include == nil || include(meta) {
+			tiles = append(tiles, *meta)
+		}
+	}
+	c.mu.RUnlock()
+	if len(tiles) == 0 {
+		return 0, fmt.Errorf("no cached tiles to export")
+	}
+
+	zw := zip.NewWriter(w)
+	index := archiveIndex{Version: archiveVersion, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	for i := range tiles {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		key := tiles[i].Key
+		data, err := os.ReadFile(c.buildFilePath(&tiles[i]))
+		if err != nil {
+			continue // Evicted since the listing
+		}
+
+		// Tiles are already compressed images, so they are stored as is
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: c.archivePath(&tiles[i]), Method: zip.Store, Modified: tiles[i].CreateTime})
+		if err != nil {
+			return 0, fmt.Errorf("failed to add tile %s: %w", key, err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			return 0, fmt.Errorf("failed to add tile %s: %w", key, err)
+		}
+		index.Tiles = append(index.Tiles, tiles[i])
+		if onTile != nil {
+			onTile(i+1, len(tiles))
+		}
+	}
+
+	entry, err := zw.Create(archiveIndexName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write archive index: %w", err)
+	}
+	if err := json.NewEncoder(entry).Encode(index); err != nil {
+		return 0, fmt.Errorf("failed to write archive index: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return len(index.Tiles), nil
+}
+
+// ImportArchive adds the tiles of an archive written by ExportArchive to the cache,
+// keeping their original capture time so the TTL still applies. Tiles already cached
+// with a newer copy, and tiles already past the TTL, are skipped. onTile is called after
+// each tile with the number processed so far and the total.
+func (c *PersistentTileCache) ImportArchive(ctx context.Context, path string, onTile func(done, total int)) (imported, skipped int, err error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	indexFile, ok := entries[archiveIndexName]
+	if !ok {
+		return 0, 0, fmt.Errorf("not a tile cache archive (no %s)", archiveIndexName)
+	}
+	index, err := readArchiveIndex(indexFile)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var totalSize int64
+	for _, tile := range index.Tiles {
+		totalSize += tile.Size
+	}
+	if totalSize > c.maxSize {
+		return 0, 0, fmt.Errorf("archive holds %.1f MB of tiles, more than the %.1f MB cache limit", float64(totalSize)/(1024*1024), float64(c.maxSize)/(1024*1024))
+	}
+
+	// The index is saved once at the end rather than after every tile
+	defer func() {
+		if imported > 0 {
+			if saveErr := c.saveMetadata(); saveErr != nil && err == nil {
+				err = saveErr
+			}
+		}
+	}()
+
+	c.mu.RLock()
+	ttl := c.ttl
+	c.mu.RUnlock()
+	for i, tile := range index.Tiles {
+		if err := ctx.Err(); err != nil {
+			return imported, skipped, err
+		}
+		if onTile != nil {
+			onTile(i+1, len(index.Tiles))
+		}
+
+		// Rebuild the key and path from the tile coordinates rather than trusting the archive
+		meta := &TileMetadata{
+			Provider:   tile.Provider,
+			Z:          tile.Z,
+			X:          tile.X,
+			Y:          tile.Y,
+			Date:       tile.Date,
+			AccessTime: time.Now(),
+			CreateTime: tile.CreateTime,
+		}
+		meta.Key = c.buildKey(meta.Provider, meta.Z, meta.X, meta.Y, meta.Date)
+		if ttl > 0 && time.Since(meta.CreateTime) > ttl {
+			skipped++
+			continue
+		}
+		c.mu.RLock()
+		existing, exists := c.metadata[meta.Key]
+		newer := exists && !existing.CreateTime.Before(meta.CreateTime)
+		c.mu.RUnlock()
+		if newer {
+			skipped++
+			continue
+		}
+
+		f, ok := entries[c.archivePath(meta)]
+		if !ok {
+			skipped++
+			continue
+		}
+		data, err := readArchiveEntry(f)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if err := c.store(meta, data); err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// archivePath is the archive entry of a tile: its path in the cache, with forward slashes
+func (c *PersistentTileCache) archivePath(meta *TileMetadata) string {
+	rel, _ := filepath.Rel(c.baseDir, c.buildFilePath(meta))
+	return filepath.ToSlash(rel)
+}
+
+func readArchiveIndex(f *zip.File) (*archiveIndex, error) {
+	data, err := readArchiveEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	var index archiveIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid archive index: %w", err)
+	}
+	if index.Version > archiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than this version supports (%d)", index.Version, archiveVersion)
+	}
+	return &index, nil
+}
+
+func readArchiveEntry(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memoryCache is a size-bounded LRU of tile bytes kept in front of the disk cache, so
+// the hot set of preview tiles is served without a disk read while panning and scrubbing
+type memoryCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List               // Front = most recently used
+	entries  map[string]*list.Element // Cache key -> element holding a *memoryEntry
+}
+
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+func newMemoryCache(maxBytes int64) *memoryCache {
+	return &memoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the tile stored under key and marks it recently used
+func (m *memoryCache) get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// set stores a tile, evicting the least recently used tiles beyond the limit. Tiles
+// larger than the whole limit are not kept.
+func (m *memoryCache) set(key string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.removeElement(elem)
+	}
+	if int64(len(data)) > m.maxBytes {
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data})
+	m.size += int64(len(data))
+	m.trim()
+}
+
+// setLimit changes the size limit, evicting tiles beyond a lower one
+func (m *memoryCache) setLimit(maxBytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxBytes = maxBytes
+	m.trim()
+}
+
+// clear drops every tile
+func (m *memoryCache) clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.order.Init()
+	m.entries = make(map[string]*list.Element)
+	m.size = 0
+}
+
+// stats returns the number of tiles held and their total size
+func (m *memoryCache) stats() (entries int, sizeBytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries), m.size
+}
+
+func (m *memoryCache) trim() {
+	for m.size > m.maxBytes {
+		m.removeElement(m.order.Back())
+	}
+}
+
+func (m *memoryCache) removeElement(elem *list.Element) {
+	entry := m.order.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.size -= int64(len(entry.data))
+}
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/metrics"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // PersistentTileCache provides disk-based caching with OGC ZXY structure
@@ -22,6 +26,8 @@ type PersistentTileCache struct {
 	mu        sync.RWMutex
 	metadata  map[string]*TileMetadata // Persistent metadata index
 	evictChan chan struct{}
+	inflight  singleflight.Group // In-flight GetOrFetch calls by cache key
+	memory    *memoryCache       // Hot tiles kept in RAM in front of the disk (see SetMemoryLimit)
 }
 
 // TileMetadata stores information about a cached tile
@@ -52,6 +58,7 @@ func NewPersistentTileCache(baseDir string, maxSizeMB int, ttlDays int) (*Persis
 		ttl:       time.Duration(ttlDays) * 24 * time.Hour,
 		metadata:  make(map[string]*TileMetadata),
 		evictChan: make(chan struct{}, 1),
+		memory:    newMemoryCache(0),
 	}
 
 	// Load metadata index from disk
@@ -73,6 +80,7 @@ func NewPersistentTileCache(baseDir string, maxSizeMB int, ttlDays int) (*Persis
 func (c *PersistentTileCache) Get(key string) ([]byte, bool) {
 	c.mu.RLock()
 	meta, exists := c.metadata[key]
+	ttl := c.ttl
 	c.mu.RUnlock()
 
 	if !exists {
@@ -80,11 +88,20 @@ func (c *PersistentTileCache) Get(key string) ([]byte, bool) {
 	}
 
 	// Check if tile has expired
-	if c.ttl > 0 && time.Since(meta.CreateTime) > c.ttl {
+	if ttl > 0 && time.Since(meta.CreateTime) > ttl {
 		c.evictTile(key, meta)
 		return nil, false
 	}
 
+	// Memory first; the index above stays authoritative, so evicted tiles are never served.
+	// The access time is persisted with the next index save rather than on every hit.
+	if data, ok := c.memory.get(key); ok {
+		c.mu.Lock()
+		meta.AccessTime = time.Now()
+		c.mu.Unlock()
+		return data, true
+	}
+
 	// Build file path: {provider}/{z}/{x}/{y}.jpg
 	filePath := c.buildFilePath(meta)
 
@@ -96,6 +113,8 @@ func (c *PersistentTileCache) Get(key string) ([]byte, bool) {
 		return nil, false
 	}
 
+	c.memory.set(key, data)
+
 	// Update access time
 	c.mu.Lock()
 	meta.AccessTime = time.Now()
@@ -107,25 +126,67 @@ func (c *PersistentTileCache) Get(key string) ([]byte, bool) {
 	return data, true
 }
 
-// Set stores a tile in cache using OGC ZXY structure
-func (c *PersistentTileCache) Set(provider string, z, x, y int, date string, data []byte) error {
+// GetOrFetch returns a tile from cache, or runs fetch and caches its result.
+// Concurrent calls for the same tile share a single fetch, so the tile server and the
+// downloaders asking for the same tile at once only hit the provider once.
+// hit reports whether the tile came from cache.
+func (c *PersistentTileCache) GetOrFetch(provider string, z, x, y int, date string, fetch func() ([]byte, error)) (data []byte, hit bool, err error) {
 	key := c.buildKey(provider, z, x, y, date)
-	size := int64(len(data))
+	if data, found := c.Get(key); found {
+		metrics.RecordCacheLookup(provider, true)
+		return data, true, nil
+	}
+	metrics.RecordCacheLookup(provider, false)
+
+	result, err, _ := c.inflight.Do(key, func() (any, error) {
+		// A fetch for this key may have completed since the lookup above
+		if data, found := c.Get(key); found {
+			return data, nil
+		}
+		data, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Set(provider, z, x, y, date, data); err != nil {
+			log.Printf("[Cache] Failed to store tile %s: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return result.([]byte), false, nil
+}
 
-	// Create metadata
+// Set stores a tile in cache using OGC ZXY structure
+func (c *PersistentTileCache) Set(provider string, z, x, y int, date string, data []byte) error {
 	now := time.Now()
-	meta := &TileMetadata{
-		Key:        key,
+	if err := c.store(&TileMetadata{
+		Key:        c.buildKey(provider, z, x, y, date),
 		Provider:   provider,
 		Z:          z,
 		X:          x,
 		Y:          y,
 		Date:       date,
-		Size:       size,
 		AccessTime: now,
 		CreateTime: now,
+	}, data); err != nil {
+		return err
 	}
 
+	// Save metadata (async)
+	go c.saveMetadata()
+
+	return nil
+}
+
+// store writes a tile's file and adds it to the index (not saved to disk); meta.Size is
+// set from data
+func (c *PersistentTileCache) store(meta *TileMetadata, data []byte) error {
+	key := meta.Key
+	size := int64(len(data))
+	meta.Size = size
+
 	// Build file path: {provider}/{z}/{x}/{y}.jpg or {provider}/{z}/{x}/{y}_{date}.jpg
 	filePath := c.buildFilePath(meta)
 
@@ -157,6 +218,7 @@ func (c *PersistentTileCache) Set(provider string, z, x, y int, date string, dat
 	}
 	c.metadata[key] = meta
 	c.mu.Unlock()
+	c.memory.set(key, data)
 
 	atomic.AddInt64(&c.currSize, size)
 
@@ -168,9 +230,6 @@ func (c *PersistentTileCache) Set(provider string, z, x, y int, date string, dat
 		}
 	}
 
-	// Save metadata (async)
-	go c.saveMetadata()
-
 	return nil
 }
 
@@ -286,21 +345,31 @@ func (c *PersistentTileCache) evictOldTiles() {
 
 // evictExpiredTiles removes tiles that exceed TTL
 func (c *PersistentTileCache) evictExpiredTiles() {
-	if c.ttl <= 0 {
-		return
-	}
+	c.PruneExpired(false)
+}
 
+// PruneExpired removes tiles older than the TTL and returns how many were removed and
+// their total size. With dryRun set nothing is removed, only counted.
+func (c *PersistentTileCache) PruneExpired(dryRun bool) (tiles int, bytes int64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.ttl <= 0 {
+		return 0, 0
+	}
+
 	now := time.Now()
 	toEvict := []string{}
 
 	for key, meta := range c.metadata {
 		if now.Sub(meta.CreateTime) > c.ttl {
 			toEvict = append(toEvict, key)
+			bytes += meta.Size
 		}
 	}
+	if dryRun {
+		return len(toEvict), bytes
+	}
 
 	for _, key := range toEvict {
 		meta := c.metadata[key]
@@ -313,6 +382,25 @@ func (c *PersistentTileCache) evictExpiredTiles() {
 	if len(toEvict) > 0 {
 		c.saveMetadataLocked()
 	}
+	return len(toEvict), bytes
+}
+
+// SetTTL changes how long tiles are kept (0 = forever); applied at the next prune
+func (c *PersistentTileCache) SetTTL(ttlDays int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = time.Duration(ttlDays) * 24 * time.Hour
+}
+
+// SetMemoryLimit sets the size of the in-memory cache of hot tiles (0 = disabled),
+// dropping the least recently used tiles beyond a lower limit
+func (c *PersistentTileCache) SetMemoryLimit(maxSizeMB int) {
+	c.memory.setLimit(int64(maxSizeMB) * 1024 * 1024)
+}
+
+// MemoryStats returns the number of tiles held in memory and their total size
+func (c *PersistentTileCache) MemoryStats() (entries int, sizeBytes int64) {
+	return c.memory.stats()
 }
 
 // loadMetadata loads the metadata index from disk
@@ -344,6 +432,13 @@ func (c *PersistentTileCache) loadMetadata() error {
 	return nil
 }
 
+// Flush writes the metadata index to disk, waiting for any in-progress write (used on exit)
+func (c *PersistentTileCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveMetadataLocked()
+}
+
 // saveMetadata saves the metadata index to disk
 func (c *PersistentTileCache) saveMetadata() error {
 	c.mu.RLock()
@@ -461,10 +556,11 @@ func (c *PersistentTileCache) Clear() error {
 
 	// Clear metadata
 	c.metadata = make(map[string]*TileMetadata)
+	c.memory.clear()
 	atomic.StoreInt64(&c.currSize, 0)
 
-	// Save empty metadata
-	return c.saveMetadata()
+	// Save empty metadata (use locked version since we already hold the mutex)
+	return c.saveMetadataLocked()
 }
 
 // GetCachePath returns the base directory of the cache
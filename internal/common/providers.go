@@ -1,5 +1,7 @@
 package common
 
+import "strings"
+
 // Provider name constants for consistent naming across the application
 const (
 	// ProviderGoogleEarth is the cache and internal identifier for Google Earth imagery
@@ -8,9 +10,87 @@ const (
 	// ProviderEsriWayback is the cache and internal identifier for Esri Wayback imagery
 	ProviderEsriWayback = "esri_wayback"
 
+	// ProviderGoogleEarthDEM is the filename prefix for Google Earth terrain (DEM) exports
+	ProviderGoogleEarthDEM = "google_earth_dem"
+
+	// ProviderMapbox is the identifier for Mapbox Satellite imagery (requires an access token)
+	ProviderMapbox = "mapbox_satellite"
+
+	// ProviderMapTiler is the identifier for MapTiler Satellite imagery (requires an API key)
+	ProviderMapTiler = "maptiler_satellite"
+
+	// ProviderNAIP is the identifier for USDA NAIP aerial imagery from USGS (US only, by year)
+	ProviderNAIP = "naip"
+
+	// ProviderCustomPrefix prefixes the identifier of a user-configured tile source
+	// (see CustomProviderID)
+	ProviderCustomPrefix = "custom_"
+
+	// ProviderMixed marks a task or timelapse whose dates each carry their own source
+	ProviderMixed = "mixed"
+
 	// DisplayNameGoogleEarth is the human-readable name shown in the UI
 	DisplayNameGoogleEarth = "Google Earth"
 
 	// DisplayNameEsriWayback is the human-readable name shown in the UI
 	DisplayNameEsriWayback = "Esri Wayback"
+
+	// DisplayNameMapbox is the human-readable name shown in the UI
+	DisplayNameMapbox = "Mapbox Satellite"
+
+	// DisplayNameMapTiler is the human-readable name shown in the UI
+	DisplayNameMapTiler = "MapTiler Satellite"
+
+	// DisplayNameNAIP is the human-readable name shown in the UI
+	DisplayNameNAIP = "USDA NAIP"
 )
+
+// ProviderDisplayName returns the human-readable name for a provider identifier
+func ProviderDisplayName(provider string) string {
+	switch provider {
+	case ProviderGoogleEarth:
+		return DisplayNameGoogleEarth
+	case ProviderEsriWayback:
+		return DisplayNameEsriWayback
+	case ProviderMapbox:
+		return DisplayNameMapbox
+	case ProviderMapTiler:
+		return DisplayNameMapTiler
+	case ProviderNAIP:
+		return DisplayNameNAIP
+	default:
+		return provider
+	}
+}
+
+// CustomProviderID returns the cache, filename and task identifier for a custom source name.
+// Anything other than ASCII letters and digits becomes "_" so the ID is safe in paths and URLs.
+func CustomProviderID(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name))
+	return ProviderCustomPrefix + slug
+}
+
+// IsCustomProvider reports whether a provider identifier refers to a custom source
+func IsCustomProvider(provider string) bool {
+	return strings.HasPrefix(provider, ProviderCustomPrefix)
+}
+
+// IsTemplateProvider reports whether a provider is served from a URL template
+// (custom sources, the API-key sources Mapbox and MapTiler, and NAIP)
+func IsTemplateProvider(provider string) bool {
+	switch provider {
+	case ProviderMapbox, ProviderMapTiler, ProviderNAIP:
+		return true
+	default:
+		return IsCustomProvider(provider)
+	}
+}
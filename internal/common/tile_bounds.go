@@ -1,13 +1,19 @@
 package common
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // TileBounds represents the min/max row and column bounds of a tile set
+// For tile sets crossing the antimeridian MaxCol continues past the last grid column
+// (MaxCol >= Wrap), so MinCol..MaxCol still runs west to east.
 type TileBounds struct {
 	MinCol int
 	MaxCol int
 	MinRow int
 	MaxRow int
+	Wrap   int // Grid width in columns when the set crosses the antimeridian, 0 otherwise
 }
 
 // Cols returns the number of columns in the bounds
@@ -15,6 +21,15 @@ func (tb TileBounds) Cols() int {
 	return tb.MaxCol - tb.MinCol + 1
 }
 
+// ColumnOffset returns the position of a tile column from the west edge of the bounds
+func (tb TileBounds) ColumnOffset(col int) int {
+	offset := col - tb.MinCol
+	if offset < 0 && tb.Wrap > 0 {
+		offset += tb.Wrap
+	}
+	return offset
+}
+
 // Rows returns the number of rows in the bounds
 func (tb TileBounds) Rows() int {
 	return tb.MaxRow - tb.MinRow + 1
@@ -24,6 +39,7 @@ func (tb TileBounds) Rows() int {
 type Tile interface {
 	GetRow() int
 	GetColumn() int
+	GetLevel() int
 }
 
 // CalculateTileBounds calculates the min/max row and column bounds from a slice of tiles
@@ -57,5 +73,40 @@ func CalculateTileBounds(tiles []Tile) (TileBounds, error) {
 		}
 	}
 
+	unwrapColumns(&bounds, tiles)
 	return bounds, nil
 }
+
+// unwrapColumns detects tile sets that span both grid edges with a gap in between, which
+// only happens across the antimeridian, and rebases the bounds to start after the gap
+func unwrapColumns(bounds *TileBounds, tiles []Tile) {
+	n := 1 << tiles[0].GetLevel()
+	if bounds.MinCol != 0 || bounds.MaxCol != n-1 {
+		return
+	}
+
+	seen := make(map[int]bool)
+	var cols []int
+	for _, tile := range tiles {
+		if col := tile.GetColumn(); !seen[col] {
+			seen[col] = true
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == n {
+		return // Whole world, no gap
+	}
+	sort.Ints(cols)
+
+	// The antimeridian-crossing set is contiguous except for the gap it wraps around
+	gapAfter := 0
+	for i := 1; i < len(cols); i++ {
+		if cols[i]-cols[i-1] > cols[gapAfter+1]-cols[gapAfter] {
+			gapAfter = i - 1
+		}
+	}
+
+	bounds.MinCol = cols[gapAfter+1]
+	bounds.MaxCol = cols[gapAfter] + n
+	bounds.Wrap = n
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// BundleFormat identifies settings bundle files
+	BundleFormat = "imagery-desktop-settings"
+
+	// BundleVersion is the bundle layout written by NewBundle
+	BundleVersion = 1
+)
+
+// Bundle is a portable copy of the settings, for standardizing configuration across
+// workstations. Custom sources and date filter presets travel as their own sections so
+// an import can merge them with the local ones.
+type Bundle struct {
+	Format     string `json:"format"`  // Always BundleFormat
+	Version    int    `json:"version"` // BundleVersion at export time
+	AppVersion string `json:"appVersion"`
+	ExportedAt string `json:"exportedAt"`
+
+	// Shared preferences; machine-specific paths, map state and the sections below are cleared
+	Settings UserSettings `json:"settings"`
+
+	CustomSources      []CustomSource      `json:"customSources"`
+	DateFilterPatterns []DateFilterPattern `json:"dateFilterPatterns"`
+
+	// Whether API keys and the proxy password were included
+	IncludesSecrets bool `json:"includesSecrets"`
+}
+
+// NewBundle returns a bundle of settings. Secrets (API keys, proxy password) are left out
+// unless includeSecrets is set; custom source URLs are copied as they are.
+func NewBundle(settings *UserSettings, appVersion string, includeSecrets bool) *Bundle {
+	shared := *settings
+	clearMachineSettings(&shared)
+	shared.CustomSources = nil
+	shared.DateFilterPatterns = nil
+	if !includeSecrets {
+		shared.ProxyPassword = ""
+		shared.MapboxAccessToken = ""
+		shared.MapTilerAPIKey = ""
+	}
+
+	return &Bundle{
+		Format:             BundleFormat,
+		Version:            BundleVersion,
+		AppVersion:         appVersion,
+		ExportedAt:         time.Now().Format(time.RFC3339),
+		Settings:           shared,
+		CustomSources:      append([]CustomSource{}, settings.CustomSources...),
+		DateFilterPatterns: append([]DateFilterPattern{}, settings.DateFilterPatterns...),
+		IncludesSecrets:    includeSecrets,
+	}
+}
+
+// ParseBundle reads a settings bundle, rejecting other files and newer layouts
+func ParseBundle(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid settings bundle: %w", err)
+	}
+	if b.Format != BundleFormat {
+		return nil, fmt.Errorf("not a settings bundle")
+	}
+	if b.Version > BundleVersion {
+		return nil, fmt.Errorf("settings bundle version %d is newer than this app supports (%d); update the app", b.Version, BundleVersion)
+	}
+	return &b, nil
+}
+
+// Apply returns local with the bundle's settings applied. Machine-specific settings and
+// secrets the bundle leaves out are kept; custom sources and date filter presets are
+// merged by name, the bundle's version replacing a local one of the same name.
+func (b *Bundle) Apply(local *UserSettings) *UserSettings {
+	merged := b.Settings
+
+	// Keep what belongs to this machine
+	merged.DownloadPath = local.DownloadPath
+	merged.CachePath = local.CachePath
+	merged.CACertFile = local.CACertFile
+	merged.WatchFolder = local.WatchFolder
+	merged.OverlayFallbackFonts = local.OverlayFallbackFonts
+	merged.ManifestSigningKey = local.ManifestSigningKey
+	merged.TileServerAddress = local.TileServerAddress
+	merged.TaskPanelOpen = local.TaskPanelOpen
+	merged.LastCenterLat = local.LastCenterLat
+	merged.LastCenterLon = local.LastCenterLon
+	merged.LastZoom = local.LastZoom
+
+	// An imported bundle never decides whose manifest signatures are trusted
+	merged.TrustedManifestKeys = local.TrustedManifestKeys
+
+	if !b.IncludesSecrets {
+		merged.ProxyPassword = local.ProxyPassword
+		merged.MapboxAccessToken = local.MapboxAccessToken
+		merged.MapTilerAPIKey = local.MapTilerAPIKey
+	}
+
+	merged.CustomSources = append([]CustomSource{}, b.CustomSources...)
+	imported := make(map[string]bool)
+	for _, s := range b.CustomSources {
+		imported[s.Name] = true
+	}
+	for _, s := range local.CustomSources {
+		if !imported[s.Name] {
+			merged.CustomSources = append(merged.CustomSources, s)
+		}
+	}
+
+	merged.DateFilterPatterns = append([]DateFilterPattern{}, b.DateFilterPatterns...)
+	imported = make(map[string]bool)
+	for _, p := range b.DateFilterPatterns {
+		imported[p.Name] = true
+	}
+	for _, p := range local.DateFilterPatterns {
+		if !imported[p.Name] {
+			merged.DateFilterPatterns = append(merged.DateFilterPatterns, p)
+		}
+	}
+
+	return &merged
+}
+
+// clearMachineSettings clears the settings that only make sense on the machine they
+// were made on: local paths (including fonts and the manifest signing key), the tile
+// server listen address and the last map and panel state
+func clearMachineSettings(s *UserSettings) {
+	s.DownloadPath = ""
+	s.CachePath = ""
+	s.CACertFile = ""
+	s.WatchFolder = ""
+	s.OverlayFallbackFonts = nil
+	s.ManifestSigningKey = ""
+	s.TileServerAddress = ""
+	s.TaskPanelOpen = false
+	s.LastCenterLat = 0
+	s.LastCenterLon = 0
+	s.LastZoom = 0
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the settings file layout this build reads and writes. Bump it and add
+// a migration whenever a field is renamed, moved or changes meaning; new fields need no
+// migration, as fields missing from a file keep their defaults.
+const SchemaVersion = 1
+
+// migration upgrades raw settings from schema version to-1 to version to
+type migration struct {
+	to    int
+	apply func(raw map[string]interface{}) error
+}
+
+// migrations run in order on files older than SchemaVersion
+var migrations = []migration{
+	// Files from before schemaVersion existed: the layout is unchanged, only stamped
+	{to: 1, apply: func(raw map[string]interface{}) error { return nil }},
+}
+
+// migrateSettings parses a settings file and upgrades it to SchemaVersion. migrated
+// reports whether any migration ran, so the caller can save the upgraded file.
+func migrateSettings(data []byte) (settings *UserSettings, migrated bool, err error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, false, describeJSONError(data, err)
+	}
+
+	version := 0
+	if v, ok := raw["schemaVersion"]; ok {
+		f, isNum := v.(float64)
+		if !isNum || f < 0 || f != float64(int(f)) {
+			return nil, false, fmt.Errorf("schemaVersion must be a non-negative whole number")
+		}
+		version = int(f)
+	}
+	if version > SchemaVersion {
+		// Written by a newer release: read the fields this build knows
+		log.Printf("Settings schema %d is newer than this version supports (%d); settings it does not know are dropped when saved", version, SchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.to <= version {
+			continue
+		}
+		if err := m.apply(raw); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate settings to schema %d: %w", m.to, err)
+		}
+		raw["schemaVersion"] = m.to
+		migrated = true
+	}
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to migrate settings: %w", err)
+	}
+
+	// Decode over the defaults so fields missing from older files keep their defaults
+	settings = DefaultSettings()
+	if err := json.Unmarshal(upgraded, settings); err != nil {
+		return nil, false, describeJSONError(upgraded, err)
+	}
+	return settings, migrated, nil
+}
+
+// describeJSONError turns a decoding error into one naming the line, column or setting at fault
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := lineColumn(data, syntaxErr.Offset)
+		return fmt.Errorf("settings file is not valid JSON at line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return fmt.Errorf("setting %q has the wrong type: expected %s, found %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Errorf("settings file must contain a JSON object, found %s", typeErr.Value)
+	}
+	return fmt.Errorf("failed to parse settings: %w", err)
+}
+
+// lineColumn converts a byte offset into a 1-based line and column
+func lineColumn(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	return line, col
+}
+
+// SetAsideSettingsFile renames an unreadable settings file so the defaults that replace it
+// do not overwrite it, and returns the new path
+func SetAsideSettingsFile() (string, error) {
+	path := GetSettingsPath()
+	aside := strings.TrimSuffix(path, filepath.Ext(path)) + ".invalid-" + time.Now().Format("20060102-150405") + ".json"
+	if err := os.Rename(path, aside); err != nil {
+		return "", err
+	}
+	return aside, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"imagery-desktop/internal/credentials"
+)
+
+// inStore caches the secrets known to be in the credential store, so saves that leave
+// them unchanged (e.g. the map position) do not rewrite them. A name missing from it is
+// unknown: the store could not be read, so its value must not be deleted blindly.
+var (
+	inStoreMu sync.Mutex
+	inStore   = make(map[string]string)
+)
+
+// secretFields returns the settings kept in the OS credential store instead of the
+// settings file, keyed by their credential name
+func secretFields(s *UserSettings) map[string]*string {
+	return map[string]*string{
+		"mapboxAccessToken": &s.MapboxAccessToken,
+		"mapTilerApiKey":    &s.MapTilerAPIKey,
+		"proxyPassword":     &s.ProxyPassword,
+	}
+}
+
+// loadSecrets fills the secrets missing from a loaded settings file from the credential
+// store. plaintext reports secrets still stored in the file (written before the store was
+// used, or while it was unavailable) that the next save moves into the store.
+func loadSecrets(s *UserSettings) (plaintext bool) {
+	if !credentials.Available() {
+		return false
+	}
+	for name, field := range secretFields(s) {
+		if *field != "" {
+			plaintext = true
+			continue
+		}
+		secret, err := credentials.Get(name)
+		inStoreMu.Lock()
+		if err != nil && !errors.Is(err, credentials.ErrNotFound) {
+			log.Printf("Failed to read %s from the credential store: %v", name, err)
+			delete(inStore, name)
+		} else {
+			*field = secret
+			inStore[name] = secret
+		}
+		inStoreMu.Unlock()
+	}
+	return plaintext
+}
+
+// storeSecrets moves the secrets of s into the credential store and clears them in stored,
+// the copy written to the settings file. A secret the store rejects stays in the file. An
+// empty field never deletes an unknown secret; only a new value replaces it.
+func storeSecrets(s, stored *UserSettings) {
+	if !credentials.Available() {
+		return
+	}
+	inStoreMu.Lock()
+	defer inStoreMu.Unlock()
+
+	storedFields := secretFields(stored)
+	for name, field := range secretFields(s) {
+		current, known := inStore[name]
+		if !known && *field == "" {
+			continue
+		}
+		if !known || current != *field {
+			var err error
+			if *field == "" {
+				err = credentials.Delete(name)
+			} else {
+				err = credentials.Set(name, *field)
+			}
+			if err != nil {
+				log.Printf("Failed to save %s to the credential store, keeping it in the settings file: %v", name, err)
+				delete(inStore, name)
+				continue
+			}
+			inStore[name] = *field
+		}
+		*storedFields[name] = ""
+	}
+}
@@ -3,19 +3,25 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // CustomSource represents a user-added imagery source
+// URL is a template: {z}/{x}/{y} (or {-y} for a flipped row) for xyz/tms/wmts sources,
+// {bbox} (EPSG:3857 minx,miny,maxx,maxy) for wms, and an optional {time} placeholder
+// filled from Times.
 type CustomSource struct {
-	Name        string `json:"name"`
-	Type        string `json:"type"` // "wmts", "wms", "xyz", "tms"
-	URL         string `json:"url"`
-	Attribution string `json:"attribution,omitempty"`
-	MaxZoom     int    `json:"maxZoom,omitempty"`
-	MinZoom     int    `json:"minZoom,omitempty"`
-	Enabled     bool   `json:"enabled"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // "wmts", "wms", "xyz", "tms"
+	URL         string   `json:"url"`
+	Attribution string   `json:"attribution,omitempty"`
+	MaxZoom     int      `json:"maxZoom,omitempty"`
+	MinZoom     int      `json:"minZoom,omitempty"`
+	Times       []string `json:"times,omitempty"` // Values of the {time} dimension, used as dates in downloads and timelapses
+	Enabled     bool     `json:"enabled"`
 }
 
 // DateFilterPattern represents a regex pattern for filtering dates
@@ -25,8 +31,21 @@ type DateFilterPattern struct {
 	Enabled bool   `json:"enabled"`
 }
 
+// ExportTemplate holds the export options of tasks queued without the UI (watch folder)
+type ExportTemplate struct {
+	Source string `json:"source"` // Provider ID, e.g. "esri_wayback"
+	Zoom   int    `json:"zoom"`
+	Format string `json:"format"` // "tiles", "geotiff" or "both"
+	From   string `json:"from"`   // YYYY-MM-DD, "" = no lower bound
+	To     string `json:"to"`     // YYYY-MM-DD, "" = no upper bound
+	Latest int    `json:"latest"` // Queue only the newest N dates in the range (0 = all)
+}
+
 // UserSettings represents persistent user preferences
 type UserSettings struct {
+	// Layout of the settings file (see SchemaVersion); stamped on every save
+	SchemaVersion int `json:"schemaVersion"`
+
 	// Download settings
 	DownloadPath string `json:"downloadPath"`
 
@@ -34,19 +53,77 @@ type UserSettings struct {
 	CachePath      string `json:"cachePath"` // Custom cache location (empty = default)
 	CacheMaxSizeMB int    `json:"cacheMaxSizeMB"`
 	CacheTTLDays   int    `json:"cacheTTLDays"`
+	CacheMemoryMB  int    `json:"cacheMemoryMB"` // In-memory cache of hot preview tiles in front of the disk (0 = disabled)
+
+	// Retention rules applied by the background janitor (0 = disabled)
+	RetentionMaxAgeDays int `json:"retentionMaxAgeDays"` // Delete task outputs completed more than N days ago
+	RetentionKeepPerAOI int `json:"retentionKeepPerAoi"` // Keep only the newest M completed task outputs per area of interest
 
 	// Rate limit handling
 	AutoRetryOnRateLimit bool `json:"autoRetryOnRateLimit"` // Enable automatic retry on rate limits
 
+	// Proxy (empty ProxyURL = use the system/environment proxy)
+	ProxyURL      string `json:"proxyUrl"`      // http://, https:// or socks5://host:port
+	ProxyUsername string `json:"proxyUsername"` // Optional proxy credentials
+	ProxyPassword string `json:"proxyPassword"`
+	ProxyBypass   string `json:"proxyBypass"` // Comma-separated hosts, domains or CIDRs to connect to directly
+
+	// TLS trust for TLS-intercepting (MITM) proxies
+	CACertFile            string `json:"caCertFile"`            // Extra PEM CA bundle trusted in addition to the system roots
+	TLSInsecureSkipVerify bool   `json:"tlsInsecureSkipVerify"` // Disable certificate verification (insecure, last resort)
+
+	// Per-provider HTTP header overrides, keyed by provider ("esri_wayback", "google_earth").
+	// Values replace the client defaults (e.g. "User-Agent"); an empty value removes the header.
+	ProviderHeaders map[string]map[string]string `json:"providerHeaders,omitempty"`
+
 	// Default map settings
 	DefaultZoom      int     `json:"defaultZoom"`
 	DefaultSource    string  `json:"defaultSource"` // "esri_wayback", "google_earth", or custom source name
 	DefaultCenterLat float64 `json:"defaultCenterLat"`
 	DefaultCenterLon float64 `json:"defaultCenterLon"`
 
+	// Map preview settings
+	PreviewWebP         bool   `json:"previewWebp"`         // Serve reprojected Google Earth preview tiles as WebP to clients that accept it
+	PreviewWebPQuality  int    `json:"previewWebpQuality"`  // WebP quality 1-100 (0 = default)
+	ReprojectionQuality string `json:"reprojectionQuality"` // Google Earth reprojection sampling: "fast" (nearest) or "quality" (bilinear, default)
+	MetricsEndpoint     bool   `json:"metricsEndpoint"`     // Serve per-provider network counters at /metrics on the tile server (Prometheus format)
+	AdvancedEpochs      bool   `json:"advancedEpochs"`      // Fall back to the Google Earth epochs discovered per region instead of the built-in list
+	TileServerAddress   string `json:"tileServerAddress"`   // Tile server listen address, e.g. ":8765" to serve other machines with the access token ("" = this machine only); applies on restart
+
 	// Download settings
 	DownloadZoomStrategy string `json:"downloadZoomStrategy"` // "current" or "fixed"
 	DownloadFixedZoom    int    `json:"downloadFixedZoom"`
+	MaxGeoTIFFDimension  int    `json:"maxGeoTiffDimension"` // Larger exports are split into parts + VRT (0 = default)
+	GeoTIFFOverviews     bool   `json:"geotiffOverviews"`    // Embed internal overviews (2x, 4x, 8x...) in GeoTIFF exports
+	SavePNGSidecars      bool   `json:"savePngSidecars"`     // Also write a PNG copy next to each GeoTIFF (video export reads GeoTIFFs directly)
+	EsriSampleGrid       int    `json:"esriSampleGrid"`      // N x N tiles sampled across the AOI for Esri date discovery and dedup (0 = default)
+	GENativeCRS          bool   `json:"geNativeCrs"`         // Georeference Google Earth GeoTIFFs in EPSG:4326 (native Plate Carrée) instead of EPSG:3857
+	TileFormat           string `json:"tileFormat"`          // Saved tiles: "original" (source bytes, default), "jpeg" or "png" (lossless)
+	TileJPEGQuality      int    `json:"tileJpegQuality"`     // JPEG quality 1-100 for re-encoded tiles (0 = 90)
+
+	// Locale preferences
+	UnitSystem         string `json:"unitSystem"`         // "metric" (default) or "imperial", for resolutions and distances
+	OverlayDateFormat  string `json:"overlayDateFormat"`  // Video date overlay: "iso", "dmy", "mdy", "long" (default, Jan 02, 2006) or "long_dmy"
+	FilenameDateFormat string `json:"filenameDateFormat"` // Dates in export names: "iso" (default, YYYY-MM-DD), "dmy" or "mdy"
+
+	// Video export settings
+	FFmpegTimeoutMinutes int    `json:"ffmpegTimeoutMinutes"` // FFmpeg encoding timeout (0 = scaled to frame count and resolution)
+	FFmpegExtraArgs      string `json:"ffmpegExtraArgs"`      // Extra FFmpeg output arguments for MP4/WebP encodes, e.g. "-tune film" (advanced)
+
+	// Font files (TTF/OTF/TTC) for overlay characters the built-in font lacks, e.g. CJK
+	// or Arabic titles; tried in order before the fonts found on the system
+	OverlayFallbackFonts []string `json:"overlayFallbackFonts,omitempty"`
+
+	// PEM ed25519 private key (PKCS #8) export manifests are signed with ("" = unsigned)
+	ManifestSigningKey string `json:"manifestSigningKey,omitempty"`
+	// Fingerprints (hex SHA-256 of the public key) of the keys whose signed manifests
+	// verify as valid, besides the own signing key
+	TrustedManifestKeys []string `json:"trustedManifestKeys,omitempty"`
+
+	// Commercial imagery API keys (the source is available only when its key is set).
+	// These and ProxyPassword are kept in the OS credential store when one is available.
+	MapboxAccessToken string `json:"mapboxAccessToken"` // Mapbox Satellite
+	MapTilerAPIKey    string `json:"mapTilerApiKey"`    // MapTiler Satellite
 
 	// Custom imagery sources
 	CustomSources []CustomSource `json:"customSources"`
@@ -61,10 +138,23 @@ type UserSettings struct {
 	ShowCoordinates     bool   `json:"showCoordinates"`
 	AutoOpenDownloadDir bool   `json:"autoOpenDownloadDir"`
 	CheckForUpdates     bool   `json:"checkForUpdates"` // Check for updates on startup
+	UpdateChannel       string `json:"updateChannel"`   // "stable" (full releases) or "beta" (also pre-releases)
+	SendCrashReports    bool   `json:"sendCrashReports"` // Send anonymized crash summaries (crash reports are always kept locally)
 
 	// Task queue settings
 	MaxConcurrentTasks int  `json:"maxConcurrentTasks"` // 1-5, default 1
 	TaskPanelOpen      bool `json:"taskPanelOpen"`      // Whether task panel is expanded
+	AutoResumeTasks    bool `json:"autoResumeTasks"`    // Resume tasks interrupted by a crash on startup (otherwise the user is asked)
+
+	// Bulk tasks only start between these local times (HH:MM, e.g. 22:00-07:00 to keep
+	// office bandwidth free during the day); both empty = any time
+	BulkWindowStart string `json:"bulkWindowStart"`
+	BulkWindowEnd   string `json:"bulkWindowEnd"`
+
+	// Watch folder: GeoJSON/KML files dropped here are queued as exports using the
+	// template, then moved to its processed/ (or failed/) subfolder
+	WatchFolder         string         `json:"watchFolder"` // "" = disabled
+	WatchFolderTemplate ExportTemplate `json:"watchFolderTemplate"`
 
 	// Last session map state (auto-saved on app close)
 	LastCenterLat float64 `json:"lastCenterLat"`
@@ -78,10 +168,12 @@ func DefaultSettings() *UserSettings {
 	downloadPath := filepath.Join(homeDir, "Downloads", "imagery")
 
 	return &UserSettings{
+		SchemaVersion:         SchemaVersion,
 		DownloadPath:          downloadPath,
 		CachePath:             "", // Empty = use default app data location
 		CacheMaxSizeMB:        500, // Increased default: 500MB
 		CacheTTLDays:          90,  // Increased default: 90 days
+		CacheMemoryMB:         64,
 		AutoRetryOnRateLimit:  true,
 		DefaultZoom:          15,
 		DefaultSource:        "esri_wayback",
@@ -89,6 +181,8 @@ func DefaultSettings() *UserSettings {
 		DefaultCenterLon:     31.2219,
 		DownloadZoomStrategy: "fixed",
 		DownloadFixedZoom:    19,
+		MaxGeoTIFFDimension:  30000, // Keeps parts openable in QGIS/GDAL without BigTIFF
+		EsriSampleGrid:       3,
 		CustomSources:        []CustomSource{},
 		DateFilterPatterns: []DateFilterPattern{
 			{
@@ -113,8 +207,15 @@ func DefaultSettings() *UserSettings {
 		ShowCoordinates:     false,
 		AutoOpenDownloadDir: true,
 		CheckForUpdates:     true, // Check for updates on startup by default
+		UpdateChannel:       "stable",
 		MaxConcurrentTasks:  1,
 		TaskPanelOpen:       false,
+		WatchFolderTemplate: ExportTemplate{
+			Source: "esri_wayback",
+			Zoom:   17,
+			Format: "geotiff",
+			Latest: 1, // Newest imagery only
+		},
 		LastCenterLat:       30.0621, // Zamalek, Cairo (same as DefaultCenterLat)
 		LastCenterLon:       31.2219, // Zamalek, Cairo (same as DefaultCenterLon)
 		LastZoom:            15,
@@ -167,9 +268,9 @@ func LoadSettings() (*UserSettings, error) {
 		return nil, fmt.Errorf("failed to read settings file: %w", err)
 	}
 
-	var settings UserSettings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	settings, migrated, err := migrateSettings(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", settingsPath, err)
 	}
 
 	// Merge with defaults for any missing fields
@@ -193,6 +294,9 @@ func LoadSettings() (*UserSettings, error) {
 	if settings.Theme == "" {
 		settings.Theme = defaults.Theme
 	}
+	if settings.UpdateChannel == "" {
+		settings.UpdateChannel = defaults.UpdateChannel
+	}
 	if settings.DownloadZoomStrategy == "" {
 		settings.DownloadZoomStrategy = defaults.DownloadZoomStrategy
 	}
@@ -218,7 +322,16 @@ func LoadSettings() (*UserSettings, error) {
 		settings.LastZoom = defaults.LastZoom
 	}
 
-	return &settings, nil
+	// Secrets live in the OS credential store; plaintext ones are moved there by saving
+	if plaintext := loadSecrets(settings); migrated || plaintext {
+		if err := SaveSettings(settings); err != nil {
+			log.Printf("Failed to save migrated settings: %v", err)
+		} else if migrated {
+			log.Printf("Migrated settings to schema %d", SchemaVersion)
+		}
+	}
+
+	return settings, nil
 }
 
 // SaveSettings saves user settings to disk
@@ -231,7 +344,10 @@ func SaveSettings(settings *UserSettings) error {
 		return fmt.Errorf("failed to create settings directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(settings, "", "  ")
+	settings.SchemaVersion = SchemaVersion
+	stored := *settings
+	storeSecrets(settings, &stored)
+	data, err := json.MarshalIndent(&stored, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
@@ -243,6 +359,23 @@ func SaveSettings(settings *UserSettings) error {
 	return nil
 }
 
+// ValidateProviderHeaders checks that header overrides are valid HTTP header names and values
+func ValidateProviderHeaders(headers map[string]map[string]string) error {
+	for provider, overrides := range headers {
+		for name, value := range overrides {
+			if name == "" || strings.IndexFunc(name, func(r rune) bool {
+				return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+			}) >= 0 {
+				return fmt.Errorf("invalid header name %q for %s", name, provider)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("invalid value for header %s (%s)", name, provider)
+			}
+		}
+	}
+	return nil
+}
+
 // ValidateCustomSource validates a custom source configuration
 func ValidateCustomSource(source *CustomSource) error {
 	if source.Name == "" {
@@ -266,5 +399,30 @@ func ValidateCustomSource(source *CustomSource) error {
 		return fmt.Errorf("invalid source type: %s (must be wmts, wms, xyz, or tms)", source.Type)
 	}
 
+	// Validate URL template placeholders
+	if source.Type == "wms" {
+		if !strings.Contains(source.URL, "{bbox}") {
+			return fmt.Errorf("WMS source URL must contain a {bbox} placeholder")
+		}
+	} else if !strings.Contains(source.URL, "{z}") || !strings.Contains(source.URL, "{x}") ||
+		!(strings.Contains(source.URL, "{y}") || strings.Contains(source.URL, "{-y}")) {
+		return fmt.Errorf("source URL must contain {z}, {x} and {y} placeholders")
+	}
+	if source.MinZoom < 0 || source.MaxZoom < 0 || source.MaxZoom > 23 || (source.MaxZoom > 0 && source.MinZoom > source.MaxZoom) {
+		return fmt.Errorf("invalid zoom range %d-%d", source.MinZoom, source.MaxZoom)
+	}
+
+	// Time values end up in cache paths and filenames
+	if strings.Contains(source.URL, "{time}") && len(source.Times) == 0 {
+		return fmt.Errorf("source URL has a {time} placeholder but no time values are set")
+	}
+	for _, t := range source.Times {
+		if t == "" || strings.IndexFunc(t, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+		}) >= 0 {
+			return fmt.Errorf("invalid time value %q (use letters, digits, '-', '_' or '.')", t)
+		}
+	}
+
 	return nil
 }
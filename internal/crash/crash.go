@@ -0,0 +1,141 @@
+// Package crash captures recovered panics as local crash reports.
+package crash
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu         sync.Mutex
+	reportDir  string
+	appVersion string
+	onCrash    func(props map[string]interface{})
+)
+
+// Configure sets where crash reports are written and an optional callback that receives
+// an anonymized summary of each crash (no paths, panic values or user data)
+func Configure(dir, version string, callback func(props map[string]interface{})) {
+	mu.Lock()
+	defer mu.Unlock()
+	reportDir = dir
+	appVersion = version
+	onCrash = callback
+}
+
+// Dir returns the crash report directory ("" if not configured)
+func Dir() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return reportDir
+}
+
+// Guard runs fn and turns a panic into an error, writing a crash report.
+// Used around tile fetches in download workers so one bad tile cannot take down the app.
+func Guard(where string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Report(where, r)
+		}
+	}()
+	return fn()
+}
+
+// Recover is deferred at the top of bound methods with a named error result:
+//
+//	defer crash.Recover("DownloadEsriImagery", &err)
+//
+// It recovers a panic, writes a crash report and returns the panic as the method's error.
+func Recover(where string, errp *error) {
+	if r := recover(); r != nil {
+		err := Report(where, r)
+		if errp != nil {
+			*errp = err
+		}
+	}
+}
+
+// Report writes a crash report for a recovered panic value and returns it as an error.
+// Must be called from the deferred function that recovered, so the stack is the panic's.
+func Report(where string, r interface{}) error {
+	stack := debug.Stack()
+
+	mu.Lock()
+	dir, version, callback := reportDir, appVersion, onCrash
+	mu.Unlock()
+
+	log.Printf("[Crash] Recovered panic in %s: %v\n%s", where, r, stack)
+
+	reportPath := ""
+	if dir != "" {
+		if path, err := writeReport(dir, version, where, r, stack); err != nil {
+			log.Printf("[Crash] Failed to write crash report: %v", err)
+		} else {
+			reportPath = path
+			log.Printf("[Crash] Report written to %s", path)
+		}
+	}
+
+	if callback != nil {
+		callback(map[string]interface{}{
+			"where":   where,
+			"version": version,
+			"os":      runtime.GOOS,
+			"arch":    runtime.GOARCH,
+			"frames":  stackFunctions(stack),
+		})
+	}
+
+	if reportPath != "" {
+		return fmt.Errorf("internal error in %s: %v (crash report: %s)", where, r, reportPath)
+	}
+	return fmt.Errorf("internal error in %s: %v", where, r)
+}
+
+// writeReport writes a plain-text crash report and returns its path
+func writeReport(dir, version, where string, r interface{}, stack []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("crash_%s.txt", now.Format("20060102_150405.000000")))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Imagery Desktop crash report\n")
+	fmt.Fprintf(&b, "Time:       %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:    %s\n", version)
+	fmt.Fprintf(&b, "OS/Arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Go:         %s\n", runtime.Version())
+	fmt.Fprintf(&b, "Location:   %s\n", where)
+	fmt.Fprintf(&b, "Panic:      %v\n\n", r)
+	b.Write(stack)
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
+
+// stackFunctions reduces a stack trace to its function names (dropping file paths and
+// argument values), which is all the telemetry event carries
+func stackFunctions(stack []byte) []string {
+	var frames []string
+	for _, line := range strings.Split(string(stack), "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if i := strings.LastIndex(line, "("); i > 0 {
+			line = line[:i]
+		}
+		frames = append(frames, line)
+	}
+	return frames
+}
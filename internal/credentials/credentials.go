@@ -0,0 +1,60 @@
+// Package credentials keeps secrets such as API keys in the operating system's credential
+// store (macOS Keychain, Windows DPAPI, the Secret Service via libsecret on Linux) instead
+// of in plaintext settings.
+package credentials
+
+import (
+	"errors"
+	"sync"
+)
+
+// Service names the app's entries in the credential store
+const Service = "imagery-desktop"
+
+var (
+	// ErrNotFound is returned by Get when no secret is stored under the name
+	ErrNotFound = errors.New("credential not found")
+
+	// ErrUnavailable is returned when this system has no usable credential store
+	ErrUnavailable = errors.New("no OS credential store available")
+)
+
+var (
+	availableOnce sync.Once
+	available     bool
+)
+
+// Available reports whether the OS credential store can be used (checked once)
+func Available() bool {
+	availableOnce.Do(func() {
+		available = storeAvailable()
+	})
+	return available
+}
+
+// Get returns the secret stored under name
+func Get(name string) (string, error) {
+	if !Available() {
+		return "", ErrUnavailable
+	}
+	return get(name)
+}
+
+// Set stores secret under name, replacing any previous value
+func Set(name, secret string) error {
+	if !Available() {
+		return ErrUnavailable
+	}
+	return set(name, secret)
+}
+
+// Delete removes the secret stored under name; a missing secret is not an error
+func Delete(name string) error {
+	if !Available() {
+		return ErrUnavailable
+	}
+	if err := del(name); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macOS: generic passwords in the login keychain, through the security tool
+
+// securityNotFound is the exit status of security when no matching item exists
+const securityNotFound = 44
+
+func storeAvailable() bool {
+	_, err := exec.LookPath("security")
+	return err == nil
+}
+
+func get(name string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", name, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func set(name, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return errors.New("keychain: secret contains a line break")
+	}
+	// -U updates an existing item instead of failing. -w without a value prompts for the
+	// password and its confirmation on stdin, keeping the secret out of the process list.
+	cmd := exec.Command("security", "add-generic-password", "-U", "-s", Service, "-a", name, "-w")
+	cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	if err := cmd.Run(); err != nil {
+		return securityError(err)
+	}
+	return nil
+}
+
+func del(name string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", Service, "-a", name).Run(); err != nil {
+		return securityError(err)
+	}
+	return nil
+}
+
+// securityError maps the security tool's exit status to ErrNotFound where it applies
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("keychain: %w", err)
+}
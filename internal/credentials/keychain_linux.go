@@ -0,0 +1,51 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Linux: the Secret Service (GNOME Keyring, KWallet) through libsecret's secret-tool
+
+func storeAvailable() bool {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return false
+	}
+	// The tool is useless without a running Secret Service; a lookup of a missing item
+	// exits 1 silently, while a missing service writes an error
+	out, err := exec.Command("secret-tool", "lookup", "service", Service, "account", "availability-check").CombinedOutput()
+	return err == nil || len(strings.TrimSpace(string(out))) == 0
+}
+
+func get(name string) (string, error) {
+	cmd := exec.Command("secret-tool", "lookup", "service", Service, "account", name)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret service: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func set(name, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", Service+" "+name, "service", Service, "account", name)
+	cmd.Stdin = strings.NewReader(secret) // Kept off the command line
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func del(name string) error {
+	if out, err := exec.Command("secret-tool", "clear", "service", Service, "account", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux && !windows
+
+package credentials
+
+// No credential store on other systems; secrets stay in the settings file
+
+func storeAvailable() bool { return false }
+
+func get(name string) (string, error) { return "", ErrUnavailable }
+
+func set(name, secret string) error { return ErrUnavailable }
+
+func del(name string) error { return ErrUnavailable }
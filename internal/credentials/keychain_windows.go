@@ -0,0 +1,122 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"syscall"
+	"unsafe"
+)
+
+// Windows: secrets encrypted with DPAPI for the current user, one file per name under
+// %APPDATA%\imagery-desktop\credentials
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+// cryptProtectUIForbidden fails instead of prompting the user
+const cryptProtectUIForbidden = 0x1
+
+// validName keeps names usable as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// dataBlob is the DATA_BLOB structure of the DPAPI calls
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newBlob(data []byte) *dataBlob {
+	if len(data) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{cbData: uint32(len(data)), pbData: &data[0]}
+}
+
+// bytes copies the blob's data and frees the buffer DPAPI allocated for it
+func (b *dataBlob) bytes() []byte {
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(b.pbData)))
+	out := make([]byte, b.cbData)
+	copy(out, unsafe.Slice(b.pbData, b.cbData))
+	return out
+}
+
+func storeAvailable() bool {
+	return procCryptProtectData.Find() == nil && procCryptUnprotectData.Find() == nil && credentialsDir() != ""
+}
+
+// credentialsDir returns the folder holding the encrypted secrets ("" if unknown)
+func credentialsDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Service, "credentials")
+}
+
+func credentialPath(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid credential name %q", name)
+	}
+	return filepath.Join(credentialsDir(), name+".bin"), nil
+}
+
+func get(name string) (string, error) {
+	path, err := credentialPath(name)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	var out dataBlob
+	r, _, callErr := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(newBlob(encrypted))), 0, 0, 0, 0,
+		cryptProtectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return "", fmt.Errorf("DPAPI decrypt: %w", callErr)
+	}
+	return string(out.bytes()), nil
+}
+
+func set(name, secret string) error {
+	path, err := credentialPath(name)
+	if err != nil {
+		return err
+	}
+
+	var out dataBlob
+	r, _, callErr := procCryptProtectData.Call(uintptr(unsafe.Pointer(newBlob([]byte(secret)))), 0, 0, 0, 0,
+		cryptProtectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return fmt.Errorf("DPAPI encrypt: %w", callErr)
+	}
+	encrypted := out.bytes()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, encrypted, 0600)
+}
+
+func del(name string) error {
+	path, err := credentialPath(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
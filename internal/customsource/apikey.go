@@ -0,0 +1,71 @@
+package customsource
+
+import (
+	"fmt"
+	"net/url"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/config"
+)
+
+// API-key sources served through the same URL template pipeline as custom sources.
+// Both use 256 px tile endpoints so tiles line up with the standard XYZ grid.
+const (
+	MapboxURLTemplate   = "https://api.mapbox.com/v4/mapbox.satellite/{z}/{x}/{y}.jpg90?access_token=%s"
+	MapTilerURLTemplate = "https://api.maptiler.com/maps/satellite/256/{z}/{x}/{y}.jpg?key=%s"
+
+	MaxZoomMapbox   = 22
+	MaxZoomMapTiler = 20
+)
+
+// SetAPIKeys registers Mapbox Satellite and MapTiler Satellite when their keys are set
+func (c *Client) SetAPIKeys(mapboxToken, mapTilerKey string) {
+	var mapbox, mapTiler *config.CustomSource
+	if mapboxToken != "" {
+		mapbox = &config.CustomSource{
+			Name:        common.DisplayNameMapbox,
+			Type:        "xyz",
+			URL:         fmt.Sprintf(MapboxURLTemplate, url.QueryEscape(mapboxToken)),
+			Attribution: "© Mapbox © OpenStreetMap © Maxar",
+			MaxZoom:     MaxZoomMapbox,
+			Enabled:     true,
+		}
+	}
+	if mapTilerKey != "" {
+		mapTiler = &config.CustomSource{
+			Name:        common.DisplayNameMapTiler,
+			Type:        "xyz",
+			URL:         fmt.Sprintf(MapTilerURLTemplate, url.QueryEscape(mapTilerKey)),
+			Attribution: "© MapTiler © OpenStreetMap contributors",
+			MaxZoom:     MaxZoomMapTiler,
+			Enabled:     true,
+		}
+	}
+
+	c.SetBuiltin(common.ProviderMapbox, mapbox)
+	c.SetBuiltin(common.ProviderMapTiler, mapTiler)
+}
+
+// SetBuiltin registers a built-in source under a fixed provider ID (nil removes it)
+func (c *Client) SetBuiltin(provider string, source *config.CustomSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if source == nil {
+		delete(c.builtins, provider)
+		return
+	}
+	c.builtins[provider] = *source
+}
+
+// redactURL drops the query string, which carries API keys for most tile services
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "REDACTED"
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,186 @@
+// Package customsource fetches tiles from user-configured XYZ, TMS, WMTS and WMS servers
+// and from the API-key sources (Mapbox, MapTiler) that share the same URL templates.
+package customsource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/esri"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/netproxy"
+)
+
+const (
+	// NoTime is the date label used for sources without a {time} dimension
+	NoTime = "current"
+
+	// DefaultMaxZoom applies when a source does not set MaxZoom
+	DefaultMaxZoom = 19
+
+	// UserAgent identifies the app to custom tile servers
+	UserAgent = "imagery-desktop"
+)
+
+// Client fetches tiles from the enabled custom and API-key sources, keyed by provider ID
+type Client struct {
+	httpClient *http.Client
+	mu         sync.RWMutex
+	sources    map[string]config.CustomSource // User-configured sources
+	builtins   map[string]config.CustomSource // API-key sources (see SetAPIKeys)
+}
+
+// NewClient creates a new custom source client with proxy support
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: metrics.NewTransport("custom", netproxy.NewTransport()), // App proxy and TLS settings, counted per provider
+		},
+		sources:  make(map[string]config.CustomSource),
+		builtins: make(map[string]config.CustomSource),
+	}
+}
+
+// SetSources replaces the registered sources; disabled sources are ignored
+func (c *Client) SetSources(sources []config.CustomSource) {
+	registered := make(map[string]config.CustomSource, len(sources))
+	for _, source := range sources {
+		if source.Enabled {
+			registered[common.CustomProviderID(source.Name)] = source
+		}
+	}
+
+	c.mu.Lock()
+	c.sources = registered
+	c.mu.Unlock()
+}
+
+// Get returns the source registered under a provider ID
+func (c *Client) Get(provider string) (config.CustomSource, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	source, ok := c.sources[provider]
+	if !ok {
+		source, ok = c.builtins[provider]
+	}
+	if !ok {
+		return config.CustomSource{}, fmt.Errorf("custom source not found or disabled: %s", provider)
+	}
+	return source, nil
+}
+
+// Sources returns the enabled sources keyed by provider ID
+func (c *Client) Sources() map[string]config.CustomSource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sources := make(map[string]config.CustomSource, len(c.sources)+len(c.builtins))
+	for provider, source := range c.builtins {
+		sources[provider] = source
+	}
+	for provider, source := range c.sources {
+		sources[provider] = source
+	}
+	return sources
+}
+
+// Dates returns the date labels of a source: its time values, or NoTime when it has none
+func Dates(source config.CustomSource) []string {
+	if len(source.Times) == 0 {
+		return []string{NoTime}
+	}
+	return source.Times
+}
+
+// MaxZoom returns the deepest zoom level served by a source
+func MaxZoom(source config.CustomSource) int {
+	if source.MaxZoom > 0 {
+		return source.MaxZoom
+	}
+	return DefaultMaxZoom
+}
+
+// ValidateZoom checks a zoom level against the source's zoom range
+func ValidateZoom(source config.CustomSource, zoom int) error {
+	if zoom < source.MinZoom || zoom > MaxZoom(source) {
+		return fmt.Errorf("zoom %d outside %d-%d for %s", zoom, source.MinZoom, MaxZoom(source), source.Name)
+	}
+	return nil
+}
+
+// TileURL expands a source's URL template for an XYZ tile (row 0 = north).
+// date is the {time} value; NoTime leaves the placeholder empty.
+func TileURL(source config.CustomSource, z, x, y int, date string) string {
+	n := 1 << z
+	row := y
+	if source.Type == "tms" {
+		row = n - 1 - y // TMS counts rows from the south
+	}
+
+	if date == NoTime {
+		date = ""
+	}
+
+	minX, maxY := esri.TileToWebMercator(x, y, z)
+	maxX, minY := esri.TileToWebMercator(x+1, y+1, z)
+	bbox := fmt.Sprintf("%f,%f,%f,%f", minX, minY, maxX, maxY)
+
+	return strings.NewReplacer(
+		"{z}", strconv.Itoa(z),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(row),
+		"{-y}", strconv.Itoa(n-1-y),
+		"{bbox}", bbox,
+		"{time}", date,
+	).Replace(source.URL)
+}
+
+// FetchTile downloads a tile from a custom source, counting the request under provider
+func (c *Client) FetchTile(provider string, source config.CustomSource, z, x, y int, date string) ([]byte, error) {
+	req, err := http.NewRequest("GET", TileURL(source, z, x, y, date), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req = req.WithContext(metrics.WithProvider(req.Context(), provider))
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Keep API keys in the URL out of logs and error messages
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(urlErr.URL)
+		}
+		return nil, fmt.Errorf("failed to fetch tile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("tile request denied with status %d (check the API key or access token)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile request failed with status: %d", resp.StatusCode)
+	}
+
+	// WMS servers report errors as XML with a 200 status
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("unexpected content type: %s", contentType)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tile: %w", err)
+	}
+	return data, nil
+}
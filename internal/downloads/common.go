@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"imagery-desktop/internal/tilemath"
 )
 
 // BoundingBox represents a geographic bounding box
@@ -21,15 +23,21 @@ type DownloadProgress struct {
 	Total       int    `json:"total"`
 	Percent     int    `json:"percent"`
 	Status      string `json:"status"`
-	CurrentDate int    `json:"currentDate"` // For range downloads (1-based)
-	TotalDates  int    `json:"totalDates"`  // For range downloads
+	CurrentDate int    `json:"currentDate"`           // For range downloads (1-based)
+	TotalDates  int    `json:"totalDates"`            // For range downloads
+	OperationID string `json:"operationId,omitempty"` // Operation reporting the progress (see Operation.ID)
 }
 
 // GEDateInfo contains date information for Google Earth historical imagery
 type GEDateInfo struct {
-	Date    string `json:"date"`    // Human-readable date (YYYY-MM-DD)
-	HexDate string `json:"hexDate"` // Hex date for Google API
-	Epoch   int    `json:"epoch"`   // Primary epoch from protobuf
+	Date    string `json:"date"`             // Human-readable date (YYYY-MM-DD)
+	HexDate string `json:"hexDate"`          // Hex date for Google API
+	Epoch   int    `json:"epoch"`            // Primary epoch from protobuf
+	Source  string `json:"source,omitempty"` // Per-date source for mixed-source tasks (empty = task source)
+
+	// Coverage is the percentage of tiles sampled over the area that have this date
+	// (set when listing dates, 0 otherwise)
+	Coverage float64 `json:"coverage,omitempty"`
 }
 
 // GEAvailableDate represents an available Google Earth historical imagery date
@@ -64,8 +72,8 @@ func (b BoundingBox) Validate() error {
 	if b.South >= b.North {
 		return fmt.Errorf("south (%f) must be less than north (%f)", b.South, b.North)
 	}
-	if b.West >= b.East {
-		return fmt.Errorf("west (%f) must be less than east (%f)", b.West, b.East)
+	if b.West == b.East {
+		return fmt.Errorf("west and east must differ (west > east crosses the antimeridian): %f", b.West)
 	}
 	if b.South < -90 || b.North > 90 {
 		return fmt.Errorf("latitude out of range [-90, 90]: south=%f, north=%f", b.South, b.North)
@@ -76,6 +84,38 @@ func (b BoundingBox) Validate() error {
 	return nil
 }
 
+// BeyondWebMercator reports whether the box reaches past the Web Mercator latitude limits
+func (b BoundingBox) BeyondWebMercator() bool {
+	return b.South < MinLat || b.North > MaxLat
+}
+
+// ValidateWebMercator rejects boxes that Web Mercator (XYZ) providers cannot cover.
+// Tiles stop at ±85.0511°, so anything further north or south would be stretched garbage.
+func (b BoundingBox) ValidateWebMercator(providerName string) error {
+	if b.North > MaxLat {
+		return fmt.Errorf("area reaches %.4f°N, beyond the Web Mercator limit of %.4f°: %s has no imagery there (Google Earth supports polar exports)", b.North, MaxLat, providerName)
+	}
+	if b.South < MinLat {
+		return fmt.Errorf("area reaches %.4f°S, beyond the Web Mercator limit of %.4f°: %s has no imagery there (Google Earth supports polar exports)", -b.South, MaxLat, providerName)
+	}
+	return nil
+}
+
+// CrossesAntimeridian reports whether the box runs east across 180° (West > East)
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return tilemath.CrossesAntimeridian(b.West, b.East)
+}
+
+// LonSpan returns the width of the box in degrees of longitude
+func (b BoundingBox) LonSpan() float64 {
+	return tilemath.LonSpan(b.West, b.East)
+}
+
+// LonAt returns the longitude at a fraction of the box width from its west edge
+func (b BoundingBox) LonAt(frac float64) float64 {
+	return tilemath.WrapLongitude(b.West + b.LonSpan()*frac)
+}
+
 // ValidateCoordinates validates zoom level and bounding box
 func ValidateCoordinates(bbox BoundingBox, zoom int) error {
 	if zoom < MinZoom || zoom > MaxZoom {
@@ -101,16 +141,23 @@ func ValidateTileCoordinates(z, x, y int) error {
 	return nil
 }
 
-// ValidateZoomForProvider validates zoom level against provider-specific limits
-func ValidateZoomForProvider(zoom int, provider string) error {
-	var maxZoom int
+// MaxZoomForProvider returns the deepest zoom level supported by a provider
+func MaxZoomForProvider(provider string) (int, error) {
 	switch provider {
 	case "esri_wayback":
-		maxZoom = MaxZoomEsri
+		return MaxZoomEsri, nil
 	case "google_earth":
-		maxZoom = MaxZoomGoogleEarth
+		return MaxZoomGoogleEarth, nil
 	default:
-		return fmt.Errorf("unknown provider: %s", provider)
+		return 0, fmt.Errorf("unknown provider: %s", provider)
+	}
+}
+
+// ValidateZoomForProvider validates zoom level against provider-specific limits
+func ValidateZoomForProvider(zoom int, provider string) error {
+	maxZoom, err := MaxZoomForProvider(provider)
+	if err != nil {
+		return err
 	}
 
 	if zoom > maxZoom {
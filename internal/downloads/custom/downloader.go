@@ -0,0 +1,456 @@
+package custom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Register decoders for custom tile formats
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+
+	"imagery-desktop/internal/cache"
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/esri"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/pkg/geotiff"
+)
+
+// tileResult holds the result of a tile download
+type tileResult struct {
+	tile *esri.EsriTile
+	data []byte
+	err  error
+}
+
+// Downloader handles imagery downloads from XYZ-scheme providers (custom, API-key and NAIP sources)
+type Downloader struct {
+	providers          *providers.Registry
+	tileCache          *cache.PersistentTileCache
+	downloadPath       string
+	progressCallback   func(downloads.DownloadProgress)
+	logCallback        func(string)
+	trackEventCallback func(string, map[string]interface{})
+	maxWorkers         int
+	sem                *semaphore.Weighted
+
+	// GeoTIFF export options
+	maxGeoTIFFDimension int  // Exports larger than this are split into parts + VRT
+	buildOverviews      bool // Embed internal overviews in GeoTIFF exports
+	savePNGCopies       bool // Write a PNG copy next to each GeoTIFF export
+
+	tileOutput downloads.TileOutput // Pixel format of saved tiles
+
+	mu sync.Mutex
+}
+
+// NewDownloader creates a new custom source downloader with injected dependencies
+func NewDownloader(
+	registry *providers.Registry,
+	tileCache *cache.PersistentTileCache,
+	downloadPath string,
+	progressCallback func(downloads.DownloadProgress),
+	logCallback func(string),
+	trackEventCallback func(string, map[string]interface{}),
+	maxWorkers int,
+) *Downloader {
+	if maxWorkers <= 0 {
+		maxWorkers = downloads.DefaultWorkers
+	}
+
+	return &Downloader{
+		providers:          registry,
+		tileCache:          tileCache,
+		downloadPath:       downloadPath,
+		progressCallback:   progressCallback,
+		logCallback:        logCallback,
+		trackEventCallback: trackEventCallback,
+		maxWorkers:         maxWorkers,
+		sem:                semaphore.NewWeighted(int64(maxWorkers)),
+	}
+}
+
+// SetDownloadPath updates the default download path, used when the operation in the
+// context has no output directory (thread-safe)
+func (d *Downloader) SetDownloadPath(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.downloadPath = path
+}
+
+// GetDownloadPath returns the current download path (thread-safe)
+func (d *Downloader) GetDownloadPath() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.downloadPath
+}
+
+// SetMaxGeoTIFFDimension sets the width/height above which GeoTIFF exports are split (thread-safe)
+func (d *Downloader) SetMaxGeoTIFFDimension(maxDim int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maxGeoTIFFDimension = maxDim
+}
+
+// SetBuildOverviews enables internal overview generation for GeoTIFF exports (thread-safe)
+func (d *Downloader) SetBuildOverviews(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.buildOverviews = enabled
+}
+
+// SetSavePNGCopies enables writing a PNG copy next to each GeoTIFF export (thread-safe)
+func (d *Downloader) SetSavePNGCopies(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.savePNGCopies = enabled
+}
+
+// SetTileOutput sets the pixel format of individually saved tiles (thread-safe)
+func (d *Downloader) SetTileOutput(output downloads.TileOutput) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tileOutput = output
+}
+
+// encodeTile converts fetched tile bytes to the configured tile output format,
+// returning them with the file extension to save them under
+func (d *Downloader) encodeTile(data []byte) ([]byte, string, error) {
+	d.mu.Lock()
+	output := d.tileOutput
+	d.mu.Unlock()
+	return output.Encode(data)
+}
+
+// emitLog emits a log message to the callback and the operation in ctx
+func (d *Downloader) emitLog(ctx context.Context, message string) {
+	downloads.ReportLog(ctx, d.logCallback, message)
+}
+
+// emitProgress emits download progress to the callback and the operation in ctx
+func (d *Downloader) emitProgress(ctx context.Context, progress downloads.DownloadProgress) {
+	downloads.ReportProgress(ctx, d.progressCallback, progress)
+}
+
+// trackEvent tracks an analytics event if callback is set
+func (d *Downloader) trackEvent(event string, properties map[string]interface{}) {
+	if d.trackEventCallback != nil {
+		d.trackEventCallback(event, properties)
+	}
+}
+
+// DownloadImagery downloads a custom source for a bounding box as georeferenced image
+// provider is the source's provider ID, date one of its dates (see ImageryProvider.ListDates)
+// format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
+func (d *Downloader) DownloadImagery(ctx context.Context, provider string, bbox downloads.BoundingBox, zoom int, date string, format string) error {
+	source, err := d.providers.Get(provider)
+	if err != nil {
+		return err
+	}
+	if source.TileScheme() != providers.SchemeXYZ {
+		return fmt.Errorf("%s does not use the XYZ tile scheme", source.Name())
+	}
+	if err := bbox.Validate(); err != nil {
+		return fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if err := bbox.ValidateWebMercator(source.Name()); err != nil {
+		return err
+	}
+	if minZoom, maxZoom := source.ZoomRange(); zoom < minZoom || zoom > maxZoom {
+		return fmt.Errorf("zoom %d outside %d-%d for %s", zoom, minZoom, maxZoom, source.Name())
+	}
+
+	downloadPath := downloads.OutputDir(ctx, d.GetDownloadPath())
+	d.emitLog(ctx, fmt.Sprintf("Starting %s download for %s at zoom %d", source.Name(), date, zoom))
+
+	// Custom sources use the standard XYZ grid, which matches the Esri tile scheme
+	tiles, err := esri.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+	if err != nil {
+		return err
+	}
+	total := len(tiles)
+	if total == 0 {
+		return fmt.Errorf("no tiles in bounding box")
+	}
+	d.emitLog(ctx, fmt.Sprintf("Downloading %d tiles with %d workers...", total, d.maxWorkers))
+
+	tileChan := make(chan *esri.EsriTile, total)
+	resultChan := make(chan tileResult, total)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tile := range tileChan {
+				if err := d.sem.Acquire(ctx, 1); err != nil {
+					resultChan <- tileResult{tile: tile, err: err}
+					continue
+				}
+
+				fetch := func() (data []byte, err error) {
+					err = crash.Guard("Custom source worker", func() (err error) {
+						data, err = source.FetchTile(zoom, tile.Column, tile.Row, providers.Date{Date: date})
+						return err
+					})
+					return data, err
+				}
+
+				// Cache first, then the network; a tile already being fetched (e.g. by the
+				// tile server) is shared rather than fetched again
+				var data []byte
+				var err error
+				if d.tileCache != nil {
+					data, _, err = d.tileCache.GetOrFetch(provider, zoom, tile.Column, tile.Row, date, fetch)
+				} else {
+					data, err = fetch()
+				}
+				d.sem.Release(1)
+
+				resultChan <- tileResult{tile: tile, data: data, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, tile := range tiles {
+			select {
+			case <-ctx.Done():
+				close(tileChan)
+				return
+			case tileChan <- tile:
+			}
+		}
+		close(tileChan)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	commonTiles := make([]common.Tile, len(tiles))
+	for i, t := range tiles {
+		commonTiles[i] = t
+	}
+	bounds, err := common.CalculateTileBounds(commonTiles)
+	if err != nil {
+		return fmt.Errorf("failed to calculate tile bounds: %w", err)
+	}
+
+	wantGeoTIFF := format == "geotiff" || format == "both"
+	wantTiles := format == "tiles" || format == "both"
+
+	var outputImg *image.RGBA
+	if wantGeoTIFF {
+		outputImg = image.NewRGBA(image.Rect(0, 0, bounds.Cols()*downloads.TileSize, bounds.Rows()*downloads.TileSize))
+	}
+
+	// OGC structure: source_date_z{zoom}_tiles/{source}/{date}/{z}/{x}/{y}.{ext}
+	var tilesDir string
+	if wantTiles {
+		tilesDir = filepath.Join(downloadPath, naming.GenerateTilesDirName(provider, date, zoom))
+		if err := os.MkdirAll(tilesDir, 0755); err != nil {
+			return fmt.Errorf("failed to create tiles directory: %w", err)
+		}
+	}
+
+	processed := 0
+	successCount := 0
+	var errors []error
+	for result := range resultChan {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		processed++
+		d.emitProgress(ctx, downloads.DownloadProgress{
+			Downloaded: processed,
+			Total:      total,
+			Percent:    processed * 100 / total,
+			Status:     fmt.Sprintf("Downloading %d/%d tiles", processed, total),
+		})
+
+		if result.err != nil {
+			errors = append(errors, result.err)
+			downloads.RecordTileFailure(ctx, provider, date, zoom, result.tile.Column, result.tile.Row, "", result.err)
+			continue
+		}
+
+		if wantTiles {
+			xDir := filepath.Join(tilesDir, provider, date, fmt.Sprintf("%d", zoom), fmt.Sprintf("%d", result.tile.Column))
+			if err := os.MkdirAll(xDir, 0755); err != nil {
+				log.Printf("Failed to create tile directories: %v", err)
+			} else {
+				if data, ext, err := d.encodeTile(result.data); err != nil {
+					log.Printf("Failed to encode tile: %v", err)
+				} else if err := os.WriteFile(filepath.Join(xDir, fmt.Sprintf("%d.%s", result.tile.Row, ext)), data, 0644); err != nil {
+					log.Printf("Failed to save tile: %v", err)
+				}
+			}
+		}
+
+		if wantGeoTIFF {
+			img, _, err := image.Decode(bytes.NewReader(result.data))
+			if err != nil {
+				errors = append(errors, fmt.Errorf("failed to decode tile: %w", err))
+				downloads.RecordTileFailure(ctx, provider, date, zoom, result.tile.Column, result.tile.Row, downloads.FailureDecode, err)
+				continue
+			}
+			xOff := bounds.ColumnOffset(result.tile.Column) * downloads.TileSize
+			yOff := (result.tile.Row - bounds.MinRow) * downloads.TileSize
+			draw.Draw(outputImg, image.Rect(xOff, yOff, xOff+downloads.TileSize, yOff+downloads.TileSize), img, img.Bounds().Min, draw.Over)
+		}
+		downloads.RecordTile(ctx)
+		successCount++
+	}
+
+	d.emitLog(ctx, fmt.Sprintf("Processed %d/%d tiles", successCount, total))
+	d.trackEvent("download_complete", map[string]interface{}{
+		"source":   "custom",
+		"provider": provider,
+		"zoom":     zoom,
+		"total":    total,
+		"success":  successCount,
+		"failed":   total - successCount,
+		"format":   format,
+	})
+
+	if successCount == 0 {
+		if len(errors) > 0 {
+			return fmt.Errorf("no tiles downloaded from %s, first error: %w", source.Name(), errors[0])
+		}
+		return fmt.Errorf("no tiles downloaded from %s", source.Name())
+	}
+
+	// Tasks with their own minimum success rate fail rather than export gaps
+	if _, enforced := downloads.MinSuccessRate(ctx); enforced {
+		if err := downloads.CheckSuccessRate(ctx, successCount, total); err != nil {
+			return err
+		}
+	}
+
+	if wantGeoTIFF {
+		d.emitProgress(ctx, downloads.DownloadProgress{
+			Downloaded: total,
+			Total:      total,
+			Percent:    99,
+			Status:     "Encoding GeoTIFF file...",
+		})
+		tifPath := filepath.Join(downloadPath, naming.GenerateGeoTIFFFilename(provider, date, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
+		if err := d.saveGeoTIFF(ctx, outputImg, tifPath, bounds, zoom, source.Name(), date); err != nil {
+			return fmt.Errorf("failed to save GeoTIFF: %w", err)
+		}
+		d.savePNGCopy(ctx, outputImg, tifPath)
+	}
+
+	if wantTiles {
+		d.emitLog(ctx, fmt.Sprintf("Tiles saved to: %s", tilesDir))
+	}
+
+	d.emitProgress(ctx, downloads.DownloadProgress{
+		Downloaded: total,
+		Total:      total,
+		Percent:    100,
+		Status:     "Complete",
+	})
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d errors during download, first: %w", len(errors), errors[0])
+	}
+	return nil
+}
+
+// saveGeoTIFF georeferences the stitched image in Web Mercator and saves it, splitting
+// it into parts + VRT when it exceeds the configured maximum dimension
+func (d *Downloader) saveGeoTIFF(ctx context.Context, img *image.RGBA, tifPath string, bounds common.TileBounds, zoom int, sourceName, date string) error {
+	d.mu.Lock()
+	maxDim := d.maxGeoTIFFDimension
+	buildOverviews := d.buildOverviews
+	d.mu.Unlock()
+
+	originX, originY := esri.TileToWebMercator(bounds.MinCol, bounds.MinRow, zoom)
+	endX, endY := esri.TileToWebMercator(bounds.MaxCol+1, bounds.MaxRow+1, zoom)
+	pixelWidth := (endX - originX) / float64(img.Bounds().Dx())
+	pixelHeight := (originY - endY) / float64(img.Bounds().Dy())
+
+	// Only masked exports carry an alpha band; every part must share the same layout for the VRT
+	alpha := geotiff.HasTransparency(img)
+	bands := 3
+	if alpha {
+		bands = 4
+	}
+
+	exportMeta := downloads.Metadata(ctx)
+	paths, err := geotiff.SaveSplit(img, tifPath, originX, originY, pixelWidth, pixelHeight, 3857, bands, maxDim,
+		func(part image.Image, partPath string, partOriginX, partOriginY float64) error {
+			opts := &geotiff.EncodeOptions{Alpha: alpha, Artist: exportMeta.Operator}
+			if !exportMeta.IsZero() {
+				opts.Description = exportMeta.Describe(sourceName)
+			}
+			if buildOverviews {
+				partBounds := part.Bounds()
+				opts.Overviews = geotiff.DefaultOverviewLevels(partBounds.Dx(), partBounds.Dy())
+			}
+			return geotiff.SaveAsGeoTIFFWithOptions(part, partPath, partOriginX, partOriginY, pixelWidth, pixelHeight, sourceName, date, "", opts)
+		})
+	if err != nil {
+		return err
+	}
+
+	if len(paths) > 1 {
+		d.emitLog(ctx, fmt.Sprintf("Export exceeds %d px, split into %d parts: %s", maxDim, len(paths)-1, paths[0]))
+	} else {
+		d.emitLog(ctx, fmt.Sprintf("Saved: %s", tifPath))
+	}
+
+	// Failed tiles are left transparent; record their footprints so mosaicking tools can fill the gaps
+	missing := geotiff.MissingFootprints(img, downloads.TileSize, originX, originY, pixelWidth, pixelHeight)
+	if len(missing) > 0 || !exportMeta.IsZero() {
+		meta := geotiff.AuxMetadata{Source: sourceName, Date: date, EPSG: 3857, Missing: missing,
+			Project: exportMeta.Project, Operator: exportMeta.Operator, Notes: exportMeta.Notes}
+		if err := geotiff.WriteAuxMetadata(paths[0], meta); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if len(missing) > 0 {
+			d.emitLog(ctx, fmt.Sprintf("%d missing tiles left transparent, footprints recorded in %s.aux.xml", len(missing), filepath.Base(paths[0])))
+		}
+	}
+	return nil
+}
+
+// savePNGCopy saves a PNG copy of an image alongside its GeoTIFF when enabled in settings,
+// for tools that cannot read GeoTIFFs (video export reads the GeoTIFF directly)
+func (d *Downloader) savePNGCopy(ctx context.Context, img image.Image, tifPath string) {
+	d.mu.Lock()
+	enabled := d.savePNGCopies
+	d.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	pngPath := strings.TrimSuffix(tifPath, ".tif") + ".png"
+	pngFile, err := os.Create(pngPath)
+	if err != nil {
+		log.Printf("Failed to create PNG file: %v", err)
+		return
+	}
+	defer pngFile.Close()
+
+	if err := png.Encode(pngFile, img); err != nil {
+		log.Printf("Failed to encode PNG: %v", err)
+		return
+	}
+	d.emitLog(ctx, fmt.Sprintf("Saved PNG copy: %s", filepath.Base(pngPath)))
+}
@@ -19,6 +19,7 @@ import (
 
 	"imagery-desktop/internal/cache"
 	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
 	"imagery-desktop/internal/downloads"
 	"imagery-desktop/internal/esri"
 	"imagery-desktop/internal/ratelimit"
@@ -44,11 +45,11 @@ type Downloader struct {
 	trackEventCallback   func(string, map[string]interface{})
 	maxWorkers           int
 	sem                  *semaphore.Weighted
-
-	// Range download state
-	inRangeDownload      bool
-	currentDateIndex     int
-	totalDatesInRange    int
+	maxGeoTIFFDimension  int  // Exports larger than this are split into parts + VRT
+	buildOverviews       bool // Embed internal overviews in GeoTIFF exports
+	savePNGCopies        bool // Write a PNG copy next to each GeoTIFF export
+	sampleGrid           int  // N x N tiles sampled for date discovery and dedup (0 = default)
+	tileOutput           downloads.TileOutput // Pixel format of saved tiles
 	mu                   sync.Mutex
 }
 
@@ -80,48 +81,66 @@ func NewDownloader(
 	}
 }
 
-// SetRangeDownloadState sets the range download state for progress tracking
-func (d *Downloader) SetRangeDownloadState(inRange bool, currentIndex, totalDates int) {
+// SetDownloadPath updates the default download path, used when the operation in the
+// context has no output directory (thread-safe)
+func (d *Downloader) SetDownloadPath(path string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.inRangeDownload = inRange
-	d.currentDateIndex = currentIndex
-	d.totalDatesInRange = totalDates
+	d.downloadPath = path
 }
 
-// GetRangeDownloadState returns the current range download state
-func (d *Downloader) GetRangeDownloadState() (inRange bool, currentIndex, totalDates int) {
+// GetDownloadPath returns the current download path (thread-safe)
+func (d *Downloader) GetDownloadPath() string {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	return d.inRangeDownload, d.currentDateIndex, d.totalDatesInRange
+	return d.downloadPath
 }
 
-// SetDownloadPath updates the download path (thread-safe)
-func (d *Downloader) SetDownloadPath(path string) {
+// SetMaxGeoTIFFDimension sets the width/height above which GeoTIFF exports are split (thread-safe)
+func (d *Downloader) SetMaxGeoTIFFDimension(maxDim int) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.downloadPath = path
+	d.maxGeoTIFFDimension = maxDim
 }
 
-// GetDownloadPath returns the current download path (thread-safe)
-func (d *Downloader) GetDownloadPath() string {
+// SetBuildOverviews enables internal overview generation for GeoTIFF exports (thread-safe)
+func (d *Downloader) SetBuildOverviews(enabled bool) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	return d.downloadPath
+	d.buildOverviews = enabled
 }
 
-// emitLog emits a log message if callback is set
-func (d *Downloader) emitLog(message string) {
-	if d.logCallback != nil {
-		d.logCallback(message)
-	}
+// SetSavePNGCopies enables writing a PNG copy next to each GeoTIFF export (thread-safe)
+func (d *Downloader) SetSavePNGCopies(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.savePNGCopies = enabled
 }
 
-// emitProgress emits download progress if callback is set
-func (d *Downloader) emitProgress(progress downloads.DownloadProgress) {
-	if d.progressCallback != nil {
-		d.progressCallback(progress)
-	}
+// SetTileOutput sets the pixel format of individually saved tiles (thread-safe)
+func (d *Downloader) SetTileOutput(output downloads.TileOutput) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tileOutput = output
+}
+
+// encodeTile converts fetched tile bytes to the configured tile output format,
+// returning them with the file extension to save them under
+func (d *Downloader) encodeTile(data []byte) ([]byte, string, error) {
+	d.mu.Lock()
+	output := d.tileOutput
+	d.mu.Unlock()
+	return output.Encode(data)
+}
+
+// emitLog emits a log message to the callback and the operation in ctx
+func (d *Downloader) emitLog(ctx context.Context, message string) {
+	downloads.ReportLog(ctx, d.logCallback, message)
+}
+
+// emitProgress emits download progress to the callback and the operation in ctx
+func (d *Downloader) emitProgress(ctx context.Context, progress downloads.DownloadProgress) {
+	downloads.ReportProgress(ctx, d.progressCallback, progress)
 }
 
 // trackEvent tracks an analytics event if callback is set
@@ -147,6 +166,10 @@ func (d *Downloader) findLayerForDate(date string) (*esri.Layer, error) {
 	return nil, fmt.Errorf("no layer found for date: %s", date)
 }
 
+// blankTileCandidateBytes is the size under which an exported tile is checked for being
+// blank for the download report; uniform tiles compress far below it
+const blankTileCandidateBytes = 4096
+
 // isBlankTile checks if a tile is blank/uniform (white, black, or single color)
 // This happens when imagery isn't available at the requested zoom level for older dates
 func (d *Downloader) isBlankTile(data []byte) bool {
@@ -260,16 +283,23 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 	if err := downloads.ValidateCoordinates(bbox, zoom); err != nil {
 		return fmt.Errorf("invalid coordinates: %w", err)
 	}
+	if err := downloads.ValidateZoomForProvider(zoom, common.ProviderEsriWayback); err != nil {
+		return err
+	}
+	if err := bbox.ValidateWebMercator(common.DisplayNameEsriWayback); err != nil {
+		return err
+	}
 
-	d.emitLog(fmt.Sprintf("Starting download for %s at zoom %d", date, zoom))
+	d.emitLog(ctx, fmt.Sprintf("Starting download for %s at zoom %d", date, zoom))
+	outputDir := downloads.OutputDir(ctx, d.GetDownloadPath())
 
 	// Find layer for this date directly (much faster than GetNearestDatedTile)
 	layer, err := d.findLayerForDate(date)
 	if err != nil {
-		d.emitLog(fmt.Sprintf("Error: %v", err))
+		d.emitLog(ctx, fmt.Sprintf("Error: %v", err))
 		return err
 	}
-	d.emitLog(fmt.Sprintf("Found layer ID %d for date %s", layer.ID, date))
+	d.emitLog(ctx, fmt.Sprintf("Found layer ID %d for date %s", layer.ID, date))
 
 	// Get tiles
 	tiles, err := esri.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
@@ -281,7 +311,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 	if total == 0 {
 		return fmt.Errorf("no tiles in bounding box")
 	}
-	d.emitLog(fmt.Sprintf("Downloading %d tiles with %d workers...", total, d.maxWorkers))
+	d.emitLog(ctx, fmt.Sprintf("Downloading %d tiles with %d workers...", total, d.maxWorkers))
 
 	// Download tiles concurrently with semaphore-based worker pool
 	var downloaded int64
@@ -302,33 +332,31 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 					continue
 				}
 
+				fetch := func() (data []byte, err error) {
+					err = crash.Guard("Esri worker", func() (err error) {
+						data, err = d.esriClient.FetchTile(layer, tile)
+						return err
+					})
+					return data, err
+				}
+
+				// Cache first, then the network; a tile already being fetched (e.g. by the
+				// tile server) is shared rather than fetched again
 				var data []byte
 				var err error
-
-				// Check cache first
 				if d.tileCache != nil {
-					cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", common.ProviderEsriWayback, zoom, tile.Column, tile.Row, date)
-					var found bool
-					data, found = d.tileCache.Get(cacheKey)
-					if found {
+					var hit bool
+					data, hit, err = d.tileCache.GetOrFetch(common.ProviderEsriWayback, zoom, tile.Column, tile.Row, date, fetch)
+					if hit {
 						log.Printf("[Cache HIT] Esri tile z=%d x=%d y=%d (date: %s)", zoom, tile.Column, tile.Row, date)
-						d.sem.Release(1)
-						resultChan <- tileResult{tile: tile, data: data, err: nil}
-						continue
 					}
+				} else {
+					data, err = fetch()
 				}
 
-				// Fetch from network if not cached
-				data, err = d.esriClient.FetchTile(layer, tile)
-
 				// Release semaphore
 				d.sem.Release(1)
 
-				// Cache the result if successful
-				if err == nil && d.tileCache != nil {
-					d.tileCache.Set(common.ProviderEsriWayback, zoom, tile.Column, tile.Row, date, data)
-				}
-
 				resultChan <- tileResult{tile: tile, data: data, err: err}
 			}
 		}()
@@ -366,7 +394,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 	}
 	cols := bounds.Cols()
 	rows := bounds.Rows()
-	d.emitLog(fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
+	d.emitLog(ctx, fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
 
 	// Create output image only if we need GeoTIFF
 	var outputImg *image.RGBA
@@ -380,14 +408,14 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 	// Create tiles directory if saving individual tiles (OGC structure: source_date_z{zoom}_tiles/{z}/{x}/{y}.jpg)
 	var tilesDir string
 	if format == "tiles" || format == "both" {
-		tilesDir = filepath.Join(d.downloadPath, naming.GenerateTilesDirName(common.ProviderEsriWayback, date, zoom))
+		tilesDir = filepath.Join(outputDir, naming.GenerateTilesDirName(common.ProviderEsriWayback, date, zoom))
 		if err := os.MkdirAll(tilesDir, 0755); err != nil {
 			return fmt.Errorf("failed to create tiles directory: %w", err)
 		}
 	}
 
-	// Get range download state
-	inRangeDownload, currentDateIndex, totalDatesInRange := d.GetRangeDownloadState()
+	// Position within a multi-date download, if any
+	currentDateIndex, totalDatesInRange, inRangeDownload := downloads.DateRange(ctx)
 
 	// Process results and stitch tiles
 	successCount := 0
@@ -422,7 +450,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 			}
 		}
 
-		d.emitProgress(downloads.DownloadProgress{
+		d.emitProgress(ctx, downloads.DownloadProgress{
 			Downloaded:  int(count),
 			Total:       total,
 			Percent:     percent,
@@ -434,6 +462,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 		if result.err != nil {
 			// Collect errors instead of just logging
 			errors = append(errors, result.err)
+			downloads.RecordTileFailure(ctx, common.ProviderEsriWayback, date, zoom, result.tile.Column, result.tile.Row, "", result.err)
 			continue
 		}
 
@@ -446,8 +475,9 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 			if err := os.MkdirAll(xDir, 0755); err != nil {
 				log.Printf("Failed to create tile directories: %v", err)
 			} else {
-				tilePath := filepath.Join(xDir, fmt.Sprintf("%d.jpg", result.tile.Row))
-				if err := os.WriteFile(tilePath, result.data, 0644); err != nil {
+				if data, ext, err := d.encodeTile(result.data); err != nil {
+					log.Printf("Failed to encode tile: %v", err)
+				} else if err := os.WriteFile(filepath.Join(xDir, fmt.Sprintf("%d.%s", result.tile.Row, ext)), data, 0644); err != nil {
 					log.Printf("Failed to save tile: %v", err)
 				}
 			}
@@ -457,16 +487,22 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 		if format == "geotiff" || format == "both" {
 			img, err := jpeg.Decode(bytes.NewReader(result.data))
 			if err != nil {
+				downloads.RecordTileFailure(ctx, common.ProviderEsriWayback, date, zoom, result.tile.Column, result.tile.Row, downloads.FailureDecode, err)
 				continue
 			}
 
 			// Calculate position in output image
-			xOff := (result.tile.Column - bounds.MinCol) * downloads.TileSize
+			xOff := bounds.ColumnOffset(result.tile.Column) * downloads.TileSize
 			yOff := (result.tile.Row - bounds.MinRow) * downloads.TileSize
 
 			// Draw tile onto output image
 			draw.Draw(outputImg, image.Rect(xOff, yOff, xOff+downloads.TileSize, yOff+downloads.TileSize), img, image.Point{0, 0}, draw.Src)
 		}
+		if len(result.data) < blankTileCandidateBytes && d.isBlankTile(result.data) {
+			downloads.RecordTileFailure(ctx, common.ProviderEsriWayback, date, zoom, result.tile.Column, result.tile.Row, downloads.FailureBlank, nil)
+		} else {
+			downloads.RecordTile(ctx)
+		}
 		successCount++
 	}
 
@@ -477,7 +513,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 		}
 	}
 
-	d.emitLog(fmt.Sprintf("Processed %d/%d tiles", successCount, total))
+	d.emitLog(ctx, fmt.Sprintf("Processed %d/%d tiles", successCount, total))
 
 	// Track download completion
 	d.trackEvent("download_complete", map[string]interface{}{
@@ -489,6 +525,13 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 		"format":  format,
 	})
 
+	// Tasks with their own minimum success rate fail rather than export gaps
+	if _, enforced := downloads.MinSuccessRate(ctx); enforced {
+		if err := downloads.CheckSuccessRate(ctx, successCount, total); err != nil {
+			return err
+		}
+	}
+
 	// Save GeoTIFF if requested
 	if format == "geotiff" || format == "both" {
 		// Calculate georeferencing in Web Mercator (EPSG:3857)
@@ -498,32 +541,64 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 		pixelHeight := (originY - endY) / float64(outputHeight)
 
 		// Save as GeoTIFF with embedded projection and rich metadata
-		tifPath := filepath.Join(d.downloadPath, naming.GenerateGeoTIFFFilename(common.ProviderEsriWayback, date, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
+		tifPath := filepath.Join(outputDir, naming.GenerateGeoTIFFFilename(common.ProviderEsriWayback, date, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
 
 		// Emit progress for GeoTIFF encoding phase
-		d.emitProgress(downloads.DownloadProgress{
+		d.emitProgress(ctx, downloads.DownloadProgress{
 			Downloaded: total,
 			Total:      total,
 			Percent:    99,
 			Status:     "Encoding GeoTIFF file...",
 		})
-		d.emitLog("Encoding GeoTIFF file...")
-		if err := d.saveAsGeoTIFFWithMetadata(outputImg, tifPath, originX, originY, pixelWidth, pixelHeight, "Esri Wayback", date); err != nil {
+		d.emitLog(ctx, "Encoding GeoTIFF file...")
+		d.mu.Lock()
+		maxDim := d.maxGeoTIFFDimension
+		d.mu.Unlock()
+
+		// Only masked exports carry an alpha band; every part must share the same layout for the VRT
+		alpha := geotiff.HasTransparency(outputImg)
+		bands := 3
+		if alpha {
+			bands = 4
+		}
+
+		// Huge AOIs are split into tiled parts with a VRT index
+		paths, err := geotiff.SaveSplit(outputImg, tifPath, originX, originY, pixelWidth, pixelHeight, 3857, bands, maxDim,
+			func(part image.Image, partPath string, partOriginX, partOriginY float64) error {
+				return d.saveAsGeoTIFFWithMetadata(ctx, part, partPath, partOriginX, partOriginY, pixelWidth, pixelHeight, "Esri Wayback", date, alpha)
+			})
+		if err != nil {
 			return fmt.Errorf("failed to save GeoTIFF: %w", err)
 		}
 
-		d.emitLog(fmt.Sprintf("Saved: %s", tifPath))
+		if len(paths) > 1 {
+			d.emitLog(ctx, fmt.Sprintf("Export exceeds %d px, split into %d parts: %s", maxDim, len(paths)-1, paths[0]))
+		} else {
+			d.emitLog(ctx, fmt.Sprintf("Saved: %s", tifPath))
+		}
 
-		// Save PNG copy for video export compatibility
-		d.savePNGCopy(outputImg, tifPath)
+		// Failed tiles are left transparent; record their footprints so mosaicking tools can fill the gaps
+		missing := geotiff.MissingFootprints(outputImg, downloads.TileSize, originX, originY, pixelWidth, pixelHeight)
+		if exportMeta := downloads.Metadata(ctx); len(missing) > 0 || !exportMeta.IsZero() {
+			meta := geotiff.AuxMetadata{Source: "Esri Wayback", Date: date, EPSG: 3857, Missing: missing,
+				Project: exportMeta.Project, Operator: exportMeta.Operator, Notes: exportMeta.Notes}
+			if err := geotiff.WriteAuxMetadata(paths[0], meta); err != nil {
+				log.Printf("Warning: %v", err)
+			} else if len(missing) > 0 {
+				d.emitLog(ctx, fmt.Sprintf("%d missing tiles left transparent, footprints recorded in %s.aux.xml", len(missing), filepath.Base(paths[0])))
+			}
+		}
+
+		// Optional PNG copy for tools that cannot read GeoTIFFs
+		d.savePNGCopy(ctx, outputImg, tifPath)
 	}
 
 	if format == "tiles" || format == "both" {
-		d.emitLog(fmt.Sprintf("Tiles saved to: %s", tilesDir))
+		d.emitLog(ctx, fmt.Sprintf("Tiles saved to: %s", tilesDir))
 	}
 
 	// Emit completion
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Downloaded: total,
 		Total:      total,
 		Percent:    100,
@@ -539,7 +614,7 @@ func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.Boundin
 }
 
 // saveAsGeoTIFFWithMetadata saves an image as a georeferenced TIFF with full metadata
-func (d *Downloader) saveAsGeoTIFFWithMetadata(img image.Image, outputPath string, originX, originY, pixelWidth, pixelHeight float64, source, date string) error {
+func (d *Downloader) saveAsGeoTIFFWithMetadata(ctx context.Context, img image.Image, outputPath string, originX, originY, pixelWidth, pixelHeight float64, source, date string, alpha bool) error {
 	// Create TIFF file
 	f, err := os.Create(outputPath)
 	if err != nil {
@@ -578,25 +653,42 @@ func (d *Downloader) saveAsGeoTIFFWithMetadata(img image.Image, outputPath strin
 	extraTags[34735] = geoKeyDirectory // GeoKeyDirectoryTag
 
 	// Add metadata tags
+	exportMeta := downloads.Metadata(ctx)
 	if source != "" {
-		extraTags[270] = source // ImageDescription
+		extraTags[270] = exportMeta.Describe(source) // ImageDescription
 	}
 	if date != "" {
 		extraTags[306] = date // DateTime
 	}
 
-	// Write GeoTIFF with metadata
-	if err := geotiff.Encode(f, img, extraTags); err != nil {
+	// Write GeoTIFF with metadata (plus alpha band and internal overviews if requested)
+	d.mu.Lock()
+	buildOverviews := d.buildOverviews
+	d.mu.Unlock()
+
+	opts := &geotiff.EncodeOptions{Alpha: alpha, Artist: exportMeta.Operator}
+	if buildOverviews {
+		bounds := img.Bounds()
+		opts.Overviews = geotiff.DefaultOverviewLevels(bounds.Dx(), bounds.Dy())
+	}
+
+	if err := geotiff.EncodeWithOptions(f, img, extraTags, opts); err != nil {
 		return fmt.Errorf("failed to encode GeoTIFF: %w", err)
 	}
 
 	return nil
 }
 
-// savePNGCopy saves a PNG copy of an image alongside its GeoTIFF for video export compatibility
-// GeoTIFF files with custom geo tags may not decode properly with standard image decoders,
-// so we create a PNG sidecar that video export can reliably use
-func (d *Downloader) savePNGCopy(img image.Image, tifPath string) {
+// savePNGCopy saves a PNG copy of an image alongside its GeoTIFF when enabled in settings,
+// for tools that cannot read GeoTIFFs (video export reads the GeoTIFF directly)
+func (d *Downloader) savePNGCopy(ctx context.Context, img image.Image, tifPath string) {
+	d.mu.Lock()
+	enabled := d.savePNGCopies
+	d.mu.Unlock()
+	if !enabled {
+		return
+	}
+
 	pngPath := strings.TrimSuffix(tifPath, ".tif") + ".png"
 	pngFile, err := os.Create(pngPath)
 	if err != nil {
@@ -609,5 +701,5 @@ func (d *Downloader) savePNGCopy(img image.Image, tifPath string) {
 		log.Printf("Failed to encode PNG: %v", err)
 		return
 	}
-	d.emitLog(fmt.Sprintf("Saved PNG copy: %s", filepath.Base(pngPath)))
+	d.emitLog(ctx, fmt.Sprintf("Saved PNG copy: %s", filepath.Base(pngPath)))
 }
@@ -0,0 +1,325 @@
+package esri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/esri"
+	"imagery-desktop/internal/utils/naming"
+)
+
+// Kinds of tile change recorded in a DeltaManifest
+const (
+	DeltaChanged = "changed" // Both dates have imagery, from different source releases
+	DeltaAdded   = "added"   // Only the new date has imagery
+	DeltaRemoved = "removed" // Only the base date has imagery (nothing to download)
+)
+
+// DeltaManifest records what an incremental archive fetched for one date: the tiles whose
+// imagery differs from the previously archived base date
+type DeltaManifest struct {
+	Source         string                `json:"source"`
+	Zoom           int                   `json:"zoom"`
+	BBox           downloads.BoundingBox `json:"bbox"`
+	BaseDate       string                `json:"baseDate"`
+	BaseRelease    int                   `json:"baseRelease"`
+	Date           string                `json:"date"`
+	Release        int                   `json:"release"`
+	TilesChecked   int                   `json:"tilesChecked"`
+	TilesUnchanged int                   `json:"tilesUnchanged"`
+	TilesFailed    int                   `json:"tilesFailed"` // Tiles that could not be compared or fetched
+	Tiles          []DeltaTile           `json:"tiles"`
+	CreatedAt      string                `json:"createdAt"`
+}
+
+// DeltaTile is one tile of an incremental archive that differs from the base date
+type DeltaTile struct {
+	Z          int    `json:"z"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Change     string `json:"change"`               // DeltaChanged, DeltaAdded or DeltaRemoved
+	BaseSource int    `json:"baseSource,omitempty"` // Release the base date's imagery comes from
+	Source     int    `json:"source,omitempty"`     // Release the new date's imagery comes from
+	Path       string `json:"path,omitempty"`       // Saved tile, relative to the manifest
+}
+
+// DownloadChangedTiles archives date incrementally against baseDate, a date already
+// archived for the same area: only tiles whose source release differs between the two
+// dates are fetched and saved (OGC tile structure), and a delta manifest listing them is
+// written next to the tiles. Unchanged tiles are only compared through the tilemap, so a
+// weekly check of a large area costs a fraction of a full download.
+func (d *Downloader) DownloadChangedTiles(ctx context.Context, bbox downloads.BoundingBox, zoom int, baseDate, date string) (*DeltaManifest, error) {
+	if err := downloads.ValidateCoordinates(bbox, zoom); err != nil {
+		return nil, fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if err := downloads.ValidateZoomForProvider(zoom, common.ProviderEsriWayback); err != nil {
+		return nil, err
+	}
+	if err := bbox.ValidateWebMercator(common.DisplayNameEsriWayback); err != nil {
+		return nil, err
+	}
+
+	baseLayer, err := d.findLayerForDate(baseDate)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := d.findLayerForDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	tiles, err := esri.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+	if err != nil {
+		return nil, err
+	}
+	total := len(tiles)
+	if total == 0 {
+		return nil, fmt.Errorf("no tiles in bounding box")
+	}
+
+	d.emitLog(ctx, fmt.Sprintf("Incremental archive of %s against %s: comparing %d tiles", date, baseDate, total))
+
+	manifest := &DeltaManifest{
+		Source:       common.ProviderEsriWayback,
+		Zoom:         zoom,
+		BBox:         bbox,
+		BaseDate:     baseDate,
+		BaseRelease:  baseLayer.ID,
+		Date:         date,
+		Release:      layer.ID,
+		TilesChecked: total,
+		Tiles:        []DeltaTile{},
+	}
+
+	// Compare the source release of every tile in both dates
+	changes := make([]*DeltaTile, total)
+	compareFailed := make([]bool, total)
+	d.forEachTile(ctx, total, func(i, done int) {
+		change, err := d.compareTile(ctx, baseLayer, layer, tiles[i])
+		if err != nil {
+			log.Printf("[Incremental] Failed to compare tile z=%d x=%d y=%d: %v", zoom, tiles[i].Column, tiles[i].Row, err)
+			compareFailed[i] = true
+		}
+		changes[i] = change
+		d.emitProgress(ctx, downloads.DownloadProgress{
+			Downloaded: done,
+			Total:      total,
+			Percent:    done * 50 / total,
+			Status:     fmt.Sprintf("Comparing tiles %d/%d", done, total),
+		})
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Fetch the tiles with new imagery
+	outputDir := downloads.OutputDir(ctx, d.GetDownloadPath())
+	tilesDirName := naming.GenerateTilesDirName(common.ProviderEsriWayback, date, zoom)
+	var fetch []int
+	for i, change := range changes {
+		switch {
+		case compareFailed[i]:
+			manifest.TilesFailed++
+		case change == nil:
+			manifest.TilesUnchanged++
+		case change.Change != DeltaRemoved:
+			fetch = append(fetch, i)
+		}
+	}
+	d.emitLog(ctx, fmt.Sprintf("%d of %d tiles changed since %s", len(fetch), total, baseDate))
+
+	var saved, fetchFailed int
+	var mu sync.Mutex
+	d.forEachTile(ctx, len(fetch), func(n, done int) {
+		i := fetch[n]
+		path, err := d.saveChangedTile(ctx, layer, tiles[i], date, filepath.Join(outputDir, tilesDirName))
+		mu.Lock()
+		if err != nil {
+			log.Printf("[Incremental] Failed to fetch tile z=%d x=%d y=%d: %v", zoom, tiles[i].Column, tiles[i].Row, err)
+			changes[i] = nil
+			fetchFailed++
+		} else {
+			changes[i].Path = filepath.ToSlash(filepath.Join(tilesDirName, path))
+			saved++
+		}
+		mu.Unlock()
+		if err != nil {
+			downloads.RecordTileFailure(ctx, common.ProviderEsriWayback, date, zoom, tiles[i].Column, tiles[i].Row, "", err)
+		} else {
+			downloads.RecordTile(ctx)
+		}
+		d.emitProgress(ctx, downloads.DownloadProgress{
+			Downloaded: done,
+			Total:      len(fetch),
+			Percent:    50 + done*50/len(fetch),
+			Status:     fmt.Sprintf("Downloading changed tile %d/%d", done, len(fetch)),
+		})
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	manifest.TilesFailed += fetchFailed
+
+	for _, change := range changes {
+		if change != nil {
+			manifest.Tiles = append(manifest.Tiles, *change)
+		}
+	}
+	sort.Slice(manifest.Tiles, func(i, j int) bool {
+		a, b := manifest.Tiles[i], manifest.Tiles[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+
+	// Tiles that could not be compared may have changed, so they count as missing
+	if needed := saved + manifest.TilesFailed; needed > 0 {
+		if _, enforced := downloads.MinSuccessRate(ctx); enforced {
+			if err := downloads.CheckSuccessRate(ctx, saved, needed); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifest.CreatedAt = time.Now().Format(time.RFC3339)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(outputDir, naming.GenerateDeltaManifestName(common.ProviderEsriWayback, date, zoom))
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write delta manifest: %w", err)
+	}
+
+	d.emitLog(ctx, fmt.Sprintf("Saved %d changed tiles, delta manifest: %s", saved, manifestPath))
+	d.trackEvent("download_complete", map[string]interface{}{
+		"source":  common.ProviderEsriWayback,
+		"zoom":    zoom,
+		"total":   len(fetch),
+		"success": saved,
+		"failed":  manifest.TilesFailed,
+		"format":  "incremental",
+	})
+	d.emitProgress(ctx, downloads.DownloadProgress{
+		Downloaded: total,
+		Total:      total,
+		Percent:    100,
+		Status:     "Complete",
+	})
+	return manifest, nil
+}
+
+// compareTile returns how a tile's imagery differs between two layers, or nil when both
+// serve the same source release (or neither has imagery)
+func (d *Downloader) compareTile(ctx context.Context, baseLayer, layer *esri.Layer, tile *esri.EsriTile) (*DeltaTile, error) {
+	if err := d.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer d.sem.Release(1)
+
+	baseSource, baseAvailable, err := d.esriClient.TileSourceRelease(baseLayer, tile)
+	if err != nil {
+		return nil, err
+	}
+	source, available, err := d.esriClient.TileSourceRelease(layer, tile)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &DeltaTile{Z: tile.Level, X: tile.Column, Y: tile.Row, BaseSource: baseSource, Source: source}
+	switch {
+	case baseAvailable && available && baseSource != source:
+		change.Change = DeltaChanged
+	case !baseAvailable && available:
+		change.Change = DeltaAdded
+	case baseAvailable && !available:
+		change.Change = DeltaRemoved
+	default:
+		return nil, nil
+	}
+	return change, nil
+}
+
+// saveChangedTile fetches a tile through the tile cache and saves it under tilesDir in the
+// OGC structure of full downloads, returning its path relative to tilesDir
+func (d *Downloader) saveChangedTile(ctx context.Context, layer *esri.Layer, tile *esri.EsriTile, date, tilesDir string) (string, error) {
+	fetch := func() (data []byte, err error) {
+		if err := d.sem.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer d.sem.Release(1)
+		err = crash.Guard("Esri incremental", func() (err error) {
+			data, err = d.esriClient.FetchTile(layer, tile)
+			return err
+		})
+		return data, err
+	}
+
+	var data []byte
+	var err error
+	if d.tileCache != nil {
+		data, _, err = d.tileCache.GetOrFetch(common.ProviderEsriWayback, tile.Level, tile.Column, tile.Row, date, fetch)
+	} else {
+		data, err = fetch()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data, ext, err := d.encodeTile(data)
+	if err != nil {
+		return "", err
+	}
+	rel := filepath.Join(common.ProviderEsriWayback, date, fmt.Sprintf("%d", tile.Level), fmt.Sprintf("%d", tile.Column), fmt.Sprintf("%d.%s", tile.Row, ext))
+	path := filepath.Join(tilesDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create tile directories: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save tile: %w", err)
+	}
+	return rel, nil
+}
+
+// forEachTile runs fn for indexes 0..n-1 on the downloader's workers. done is the number of
+// calls started so far, for progress reporting. Stops handing out work when ctx is cancelled.
+func (d *Downloader) forEachTile(ctx context.Context, n int, fn func(i, done int)) {
+	var started int64
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < d.maxWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i, int(atomic.AddInt64(&started, 1)))
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case next <- i:
+		}
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,77 @@
+package esri
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/esri"
+)
+
+const (
+	// MinZoomCoverage is the share of sampled tiles a zoom needs imagery for to be usable
+	MinZoomCoverage = 0.5
+
+	// minProbedZoom is the lowest zoom MaxAvailableZoom probes; every release covers it
+	minProbedZoom = 10
+)
+
+// MaxAvailableZoom returns the highest zoom, from maxZoom down, at which layer has imagery
+// for at least MinZoomCoverage of the tiles sampled across bbox (see SampleTiles), and
+// the share covered at that zoom. Older Wayback releases often lack the highest zooms,
+// where downloads would come back blank. Only the tilemap is queried, no tile is fetched.
+func (d *Downloader) MaxAvailableZoom(ctx context.Context, bbox downloads.BoundingBox, layer *esri.Layer, maxZoom int) (zoom int, coverage float64, err error) {
+	for zoom = maxZoom; zoom >= minProbedZoom; zoom-- {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		tiles, err := d.SampleTiles(bbox, zoom)
+		if err != nil {
+			return 0, 0, err
+		}
+		if coverage, err = d.tileMapCoverage(ctx, layer, tiles); err != nil {
+			return 0, 0, err
+		}
+		if coverage >= MinZoomCoverage {
+			return zoom, coverage, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("release %s has no imagery for the area at zoom %d-%d", layer.Date.Format("2006-01-02"), minProbedZoom, maxZoom)
+}
+
+// tileMapCoverage returns the share of tiles the layer's tilemap reports imagery for.
+// An error is returned only when none of the tilemap requests succeeded.
+func (d *Downloader) tileMapCoverage(ctx context.Context, layer *esri.Layer, tiles []*esri.EsriTile) (float64, error) {
+	available := make([]bool, len(tiles))
+	errs := make([]error, len(tiles))
+
+	var wg sync.WaitGroup
+	for i, tile := range tiles {
+		wg.Add(1)
+		go func(i int, tile *esri.EsriTile) {
+			defer wg.Done()
+			if err := d.sem.Acquire(ctx, 1); err != nil {
+				errs[i] = err
+				return
+			}
+			defer d.sem.Release(1)
+			_, available[i], errs[i] = d.esriClient.TileSourceRelease(layer, tile)
+		}(i, tile)
+	}
+	wg.Wait()
+
+	covered, failed := 0, 0
+	for i := range tiles {
+		switch {
+		case errs[i] != nil:
+			failed++
+		case available[i]:
+			covered++
+		}
+	}
+	if failed == len(tiles) {
+		return 0, fmt.Errorf("failed to query the tilemap for %d sample tiles: %w", len(tiles), errs[0])
+	}
+	return float64(covered) / float64(len(tiles)-failed), nil
+}
@@ -2,16 +2,18 @@ package esri
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 
+	"imagery-desktop/internal/common"
 	"imagery-desktop/internal/downloads"
-	"imagery-desktop/internal/esri"
 )
 
 // DownloadImageryRange downloads Esri Wayback imagery for multiple dates (bulk download)
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-// This function deduplicates by checking the center tile - dates with identical imagery are skipped
+// This function deduplicates by hashing a grid of sample tiles across the AOI - dates with
+// identical imagery at every sample (or no coverage) are skipped
 func (d *Downloader) DownloadImageryRange(ctx context.Context, bbox downloads.BoundingBox, zoom int, dates []string, format string) error {
 	if len(dates) == 0 {
 		return fmt.Errorf("no dates provided")
@@ -21,30 +23,33 @@ func (d *Downloader) DownloadImageryRange(ctx context.Context, bbox downloads.Bo
 	if err := downloads.ValidateCoordinates(bbox, zoom); err != nil {
 		return fmt.Errorf("invalid coordinates: %w", err)
 	}
+	if err := downloads.ValidateZoomForProvider(zoom, common.ProviderEsriWayback); err != nil {
+		return err
+	}
+	if err := bbox.ValidateWebMercator(common.DisplayNameEsriWayback); err != nil {
+		return err
+	}
 
-	d.emitLog(fmt.Sprintf("Starting bulk download for %d dates (with deduplication)", len(dates)))
+	d.emitLog(ctx, fmt.Sprintf("Starting bulk download for %d dates (with deduplication)", len(dates)))
 
 	// Sort dates for consistent output
 	sort.Strings(dates)
 
-	// Get center tile for deduplication check
-	centerLat := (bbox.South + bbox.North) / 2
-	centerLon := (bbox.West + bbox.East) / 2
-	centerTile, err := esri.GetTileForWgs84(centerLat, centerLon, zoom)
+	// Sample tiles across the AOI for deduplication, so edge-of-AOI changes aren't missed
+	sampleTiles, err := d.SampleTiles(bbox, zoom)
 	if err != nil {
-		return fmt.Errorf("failed to get center tile: %w", err)
+		return fmt.Errorf("failed to get sample tiles: %w", err)
 	}
+	d.emitLog(ctx, fmt.Sprintf("Deduplicating with %d sample tiles across the area", len(sampleTiles)))
 
 	// Track seen tile hashes to skip duplicates
 	seenHashes := make(map[string]string) // hash -> first date that had this imagery
 	downloadedCount := 0
 	skippedCount := 0
 
-	// Enable range download mode for unified progress
-	d.SetRangeDownloadState(true, 0, len(dates))
-	defer func() {
-		d.SetRangeDownloadState(false, 0, 0)
-	}()
+	// Range position travels with this run's context for unified progress
+	tracker := downloads.NewRangeTracker(len(dates))
+	ctx = downloads.WithRange(ctx, tracker)
 
 	total := len(dates)
 	for i, date := range dates {
@@ -55,57 +60,57 @@ func (d *Downloader) DownloadImageryRange(ctx context.Context, bbox downloads.Bo
 		default:
 		}
 
-		d.SetRangeDownloadState(true, i+1, total)
+		tracker.SetCurrentDate(i + 1)
 
 		// Find layer for this date
 		layer, err := d.findLayerForDate(date)
 		if err != nil {
-			d.emitLog(fmt.Sprintf("Skipping %s: %v", date, err))
+			d.emitLog(ctx, fmt.Sprintf("Skipping %s: %v", date, err))
 			skippedCount++
 			continue
 		}
 
-		// Fetch center tile to check for duplicates
-		tileData, err := d.esriClient.FetchTile(layer, centerTile)
-		if err != nil || len(tileData) == 0 {
-			d.emitLog(fmt.Sprintf("Skipping %s: no tile data available", date))
+		// Fingerprint the sample tiles to check for duplicates
+		// A date some samples failed for is downloaded without deduplicating it
+		hashKey, blank, err := d.FingerprintArea(ctx, layer, sampleTiles)
+		switch {
+		case errors.Is(err, ErrIncompleteFingerprint):
+			d.emitLog(ctx, fmt.Sprintf("Not deduplicating %s: %v", date, err))
+		case err != nil:
+			d.emitLog(ctx, fmt.Sprintf("Skipping %s: %v", date, err))
 			skippedCount++
 			continue
-		}
-
-		// Compute simple hash of tile data (first 1KB + last 1KB + length)
-		var hashKey string
-		if len(tileData) < 2048 {
-			hashKey = fmt.Sprintf("%x-%d", tileData, len(tileData))
-		} else {
-			hashKey = fmt.Sprintf("%x-%x-%d", tileData[:1024], tileData[len(tileData)-1024:], len(tileData))
-		}
-
-		// Check if we've seen this imagery before
-		if firstDate, exists := seenHashes[hashKey]; exists {
-			d.emitLog(fmt.Sprintf("Skipping %s: identical to %s", date, firstDate))
+		case blank:
+			d.emitLog(ctx, fmt.Sprintf("Skipping %s: no coverage at zoom %d", date, zoom))
 			skippedCount++
 			continue
+		default:
+			// Check if we've seen this imagery before
+			if firstDate, exists := seenHashes[hashKey]; exists {
+				d.emitLog(ctx, fmt.Sprintf("Skipping %s: identical to %s", date, firstDate))
+				skippedCount++
+				continue
+			}
+			seenHashes[hashKey] = date
 		}
-		seenHashes[hashKey] = date
 
 		// Download this unique date
 		if err := d.DownloadImagery(ctx, bbox, zoom, date, format); err != nil {
-			d.emitLog(fmt.Sprintf("Failed to download %s: %v", date, err))
+			d.emitLog(ctx, fmt.Sprintf("Failed to download %s: %v", date, err))
 		} else {
 			downloadedCount++
 		}
 	}
 
 	// Emit completion
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Downloaded: total,
 		Total:      total,
 		Percent:    100,
 		Status:     fmt.Sprintf("Downloaded %d unique dates (skipped %d duplicates)", downloadedCount, skippedCount),
 	})
 
-	d.emitLog(fmt.Sprintf("Bulk download complete: %d unique, %d skipped", downloadedCount, skippedCount))
+	d.emitLog(ctx, fmt.Sprintf("Bulk download complete: %d unique, %d skipped", downloadedCount, skippedCount))
 
 	return nil
 }
@@ -0,0 +1,205 @@
+package esri
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/esri"
+)
+
+const (
+	// DefaultSampleGrid samples a 3x3 grid of tiles for date discovery and dedup
+	DefaultSampleGrid = 3
+	// MaxSampleGrid caps sampling at 8x8 (64 tiles) per date
+	MaxSampleGrid = 8
+)
+
+// SetSampleGrid sets the grid size (N for an N x N grid) used to sample tiles across the AOI
+// for date discovery and dedup hashing (thread-safe). 0 selects the default.
+func (d *Downloader) SetSampleGrid(grid int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sampleGrid = grid
+}
+
+// SampleTiles returns the tiles sampled across bbox for date discovery and dedup
+func (d *Downloader) SampleTiles(bbox downloads.BoundingBox, zoom int) ([]*esri.EsriTile, error) {
+	d.mu.Lock()
+	grid := d.sampleGrid
+	d.mu.Unlock()
+
+	if grid <= 0 {
+		grid = DefaultSampleGrid
+	}
+	if grid > MaxSampleGrid {
+		grid = MaxSampleGrid
+	}
+
+	return esri.GetSampleTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, zoom, grid)
+}
+
+// sampleRetries is how many more times FingerprintArea fetches a sample tile that failed
+const sampleRetries = 2
+
+// ErrIncompleteFingerprint is returned by FingerprintArea when some sample tiles could not
+// be fetched; the date can still be downloaded but must not be deduplicated on its hash.
+var ErrIncompleteFingerprint = errors.New("incomplete sample fingerprint")
+
+// FingerprintArea fetches the sample tiles for a layer and returns a combined hash of their
+// content, so two dates only match when every sampled tile is identical.
+// blank is true when every tile is blank (no coverage at this zoom).
+// Tiles that fail to fetch are retried; when some still fail the error wraps
+// ErrIncompleteFingerprint, as a partial hash would miss or invent duplicates.
+func (d *Downloader) FingerprintArea(ctx context.Context, layer *esri.Layer, tiles []*esri.EsriTile) (hash string, blank bool, err error) {
+	date := layer.Date.Format("2006-01-02")
+
+	type sample struct {
+		data []byte
+		err  error
+	}
+	samples := make([]sample, len(tiles))
+	fetched := make([]bool, len(tiles))
+
+	for attempt := 0; attempt <= sampleRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", false, ctx.Err()
+			case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+			}
+		}
+
+		var wg sync.WaitGroup
+		for i, tile := range tiles {
+			if fetched[i] {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, tile *esri.EsriTile) {
+				defer wg.Done()
+				data, err := d.fetchSampleTile(ctx, layer, tile, date)
+				if err == nil && len(data) == 0 {
+					err = fmt.Errorf("no tile data available")
+				}
+				samples[i], fetched[i] = sample{data, err}, err == nil
+			}(i, tile)
+		}
+		wg.Wait()
+
+		missing := 0
+		for _, ok := range fetched {
+			if !ok {
+				missing++
+			}
+		}
+		if missing == 0 {
+			break
+		}
+	}
+
+	h := sha256.New()
+	count := 0
+	blank = true
+	var firstErr error
+	for i, s := range samples {
+		if !fetched[i] {
+			if firstErr == nil {
+				firstErr = s.err
+			}
+			continue
+		}
+		count++
+		tileHash := sha256.Sum256(s.data)
+		fmt.Fprintf(h, "%d:%x;", i, tileHash)
+		if !d.isBlankTile(s.data) {
+			blank = false
+		}
+	}
+
+	if count == 0 {
+		return "", false, fmt.Errorf("failed to fetch any of %d sample tiles: %w", len(tiles), firstErr)
+	}
+	if count < len(tiles) {
+		return "", false, fmt.Errorf("%w: %d of %d sample tiles failed: %v", ErrIncompleteFingerprint, len(tiles)-count, len(tiles), firstErr)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), blank, nil
+}
+
+// fetchSampleTile fetches one sample tile through the tile cache, so sampled tiles are
+// reused by the download that follows
+func (d *Downloader) fetchSampleTile(ctx context.Context, layer *esri.Layer, tile *esri.EsriTile, date string) ([]byte, error) {
+	fetch := func() ([]byte, error) {
+		if err := d.sem.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer d.sem.Release(1)
+		return d.esriClient.FetchTile(layer, tile)
+	}
+
+	if d.tileCache == nil {
+		return fetch()
+	}
+	data, _, err := d.tileCache.GetOrFetch(common.ProviderEsriWayback, tile.Level, tile.Column, tile.Row, date, fetch)
+	return data, err
+}
+
+// GetAvailableDatesForArea returns the layer dates with local changes at any of the sample
+// tiles across bbox, newest first. Edge-of-AOI changes are found even when the center
+// tile never changed.
+func (d *Downloader) GetAvailableDatesForArea(bbox downloads.BoundingBox, zoom int) ([]string, error) {
+	tiles, err := d.SampleTiles(bbox, zoom)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]*esri.DatedTile, len(tiles))
+	errs := make([]error, len(tiles))
+
+	var wg sync.WaitGroup
+	for i, tile := range tiles {
+		wg.Add(1)
+		go func(i int, tile *esri.EsriTile) {
+			defer wg.Done()
+			results[i], errs[i] = d.esriClient.GetAvailableDates(tile)
+		}(i, tile)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	var dates []string
+	var firstErr error
+	succeeded := 0
+	for i, datedTiles := range results {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		succeeded++
+		for _, dt := range datedTiles {
+			// LayerDate (not CaptureDate) is what downloads use to find the layer
+			dateStr := dt.LayerDate.Format("2006-01-02")
+			if !seen[dateStr] {
+				seen[dateStr] = true
+				dates = append(dates, dateStr)
+			}
+		}
+	}
+
+	if succeeded == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	d.emitLog(context.Background(), fmt.Sprintf("Found %d Esri dates across %d sample tiles", len(dates), len(tiles)))
+	return dates, nil
+}
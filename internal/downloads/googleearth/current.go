@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"image"
 	"image/draw"
-	"image/jpeg"
+	_ "image/jpeg"
 	"image/png"
 	"log"
 	"os"
@@ -14,16 +14,17 @@ import (
 	"time"
 
 	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
 	"imagery-desktop/internal/downloads"
 	"imagery-desktop/internal/googleearth"
 	"imagery-desktop/internal/utils/naming"
-	"imagery-desktop/pkg/geotiff"
 )
 
 // DownloadImagery downloads current Google Earth imagery for a bounding box
 // format: "tiles" = individual tiles only, "geotiff" = merged GeoTIFF only, "both" = keep both
-func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, format string) error {
-	d.emitLog("Starting Google Earth download...")
+func (d *Downloader) DownloadImagery(ctx context.Context, bbox downloads.BoundingBox, zoom int, format string) error {
+	d.emitLog(ctx, "Starting Google Earth download...")
+	outputDir := downloads.OutputDir(ctx, d.GetDownloadPath())
 
 	// Validate request
 	if err := d.validateDownloadRequest(bbox, zoom, format); err != nil {
@@ -40,7 +41,7 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 	if total == 0 {
 		return fmt.Errorf("no tiles in bounding box")
 	}
-	d.emitLog(fmt.Sprintf("Downloading %d tiles...", total))
+	d.emitLog(ctx, fmt.Sprintf("Downloading %d tiles...", total))
 
 	// Calculate tile bounds for stitching
 	bounds, err := calculateTileBounds(tiles)
@@ -49,7 +50,7 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 	}
 	cols := bounds.Cols()
 	rows := bounds.Rows()
-	d.emitLog(fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
+	d.emitLog(ctx, fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
 
 	// Create output image only if we need GeoTIFF
 	var outputImg *image.RGBA
@@ -64,14 +65,13 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 	timestamp := time.Now().Format("2006-01-02")
 	var tilesDir string
 	if format == "tiles" || format == "both" {
-		tilesDir = filepath.Join(d.downloadPath, naming.GenerateTilesDirName(common.ProviderGoogleEarth, timestamp, zoom))
+		tilesDir = filepath.Join(outputDir, naming.GenerateTilesDirName(common.ProviderGoogleEarth, timestamp, zoom))
 		if err := os.MkdirAll(tilesDir, 0755); err != nil {
 			return fmt.Errorf("failed to create tiles directory: %w", err)
 		}
 	}
 
 	// Download and stitch tiles with semaphore-based concurrency
-	ctx := context.Background()
 	successCount := 0
 	errors := make(chan error, total)
 
@@ -95,11 +95,15 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 				}
 
 				// Download tile
-				data, err := d.geClient.FetchTile(job.tile)
+				var data []byte
+				err := crash.Guard("GECurrent worker", func() (err error) {
+					data, err = d.geClient.FetchTile(job.tile)
+					return err
+				})
 				d.releaseWorker()
 
 				if err != nil {
-					d.emitLog(fmt.Sprintf("[GEDownload] Failed to download tile %s: %v", job.tile.Path, err))
+					d.emitLog(ctx, fmt.Sprintf("[GEDownload] Failed to download tile %s: %v", job.tile.Path, err))
 					resultChan <- tileResult{tile: job.tile, index: job.index, success: false, err: err}
 					continue
 				}
@@ -130,7 +134,7 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 		} else {
 			status = fmt.Sprintf("Downloading tile %d/%d", processedCount, total)
 		}
-		d.emitProgress(downloads.DownloadProgress{
+		d.emitProgress(ctx, downloads.DownloadProgress{
 			Downloaded: processedCount,
 			Total:      total,
 			Percent:    (processedCount * 100) / total,
@@ -139,6 +143,7 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 
 		if !result.success {
 			errors <- result.err
+			downloads.RecordTileFailure(ctx, common.ProviderGoogleEarth, "", zoom, result.tile.Column, result.tile.Row, "", result.err)
 			continue
 		}
 
@@ -152,19 +157,24 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 		// Decode and stitch for GeoTIFF
 		if format == "geotiff" || format == "both" {
 			if err := d.stitchTile(outputImg, result.tile, result.data, bounds); err != nil {
-				d.emitLog(fmt.Sprintf("[GEDownload] Failed to decode tile %s: %v", result.tile.Path, err))
+				d.emitLog(ctx, fmt.Sprintf("[GEDownload] Failed to decode tile %s: %v", result.tile.Path, err))
+				downloads.RecordTileFailure(ctx, common.ProviderGoogleEarth, "", zoom, result.tile.Column, result.tile.Row, downloads.FailureDecode, err)
 				continue
 			}
 		}
+		downloads.RecordTile(ctx)
 		successCount++
 	}
 	close(errors)
 
-	d.emitLog(fmt.Sprintf("Processed %d/%d tiles", successCount, total))
+	d.emitLog(ctx, fmt.Sprintf("Processed %d/%d tiles", successCount, total))
 
-	// Check if we have enough tiles
-	if err := checkSuccessRate(successCount, total); err != nil {
-		d.emitLog(fmt.Sprintf("Warning: %v - GeoTIFF may have gaps", err))
+	// Check if we have enough tiles; tasks with their own minimum fail rather than export gaps
+	if err := downloads.CheckSuccessRate(ctx, successCount, total); err != nil {
+		if _, enforced := downloads.MinSuccessRate(ctx); enforced {
+			return err
+		}
+		d.emitLog(ctx, fmt.Sprintf("Warning: %v - GeoTIFF may have gaps", err))
 	}
 
 	// Track download completion
@@ -179,17 +189,17 @@ func (d *Downloader) DownloadImagery(bbox downloads.BoundingBox, zoom int, forma
 
 	// Save GeoTIFF if requested
 	if format == "geotiff" || format == "both" {
-		if err := d.saveGeoTIFF(outputImg, bbox, zoom, bounds, timestamp, outputWidth, outputHeight); err != nil {
+		if err := d.saveGeoTIFF(ctx, outputDir, outputImg, bbox, zoom, bounds, timestamp, outputWidth, outputHeight); err != nil {
 			return fmt.Errorf("failed to save GeoTIFF: %w", err)
 		}
 	}
 
 	if format == "tiles" || format == "both" {
-		d.emitLog(fmt.Sprintf("Tiles saved to: %s", tilesDir))
+		d.emitLog(ctx, fmt.Sprintf("Tiles saved to: %s", tilesDir))
 	}
 
 	// Emit completion
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Downloaded: total,
 		Total:      total,
 		Percent:    100,
@@ -210,7 +220,12 @@ func (d *Downloader) saveTile(tilesDir, source, date string, zoom int, tile *goo
 		return fmt.Errorf("failed to create tile directories: %w", err)
 	}
 
-	tilePath := filepath.Join(xDir, fmt.Sprintf("%d.jpg", tile.Row))
+	data, ext, err := d.encodeTile(data)
+	if err != nil {
+		return err
+	}
+
+	tilePath := filepath.Join(xDir, fmt.Sprintf("%d.%s", tile.Row, ext))
 	if err := os.WriteFile(tilePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write tile file: %w", err)
 	}
@@ -218,9 +233,10 @@ func (d *Downloader) saveTile(tilesDir, source, date string, zoom int, tile *goo
 	return nil
 }
 
-// stitchTile decodes a tile and draws it onto the output image
+// stitchTile decodes a tile (JPEG, or PNG for lossless zoom fallback tiles) and draws it
+// onto the output image
 func (d *Downloader) stitchTile(outputImg *image.RGBA, tile *googleearth.Tile, data []byte, bounds TileBounds) error {
-	img, err := jpeg.Decode(bytes.NewReader(data))
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to decode tile: %w", err)
 	}
@@ -228,7 +244,7 @@ func (d *Downloader) stitchTile(outputImg *image.RGBA, tile *googleearth.Tile, d
 	// Calculate position in output image
 	// GE rows increase from south to north, but image Y=0 is at top
 	// So we need to invert: higher row numbers go to lower Y positions
-	xOff := (tile.Column - bounds.MinCol) * downloads.TileSize
+	xOff := bounds.ColumnOffset(tile.Column) * downloads.TileSize
 	yOff := (bounds.MaxRow - tile.Row) * downloads.TileSize
 
 	// Draw tile onto output image
@@ -238,52 +254,37 @@ func (d *Downloader) stitchTile(outputImg *image.RGBA, tile *googleearth.Tile, d
 }
 
 // saveGeoTIFF saves the stitched image as a GeoTIFF with metadata
-func (d *Downloader) saveGeoTIFF(outputImg *image.RGBA, bbox downloads.BoundingBox, zoom int, bounds TileBounds, timestamp string, outputWidth, outputHeight int) error {
-	// Calculate georeferencing in Web Mercator (EPSG:3857)
-	// After Y-inversion, image top-left corresponds to (bounds.MinCol, bounds.MaxRow+1) in GE coords
-	// Image bottom-right corresponds to (bounds.MaxCol+1, bounds.MinRow)
-	originX, originY := googleearth.TileToWebMercator(bounds.MaxRow+1, bounds.MinCol, zoom)
-	endX, endY := googleearth.TileToWebMercator(bounds.MinRow, bounds.MaxCol+1, zoom)
-	pixelWidth := (endX - originX) / float64(outputWidth)
-	pixelHeight := (endY - originY) / float64(outputHeight) // Will be negative (Y decreases going down)
+func (d *Downloader) saveGeoTIFF(ctx context.Context, outputDir string, outputImg *image.RGBA, bbox downloads.BoundingBox, zoom int, bounds TileBounds, timestamp string, outputWidth, outputHeight int) error {
+	originX, originY, pixelWidth, pixelHeight, epsg := d.georeference(ctx, bbox, zoom, bounds, outputWidth, outputHeight)
 
 	// Generate GeoTIFF filename
-	tifPath := filepath.Join(d.downloadPath, naming.GenerateGeoTIFFFilename(common.ProviderGoogleEarth, timestamp, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
+	tifPath := filepath.Join(outputDir, naming.GenerateGeoTIFFFilename(common.ProviderGoogleEarth, timestamp, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
 
 	// Emit progress for GeoTIFF encoding phase
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Percent: 99,
 		Status:  "Encoding GeoTIFF file...",
 	})
-	d.emitLog("Encoding GeoTIFF file...")
-
-	// Save as GeoTIFF with embedded projection and metadata
-	if err := geotiff.SaveAsGeoTIFFWithMetadata(
-		outputImg,
-		tifPath,
-		originX,
-		originY,
-		pixelWidth,
-		pixelHeight,
-		"Google Earth",
-		timestamp,
-		"", // appVersion - not available in downloader context
-	); err != nil {
+	d.emitLog(ctx, "Encoding GeoTIFF file...")
+
+	// Save as GeoTIFF with embedded projection, metadata and provider credits (split into parts if huge)
+	providers := d.dominantProviders(ctx, bbox, zoom, "")
+	if err := d.saveSplitGeoTIFF(ctx, outputImg, tifPath, originX, originY, pixelWidth, pixelHeight, epsg, "Google Earth", timestamp, providers); err != nil {
 		return fmt.Errorf("failed to save GeoTIFF: %w", err)
 	}
 
-	d.emitLog(fmt.Sprintf("Saved: %s", tifPath))
-
-	// Save PNG copy for video export compatibility
-	pngPath := tifPath[:len(tifPath)-4] + ".png"
-	if err := savePNGCopy(outputImg, pngPath); err != nil {
-		log.Printf("Warning: Failed to save PNG copy: %v", err)
+	// Optional PNG copy for tools that cannot read GeoTIFFs
+	if d.shouldSavePNGCopies() {
+		pngPath := tifPath[:len(tifPath)-4] + ".png"
+		if err := savePNGCopy(outputImg, pngPath); err != nil {
+			log.Printf("Warning: Failed to save PNG copy: %v", err)
+		}
 	}
 
 	return nil
 }
 
-// savePNGCopy saves a PNG copy of the image for video export
+// savePNGCopy saves a PNG copy of the image alongside its GeoTIFF
 func savePNGCopy(img *image.RGBA, path string) error {
 	f, err := os.Create(path)
 	if err != nil {
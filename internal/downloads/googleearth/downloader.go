@@ -5,19 +5,19 @@ import (
 	"fmt"
 	"image"
 	"log"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"golang.org/x/sync/semaphore"
 
 	"imagery-desktop/internal/cache"
+	"imagery-desktop/internal/common"
 	"imagery-desktop/internal/downloads"
 	"imagery-desktop/internal/googleearth"
 	"imagery-desktop/internal/ratelimit"
-)
-
-const (
-	// MinSuccessRate is the minimum percentage of tiles needed for a valid download
-	MinSuccessRate = 0.3
+	"imagery-desktop/internal/tilemath"
+	"imagery-desktop/pkg/geotiff"
 )
 
 // Downloader handles Google Earth imagery downloads with dependency injection
@@ -35,6 +35,14 @@ type Downloader struct {
 	maxWorkers   int64
 	mu           sync.Mutex
 
+	// GeoTIFF export options
+	maxGeoTIFFDimension int  // Exports larger than this (px) are split into parts + VRT
+	buildOverviews      bool // Embed internal overviews
+	savePNGCopies       bool // Write a PNG copy next to each GeoTIFF export
+	nativeCRS           bool // Georeference in EPSG:4326, GE's native Plate Carrée grid
+
+	tileOutput downloads.TileOutput // Pixel format of saved tiles
+
 	// Tile server for historical tile fetching with epoch fallback
 	tileServer TileServerInterface
 }
@@ -54,6 +62,10 @@ type Config struct {
 	RateLimitHandler  *ratelimit.Handler
 	TrackEventCallback func(string, map[string]interface{})
 	MaxWorkers        int
+	MaxGeoTIFFDimension int  // Split exports above this width/height (0 = default)
+	BuildOverviews      bool // Embed internal overviews in GeoTIFF exports
+	SavePNGCopies       bool // Write a PNG copy next to each GeoTIFF export
+	NativeCRS           bool // Georeference GeoTIFF exports in EPSG:4326 instead of EPSG:3857
 	TileServer        TileServerInterface // For historical downloads with epoch fallback
 }
 
@@ -82,23 +94,26 @@ func NewDownloader(cfg Config) (*Downloader, error) {
 		semaphore:         semaphore.NewWeighted(int64(maxWorkers)),
 		maxWorkers:        int64(maxWorkers),
 		tileServer:        cfg.TileServer,
+		maxGeoTIFFDimension: cfg.MaxGeoTIFFDimension,
+		buildOverviews:      cfg.BuildOverviews,
+		savePNGCopies:       cfg.SavePNGCopies,
+		nativeCRS:           cfg.NativeCRS,
 	}, nil
 }
 
-// emitLog sends a log message via callback if available
-func (d *Downloader) emitLog(message string) {
-	if d.logCallback != nil {
-		d.logCallback(message)
-	} else {
-		log.Println(message)
+// emitLog sends a log message via callback if available (logged otherwise), and to the
+// operation in ctx
+func (d *Downloader) emitLog(ctx context.Context, message string) {
+	callback := d.logCallback
+	if callback == nil {
+		callback = func(message string) { log.Println(message) }
 	}
+	downloads.ReportLog(ctx, callback, message)
 }
 
-// emitProgress sends progress update via callback if available
-func (d *Downloader) emitProgress(progress downloads.DownloadProgress) {
-	if d.progressCallback != nil {
-		d.progressCallback(progress)
-	}
+// emitProgress sends progress update to the callback and the operation in ctx
+func (d *Downloader) emitProgress(ctx context.Context, progress downloads.DownloadProgress) {
+	downloads.ReportProgress(ctx, d.progressCallback, progress)
 }
 
 // trackEvent tracks an event via callback if available
@@ -108,7 +123,8 @@ func (d *Downloader) trackEvent(event string, properties map[string]interface{})
 	}
 }
 
-// SetDownloadPath updates the download path (thread-safe)
+// SetDownloadPath updates the default download path, used when the operation in the
+// context has no output directory (thread-safe)
 func (d *Downloader) SetDownloadPath(path string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -122,53 +138,164 @@ func (d *Downloader) GetDownloadPath() string {
 	return d.downloadPath
 }
 
-// TileBounds represents the bounds of a tile grid
-type TileBounds struct {
-	MinCol int
-	MaxCol int
-	MinRow int
-	MaxRow int
+// SetMaxGeoTIFFDimension sets the width/height above which GeoTIFF exports are split (thread-safe)
+func (d *Downloader) SetMaxGeoTIFFDimension(maxDim int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.maxGeoTIFFDimension = maxDim
 }
 
-// Cols returns the number of columns in the bounds
-func (b TileBounds) Cols() int {
-	return b.MaxCol - b.MinCol + 1
+// SetBuildOverviews enables internal overview generation for GeoTIFF exports (thread-safe)
+func (d *Downloader) SetBuildOverviews(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.buildOverviews = enabled
 }
 
-// Rows returns the number of rows in the bounds
-func (b TileBounds) Rows() int {
-	return b.MaxRow - b.MinRow + 1
+// SetSavePNGCopies enables writing a PNG copy next to each GeoTIFF export (thread-safe)
+func (d *Downloader) SetSavePNGCopies(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.savePNGCopies = enabled
 }
 
-// calculateTileBounds calculates the bounds of a tile set
-func calculateTileBounds(tiles []*googleearth.Tile) (TileBounds, error) {
-	if len(tiles) == 0 {
-		return TileBounds{}, fmt.Errorf("no tiles provided")
+// SetTileOutput sets the pixel format of individually saved tiles (thread-safe)
+func (d *Downloader) SetTileOutput(output downloads.TileOutput) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tileOutput = output
+}
+
+// encodeTile converts fetched tile bytes to the configured tile output format,
+// returning them with the file extension to save them under
+func (d *Downloader) encodeTile(data []byte) ([]byte, string, error) {
+	d.mu.Lock()
+	output := d.tileOutput
+	d.mu.Unlock()
+	return output.Encode(data)
+}
+
+// SetNativeCRS makes GeoTIFF exports use EPSG:4326 for every area (thread-safe)
+func (d *Downloader) SetNativeCRS(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nativeCRS = enabled
+}
+
+// shouldSavePNGCopies reports whether PNG copies are enabled (thread-safe)
+func (d *Downloader) shouldSavePNGCopies() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.savePNGCopies
+}
+
+// georeference returns the GeoTIFF origin, pixel size and CRS of a stitched GE mosaic.
+// Areas within the Web Mercator limits are georeferenced in EPSG:3857 like other providers;
+// polar areas, and all areas when the native CRS is enabled, use EPSG:4326, which matches
+// GE's native Plate Carrée tiles exactly (the pixels are never resampled either way).
+func (d *Downloader) georeference(ctx context.Context, bbox downloads.BoundingBox, zoom int, bounds TileBounds, outputWidth, outputHeight int) (originX, originY, pixelWidth, pixelHeight float64, epsg int) {
+	d.mu.Lock()
+	nativeCRS := d.nativeCRS
+	d.mu.Unlock()
+
+	if nativeCRS || bbox.BeyondWebMercator() {
+		if nativeCRS {
+			d.emitLog(ctx, "Saving GeoTIFF in EPSG:4326, Google Earth's native Plate Carrée grid")
+		} else {
+			d.emitLog(ctx, "Area extends beyond the Web Mercator limit (±85.05°), saving GeoTIFF in EPSG:4326")
+		}
+		// After Y-inversion, image top-left is the west edge of MinCol and north edge of MaxRow
+		originX = tilemath.GEToDegrees(float64(bounds.MinCol), zoom)
+		originY = tilemath.GEToDegrees(float64(bounds.MaxRow+1), zoom)
+		pixelWidth = (tilemath.GEToDegrees(float64(bounds.MaxCol+1), zoom) - originX) / float64(outputWidth)
+		pixelHeight = (tilemath.GEToDegrees(float64(bounds.MinRow), zoom) - originY) / float64(outputHeight)
+		return originX, originY, pixelWidth, pixelHeight, 4326
 	}
 
-	bounds := TileBounds{
-		MinCol: tiles[0].Column,
-		MaxCol: tiles[0].Column,
-		MinRow: tiles[0].Row,
-		MaxRow: tiles[0].Row,
+	// Calculate georeferencing in Web Mercator (EPSG:3857)
+	// After Y-inversion, image top-left corresponds to (bounds.MinCol, bounds.MaxRow+1) in GE coords
+	// Image bottom-right corresponds to (bounds.MaxCol+1, bounds.MinRow)
+	originX, originY = googleearth.TileToWebMercator(bounds.MaxRow+1, bounds.MinCol, zoom)
+	endX, endY := googleearth.TileToWebMercator(bounds.MinRow, bounds.MaxCol+1, zoom)
+	pixelWidth = (endX - originX) / float64(outputWidth)
+	pixelHeight = (endY - originY) / float64(outputHeight) // Will be negative (Y decreases going down)
+	return originX, originY, pixelWidth, pixelHeight, 3857
+}
+
+// saveSplitGeoTIFF saves a stitched image in the given CRS (3857 or 4326), splitting it
+// into parts + VRT when it exceeds the configured maximum dimension. providers are credited
+// in the Copyright tag of every part and in the .aux.xml sidecar.
+func (d *Downloader) saveSplitGeoTIFF(ctx context.Context, img *image.RGBA, tifPath string, originX, originY, pixelWidth, pixelHeight float64, epsg int, source, date string, providers []string) error {
+	d.mu.Lock()
+	maxDim := d.maxGeoTIFFDimension
+	buildOverviews := d.buildOverviews
+	d.mu.Unlock()
+
+	// Only masked exports carry an alpha band; every part must share the same layout for the VRT
+	alpha := geotiff.HasTransparency(img)
+	bands := 3
+	if alpha {
+		bands = 4
 	}
 
-	for _, tile := range tiles {
-		if tile.Column < bounds.MinCol {
-			bounds.MinCol = tile.Column
-		}
-		if tile.Column > bounds.MaxCol {
-			bounds.MaxCol = tile.Column
-		}
-		if tile.Row < bounds.MinRow {
-			bounds.MinRow = tile.Row
-		}
-		if tile.Row > bounds.MaxRow {
-			bounds.MaxRow = tile.Row
+	exportMeta := downloads.Metadata(ctx)
+	paths, err := geotiff.SaveSplit(img, tifPath, originX, originY, pixelWidth, pixelHeight, epsg, bands, maxDim,
+		func(part image.Image, partPath string, partOriginX, partOriginY float64) error {
+			opts := &geotiff.EncodeOptions{Alpha: alpha, EPSG: epsg, Copyright: strings.Join(providers, "; "), Artist: exportMeta.Operator}
+			if !exportMeta.IsZero() {
+				opts.Description = exportMeta.Describe(source)
+			}
+			if buildOverviews {
+				bounds := part.Bounds()
+				opts.Overviews = geotiff.DefaultOverviewLevels(bounds.Dx(), bounds.Dy())
+			}
+			return geotiff.SaveAsGeoTIFFWithOptions(
+				part,
+				partPath,
+				partOriginX,
+				partOriginY,
+				pixelWidth,
+				pixelHeight,
+				source,
+				date,
+				"", // appVersion - not available in downloader context
+				opts,
+			)
+		})
+	if err != nil {
+		return err
+	}
+
+	if len(paths) > 1 {
+		d.emitLog(ctx, fmt.Sprintf("Export exceeds %d px, split into %d parts: %s", maxDim, len(paths)-1, paths[0]))
+	} else {
+		d.emitLog(ctx, fmt.Sprintf("Saved: %s", tifPath))
+	}
+
+	// Failed tiles are left transparent; record their footprints so mosaicking tools can fill the gaps
+	missing := geotiff.MissingFootprints(img, downloads.TileSize, originX, originY, pixelWidth, pixelHeight)
+	if len(missing) > 0 || len(providers) > 0 || !exportMeta.IsZero() {
+		meta := geotiff.AuxMetadata{Source: source, Date: date, EPSG: epsg, Providers: providers, Missing: missing,
+			Project: exportMeta.Project, Operator: exportMeta.Operator, Notes: exportMeta.Notes}
+		if err := geotiff.WriteAuxMetadata(paths[0], meta); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if len(missing) > 0 {
+			d.emitLog(ctx, fmt.Sprintf("%d missing tiles left transparent, footprints recorded in %s.aux.xml", len(missing), filepath.Base(paths[0])))
 		}
 	}
+	return nil
+}
+
+// TileBounds represents the bounds of a tile grid
+type TileBounds = common.TileBounds
 
-	return bounds, nil
+// calculateTileBounds calculates the bounds of a tile set (antimeridian-aware)
+func calculateTileBounds(tiles []*googleearth.Tile) (TileBounds, error) {
+	commonTiles := make([]common.Tile, len(tiles))
+	for i, tile := range tiles {
+		commonTiles[i] = tile
+	}
+	return common.CalculateTileBounds(commonTiles)
 }
 
 // tileResult represents the result of downloading a tile
@@ -214,21 +341,6 @@ func max(a, b int) int {
 	return b
 }
 
-// checkSuccessRate validates that enough tiles were successfully downloaded
-func checkSuccessRate(successCount, total int) error {
-	if successCount == 0 {
-		return fmt.Errorf("failed to download any tiles - all attempts failed")
-	}
-
-	successRate := float64(successCount) / float64(total)
-	if successRate < MinSuccessRate {
-		return fmt.Errorf("only %d/%d tiles (%.1f%%) downloaded - below minimum threshold of %.1f%%",
-			successCount, total, successRate*100, MinSuccessRate*100)
-	}
-
-	return nil
-}
-
 // acquireWorker acquires a worker slot from the semaphore
 func (d *Downloader) acquireWorker(ctx context.Context) error {
 	return d.semaphore.Acquire(ctx, 1)
@@ -10,10 +10,10 @@ import (
 	"path/filepath"
 
 	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
 	"imagery-desktop/internal/downloads"
 	"imagery-desktop/internal/googleearth"
 	"imagery-desktop/internal/utils/naming"
-	"imagery-desktop/pkg/geotiff"
 )
 
 // DownloadHistoricalImagery downloads historical Google Earth imagery for a specific date
@@ -32,8 +32,9 @@ import (
 //   - epoch: Primary epoch to try (from protobuf)
 //   - dateStr: Human-readable date (YYYY-MM-DD) for cache and filenames
 //   - format: "tiles", "geotiff", or "both"
-func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom int, hexDate string, epoch int, dateStr string, format string) error {
-	d.emitLog(fmt.Sprintf("Starting Google Earth historical download for %s...", dateStr))
+func (d *Downloader) DownloadHistoricalImagery(ctx context.Context, bbox downloads.BoundingBox, zoom int, hexDate string, epoch int, dateStr string, format string) error {
+	d.emitLog(ctx, fmt.Sprintf("Starting Google Earth historical download for %s...", dateStr))
+	outputDir := downloads.OutputDir(ctx, d.GetDownloadPath())
 
 	// Validate request
 	if err := d.validateDownloadRequest(bbox, zoom, format); err != nil {
@@ -58,7 +59,7 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 	if total == 0 {
 		return fmt.Errorf("no tiles in bounding box")
 	}
-	d.emitLog(fmt.Sprintf("Downloading %d tiles...", total))
+	d.emitLog(ctx, fmt.Sprintf("Downloading %d tiles...", total))
 
 	// Calculate tile bounds for stitching
 	bounds, err := calculateTileBounds(tiles)
@@ -67,7 +68,7 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 	}
 	cols := bounds.Cols()
 	rows := bounds.Rows()
-	d.emitLog(fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
+	d.emitLog(ctx, fmt.Sprintf("Grid: %d cols x %d rows", cols, rows))
 
 	// Create output image only if we need GeoTIFF
 	var outputImg *image.RGBA
@@ -81,14 +82,13 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 	// Create tiles directory if saving individual tiles (OGC structure)
 	var tilesDir string
 	if format == "tiles" || format == "both" {
-		tilesDir = filepath.Join(d.downloadPath, naming.GenerateTilesDirName(common.ProviderGoogleEarth, dateStr, zoom))
+		tilesDir = filepath.Join(outputDir, naming.GenerateTilesDirName(common.ProviderGoogleEarth, dateStr, zoom))
 		if err := os.MkdirAll(tilesDir, 0755); err != nil {
 			return fmt.Errorf("failed to create tiles directory: %w", err)
 		}
 	}
 
 	// Download tiles concurrently with semaphore control and zoom fallback
-	ctx := context.Background()
 	successCount := 0
 	errors := make(chan error, total)
 
@@ -121,12 +121,17 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 					maxFallback = 6 // More aggressive fallback for lower zooms
 				}
 
-				data, actualZoom, err := d.tileServer.FetchHistoricalGETileWithZoomFallback(
-					job.tile,
-					dateStr,
-					hexDate,
-					maxFallback,
-				)
+				var data []byte
+				var actualZoom int
+				err := crash.Guard("GEHistorical worker", func() (err error) {
+					data, actualZoom, err = d.tileServer.FetchHistoricalGETileWithZoomFallback(
+						job.tile,
+						dateStr,
+						hexDate,
+						maxFallback,
+					)
+					return err
+				})
 				d.releaseWorker()
 
 				if err != nil {
@@ -167,7 +172,7 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 		} else {
 			status = fmt.Sprintf("Downloading tile %d/%d", processedCount, total)
 		}
-		d.emitProgress(downloads.DownloadProgress{
+		d.emitProgress(ctx, downloads.DownloadProgress{
 			Downloaded: processedCount,
 			Total:      total,
 			Percent:    (processedCount * 100) / total,
@@ -176,6 +181,7 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 
 		if !result.success {
 			errors <- result.err
+			downloads.RecordTileFailure(ctx, common.ProviderGoogleEarth, dateStr, zoom, result.tile.Column, result.tile.Row, "", result.err)
 			continue
 		}
 
@@ -190,18 +196,23 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 		if format == "geotiff" || format == "both" {
 			if err := d.stitchTile(outputImg, result.tile, result.data, bounds); err != nil {
 				log.Printf("[GEHistorical] Failed to decode tile %s: %v", result.tile.Path, err)
+				downloads.RecordTileFailure(ctx, common.ProviderGoogleEarth, dateStr, zoom, result.tile.Column, result.tile.Row, downloads.FailureDecode, err)
 				continue
 			}
 		}
+		downloads.RecordTile(ctx)
 		successCount++
 	}
 	close(errors)
 
-	d.emitLog(fmt.Sprintf("Processed %d/%d tiles", successCount, total))
+	d.emitLog(ctx, fmt.Sprintf("Processed %d/%d tiles", successCount, total))
 
-	// Check if we have enough tiles
-	if err := checkSuccessRate(successCount, total); err != nil {
-		d.emitLog(fmt.Sprintf("Warning: %v - GeoTIFF may have gaps", err))
+	// Check if we have enough tiles; tasks with their own minimum fail rather than export gaps
+	if err := downloads.CheckSuccessRate(ctx, successCount, total); err != nil {
+		if _, enforced := downloads.MinSuccessRate(ctx); enforced {
+			return err
+		}
+		d.emitLog(ctx, fmt.Sprintf("Warning: %v - GeoTIFF may have gaps", err))
 	}
 
 	// Track download completion
@@ -217,17 +228,17 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 
 	// Save GeoTIFF if requested
 	if format == "geotiff" || format == "both" {
-		if err := d.saveHistoricalGeoTIFF(outputImg, bbox, zoom, bounds, dateStr, outputWidth, outputHeight); err != nil {
+		if err := d.saveHistoricalGeoTIFF(ctx, outputDir, outputImg, bbox, zoom, bounds, hexDate, dateStr, outputWidth, outputHeight); err != nil {
 			return fmt.Errorf("failed to save GeoTIFF: %w", err)
 		}
 	}
 
 	if format == "tiles" || format == "both" {
-		d.emitLog(fmt.Sprintf("Tiles saved to: %s", tilesDir))
+		d.emitLog(ctx, fmt.Sprintf("Tiles saved to: %s", tilesDir))
 	}
 
 	// Emit completion
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Downloaded: total,
 		Total:      total,
 		Percent:    100,
@@ -238,52 +249,37 @@ func (d *Downloader) DownloadHistoricalImagery(bbox downloads.BoundingBox, zoom
 }
 
 // saveHistoricalGeoTIFF saves the stitched historical image as a GeoTIFF with metadata
-func (d *Downloader) saveHistoricalGeoTIFF(outputImg *image.RGBA, bbox downloads.BoundingBox, zoom int, bounds TileBounds, dateStr string, outputWidth, outputHeight int) error {
-	// Calculate georeferencing in Web Mercator (EPSG:3857)
-	// After Y-inversion, image top-left corresponds to (bounds.MinCol, bounds.MaxRow+1) in GE coords
-	// Image bottom-right corresponds to (bounds.MaxCol+1, bounds.MinRow)
-	originX, originY := googleearth.TileToWebMercator(bounds.MaxRow+1, bounds.MinCol, zoom)
-	endX, endY := googleearth.TileToWebMercator(bounds.MinRow, bounds.MaxCol+1, zoom)
-	pixelWidth := (endX - originX) / float64(outputWidth)
-	pixelHeight := (endY - originY) / float64(outputHeight) // Will be negative (Y decreases going down)
+func (d *Downloader) saveHistoricalGeoTIFF(ctx context.Context, outputDir string, outputImg *image.RGBA, bbox downloads.BoundingBox, zoom int, bounds TileBounds, hexDate, dateStr string, outputWidth, outputHeight int) error {
+	originX, originY, pixelWidth, pixelHeight, epsg := d.georeference(ctx, bbox, zoom, bounds, outputWidth, outputHeight)
 
 	// Generate GeoTIFF filename
-	tifPath := filepath.Join(d.downloadPath, naming.GenerateGeoTIFFFilename(common.ProviderGoogleEarth, dateStr, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
+	tifPath := filepath.Join(outputDir, naming.GenerateGeoTIFFFilename(common.ProviderGoogleEarth, dateStr, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
 
 	// Emit progress for GeoTIFF encoding phase
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Percent: 99,
 		Status:  "Encoding GeoTIFF file...",
 	})
-	d.emitLog("Encoding GeoTIFF file...")
-
-	// Save as GeoTIFF with embedded projection and metadata
-	if err := geotiff.SaveAsGeoTIFFWithMetadata(
-		outputImg,
-		tifPath,
-		originX,
-		originY,
-		pixelWidth,
-		pixelHeight,
-		"Google Earth Historical",
-		dateStr,
-		"", // appVersion - not available in downloader context
-	); err != nil {
+	d.emitLog(ctx, "Encoding GeoTIFF file...")
+
+	// Save as GeoTIFF with embedded projection, metadata and provider credits (split into parts if huge)
+	providers := d.dominantProviders(ctx, bbox, zoom, hexDate)
+	if err := d.saveSplitGeoTIFF(ctx, outputImg, tifPath, originX, originY, pixelWidth, pixelHeight, epsg, "Google Earth Historical", dateStr, providers); err != nil {
 		return fmt.Errorf("failed to save GeoTIFF: %w", err)
 	}
 
-	d.emitLog(fmt.Sprintf("Saved: %s", tifPath))
-
-	// Save PNG copy for video export compatibility
-	pngPath := tifPath[:len(tifPath)-4] + ".png"
-	if err := saveHistoricalPNGCopy(outputImg, pngPath); err != nil {
-		log.Printf("Warning: Failed to save PNG copy: %v", err)
+	// Optional PNG copy for tools that cannot read GeoTIFFs
+	if d.shouldSavePNGCopies() {
+		pngPath := tifPath[:len(tifPath)-4] + ".png"
+		if err := saveHistoricalPNGCopy(outputImg, pngPath); err != nil {
+			log.Printf("Warning: Failed to save PNG copy: %v", err)
+		}
 	}
 
 	return nil
 }
 
-// saveHistoricalPNGCopy saves a PNG copy of the historical image for video export
+// saveHistoricalPNGCopy saves a PNG copy of the historical image alongside its GeoTIFF
 func saveHistoricalPNGCopy(img *image.RGBA, path string) error {
 	f, err := os.Create(path)
 	if err != nil {
@@ -0,0 +1,90 @@
+package googleearth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/tilemath"
+)
+
+const (
+	// providerSampleGrid is the number of tiles per side sampled to find an export's providers
+	providerSampleGrid = 3
+
+	// maxCreditedProviders caps the providers credited for one export
+	maxCreditedProviders = 3
+)
+
+// dominantProviders samples tiles across bbox and returns the copyright strings of the
+// imagery providers covering them, most common first. hexDate selects historical imagery
+// ("" for current imagery). Lookups only affect attribution, so failures are logged and
+// skipped.
+func (d *Downloader) dominantProviders(ctx context.Context, bbox downloads.BoundingBox, zoom int, hexDate string) []string {
+	seen := make(map[string]bool)
+	var tiles []*googleearth.Tile
+	for r := 0; r < providerSampleGrid; r++ {
+		lat := bbox.North - (bbox.North-bbox.South)*(float64(r)+0.5)/providerSampleGrid
+		for c := 0; c < providerSampleGrid; c++ {
+			lon := tilemath.WrapLongitude(bbox.West + bbox.LonSpan()*(float64(c)+0.5)/providerSampleGrid)
+			tile, err := googleearth.GetTileForCoord(lat, lon, zoom)
+			if err != nil || seen[tile.Path] {
+				continue
+			}
+			seen[tile.Path] = true
+			tiles = append(tiles, tile)
+		}
+	}
+
+	historical := hexDate != ""
+	ids := make([]int, len(tiles))
+	errs := make([]error, len(tiles))
+	var wg sync.WaitGroup
+	for i, tile := range tiles {
+		wg.Add(1)
+		go func(i int, tile *googleearth.Tile) {
+			defer wg.Done()
+			if historical {
+				ids[i], errs[i] = d.geClient.HistoricalTileProvider(tile, hexDate)
+			} else {
+				ids[i], errs[i] = d.geClient.TileProvider(tile)
+			}
+		}(i, tile)
+	}
+	wg.Wait()
+
+	counts := make(map[string]int)
+	for i, id := range ids {
+		if errs[i] != nil {
+			log.Printf("[GoogleEarth] Provider lookup failed for tile %s: %v", tiles[i].Path, errs[i])
+			continue
+		}
+		if name := d.geClient.ProviderName(id, historical); name != "" {
+			counts[name]++
+		}
+	}
+
+	providers := make([]string, 0, len(counts))
+	for name := range counts {
+		providers = append(providers, name)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		if counts[providers[i]] != counts[providers[j]] {
+			return counts[providers[i]] > counts[providers[j]]
+		}
+		return providers[i] < providers[j]
+	})
+	if len(providers) > maxCreditedProviders {
+		providers = providers[:maxCreditedProviders]
+	}
+
+	if len(providers) > 0 {
+		d.emitLog(ctx, fmt.Sprintf("Imagery providers: %s", strings.Join(providers, ", ")))
+	}
+	return providers
+}
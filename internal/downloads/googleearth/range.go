@@ -1,6 +1,7 @@
 package googleearth
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -18,24 +19,26 @@ type GEDateInfo = downloads.GEDateInfo
 //   - Zoom fallback with quadrant extraction
 //   - Concurrent tile downloads with semaphore control
 //
+// Progress updates carry the date position (CurrentDate/TotalDates) of this range.
+//
 // Parameters:
+//   - ctx: Carries the download operation (output directory, progress routing)
 //   - bbox: Geographic bounding box
 //   - zoom: Zoom level (10-21 for Google Earth)
 //   - dates: List of dates to download (each with date, hexDate, and epoch)
 //   - format: "tiles", "geotiff", or "both"
-//   - rangeTracker: Optional progress tracker for range downloads (can be nil)
 func (d *Downloader) DownloadHistoricalImageryRange(
+	ctx context.Context,
 	bbox downloads.BoundingBox,
 	zoom int,
 	dates []GEDateInfo,
 	format string,
-	rangeTracker *downloads.RangeTracker,
 ) error {
 	if len(dates) == 0 {
 		return fmt.Errorf("no dates provided")
 	}
 
-	d.emitLog(fmt.Sprintf("Starting bulk download for %d Google Earth dates", len(dates)))
+	d.emitLog(ctx, fmt.Sprintf("Starting bulk download for %d Google Earth dates", len(dates)))
 
 	// Validate the request once before processing all dates
 	if err := d.validateDownloadRequest(bbox, zoom, format); err != nil {
@@ -47,20 +50,25 @@ func (d *Downloader) DownloadHistoricalImageryRange(
 	var failedDates []string
 	errors := make([]error, 0)
 
+	// Range position travels with this run's context for unified progress
+	rangeTracker := downloads.NewRangeTracker(len(dates))
+	ctx = downloads.WithRange(ctx, rangeTracker)
+
 	total := len(dates)
 	for i, dateInfo := range dates {
-		currentIndex := i + 1
-
-		// Update range tracker if provided
-		if rangeTracker != nil {
-			rangeTracker.SetCurrentDate(currentIndex)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		d.emitLog(fmt.Sprintf("Downloading date %d/%d: %s", currentIndex, total, dateInfo.Date))
+		currentIndex := i + 1
+		rangeTracker.SetCurrentDate(currentIndex)
+
+		d.emitLog(ctx, fmt.Sprintf("Downloading date %d/%d: %s", currentIndex, total, dateInfo.Date))
 
 		// Download the historical imagery for this date
 		// This will use the tile server's epoch fallback logic and zoom fallback
 		err := d.DownloadHistoricalImagery(
+			ctx,
 			bbox,
 			zoom,
 			dateInfo.HexDate,
@@ -70,18 +78,18 @@ func (d *Downloader) DownloadHistoricalImageryRange(
 		)
 
 		if err != nil {
-			d.emitLog(fmt.Sprintf("Failed to download %s: %v", dateInfo.Date, err))
+			d.emitLog(ctx, fmt.Sprintf("Failed to download %s: %v", dateInfo.Date, err))
 			failedDates = append(failedDates, dateInfo.Date)
 			errors = append(errors, fmt.Errorf("%s: %w", dateInfo.Date, err))
 			continue
 		}
 
 		successfulDates = append(successfulDates, dateInfo.Date)
-		d.emitLog(fmt.Sprintf("Successfully downloaded %s", dateInfo.Date))
+		d.emitLog(ctx, fmt.Sprintf("Successfully downloaded %s", dateInfo.Date))
 	}
 
 	// Emit final progress
-	d.emitProgress(downloads.DownloadProgress{
+	d.emitProgress(ctx, downloads.DownloadProgress{
 		Downloaded: total,
 		Total:      total,
 		Percent:    100,
@@ -89,9 +97,9 @@ func (d *Downloader) DownloadHistoricalImageryRange(
 	})
 
 	// Log summary
-	d.emitLog(fmt.Sprintf("Range download complete: %d successful, %d failed", len(successfulDates), len(failedDates)))
+	d.emitLog(ctx, fmt.Sprintf("Range download complete: %d successful, %d failed", len(successfulDates), len(failedDates)))
 	if len(failedDates) > 0 {
-		d.emitLog(fmt.Sprintf("Failed dates: %v", failedDates))
+		d.emitLog(ctx, fmt.Sprintf("Failed dates: %v", failedDates))
 	}
 
 	// Track the range download completion
@@ -117,34 +125,6 @@ func (d *Downloader) DownloadHistoricalImageryRange(
 	return nil
 }
 
-// DownloadHistoricalImageryRangeWithProgress downloads multiple dates with unified progress reporting
-// This variant provides more granular progress updates across the entire range
-func (d *Downloader) DownloadHistoricalImageryRangeWithProgress(
-	bbox downloads.BoundingBox,
-	zoom int,
-	dates []GEDateInfo,
-	format string,
-) error {
-	// Create a range tracker for unified progress
-	rangeTracker := downloads.NewRangeTracker(len(dates))
-
-	// Wrap the progress callback to include range information
-	originalCallback := d.progressCallback
-	if originalCallback != nil {
-		d.progressCallback = func(progress downloads.DownloadProgress) {
-			currentDate, totalDates := rangeTracker.GetProgress()
-			progress.CurrentDate = currentDate
-			progress.TotalDates = totalDates
-			originalCallback(progress)
-		}
-		defer func() {
-			d.progressCallback = originalCallback
-		}()
-	}
-
-	return d.DownloadHistoricalImageryRange(bbox, zoom, dates, format, rangeTracker)
-}
-
 // ValidateDateRange validates a list of dates for download
 func ValidateDateRange(dates []GEDateInfo) error {
 	if len(dates) == 0 {
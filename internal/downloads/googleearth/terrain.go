@@ -0,0 +1,324 @@
+package googleearth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/crash"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/pkg/geotiff"
+)
+
+const (
+	// MaxTerrainZoom is the finest quadtree level terrain packets are requested at
+	// GE terrain is much coarser than imagery, so deeper levels rarely exist
+	MaxTerrainZoom = 16
+
+	// MaxTerrainParentFallback is how many levels to walk up when a node has no terrain
+	MaxTerrainParentFallback = 4
+
+	// MaxDEMDimension caps the DEM raster width/height in pixels
+	MaxDEMDimension = 8192
+
+	// DEMNoData marks cells not covered by any terrain mesh
+	DEMNoData float32 = -9999
+)
+
+// DEM CRS options
+const (
+	DEMCRSGeographic = "EPSG:4326"
+	DEMCRSMercator   = "EPSG:3857"
+)
+
+// DownloadTerrain downloads Google Earth terrain meshes for a bounding box and
+// rasterizes them into a float32 DEM GeoTIFF. Returns the path of the written file.
+// crs: "EPSG:4326" (default) or "EPSG:3857"
+func (d *Downloader) DownloadTerrain(ctx context.Context, bbox downloads.BoundingBox, zoom int, crs string) (string, error) {
+	if err := downloads.ValidateCoordinates(bbox, zoom); err != nil {
+		return "", fmt.Errorf("invalid coordinates: %w", err)
+	}
+	if crs == "" {
+		crs = DEMCRSGeographic
+	}
+	if crs != DEMCRSGeographic && crs != DEMCRSMercator {
+		return "", fmt.Errorf("invalid CRS %q: must be %q or %q", crs, DEMCRSGeographic, DEMCRSMercator)
+	}
+
+	terrainZoom := zoom
+	if terrainZoom > MaxTerrainZoom {
+		terrainZoom = MaxTerrainZoom
+	}
+
+	d.emitLog(ctx, fmt.Sprintf("Starting Google Earth terrain download (terrain level %d, %s)...", terrainZoom, crs))
+
+	tiles, err := googleearth.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, terrainZoom)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tiles in bounds: %w", err)
+	}
+	if len(tiles) == 0 {
+		return "", fmt.Errorf("no tiles in bounding box")
+	}
+
+	meshes := d.fetchTerrainMeshes(ctx, tiles)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if len(meshes) == 0 {
+		return "", fmt.Errorf("no terrain available for this area")
+	}
+	d.emitLog(ctx, fmt.Sprintf("Decoded %d terrain meshes", len(meshes)))
+
+	d.emitProgress(ctx, downloads.DownloadProgress{
+		Percent: 95,
+		Status:  "Rasterizing DEM...",
+	})
+
+	dem := rasterizeDEM(meshes, bbox, crs)
+	if dem.validCells == 0 {
+		return "", fmt.Errorf("terrain meshes do not cover the requested area")
+	}
+
+	timestamp := time.Now().Format("2006-01-02")
+	tifPath := filepath.Join(downloads.OutputDir(ctx, d.GetDownloadPath()), naming.GenerateGeoTIFFFilename(common.ProviderGoogleEarthDEM, timestamp, bbox.South, bbox.West, bbox.North, bbox.East, zoom))
+
+	epsg := 4326
+	if crs == DEMCRSMercator {
+		epsg = 3857
+	}
+
+	if err := geotiff.SaveDEMAsGeoTIFF(dem.data, dem.width, dem.height, tifPath, dem.originX, dem.originY, dem.pixelWidth, dem.pixelHeight, epsg, DEMNoData); err != nil {
+		return "", fmt.Errorf("failed to save DEM: %w", err)
+	}
+
+	d.emitLog(ctx, fmt.Sprintf("Saved DEM (%dx%d, elevation %.1fm to %.1fm): %s",
+		dem.width, dem.height, dem.minElevation, dem.maxElevation, tifPath))
+
+	d.trackEvent("terrain_download_complete", map[string]interface{}{
+		"zoom":   zoom,
+		"crs":    crs,
+		"meshes": len(meshes),
+		"width":  dem.width,
+		"height": dem.height,
+	})
+
+	d.emitProgress(ctx, downloads.DownloadProgress{
+		Downloaded: len(tiles),
+		Total:      len(tiles),
+		Percent:    100,
+		Status:     "Complete",
+	})
+
+	return tifPath, nil
+}
+
+// fetchTerrainMeshes fetches terrain for all tiles, walking up to parent nodes when a
+// node carries no terrain. Each packet path is fetched at most once.
+func (d *Downloader) fetchTerrainMeshes(ctx context.Context, tiles []*googleearth.Tile) []*googleearth.TerrainMesh {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		meshes    []*googleearth.TerrainMesh
+		fetched   = make(map[string]bool)
+		processed int
+	)
+	total := len(tiles)
+
+	for _, tile := range tiles {
+		if err := d.acquireWorker(ctx); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func(tile *googleearth.Tile) {
+			defer wg.Done()
+			defer d.releaseWorker()
+
+			path := tile.Path
+			for level := 0; level <= MaxTerrainParentFallback && len(path) > 1; level++ {
+				mu.Lock()
+				done := fetched[path]
+				fetched[path] = true
+				mu.Unlock()
+				if done {
+					break
+				}
+
+				t, err := googleearth.NewTileFromPath(path)
+				if err != nil {
+					break
+				}
+
+				var result []*googleearth.TerrainMesh
+				err = crash.Guard("GETerrain worker", func() (err error) {
+					result, err = d.geClient.FetchTerrain(t)
+					return err
+				})
+				if err == nil {
+					mu.Lock()
+					meshes = append(meshes, result...)
+					mu.Unlock()
+					break
+				}
+				path = path[:len(path)-1]
+			}
+
+			mu.Lock()
+			processed++
+			current := processed
+			mu.Unlock()
+			d.emitProgress(ctx, downloads.DownloadProgress{
+				Downloaded: current,
+				Total:      total,
+				Percent:    (current * 90) / total,
+				Status:     fmt.Sprintf("Downloading terrain %d/%d", current, total),
+			})
+		}(tile)
+	}
+	wg.Wait()
+
+	return meshes
+}
+
+// demRaster holds a rasterized DEM and its georeferencing
+type demRaster struct {
+	data         []float32
+	width        int
+	height       int
+	originX      float64
+	originY      float64
+	pixelWidth   float64
+	pixelHeight  float64 // negative (Y decreases going down)
+	validCells   int
+	minElevation float64
+	maxElevation float64
+}
+
+// rasterizeDEM burns terrain mesh triangles into a regular grid covering bbox
+// Meshes are drawn coarse-to-fine so higher-level meshes overwrite lower ones
+func rasterizeDEM(meshes []*googleearth.TerrainMesh, bbox downloads.BoundingBox, crs string) *demRaster {
+	sort.SliceStable(meshes, func(i, j int) bool {
+		return meshes[i].Level < meshes[j].Level
+	})
+
+	// Native resolution: finest mesh grid step (degrees)
+	stepDeg := math.MaxFloat64
+	for _, m := range meshes {
+		if s := math.Abs(m.StepX); s > 0 && s < stepDeg {
+			stepDeg = s
+		}
+	}
+	if stepDeg == math.MaxFloat64 {
+		stepDeg = (bbox.East - bbox.West) / 256
+	}
+
+	// The grid has one cell per native mesh step across the bbox in degrees, in either CRS
+	width := int(math.Ceil((bbox.East - bbox.West) / stepDeg))
+	height := int(math.Ceil((bbox.North - bbox.South) / stepDeg))
+	if width > MaxDEMDimension || height > MaxDEMDimension {
+		scale := float64(max(width, height)) / MaxDEMDimension
+		width = int(math.Ceil(float64(width) / scale))
+		height = int(math.Ceil(float64(height) / scale))
+	}
+	width = max(width, 1)
+	height = max(height, 1)
+
+	// Project function maps lon/lat into the output CRS. The extents come from the
+	// projected bbox, so Mercator pixel heights follow the latitude stretch.
+	project := func(lat, lon float64) (float64, float64) { return lon, lat }
+	minX, minY, maxX, maxY := bbox.West, bbox.South, bbox.East, bbox.North
+	if crs == DEMCRSMercator {
+		project = func(lat, lon float64) (float64, float64) {
+			return googleearth.LatLonToWebMercator(lat, lon)
+		}
+		minX, minY = googleearth.LatLonToWebMercator(bbox.South, bbox.West)
+		maxX, maxY = googleearth.LatLonToWebMercator(bbox.North, bbox.East)
+	}
+
+	r := &demRaster{
+		data:         make([]float32, width*height),
+		width:        width,
+		height:       height,
+		originX:      minX,
+		originY:      maxY,
+		pixelWidth:   (maxX - minX) / float64(width),
+		pixelHeight:  -(maxY - minY) / float64(height),
+		minElevation: math.MaxFloat64,
+		maxElevation: -math.MaxFloat64,
+	}
+	for i := range r.data {
+		r.data[i] = DEMNoData
+	}
+
+	// toPixel converts output CRS coordinates to fractional pixel coordinates
+	toPixel := func(p googleearth.TerrainPoint) (float64, float64) {
+		x, y := project(p.Lat, p.Lon)
+		return (x - r.originX) / r.pixelWidth, (y - r.originY) / r.pixelHeight
+	}
+
+	for _, m := range meshes {
+		for _, f := range m.Faces {
+			p0, p1, p2 := m.Points[f[0]], m.Points[f[1]], m.Points[f[2]]
+			x0, y0 := toPixel(p0)
+			x1, y1 := toPixel(p1)
+			x2, y2 := toPixel(p2)
+			r.fillTriangle(x0, y0, p0.Elevation, x1, y1, p1.Elevation, x2, y2, p2.Elevation)
+		}
+	}
+
+	for _, v := range r.data {
+		if v == DEMNoData {
+			continue
+		}
+		r.validCells++
+		r.minElevation = math.Min(r.minElevation, float64(v))
+		r.maxElevation = math.Max(r.maxElevation, float64(v))
+	}
+
+	return r
+}
+
+// fillTriangle rasterizes a triangle, interpolating elevation at pixel centers
+func (r *demRaster) fillTriangle(x0, y0, z0, x1, y1, z1, x2, y2, z2 float64) {
+	area := (x1-x0)*(y2-y0) - (x2-x0)*(y1-y0)
+	if area == 0 {
+		return
+	}
+
+	minPX := max(int(math.Floor(math.Min(x0, math.Min(x1, x2)))), 0)
+	maxPX := min(int(math.Ceil(math.Max(x0, math.Max(x1, x2)))), r.width-1)
+	minPY := max(int(math.Floor(math.Min(y0, math.Min(y1, y2)))), 0)
+	maxPY := min(int(math.Ceil(math.Max(y0, math.Max(y1, y2)))), r.height-1)
+
+	for py := minPY; py <= maxPY; py++ {
+		cy := float64(py) + 0.5
+		for px := minPX; px <= maxPX; px++ {
+			cx := float64(px) + 0.5
+
+			// Barycentric weights
+			w0 := ((x1-cx)*(y2-cy) - (x2-cx)*(y1-cy)) / area
+			w1 := ((x2-cx)*(y0-cy) - (x0-cx)*(y2-cy)) / area
+			w2 := 1 - w0 - w1
+			const eps = -1e-9
+			if w0 < eps || w1 < eps || w2 < eps {
+				continue
+			}
+
+			r.data[py*r.width+px] = float32(w0*z0 + w1*z1 + w2*z2)
+		}
+	}
+}
+
+// min returns the minimum of two integers
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,214 @@
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultMinSuccessRate is the share of tiles below which a download is reported as
+// incomplete when the operation sets no rate of its own
+const DefaultMinSuccessRate = 0.3
+
+// Operation is the state of one download run: a manual download from the UI or a queued
+// task. It travels with the context, so overlapping runs never share their output
+// directory, date-range position or progress routing through downloader or App fields.
+type Operation struct {
+	// Identifies the run in the progress and log events sent to the UI ("" = unattributed)
+	ID string
+
+	// Directory files are written to ("" = the downloader's download path)
+	OutputDir string
+
+	// Queue task running the download ("" for manual downloads)
+	TaskID string
+
+	// Position within a multi-date download (nil for a single date)
+	Range *RangeTracker
+
+	// Receives every progress update of this run in addition to the downloader's callback
+	OnProgress func(DownloadProgress)
+
+	// Receives every log message of this run in addition to the downloader's callback
+	OnLog func(string)
+
+	// Share of tiles (0-1) a date needs to be exported; below it the date fails instead
+	// of writing a gappy export (0 = DefaultMinSuccessRate, only reported)
+	MinSuccessRate float64
+
+	// Strict requires every tile (all-or-nothing exports)
+	Strict bool
+
+	// Free-text description of the export, written into GeoTIFF tags, .aux.xml sidecars
+	// and video containers
+	Metadata ExportMetadata
+
+	// Collects the tiles the run could not export and why (nil = not recorded)
+	Report *ReportRecorder
+}
+
+// ExportMetadata is free-text metadata a user attaches to an export so the files document
+// themselves once they land on a shared drive
+type ExportMetadata struct {
+	Project  string `json:"project,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// IsZero reports whether no metadata is set
+func (m ExportMetadata) IsZero() bool {
+	return m.Project == "" && m.Operator == "" && m.Notes == ""
+}
+
+// Describe returns base followed by a "Key: value" line per metadata field that is set,
+// e.g. for the TIFF ImageDescription tag
+func (m ExportMetadata) Describe(base string) string {
+	lines := []string{base}
+	for _, field := range []struct{ key, value string }{
+		{"Project", m.Project},
+		{"Operator", m.Operator},
+		{"Notes", m.Notes},
+	} {
+		if field.value != "" {
+			lines = append(lines, field.key+": "+field.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type operationKey struct{}
+
+// WithOperation returns a context carrying op
+func WithOperation(ctx context.Context, op *Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFrom returns the operation carried by ctx, or nil
+func OperationFrom(ctx context.Context) *Operation {
+	if ctx == nil {
+		return nil
+	}
+	op, _ := ctx.Value(operationKey{}).(*Operation)
+	return op
+}
+
+// WithRange returns a context for a multi-date download within the operation in ctx.
+// The operation is copied so the caller's own operation keeps its range.
+func WithRange(ctx context.Context, tracker *RangeTracker) context.Context {
+	op := Operation{}
+	if parent := OperationFrom(ctx); parent != nil {
+		op = *parent
+	}
+	op.Range = tracker
+	return WithOperation(ctx, &op)
+}
+
+// WithOutputDir returns a context for work within the operation in ctx that writes to
+// dir on behalf of the queue task taskID. The operation is copied so the caller's own
+// operation keeps its directory.
+func WithOutputDir(ctx context.Context, dir, taskID string) context.Context {
+	op := Operation{}
+	if parent := OperationFrom(ctx); parent != nil {
+		op = *parent
+	}
+	op.OutputDir, op.TaskID = dir, taskID
+	return WithOperation(ctx, &op)
+}
+
+// WithMetadata returns a context for work within the operation in ctx that describes its
+// exports with meta. The operation is copied so the caller's own operation is unchanged.
+func WithMetadata(ctx context.Context, meta ExportMetadata) context.Context {
+	op := Operation{}
+	if parent := OperationFrom(ctx); parent != nil {
+		op = *parent
+	}
+	op.Metadata = meta
+	return WithOperation(ctx, &op)
+}
+
+// Metadata returns the export metadata of the operation in ctx (zero when there is none)
+func Metadata(ctx context.Context) ExportMetadata {
+	if op := OperationFrom(ctx); op != nil {
+		return op.Metadata
+	}
+	return ExportMetadata{}
+}
+
+// OutputDir returns the directory the operation in ctx writes to, or fallback
+func OutputDir(ctx context.Context, fallback string) string {
+	if op := OperationFrom(ctx); op != nil && op.OutputDir != "" {
+		return op.OutputDir
+	}
+	return fallback
+}
+
+// DateRange returns the 1-based date being downloaded and the number of dates when the
+// operation in ctx is a multi-date download (inRange false otherwise)
+func DateRange(ctx context.Context) (current, total int, inRange bool) {
+	op := OperationFrom(ctx)
+	if op == nil || op.Range == nil {
+		return 0, 0, false
+	}
+	current, total = op.Range.GetProgress()
+	return current, total, true
+}
+
+// ReportProgress fills in the range position and ID of the operation in ctx, sends
+// progress to callback (the downloader's) and to the operation's own progress hook
+func ReportProgress(ctx context.Context, callback func(DownloadProgress), progress DownloadProgress) {
+	if current, total, inRange := DateRange(ctx); inRange && progress.TotalDates == 0 {
+		progress.CurrentDate, progress.TotalDates = current, total
+	}
+	if op := OperationFrom(ctx); op != nil && progress.OperationID == "" {
+		progress.OperationID = op.ID
+	}
+	if callback != nil {
+		callback(progress)
+	}
+	if op := OperationFrom(ctx); op != nil && op.OnProgress != nil {
+		op.OnProgress(progress)
+	}
+}
+
+// ReportLog sends message to callback (the downloader's) and to the log hook of the
+// operation in ctx
+func ReportLog(ctx context.Context, callback func(string), message string) {
+	if callback != nil {
+		callback(message)
+	}
+	if op := OperationFrom(ctx); op != nil && op.OnLog != nil {
+		op.OnLog(message)
+	}
+}
+
+// MinSuccessRate returns the share of tiles the operation in ctx requires: 1 in strict
+// mode, its own rate when set, DefaultMinSuccessRate otherwise. enforced reports whether
+// a shortfall must fail the download rather than only be reported.
+func MinSuccessRate(ctx context.Context) (rate float64, enforced bool) {
+	op := OperationFrom(ctx)
+	switch {
+	case op == nil:
+		return DefaultMinSuccessRate, false
+	case op.Strict:
+		return 1, true
+	case op.MinSuccessRate > 0:
+		return min(op.MinSuccessRate, 1), true
+	}
+	return DefaultMinSuccessRate, false
+}
+
+// CheckSuccessRate returns an error when no tiles, or fewer than the share required by
+// the operation in ctx, were downloaded (see MinSuccessRate)
+func CheckSuccessRate(ctx context.Context, successCount, total int) error {
+	if successCount == 0 {
+		return fmt.Errorf("failed to download any tiles - all attempts failed")
+	}
+
+	rate, _ := MinSuccessRate(ctx)
+	successRate := float64(successCount) / float64(total)
+	if successRate < rate {
+		return fmt.Errorf("only %d/%d tiles (%.1f%%) downloaded - below minimum threshold of %.1f%%",
+			successCount, total, successRate*100, rate*100)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package downloads
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG decoder for tile data
+	_ "image/png"  // Register PNG decoder for tile data
+)
+
+// qualitySampleGrid is the number of pixels sampled along each axis when scoring a tile
+const qualitySampleGrid = 64
+
+// TileQualityScore rates how unusable a tile's imagery is, from 0 (clear) to 1 (blank).
+// It is the fraction of sampled pixels that look like cloud or haze (bright and
+// desaturated), no-data fill (near black), or a uniform placeholder color.
+// Used to rank candidate dates; it is a cheap heuristic, not a cloud mask.
+func TileQualityScore(data []byte) (float64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 1, fmt.Errorf("failed to decode tile: %w", err)
+	}
+
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return 1, nil
+	}
+
+	var bad, total int
+	var sumR, sumG, sumB, sumSq uint64
+	for sy := 0; sy < qualitySampleGrid; sy++ {
+		y := b.Min.Y + (sy*b.Dy()+b.Dy()/2)/qualitySampleGrid
+		for sx := 0; sx < qualitySampleGrid; sx++ {
+			x := b.Min.X + (sx*b.Dx()+b.Dx()/2)/qualitySampleGrid
+			r32, g32, b32, _ := img.At(x, y).RGBA()
+			r, g, bl := int(r32>>8), int(g32>>8), int(b32>>8)
+
+			lo, hi := r, r
+			for _, v := range []int{g, bl} {
+				if v < lo {
+					lo = v
+				}
+				if v > hi {
+					hi = v
+				}
+			}
+
+			switch {
+			case lo >= 200 && hi-lo <= 25: // Cloud, haze or white fill
+				bad++
+			case hi <= 20: // Black no-data fill
+				bad++
+			}
+
+			total++
+			sumR += uint64(r)
+			sumG += uint64(g)
+			sumB += uint64(bl)
+			sumSq += uint64(r*r + g*g + bl*bl)
+		}
+	}
+
+	// A near-uniform tile is a placeholder regardless of its color
+	n := float64(total)
+	mr, mg, mb := float64(sumR)/n, float64(sumG)/n, float64(sumB)/n
+	if variance := float64(sumSq)/n - (mr*mr + mg*mg + mb*mb); variance < 30 {
+		return 1, nil
+	}
+
+	return float64(bad) / float64(total), nil
+}
@@ -0,0 +1,226 @@
+package downloads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tile failure reasons
+const (
+	FailureNotFound    = "not_found"    // 404, or no imagery for the tile/date
+	FailureRateLimited = "rate_limited" // 429, 403 or 509 (see ratelimit.Handler)
+	FailureHTTP        = "http_error"   // Any other HTTP error status
+	FailureTimeout     = "timeout"
+	FailureNetwork     = "network" // Connection refused/reset, DNS, TLS...
+	FailureDecode      = "decode"  // Served data is not a valid image
+	FailureBlank       = "blank"   // Served a uniform tile holding no imagery
+	FailureCancelled   = "cancelled"
+	FailureOther       = "other"
+)
+
+// maxReportedFailures caps the failures listed in a report; the counts include them all
+const maxReportedFailures = 1000
+
+// statusPattern extracts the HTTP status from the clients' "request failed with status: N" errors
+var statusPattern = regexp.MustCompile(`status:? (\d{3})\b`)
+
+// TileFailure is one tile a download could not export. Google Earth tiles use its Plate
+// Carrée rows and columns, every other source XYZ tiles.
+type TileFailure struct {
+	Source string `json:"source"`
+	Date   string `json:"date,omitempty"`
+	Z      int    `json:"z"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Reason string `json:"reason"`
+	Status int    `json:"status,omitempty"` // HTTP status, when the provider answered
+	Error  string `json:"error,omitempty"`
+}
+
+// DownloadReport lists the tiles a download run could not export and why, so gaps can be
+// told apart as provider-side (missing imagery, throttling) or network-side
+type DownloadReport struct {
+	OperationID  string         `json:"operationId,omitempty"`
+	TaskID       string         `json:"taskId,omitempty"`
+	StartedAt    string         `json:"startedAt"`
+	FinishedAt   string         `json:"finishedAt,omitempty"`
+	Tiles        int            `json:"tiles"`        // Tiles processed
+	Failed       int            `json:"failed"`       // Tiles missing or blank in the export
+	ProviderSide int            `json:"providerSide"` // Failures the provider answered: not found, rate limited, HTTP errors, bad or blank data
+	NetworkSide  int            `json:"networkSide"`  // Failures that never got an answer: timeouts and connection errors
+	ByReason     map[string]int `json:"byReason"`
+	Failures     []TileFailure  `json:"failures"`
+	Truncated    bool           `json:"truncated,omitempty"` // More than maxReportedFailures failures; only the first are listed
+	Path         string         `json:"path,omitempty"`      // Where the report was saved
+}
+
+// ReportRecorder collects the tile outcomes of a download run into a DownloadReport.
+// It is shared by every download of the run and safe for concurrent use.
+type ReportRecorder struct {
+	mu     sync.Mutex
+	report DownloadReport
+}
+
+// NewReportRecorder returns a recorder for a run identified by operationID and taskID
+func NewReportRecorder(operationID, taskID string) *ReportRecorder {
+	return &ReportRecorder{report: DownloadReport{
+		OperationID: operationID,
+		TaskID:      taskID,
+		StartedAt:   time.Now().Format(time.RFC3339),
+		ByReason:    make(map[string]int),
+		Failures:    []TileFailure{},
+	}}
+}
+
+// Tiles returns the number of tiles recorded so far
+func (r *ReportRecorder) Tiles() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report.Tiles
+}
+
+// Path returns where the report was saved ("" until Save)
+func (r *ReportRecorder) Path() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report.Path
+}
+
+// Snapshot returns a copy of the report as recorded so far
+func (r *ReportRecorder) Snapshot() *DownloadReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := r.report
+	report.ByReason = make(map[string]int, len(r.report.ByReason))
+	for reason, n := range r.report.ByReason {
+		report.ByReason[reason] = n
+	}
+	report.Failures = append([]TileFailure{}, r.report.Failures...)
+	return &report
+}
+
+// Save marks the report finished and writes it into dir as download_report_<start>.json.
+// Later calls return the path of the first save without rewriting it, so a report listed
+// in an export manifest keeps its checksum.
+func (r *ReportRecorder) Save(dir string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report.Path != "" {
+		return r.report.Path, nil
+	}
+
+	r.report.FinishedAt = time.Now().Format(time.RFC3339)
+	started, err := time.Parse(time.RFC3339, r.report.StartedAt)
+	if err != nil {
+		started = time.Now()
+	}
+	path := filepath.Join(dir, fmt.Sprintf("download_report_%s.json", started.Format("20060102_150405")))
+	data, err := json.MarshalIndent(r.report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal download report: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write download report: %w", err)
+	}
+	r.report.Path = path
+	return path, nil
+}
+
+func (r *ReportRecorder) record(failure *TileFailure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Tiles++
+	if failure == nil {
+		return
+	}
+	r.report.Failed++
+	r.report.ByReason[failure.Reason]++
+	switch failure.Reason {
+	case FailureTimeout, FailureNetwork:
+		r.report.NetworkSide++
+	case FailureNotFound, FailureRateLimited, FailureHTTP, FailureDecode, FailureBlank:
+		r.report.ProviderSide++
+	}
+	if len(r.report.Failures) < maxReportedFailures {
+		r.report.Failures = append(r.report.Failures, *failure)
+	} else {
+		r.report.Truncated = true
+	}
+}
+
+// RecordTile counts an exported tile in the report of the operation in ctx, if any
+func RecordTile(ctx context.Context) {
+	if op := OperationFrom(ctx); op != nil && op.Report != nil {
+		op.Report.record(nil)
+	}
+}
+
+// RecordTileFailure adds a tile that could not be exported to the report of the operation
+// in ctx, if any. An empty reason is classified from err (see ClassifyTileError).
+func RecordTileFailure(ctx context.Context, source, date string, z, x, y int, reason string, err error) {
+	op := OperationFrom(ctx)
+	if op == nil || op.Report == nil {
+		return
+	}
+	failure := &TileFailure{Source: source, Date: date, Z: z, X: x, Y: y, Reason: reason}
+	if err != nil {
+		failure.Error = err.Error()
+		classified, status := ClassifyTileError(err)
+		if failure.Reason == "" {
+			failure.Reason = classified
+		}
+		failure.Status = status
+	}
+	if failure.Reason == "" {
+		failure.Reason = FailureOther
+	}
+	op.Report.record(failure)
+}
+
+// ClassifyTileError returns the failure reason of a tile fetch error and the HTTP status
+// it carries (0 if none)
+func ClassifyTileError(err error) (reason string, status int) {
+	if err == nil {
+		return FailureOther, 0
+	}
+	msg := err.Error()
+	if m := statusPattern.FindStringSubmatch(msg); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+	lower := strings.ToLower(msg)
+
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return FailureCancelled, status
+	case errors.Is(err, context.DeadlineExceeded), isNetErr && netErr.Timeout(), strings.Contains(lower, "timeout"):
+		return FailureTimeout, status
+	case status == 404:
+		return FailureNotFound, status
+	case status == 429, status == 403, status == 509:
+		return FailureRateLimited, status
+	case status != 0:
+		return FailureHTTP, status
+	case isNetErr, strings.Contains(lower, "connection"), strings.Contains(lower, "no such host"), strings.Contains(lower, "eof"), strings.Contains(lower, "tls"):
+		return FailureNetwork, status
+	case strings.Contains(lower, "not found"), strings.Contains(lower, "not available"), strings.Contains(lower, "no imagery"):
+		return FailureNotFound, status
+	case strings.Contains(lower, "decode"):
+		return FailureDecode, status
+	}
+	return FailureOther, status
+}
@@ -0,0 +1,68 @@
+package downloads
+
+import (
+	"fmt"
+	"math"
+
+	"imagery-desktop/internal/googleearth"
+)
+
+// ZoomForResolution returns the lowest zoom level whose ground resolution at lat is at
+// least as fine as metersPerPixel, clamped to the provider's maximum zoom.
+// Picking the lowest sufficient zoom keeps tile counts down while meeting the target,
+// and evaluating at the AOI latitude keeps exports consistent across latitudes.
+func ZoomForResolution(metersPerPixel, lat float64, provider string) (int, error) {
+	if metersPerPixel <= 0 || math.IsNaN(metersPerPixel) || math.IsInf(metersPerPixel, 0) {
+		return 0, fmt.Errorf("invalid target resolution: %v m/px", metersPerPixel)
+	}
+
+	maxZoom, err := MaxZoomForProvider(provider)
+	if err != nil {
+		return 0, err
+	}
+	return ZoomForResolutionInRange(metersPerPixel, lat, MinZoom, maxZoom)
+}
+
+// ZoomForResolutionInRange is ZoomForResolution for an explicit zoom range, for providers
+// that report their own ZoomRange
+func ZoomForResolutionInRange(metersPerPixel, lat float64, minZoom, maxZoom int) (int, error) {
+	if metersPerPixel <= 0 || math.IsNaN(metersPerPixel) || math.IsInf(metersPerPixel, 0) {
+		return 0, fmt.Errorf("invalid target resolution: %v m/px", metersPerPixel)
+	}
+
+	for zoom := minZoom; zoom <= maxZoom; zoom++ {
+		if googleearth.ResolutionAtZoom(zoom, lat) <= metersPerPixel {
+			return zoom, nil
+		}
+	}
+	return maxZoom, nil
+}
+
+// ResolutionForOutputSize returns the ground resolution (m/px) needed for bbox to fill
+// an output of width x height pixels. A zero dimension is ignored.
+// The finer of the two axes is returned so neither dimension is undersampled.
+func ResolutionForOutputSize(bbox BoundingBox, width, height int) (float64, error) {
+	if width <= 0 && height <= 0 {
+		return 0, fmt.Errorf("output width or height must be positive")
+	}
+	if err := bbox.Validate(); err != nil {
+		return 0, err
+	}
+
+	centerLat := (bbox.South + bbox.North) / 2
+	metersPerDegreeLat := googleearth.Equator / 360.0
+	groundWidth := bbox.LonSpan() * metersPerDegreeLat * math.Cos(centerLat*math.Pi/180)
+	groundHeight := (bbox.North - bbox.South) * metersPerDegreeLat
+
+	resolution := math.MaxFloat64
+	if width > 0 {
+		resolution = math.Min(resolution, groundWidth/float64(width))
+	}
+	if height > 0 {
+		resolution = math.Min(resolution, groundHeight/float64(height))
+	}
+	if resolution <= 0 {
+		return 0, fmt.Errorf("bounding box has no extent")
+	}
+	return resolution, nil
+}
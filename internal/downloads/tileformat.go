@@ -0,0 +1,92 @@
+package downloads
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Tile output formats for individually saved tiles
+const (
+	TileFormatOriginal = "original" // Source bytes as fetched, no recompression (default)
+	TileFormatJPEG     = "jpeg"     // Re-encoded as JPEG at TileOutput.Quality
+	TileFormatPNG      = "png"      // Re-encoded losslessly as PNG
+
+	DefaultJPEGQuality = 90
+)
+
+// TileOutput selects the pixel format of saved tiles and of tiles the app has to
+// re-encode itself (e.g. zoom fallback tiles)
+type TileOutput struct {
+	Format  string // TileFormatOriginal, TileFormatJPEG or TileFormatPNG ("" = original)
+	Quality int    // JPEG quality 1-100 (0 = DefaultJPEGQuality)
+}
+
+// Validate checks the format and JPEG quality
+func (o TileOutput) Validate() error {
+	switch o.Format {
+	case "", TileFormatOriginal, TileFormatJPEG, TileFormatPNG:
+	default:
+		return fmt.Errorf("tile format must be '%s', '%s' or '%s'", TileFormatOriginal, TileFormatJPEG, TileFormatPNG)
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("JPEG quality must be between 0 and 100")
+	}
+	return nil
+}
+
+// jpegQuality returns the JPEG quality to encode with
+func (o TileOutput) jpegQuality() int {
+	if o.Quality <= 0 {
+		return DefaultJPEGQuality
+	}
+	return o.Quality
+}
+
+// Encode converts fetched tile bytes to the output format and returns them with the
+// file extension to save them under. Original bytes are returned unchanged.
+func (o TileOutput) Encode(data []byte) ([]byte, string, error) {
+	switch o.Format {
+	case TileFormatJPEG, TileFormatPNG:
+	default:
+		return data, TileExtension(data), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode tile: %w", err)
+	}
+	return o.EncodeImage(img)
+}
+
+// EncodeImage encodes a tile image in the output format. The original format has no
+// source bytes to keep, so it is encoded as JPEG.
+func (o TileOutput) EncodeImage(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if o.Format == TileFormatPNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode tile: %w", err)
+		}
+		return buf.Bytes(), "png", nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: o.jpegQuality()}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode tile: %w", err)
+	}
+	return buf.Bytes(), "jpg", nil
+}
+
+// TileExtension returns the file extension matching the image format of tile bytes
+func TileExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "png"
+	case "image/webp":
+		return "webp"
+	default:
+		return "jpg"
+	}
+}
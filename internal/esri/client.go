@@ -12,6 +12,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/netproxy"
 )
 
 const (
@@ -48,24 +52,44 @@ type Client struct {
 	layerList   []*Layer // Ordered by date (newest first)
 	mu          sync.RWMutex
 	initialized bool
+
+	headerMu sync.RWMutex
+	headers  map[string]string // User header overrides applied after the defaults
 }
 
-// NewClient creates a new Esri Wayback client with system proxy support
+// NewClient creates a new Esri Wayback client with proxy support
 func NewClient() *Client {
-	// Use http.ProxyFromEnvironment to respect system proxy settings
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
 	return &Client{
 		httpClient: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: transport,
+			Transport: metrics.NewTransport(common.ProviderEsriWayback, netproxy.NewTransport()), // App proxy and TLS settings, counted per provider
 		},
 		layers: make(map[int]*Layer),
 	}
 }
 
+// SetHeaderOverrides replaces the headers sent with every request. Values override the
+// defaults (e.g. User-Agent); an empty value removes the header.
+func (c *Client) SetHeaderOverrides(headers map[string]string) {
+	c.headerMu.Lock()
+	c.headers = headers
+	c.headerMu.Unlock()
+}
+
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", UserAgent)
+
+	c.headerMu.RLock()
+	defer c.headerMu.RUnlock()
+	for name, value := range c.headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
 // Initialize fetches the WMTS capabilities and parses available layers
 func (c *Client) Initialize() error {
 	c.mu.Lock()
@@ -79,7 +103,7 @@ func (c *Client) Initialize() error {
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("User-Agent", UserAgent)
+	c.setHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -158,7 +182,7 @@ func (c *Client) FetchTile(layer *Layer, tile *EsriTile) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("User-Agent", UserAgent)
+	c.setHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -449,7 +473,7 @@ func (c *Client) checkTileMap(tileMapURL string) (available bool, nextID int, er
 	if err != nil {
 		return false, 0, err
 	}
-	req.Header.Set("User-Agent", UserAgent)
+	c.setHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -478,24 +502,65 @@ func (c *Client) checkTileMap(tileMapURL string) (available bool, nextID int, er
 	return available, nextID, nil
 }
 
+// TileChanged reports whether a tile's imagery changed in a release. The tilemap's
+// "select" names the release a tile's imagery actually comes from, so the tile changed
+// when it is absent or points at the release itself. available is false when the
+// release has no imagery for the tile.
+func (c *Client) TileChanged(layer *Layer, tile *EsriTile) (available, changed bool, err error) {
+	release, available, err := c.TileSourceRelease(layer, tile)
+	if err != nil || !available {
+		return false, false, err
+	}
+	return true, release == layer.ID, nil
+}
+
+// TileSourceRelease returns the release a tile's imagery in layer actually comes from:
+// the tilemap's "select", or the layer's own release when it is absent. Two releases
+// serve the same imagery for a tile exactly when their source releases match.
+// available is false when the release has no imagery for the tile.
+func (c *Client) TileSourceRelease(layer *Layer, tile *EsriTile) (release int, available bool, err error) {
+	available, selectReleaseNum, err := c.checkTileMap(layer.GetTileMapURL(tile))
+	if err != nil || !available {
+		return 0, false, err
+	}
+	if selectReleaseNum == 0 {
+		return layer.ID, true, nil
+	}
+	return selectReleaseNum, true, nil
+}
+
 // getTileDate fetches the actual capture date for a tile
 func (c *Client) getTileDate(layer *Layer, tile *EsriTile) (time.Time, error) {
-	metadataURL := layer.GetPointQueryURL(tile)
+	date, err := c.fetchSourceDate(layer.GetPointQueryURL(tile))
+	if err != nil || date.IsZero() {
+		return layer.Date, err
+	}
+	return date, nil
+}
 
+// GetCaptureDate returns the acquisition date (SRC_DATE2) of a release's imagery at a point,
+// from the metadata scale matching level. The date is zero when the metadata has none.
+func (c *Client) GetCaptureDate(layer *Layer, point WebMercator, level int) (time.Time, error) {
+	return c.fetchSourceDate(layer.pointQueryURL(point, level))
+}
+
+// fetchSourceDate runs a metadata point query and returns the SRC_DATE2 of the first
+// feature (zero when there is none)
+func (c *Client) fetchSourceDate(metadataURL string) (time.Time, error) {
 	req, err := http.NewRequest("GET", metadataURL, nil)
 	if err != nil {
-		return layer.Date, err
+		return time.Time{}, err
 	}
-	req.Header.Set("User-Agent", UserAgent)
+	c.setHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return layer.Date, err
+		return time.Time{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return layer.Date, nil
+		return time.Time{}, nil
 	}
 
 	var result struct {
@@ -507,14 +572,14 @@ func (c *Client) getTileDate(layer *Layer, tile *EsriTile) (time.Time, error) {
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return layer.Date, err
+		return time.Time{}, err
 	}
 
 	if len(result.Features) > 0 && result.Features[0].Attributes.SrcDate2 > 0 {
 		return time.UnixMilli(result.Features[0].Attributes.SrcDate2), nil
 	}
 
-	return layer.Date, nil
+	return time.Time{}, nil
 }
 
 // GetAssetURL returns the tile image URL
@@ -540,6 +605,11 @@ func (l *Layer) GetTileMapURL(tile *EsriTile) string {
 
 // GetPointQueryURL returns the metadata query URL for a tile center
 func (l *Layer) GetPointQueryURL(tile *EsriTile) string {
+	return l.pointQueryURL(tile.Center(), tile.Level)
+}
+
+// pointQueryURL returns the metadata query URL for a point, at the metadata scale for level
+func (l *Layer) pointQueryURL(point WebMercator, level int) string {
 	const keyText = "/World_Imagery"
 	idx := strings.Index(l.ResourceURL, keyText)
 	if idx == -1 {
@@ -554,15 +624,13 @@ func (l *Layer) GetPointQueryURL(tile *EsriTile) string {
 	base := newDomain[:metaIdx+len(keyText)]
 
 	// Determine scale level for metadata service
-	scale := min(13, 23-tile.Level)
+	scale := max(0, min(13, 23-level))
 
 	// Get identifier suffix (remove "WB" prefix)
 	suffix := strings.ToLower(strings.Replace(l.Identifier, "WB", "", 1))
 
-	center := tile.Center()
-
 	queryURL := fmt.Sprintf("%s_Metadata%s/MapServer/%d/query?f=json&where=1%%3D1&outFields=SRC_DATE2&returnGeometry=false&geometryType=esriGeometryPoint&spatialRel=esriSpatialRelIntersects&geometry=%%7B%%22spatialReference%%22%%3A%%7B%%22wkid%%22%%3A%d%%7D%%2C%%22x%%22%%3A%f%%2C%%22y%%22%%3A%f%%7D",
-		base, suffix, scale, EpsgNumber, center.X, center.Y)
+		base, suffix, scale, EpsgNumber, point.X, point.Y)
 
 	return queryURL
 }
@@ -0,0 +1,27 @@
+package esri
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PingCapabilities requests the Wayback capabilities document through the client's
+// transport (app proxy, TLS and header settings) and returns the HTTP status, without
+// parsing or caching the response
+func (c *Client) PingCapabilities(ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", WayBackCapabilitiesURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
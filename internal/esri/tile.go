@@ -2,7 +2,8 @@ package esri
 
 import (
 	"fmt"
-	"math"
+
+	"imagery-desktop/internal/tilemath"
 )
 
 // EsriTile represents a tile in Web Mercator projection (EPSG:3857)
@@ -22,10 +23,15 @@ func (t *EsriTile) GetColumn() int {
 	return t.Column
 }
 
+// GetLevel implements common.Tile interface
+func (t *EsriTile) GetLevel() int {
+	return t.Level
+}
+
 const (
 	MaxLevel = 23
 	// Web Mercator constants
-	Equator    = 40075016.685578 // Earth's equator in meters
+	Equator    = tilemath.Equator // Earth's equator in meters
 	EpsgNumber = 3857
 )
 
@@ -66,9 +72,7 @@ func (t *EsriTile) ToXYZ() (x, y, z int) {
 
 // toCoordinate converts tile position to Web Mercator coordinate
 func (t *EsriTile) toCoordinate(column, row float64) WebMercator {
-	n := float64(int(1) << t.Level)
-	x := (column/n - 0.5) * Equator
-	y := (0.5 - row/n) * Equator
+	x, y := tilemath.XYZToMeters(column, row, t.Level)
 	return WebMercator{X: x, Y: y}
 }
 
@@ -99,60 +103,38 @@ func (t *EsriTile) Wgs84Bounds() (south, west, north, east float64) {
 
 // ToWgs84 converts Web Mercator to WGS84
 func (m WebMercator) ToWgs84() Wgs84 {
-	lon := m.X / Equator * 360.0
-	lat := math.Atan(math.Sinh(m.Y/Equator*2*math.Pi)) * 180.0 / math.Pi
+	lat, lon := tilemath.MetersToLatLon(m.X, m.Y)
 	return Wgs84{Lat: lat, Lon: lon}
 }
 
-// ToWebMercator converts WGS84 to Web Mercator
+// ToWebMercator converts WGS84 to Web Mercator (latitude clamped to the Web Mercator range)
 func (w Wgs84) ToWebMercator() WebMercator {
-	x := w.Lon / 360.0 * Equator
-	latRad := w.Lat * math.Pi / 180.0
-	y := math.Log(math.Tan(math.Pi/4+latRad/2)) / (2 * math.Pi) * Equator
+	x, y := tilemath.LatLonToMeters(w.Lat, w.Lon)
 	return WebMercator{X: x, Y: y}
 }
 
 // GetTileForCoord returns the tile containing a Web Mercator coordinate at given level
 func GetTileForCoord(coord WebMercator, level int) (*EsriTile, error) {
-	size := 1 << level
-	column := int((0.5 + coord.X/Equator) * float64(size))
-	row := int((0.5 - coord.Y/Equator) * float64(size))
-
-	// Clamp to valid range
-	column = clamp(column, 0, size-1)
-	row = clamp(row, 0, size-1)
-
-	return NewEsriTile(row, column, level)
+	lat, lon := tilemath.MetersToLatLon(coord.X, coord.Y)
+	return GetTileForWgs84(lat, lon, level)
 }
 
 // GetTileForWgs84 returns the tile containing a WGS84 coordinate at given level
 func GetTileForWgs84(lat, lon float64, level int) (*EsriTile, error) {
-	coord := Wgs84{Lat: lat, Lon: lon}.ToWebMercator()
-	return GetTileForCoord(coord, level)
+	column, row := tilemath.LatLonToXYZ(lat, lon, level)
+	return NewEsriTile(row, column, level)
 }
 
-// GetTilesInBounds returns all tiles within a WGS84 bounding box
+// GetTilesInBounds returns all tiles within a WGS84 bounding box, west to east
+// (west > east selects a box crossing the antimeridian)
 func GetTilesInBounds(south, west, north, east float64, level int) ([]*EsriTile, error) {
-	sw := Wgs84{Lat: south, Lon: west}.ToWebMercator()
-	ne := Wgs84{Lat: north, Lon: east}.ToWebMercator()
-
-	size := 1 << level
-
-	minCol := int((0.5 + sw.X/Equator) * float64(size))
-	maxCol := int((0.5 + ne.X/Equator) * float64(size))
-	maxRow := int((0.5 - sw.Y/Equator) * float64(size)) // south = larger row
-	minRow := int((0.5 - ne.Y/Equator) * float64(size)) // north = smaller row
-
-	// Clamp to valid range
-	minCol = clamp(minCol, 0, size-1)
-	maxCol = clamp(maxCol, 0, size-1)
-	minRow = clamp(minRow, 0, size-1)
-	maxRow = clamp(maxRow, 0, size-1)
+	minCol, minRow, maxCol, maxRow := tilemath.XYZRange(south, west, north, east, level)
 
 	var tiles []*EsriTile
 	for row := minRow; row <= maxRow; row++ {
 		for col := minCol; col <= maxCol; col++ {
-			tile, err := NewEsriTile(row, col, level)
+			// Columns past the east edge belong to an antimeridian-crossing box
+			tile, err := NewEsriTile(row, tilemath.WrapColumn(col, level), level)
 			if err != nil {
 				return nil, err
 			}
@@ -163,27 +145,43 @@ func GetTilesInBounds(south, west, north, east float64, level int) ([]*EsriTile,
 	return tiles, nil
 }
 
+// GetSampleTilesInBounds returns the distinct tiles under an evenly spaced grid x grid
+// set of points across a WGS84 bounding box (cell centers, so an odd grid includes the
+// bbox center). Small AOIs return fewer tiles when several points share a tile.
+func GetSampleTilesInBounds(south, west, north, east float64, level, grid int) ([]*EsriTile, error) {
+	if grid < 1 {
+		grid = 1
+	}
+
+	seen := make(map[[2]int]bool)
+	var tiles []*EsriTile
+	for r := 0; r < grid; r++ {
+		lat := north - (north-south)*(float64(r)+0.5)/float64(grid)
+		for c := 0; c < grid; c++ {
+			lon := tilemath.WrapLongitude(west + tilemath.LonSpan(west, east)*(float64(c)+0.5)/float64(grid))
+			tile, err := GetTileForWgs84(lat, lon, level)
+			if err != nil {
+				return nil, err
+			}
+			key := [2]int{tile.Row, tile.Column}
+			if !seen[key] {
+				seen[key] = true
+				tiles = append(tiles, tile)
+			}
+		}
+	}
+
+	return tiles, nil
+}
+
 // ResolutionAtZoom returns approximate meters per pixel at given zoom level
 func ResolutionAtZoom(zoom int) float64 {
 	// At zoom 0, the entire world (Equator meters) fits in 256 pixels
 	return Equator / float64(int(256)<<zoom)
 }
 
-func clamp(val, min, max int) int {
-	if val < min {
-		return min
-	}
-	if val > max {
-		return max
-	}
-	return val
-}
-
 // TileToWebMercator converts tile column/row at a zoom level to Web Mercator coordinates
 // Returns the top-left corner of the tile
 func TileToWebMercator(col, row, zoom int) (x, y float64) {
-	n := float64(int(1) << zoom)
-	x = (float64(col)/n - 0.5) * Equator
-	y = (0.5 - float64(row)/n) * Equator
-	return x, y
+	return tilemath.XYZToMeters(float64(col), float64(row), zoom)
 }
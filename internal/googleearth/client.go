@@ -9,6 +9,10 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/netproxy"
 )
 
 const (
@@ -40,19 +44,23 @@ type Client struct {
 	tmEncryptionKey  []byte
 	tmDbVersion      int
 	tmInitialized    bool
+
+	// Imagery provider ID -> copyright string, from each database's dbRoot
+	providers   map[int]string
+	tmProviders map[int]string
+
+	packetCache *PacketCache // Shared quadtree packet cache (optional)
+
+	headerMu sync.RWMutex
+	headers  map[string]string // User header overrides applied after the defaults
 }
 
-// NewClient creates a new Google Earth client with system proxy support
+// NewClient creates a new Google Earth client with proxy support
 func NewClient() *Client {
-	// Use http.ProxyFromEnvironment to respect system proxy settings
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
 	return &Client{
 		httpClient: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: transport,
+			Transport: metrics.NewTransport(common.ProviderGoogleEarth, netproxy.NewTransport()), // App proxy and TLS settings, counted per provider
 		},
 	}
 }
@@ -177,6 +185,7 @@ func (c *Client) parseTimeMachineDbRoot(data []byte) error {
 
 				// Extract quadtree version from decompressed protobuf
 				c.tmDbVersion = c.extractQuadtreeVersion(decompressed)
+				c.tmProviders = parseProviderTable(decompressed)
 			}
 			offset += int(length)
 		} else {
@@ -262,6 +271,7 @@ func (c *Client) parseDbRoot(data []byte) error {
 
 				// Extract quadtree version from decompressed protobuf
 				c.dbVersion = c.extractQuadtreeVersion(decompressed)
+				c.providers = parseProviderTable(decompressed)
 			}
 			offset += int(length)
 		} else {
@@ -562,6 +572,11 @@ func (c *Client) GetQuadtreePacket(tile *Tile) (*QuadtreePacket, error) {
 	return packet, nil
 }
 
+// SetPacketCache sets the cache used for quadtree and TimeMachine packets
+func (c *Client) SetPacketCache(cache *PacketCache) {
+	c.packetCache = cache
+}
+
 // FetchQuadtreePacket downloads and parses a quadtree packet for date availability
 func (c *Client) FetchQuadtreePacket(tile *Tile, epoch int) (*QuadtreePacket, error) {
 	if !c.initialized {
@@ -570,6 +585,25 @@ func (c *Client) FetchQuadtreePacket(tile *Tile, epoch int) (*QuadtreePacket, er
 		}
 	}
 
+	if c.packetCache == nil {
+		data, err := c.fetchQuadtreePacketData(tile, epoch)
+		if err != nil {
+			return nil, err
+		}
+		return parseQuadtreePacketData(data)
+	}
+
+	packet, err := c.packetCache.get(packetKindQuadtree, tile.Path, epoch,
+		func() ([]byte, error) { return c.fetchQuadtreePacketData(tile, epoch) },
+		func(data []byte) (any, error) { return parseQuadtreePacketData(data) })
+	if err != nil {
+		return nil, err
+	}
+	return packet.(*QuadtreePacket), nil
+}
+
+// fetchQuadtreePacketData downloads a quadtree packet and returns it decrypted and decompressed
+func (c *Client) fetchQuadtreePacketData(tile *Tile, epoch int) ([]byte, error) {
 	url := fmt.Sprintf(QuadtreePacketURL, tile.Path, epoch)
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -601,22 +635,42 @@ func (c *Client) FetchQuadtreePacket(tile *Tile, epoch int) (*QuadtreePacket, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to decompress quadtree packet: %w", err)
 	}
+	return decompressed, nil
+}
 
-	// Parse binary packet
-	packet, err := ParseQuadtreePacket(decompressed, false)
+// parseQuadtreePacketData parses a decompressed binary quadtree packet
+func parseQuadtreePacketData(data []byte) (*QuadtreePacket, error) {
+	packet, err := ParseQuadtreePacket(data, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse quadtree packet: %w", err)
 	}
-
 	return packet, nil
 }
 
+// SetHeaderOverrides replaces the headers sent with every request. Values override the
+// defaults (e.g. the Google Earth Pro User-Agent); an empty value removes the header.
+func (c *Client) SetHeaderOverrides(headers map[string]string) {
+	c.headerMu.Lock()
+	c.headers = headers
+	c.headerMu.Unlock()
+}
+
 func (c *Client) setHeaders(req *http.Request) {
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Set("Accept", "application/vnd.google-earth.kml+xml, application/vnd.google-earth.kmz, image/*, */*")
 	req.Header.Set("Accept-Encoding", "gzip")
 	req.Header.Set("Accept-Language", "en-US,*")
 	req.Header.Set("Connection", "Keep-Alive")
+
+	c.headerMu.RLock()
+	defer c.headerMu.RUnlock()
+	for name, value := range c.headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
 }
 
 // decodeVarint decodes a protobuf varint
@@ -0,0 +1,127 @@
+package googleearth
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// batchDateWorkers is the number of tiles resolved concurrently by GetAvailableDatesForTiles
+const batchDateWorkers = 8
+
+// TileDates holds the historical imagery dates of one tile, or why they could not be read
+type TileDates struct {
+	Tile  *Tile
+	Dates []DatedTile
+	Err   error
+}
+
+// GetAvailableDatesForTiles returns the historical imagery dates of each tile, in the order
+// given. All tiles are traversed in one pass: every TimeMachine packet on the way (root and
+// shared parents included) is fetched once, however many tiles sit below it. A tile that
+// cannot be resolved gets an Err; the returned error is only set when the TimeMachine
+// database itself is unavailable.
+func (c *Client) GetAvailableDatesForTiles(tiles []*Tile) ([]TileDates, error) {
+	if !c.initialized {
+		if err := c.Initialize(); err != nil {
+			return nil, err
+		}
+	}
+	if !c.tmInitialized {
+		if err := c.InitializeTimeMachine(); err != nil {
+			return nil, err
+		}
+	}
+
+	dbVersion := c.tmDbVersion
+	if dbVersion == 0 {
+		dbVersion = 1
+	}
+
+	// Packets fetched during this pass, by quadtree path
+	type packetResult struct {
+		packet *TimeMachinePacket
+		err    error
+	}
+	var (
+		mu      sync.Mutex
+		packets = make(map[string]packetResult)
+		group   singleflight.Group
+	)
+	fetchPacket := func(path string, epoch int) (*TimeMachinePacket, error) {
+		mu.Lock()
+		result, exists := packets[path]
+		mu.Unlock()
+		if exists {
+			return result.packet, result.err
+		}
+
+		packet, err, _ := group.Do(path, func() (any, error) {
+			packet, err := c.fetchSingleTimeMachinePacket(&Tile{Path: path}, epoch)
+			mu.Lock()
+			packets[path] = packetResult{packet: packet, err: err}
+			mu.Unlock()
+			return packet, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return packet.(*TimeMachinePacket), nil
+	}
+
+	// resolve walks from the root to the packet holding the tile's node
+	resolve := func(tile *Tile) (*TimeMachinePacket, error) {
+		packet, err := fetchPacket("0", dbVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch root packet: %w", err)
+		}
+		for _, pathStr := range tile.TraversalPaths() {
+			subIndex := GetSubIndex(pathStr)
+			var node *TimeMachineNode
+			for _, n := range packet.Nodes {
+				if int(n.Index) == subIndex {
+					node = n
+					break
+				}
+			}
+			if node == nil {
+				return nil, fmt.Errorf("traversal failed at %s", pathStr)
+			}
+			if node.CacheNodeEpoch != 0 {
+				packet, err = fetchPacket(pathStr, int(node.CacheNodeEpoch))
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch child packet at %s: %w", pathStr, err)
+				}
+			}
+		}
+		return packet, nil
+	}
+
+	results := make([]TileDates, len(tiles))
+	indexChan := make(chan int, len(tiles))
+	var wg sync.WaitGroup
+	for i := 0; i < min(batchDateWorkers, len(tiles)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexChan {
+				tile := tiles[idx]
+				results[idx].Tile = tile
+				packet, err := resolve(tile)
+				if err != nil {
+					results[idx].Err = fmt.Errorf("failed to fetch TimeMachine packet: %w", err)
+					continue
+				}
+				results[idx].Dates, results[idx].Err = datesFromPacket(packet, tile)
+			}
+		}()
+	}
+	for i := range tiles {
+		indexChan <- i
+	}
+	close(indexChan)
+	wg.Wait()
+
+	return results, nil
+}
@@ -0,0 +1,279 @@
+package googleearth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EpochRegionPrefixLength is the number of quadtree path characters used as the region key.
+// A path prefix of 10 characters corresponds to a level-9 node (~40km across at the equator),
+// which is small enough that neighbouring tiles share the same serving epoch in practice.
+const EpochRegionPrefixLength = 10
+
+// epochCacheSaveDelay batches the changes of a burst of tile fetches into one file write
+const epochCacheSaveDelay = 5 * time.Second
+
+// LearnedEpoch records an epoch/hexDate pair that successfully served a historical tile
+type LearnedEpoch struct {
+	Epoch     int       `json:"epoch"`
+	HexDate   string    `json:"hexDate"` // Actual hexDate that worked (may differ from requested when nearest date was used)
+	Hits      int       `json:"hits"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DiscoveredEpochs are the epochs found serving historical tiles in a region by probing
+// (see Client.ProbeEpochs)
+type DiscoveredEpochs struct {
+	Epochs   []int     `json:"epochs"` // Newest first
+	ProbedAt time.Time `json:"probedAt"`
+}
+
+// EpochCache persists working epochs per region so repeat fetches of the same area
+// can skip the TimeMachine lookup and the known-good epoch probing.
+// Key format: "{quadtree prefix}:{level}:{requested hexDate}"
+// Discovered epochs are keyed by region only: "{quadtree prefix}:{level}"
+type EpochCache struct {
+	path       string
+	mu         sync.RWMutex
+	entries    map[string]*LearnedEpoch
+	discovered map[string]*DiscoveredEpochs
+	saveMu     sync.Mutex
+
+	pendingMu sync.Mutex
+	saveTimer *time.Timer // Set while a save is scheduled (see scheduleSave)
+}
+
+// epochCacheFile is the layout of the cache file. Files from before epoch discovery
+// hold the learned entries map alone.
+type epochCacheFile struct {
+	Learned    map[string]*LearnedEpoch     `json:"learned"`
+	Discovered map[string]*DiscoveredEpochs `json:"discovered"`
+}
+
+// NewEpochCache creates an epoch cache backed by a JSON file at path
+// A missing or corrupt file results in an empty cache rather than an error
+func NewEpochCache(path string) (*EpochCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create epoch cache directory: %w", err)
+	}
+
+	c := &EpochCache{
+		path:       path,
+		entries:    make(map[string]*LearnedEpoch),
+		discovered: make(map[string]*DiscoveredEpochs),
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var file epochCacheFile
+		if err := json.Unmarshal(data, &file); err == nil && (file.Learned != nil || file.Discovered != nil) {
+			if file.Learned != nil {
+				c.entries = file.Learned
+			}
+			if file.Discovered != nil {
+				c.discovered = file.Discovered
+			}
+		} else {
+			var entries map[string]*LearnedEpoch
+			if err := json.Unmarshal(data, &entries); err == nil && entries != nil {
+				c.entries = entries
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read epoch cache: %w", err)
+	}
+
+	return c, nil
+}
+
+// epochCacheKey builds the region key for a tile and requested hexDate
+// The level is part of the key because GE serves different epochs at different zooms
+func epochCacheKey(tile *Tile, hexDate string) string {
+	return fmt.Sprintf("%s:%s", epochRegionKey(tile), hexDate)
+}
+
+// epochRegionKey builds the key of the region a tile lies in, at the tile's level
+func epochRegionKey(tile *Tile) string {
+	prefix := tile.Path
+	if len(prefix) > EpochRegionPrefixLength {
+		prefix = prefix[:EpochRegionPrefixLength]
+	}
+	return fmt.Sprintf("%s:%d", prefix, tile.Level)
+}
+
+// Lookup returns the learned epoch for the tile's region and requested hexDate
+func (c *EpochCache) Lookup(tile *Tile, hexDate string) (LearnedEpoch, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[epochCacheKey(tile, hexDate)]
+	if !exists {
+		return LearnedEpoch{}, false
+	}
+	return *entry, true
+}
+
+// Record stores a working epoch for the tile's region and requested hexDate
+// The file is only rewritten when the epoch or resolved hexDate actually changes
+func (c *EpochCache) Record(tile *Tile, hexDate string, epoch int, resolvedHexDate string) {
+	key := epochCacheKey(tile, hexDate)
+
+	c.mu.Lock()
+	entry, exists := c.entries[key]
+	changed := !exists || entry.Epoch != epoch || entry.HexDate != resolvedHexDate
+	if changed {
+		c.entries[key] = &LearnedEpoch{
+			Epoch:     epoch,
+			HexDate:   resolvedHexDate,
+			Hits:      1,
+			UpdatedAt: time.Now(),
+		}
+	} else {
+		entry.Hits++
+	}
+	c.mu.Unlock()
+
+	if changed {
+		c.scheduleSave()
+	}
+}
+
+// Forget removes a learned epoch that no longer works (e.g. after Google rotates epochs)
+func (c *EpochCache) Forget(tile *Tile, hexDate string) {
+	key := epochCacheKey(tile, hexDate)
+
+	c.mu.Lock()
+	_, exists := c.entries[key]
+	delete(c.entries, key)
+	c.mu.Unlock()
+
+	if exists {
+		c.scheduleSave()
+	}
+}
+
+// RecordDiscovered adds probed epochs that served tiles in the tile's region to the ones
+// found before, as different dates can be served from different epochs
+func (c *EpochCache) RecordDiscovered(tile *Tile, epochs []int) {
+	if len(epochs) == 0 {
+		return
+	}
+	key := epochRegionKey(tile)
+
+	c.mu.Lock()
+	seen := make(map[int]bool)
+	var merged []int
+	if existing, ok := c.discovered[key]; ok {
+		merged = append(merged, existing.Epochs...)
+	}
+	merged = append(merged, epochs...)
+	unique := merged[:0]
+	for _, epoch := range merged {
+		if !seen[epoch] {
+			seen[epoch] = true
+			unique = append(unique, epoch)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(unique)))
+	c.discovered[key] = &DiscoveredEpochs{Epochs: unique, ProbedAt: time.Now()}
+	c.mu.Unlock()
+
+	c.scheduleSave()
+}
+
+// Discovered returns the epochs found serving tiles in the tile's region, newest first
+func (c *EpochCache) Discovered(tile *Tile) ([]int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.discovered[epochRegionKey(tile)]
+	if !exists || len(entry.Epochs) == 0 {
+		return nil, false
+	}
+	return append([]int(nil), entry.Epochs...), true
+}
+
+// Len returns the number of learned entries
+func (c *EpochCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Clear removes all learned epochs from memory and disk
+func (c *EpochCache) Clear() error {
+	c.mu.Lock()
+	c.entries = make(map[string]*LearnedEpoch)
+	c.discovered = make(map[string]*DiscoveredEpochs)
+	c.mu.Unlock()
+	c.cancelPendingSave()
+	return c.save()
+}
+
+// Flush writes a scheduled save now; call it on shutdown so recent changes are kept
+func (c *EpochCache) Flush() error {
+	if !c.cancelPendingSave() {
+		return nil
+	}
+	return c.save()
+}
+
+// scheduleSave saves the cache after epochCacheSaveDelay, unless a save is already
+// scheduled, which then includes this change
+func (c *EpochCache) scheduleSave() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.saveTimer != nil {
+		return
+	}
+	c.saveTimer = time.AfterFunc(epochCacheSaveDelay, func() {
+		c.pendingMu.Lock()
+		c.saveTimer = nil
+		c.pendingMu.Unlock()
+		if err := c.save(); err != nil {
+			log.Printf("[EpochCache] Failed to save: %v", err)
+		}
+	})
+}
+
+// cancelPendingSave cancels a scheduled save and reports whether one was pending
+func (c *EpochCache) cancelPendingSave() bool {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.saveTimer == nil {
+		return false
+	}
+	c.saveTimer.Stop()
+	c.saveTimer = nil
+	return true
+}
+
+// save writes the cache to disk (temp file + rename for atomicity)
+func (c *EpochCache) save() error {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.RLock()
+	data, err := json.MarshalIndent(epochCacheFile{Learned: c.entries, Discovered: c.discovered}, "", "  ")
+	c.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal epoch cache: %w", err)
+	}
+
+	tempPath := c.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write epoch cache: %w", err)
+	}
+
+	if err := os.Rename(tempPath, c.path); err != nil {
+		return fmt.Errorf("failed to rename epoch cache file: %w", err)
+	}
+
+	return nil
+}
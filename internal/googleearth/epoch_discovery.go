@@ -0,0 +1,106 @@
+package googleearth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SeedEpochs are historical tile epochs found to serve tiles in most regions, tried after
+// the TimeMachine epochs of a tile fail when its region has no discovered epochs.
+// Newest first (more likely to have tiles for recent dates):
+// - 365, 361, 360: 2025+ dates at high zoom levels (17-21)
+// - 358, 357, 356, 354, 352: 2024 dates
+// - 321: 2023 dates
+// - 296, 273: 2020-2022 dates
+var SeedEpochs = []int{365, 361, 360, 358, 357, 356, 354, 352, 321, 296, 273}
+
+const (
+	// DefaultEpochProbeSpan is how many epochs below the TimeMachine database version are
+	// probed when no range is given
+	DefaultEpochProbeSpan = 100
+
+	// MaxEpochProbeSpan bounds the epochs probed per discovery
+	MaxEpochProbeSpan = 250
+
+	// epochProbeWorkers bounds the concurrent probe requests
+	epochProbeWorkers = 4
+)
+
+// EpochProbe is the number of sampled tiles one epoch served for the probed date
+type EpochProbe struct {
+	Epoch  int `json:"epoch"`
+	Served int `json:"served"`
+}
+
+// TimeMachineVersion returns the version of the TimeMachine database, the newest epoch
+// historical tiles can be served from
+func (c *Client) TimeMachineVersion() (int, error) {
+	if err := c.InitializeTimeMachine(); err != nil {
+		return 0, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.tmDbVersion == 0 {
+		return 0, fmt.Errorf("TimeMachine database version is unknown")
+	}
+	return c.tmDbVersion, nil
+}
+
+// ProbeEpochs requests every tile for hexDate from the flatfile endpoint at each epoch
+// from maxEpoch down to minEpoch and returns the epochs that served at least one tile,
+// newest first. onEpoch is called after each epoch with the number probed so far and the
+// total. Stops early, returning what was found, when ctx is cancelled.
+func (c *Client) ProbeEpochs(ctx context.Context, tiles []*Tile, hexDate string, minEpoch, maxEpoch int, onEpoch func(done, total int)) ([]EpochProbe, error) {
+	if len(tiles) == 0 {
+		return nil, fmt.Errorf("no tiles to probe")
+	}
+	if minEpoch < 1 || maxEpoch < minEpoch {
+		return nil, fmt.Errorf("invalid epoch range %d-%d", minEpoch, maxEpoch)
+	}
+	if span := maxEpoch - minEpoch + 1; span > MaxEpochProbeSpan {
+		return nil, fmt.Errorf("epoch range %d-%d spans %d epochs, more than the %d probed at once", minEpoch, maxEpoch, span, MaxEpochProbeSpan)
+	}
+
+	total := maxEpoch - minEpoch + 1
+	served := make(map[int]int)
+	done := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, epochProbeWorkers)
+	for epoch := maxEpoch; epoch >= minEpoch; epoch-- {
+		if ctx.Err() != nil {
+			break
+		}
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(epoch int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			n := 0
+			for _, tile := range tiles {
+				if _, err := c.FetchHistoricalTile(tile, epoch, hexDate); err == nil {
+					n++
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if n > 0 {
+				served[epoch] = n
+			}
+			done++
+			if onEpoch != nil {
+				onEpoch(done, total)
+			}
+		}(epoch)
+	}
+	wg.Wait()
+
+	probes := make([]EpochProbe, 0, len(served))
+	for epoch, n := range served {
+		probes = append(probes, EpochProbe{Epoch: epoch, Served: n})
+	}
+	sort.Slice(probes, func(i, j int) bool { return probes[i].Epoch > probes[j].Epoch })
+	return probes, ctx.Err()
+}
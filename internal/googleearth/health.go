@@ -0,0 +1,35 @@
+package googleearth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PingDatabase requests the dbRoot through the client's transport (app proxy, TLS and
+// header settings) and returns the HTTP status, without parsing the response
+func (c *Client) PingDatabase(ctx context.Context) (int, error) {
+	return c.ping(ctx, DatabaseURL)
+}
+
+// PingTimeMachineDatabase requests the TimeMachine dbRoot like PingDatabase
+func (c *Client) PingTimeMachineDatabase(ctx context.Context) (int, error) {
+	return c.ping(ctx, TimeMachineDatabaseURL)
+}
+
+func (c *Client) ping(ctx context.Context, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
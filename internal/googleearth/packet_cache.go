@@ -0,0 +1,169 @@
+package googleearth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultPacketCacheTTL is how long a cached quadtree packet is reused. Packets are
+	// addressed by epoch and rarely change, so a day only bounds stale data after a rotation.
+	DefaultPacketCacheTTL = 24 * time.Hour
+
+	// maxMemoryPackets caps the parsed packets held in memory (a packet is ~10-50 KB parsed)
+	maxMemoryPackets = 2048
+)
+
+// Packet kinds, each served by its own database
+const (
+	packetKindQuadtree    = "qt"
+	packetKindTimeMachine = "tm"
+)
+
+// cachedPacket is a parsed packet held in memory
+type cachedPacket struct {
+	packet    any // *QuadtreePacket or *TimeMachinePacket
+	fetchedAt time.Time
+}
+
+// PacketCache keeps decrypted quadtree packets in memory (parsed) and on disk (decompressed
+// bytes) so date lookups and traversals stop refetching the same packets for every tile.
+// Concurrent requests for the same packet share a single fetch.
+// Key format: "{kind}:{quadtree path}:{epoch}"
+type PacketCache struct {
+	dir   string
+	ttl   time.Duration
+	mu    sync.RWMutex
+	mem   map[string]*cachedPacket
+	group singleflight.Group
+}
+
+// NewPacketCache creates a packet cache storing packets under dir
+// ttl <= 0 uses DefaultPacketCacheTTL
+func NewPacketCache(dir string, ttl time.Duration) (*PacketCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create packet cache directory: %w", err)
+	}
+	if ttl <= 0 {
+		ttl = DefaultPacketCacheTTL
+	}
+	return &PacketCache{
+		dir: dir,
+		ttl: ttl,
+		mem: make(map[string]*cachedPacket),
+	}, nil
+}
+
+// get returns the packet of kind at path and epoch, from memory, then disk, then fetch.
+// fetch returns the decompressed packet bytes; parse turns them into a packet.
+func (c *PacketCache) get(kind, path string, epoch int, fetch func() ([]byte, error), parse func([]byte) (any, error)) (any, error) {
+	key := fmt.Sprintf("%s:%s:%d", kind, path, epoch)
+
+	c.mu.RLock()
+	entry, exists := c.mem[key]
+	c.mu.RUnlock()
+	if exists && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.packet, nil
+	}
+
+	packet, err, _ := c.group.Do(key, func() (any, error) {
+		filePath := filepath.Join(c.dir, fmt.Sprintf("%s_%s_%d.bin", kind, path, epoch))
+		fetchedAt := time.Now()
+
+		var data []byte
+		if info, err := os.Stat(filePath); err == nil && time.Since(info.ModTime()) < c.ttl {
+			if data, err = os.ReadFile(filePath); err == nil {
+				fetchedAt = info.ModTime()
+			}
+		}
+
+		fromDisk := data != nil
+		if !fromDisk {
+			var err error
+			if data, err = fetch(); err != nil {
+				return nil, err
+			}
+		}
+
+		packet, err := parse(data)
+		if err != nil {
+			if fromDisk {
+				os.Remove(filePath) // Corrupt entry; the next request refetches
+			}
+			return nil, err
+		}
+
+		if !fromDisk {
+			if err := writeFileAtomic(filePath, data); err != nil {
+				log.Printf("[PacketCache] Failed to store packet %s: %v", key, err)
+			}
+		}
+		c.store(key, &cachedPacket{packet: packet, fetchedAt: fetchedAt})
+		return packet, nil
+	})
+	return packet, err
+}
+
+// store adds a parsed packet to memory, evicting expired packets (then the oldest) when full
+func (c *PacketCache) store(key string, entry *cachedPacket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.mem) >= maxMemoryPackets {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range c.mem {
+			if time.Since(e.fetchedAt) >= c.ttl {
+				delete(c.mem, k)
+				continue
+			}
+			if oldestKey == "" || e.fetchedAt.Before(oldest) {
+				oldestKey, oldest = k, e.fetchedAt
+			}
+		}
+		if len(c.mem) >= maxMemoryPackets {
+			delete(c.mem, oldestKey)
+		}
+	}
+	c.mem[key] = entry
+}
+
+// Len returns the number of packets held in memory
+func (c *PacketCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.mem)
+}
+
+// Clear removes all cached packets from memory and disk
+func (c *PacketCache) Clear() error {
+	c.mu.Lock()
+	c.mem = make(map[string]*cachedPacket)
+	c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read packet cache directory: %w", err)
+	}
+	for _, entry := range entries {
+		if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cached packet: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temp file + rename
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
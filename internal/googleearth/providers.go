@@ -0,0 +1,174 @@
+package googleearth
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DbRootProto fields used for imagery provider lookup
+const (
+	dbRootFieldProviderInfo     = 3 // repeated ProviderInfoProto
+	dbRootFieldTranslationEntry = 8 // repeated StringEntryProto
+)
+
+// parseProviderTable reads the provider table of a decompressed DbRootProto into provider
+// ID -> copyright string (e.g. "© 2024 Maxar Technologies"). A copyright is either
+// stored inline or as a string ID resolved through the translation entries.
+func parseProviderTable(dbRoot []byte) map[int]string {
+	translations := make(map[uint32]string)
+	type providerInfo struct {
+		id       int
+		stringID uint32
+		value    string
+	}
+	var infos []providerInfo
+
+	forEachField(dbRoot, func(fieldNum, wireType int, _ uint64, raw []byte) {
+		if wireType != 2 {
+			return
+		}
+		switch fieldNum {
+		case dbRootFieldTranslationEntry:
+			// StringEntryProto: string_id (1, fixed32), string_value (2)
+			var id uint32
+			var value string
+			forEachField(raw, func(fieldNum, wireType int, _ uint64, raw []byte) {
+				switch {
+				case fieldNum == 1 && wireType == 5:
+					id = binary.LittleEndian.Uint32(raw)
+				case fieldNum == 2 && wireType == 2:
+					value = string(raw)
+				}
+			})
+			translations[id] = value
+
+		case dbRootFieldProviderInfo:
+			// ProviderInfoProto: provider_id (1), copyright_string (2, StringIdOrValueProto)
+			var info providerInfo
+			forEachField(raw, func(fieldNum, wireType int, varint uint64, raw []byte) {
+				switch {
+				case fieldNum == 1 && wireType == 0:
+					info.id = int(varint)
+				case fieldNum == 2 && wireType == 2:
+					// StringIdOrValueProto: string_id (1, fixed32), value (2)
+					forEachField(raw, func(fieldNum, wireType int, _ uint64, raw []byte) {
+						switch {
+						case fieldNum == 1 && wireType == 5:
+							info.stringID = binary.LittleEndian.Uint32(raw)
+						case fieldNum == 2 && wireType == 2:
+							info.value = string(raw)
+						}
+					})
+				}
+			})
+			infos = append(infos, info)
+		}
+	})
+
+	providers := make(map[int]string, len(infos))
+	for _, info := range infos {
+		name := info.value
+		if name == "" {
+			name = translations[info.stringID]
+		}
+		if name != "" {
+			providers[info.id] = name
+		}
+	}
+	return providers
+}
+
+// forEachField calls fn for every field of a protobuf message with its varint value
+// (wire type 0) or raw bytes (fixed64, length-delimited and fixed32). Parsing stops at
+// the first malformed field.
+func forEachField(data []byte, fn func(fieldNum, wireType int, varint uint64, raw []byte)) {
+	offset := 0
+	for offset < len(data) {
+		tag, n := decodeVarint(data[offset:])
+		if n == 0 {
+			return
+		}
+		offset += n
+		fieldNum, wireType := int(tag>>3), int(tag&0x07)
+
+		size := 0
+		switch wireType {
+		case 0: // Varint
+			v, n := decodeVarint(data[offset:])
+			offset += n
+			fn(fieldNum, wireType, v, nil)
+			continue
+		case 1: // 64-bit
+			size = 8
+		case 2: // Length-delimited
+			length, n := decodeVarint(data[offset:])
+			offset += n
+			if length > uint64(len(data)-offset) {
+				return
+			}
+			size = int(length)
+		case 5: // 32-bit
+			size = 4
+		default:
+			return
+		}
+		if offset+size > len(data) {
+			return
+		}
+		fn(fieldNum, wireType, 0, data[offset:offset+size])
+		offset += size
+	}
+}
+
+// ProviderName returns the copyright string of an imagery provider ID from the current
+// (historical false) or TimeMachine (historical true) database, or "" when unknown
+func (c *Client) ProviderName(id int, historical bool) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if historical {
+		return c.tmProviders[id]
+	}
+	return c.providers[id]
+}
+
+// TileProvider returns the provider ID of a tile's current imagery from its quadtree node
+func (c *Client) TileProvider(tile *Tile) (int, error) {
+	if !c.initialized {
+		if err := c.Initialize(); err != nil {
+			return 0, err
+		}
+	}
+
+	packet, err := c.GetQuadtreePacket(tile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quadtree packet: %w", err)
+	}
+
+	subIndex := GetSubIndex(tile.Path)
+	for _, sqNode := range packet.SparseQuadtreeNodes {
+		if int(sqNode.Index) != subIndex {
+			continue
+		}
+		for _, layer := range sqNode.Node.Layers {
+			if layer.Type == 2 { // Imagery (see ParseQuadtreePacket)
+				return int(layer.Provider), nil
+			}
+		}
+		return 0, fmt.Errorf("no imagery for tile %s", tile.Path)
+	}
+	return 0, fmt.Errorf("node not found in packet for subindex %d", subIndex)
+}
+
+// HistoricalTileProvider returns the provider ID of a tile's historical imagery for a date
+func (c *Client) HistoricalTileProvider(tile *Tile, hexDate string) (int, error) {
+	dates, err := c.GetAvailableDates(tile)
+	if err != nil {
+		return 0, err
+	}
+	for _, dt := range dates {
+		if dt.HexDate == hexDate {
+			return dt.Provider, nil
+		}
+	}
+	return 0, fmt.Errorf("no imagery dated %s for tile %s", hexDate, tile.Path)
+}
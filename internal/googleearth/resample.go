@@ -0,0 +1,150 @@
+package googleearth
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"imagery-desktop/internal/tilemath"
+)
+
+// Resampling selects how source pixels are sampled when reprojecting GE tiles
+type Resampling int
+
+const (
+	// ResampleNearest takes the nearest source pixel (fast, shimmers at high zoom)
+	ResampleNearest Resampling = iota
+	// ResampleBilinear blends the four nearest source pixels, across tile edges
+	ResampleBilinear
+)
+
+// ParseResampling maps the reprojection quality setting to a Resampling:
+// "fast" is nearest neighbour, "quality" or empty is bilinear
+func ParseResampling(quality string) (Resampling, error) {
+	switch quality {
+	case "", "quality":
+		return ResampleBilinear, nil
+	case "fast":
+		return ResampleNearest, nil
+	}
+	return ResampleNearest, fmt.Errorf("reprojection quality must be 'fast' or 'quality'")
+}
+
+// ReprojectToWebMercatorResampled creates a Web Mercator tile from GE tiles at sourceZoom
+// (see ReprojectToWebMercatorWithSourceZoom) using the given resampling
+func ReprojectToWebMercatorResampled(geTiles map[string]image.Image, x, y, z, sourceZoom, tileSize int, resampling Resampling) *image.RGBA {
+	if resampling == ResampleBilinear {
+		return reprojectBilinear(geTiles, x, y, z, sourceZoom, tileSize)
+	}
+
+	output := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+
+	for py := 0; py < tileSize; py++ {
+		for px := 0; px < tileSize; px++ {
+			// Get lat/lon for this output pixel (at the output zoom z)
+			lat, lon := PixelToLatLon(x, y, z, px, py, tileSize)
+
+			// Find which GE tile and pixel this corresponds to (at the source zoom)
+			geRow, geCol, gePx, gePy := LatLonToGETilePixel(lat, lon, sourceZoom, tileSize)
+
+			// Look up the source tile
+			key := fmt.Sprintf("%d,%d", geRow, geCol)
+			srcImg, ok := geTiles[key]
+			if !ok {
+				// No tile available, leave transparent
+				continue
+			}
+
+			// Sample the source pixel
+			c := srcImg.At(gePx, gePy)
+			output.Set(px, py, c)
+		}
+	}
+
+	return output
+}
+
+// reprojectBilinear samples the GE mosaic as one continuous image, so pixels next to a
+// tile edge blend with the neighbouring tile. Samples falling in missing tiles are left
+// out and the remaining weights renormalised.
+func reprojectBilinear(geTiles map[string]image.Image, x, y, z, sourceZoom, tileSize int) *image.RGBA {
+	output := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+
+	// Index tiles by row and column once rather than formatting a key per sample
+	tiles := make(map[[2]int]image.Image, len(geTiles))
+	for key, img := range geTiles {
+		var row, col int
+		if _, err := fmt.Sscanf(key, "%d,%d", &row, &col); err == nil {
+			tiles[[2]int{row, col}] = img
+		}
+	}
+
+	// sample returns the pixel at global mosaic position (gx, gy): gx counts pixels east
+	// from column 0, gy counts pixels south from the top of row 0 (rows grow northwards)
+	sample := func(gx, gy int) (color.RGBA64, bool) {
+		col := floorDiv(gx, tileSize)
+		row := -floorDiv(gy, tileSize) - 1
+		img, ok := tiles[[2]int{row, tilemath.WrapColumn(col, sourceZoom)}]
+		if !ok {
+			return color.RGBA64{}, false
+		}
+		px, py := gx-col*tileSize, gy+(row+1)*tileSize
+		r, g, b, a := img.At(img.Bounds().Min.X+px, img.Bounds().Min.Y+py).RGBA()
+		return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}, true
+	}
+
+	for py := 0; py < tileSize; py++ {
+		for px := 0; px < tileSize; px++ {
+			lat, lon := PixelToLatLon(x, y, z, px, py, tileSize)
+			rowF, colF := tilemath.LatLonToGEFrac(lat, lon, sourceZoom)
+
+			// Continuous position relative to source pixel centers
+			sx := colF*float64(tileSize) - 0.5
+			sy := -rowF*float64(tileSize) - 0.5
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+
+			weights := [4]float64{(1 - fx) * (1 - fy), fx * (1 - fy), (1 - fx) * fy, fx * fy}
+			offsets := [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+
+			var sum [4]float64
+			var total float64
+			for i, off := range offsets {
+				if weights[i] == 0 {
+					continue
+				}
+				c, ok := sample(x0+off[0], y0+off[1])
+				if !ok {
+					continue
+				}
+				sum[0] += weights[i] * float64(c.R)
+				sum[1] += weights[i] * float64(c.G)
+				sum[2] += weights[i] * float64(c.B)
+				sum[3] += weights[i] * float64(c.A)
+				total += weights[i]
+			}
+			if total == 0 {
+				continue // No tile available, leave transparent
+			}
+
+			output.SetRGBA64(px, py, color.RGBA64{
+				R: uint16(sum[0]/total + 0.5),
+				G: uint16(sum[1]/total + 0.5),
+				B: uint16(sum[2]/total + 0.5),
+				A: uint16(sum[3]/total + 0.5),
+			})
+		}
+	}
+
+	return output
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
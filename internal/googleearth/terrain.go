@@ -0,0 +1,211 @@
+package googleearth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+)
+
+// TerrainTileURL is the flatfile pattern for terrain packets (path, terrain version)
+const TerrainTileURL = "https://kh.google.com/flatfile?f1c-%s-t.%d"
+
+// TerrainHeightScale converts packed terrain heights (Earth radii) to meters
+const TerrainHeightScale = 6371010.0
+
+// TerrainPoint is a mesh vertex in geographic coordinates with elevation in meters
+type TerrainPoint struct {
+	Lon       float64
+	Lat       float64
+	Elevation float64
+}
+
+// TerrainMesh is a single decoded terrain mesh from a terrain packet
+// Vertices are placed on a byte grid: lon = OriginX + x*StepX, lat = OriginY + y*StepY
+type TerrainMesh struct {
+	OriginX float64 // degrees
+	OriginY float64 // degrees
+	StepX   float64 // degrees
+	StepY   float64 // degrees
+	Level   int
+	Points  []TerrainPoint
+	Faces   [][3]uint16
+}
+
+// terrainMeshHeaderSize is origin/step (4 doubles) + numPoints, numFaces, level (3 int32)
+const terrainMeshHeaderSize = 4*8 + 3*4
+
+// FetchTerrain downloads and decodes the terrain packet for a tile
+// Returns an error if the quadtree reports no terrain for this node
+func (c *Client) FetchTerrain(tile *Tile) ([]*TerrainMesh, error) {
+	if !c.initialized {
+		if err := c.Initialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	packet, err := c.GetQuadtreePacket(tile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quadtree packet: %w", err)
+	}
+
+	node := findPacketNode(packet, tile.Path)
+	if node == nil {
+		return nil, fmt.Errorf("node not found in packet for %s", tile.Path)
+	}
+
+	hasTerrain := false
+	for _, layer := range node.Layers {
+		if layer.Type == LayerTypeTerrain {
+			hasTerrain = true
+			break
+		}
+	}
+	if !hasTerrain || node.TerrainVersion <= 0 {
+		return nil, fmt.Errorf("no terrain available for %s", tile.Path)
+	}
+
+	url := fmt.Sprintf(TerrainTileURL, tile.Path, node.TerrainVersion)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch terrain: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("terrain request failed with status: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terrain data: %w", err)
+	}
+
+	c.decrypt(data)
+
+	decompressed, err := c.decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress terrain packet: %w", err)
+	}
+
+	meshes, err := ParseTerrainPacket(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse terrain packet for %s: %w", tile.Path, err)
+	}
+
+	return meshes, nil
+}
+
+// findPacketNode returns the node for a quadtree path within a packet, or nil
+func findPacketNode(packet *QuadtreePacket, path string) *QuadtreeNode {
+	if packet == nil {
+		return nil
+	}
+	subIndex := GetSubIndex(path)
+	for _, sqNode := range packet.SparseQuadtreeNodes {
+		if int(sqNode.Index) == subIndex {
+			return sqNode.Node
+		}
+	}
+	return nil
+}
+
+// ParseTerrainPacket decodes a decompressed terrain packet into its meshes
+// Layout per mesh (little-endian):
+//
+//	uint32 size | float64 originX, originY, stepX, stepY (units of 180°)
+//	int32 numPoints | int32 numFaces | int32 level
+//	numPoints × (uint8 x, uint8 y, float32 height in Earth radii)
+//	numFaces × 3 × uint16 vertex indices
+func ParseTerrainPacket(data []byte) ([]*TerrainMesh, error) {
+	var meshes []*TerrainMesh
+	offset := 0
+
+	for offset+4 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if size == 0 {
+			continue
+		}
+		if size < terrainMeshHeaderSize || offset+size > len(data) {
+			return meshes, fmt.Errorf("mesh at offset %d has invalid size %d", offset-4, size)
+		}
+
+		mesh, err := parseTerrainMesh(data[offset : offset+size])
+		if err != nil {
+			return meshes, err
+		}
+		meshes = append(meshes, mesh)
+		offset += size
+	}
+
+	if len(meshes) == 0 {
+		return nil, fmt.Errorf("no meshes in terrain packet")
+	}
+
+	return meshes, nil
+}
+
+// parseTerrainMesh decodes a single mesh body (without the size prefix)
+func parseTerrainMesh(b []byte) (*TerrainMesh, error) {
+	readF64 := func(off int) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[off : off+8]))
+	}
+
+	mesh := &TerrainMesh{
+		OriginX: readF64(0) * 180.0,
+		OriginY: readF64(8) * 180.0,
+		StepX:   readF64(16) * 180.0,
+		StepY:   readF64(24) * 180.0,
+	}
+	numPoints := int(int32(binary.LittleEndian.Uint32(b[32:36])))
+	numFaces := int(int32(binary.LittleEndian.Uint32(b[36:40])))
+	mesh.Level = int(int32(binary.LittleEndian.Uint32(b[40:44])))
+
+	if numPoints < 0 || numFaces < 0 {
+		return nil, fmt.Errorf("invalid mesh counts: points=%d faces=%d", numPoints, numFaces)
+	}
+
+	offset := terrainMeshHeaderSize
+	if offset+numPoints*6+numFaces*6 > len(b) {
+		return nil, fmt.Errorf("mesh truncated: need %d bytes, have %d", offset+numPoints*6+numFaces*6, len(b))
+	}
+
+	mesh.Points = make([]TerrainPoint, numPoints)
+	for i := 0; i < numPoints; i++ {
+		x := float64(b[offset])
+		y := float64(b[offset+1])
+		h := math.Float32frombits(binary.LittleEndian.Uint32(b[offset+2 : offset+6]))
+		mesh.Points[i] = TerrainPoint{
+			Lon:       mesh.OriginX + x*mesh.StepX,
+			Lat:       mesh.OriginY + y*mesh.StepY,
+			Elevation: float64(h) * TerrainHeightScale,
+		}
+		offset += 6
+	}
+
+	mesh.Faces = make([][3]uint16, 0, numFaces)
+	for i := 0; i < numFaces; i++ {
+		face := [3]uint16{
+			binary.LittleEndian.Uint16(b[offset : offset+2]),
+			binary.LittleEndian.Uint16(b[offset+2 : offset+4]),
+			binary.LittleEndian.Uint16(b[offset+4 : offset+6]),
+		}
+		offset += 6
+		// Drop faces referencing missing vertices rather than failing the whole mesh
+		if int(face[0]) >= numPoints || int(face[1]) >= numPoints || int(face[2]) >= numPoints {
+			continue
+		}
+		mesh.Faces = append(mesh.Faces, face)
+	}
+
+	return mesh, nil
+}
@@ -3,7 +3,8 @@ package googleearth
 import (
 	"fmt"
 	"image"
-	"math"
+
+	"imagery-desktop/internal/tilemath"
 )
 
 // Tile represents a Google Earth tile using quadtree path
@@ -24,39 +25,21 @@ func (t *Tile) GetColumn() int {
 	return t.Column
 }
 
+// GetLevel implements common.Tile interface
+func (t *Tile) GetLevel() int {
+	return t.Level
+}
+
 const MaxLevel = 30
 
 // NewTileFromPath creates a Tile from a quadtree path string
+// (see tilemath for the quadrant layout)
 func NewTileFromPath(path string) (*Tile, error) {
-	if len(path) == 0 || path[0] != '0' {
-		return nil, fmt.Errorf("invalid quadtree path: must start with '0'")
-	}
-
-	t := &Tile{
-		Path:  path,
-		Level: len(path) - 1,
-	}
-
-	// Convert quadtree path to row/column
-	// Quadrant layout:
-	// |-----|-----|
-	// |  3  |  2  |
-	// |-----|-----|
-	// |  0  |  1  |
-	// |-----|-----|
-	for i := 0; i < len(path); i++ {
-		cell := int(path[i] - '0')
-		if cell < 0 || cell > 3 {
-			return nil, fmt.Errorf("invalid quadtree path character: %c", path[i])
-		}
-		row := cell >> 1
-		col := row ^ (cell & 1)
-
-		t.Row = (t.Row << 1) | row
-		t.Column = (t.Column << 1) | col
+	row, col, level, err := tilemath.GEPathToRowCol(path)
+	if err != nil {
+		return nil, err
 	}
-
-	return t, nil
+	return &Tile{Path: path, Level: level, Row: row, Column: col}, nil
 }
 
 // NewTileFromRowCol creates a Tile from row, column and level
@@ -69,19 +52,8 @@ func NewTileFromRowCol(row, col, level int) (*Tile, error) {
 		return nil, fmt.Errorf("level exceeds maximum: %d", MaxLevel)
 	}
 
-	// Build path from row/col
-	chars := make([]byte, level+1)
-	r, c := row, col
-	for i := level; i >= 0; i-- {
-		rowBit := r & 1
-		colBit := c & 1
-		r >>= 1
-		c >>= 1
-		chars[i] = byte((rowBit << 1) | (rowBit ^ colBit) + '0')
-	}
-
 	return &Tile{
-		Path:   string(chars),
+		Path:   tilemath.GEPath(row, col, level),
 		Level:  level,
 		Row:    row,
 		Column: col,
@@ -92,30 +64,8 @@ func NewTileFromRowCol(row, col, level int) (*Tile, error) {
 // MapLibre/OSM uses Web Mercator (EPSG:3857), Google Earth uses Plate Carrée (EPSG:4326)
 // We convert the XYZ tile center from Web Mercator to lat/lon, then find the GE tile
 func NewTileFromXYZ(x, y, z int) (*Tile, error) {
-	// Get the center of the Web Mercator XYZ tile in lat/lon
-	lat, lon := xyzTileToLatLon(x, y, z)
-
-	// Find the Google Earth tile at this lat/lon
-	return GetTileForCoord(lat, lon, z)
-}
-
-// xyzTileToLatLon converts Web Mercator XYZ tile coordinates to lat/lon (center of tile)
-func xyzTileToLatLon(x, y, z int) (lat, lon float64) {
-	n := float64(int(1) << z)
-
-	// Tile center in normalized coordinates (0-1)
-	tileX := (float64(x) + 0.5) / n
-	tileY := (float64(y) + 0.5) / n
-
-	// Convert to longitude (-180 to 180)
-	lon = tileX*360.0 - 180.0
-
-	// Convert to latitude using inverse Web Mercator formula
-	// Web Mercator Y goes from 0 (top/north) to 1 (bottom/south)
-	latRad := math.Atan(math.Sinh(math.Pi * (1 - 2*tileY)))
-	lat = latRad * 180.0 / math.Pi
-
-	return lat, lon
+	row, col := tilemath.XYZToGE(x, y, z)
+	return NewTileFromRowCol(row, col, z)
 }
 
 // ToXYZ converts the tile to standard XYZ coordinates
@@ -129,23 +79,14 @@ func (t *Tile) ToXYZ() (x, y, z int) {
 
 // Center returns the center lat/lon of the tile
 func (t *Tile) Center() (lat, lon float64) {
-	lat = t.rowColToLatLon(float64(t.Row) + 0.5)
-	lon = t.rowColToLatLon(float64(t.Column) + 0.5)
+	lat = tilemath.GEToDegrees(float64(t.Row)+0.5, t.Level)
+	lon = tilemath.GEToDegrees(float64(t.Column)+0.5, t.Level)
 	return
 }
 
 // Bounds returns the bounding box (south, west, north, east)
 func (t *Tile) Bounds() (south, west, north, east float64) {
-	south = t.rowColToLatLon(float64(t.Row))
-	west = t.rowColToLatLon(float64(t.Column))
-	north = t.rowColToLatLon(float64(t.Row + 1))
-	east = t.rowColToLatLon(float64(t.Column + 1))
-	return
-}
-
-func (t *Tile) rowColToLatLon(rowCol float64) float64 {
-	numTiles := float64(int(1) << t.Level)
-	return (rowCol/numTiles)*360.0 - 180.0
+	return tilemath.GEBounds(t.Row, t.Column, t.Level)
 }
 
 // GetTileForCoord returns the tile containing a lat/lon at a given zoom level
@@ -154,41 +95,20 @@ func (t *Tile) rowColToLatLon(rowCol float64) float64 {
 // - Latitude: -180 to +180 maps to rows 0 to numTiles-1 (not -90 to +90!)
 // This means actual geographic content (-90 to +90 lat) only covers the middle half of the row range.
 func GetTileForCoord(lat, lon float64, level int) (*Tile, error) {
-	numTiles := 1 << level
-
-	// Convert lat/lon to row/col
-	// GE Plate Carrée: both dimensions span -180 to +180
-	// Latitude (-90 to +90) maps to the middle half of the grid
-	row := int((lat + 180.0) / 360.0 * float64(numTiles))
-	col := int((lon + 180.0) / 360.0 * float64(numTiles))
-
-	// Clamp to valid range
-	row = clamp(row, 0, numTiles-1)
-	col = clamp(col, 0, numTiles-1)
-
+	row, col := tilemath.LatLonToGE(lat, lon, level)
 	return NewTileFromRowCol(row, col, level)
 }
 
-// GetTilesInBounds returns all tiles within a bounding box at a given zoom level
+// GetTilesInBounds returns all tiles within a bounding box at a given zoom level, west to east
+// (west > east selects a box crossing the antimeridian)
 func GetTilesInBounds(south, west, north, east float64, level int) ([]*Tile, error) {
-	numTiles := 1 << level
-
-	// Convert bounds to row/col range
-	minRow := int((south + 180.0) / 360.0 * float64(numTiles))
-	maxRow := int((north + 180.0) / 360.0 * float64(numTiles))
-	minCol := int((west + 180.0) / 360.0 * float64(numTiles))
-	maxCol := int((east + 180.0) / 360.0 * float64(numTiles))
-
-	// Clamp to valid range
-	minRow = clamp(minRow, 0, numTiles-1)
-	maxRow = clamp(maxRow, 0, numTiles-1)
-	minCol = clamp(minCol, 0, numTiles-1)
-	maxCol = clamp(maxCol, 0, numTiles-1)
+	minRow, minCol, maxRow, maxCol := tilemath.GERange(south, west, north, east, level)
 
 	var tiles []*Tile
 	for row := minRow; row <= maxRow; row++ {
 		for col := minCol; col <= maxCol; col++ {
-			tile, err := NewTileFromRowCol(row, col, level)
+			// Columns past the east edge belong to an antimeridian-crossing box
+			tile, err := NewTileFromRowCol(row, tilemath.WrapColumn(col, level), level)
 			if err != nil {
 				return nil, err
 			}
@@ -201,52 +121,23 @@ func GetTilesInBounds(south, west, north, east float64, level int) ([]*Tile, err
 
 // ResolutionAtZoom returns approximate meters per pixel at given zoom level
 func ResolutionAtZoom(zoom int, lat float64) float64 {
-	// Earth circumference at equator ≈ 40,075,016.686 meters
-	// Tile size = 256 pixels
-	earthCircumference := 40075016.686
-	return earthCircumference * math.Cos(lat*math.Pi/180) / float64(int(256)<<zoom)
+	return tilemath.ResolutionAtZoom(zoom, lat)
 }
 
 // Equator is the circumference of the Earth in Web Mercator coordinates
-const Equator = 40075016.686
+const Equator = tilemath.Equator
 
 // TileToWebMercator converts tile row/col at a zoom level to Web Mercator coordinates
-// Returns the top-left corner of the tile (in EPSG:3857)
-// IMPORTANT: GE tiles are in Plate Carrée, so we must convert via lat/lon
+// Returns the bottom-left corner of the tile (in EPSG:3857); rows increase from south to north
+// IMPORTANT: GE tiles are in Plate Carrée, so the conversion goes via lat/lon
 func TileToWebMercator(row, col, zoom int) (x, y float64) {
-	numTiles := float64(int(1) << zoom)
-
-	// Step 1: Convert GE row/col to lat/lon (Plate Carrée - linear mapping)
-	// Row increases from south to north in GE coordinate system
-	lat := (float64(row)/numTiles)*360.0 - 180.0
-	lon := (float64(col)/numTiles)*360.0 - 180.0
-
-	// Step 2: Convert lat/lon to Web Mercator (EPSG:3857)
-	x = lon * Equator / 360.0
-
-	// Clamp latitude to valid Web Mercator range (avoid infinity at poles)
-	if lat > 85.051129 {
-		lat = 85.051129
-	} else if lat < -85.051129 {
-		lat = -85.051129
-	}
-
-	// Web Mercator Y formula: R * ln(tan(π/4 + φ/2))
-	// Simplified: y = R * ln((1 + sin(φ)) / (1 - sin(φ))) / 2
-	latRad := lat * math.Pi / 180.0
-	y = Equator * math.Log(math.Tan(math.Pi/4+latRad/2)) / (2 * math.Pi)
-
-	return x, y
+	return tilemath.GEToMeters(row, col, zoom)
 }
 
-func clamp(val, min, max int) int {
-	if val < min {
-		return min
-	}
-	if val > max {
-		return max
-	}
-	return val
+// LatLonToWebMercator converts WGS84 coordinates to Web Mercator (EPSG:3857) meters
+// Latitude is clamped to the valid Web Mercator range
+func LatLonToWebMercator(lat, lon float64) (x, y float64) {
+	return tilemath.LatLonToMeters(lat, lon)
 }
 
 // SubIndex calculation constants
@@ -309,69 +200,24 @@ func (t *Tile) TraversalPaths() []string {
 // WebMercatorTileBounds returns the geographic bounds (lat/lon) for a Web Mercator XYZ tile
 // This is what MapLibre expects each tile to cover
 func WebMercatorTileBounds(x, y, z int) (south, west, north, east float64) {
-	n := float64(int(1) << z)
-
-	// Convert tile edges to normalized coordinates (0-1)
-	west = (float64(x) / n) * 360.0 - 180.0
-	east = (float64(x+1) / n) * 360.0 - 180.0
-
-	// Web Mercator Y: 0 at top (north), increases going south
-	// Convert using inverse Mercator formula
-	northY := float64(y) / n
-	southY := float64(y+1) / n
-
-	// Inverse Web Mercator: lat = atan(sinh(π * (1 - 2*y)))
-	north = math.Atan(math.Sinh(math.Pi*(1-2*northY))) * 180.0 / math.Pi
-	south = math.Atan(math.Sinh(math.Pi*(1-2*southY))) * 180.0 / math.Pi
-
-	return south, west, north, east
+	return tilemath.XYZBounds(x, y, z)
 }
 
 // PixelToLatLon converts a pixel position within a Web Mercator tile to lat/lon
 // px and py are pixel coordinates (0-255), tileSize is typically 256
 func PixelToLatLon(x, y, z, px, py, tileSize int) (lat, lon float64) {
-	n := float64(int(1) << z)
-
-	// Normalized position within the tile (0-1)
-	fracX := (float64(px) + 0.5) / float64(tileSize)
-	fracY := (float64(py) + 0.5) / float64(tileSize)
-
-	// Global normalized position
-	globalX := (float64(x) + fracX) / n
-	globalY := (float64(y) + fracY) / n
-
-	// Convert to lon/lat
-	lon = globalX*360.0 - 180.0
-	lat = math.Atan(math.Sinh(math.Pi*(1-2*globalY))) * 180.0 / math.Pi
-
-	return lat, lon
+	// Pixel centers, as a fraction of the tile grid
+	globalX := float64(x) + (float64(px)+0.5)/float64(tileSize)
+	globalY := float64(y) + (float64(py)+0.5)/float64(tileSize)
+	return tilemath.XYZToLatLon(globalX, globalY, z)
 }
 
 // LatLonToGETilePixel converts a lat/lon to GE tile row/col and pixel position within that tile
 // Returns the tile and the pixel coordinates (0-255) within it
 func LatLonToGETilePixel(lat, lon float64, level, tileSize int) (row, col, px, py int) {
-	numTiles := float64(int(1) << level)
-
-	// GE uses Plate Carrée: both lat and lon map linearly to -180..+180
 	// Note: GE row increases from south (-180) to north (+180)
-	rowF := (lat + 180.0) / 360.0 * numTiles
-	colF := (lon + 180.0) / 360.0 * numTiles
-
-	// Tile indices
-	row = int(rowF)
-	col = int(colF)
-
-	// Clamp to valid range
-	if row < 0 {
-		row = 0
-	} else if row >= int(numTiles) {
-		row = int(numTiles) - 1
-	}
-	if col < 0 {
-		col = 0
-	} else if col >= int(numTiles) {
-		col = int(numTiles) - 1
-	}
+	rowF, colF := tilemath.LatLonToGEFrac(lat, lon, level)
+	row, col = tilemath.LatLonToGE(lat, lon, level)
 
 	// Pixel position within tile
 	px = int((colF - float64(col)) * float64(tileSize))
@@ -407,25 +253,12 @@ func GetRequiredGETiles(x, y, z int) []TileCoord {
 
 // GetGETilesForBounds returns all GE tiles at a given zoom level that cover the specified geographic bounds
 func GetGETilesForBounds(south, west, north, east float64, z int) []TileCoord {
-	numTiles := float64(int(1) << z)
-
-	// Convert bounds to GE row/col range
-	minRow := int((south + 180.0) / 360.0 * numTiles)
-	maxRow := int((north + 180.0) / 360.0 * numTiles)
-	minCol := int((west + 180.0) / 360.0 * numTiles)
-	maxCol := int((east + 180.0) / 360.0 * numTiles)
-
-	// Clamp
-	maxTile := int(numTiles) - 1
-	minRow = clamp(minRow, 0, maxTile)
-	maxRow = clamp(maxRow, 0, maxTile)
-	minCol = clamp(minCol, 0, maxTile)
-	maxCol = clamp(maxCol, 0, maxTile)
+	minRow, minCol, maxRow, maxCol := tilemath.GERange(south, west, north, east, z)
 
 	var tiles []TileCoord
 	for row := minRow; row <= maxRow; row++ {
 		for col := minCol; col <= maxCol; col++ {
-			tiles = append(tiles, TileCoord{Row: row, Column: col, Level: z})
+			tiles = append(tiles, TileCoord{Row: row, Column: tilemath.WrapColumn(col, z), Level: z})
 		}
 	}
 
@@ -447,29 +280,5 @@ func ReprojectToWebMercator(geTiles map[string]image.Image, x, y, z, tileSize in
 // sourceZoom is the zoom level of the source GE tiles
 // tileSize is typically 256
 func ReprojectToWebMercatorWithSourceZoom(geTiles map[string]image.Image, x, y, z, sourceZoom, tileSize int) *image.RGBA {
-	output := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
-
-	for py := 0; py < tileSize; py++ {
-		for px := 0; px < tileSize; px++ {
-			// Get lat/lon for this output pixel (at the output zoom z)
-			lat, lon := PixelToLatLon(x, y, z, px, py, tileSize)
-
-			// Find which GE tile and pixel this corresponds to (at the source zoom)
-			geRow, geCol, gePx, gePy := LatLonToGETilePixel(lat, lon, sourceZoom, tileSize)
-
-			// Look up the source tile
-			key := fmt.Sprintf("%d,%d", geRow, geCol)
-			srcImg, ok := geTiles[key]
-			if !ok {
-				// No tile available, leave transparent
-				continue
-			}
-
-			// Sample the source pixel
-			c := srcImg.At(gePx, gePy)
-			output.Set(px, py, c)
-		}
-	}
-
-	return output
+	return ReprojectToWebMercatorResampled(geTiles, x, y, z, sourceZoom, tileSize, ResampleNearest)
 }
@@ -97,6 +97,12 @@ func (c *Client) GetAvailableDates(tile *Tile) ([]DatedTile, error) {
 		return nil, fmt.Errorf("failed to fetch TimeMachine packet: %w", err)
 	}
 
+	return datesFromPacket(packet, tile)
+}
+
+// datesFromPacket extracts the historical imagery dates of a tile from the TimeMachine
+// packet holding its node
+func datesFromPacket(packet *TimeMachinePacket, tile *Tile) ([]DatedTile, error) {
 	// Find the node for this tile
 	subIndex := GetSubIndex(tile.Path)
 	var targetNode *TimeMachineNode
@@ -247,8 +253,28 @@ func (c *Client) FetchTimeMachinePacket(tile *Tile) (*TimeMachinePacket, error)
 	return packet, nil
 }
 
-// fetchSingleTimeMachinePacket downloads and parses a single TimeMachine protobuf packet
+// fetchSingleTimeMachinePacket downloads and parses a single TimeMachine protobuf packet,
+// through the packet cache when one is set
 func (c *Client) fetchSingleTimeMachinePacket(tile *Tile, epoch int) (*TimeMachinePacket, error) {
+	if c.packetCache == nil {
+		data, err := c.fetchTimeMachinePacketData(tile, epoch)
+		if err != nil {
+			return nil, err
+		}
+		return parseTimeMachinePacketData(data)
+	}
+
+	packet, err := c.packetCache.get(packetKindTimeMachine, tile.Path, epoch,
+		func() ([]byte, error) { return c.fetchTimeMachinePacketData(tile, epoch) },
+		func(data []byte) (any, error) { return parseTimeMachinePacketData(data) })
+	if err != nil {
+		return nil, err
+	}
+	return packet.(*TimeMachinePacket), nil
+}
+
+// fetchTimeMachinePacketData downloads a TimeMachine packet and returns it decrypted and decompressed
+func (c *Client) fetchTimeMachinePacketData(tile *Tile, epoch int) ([]byte, error) {
 	url := fmt.Sprintf(TimeMachinePacketURL, tile.Path, epoch)
 	log.Printf("[TimeMachine] Fetching packet URL: %s", url)
 
@@ -294,10 +320,13 @@ func (c *Client) fetchSingleTimeMachinePacket(tile *Tile, epoch int) (*TimeMachi
 		return nil, fmt.Errorf("failed to decompress TimeMachine packet: %w", err)
 	}
 	log.Printf("[TimeMachine] Decompressed to %d bytes", len(decompressed))
+	return decompressed, nil
+}
 
-	// Parse protobuf
+// parseTimeMachinePacketData parses a decompressed TimeMachine protobuf packet
+func parseTimeMachinePacketData(data []byte) (*TimeMachinePacket, error) {
 	log.Printf("[TimeMachine] Parsing protobuf packet...")
-	packet, err := ParseTimeMachinePacket(decompressed)
+	packet, err := ParseTimeMachinePacket(data)
 	if err != nil {
 		log.Printf("[TimeMachine] Protobuf parsing failed: %v", err)
 		return nil, fmt.Errorf("failed to parse TimeMachine packet: %w", err)
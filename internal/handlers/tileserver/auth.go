@@ -0,0 +1,81 @@
+package tileserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TokenQueryParam is the query parameter that carries the access token for clients that
+// cannot set an Authorization header (e.g. tile layers in GIS software)
+const TokenQueryParam = "token"
+
+// newToken returns a random 256-bit access token
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate tile server token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Token returns the access token required from clients outside this machine
+func (s *Server) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// RegenerateToken replaces the access token, revoking access for clients using the old one
+func (s *Server) RegenerateToken() (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+	return token, nil
+}
+
+// authMiddleware requires the access token on every request that does not come from the
+// loopback interface, so serving other machines (see SetListenAddress) does not open a
+// proxy to the imagery providers. The token is accepted as "Authorization: Bearer <token>" or as the
+// token query parameter.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLoopback(r.RemoteAddr) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provided := r.URL.Query().Get(TokenQueryParam)
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			if bearer, ok := strings.CutPrefix(auth, "Bearer "); ok {
+				provided = strings.TrimSpace(bearer)
+			}
+		}
+
+		token := s.Token()
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="imagery-desktop"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether a request's remote address is on the loopback interface
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
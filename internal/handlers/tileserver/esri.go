@@ -1,6 +1,7 @@
 package tileserver
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,9 @@ import (
 	"imagery-desktop/internal/esri"
 )
 
+// errNoEsriLayer is returned when no Wayback layer matches the requested date
+var errNoEsriLayer = errors.New("no Esri Wayback layer for date")
+
 // handleEsriTile serves Esri Wayback tiles with persistent caching
 // URL format: /esri-wayback/{date}/{z}/{x}/{y}
 // This provides the same caching benefits as Google Earth tile proxy
@@ -43,53 +47,41 @@ func (s *Server) handleEsriTile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check cache first
+	// Cache first, then the Esri API; concurrent requests for the tile share one fetch
 	cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", common.ProviderEsriWayback, z, x, y, date)
-	if cachedData, found := s.tileCache.Get(cacheKey); found {
-		log.Printf("[EsriTileServer] Cache hit: %s", cacheKey)
-		w.Header().Set("Content-Type", "image/jpeg")
-		w.Header().Set("Cache-Control", "public, max-age=31536000") // 1 year cache
-		w.Header().Set("X-Cache-Status", "HIT")
-		w.Write(cachedData)
-		return
-	}
-
-	// Cache miss - fetch from Esri API
-	log.Printf("[EsriTileServer] Cache miss, fetching: date=%s z=%d x=%d y=%d", date, z, x, y)
+	tileData, hit, err := s.tileCache.GetOrFetch(common.ProviderEsriWayback, z, x, y, date, func() ([]byte, error) {
+		log.Printf("[EsriTileServer] Cache miss, fetching: date=%s z=%d x=%d y=%d", date, z, x, y)
 
-	// Find Esri layer for this date
-	layer, err := s.findLayerForDate(date)
-	if err != nil {
+		// Find Esri layer for this date
+		layer, err := s.findLayerForDate(date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errNoEsriLayer, err)
+		}
+		return s.esriClient.FetchTile(layer, &esri.EsriTile{
+			Level:  z,
+			Row:    y,
+			Column: x,
+		})
+	})
+	if errors.Is(err, errNoEsriLayer) {
 		log.Printf("[EsriTileServer] Failed to find layer for date %s: %v", date, err)
 		http.Error(w, fmt.Sprintf("No Esri Wayback layer found for date %s", date), http.StatusNotFound)
 		return
 	}
-
-	// Create Esri tile
-	tile := &esri.EsriTile{
-		Level:  z,
-		Row:    y,
-		Column: x,
-	}
-
-	// Fetch tile from Esri API
-	tileData, err := s.esriClient.FetchTile(layer, tile)
 	if err != nil {
 		log.Printf("[EsriTileServer] Failed to fetch tile: %v", err)
 		// Serve transparent tile on error
-		s.serveTransparentTile(w)
+		s.serveTransparentTile(w, r)
 		return
 	}
 
-	// Cache the tile
-	s.tileCache.Set(common.ProviderEsriWayback, z, x, y, date, tileData)
-	log.Printf("[EsriTileServer] Cached tile: %s", cacheKey)
-
-	// Serve the tile
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.Header().Set("Cache-Control", "public, max-age=31536000") // 1 year cache
-	w.Header().Set("X-Cache-Status", "MISS")
-	w.Write(tileData)
+	if hit {
+		log.Printf("[EsriTileServer] Cache hit: %s", cacheKey)
+		w.Header().Set("X-Cache-Status", "HIT")
+	} else {
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+	serveTile(w, r, tileData, "image/jpeg", cacheImmutable)
 }
 
 // findLayerForDate finds the Esri Wayback layer matching a specific date
@@ -64,10 +64,15 @@ func (s *Server) handleGoogleEarthTile(w http.ResponseWriter, r *http.Request) {
 	// Try at the requested zoom level first, then fall back to lower zooms if tiles aren't available
 	geTiles := make(map[string]image.Image)
 	sourceZoom := z
+	complete := false // All tiles found at the requested zoom
 
 	// Get geographic bounds of the requested Web Mercator tile (fixed for all attempts)
 	south, west, north, east := googleearth.WebMercatorTileBounds(x, y, z)
 
+	fetch := func(tile *googleearth.Tile) ([]byte, error) {
+		return s.fetchCurrentGETile(tile, dateStr)
+	}
+
 	// Try to fetch tiles, with fallback to lower zoom levels
 	for tryZoom := z; tryZoom >= 10 && len(geTiles) == 0; tryZoom-- {
 		// Find GE tiles at tryZoom that cover the same geographic area
@@ -76,54 +81,11 @@ func (s *Server) handleGoogleEarthTile(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		for _, tc := range requiredTiles {
-			tile, err := googleearth.NewTileFromRowCol(tc.Row, tc.Column, tc.Level)
-			if err != nil {
-				continue
-			}
-
-			// Use date from URL for caching
-			var data []byte
-
-			// Check cache first
-			if s.tileCache != nil {
-				cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, dateStr)
-				if cachedData, found := s.tileCache.Get(cacheKey); found {
-					data = cachedData
-					if s.devMode {
-						log.Printf("[Cache HIT] Google Earth tile z=%d x=%d y=%d (date: %s)", tile.Level, tile.Column, tile.Row, dateStr)
-					}
-				}
-			}
-
-			// Fetch from source if not cached
-			if data == nil {
-				data, err = s.geClient.FetchTile(tile)
-				if err != nil {
-					continue
-				}
-
-				if s.devMode {
-					log.Printf("[Cache MISS] Google Earth tile z=%d x=%d y=%d (date: %s) - fetched from network", tile.Level, tile.Column, tile.Row, dateStr)
-				}
-
-				// Cache the result
-				if s.tileCache != nil {
-					s.tileCache.Set(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, dateStr, data)
-				}
-			}
-
-			img, _, err := image.Decode(bytes.NewReader(data))
-			if err != nil {
-				continue
-			}
-
-			key := fmt.Sprintf("%d,%d", tc.Row, tc.Column)
-			geTiles[key] = img
-		}
+		geTiles = s.fetchSourceTiles(requiredTiles, dateStr, fetch)
 
 		if len(geTiles) > 0 {
 			sourceZoom = tryZoom
+			complete = tryZoom == z && len(geTiles) == len(requiredTiles)
 			if tryZoom < z {
 				log.Printf("[GETile] z=%d x=%d y=%d: fell back to zoom %d", z, x, y, tryZoom)
 			}
@@ -135,19 +97,47 @@ func (s *Server) handleGoogleEarthTile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "No tiles available", http.StatusNotFound)
 		return
 	}
+	s.prewarmNeighbors(x, y, z, sourceZoom, dateStr, fetch)
 
 	// Reproject to Web Mercator (using source zoom for tile lookups)
-	output := googleearth.ReprojectToWebMercatorWithSourceZoom(geTiles, x, y, z, sourceZoom, TileSize)
+	s.mu.Lock()
+	resampling := s.resampling
+	s.mu.Unlock()
+	output := googleearth.ReprojectToWebMercatorResampled(geTiles, x, y, z, sourceZoom, TileSize, resampling)
 
-	// Encode as JPEG
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, output, &jpeg.Options{Quality: 90}); err != nil {
+	data, contentType, err := s.encodePreviewTile(w, r, output)
+	if err != nil {
 		http.Error(w, "Failed to encode tile", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.Write(buf.Bytes())
+	cacheControl := cacheDay
+	if !complete {
+		cacheControl = cacheShort // Patched from a lower zoom or missing neighbours
+	}
+	serveTile(w, r, data, contentType, cacheControl)
+}
+
+// fetchCurrentGETile returns a current Google Earth tile from the cache, or fetches and caches it
+func (s *Server) fetchCurrentGETile(tile *googleearth.Tile, date string) ([]byte, error) {
+	if s.tileCache == nil {
+		return s.geClient.FetchTile(tile)
+	}
+
+	data, hit, err := s.tileCache.GetOrFetch(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, func() ([]byte, error) {
+		return s.geClient.FetchTile(tile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.devMode {
+		if hit {
+			log.Printf("[Cache HIT] Google Earth tile z=%d x=%d y=%d (date: %s)", tile.Level, tile.Column, tile.Row, date)
+		} else {
+			log.Printf("[Cache MISS] Google Earth tile z=%d x=%d y=%d (date: %s) - fetched from network", tile.Level, tile.Column, tile.Row, date)
+		}
+	}
+	return data, nil
 }
 
 // handleGoogleEarthHistoricalTile handles requests for historical Google Earth tiles
@@ -198,6 +188,7 @@ func (s *Server) handleGoogleEarthHistoricalTile(w http.ResponseWriter, r *http.
 	// Strategy: Try harder at requested zoom before falling back (epoch fallback happens per tile)
 	geTiles := make(map[string]image.Image)
 	sourceZoom := z
+	complete := false // All tiles found at the requested zoom
 
 	// Get geographic bounds of the requested Web Mercator tile (fixed for all attempts)
 	south, west, north, east := googleearth.WebMercatorTileBounds(x, y, z)
@@ -210,61 +201,23 @@ func (s *Server) handleGoogleEarthHistoricalTile(w http.ResponseWriter, r *http.
 		maxFallback = 6 // More aggressive fallback for lower zooms where coverage is sparser
 	}
 
+	fetch := func(tile *googleearth.Tile) ([]byte, error) {
+		return s.fetchHistoricalGETile(tile, date, hexDate)
+	}
+
 	for tryZoom := z; tryZoom >= max(z-maxFallback, 10) && len(geTiles) == 0; tryZoom-- {
 		// Find GE tiles at tryZoom that cover the same geographic area
 		requiredTiles := googleearth.GetGETilesForBounds(south, west, north, east, tryZoom)
 
 		log.Printf("[GEHistorical] z=%d x=%d y=%d: trying zoom %d, need %d tiles", z, x, y, tryZoom, len(requiredTiles))
 
-		successCount := 0
-		for _, tc := range requiredTiles {
-			tile, err := googleearth.NewTileFromRowCol(tc.Row, tc.Column, tc.Level)
-			if err != nil {
-				log.Printf("[GEHistorical] Failed to create tile from row=%d col=%d level=%d: %v", tc.Row, tc.Column, tc.Level, err)
-				continue
-			}
-
-			// Try cache first
-			var data []byte
-
-			if s.tileCache != nil {
-				// Build cache key using human-readable date for organized cache structure
-				cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date)
-				if cachedData, found := s.tileCache.Get(cacheKey); found {
-					data = cachedData
-					successCount++
-				}
-			}
-
-			// Fetch from source if not cached (with full epoch fallback)
-			if data == nil {
-				data, err = s.fetchHistoricalGETile(tile, date, hexDate)
-				if err != nil {
-					log.Printf("[GEHistorical] Tile %s at zoom %d failed: %v", tile.Path, tryZoom, err)
-					continue
-				}
-
-				// Cache the successful result using OGC ZXY structure with human-readable date
-				if s.tileCache != nil {
-					s.tileCache.Set(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, data)
-				}
-				successCount++
-			}
-
-			img, _, err := image.Decode(bytes.NewReader(data))
-			if err != nil {
-				log.Printf("[GEHistorical] Failed to decode tile %s: %v", tile.Path, err)
-				continue
-			}
-
-			key := fmt.Sprintf("%d,%d", tc.Row, tc.Column)
-			geTiles[key] = img
-		}
+		geTiles = s.fetchSourceTiles(requiredTiles, date, fetch)
 
 		log.Printf("[GEHistorical] z=%d x=%d y=%d: zoom %d got %d/%d tiles", z, x, y, tryZoom, len(geTiles), len(requiredTiles))
 
 		if len(geTiles) > 0 {
 			sourceZoom = tryZoom
+			complete = tryZoom == z && len(geTiles) == len(requiredTiles)
 			if tryZoom < z {
 				log.Printf("[GEHistorical] z=%d x=%d y=%d hexDate=%s: fell back to zoom %d (got %d/%d tiles)",
 					z, x, y, hexDate, tryZoom, len(geTiles), len(requiredTiles))
@@ -275,38 +228,73 @@ func (s *Server) handleGoogleEarthHistoricalTile(w http.ResponseWriter, r *http.
 	}
 
 	if len(geTiles) == 0 {
-		s.serveTransparentTile(w)
+		s.serveTransparentTile(w, r)
 		return
 	}
+	s.prewarmNeighbors(x, y, z, sourceZoom, date, fetch)
 
 	// Reproject to Web Mercator (using source zoom for tile lookups)
-	output := googleearth.ReprojectToWebMercatorWithSourceZoom(geTiles, x, y, z, sourceZoom, TileSize)
+	s.mu.Lock()
+	resampling := s.resampling
+	s.mu.Unlock()
+	output := googleearth.ReprojectToWebMercatorResampled(geTiles, x, y, z, sourceZoom, TileSize, resampling)
 
-	// Encode as JPEG
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, output, &jpeg.Options{Quality: 90}); err != nil {
+	data, contentType, err := s.encodePreviewTile(w, r, output)
+	if err != nil {
 		http.Error(w, "Failed to encode tile", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.Header().Set("Cache-Control", "max-age=86400") // Cache for 24 hours
-	w.Write(buf.Bytes())
+	// A date's imagery never changes, but tiles patched from a lower zoom or missing
+	// neighbours may fill in on a later request
+	cacheControl := cacheImmutable
+	if !complete {
+		cacheControl = cacheShort
+	}
+	serveTile(w, r, data, contentType, cacheControl)
 }
 
 // fetchHistoricalGETile fetches a historical tile for the given GE tile coordinates and hexDate
 // It handles epoch lookup and fallback to nearest date
 // date: human-readable date (YYYY-MM-DD) for cache storage
 // hexDate: hex date for Google API tile fetching
+// Concurrent requests for the same tile (tile server and downloads) share one fetch
 func (s *Server) fetchHistoricalGETile(tile *googleearth.Tile, date, hexDate string) ([]byte, error) {
-	// Check cache first
-	if s.tileCache != nil {
-		cacheKey := fmt.Sprintf("%s:%d:%d:%d:%s", common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date)
-		if cachedData, found := s.tileCache.Get(cacheKey); found {
+	if s.tileCache == nil {
+		return s.resolveHistoricalGETile(tile, hexDate)
+	}
+
+	data, hit, err := s.tileCache.GetOrFetch(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, func() ([]byte, error) {
+		return s.resolveHistoricalGETile(tile, hexDate)
+	})
+	if hit && s.devMode {
+		log.Printf("[Cache HIT] Historical tile %s (date: %s)", tile.Path, date)
+	}
+	return data, err
+}
+
+// resolveHistoricalGETile fetches a historical tile from the network, finding a working
+// epoch through the learned epochs, the tile's TimeMachine dates and known-good epochs
+func (s *Server) resolveHistoricalGETile(tile *googleearth.Tile, hexDate string) ([]byte, error) {
+	// Try the epoch learned from previous fetches in this region first
+	// This skips the TimeMachine lookup and avoids 404 storms on repeat downloads
+	var learnedEpoch int
+	hasLearned := false
+	if s.epochCache != nil {
+		if learned, ok := s.epochCache.Lookup(tile, hexDate); ok {
+			learnedEpoch = learned.Epoch
+			hasLearned = true
+			data, err := s.geClient.FetchHistoricalTile(tile, learned.Epoch, learned.HexDate)
+			if err == nil {
+				if s.devMode {
+					log.Printf("[EpochCache HIT] Tile %s hexDate=%s epoch=%d", tile.Path, hexDate, learned.Epoch)
+				}
+				s.recordWorkingEpoch(tile, hexDate, learned.Epoch, learned.HexDate)
+				return data, nil
+			}
 			if s.devMode {
-				log.Printf("[Cache HIT] Historical tile %s (date: %s)", tile.Path, date)
+				log.Printf("[EpochCache STALE] Tile %s hexDate=%s epoch=%d: %v", tile.Path, hexDate, learned.Epoch, err)
 			}
-			return cachedData, nil
 		}
 	}
 
@@ -361,10 +349,7 @@ func (s *Server) fetchHistoricalGETile(tile *googleearth.Tile, date, hexDate str
 	// Try fetching with the protobuf-reported epoch first
 	data, err := s.geClient.FetchHistoricalTile(tile, epoch, foundHexDate)
 	if err == nil {
-		// Cache the result using human-readable date for OGC compliance
-		if s.tileCache != nil {
-			s.tileCache.Set(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, data)
-		}
+		s.recordWorkingEpoch(tile, hexDate, epoch, foundHexDate)
 		return data, nil
 	}
 
@@ -396,22 +381,14 @@ func (s *Server) fetchHistoricalGETile(tile *googleearth.Tile, date, hexDate str
 	for _, ef := range epochList {
 		data, err := s.geClient.FetchHistoricalTile(tile, ef.epoch, foundHexDate)
 		if err == nil {
-			// Cache the result using human-readable date for OGC compliance
-			if s.tileCache != nil {
-				s.tileCache.Set(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, data)
-			}
+			s.recordWorkingEpoch(tile, hexDate, ef.epoch, foundHexDate)
 			return data, nil
 		}
 	}
 
-	// Last resort: Try known-good epochs for recent dates
-	// These epochs may not be in the protobuf but are known to work from testing
-	// Epochs are ordered newest-first (more likely to have tiles for recent dates):
-	// - 365, 361, 360: 2025+ dates at high zoom levels (17-21)
-	// - 358, 357, 356, 354, 352: 2024 dates
-	// - 321: 2023 dates
-	// - 296, 273: 2020-2022 dates
-	knownGoodEpochs := []int{365, 361, 360, 358, 357, 356, 354, 352, 321, 296, 273}
+	// Last resort: Try the epochs discovered by probing this region (advanced epochs mode),
+	// or the seed epochs known to work in most regions. Neither may be in the protobuf.
+	knownGoodEpochs := s.candidateEpochs(tile)
 	for _, knownEpoch := range knownGoodEpochs {
 		// Skip if already tried
 		if knownEpoch == epoch {
@@ -431,17 +408,43 @@ func (s *Server) fetchHistoricalGETile(tile *googleearth.Tile, date, hexDate str
 		log.Printf("[DEBUG fetchHistoricalGETile] Trying known-good epoch %d...", knownEpoch)
 		data, err := s.geClient.FetchHistoricalTile(tile, knownEpoch, foundHexDate)
 		if err == nil {
-			// Cache the result using human-readable date for OGC compliance
-			if s.tileCache != nil {
-				s.tileCache.Set(common.ProviderGoogleEarth, tile.Level, tile.Column, tile.Row, date, data)
-			}
+			s.recordWorkingEpoch(tile, hexDate, knownEpoch, foundHexDate)
 			return data, nil
 		}
 	}
 
+	// Nothing worked - drop the stale learned epoch so the next request re-probes
+	if hasLearned {
+		s.epochCache.Forget(tile, hexDate)
+		log.Printf("[EpochCache] Forgot stale epoch %d for tile %s hexDate=%s", learnedEpoch, tile.Path, hexDate)
+	}
+
 	return nil, fmt.Errorf("tile not available with any known epoch (tried %d epochs)", len(epochList)+1+len(knownGoodEpochs))
 }
 
+// candidateEpochs returns the epochs to try after a tile's TimeMachine epochs fail: those
+// discovered by probing the tile's region when advanced epochs are enabled and the region
+// has been probed, otherwise the seed epochs
+func (s *Server) candidateEpochs(tile *googleearth.Tile) []int {
+	s.mu.Lock()
+	advanced := s.advancedEpochs
+	s.mu.Unlock()
+	if advanced && s.epochCache != nil {
+		if epochs, ok := s.epochCache.Discovered(tile); ok {
+			return epochs
+		}
+	}
+	return googleearth.SeedEpochs
+}
+
+// recordWorkingEpoch remembers the epoch that served a historical tile for its region
+// hexDate is the requested hexDate, resolvedHexDate the one actually used for the fetch
+func (s *Server) recordWorkingEpoch(tile *googleearth.Tile, hexDate string, epoch int, resolvedHexDate string) {
+	if s.epochCache != nil {
+		s.epochCache.Record(tile, hexDate, epoch, resolvedHexDate)
+	}
+}
+
 // FetchHistoricalGETileWithZoomFallback attempts to fetch a historical tile with automatic zoom fallback
 // If the tile doesn't exist at the requested zoom, it tries lower zoom levels (z-1, z-2, etc.)
 // When using a lower zoom tile, it extracts and upscales the correct portion to match the original tile
@@ -563,11 +566,14 @@ func (s *Server) extractQuadrantFromFallbackTile(data []byte, origRow, origCol,
 		}
 	}
 
-	// Encode back to JPEG
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, dstImg, &jpeg.Options{Quality: 90}); err != nil {
+	// Encode in the tile output format (JPEG unless lossless PNG is selected)
+	s.mu.Lock()
+	output := s.fallbackOut
+	s.mu.Unlock()
+	encoded, _, err := output.EncodeImage(dstImg)
+	if err != nil {
 		return nil, fmt.Errorf("failed to encode extracted quadrant: %w", err)
 	}
 
-	return buf.Bytes(), nil
+	return encoded, nil
 }
@@ -0,0 +1,113 @@
+package tileserver
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"log"
+	"sync"
+
+	"imagery-desktop/internal/googleearth"
+)
+
+const (
+	// subTileWorkers bounds the concurrent source tile fetches of one reprojected tile
+	subTileWorkers = 4
+
+	// prewarmWorkers bounds the background neighbour fetches across all requests
+	prewarmWorkers = 4
+)
+
+// sourceTileFetcher returns the bytes of one Google Earth source tile from the cache, or
+// fetches and caches it (concurrent fetches of a tile are shared, see GetOrFetch)
+type sourceTileFetcher func(tile *googleearth.Tile) ([]byte, error)
+
+// fetchSourceTiles fetches and decodes the source tiles concurrently, keyed "row,col" as
+// ReprojectToWebMercatorWithSourceZoom expects. Tiles that fail are left out.
+func (s *Server) fetchSourceTiles(coords []googleearth.TileCoord, date string, fetch sourceTileFetcher) map[string]image.Image {
+	geTiles := make(map[string]image.Image, len(coords))
+	var mu sync.Mutex
+
+	coordChan := make(chan googleearth.TileCoord, len(coords))
+	var wg sync.WaitGroup
+	for i := 0; i < min(subTileWorkers, len(coords)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tc := range coordChan {
+				tile, err := googleearth.NewTileFromRowCol(tc.Row, tc.Column, tc.Level)
+				if err != nil {
+					log.Printf("[GETile] Failed to create tile from row=%d col=%d level=%d: %v", tc.Row, tc.Column, tc.Level, err)
+					continue
+				}
+
+				data, err := fetch(tile)
+				if err != nil {
+					log.Printf("[GETile] Tile %s (date: %s) failed: %v", tile.Path, date, err)
+					continue
+				}
+
+				img, _, err := image.Decode(bytes.NewReader(data))
+				if err != nil {
+					log.Printf("[GETile] Failed to decode tile %s: %v", tile.Path, err)
+					continue
+				}
+
+				mu.Lock()
+				geTiles[fmt.Sprintf("%d,%d", tc.Row, tc.Column)] = img
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, tc := range coords {
+		coordChan <- tc
+	}
+	close(coordChan)
+	wg.Wait()
+
+	return geTiles
+}
+
+// prewarmNeighbors fetches the source tiles of the eight Web Mercator tiles around x, y, z
+// into the tile cache in the background, so panning finds them ready. Skipped when the
+// pre-warm workers are all busy, as the user has likely moved on by then.
+func (s *Server) prewarmNeighbors(x, y, z, sourceZoom int, date string, fetch sourceTileFetcher) {
+	if s.tileCache == nil || !s.prewarmSem.TryAcquire(1) {
+		return
+	}
+
+	go func() {
+		defer s.prewarmSem.Release(1)
+
+		n := 1 << z
+		seen := make(map[googleearth.TileCoord]bool)
+		for dy := -1; dy <= 1; dy++ {
+			ny := y + dy
+			if ny < 0 || ny >= n {
+				continue
+			}
+			for dx := -1; dx <= 1; dx++ {
+				if dx == 0 && dy == 0 {
+					continue // Just served
+				}
+				nx := ((x+dx)%n + n) % n
+				south, west, north, east := googleearth.WebMercatorTileBounds(nx, ny, z)
+				for _, tc := range googleearth.GetGETilesForBounds(south, west, north, east, sourceZoom) {
+					if seen[tc] {
+						continue
+					}
+					seen[tc] = true
+
+					tile, err := googleearth.NewTileFromRowCol(tc.Row, tc.Column, tc.Level)
+					if err != nil {
+						continue
+					}
+					// Cached tiles return without a network request
+					if _, err := fetch(tile); err != nil && s.devMode {
+						log.Printf("[Prewarm] Tile %s (date: %s) failed: %v", tile.Path, date, err)
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,74 @@
+package tileserver
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"imagery-desktop/internal/providers"
+)
+
+// handleProviderTile serves tiles from any registered XYZ-scheme provider with persistent caching
+// URL format: /tiles/{provider}/{date}/{z}/{x}/{y} (date as returned by the provider's ListDates)
+func (s *Server) handleProviderTile(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/tiles/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) != 5 {
+		http.Error(w, "Invalid URL format. Expected: /tiles/{provider}/{date}/{z}/{x}/{y}", http.StatusBadRequest)
+		return
+	}
+
+	if s.providers == nil {
+		http.Error(w, "Providers not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	provider, date := parts[0], parts[1]
+	source, err := s.providers.Get(provider)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if source.TileScheme() != providers.SchemeXYZ {
+		http.Error(w, fmt.Sprintf("%s does not use the XYZ tile scheme", provider), http.StatusBadRequest)
+		return
+	}
+
+	z, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid zoom level", http.StatusBadRequest)
+		return
+	}
+
+	x, err := strconv.Atoi(parts[3])
+	if err != nil {
+		http.Error(w, "Invalid X coordinate", http.StatusBadRequest)
+		return
+	}
+
+	y, err := strconv.Atoi(parts[4])
+	if err != nil {
+		http.Error(w, "Invalid Y coordinate", http.StatusBadRequest)
+		return
+	}
+
+	// Cache first, then the provider; concurrent requests for the tile share one fetch
+	tileData, hit, err := s.tileCache.GetOrFetch(provider, z, x, y, date, func() ([]byte, error) {
+		return source.FetchTile(z, x, y, providers.Date{Date: date})
+	})
+	if err != nil {
+		log.Printf("[ProviderTileServer] Failed to fetch %s tile z=%d x=%d y=%d: %v", source.Name(), z, x, y, err)
+		s.serveTransparentTile(w, r)
+		return
+	}
+
+	if hit {
+		w.Header().Set("X-Cache-Status", "HIT")
+	} else {
+		w.Header().Set("X-Cache-Status", "MISS")
+	}
+	serveTile(w, r, tileData, http.DetectContentType(tileData), cacheImmutable)
+}
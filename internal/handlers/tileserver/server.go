@@ -2,29 +2,56 @@ package tileserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"imagery-desktop/internal/cache"
+	"imagery-desktop/internal/downloads"
 	"imagery-desktop/internal/esri"
 	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/providers"
+	"imagery-desktop/pkg/webp"
+
+	"golang.org/x/sync/semaphore"
 )
 
 // Server manages the tile server HTTP server
 type Server struct {
-	ctx           context.Context
-	geClient      *googleearth.Client
-	esriClient    *esri.Client
-	esriLayers    []*esri.Layer
-	tileCache     *cache.PersistentTileCache
-	tileServerURL string
-	devMode       bool
+	ctx            context.Context
+	geClient       *googleearth.Client
+	esriClient     *esri.Client
+	esriLayers     []*esri.Layer
+	tileCache      *cache.PersistentTileCache
+	epochCache     *googleearth.EpochCache // Learned working epochs per region (optional)
+	providers      *providers.Registry     // XYZ providers served under /tiles/ (optional)
+	tileServerURL  string
+	listenAddr     string // "" = a random loopback port (see SetListenAddress)
+	devMode        bool
+	httpServer     *http.Server // Set once Start succeeds
+	token          string       // Access token for non-loopback clients (see authMiddleware)
+	webpPreview    bool         // Encode reprojected tiles as WebP for clients that accept it
+	webpQuality    int
+	resampling     googleearth.Resampling // Sampling used when reprojecting GE tiles
+	fallbackOut    downloads.TileOutput   // Encoding of upscaled zoom fallback tiles
+	metricsOn      bool                   // Serve /metrics in the Prometheus text format
+	prewarmSem     *semaphore.Weighted    // Bounds background neighbour pre-warming
+	advancedEpochs bool                   // Try epochs discovered per region instead of the seed epochs
+	mu             sync.Mutex             // Guards httpServer, token and the encoding options (Start runs in the background)
 }
 
 // NewServer creates a new tile server instance
+// A fresh access token is generated for every session
 func NewServer(ctx context.Context, geClient *googleearth.Client, esriClient *esri.Client, esriLayers []*esri.Layer, tileCache *cache.PersistentTileCache, devMode bool) *Server {
+	token, err := newToken()
+	if err != nil {
+		log.Printf("%v; remote tile requests will be refused", err)
+	}
 	return &Server{
 		ctx:        ctx,
 		geClient:   geClient,
@@ -32,6 +59,115 @@ func NewServer(ctx context.Context, geClient *googleearth.Client, esriClient *es
 		esriLayers: esriLayers,
 		tileCache:  tileCache,
 		devMode:    devMode,
+		token:      token,
+		resampling: googleearth.ResampleBilinear,
+		prewarmSem: semaphore.NewWeighted(prewarmWorkers),
+	}
+}
+
+// SetListenAddress sets the address Start listens on. "" keeps the server on a random
+// loopback port; a wildcard address such as ":8765" also serves other machines, which
+// must present the access token (see authMiddleware). Call before Start.
+func (s *Server) SetListenAddress(addr string) error {
+	if err := ValidateListenAddress(addr); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listenAddr = addr
+	s.mu.Unlock()
+	return nil
+}
+
+// ValidateListenAddress checks a tile server listen address: "" or a "host:port" whose
+// host is empty, a wildcard or loopback, so the app itself can always reach the server
+func ValidateListenAddress(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("tile server address %q must be host:port, e.g. \":8765\"", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("tile server address %q has an invalid port", addr)
+	}
+	if host == "" || host == "localhost" {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !(ip.IsUnspecified() || ip.IsLoopback()) {
+		return fmt.Errorf("tile server address %q must listen on all interfaces (e.g. \"0.0.0.0:8765\") or loopback", addr)
+	}
+	return nil
+}
+
+// SetEpochCache sets the learned-epoch cache used for historical Google Earth tiles
+func (s *Server) SetEpochCache(epochCache *googleearth.EpochCache) {
+	s.epochCache = epochCache
+}
+
+// SetProviderRegistry sets the registry used to serve XYZ provider tiles
+func (s *Server) SetProviderRegistry(registry *providers.Registry) {
+	s.providers = registry
+}
+
+// SetWebPPreview enables WebP encoding of reprojected Google Earth tiles for clients
+// sending "Accept: image/webp"; quality <= 0 uses webp.DefaultQuality
+func (s *Server) SetWebPPreview(enabled bool, quality int) {
+	if quality <= 0 {
+		quality = webp.DefaultQuality
+	}
+	s.mu.Lock()
+	s.webpPreview = enabled
+	s.webpQuality = min(quality, 100)
+	s.mu.Unlock()
+}
+
+// SetResampling sets how Google Earth tiles are sampled when reprojected to Web Mercator
+func (s *Server) SetResampling(resampling googleearth.Resampling) {
+	s.mu.Lock()
+	s.resampling = resampling
+	s.mu.Unlock()
+}
+
+// SetAdvancedEpochs makes historical Google Earth fetches fall back to the epochs
+// discovered by probing each region (see Client.ProbeEpochs) instead of the seed epochs
+func (s *Server) SetAdvancedEpochs(enabled bool) {
+	s.mu.Lock()
+	s.advancedEpochs = enabled
+	s.mu.Unlock()
+}
+
+// SetMetricsEndpoint enables /metrics, the per-provider network counters in the
+// Prometheus text format
+func (s *Server) SetMetricsEndpoint(enabled bool) {
+	s.mu.Lock()
+	s.metricsOn = enabled
+	s.mu.Unlock()
+}
+
+// SetFallbackTileOutput sets how upscaled zoom fallback tiles are encoded, so downloads
+// honor the tile output format (the original format encodes as JPEG)
+func (s *Server) SetFallbackTileOutput(output downloads.TileOutput) {
+	s.mu.Lock()
+	s.fallbackOut = output
+	s.mu.Unlock()
+}
+
+// handleMetrics serves the network counters for Prometheus when enabled in settings
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	enabled := s.metricsOn
+	s.mu.Unlock()
+	if !enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := metrics.WritePrometheus(w); err != nil {
+		log.Printf("[Metrics] Failed to write metrics: %v", err)
 	}
 }
 
@@ -47,7 +183,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// Allow all origins (needed for wails://wails on macOS/Linux)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
 
 		// Handle preflight OPTIONS request
 		if r.Method == "OPTIONS" {
@@ -66,28 +202,60 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/google-earth/", s.handleGoogleEarthTile)
 	mux.HandleFunc("/google-earth-historical/", s.handleGoogleEarthHistoricalTile)
 	mux.HandleFunc("/esri-wayback/", s.handleEsriTile)
+	mux.HandleFunc("/tiles/", s.handleProviderTile)
+	mux.HandleFunc("/metrics", s.handleMetrics)
 
-	// Listen on a random available port
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	// Listen on a random available loopback port unless an address is set
+	s.mu.Lock()
+	addr := s.listenAddr
+	s.mu.Unlock()
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("failed to start tile server: %w", err)
 	}
 
+	// The app reaches the server over loopback, so its requests never need the token
 	port := listener.Addr().(*net.TCPAddr).Port
 	s.tileServerURL = fmt.Sprintf("http://127.0.0.1:%d", port)
-	log.Printf("Tile server started on %s", s.tileServerURL)
+	if listenIP := listener.Addr().(*net.TCPAddr).IP; !listenIP.IsLoopback() {
+		log.Printf("Tile server started on %s, also serving other machines on port %d (access token required)", s.tileServerURL, port)
+	} else {
+		log.Printf("Tile server started on %s", s.tileServerURL)
+	}
 
-	// Wrap mux with CORS middleware
+	// Wrap mux with CORS and token middleware (preflight requests carry no credentials)
 	server := &http.Server{
-		Handler: corsMiddleware(mux),
+		Handler: corsMiddleware(s.authMiddleware(mux)),
 	}
+	s.mu.Lock()
+	s.httpServer = server
+	s.mu.Unlock()
 
 	// Start server in goroutine
 	go func() {
-		if err := server.Serve(listener); err != nil {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Printf("Tile server stopped: %v", err)
 		}
 	}()
 
 	return nil
 }
+
+// Shutdown closes the listener and waits for in-flight tile requests until ctx expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	server := s.httpServer
+	s.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		return err
+	}
+	log.Printf("Tile server stopped")
+	return nil
+}
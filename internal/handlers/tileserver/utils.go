@@ -1,11 +1,82 @@
 package tileserver
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
 	"net/http"
+	"strings"
+
+	"imagery-desktop/pkg/webp"
+)
+
+// Cache-Control policies for served tiles
+const (
+	// cacheImmutable is for tiles of a fixed imagery date, which never change
+	cacheImmutable = "public, max-age=31536000, immutable"
+
+	// cacheDay is for current imagery, which the provider may update
+	cacheDay = "public, max-age=86400"
+
+	// cacheShort is for placeholders and degraded tiles worth retrying soon
+	cacheShort = "max-age=3600"
 )
 
+// serveTile writes tile bytes with a strong ETag (hash of the bytes), answering
+// 304 Not Modified when the client already holds the same tile
+func serveTile(w http.ResponseWriter, r *http.Request, data []byte, contentType, cacheControl string) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// encodePreviewTile encodes a reprojected tile as WebP when enabled (see SetWebPPreview) and
+// the client accepts it, otherwise as JPEG quality 90. Returns the bytes and content type.
+func (s *Server) encodePreviewTile(w http.ResponseWriter, r *http.Request, img image.Image) ([]byte, string, error) {
+	s.mu.Lock()
+	enabled, quality := s.webpPreview, s.webpQuality
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
+	if enabled {
+		w.Header().Set("Vary", "Accept")
+		if strings.Contains(r.Header.Get("Accept"), "image/webp") {
+			if err := webp.Encode(&buf, img, &webp.Options{Quality: quality}); err != nil {
+				return nil, "", err
+			}
+			return buf.Bytes(), "image/webp", nil
+		}
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (or is "*").
+// Weak validators match too, as If-None-Match uses weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // serveTransparentTile serves a 256x256 transparent PNG tile for missing data
-func (s *Server) serveTransparentTile(w http.ResponseWriter) {
+func (s *Server) serveTransparentTile(w http.ResponseWriter, r *http.Request) {
 	// 1x1 transparent PNG, scaled by MapLibre to 256x256
 	// This is a minimal valid PNG with transparency
 	transparentPNG := []byte{
@@ -20,7 +91,5 @@ func (s *Server) serveTransparentTile(w http.ResponseWriter) {
 		0x21, 0x00, 0x00, 0x01, 0x9a, 0x60, 0xe1, 0xd5, 0x00, 0x00, 0x00, 0x00,
 		0x49, 0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
 	}
-	w.Header().Set("Content-Type", "image/png")
-	w.Header().Set("Cache-Control", "max-age=3600") // Cache for 1 hour
-	w.Write(transparentPNG)
+	serveTile(w, r, transparentPNG, "image/png", cacheShort)
 }
@@ -0,0 +1,361 @@
+// Package manifest writes and verifies checksummed export manifests: a JSON record of
+// every file in a task's output folder with its SHA-256 hash, the capture metadata of the
+// export and the app version, optionally signed with the user's ed25519 key, so outputs
+// handed over as evidence can later be shown to be unaltered.
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// FileName is the manifest written at the root of an output folder
+	FileName = "manifest.json"
+
+	// SignatureName is the detached base64 ed25519 signature of the manifest file
+	SignatureName = FileName + ".sig"
+
+	// Algorithm is the hash used for files
+	Algorithm = "SHA-256"
+)
+
+// Manifest lists the files of an export with their hashes and how they were captured
+type Manifest struct {
+	App        string  `json:"app"`
+	AppVersion string  `json:"appVersion"`
+	CreatedAt  string  `json:"createdAt"` // RFC 3339, UTC
+	Algorithm  string  `json:"algorithm"`
+	Capture    Capture `json:"capture"`
+	Files      []File  `json:"files"` // Sorted by path
+	Signer     *Signer `json:"signer,omitempty"`
+}
+
+// Capture is the metadata of the export the files came from
+type Capture struct {
+	TaskID   string      `json:"taskId,omitempty"`
+	TaskName string      `json:"taskName,omitempty"`
+	Source   string      `json:"source"`
+	Zoom     int         `json:"zoom"`
+	BBox     BoundingBox `json:"bbox"`
+	Dates    []Date      `json:"dates"`
+	Project  string      `json:"project,omitempty"`
+	Operator string      `json:"operator,omitempty"`
+	Notes    string      `json:"notes,omitempty"`
+}
+
+// BoundingBox is the exported area in WGS84 degrees
+type BoundingBox struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+// Date is one imagery date of the export
+type Date struct {
+	Date    string `json:"date"`
+	Source  string `json:"source,omitempty"` // Provider, for mixed-source exports
+	HexDate string `json:"hexDate,omitempty"`
+	Epoch   int    `json:"epoch,omitempty"`
+}
+
+// File is one file of the export
+type File struct {
+	Path    string `json:"path"` // Relative to the manifest, with forward slashes
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	ModTime string `json:"modTime"`
+}
+
+// Signer identifies the key the manifest is signed with
+type Signer struct {
+	Algorithm   string `json:"algorithm"`   // "ed25519"
+	PublicKey   string `json:"publicKey"`   // Base64
+	Fingerprint string `json:"fingerprint"` // Hex SHA-256 of the public key
+}
+
+// excluded reports whether a file in the output folder is left out of its manifest: the
+// manifest and its signature, task logs (still written after the manifest) and partial
+// files
+func excluded(rel string) bool {
+	switch {
+	case rel == FileName, rel == SignatureName:
+		return true
+	case strings.HasSuffix(rel, ".log"), strings.HasSuffix(rel, ".part"), strings.HasSuffix(rel, ".tmp"):
+		return true
+	}
+	return false
+}
+
+// Build hashes every file under root into a manifest of capture, calling onFile after
+// each file with the number hashed so far and the total
+func Build(root string, capture Capture, appVersion string, onFile func(done, total int)) (*Manifest, error) {
+	paths, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		App:        "imagery-desktop",
+		AppVersion: appVersion,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		Algorithm:  Algorithm,
+		Capture:    capture,
+		Files:      make([]File, 0, len(paths)),
+	}
+	for i, path := range paths {
+		file, err := hashFile(root, path)
+		if err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, file)
+		if onFile != nil {
+			onFile(i+1, len(paths))
+		}
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// listFiles returns the files under root a manifest covers
+func listFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			if rel, _ := filepath.Rel(root, path); !excluded(filepath.ToSlash(rel)) {
+				paths = append(paths, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return paths, nil
+}
+
+func hashFile(root, path string) (File, error) {
+	rel, _ := filepath.Rel(root, path)
+	name := filepath.ToSlash(rel)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return File{}, fmt.Errorf("failed to hash %s: %w", name, err)
+	}
+	return File{
+		Path:    name,
+		Size:    info.Size(),
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		ModTime: info.ModTime().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Write saves m to root, signed with key when one is given (the signer is recorded in
+// the manifest and the signature of the file's exact bytes written next to it)
+func Write(root string, m *Manifest, key ed25519.PrivateKey) error {
+	if key != nil {
+		m.Signer = signerOf(key.Public().(ed25519.PublicKey))
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// A stale signature must not sit next to a new manifest
+	sigPath := filepath.Join(root, SignatureName)
+	if err := os.Remove(sigPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old signature: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, FileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+		if err := os.WriteFile(sigPath, []byte(sig+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write manifest signature: %w", err)
+		}
+	}
+	return nil
+}
+
+func signerOf(pub ed25519.PublicKey) *Signer {
+	return &Signer{
+		Algorithm:   "ed25519",
+		PublicKey:   base64.StdEncoding.EncodeToString(pub),
+		Fingerprint: Fingerprint(pub),
+	}
+}
+
+// Fingerprint returns the hex SHA-256 of a public key, as recorded in signed manifests
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateFingerprint checks that s is a key fingerprint as returned by Fingerprint
+func ValidateFingerprint(s string) error {
+	if b, err := hex.DecodeString(s); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("key fingerprint %q must be %d hex characters", s, 2*sha256.Size)
+	}
+	return nil
+}
+
+// LoadSigningKey reads an ed25519 private key from a PEM file in PKCS #8 form, as written
+// by "openssl genpkey -algorithm ed25519"
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key must be a PEM \"PRIVATE KEY\" (PKCS #8) file")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key must be an ed25519 key")
+	}
+	return key, nil
+}
+
+// Verification is the result of checking an output folder against its manifest
+type Verification struct {
+	Valid     bool     `json:"valid"`   // Every file matches and the signature (if any) is valid and trusted
+	Signed    bool     `json:"signed"`  // A signature was found
+	Trusted   bool     `json:"trusted"` // The signature is valid and made with one of the trusted keys
+	Signer    *Signer  `json:"signer,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+	Files     int      `json:"files"`               // Files listed in the manifest
+	Modified  []string `json:"modified,omitempty"`  // Listed files whose content changed
+	Missing   []string `json:"missing,omitempty"`   // Listed files no longer present
+	Unlisted  []string `json:"unlisted,omitempty"`  // Files added since the manifest was written
+	Signature string   `json:"signature,omitempty"` // Why the signature is invalid
+}
+
+// Verify rehashes the files under root and checks them, and the manifest's signature when
+// there is one, against the manifest. The key named in the manifest proves nothing by
+// itself, as anyone can re-sign an altered folder: a signed manifest is only valid when
+// the key's fingerprint is among trusted.
+func Verify(root string, trusted []string) (*Verification, error) {
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	v := &Verification{Signer: m.Signer, CreatedAt: m.CreatedAt, Files: len(m.Files)}
+	if sig, err := os.ReadFile(filepath.Join(root, SignatureName)); err == nil {
+		v.Signed = true
+		pub, err := verifySignature(m.Signer, data, sig)
+		switch {
+		case err != nil:
+			v.Signature = err.Error()
+		case !isTrusted(Fingerprint(pub), trusted):
+			v.Signature = fmt.Sprintf("signed by untrusted key %s", Fingerprint(pub)[:16])
+		default:
+			v.Trusted = true
+		}
+		if err == nil {
+			// Report the fingerprint of the key that signed, not the one the file claims
+			signer := *m.Signer
+			signer.Fingerprint = Fingerprint(pub)
+			v.Signer = &signer
+		}
+	} else if m.Signer != nil {
+		v.Signed = true
+		v.Signature = "signature file is missing"
+	}
+
+	listed := make(map[string]bool, len(m.Files))
+	for _, want := range m.Files {
+		listed[want.Path] = true
+		path := filepath.Join(root, filepath.FromSlash(want.Path))
+		got, err := hashFile(root, path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			v.Missing = append(v.Missing, want.Path)
+		case err != nil:
+			return nil, err
+		case got.SHA256 != want.SHA256 || got.Size != want.Size:
+			v.Modified = append(v.Modified, want.Path)
+		}
+	}
+
+	current, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range current {
+		rel, _ := filepath.Rel(root, path)
+		if name := filepath.ToSlash(rel); !listed[name] {
+			v.Unlisted = append(v.Unlisted, name)
+		}
+	}
+
+	v.Valid = len(v.Modified) == 0 && len(v.Missing) == 0 && len(v.Unlisted) == 0 && v.Signature == ""
+	return v, nil
+}
+
+// verifySignature checks the signature against the key the manifest names and returns that key
+func verifySignature(signer *Signer, data, sigB64 []byte) (ed25519.PublicKey, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("manifest does not name its signer")
+	}
+	pub, err := base64.StdEncoding.DecodeString(signer.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("malformed signer public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigB64)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("malformed signature")
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return nil, fmt.Errorf("signature is invalid")
+	}
+	return ed25519.PublicKey(pub), nil
+}
+
+// isTrusted reports whether fingerprint is among trusted (compared case-insensitively)
+func isTrusted(fingerprint string, trusted []string) bool {
+	for _, t := range trusted {
+		if strings.EqualFold(strings.TrimSpace(t), fingerprint) {
+			return true
+		}
+	}
+	return false
+}
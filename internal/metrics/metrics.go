@@ -0,0 +1,144 @@
+// Package metrics counts outbound requests and tile cache lookups per provider, so slow
+// or failing downloads can be traced to the source (see GetNetworkStats).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProviderStats is a snapshot of the counters of one provider
+type ProviderStats struct {
+	Provider      string  `json:"provider"`
+	Requests      int64   `json:"requests"`
+	Errors        int64   `json:"errors"`    // Transport failures and error statuses (404, "no imagery", excluded)
+	ErrorRate     float64 `json:"errorRate"` // Errors / Requests
+	BytesReceived int64   `json:"bytesReceived"`
+	AvgLatencyMs  float64 `json:"avgLatencyMs"` // Mean time from request to fully read response
+	CacheHits     int64   `json:"cacheHits"`
+	CacheMisses   int64   `json:"cacheMisses"`
+	CacheHitRatio float64 `json:"cacheHitRatio"` // Hits / (Hits + Misses)
+}
+
+// counters holds the running totals of one provider
+type counters struct {
+	requests    int64
+	errors      int64
+	bytes       int64
+	latency     time.Duration
+	cacheHits   int64
+	cacheMisses int64
+}
+
+var (
+	mu        sync.Mutex
+	providers = make(map[string]*counters)
+)
+
+// get returns the counters of provider, creating them on first use (mu must be held)
+func get(provider string) *counters {
+	c, ok := providers[provider]
+	if !ok {
+		c = &counters{}
+		providers[provider] = c
+	}
+	return c
+}
+
+// RecordRequest counts one completed request to provider
+func RecordRequest(provider string, bytes int64, latency time.Duration, failed bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	c := get(provider)
+	c.requests++
+	c.bytes += bytes
+	c.latency += latency
+	if failed {
+		c.errors++
+	}
+}
+
+// RecordCacheLookup counts a tile cache hit or miss for provider
+func RecordCacheLookup(provider string, hit bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	c := get(provider)
+	if hit {
+		c.cacheHits++
+	} else {
+		c.cacheMisses++
+	}
+}
+
+// Snapshot returns the stats of every provider seen since start (or Reset), sorted by name
+func Snapshot() []ProviderStats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats := make([]ProviderStats, 0, len(providers))
+	for name, c := range providers {
+		s := ProviderStats{
+			Provider:      name,
+			Requests:      c.requests,
+			Errors:        c.errors,
+			BytesReceived: c.bytes,
+			CacheHits:     c.cacheHits,
+			CacheMisses:   c.cacheMisses,
+		}
+		if c.requests > 0 {
+			s.ErrorRate = float64(c.errors) / float64(c.requests)
+			s.AvgLatencyMs = float64(c.latency) / float64(time.Millisecond) / float64(c.requests)
+		}
+		if lookups := c.cacheHits + c.cacheMisses; lookups > 0 {
+			s.CacheHitRatio = float64(c.cacheHits) / float64(lookups)
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
+
+// Reset clears all counters
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = make(map[string]*counters)
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition format
+func WritePrometheus(w io.Writer) error {
+	stats := Snapshot()
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(ProviderStats) float64
+	}{
+		{"imagery_requests_total", "counter", "Outbound requests per provider",
+			func(s ProviderStats) float64 { return float64(s.Requests) }},
+		{"imagery_request_errors_total", "counter", "Failed outbound requests per provider",
+			func(s ProviderStats) float64 { return float64(s.Errors) }},
+		{"imagery_received_bytes_total", "counter", "Response bytes received per provider",
+			func(s ProviderStats) float64 { return float64(s.BytesReceived) }},
+		{"imagery_request_latency_avg_ms", "gauge", "Mean request latency per provider in milliseconds",
+			func(s ProviderStats) float64 { return s.AvgLatencyMs }},
+		{"imagery_cache_hits_total", "counter", "Tile cache hits per provider",
+			func(s ProviderStats) float64 { return float64(s.CacheHits) }},
+		{"imagery_cache_misses_total", "counter", "Tile cache misses per provider",
+			func(s ProviderStats) float64 { return float64(s.CacheMisses) }},
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, s := range stats {
+			if _, err := fmt.Fprintf(w, "%s{provider=%q} %g\n", m.name, s.Provider, m.value(s)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
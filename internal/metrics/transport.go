@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type providerKey struct{}
+
+// WithProvider returns a context that attributes requests made with it to provider,
+// overriding the transport's default (for clients serving several providers)
+func WithProvider(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// NewTransport wraps next so every request is counted under provider (or the provider
+// carried by the request context, see WithProvider)
+func NewTransport(provider string, next http.RoundTripper) http.RoundTripper {
+	return &transport{provider: provider, next: next}
+}
+
+type transport struct {
+	provider string
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider := t.provider
+	if p, ok := req.Context().Value(providerKey{}).(string); ok && p != "" {
+		provider = p
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		RecordRequest(provider, 0, time.Since(start), true)
+		return nil, err
+	}
+
+	// Counted once the body is read or closed, so latency and bytes cover the whole response
+	failed := resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound
+	resp.Body = &countingBody{ReadCloser: resp.Body, provider: provider, start: start, failed: failed}
+	return resp, nil
+}
+
+// countingBody counts the bytes read from a response body and records the request at
+// EOF or Close, whichever comes first
+type countingBody struct {
+	io.ReadCloser
+	provider string
+	start    time.Time
+	failed   bool
+	n        int64
+	once     sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.record()
+	} else if err != nil {
+		b.failed = true
+		b.record()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.record()
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) record() {
+	b.once.Do(func() {
+		RecordRequest(b.provider, b.n, time.Since(b.start), b.failed)
+	})
+}
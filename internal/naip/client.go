@@ -0,0 +1,151 @@
+// Package naip queries the USGS National Map NAIP image service for US aerial imagery by year.
+package naip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/config"
+	"imagery-desktop/internal/metrics"
+	"imagery-desktop/internal/netproxy"
+)
+
+const (
+	// ServiceURL is the USGS National Map NAIP ArcGIS ImageServer
+	ServiceURL = "https://imagery.nationalmap.gov/arcgis/rest/services/USGSNAIPPlus/ImageServer"
+
+	// YearField is the raster catalog attribute holding the acquisition year
+	YearField = "Year"
+
+	// FirstYear is the first year of the NAIP program
+	FirstYear = 2002
+
+	// MinZoom and MaxZoom bound the zoom levels worth requesting (NAIP is 0.3-1 m/px)
+	MinZoom = 8
+	MaxZoom = 18
+)
+
+// Client queries the NAIP raster catalog
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new NAIP client with proxy support
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: metrics.NewTransport(common.ProviderNAIP, netproxy.NewTransport()), // App proxy and TLS settings, counted per provider
+		},
+	}
+}
+
+// queryResponse is the subset of an ImageServer query response we need
+type queryResponse struct {
+	Features []struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	} `json:"features"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// GetAvailableYears returns the NAIP acquisition years covering a WGS84 bounding box,
+// newest first. Areas outside the contiguous US return no years.
+func (c *Client) GetAvailableYears(south, west, north, east float64) ([]int, error) {
+	params := url.Values{}
+	params.Set("geometry", fmt.Sprintf("%f,%f,%f,%f", west, south, east, north))
+	params.Set("geometryType", "esriGeometryEnvelope")
+	params.Set("inSR", "4326")
+	params.Set("spatialRel", "esriSpatialRelIntersects")
+	params.Set("outFields", YearField)
+	params.Set("returnGeometry", "false")
+	params.Set("returnDistinctValues", "true")
+	params.Set("f", "json")
+
+	req, err := http.NewRequest("GET", ServiceURL+"/query?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NAIP catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NAIP catalog query failed with status: %d", resp.StatusCode)
+	}
+
+	var result queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse NAIP catalog response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("NAIP catalog error %d: %s", result.Error.Code, result.Error.Message)
+	}
+
+	seen := make(map[int]bool)
+	var years []int
+	for _, feature := range result.Features {
+		year, ok := parseYear(feature.Attributes[YearField])
+		if ok && !seen[year] {
+			seen[year] = true
+			years = append(years, year)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return years, nil
+}
+
+// parseYear reads a year attribute, which the service may return as a number or string
+func parseYear(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), v >= FirstYear
+	case string:
+		year, err := strconv.Atoi(v)
+		return year, err == nil && year >= FirstYear
+	default:
+		return 0, false
+	}
+}
+
+// Source returns NAIP as a URL-template source for the tile pipeline. Tiles come from
+// exportImage, filtered to one year by the {time} value.
+func Source() config.CustomSource {
+	// mosaicRule={"mosaicMethod":"esriMosaicAttribute","sortField":"Year","where":"Year={time}"}
+	// (JSON pre-encoded so only the {time} placeholder is substituted)
+	mosaicRule := "%7B%22mosaicMethod%22%3A%22esriMosaicAttribute%22%2C%22sortField%22%3A%22" + YearField +
+		"%22%2C%22where%22%3A%22" + YearField + "%3D{time}%22%7D"
+
+	return config.CustomSource{
+		Name: common.DisplayNameNAIP,
+		Type: "wms",
+		URL: ServiceURL + "/exportImage?bbox={bbox}&bboxSR=3857&imageSR=3857&size=256,256" +
+			"&format=jpgpng&f=image&mosaicRule=" + mosaicRule,
+		Attribution: "USDA National Agriculture Imagery Program (NAIP), USGS The National Map",
+		MinZoom:     MinZoom,
+		MaxZoom:     MaxZoom,
+		Times:       Years(),
+		Enabled:     true,
+	}
+}
+
+// Years returns every NAIP program year up to the current year as date labels, newest first
+func Years() []string {
+	var years []string
+	for year := time.Now().Year(); year >= FirstYear; year-- {
+		years = append(years, strconv.Itoa(year))
+	}
+	return years
+}
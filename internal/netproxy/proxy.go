@@ -0,0 +1,120 @@
+// Package netproxy holds the proxy and TLS configuration shared by every outbound HTTP transport.
+package netproxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	mu       sync.RWMutex
+	proxyURL *url.URL // nil = use environment proxies
+	bypass   []string
+)
+
+// Configure sets an explicit proxy for all clients. An empty rawURL restores the default
+// of honoring HTTP_PROXY/HTTPS_PROXY/NO_PROXY from the environment.
+// rawURL may be http://, https:// or socks5:// host:port; username and password (if set)
+// override any credentials in the URL. bypass is a comma-separated list of hosts,
+// domains (".example.com" or "*.example.com" match subdomains), IPs or CIDR ranges.
+func Configure(rawURL, username, password, bypassList string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		mu.Lock()
+		proxyURL = nil
+		bypass = nil
+		mu.Unlock()
+		return nil
+	}
+
+	u, err := Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		u.User = url.UserPassword(username, password)
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(bypassList, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	mu.Lock()
+	proxyURL = u
+	bypass = entries
+	mu.Unlock()
+	return nil
+}
+
+// Parse validates a proxy URL
+func Parse(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("proxy URL must include a host and port")
+	}
+	return u, nil
+}
+
+// Func is the Proxy function for http.Transport. It reads the current configuration on
+// every request, so settings changes apply without rebuilding clients.
+func Func(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	u, entries := proxyURL, bypass
+	mu.RUnlock()
+
+	if u == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	if shouldBypass(req.URL.Hostname(), entries) {
+		return nil, nil
+	}
+	return u, nil
+}
+
+// shouldBypass reports whether host matches a bypass entry (loopback is always direct)
+func shouldBypass(host string, entries []string) bool {
+	host = strings.ToLower(host)
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "/"):
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry, "*."), strings.HasPrefix(entry, "."):
+			suffix := strings.TrimPrefix(entry, "*")
+			if strings.HasSuffix(host, suffix) || host == suffix[1:] {
+				return true
+			}
+		default:
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
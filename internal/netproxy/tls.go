@@ -0,0 +1,98 @@
+package netproxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	tlsMu     sync.RWMutex
+	tlsConfig *tls.Config     // nil = system roots with full verification
+	transport *http.Transport // Shared by every client from NewTransport
+)
+
+// ConfigureTLS sets the TLS trust used by all clients. caFile is an optional PEM bundle
+// added to the system roots (for TLS-intercepting corporate proxies); insecure disables
+// certificate verification entirely and should only be used as a last resort.
+func ConfigureTLS(caFile string, insecure bool) error {
+	caFile = strings.TrimSpace(caFile)
+
+	var cfg *tls.Config
+	if caFile != "" || insecure {
+		cfg = &tls.Config{InsecureSkipVerify: insecure}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	tlsMu.Lock()
+	old := transport
+	tlsConfig = cfg
+	transport = nil
+	tlsMu.Unlock()
+
+	// Drop pooled connections made with the previous trust settings
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// TLSConfig returns a copy of the current TLS configuration (nil = defaults)
+func TLSConfig() *tls.Config {
+	tlsMu.RLock()
+	defer tlsMu.RUnlock()
+	if tlsConfig == nil {
+		return nil
+	}
+	return tlsConfig.Clone()
+}
+
+// NewTransport returns a RoundTripper that uses the shared proxy and TLS configuration.
+// TLS changes swap the underlying transport, so clients never need to be rebuilt.
+func NewTransport() http.RoundTripper {
+	return roundTripper{}
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return currentTransport().RoundTrip(req)
+}
+
+// currentTransport returns the shared transport, building it for the current TLS settings
+func currentTransport() *http.Transport {
+	tlsMu.RLock()
+	t := transport
+	tlsMu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	tlsMu.Lock()
+	defer tlsMu.Unlock()
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = Func
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.Clone()
+		}
+	}
+	return transport
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"fmt"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/downloads"
+	esriDownloads "imagery-desktop/internal/downloads/esri"
+	"imagery-desktop/internal/esri"
+)
+
+// EsriProvider serves Esri World Imagery Wayback releases
+type EsriProvider struct {
+	client     *esri.Client
+	downloader *esriDownloads.Downloader // Area sampling for ListDates
+}
+
+// NewEsriProvider creates the Esri Wayback provider
+func NewEsriProvider(client *esri.Client, downloader *esriDownloads.Downloader) *EsriProvider {
+	return &EsriProvider{client: client, downloader: downloader}
+}
+
+func (p *EsriProvider) ID() string             { return common.ProviderEsriWayback }
+func (p *EsriProvider) Name() string           { return common.DisplayNameEsriWayback }
+func (p *EsriProvider) TileScheme() TileScheme { return SchemeXYZ }
+
+func (p *EsriProvider) Attribution() string {
+	return "Esri, Maxar, Earthstar Geographics, and the GIS User Community"
+}
+
+func (p *EsriProvider) ZoomRange() (int, int) {
+	return downloads.MinZoom, downloads.MaxZoomEsri
+}
+
+// ListDates samples a grid of tiles across bbox and returns the release (layer) dates
+// with local changes. Layer dates, not capture dates, are what FetchTile needs.
+func (p *EsriProvider) ListDates(bbox downloads.BoundingBox, zoom int) ([]Date, error) {
+	layerDates, err := p.downloader.GetAvailableDatesForArea(bbox, zoom)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]Date, len(layerDates))
+	for i, date := range layerDates {
+		dates[i] = Date{Date: date}
+	}
+	return dates, nil
+}
+
+// FetchTile downloads an XYZ tile from the Wayback release published on date.Date
+func (p *EsriProvider) FetchTile(z, x, y int, date Date) ([]byte, error) {
+	layer, err := p.findLayer(date.Date)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.FetchTile(layer, &esri.EsriTile{Level: z, Row: y, Column: x})
+}
+
+// findLayer finds the Wayback release for a layer date (YYYY-MM-DD)
+func (p *EsriProvider) findLayer(date string) (*esri.Layer, error) {
+	layers, err := p.client.GetLayers()
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		if layer.Date.Format("2006-01-02") == date {
+			return layer, nil
+		}
+	}
+	return nil, fmt.Errorf("no layer found for date: %s", date)
+}
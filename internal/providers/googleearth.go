@@ -0,0 +1,249 @@
+package providers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/googleearth"
+	"imagery-desktop/internal/tilemath"
+)
+
+// GoogleEarthProvider serves Google Earth current and historical imagery
+type GoogleEarthProvider struct {
+	client *googleearth.Client
+}
+
+// NewGoogleEarthProvider creates the Google Earth provider
+func NewGoogleEarthProvider(client *googleearth.Client) *GoogleEarthProvider {
+	return &GoogleEarthProvider{client: client}
+}
+
+func (p *GoogleEarthProvider) ID() string             { return common.ProviderGoogleEarth }
+func (p *GoogleEarthProvider) Name() string           { return common.DisplayNameGoogleEarth }
+func (p *GoogleEarthProvider) Attribution() string    { return "Google Earth" }
+func (p *GoogleEarthProvider) TileScheme() TileScheme { return SchemeGoogleEarth }
+
+func (p *GoogleEarthProvider) ZoomRange() (int, int) {
+	return downloads.MinZoom, downloads.MaxZoomGoogleEarth
+}
+
+const (
+	// minDateSampleGrid and maxDateSampleGrid bound the points per side sampled by ListDates;
+	// the grid grows with the area, and areas spanning fewer tiles have every tile sampled
+	minDateSampleGrid = 8
+	maxDateSampleGrid = 16
+
+	// maxThoroughScanTiles caps the tiles resolved by a thorough scan
+	maxThoroughScanTiles = 4096
+
+	// minDateCoverage is the share of sampled tiles (percent) a date must cover to be listed
+	// by the default scan
+	minDateCoverage = 60
+)
+
+// DateScanOptions controls how densely ScanDates samples an area
+type DateScanOptions struct {
+	// Thorough samples every Stride-th tile in each direction instead of an adaptive grid,
+	// and lists every date found however little of the area it covers
+	Thorough bool
+	Stride   int // Tile step of a thorough scan (0 or 1 = every tile)
+}
+
+// ListDates returns historical imagery dates for an area.
+// This samples multiple tiles across the viewport to ensure returned dates are available
+// at the current zoom level and location - critical for zoom levels 17-19 where date
+// availability varies significantly between tiles
+func (p *GoogleEarthProvider) ListDates(bbox downloads.BoundingBox, zoom int) ([]Date, error) {
+	return p.ScanDates(bbox, zoom, DateScanOptions{})
+}
+
+// ScanDates returns historical imagery dates for an area with the share of sampled tiles
+// each date covers (Coverage, percent), newest first
+func (p *GoogleEarthProvider) ScanDates(bbox downloads.BoundingBox, zoom int, opts DateScanOptions) ([]Date, error) {
+	// IMPORTANT: Sample at zoom 16 to get stable, reliable epoch values
+	// At zoom 17-19, the protobuf reports newer epochs (like 359) that don't have actual tiles
+	// Zoom 16 provides epochs (like 358) that work across ALL zoom levels including 17-19
+	// This is critical for 2025+ dates where high zoom epochs in protobuf are incorrect
+	sampleZoom := 16
+	if zoom < 16 {
+		sampleZoom = zoom // Use requested zoom if it's lower than 16
+	}
+	log.Printf("[GEDates] Sampling at zoom %d for epoch stability (requested zoom: %d)", sampleZoom, zoom)
+
+	sampleTiles, err := dateSampleTiles(bbox, sampleZoom, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[GEDates] Sampling %d tiles at zoom %d (thorough: %v)", len(sampleTiles), sampleZoom, opts.Thorough)
+
+	// Resolve all sample tiles in one pass sharing their parent packets
+	results, err := p.client.GetAvailableDatesForTiles(sampleTiles)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect dates from all sample tiles
+	allDatesMap := make(map[string]map[string]Date) // hexDate -> tileID -> date info
+	tileSampleCount := 0
+
+	for _, result := range results {
+		if result.Err != nil {
+			log.Printf("[GEDates] Failed to get dates for tile %s: %v", result.Tile.Path, result.Err)
+			continue
+		}
+
+		tileSampleCount++
+		tileID := result.Tile.Path
+
+		// Add this tile's dates to the map
+		for _, dt := range result.Dates {
+			if allDatesMap[dt.HexDate] == nil {
+				allDatesMap[dt.HexDate] = make(map[string]Date)
+			}
+			allDatesMap[dt.HexDate][tileID] = Date{
+				Date:    dt.Date.Format("2006-01-02"),
+				Epoch:   dt.Epoch,
+				HexDate: dt.HexDate,
+			}
+		}
+	}
+
+	if tileSampleCount == 0 {
+		return nil, fmt.Errorf("failed to sample any tiles in the area")
+	}
+
+	// The default scan keeps dates that appear in at least 60% of sampled tiles
+	// This ensures good coverage while allowing for some tile variation
+	minCoverage := float64(minDateCoverage)
+	if opts.Thorough {
+		minCoverage = 0
+	}
+	dates := collectDates(allDatesMap, tileSampleCount, minCoverage)
+	if len(dates) == 0 {
+		log.Printf("[GEDates] No common dates found across sampled tiles - showing all available dates")
+		// Fallback: show all dates if filtering is too strict
+		dates = collectDates(allDatesMap, tileSampleCount, 0)
+	}
+
+	// Sort dates newest first so index 0 is the latest
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Date > dates[j].Date
+	})
+
+	log.Printf("[GEDates] Found %d dates available across viewport (sampled at zoom %d, requested zoom %d)", len(dates), sampleZoom, zoom)
+	return dates, nil
+}
+
+// collectDates returns the dates covering at least minCoverage percent of the sampled tiles,
+// each with the epoch most tiles report for it.
+// Different tiles may report different epochs for the same date.
+func collectDates(allDatesMap map[string]map[string]Date, tileSampleCount int, minCoverage float64) []Date {
+	var dates []Date
+	seen := make(map[string]bool)
+
+	for hexDate, tilesWithDate := range allDatesMap {
+		coverage := 100 * float64(len(tilesWithDate)) / float64(tileSampleCount)
+		if coverage < minCoverage {
+			continue
+		}
+
+		epochCounts := make(map[int]int)
+		var sampleDateInfo Date
+		for _, dateInfo := range tilesWithDate {
+			epochCounts[dateInfo.Epoch]++
+			sampleDateInfo = dateInfo // Keep one for the date string
+		}
+
+		// Use the most frequently occurring epoch (lowest on ties, for stable results)
+		bestEpoch := sampleDateInfo.Epoch
+		maxCount := 0
+		for epoch, count := range epochCounts {
+			if count > maxCount || (count == maxCount && epoch < bestEpoch) {
+				maxCount = count
+				bestEpoch = epoch
+			}
+		}
+
+		if !seen[sampleDateInfo.Date] {
+			seen[sampleDateInfo.Date] = true
+			dates = append(dates, Date{
+				Date:     sampleDateInfo.Date,
+				Epoch:    bestEpoch,
+				HexDate:  hexDate,
+				Coverage: coverage,
+			})
+			log.Printf("[GEDates] Date %s (hex: %s, epoch: %d) available in %d/%d tiles (epoch used by %d tiles)",
+				sampleDateInfo.Date, hexDate, bestEpoch, len(tilesWithDate), tileSampleCount, maxCount)
+		}
+	}
+	return dates
+}
+
+// dateSampleTiles returns the tiles at level sampled across bbox. Areas spanning up to
+// minDateSampleGrid tiles per side are sampled completely; larger areas get a grid of
+// points that grows with the tile count up to maxDateSampleGrid per side. A thorough scan
+// takes every Stride-th tile instead.
+func dateSampleTiles(bbox downloads.BoundingBox, level int, opts DateScanOptions) ([]*googleearth.Tile, error) {
+	minRow, minCol, maxRow, maxCol := tilemath.GERange(bbox.South, bbox.West, bbox.North, bbox.East, level)
+	rows, cols := maxRow-minRow+1, maxCol-minCol+1
+
+	if opts.Thorough {
+		stride := max(opts.Stride, 1)
+		count := ((rows + stride - 1) / stride) * ((cols + stride - 1) / stride)
+		if count > maxThoroughScanTiles {
+			return nil, fmt.Errorf("thorough scan would sample %d tiles (max %d); increase the stride or select a smaller area", count, maxThoroughScanTiles)
+		}
+		tiles := make([]*googleearth.Tile, 0, count)
+		for row := minRow; row <= maxRow; row += stride {
+			for col := minCol; col <= maxCol; col += stride {
+				// Columns past the east edge belong to an antimeridian-crossing box
+				tile, err := googleearth.NewTileFromRowCol(row, tilemath.WrapColumn(col, level), level)
+				if err != nil {
+					return nil, err
+				}
+				tiles = append(tiles, tile)
+			}
+		}
+		return tiles, nil
+	}
+
+	if rows <= minDateSampleGrid && cols <= minDateSampleGrid {
+		return googleearth.GetTilesInBounds(bbox.South, bbox.West, bbox.North, bbox.East, level)
+	}
+
+	// About one point per 4x4 block of tiles, within the grid bounds
+	grid := int(math.Ceil(math.Sqrt(float64(rows)*float64(cols)) / 4))
+	grid = min(max(grid, minDateSampleGrid), maxDateSampleGrid)
+
+	sampled := make(map[string]bool)
+	var tiles []*googleearth.Tile
+	for row := 0; row < grid; row++ {
+		lat := bbox.North - (bbox.North-bbox.South)*(float64(row)+0.5)/float64(grid)
+		for col := 0; col < grid; col++ {
+			tile, err := googleearth.GetTileForCoord(lat, bbox.LonAt((float64(col)+0.5)/float64(grid)), level)
+			if err != nil || sampled[tile.Path] {
+				continue
+			}
+			sampled[tile.Path] = true
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles, nil
+}
+
+// FetchTile downloads a Google Earth tile (row 0 at the south edge). An empty HexDate
+// fetches current imagery; otherwise the historical tile for Epoch/HexDate is returned.
+func (p *GoogleEarthProvider) FetchTile(z, x, y int, date Date) ([]byte, error) {
+	tile, err := googleearth.NewTileFromRowCol(y, x, z)
+	if err != nil {
+		return nil, err
+	}
+	if date.HexDate == "" {
+		return p.client.FetchTile(tile)
+	}
+	return p.client.FetchHistoricalTile(tile, date.Epoch, date.HexDate)
+}
@@ -0,0 +1,114 @@
+// Package providers defines the ImageryProvider interface shared by every imagery source,
+// so the tile server, downloaders and app bindings handle new sources through one code path.
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/customsource"
+	"imagery-desktop/internal/downloads"
+)
+
+// TileScheme identifies how a provider addresses its tiles
+type TileScheme string
+
+const (
+	// SchemeXYZ is the Web Mercator XYZ grid (EPSG:3857, row 0 at the north edge)
+	SchemeXYZ TileScheme = "xyz"
+
+	// SchemeGoogleEarth is the Google Earth Plate Carrée quadtree (row 0 at the south edge)
+	SchemeGoogleEarth TileScheme = "google_earth"
+)
+
+// Date identifies one imagery date of a provider. HexDate and Epoch are only used by
+// Google Earth historical imagery; other providers only need Date.
+type Date = downloads.GEDateInfo
+
+// ImageryProvider is an imagery source that can list dates for an area and fetch tiles
+type ImageryProvider interface {
+	// ID is the identifier used in tasks, cache keys and filenames (e.g. "esri_wayback")
+	ID() string
+
+	// Name is the human-readable name shown in the UI
+	Name() string
+
+	// Attribution is the credit line required by the provider
+	Attribution() string
+
+	// TileScheme is the grid the z/x/y arguments of FetchTile are expressed in
+	TileScheme() TileScheme
+
+	// ZoomRange returns the shallowest and deepest zoom levels served
+	ZoomRange() (minZoom, maxZoom int)
+
+	// ListDates returns the dates with imagery over bbox at zoom, newest first
+	ListDates(bbox downloads.BoundingBox, zoom int) ([]Date, error)
+
+	// FetchTile downloads the encoded image of a tile for a date
+	FetchTile(z, x, y int, date Date) ([]byte, error)
+}
+
+// Registry holds the available providers. Custom and API-key sources are resolved
+// from the template client on each lookup, so settings changes apply immediately.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]ImageryProvider
+	templates *customsource.Client
+}
+
+// NewRegistry creates a registry; templates may be nil when no URL-template sources are used
+func NewRegistry(templates *customsource.Client) *Registry {
+	return &Registry{
+		providers: make(map[string]ImageryProvider),
+		templates: templates,
+	}
+}
+
+// Register adds or replaces a provider
+func (r *Registry) Register(p ImageryProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.ID()] = p
+}
+
+// Get returns the provider with the given ID
+func (r *Registry) Get(id string) (ImageryProvider, error) {
+	r.mu.RLock()
+	p, ok := r.providers[id]
+	r.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	if r.templates != nil && common.IsTemplateProvider(id) {
+		if _, err := r.templates.Get(id); err != nil {
+			return nil, err
+		}
+		return &TemplateProvider{id: id, client: r.templates}, nil
+	}
+	return nil, fmt.Errorf("unknown provider: %s", id)
+}
+
+// All returns every available provider sorted by ID
+func (r *Registry) All() []ImageryProvider {
+	r.mu.RLock()
+	all := make([]ImageryProvider, 0, len(r.providers))
+	for _, p := range r.providers {
+		all = append(all, p)
+	}
+
+	if r.templates != nil {
+		for id := range r.templates.Sources() {
+			if _, registered := r.providers[id]; !registered {
+				all = append(all, &TemplateProvider{id: id, client: r.templates})
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID() < all[j].ID() })
+	return all
+}
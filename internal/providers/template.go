@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"strconv"
+
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/customsource"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/naip"
+)
+
+// TemplateProvider serves a custom or API-key source from its URL template.
+// The source is looked up on every call so settings changes apply immediately.
+type TemplateProvider struct {
+	id     string
+	client *customsource.Client
+}
+
+func (p *TemplateProvider) ID() string             { return p.id }
+func (p *TemplateProvider) TileScheme() TileScheme { return SchemeXYZ }
+
+func (p *TemplateProvider) Name() string {
+	source, err := p.client.Get(p.id)
+	if err != nil {
+		return p.id
+	}
+	return source.Name
+}
+
+func (p *TemplateProvider) Attribution() string {
+	source, _ := p.client.Get(p.id)
+	return source.Attribution
+}
+
+func (p *TemplateProvider) ZoomRange() (int, int) {
+	source, err := p.client.Get(p.id)
+	if err != nil {
+		return 0, customsource.DefaultMaxZoom
+	}
+	return source.MinZoom, customsource.MaxZoom(source)
+}
+
+// ListDates returns the source's time values (or customsource.NoTime) regardless of area
+func (p *TemplateProvider) ListDates(bbox downloads.BoundingBox, zoom int) ([]Date, error) {
+	source, err := p.client.Get(p.id)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := customsource.Dates(source)
+	dates := make([]Date, len(labels))
+	for i, label := range labels {
+		dates[i] = Date{Date: label}
+	}
+	return dates, nil
+}
+
+// FetchTile downloads an XYZ tile for the time value date.Date
+func (p *TemplateProvider) FetchTile(z, x, y int, date Date) ([]byte, error) {
+	source, err := p.client.Get(p.id)
+	if err != nil {
+		return nil, err
+	}
+	return p.client.FetchTile(p.id, source, z, x, y, date.Date)
+}
+
+// NAIPProvider serves USDA NAIP through its URL template, listing only the years
+// that actually cover the requested area
+type NAIPProvider struct {
+	TemplateProvider
+	catalog *naip.Client
+}
+
+// NewNAIPProvider creates the NAIP provider; the NAIP template must be registered in client
+func NewNAIPProvider(client *customsource.Client, catalog *naip.Client) *NAIPProvider {
+	return &NAIPProvider{
+		TemplateProvider: TemplateProvider{id: common.ProviderNAIP, client: client},
+		catalog:          catalog,
+	}
+}
+
+// ListDates queries the NAIP catalog for the years covering bbox
+func (p *NAIPProvider) ListDates(bbox downloads.BoundingBox, zoom int) ([]Date, error) {
+	years, err := p.catalog.GetAvailableYears(bbox.South, bbox.West, bbox.North, bbox.East)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]Date, len(years))
+	for i, year := range years {
+		dates[i] = Date{Date: strconv.Itoa(year)}
+	}
+	return dates, nil
+}
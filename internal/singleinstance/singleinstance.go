@@ -0,0 +1,257 @@
+// Package singleinstance keeps a single app instance running per user. The first instance
+// creates a lock file in the user's app directory naming the loopback address it listens on
+// and a random token; a later instance finds the file, hands its launch arguments to that
+// address with the token and exits, so two instances never share the task queue files or
+// the tile cache. The file is readable only by its owner, so other users on the machine can
+// neither find nor drive the instance.
+package singleinstance
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockFileName is the file in the app directory that marks the running instance
+const LockFileName = "instance.lock"
+
+// handoffTimeout bounds every read and write of a handoff
+const handoffTimeout = 3 * time.Second
+
+// acquireAttempts is how often Acquire retries after removing a stale lock file, or when
+// the file is still being written by an instance starting at the same time
+const acquireAttempts = 3
+
+// ErrAlreadyRunning is returned by Acquire after the arguments were handed to the running instance
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// LaunchData is what a second instance hands over
+type LaunchData struct {
+	Token            string   `json:"token"`            // Secret from the lock file, proving the sender is the same user
+	Args             []string `json:"args"`             // Command-line arguments (without the program name)
+	WorkingDirectory string   `json:"workingDirectory"` // For resolving relative paths in Args
+}
+
+// lockInfo is the content of the lock file
+type lockInfo struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+	PID   int    `json:"pid"`
+}
+
+// Lock is held by the running instance
+type Lock struct {
+	listener net.Listener
+	path     string
+	token    string
+
+	mu       sync.Mutex
+	onLaunch func(LaunchData)
+	pending  []LaunchData // Launches received before a handler was set
+}
+
+// Acquire takes the single-instance lock in dir, the user's app directory. If another
+// instance holds it, the current process's arguments are handed to that instance and
+// ErrAlreadyRunning is returned. A lock file nobody answers on is left by a crashed
+// instance and is replaced. Any other error means single-instance enforcement is
+// unavailable and the caller may carry on without it.
+func Acquire(dir string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, LockFileName)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	token, err := newToken()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	info := lockInfo{Addr: listener.Addr().String(), Token: token, PID: os.Getpid()}
+
+	var handErr error
+	for attempt := 0; attempt < acquireAttempts; attempt++ {
+		created, err := createLockFile(path, info)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		if created {
+			l := &Lock{listener: listener, path: path, token: token}
+			go l.serve()
+			return l, nil
+		}
+
+		running, err := readLockFile(path)
+		if err != nil {
+			// An instance starting right now may not have written the file yet; give it
+			// a moment before treating the file as stale
+			handErr = err
+			if attempt == 0 {
+				time.Sleep(100 * time.Millisecond)
+			} else {
+				os.Remove(path)
+			}
+			continue
+		}
+		wd, _ := os.Getwd()
+		handErr = handOff(running, LaunchData{Token: running.Token, Args: os.Args[1:], WorkingDirectory: wd})
+		if handErr == nil {
+			listener.Close()
+			return nil, ErrAlreadyRunning
+		}
+		log.Printf("[SingleInstance] Instance in %s did not answer, replacing its lock: %v", path, handErr)
+		os.Remove(path)
+	}
+	listener.Close()
+	return nil, fmt.Errorf("cannot take %s and no instance answered: %w", path, handErr)
+}
+
+// newToken returns the random secret a second instance must present
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sameToken compares tokens in constant time
+func sameToken(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// createLockFile creates the lock file only if none exists, readable by the owner alone.
+// created is false when another instance's file is already there.
+func createLockFile(path string, info lockInfo) (created bool, err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.NewEncoder(f).Encode(info); err != nil {
+		f.Close()
+		os.Remove(path)
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return false, err
+	}
+	return true, nil
+}
+
+// readLockFile reads the running instance's address and token
+func readLockFile(path string) (lockInfo, error) {
+	var info lockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("invalid lock file: %w", err)
+	}
+	if info.Addr == "" || info.Token == "" {
+		return info, errors.New("incomplete lock file")
+	}
+	return info, nil
+}
+
+// handOff sends data to the running instance and waits for its acknowledgement
+func handOff(running lockInfo, data LaunchData) error {
+	conn, err := net.DialTimeout("tcp", running.Addr, handoffTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoffTimeout))
+
+	if err := json.NewEncoder(conn).Encode(data); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !sameToken(strings.TrimSpace(reply), running.Token) {
+		return fmt.Errorf("unexpected reply from %s", running.Addr)
+	}
+	return nil
+}
+
+// OnLaunch sets the function called for each later launch. Launches received before it
+// was set are delivered immediately.
+func (l *Lock) OnLaunch(onLaunch func(LaunchData)) {
+	l.mu.Lock()
+	l.onLaunch = onLaunch
+	pending := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	for _, data := range pending {
+		onLaunch(data)
+	}
+}
+
+// Close releases the lock, removing the lock file unless another instance replaced it
+func (l *Lock) Close() error {
+	if running, err := readLockFile(l.path); err == nil && running.Token == l.token {
+		os.Remove(l.path)
+	}
+	return l.listener.Close()
+}
+
+// serve accepts handoffs until the lock is closed
+func (l *Lock) serve() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("[SingleInstance] Stopped accepting launches: %v", err)
+			}
+			return
+		}
+		go l.handle(conn)
+	}
+}
+
+// handle reads one handoff and acknowledges it
+func (l *Lock) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(handoffTimeout))
+
+	var data LaunchData
+	if err := json.NewDecoder(conn).Decode(&data); err != nil || !sameToken(data.Token, l.token) {
+		return // Not one of ours
+	}
+	conn.Write([]byte(l.token + "\n"))
+
+	log.Printf("[SingleInstance] Second instance launched with args %v", data.Args)
+
+	l.mu.Lock()
+	onLaunch := l.onLaunch
+	if onLaunch == nil {
+		l.pending = append(l.pending, data)
+	}
+	l.mu.Unlock()
+
+	if onLaunch != nil {
+		onLaunch(data)
+	}
+}
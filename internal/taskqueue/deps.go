@@ -0,0 +1,178 @@
+package taskqueue
+
+import (
+	"fmt"
+	"log"
+)
+
+// Task kinds
+const (
+	TaskKindExport = "export" // Download imagery, optionally followed by video export
+	TaskKindVideo  = "video"  // Export video from a dependency's downloaded imagery
+)
+
+// IsVideoOnly reports whether the task renders video from its dependencies' output
+// instead of downloading imagery itself
+func (t *ExportTask) IsVideoOnly() bool {
+	return t.Kind == TaskKindVideo
+}
+
+// validateDependenciesLocked checks a new task's dependencies against the queue.
+// Dependencies must already be queued, so a chain can never form a cycle.
+// Caller must hold qm.mu.
+func (qm *QueueManager) validateDependenciesLocked(task *ExportTask) error {
+	switch task.Kind {
+	case "", TaskKindExport, TaskKindVideo:
+	default:
+		return fmt.Errorf("unknown task kind: %s", task.Kind)
+	}
+
+	seen := make(map[string]bool)
+	for _, depID := range task.DependsOn {
+		if depID == task.ID {
+			return fmt.Errorf("task cannot depend on itself")
+		}
+		if seen[depID] {
+			return fmt.Errorf("duplicate dependency: %s", depID)
+		}
+		seen[depID] = true
+		if _, exists := qm.tasks[depID]; !exists {
+			return fmt.Errorf("dependency not found: %s", depID)
+		}
+	}
+
+	if task.IsVideoOnly() {
+		if len(task.DependsOn) == 0 {
+			return fmt.Errorf("video task must depend on a download task")
+		}
+		if task.VideoOpts == nil {
+			return fmt.Errorf("video task has no video options")
+		}
+	}
+	return nil
+}
+
+// dependencyStateLocked reports whether all of a task's dependencies have completed.
+// A non-nil error means the task can never run (a dependency failed, was cancelled or deleted).
+// Caller must hold qm.mu.
+func (qm *QueueManager) dependencyStateLocked(task *ExportTask) (ready bool, err error) {
+	ready = true
+	for _, depID := range task.DependsOn {
+		dep, exists := qm.tasks[depID]
+		if !exists {
+			return false, fmt.Errorf("dependency %s no longer exists", depID)
+		}
+		switch dep.Status {
+		case TaskStatusCompleted:
+		case TaskStatusFailed, TaskStatusCancelled:
+			return false, fmt.Errorf("dependency '%s' %s", dep.Name, dep.Status)
+		default:
+			ready = false
+		}
+	}
+	return ready, nil
+}
+
+// failBlockedTasksLocked fails pending tasks whose dependencies can no longer complete.
+// Runs until nothing changes so failures propagate down a chain.
+// Caller must hold qm.mu.
+func (qm *QueueManager) failBlockedTasksLocked() {
+	for changed := true; changed; {
+		changed = false
+		for _, id := range qm.taskOrder {
+			task := qm.tasks[id]
+			if task.Status != TaskStatusPending {
+				continue
+			}
+			if _, err := qm.dependencyStateLocked(task); err != nil {
+				task.MarkFailed(err)
+				qm.saveTask(task)
+				log.Printf("[TaskQueue] Task %s cannot run: %v", task.ID, err)
+				changed = true
+			}
+		}
+	}
+}
+
+// resolveInputsLocked records the output paths of a task's dependencies before it runs.
+// Video tasks inherit any area, zoom, source and dates they leave unset from their
+// first download dependency. Caller must hold qm.mu.
+func (qm *QueueManager) resolveInputsLocked(task *ExportTask) {
+	task.InputPaths = nil
+	var source *ExportTask
+	for _, depID := range task.DependsOn {
+		dep := qm.tasks[depID]
+		if dep.OutputPath != "" {
+			task.InputPaths = append(task.InputPaths, dep.OutputPath)
+		}
+		if source == nil && !dep.IsVideoOnly() {
+			source = dep
+		}
+	}
+
+	if !task.IsVideoOnly() || source == nil {
+		return
+	}
+	if task.Source == "" {
+		task.Source = source.Source
+	}
+	if task.BBox == (BoundingBox{}) {
+		task.BBox = source.BBox
+	}
+	if task.Zoom == 0 {
+		task.Zoom = source.Zoom
+	}
+	if len(task.Dates) == 0 {
+		task.Dates = source.Dates
+		task.Progress.TotalDates = len(task.Dates)
+	}
+}
+
+// hasDependentsLocked reports whether any unfinished task depends on id.
+// Caller must hold qm.mu.
+func (qm *QueueManager) hasDependentsLocked(id string) bool {
+	for _, task := range qm.tasks {
+		if task.Status != TaskStatusPending && task.Status != TaskStatusRunning {
+			continue
+		}
+		for _, depID := range task.DependsOn {
+			if depID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequeueTask resets a finished or interrupted task to pending so it runs again from scratch.
+// Tasks that depend on it are not requeued; requeue them separately to re-run a chain.
+func (qm *QueueManager) RequeueTask(id string) error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	task, exists := qm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status == TaskStatusPending || task.Status == TaskStatusRunning {
+		return fmt.Errorf("task is already %s", task.Status)
+	}
+
+	// A requeue runs the task from scratch
+	task.MarkPending()
+	task.Checkpoint = nil
+	if err := qm.saveTask(task); err != nil {
+		return err
+	}
+
+	qm.emitQueueUpdateLocked()
+
+	// Signal worker
+	select {
+	case qm.taskAdded <- struct{}{}:
+	default:
+	}
+
+	log.Printf("[TaskQueue] Requeued task: %s", id)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"sync"
+	"time"
 )
 
 // QueueState represents the persistent queue state
@@ -43,10 +44,13 @@ type QueueManager struct {
 	// State
 	isRunning bool
 	isPaused  bool
+	workerActive bool // A worker goroutine is running; there is never more than one
 	currentTask *ExportTask
+	shuttingDown bool // App is closing: start no further tasks
+	bulkWindow   Window // When bulk tasks without a window of their own may start
 
 	// Channels
-	stopWorker  chan struct{}
+	wakeWorker  chan struct{} // Buffered: makes the worker re-check the queue state (stop, pause, restart, shutdown)
 	pauseWorker chan struct{}
 	taskAdded   chan struct{}
 
@@ -57,6 +61,12 @@ type QueueManager struct {
 	// Executor
 	executor TaskExecutor
 
+	// Per-task log capture (the executor begins it, the worker ends it)
+	taskLog *TaskLogger
+
+	// Running totals of bytes written by completed tasks
+	usage *UsageLedger
+
 	// Event emission callback
 	onQueueUpdate  func(status QueueStatus)
 	onTasksChanged func(tasks []*ExportTask) // New: emit full task list on any change
@@ -85,9 +95,11 @@ func NewQueueManager(storagePath string, maxConcurrent int) *QueueManager {
 		taskOrder:     make([]string, 0),
 		storagePath:   storagePath,
 		maxConcurrent: maxConcurrent,
-		stopWorker:    make(chan struct{}),
+		wakeWorker:    make(chan struct{}, 1),
 		pauseWorker:   make(chan struct{}),
 		taskAdded:     make(chan struct{}, 1),
+		taskLog:       &TaskLogger{},
+		usage:         loadUsageLedger(filepath.Join(storagePath, "usage.json")),
 		ctx:           ctx,
 		cancelFunc:    cancel,
 	}
@@ -105,6 +117,17 @@ func (qm *QueueManager) SetExecutor(executor TaskExecutor) {
 	qm.executor = executor
 }
 
+// TaskLog returns the logger that captures per-task log files.
+// The executor calls Begin once it knows the task's output directory.
+func (qm *QueueManager) TaskLog() *TaskLogger {
+	return qm.taskLog
+}
+
+// Usage returns the ledger of bytes written by completed tasks
+func (qm *QueueManager) Usage() *UsageLedger {
+	return qm.usage
+}
+
 // SetCallbacks sets event callbacks
 func (qm *QueueManager) SetCallbacks(
 	onQueueUpdate func(QueueStatus),
@@ -178,6 +201,9 @@ func (qm *QueueManager) loadState() error {
 		}
 	}
 
+	// Tasks still marked running were cut off by a crash or forced quit
+	qm.recoverOrphanedLocked()
+
 	log.Printf("[TaskQueue] Loaded %d tasks from disk", len(qm.tasks))
 	return nil
 }
@@ -224,6 +250,18 @@ func (qm *QueueManager) AddTask(task *ExportTask) error {
 		task.ID = generateTaskID()
 	}
 
+	if err := qm.validateDependenciesLocked(task); err != nil {
+		return err
+	}
+	if err := ValidatePriorityClass(task.PriorityClass); err != nil {
+		return err
+	}
+	if task.Window != nil {
+		if err := task.Window.Validate(); err != nil {
+			return err
+		}
+	}
+
 	qm.tasks[task.ID] = task
 	qm.taskOrder = append(qm.taskOrder, task.ID)
 
@@ -324,6 +362,58 @@ func (qm *QueueManager) UpdateTask(id string, updates map[string]interface{}) er
 	if videoExport, ok := updates["videoExport"].(bool); ok {
 		task.VideoExport = videoExport
 	}
+	if maxRetries, ok := updates["maxRetries"].(float64); ok && maxRetries >= 0 {
+		task.MaxRetries = int(maxRetries)
+	}
+	if retryDelay, ok := updates["retryDelaySeconds"].(float64); ok && retryDelay >= 0 {
+		task.RetryDelaySeconds = int(retryDelay)
+	}
+	if minSuccessRate, ok := updates["minSuccessRate"].(float64); ok && minSuccessRate >= 0 && minSuccessRate <= 1 {
+		task.MinSuccessRate = minSuccessRate
+	}
+	if strict, ok := updates["strict"].(bool); ok {
+		task.Strict = strict
+	}
+	if incremental, ok := updates["incremental"].(bool); ok {
+		task.Incremental = incremental
+	}
+	if project, ok := updates["project"].(string); ok {
+		task.Project = project
+	}
+	if operator, ok := updates["operator"].(string); ok {
+		task.Operator = operator
+	}
+	if notes, ok := updates["notes"].(string); ok {
+		task.Notes = notes
+	}
+	if writeManifest, ok := updates["manifest"].(bool); ok {
+		task.Manifest = writeManifest
+	}
+	if zipOutput, ok := updates["zipOutput"].(bool); ok {
+		task.ZipOutput = zipOutput
+	}
+	if zipExcludeTiles, ok := updates["zipExcludeTiles"].(bool); ok {
+		task.ZipExcludeTiles = zipExcludeTiles
+	}
+	if class, ok := updates["priorityClass"].(string); ok {
+		if err := ValidatePriorityClass(class); err != nil {
+			return err
+		}
+		task.PriorityClass = class
+	}
+	if window, ok := updates["window"].(map[string]interface{}); ok {
+		start, _ := window["start"].(string)
+		end, _ := window["end"].(string)
+		w := Window{Start: start, End: end}
+		if err := w.Validate(); err != nil {
+			return err
+		}
+		if w.IsZero() {
+			task.Window = nil
+		} else {
+			task.Window = &w
+		}
+	}
 
 	// Save to disk
 	if err := qm.saveTask(task); err != nil {
@@ -349,6 +439,11 @@ func (qm *QueueManager) DeleteTask(id string) error {
 		return fmt.Errorf("cannot delete running task - cancel it first")
 	}
 
+	// Can't delete a task that queued tasks still need the output of
+	if qm.hasDependentsLocked(id) {
+		return fmt.Errorf("cannot delete task - other queued tasks depend on it")
+	}
+
 	// Remove from order
 	newOrder := make([]string, 0, len(qm.taskOrder)-1)
 	for _, taskId := range qm.taskOrder {
@@ -468,14 +563,30 @@ func (qm *QueueManager) StartQueue() error {
 		qm.mu.Unlock()
 		return fmt.Errorf("queue is already running")
 	}
+	if qm.shuttingDown {
+		qm.mu.Unlock()
+		return fmt.Errorf("app is shutting down")
+	}
 
 	qm.isRunning = true
 	qm.isPaused = false
 	qm.saveState()
-	qm.mu.Unlock()
 
-	// Start worker if not already running
-	go qm.worker()
+	// A worker still finishing its task (or waiting out a delay) after a pause or stop
+	// carries on instead of a second one being started
+	if qm.workerActive {
+		qm.mu.Unlock()
+		qm.wake()
+	} else {
+		qm.workerActive = true
+		qm.mu.Unlock()
+
+		qm.workerWg.Add(1)
+		go func() {
+			defer qm.workerWg.Done()
+			qm.worker()
+		}()
+	}
 
 	qm.emitQueueUpdate()
 	log.Printf("[TaskQueue] Queue started")
@@ -493,6 +604,7 @@ func (qm *QueueManager) PauseQueue() error {
 
 	qm.isPaused = true
 	qm.saveState()
+	qm.wake()
 
 	qm.emitQueueUpdateLocked()
 	log.Printf("[TaskQueue] Queue paused (will stop after current task)")
@@ -512,15 +624,21 @@ func (qm *QueueManager) StopQueue() {
 	qm.ctx, qm.cancelFunc = context.WithCancel(context.Background())
 
 	// Signal worker to stop
-	select {
-	case qm.stopWorker <- struct{}{}:
-	default:
-	}
+	qm.wake()
 
 	qm.emitQueueUpdate()
 	log.Printf("[TaskQueue] Queue stopped")
 }
 
+// wake makes the worker re-check the queue state, also when it is waiting out a retry
+// delay or a bulk window. The signal is kept until the worker next waits.
+func (qm *QueueManager) wake() {
+	select {
+	case qm.wakeWorker <- struct{}{}:
+	default:
+	}
+}
+
 // GetStatus returns the current queue status
 func (qm *QueueManager) GetStatus() QueueStatus {
 	qm.mu.RLock()
@@ -562,33 +680,63 @@ func (qm *QueueManager) worker() {
 	defer log.Printf("[TaskQueue] Worker stopped")
 
 	for {
-		// Check if we should stop
-		select {
-		case <-qm.stopWorker:
-			return
-		default:
-		}
-
+		// Stopped, paused or closing: the flag is cleared under the same lock the state
+		// is checked with, so StartQueue either sees this worker or starts a new one
 		qm.mu.Lock()
-		if !qm.isRunning || qm.isPaused {
+		if !qm.isRunning || qm.isPaused || qm.shuttingDown {
+			qm.workerActive = false
 			qm.mu.Unlock()
 			return
 		}
 
-		// Find next pending task (respecting priority)
+		// Fail tasks whose dependencies failed, then find the next pending task
+		// whose dependencies have all completed (respecting priority)
+		// Tasks waiting out a retry delay, and bulk tasks outside their window, are
+		// skipped until it elapses; interactive tasks go before bulk ones
+		qm.failBlockedTasksLocked()
 		var nextTask *ExportTask
+		var retryWait time.Duration
+		now := time.Now()
 		for _, id := range qm.taskOrder {
 			task := qm.tasks[id]
 			if task.Status == TaskStatusPending {
-				if nextTask == nil || task.Priority > nextTask.Priority {
+				if ready, _ := qm.dependencyStateLocked(task); !ready {
+					continue
+				}
+				wait := task.retryWait(now)
+				if w := task.scheduleWait(qm.bulkWindow, now); w > wait {
+					wait = w
+				}
+				if wait > 0 {
+					if retryWait == 0 || wait < retryWait {
+						retryWait = wait
+					}
+					continue
+				}
+				if nextTask == nil || task.runsBefore(nextTask) {
 					nextTask = task
 				}
 			}
 		}
 
+		if nextTask == nil && retryWait > 0 {
+			// Only retries and deferred bulk tasks are left - wait for the earliest one
+			// (or a new task, or a change of queue state)
+			qm.mu.Unlock()
+			timer := time.NewTimer(retryWait)
+			select {
+			case <-qm.wakeWorker:
+			case <-qm.taskAdded:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
 		if nextTask == nil {
 			// No more tasks
 			qm.isRunning = false
+			qm.workerActive = false
 			qm.saveState()
 			qm.mu.Unlock()
 
@@ -609,12 +757,19 @@ func (qm *QueueManager) worker() {
 		}
 
 		qm.currentTask = nextTask
+		qm.resolveInputsLocked(nextTask)
 		nextTask.MarkStarted()
 		qm.saveTask(nextTask)
 		qm.mu.Unlock()
 
 		qm.emitQueueUpdate()
 
+		// Video-only tasks write into their dependency's directory; only count what they add
+		var sizeBefore int64
+		if nextTask.IsVideoOnly() && len(nextTask.InputPaths) > 0 {
+			sizeBefore = DirSize(nextTask.InputPaths[0])
+		}
+
 		// Execute task
 		log.Printf("[TaskQueue] Executing task: %s (%s)", nextTask.Name, nextTask.ID)
 
@@ -640,11 +795,25 @@ func (qm *QueueManager) worker() {
 		}
 		close(progressChan)
 
+		var bytesWritten int64
+		if execErr == nil && nextTask.OutputPath != "" {
+			bytesWritten = max(DirSize(nextTask.OutputPath)-sizeBefore, 0)
+			if err := qm.usage.Add(nextTask.usageProvider(), bytesWritten); err != nil {
+				log.Printf("[TaskQueue] %v", err)
+			}
+		}
+
 		qm.mu.Lock()
 		if execErr != nil {
-			if qm.ctx.Err() != nil {
+			if qm.ctx.Err() != nil && qm.shuttingDown {
+				// Cut off by app exit - resumable from its checkpoint on next launch
+				nextTask.MarkInterrupted()
+			} else if qm.ctx.Err() != nil {
 				// Context was cancelled
 				nextTask.MarkCancelled()
+			} else if nextTask.scheduleRetry(execErr) {
+				log.Printf("[TaskQueue] Task failed: %s - %v (retry %d/%d in %s)",
+					nextTask.ID, execErr, nextTask.RetryCount, nextTask.maxRetries(), nextTask.retryDelay())
 			} else {
 				nextTask.MarkFailed(execErr)
 				log.Printf("[TaskQueue] Task failed: %s - %v", nextTask.ID, execErr)
@@ -656,7 +825,12 @@ func (qm *QueueManager) worker() {
 			}
 		} else {
 			nextTask.MarkCompleted(nextTask.OutputPath)
-			log.Printf("[TaskQueue] Task completed: %s", nextTask.ID)
+			nextTask.BytesWritten = bytesWritten
+			log.Printf("[TaskQueue] Task completed: %s (%.1f MB written)", nextTask.ID, float64(bytesWritten)/1024/1024)
+		}
+		// Close the task log after the result is logged, so a failure's reason is in it
+		if logPath := qm.taskLog.End(); logPath != "" {
+			nextTask.LogPath = logPath
 		}
 		qm.saveTask(nextTask)
 		qm.currentTask = nil
@@ -692,7 +866,7 @@ func (qm *QueueManager) emitQueueUpdate() {
 	qm.emitQueueUpdateLocked()
 }
 
-// SortByPriority sorts tasks by priority (higher first)
+// SortByPriority sorts tasks by priority class (interactive first), then priority (higher first)
 func (qm *QueueManager) SortByPriority() {
 	qm.mu.Lock()
 	defer qm.mu.Unlock()
@@ -710,9 +884,9 @@ func (qm *QueueManager) SortByPriority() {
 		}
 	}
 
-	// Sort pending by priority
-	sort.Slice(pendingTasks, func(i, j int) bool {
-		return pendingTasks[i].Priority > pendingTasks[j].Priority
+	// Sort pending by priority class, then priority
+	sort.SliceStable(pendingTasks, func(i, j int) bool {
+		return pendingTasks[i].runsBefore(pendingTasks[j])
 	})
 
 	// Rebuild order: non-pending first (maintain order), then pending (sorted)
@@ -736,7 +910,9 @@ func (qm *QueueManager) ClearCompleted() {
 	newOrder := make([]string, 0)
 	for _, id := range qm.taskOrder {
 		task := qm.tasks[id]
-		if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed || task.Status == TaskStatusCancelled {
+		finished := task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed || task.Status == TaskStatusCancelled
+		// Keep finished tasks whose output queued tasks still depend on
+		if finished && !qm.hasDependentsLocked(id) {
 			task.DeleteFile(tasksDir)
 			delete(qm.tasks, id)
 		} else {
@@ -755,3 +931,40 @@ func (qm *QueueManager) Close() {
 	qm.StopQueue()
 	qm.workerWg.Wait()
 }
+
+// Shutdown stops the queue for app exit without changing its saved running/paused state.
+// No further task is started; the running task gets up to grace to finish, then it is
+// cancelled and marked interrupted, so the next launch resumes it from its checkpoint.
+// Returns false if the worker still had not stopped after that.
+func (qm *QueueManager) Shutdown(grace time.Duration) bool {
+	qm.mu.Lock()
+	qm.shuttingDown = true
+	qm.mu.Unlock()
+
+	// Wake a worker waiting out a retry delay or a bulk window
+	qm.wake()
+
+	done := make(chan struct{})
+	go func() {
+		qm.workerWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(grace):
+	}
+
+	log.Printf("[TaskQueue] Current task still running after %s, interrupting it", grace)
+	qm.mu.Lock()
+	qm.cancelFunc()
+	qm.mu.Unlock()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(grace):
+		return false
+	}
+}
@@ -0,0 +1,147 @@
+package taskqueue
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// TaskCheckpoint records which dates of a task have been fully written.
+// Tiles of a partially downloaded date are not listed here: they are already in the
+// persistent tile cache, so re-running that date only fetches the missing ones.
+type TaskCheckpoint struct {
+	CompletedDates []string `json:"completedDates"` // CheckpointKey values
+	UpdatedAt      string   `json:"updatedAt"`      // ISO 8601 format
+}
+
+// CheckpointKey identifies one date of one source within a task
+// (mixed-source tasks can hold the same date from both providers)
+func CheckpointKey(source, date string) string {
+	return source + "/" + date
+}
+
+// MarkInterrupted marks a task that was running when the app exited
+func (t *ExportTask) MarkInterrupted() {
+	t.Status = TaskStatusInterrupted
+	t.Error = "Interrupted before completion (app closed or crashed)"
+}
+
+// recoverOrphanedLocked marks tasks left "running" by a previous session as interrupted.
+// Called on load, before any worker starts. Caller must hold qm.mu (or own qm exclusively).
+func (qm *QueueManager) recoverOrphanedLocked() int {
+	recovered := 0
+	for _, id := range qm.taskOrder {
+		task := qm.tasks[id]
+		if task.Status != TaskStatusRunning {
+			continue
+		}
+		task.MarkInterrupted()
+		qm.saveTask(task)
+		recovered++
+
+		done := 0
+		if task.Checkpoint != nil {
+			done = len(task.Checkpoint.CompletedDates)
+		}
+		log.Printf("[TaskQueue] Recovered interrupted task: %s (%d/%d dates checkpointed)", task.ID, done, len(task.Dates))
+	}
+	return recovered
+}
+
+// InterruptedTasks returns the tasks interrupted by a previous app exit
+func (qm *QueueManager) InterruptedTasks() []*ExportTask {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+
+	result := make([]*ExportTask, 0)
+	for _, id := range qm.taskOrder {
+		if task := qm.tasks[id]; task.Status == TaskStatusInterrupted {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// ResumeTask returns an interrupted task to the queue, keeping its checkpoint and
+// output directory so completed dates are skipped
+func (qm *QueueManager) ResumeTask(id string) error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	task, exists := qm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status != TaskStatusInterrupted {
+		return fmt.Errorf("task is not interrupted (status: %s)", task.Status)
+	}
+
+	qm.resumeLocked(task)
+	qm.emitQueueUpdateLocked()
+	return nil
+}
+
+// ResumeInterruptedTasks returns every interrupted task to the queue
+func (qm *QueueManager) ResumeInterruptedTasks() int {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	resumed := 0
+	for _, id := range qm.taskOrder {
+		if task := qm.tasks[id]; task.Status == TaskStatusInterrupted {
+			qm.resumeLocked(task)
+			resumed++
+		}
+	}
+	if resumed > 0 {
+		qm.emitQueueUpdateLocked()
+	}
+	return resumed
+}
+
+// resumeLocked sets an interrupted task back to pending. Caller must hold qm.mu.
+func (qm *QueueManager) resumeLocked(task *ExportTask) {
+	task.Status = TaskStatusPending
+	task.Error = ""
+	qm.saveTask(task)
+
+	// Signal worker
+	select {
+	case qm.taskAdded <- struct{}{}:
+	default:
+	}
+
+	log.Printf("[TaskQueue] Resuming task: %s", task.ID)
+}
+
+// CheckpointDate records that a date of a task has been fully written
+func (qm *QueueManager) CheckpointDate(taskID, key string) error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	task, exists := qm.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+
+	if task.Checkpoint == nil {
+		task.Checkpoint = &TaskCheckpoint{}
+	}
+	task.Checkpoint.CompletedDates = append(task.Checkpoint.CompletedDates, key)
+	task.Checkpoint.UpdatedAt = time.Now().Format(time.RFC3339)
+	return qm.saveTask(task)
+}
+
+// CheckpointedDates returns the set of CheckpointKey values already written for a task
+func (qm *QueueManager) CheckpointedDates(taskID string) map[string]bool {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+
+	done := make(map[string]bool)
+	if task, exists := qm.tasks[taskID]; exists && task.Checkpoint != nil {
+		for _, key := range task.Checkpoint.CompletedDates {
+			done[key] = true
+		}
+	}
+	return done
+}
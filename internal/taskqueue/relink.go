@@ -0,0 +1,80 @@
+package taskqueue
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// relinkPath rewrites path to sit under newRoot if it is under oldRoot
+func relinkPath(path, oldRoot, newRoot string) (string, bool) {
+	if path == "" {
+		return path, false
+	}
+	rel, err := filepath.Rel(oldRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return filepath.Join(newRoot, rel), true
+}
+
+// relink rewrites the task's output, input and log paths from oldRoot to newRoot
+// and reports whether anything changed
+func (t *ExportTask) relink(oldRoot, newRoot string) bool {
+	changed := false
+	var ok bool
+
+	if t.OutputPath, ok = relinkPath(t.OutputPath, oldRoot, newRoot); ok {
+		changed = true
+	}
+	if t.LogPath, ok = relinkPath(t.LogPath, oldRoot, newRoot); ok {
+		changed = true
+	}
+	for i, p := range t.InputPaths {
+		if t.InputPaths[i], ok = relinkPath(p, oldRoot, newRoot); ok {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// RelinkPaths rewrites the file paths of every task under oldRoot to newRoot (after the
+// download folder moved) and returns how many tasks changed. Either every changed task
+// record is saved or, on a write failure, all of them are restored.
+func (qm *QueueManager) RelinkPaths(oldRoot, newRoot string) (int, error) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	if qm.currentTask != nil {
+		return 0, fmt.Errorf("cannot relink tasks while task '%s' is running", qm.currentTask.Name)
+	}
+
+	type original struct {
+		task                *ExportTask
+		outputPath, logPath string
+		inputPaths          []string
+	}
+	var changed []original
+	for _, id := range qm.taskOrder {
+		task := qm.tasks[id]
+		prev := original{task, task.OutputPath, task.LogPath, append([]string(nil), task.InputPaths...)}
+		if !task.relink(oldRoot, newRoot) {
+			continue
+		}
+		changed = append(changed, prev)
+
+		if err := qm.saveTask(task); err != nil {
+			// Roll back every task rewritten so far, in memory and on disk
+			for _, c := range changed {
+				c.task.OutputPath, c.task.LogPath, c.task.InputPaths = c.outputPath, c.logPath, c.inputPaths
+				qm.saveTask(c.task)
+			}
+			return 0, fmt.Errorf("failed to save task %s: %w", task.ID, err)
+		}
+	}
+
+	if len(changed) > 0 {
+		qm.emitQueueUpdateLocked()
+	}
+	return len(changed), nil
+}
@@ -0,0 +1,91 @@
+package taskqueue
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultRetryDelay is the wait before an automatic retry when a task sets no delay
+	DefaultRetryDelay = 30 * time.Second
+
+	// DefaultStrictRetries is the number of automatic retries of strict tasks that set none
+	DefaultStrictRetries = 3
+)
+
+// maxRetries returns the number of automatic retries the task allows
+func (t *ExportTask) maxRetries() int {
+	if t.Strict && t.MaxRetries == 0 {
+		return DefaultStrictRetries
+	}
+	return t.MaxRetries
+}
+
+// retryDelay returns the wait before the task's next automatic retry
+func (t *ExportTask) retryDelay() time.Duration {
+	if t.RetryDelaySeconds > 0 {
+		return time.Duration(t.RetryDelaySeconds) * time.Second
+	}
+	return DefaultRetryDelay
+}
+
+// scheduleRetry requeues a failed run if the task has retries left.
+// The error is kept so the UI can show why the task is retrying.
+func (t *ExportTask) scheduleRetry(err error) bool {
+	if t.RetryCount >= t.maxRetries() {
+		return false
+	}
+	t.RetryCount++
+	t.Status = TaskStatusPending
+	t.CompletedAt = ""
+	t.NextRetryAt = time.Now().Add(t.retryDelay()).Format(time.RFC3339)
+	if err != nil {
+		t.Error = err.Error()
+	}
+	return true
+}
+
+// retryWait returns how long until a pending task may run again (0 if it can run now)
+func (t *ExportTask) retryWait(now time.Time) time.Duration {
+	if t.NextRetryAt == "" {
+		return 0
+	}
+	at, err := time.Parse(time.RFC3339, t.NextRetryAt)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
+
+// RetryTask resets a failed or cancelled task to pending and clears its retry count.
+// The task keeps its ID, so it reuses its existing (partial) output directory.
+func (qm *QueueManager) RetryTask(id string) error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	task, exists := qm.tasks[id]
+	if !exists {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status != TaskStatusFailed && task.Status != TaskStatusCancelled {
+		return fmt.Errorf("only failed or cancelled tasks can be retried (status: %s)", task.Status)
+	}
+
+	task.MarkPending()
+	task.RetryCount = 0
+	if err := qm.saveTask(task); err != nil {
+		return err
+	}
+
+	qm.emitQueueUpdateLocked()
+
+	// Signal worker
+	select {
+	case qm.taskAdded <- struct{}{}:
+	default:
+	}
+
+	log.Printf("[TaskQueue] Retrying task: %s", id)
+	return nil
+}
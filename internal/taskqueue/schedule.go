@@ -0,0 +1,147 @@
+package taskqueue
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Priority classes: interactive tasks run before bulk ones whatever their priority, and
+// bulk tasks only run within their scheduling window (e.g. overnight, to leave office
+// bandwidth alone during the day)
+const (
+	PriorityInteractive = "interactive" // Default
+	PriorityBulk        = "bulk"
+)
+
+// ValidatePriorityClass checks a task's priority class ("" = interactive)
+func ValidatePriorityClass(class string) error {
+	switch class {
+	case "", PriorityInteractive, PriorityBulk:
+		return nil
+	}
+	return fmt.Errorf("priority class must be '%s' or '%s'", PriorityInteractive, PriorityBulk)
+}
+
+// Window is a daily time window in local time, e.g. 22:00-07:00. A window ending at or
+// before its start wraps past midnight; one with no start and end is always open.
+type Window struct {
+	Start string `json:"start"` // HH:MM
+	End   string `json:"end"`   // HH:MM
+}
+
+// IsZero reports whether the window is unset (always open)
+func (w Window) IsZero() bool {
+	return w.Start == "" && w.End == ""
+}
+
+// Validate checks the window's times
+func (w Window) Validate() error {
+	if w.IsZero() {
+		return nil
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return fmt.Errorf("window start: %w", err)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return fmt.Errorf("window end: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("window start and end must differ")
+	}
+	return nil
+}
+
+// untilOpen returns how long until the window opens at now (0 if it is open or invalid)
+func (w Window) untilOpen(now time.Time) time.Duration {
+	if w.IsZero() {
+		return 0
+	}
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	if err1 != nil || err2 != nil || start == end {
+		return 0
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	clock := now.Sub(midnight)
+	var open bool
+	if start < end {
+		open = clock >= start && clock < end
+	} else {
+		open = clock >= start || clock < end // Wraps past midnight
+	}
+	if open {
+		return 0
+	}
+
+	opens := midnight.Add(start)
+	if !opens.After(now) {
+		opens = midnight.AddDate(0, 0, 1).Add(start)
+	}
+	return opens.Sub(now)
+}
+
+// parseClock parses HH:MM into the time since midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// isBulk reports whether the task is in the bulk priority class
+func (t *ExportTask) isBulk() bool {
+	return t.PriorityClass == PriorityBulk
+}
+
+// runsBefore reports whether the task should be picked before other: interactive tasks
+// first, then higher priority
+func (t *ExportTask) runsBefore(other *ExportTask) bool {
+	if t.isBulk() != other.isBulk() {
+		return !t.isBulk()
+	}
+	return t.Priority > other.Priority
+}
+
+// scheduleWait returns how long a pending bulk task must wait for its window (its own, or
+// the queue's bulk window) to open; 0 for interactive tasks and open windows
+func (t *ExportTask) scheduleWait(bulkWindow Window, now time.Time) time.Duration {
+	if !t.isBulk() {
+		return 0
+	}
+	window := bulkWindow
+	if t.Window != nil && !t.Window.IsZero() {
+		window = *t.Window
+	}
+	return window.untilOpen(now)
+}
+
+// SetBulkWindow sets the window bulk tasks without a window of their own run in (zero =
+// always). Bulk tasks already running finish even when the window closes.
+func (qm *QueueManager) SetBulkWindow(window Window) error {
+	if err := window.Validate(); err != nil {
+		return err
+	}
+	qm.mu.Lock()
+	qm.bulkWindow = window
+	qm.mu.Unlock()
+
+	// Wake the worker so it re-evaluates deferred tasks
+	select {
+	case qm.taskAdded <- struct{}{}:
+	default:
+	}
+	log.Printf("[TaskQueue] Bulk task window: %s", describeWindow(window))
+	return nil
+}
+
+func describeWindow(w Window) string {
+	if w.IsZero() {
+		return "always"
+	}
+	return w.Start + "-" + w.End
+}
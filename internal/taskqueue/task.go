@@ -8,23 +8,28 @@ import (
 	"time"
 
 	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/video"
 )
 
 // TaskStatus represents the current status of a task
 type TaskStatus string
 
 const (
-	TaskStatusPending   TaskStatus = "pending"
-	TaskStatusRunning   TaskStatus = "running"
-	TaskStatusCompleted TaskStatus = "completed"
-	TaskStatusFailed    TaskStatus = "failed"
-	TaskStatusCancelled TaskStatus = "cancelled"
+	TaskStatusPending     TaskStatus = "pending"
+	TaskStatusRunning     TaskStatus = "running"
+	TaskStatusCompleted   TaskStatus = "completed"
+	TaskStatusFailed      TaskStatus = "failed"
+	TaskStatusCancelled   TaskStatus = "cancelled"
+	TaskStatusInterrupted TaskStatus = "interrupted" // Was running when the app exited
 )
 
 // Type aliases for downloads package types (used in task serialization)
 type BoundingBox = downloads.BoundingBox
 type GEDateInfo = downloads.GEDateInfo
 
+// SpotlightRegion aliases the video package's extra spotlight region
+type SpotlightRegion = video.SpotlightRegion
+
 // VideoExportOptions contains video export settings (matches app.go definition)
 type VideoExportOptions struct {
 	Width            int      `json:"width"`
@@ -33,19 +38,37 @@ type VideoExportOptions struct {
 	Presets          []string `json:"presets,omitempty"` // Multiple presets for batch export
 	CropX            float64  `json:"cropX"`
 	CropY            float64  `json:"cropY"`
+	AutoCrop         bool     `json:"autoCrop"`
 	SpotlightEnabled bool     `json:"spotlightEnabled"`
 	SpotlightCenterLat float64 `json:"spotlightCenterLat"`
 	SpotlightCenterLon float64 `json:"spotlightCenterLon"`
 	SpotlightRadiusKm  float64 `json:"spotlightRadiusKm"`
+	SpotlightUnit      string  `json:"spotlightUnit,omitempty"` // "km" (default) or "mi"
+	SpotlightShape     string  `json:"spotlightShape,omitempty"`
+	SpotlightFeather   int     `json:"spotlightFeather,omitempty"`
+	ExtraSpotlights    []SpotlightRegion `json:"extraSpotlights,omitempty"`
 	OverlayOpacity   float64  `json:"overlayOpacity"`
 	ShowDateOverlay  bool     `json:"showDateOverlay"`
+	ShowTimelineBar  bool     `json:"showTimelineBar"`
+	Title            string   `json:"title,omitempty"`
+	Subtitles        bool     `json:"subtitles,omitempty"`
+	ShowInfoOverlay  bool     `json:"showInfoOverlay,omitempty"`
+	InfoPosition     string   `json:"infoPosition,omitempty"`
 	DateFontSize     float64  `json:"dateFontSize"`
 	DatePosition     string   `json:"datePosition"`
 	ShowLogo         bool     `json:"showLogo"`
 	LogoPosition     string   `json:"logoPosition"`
 	FrameDelay       float64  `json:"frameDelay"`
+	FrameDurations   map[string]float64 `json:"frameDurations,omitempty"`
 	OutputFormat     string   `json:"outputFormat"`
+	ImageFormat      string   `json:"imageFormat,omitempty"`
+	AudioPath        string   `json:"audioPath,omitempty"`
 	Quality          int      `json:"quality"`
+	DedupeFrames     bool     `json:"dedupeFrames"`
+	DedupeThreshold  *int     `json:"dedupeThreshold,omitempty"`
+	StabilizeFrames  bool     `json:"stabilizeFrames"`
+	GIFScale         float64  `json:"gifScale"`
+	GIFMaxSizeMB     float64  `json:"gifMaxSizeMB"`
 }
 
 // CropPreview represents crop area for map preview (relative 0-1 coords)
@@ -76,6 +99,23 @@ type ExportTask struct {
 	StartedAt   string     `json:"startedAt,omitempty"`
 	CompletedAt string     `json:"completedAt,omitempty"`
 
+	// Task kind: TaskKindExport (default) downloads imagery, TaskKindVideo only
+	// exports video from the output of the tasks it depends on
+	Kind string `json:"kind,omitempty"`
+
+	// IDs of tasks that must complete before this one runs
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Output paths of the completed dependencies, resolved when the task starts
+	InputPaths []string `json:"inputPaths,omitempty"`
+
+	// Retry policy: failed runs are requeued up to MaxRetries times,
+	// RetryDelaySeconds apart (DefaultRetryDelay when unset)
+	MaxRetries        int    `json:"maxRetries,omitempty"`
+	RetryDelaySeconds int    `json:"retryDelaySeconds,omitempty"`
+	RetryCount        int    `json:"retryCount,omitempty"`
+	NextRetryAt       string `json:"nextRetryAt,omitempty"` // ISO 8601 format
+
 	// Export settings
 	Source string      `json:"source"` // "esri_wayback" or "google_earth"
 	BBox   BoundingBox `json:"bbox"`
@@ -89,17 +129,62 @@ type ExportTask struct {
 	VideoExport bool                `json:"videoExport"`
 	VideoOpts   *VideoExportOptions `json:"videoOpts,omitempty"`
 
+	// Export a terrain DEM GeoTIFF alongside the imagery
+	IncludeDEM bool `json:"includeDem,omitempty"`
+
+	// Share of tiles (0-1) each date needs, below which the date fails instead of
+	// exporting gaps (0 = only warn below downloads.DefaultMinSuccessRate)
+	MinSuccessRate float64 `json:"minSuccessRate,omitempty"`
+
+	// Strict makes exports all-or-nothing: any missing tile fails the date and the
+	// task, which is retried (DefaultStrictRetries times when MaxRetries is unset)
+	Strict bool `json:"strict,omitempty"`
+
+	// Incremental archives each Esri Wayback date against the latest earlier date already
+	// archived for the same area and zoom: only changed tiles are saved, with a delta
+	// manifest, instead of the full export in Format
+	Incremental bool `json:"incremental,omitempty"`
+
+	// PriorityClass is PriorityInteractive ("", picked before any bulk task) or
+	// PriorityBulk (deferred outside Window, or the queue's bulk window when unset)
+	PriorityClass string  `json:"priorityClass,omitempty"`
+	Window        *Window `json:"window,omitempty"`
+
+	// Free-text description written into the GeoTIFF tags, .aux.xml sidecars and video
+	// containers of the task's exports
+	Project  string `json:"project,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+
+	// Manifest writes a manifest of the output files' SHA-256 hashes and capture metadata
+	// (signed when a signing key is set) into the output folder once the task succeeds
+	Manifest bool `json:"manifest,omitempty"`
+
+	// ZipOutput packages the output folder into a .zip next to it once the task succeeds,
+	// leaving tile pyramids out when ZipExcludeTiles is set
+	ZipOutput       bool `json:"zipOutput,omitempty"`
+	ZipExcludeTiles bool `json:"zipExcludeTiles,omitempty"`
+
 	// Crop area for map preview
 	CropPreview *CropPreview `json:"cropPreview,omitempty"`
 
 	// Progress tracking
 	Progress TaskProgress `json:"progress"`
 
+	// Dates already written, so a resumed or retried task skips them
+	Checkpoint *TaskCheckpoint `json:"checkpoint,omitempty"`
+
 	// Error message if failed
 	Error string `json:"error,omitempty"`
 
 	// Output path for completed exports
 	OutputPath string `json:"outputPath,omitempty"`
+
+	// Bytes the completed task added to its output directory
+	BytesWritten int64 `json:"bytesWritten,omitempty"`
+
+	// Per-task log file (see TaskLogger)
+	LogPath string `json:"logPath,omitempty"`
 }
 
 // NewExportTask creates a new export task with default values
@@ -202,6 +287,9 @@ func (t *ExportTask) MarkCompleted(outputPath string) {
 	t.Status = TaskStatusCompleted
 	t.OutputPath = outputPath
 	t.Progress.Percent = 100
+	t.Error = "" // Clear the error left by a retried run
+	t.NextRetryAt = ""
+	t.Checkpoint = nil
 }
 
 // MarkFailed marks the task as failed with an error
@@ -218,3 +306,19 @@ func (t *ExportTask) MarkCancelled() {
 	t.CompletedAt = time.Now().Format(time.RFC3339)
 	t.Status = TaskStatusCancelled
 }
+
+// MarkPending resets a finished task so the queue runs it again
+func (t *ExportTask) MarkPending() {
+	t.Status = TaskStatusPending
+	t.StartedAt = ""
+	t.CompletedAt = ""
+	t.Error = ""
+	t.NextRetryAt = ""
+	t.InputPaths = nil
+	t.Progress = TaskProgress{TotalDates: len(t.Dates)}
+}
+
+// ExportMetadata returns the task's free-text description of its exports
+func (t *ExportTask) ExportMetadata() downloads.ExportMetadata {
+	return downloads.ExportMetadata{Project: t.Project, Operator: t.Operator, Notes: t.Notes}
+}
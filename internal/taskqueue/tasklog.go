@@ -0,0 +1,139 @@
+package taskqueue
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskLogger tees log output into a per-task log file while a task runs.
+// It is installed as (part of) the global log output; writes outside a task are dropped.
+type TaskLogger struct {
+	mu     sync.Mutex
+	file   *os.File
+	taskID string
+	path   string
+	onLine func(taskID, line string)
+}
+
+// TaskLogPath returns the log file path for a task within its output directory.
+// Named by task ID because video-only tasks share their dependency's directory.
+func TaskLogPath(dir, taskID string) string {
+	return filepath.Join(dir, taskID+".log")
+}
+
+// SetLineCallback sets a function called with each line written during a task
+func (l *TaskLogger) SetLineCallback(onLine func(taskID, line string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onLine = onLine
+}
+
+// Begin starts capturing log output for a task into dir/<taskID>.log (appending, so
+// retried and resumed runs keep the earlier attempts)
+func (l *TaskLogger) Begin(taskID, dir string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closeLocked()
+
+	path := TaskLogPath(dir, taskID)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open task log: %w", err)
+	}
+	fmt.Fprintf(f, "=== Task %s started %s ===\n", taskID, time.Now().Format(time.RFC3339))
+
+	l.file = f
+	l.taskID = taskID
+	l.path = path
+	return nil
+}
+
+// End stops capturing and returns the path of the log that was written ("" if none)
+func (l *TaskLogger) End() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	path := l.path
+	l.closeLocked()
+	return path
+}
+
+// ActivePath returns the log path of taskID if it is the task currently being logged
+func (l *TaskLogger) ActivePath(taskID string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.taskID != taskID {
+		return ""
+	}
+	return l.path
+}
+
+// Active reports whether a task's log is currently being captured
+func (l *TaskLogger) Active() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file != nil
+}
+
+// closeLocked closes the current log file. Caller must hold l.mu.
+func (l *TaskLogger) closeLocked() {
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = nil
+	l.taskID = ""
+	l.path = ""
+}
+
+// Write implements io.Writer
+func (l *TaskLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	if l.file == nil {
+		l.mu.Unlock()
+		return len(p), nil
+	}
+
+	// Never fail the global logger because the task log is unwritable
+	l.file.Write(p)
+	taskID, onLine := l.taskID, l.onLine
+	l.mu.Unlock()
+
+	// Called without the lock, in case the callback logs
+	if onLine != nil {
+		for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+			onLine(taskID, line)
+		}
+	}
+	return len(p), nil
+}
+
+// ReadTaskLog returns the last tail lines of a task log (all lines if tail <= 0)
+func ReadTaskLog(path string, tail int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task log: %w", err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read task log: %w", err)
+	}
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,97 @@
+package taskqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// UsageProviderVideo is the ledger key for bytes written by video-only tasks
+const UsageProviderVideo = "video"
+
+// UsageLedger keeps running totals of bytes written by completed tasks per provider.
+// Totals are historical: deleting exports later does not lower them.
+type UsageLedger struct {
+	mu        sync.Mutex
+	path      string
+	Providers map[string]int64 `json:"providers"`
+	Total     int64            `json:"total"`
+}
+
+// loadUsageLedger reads the ledger from path, starting empty if it does not exist yet
+func loadUsageLedger(path string) *UsageLedger {
+	ledger := &UsageLedger{path: path, Providers: make(map[string]int64)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[TaskQueue] Failed to read usage ledger: %v", err)
+		}
+		return ledger
+	}
+	if err := json.Unmarshal(data, ledger); err != nil {
+		log.Printf("[TaskQueue] Failed to parse usage ledger: %v", err)
+	}
+	if ledger.Providers == nil {
+		ledger.Providers = make(map[string]int64)
+	}
+	return ledger
+}
+
+// Add records bytes written for a provider and persists the totals
+func (l *UsageLedger) Add(provider string, bytes int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.Providers[provider] += bytes
+	l.Total += bytes
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage ledger: %w", err)
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Totals returns a copy of the per-provider totals and their sum
+func (l *UsageLedger) Totals() (map[string]int64, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	providers := make(map[string]int64, len(l.Providers))
+	for k, v := range l.Providers {
+		providers[k] = v
+	}
+	return providers, l.Total
+}
+
+// usageProvider returns the ledger key a task's output is counted under
+func (t *ExportTask) usageProvider() string {
+	if t.IsVideoOnly() {
+		return UsageProviderVideo
+	}
+	return t.Source
+}
+
+// DirSize returns the total size in bytes of the regular files under dir.
+// Unreadable entries are skipped; a missing dir has size 0.
+func DirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
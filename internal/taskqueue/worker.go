@@ -1,7 +1,7 @@
 package taskqueue
 
 import (
-	"math"
+	"imagery-desktop/internal/tilemath"
 )
 
 // TileCoord represents a tile coordinate
@@ -34,7 +34,7 @@ func CalculateTilesForCrop(bbox BoundingBox, zoom int, crop *CropPreview) []Tile
 
 	// Calculate the geographic extent of the crop area
 	latRange := bbox.North - bbox.South
-	lonRange := bbox.East - bbox.West
+	lonRange := tilemath.LonSpan(bbox.West, bbox.East)
 
 	cropSouth := bbox.South + (1-crop.Y-crop.Height)*latRange
 	cropNorth := bbox.South + (1-crop.Y)*latRange
@@ -58,38 +58,14 @@ func CalculateTilesForCrop(bbox BoundingBox, zoom int, crop *CropPreview) []Tile
 	return CalculateTilesForBBox(croppedBBox, zoom)
 }
 
-// LatLonToTile converts latitude/longitude to tile coordinates
+// LatLonToTile converts latitude/longitude to tile coordinates (clamped to the grid)
 func LatLonToTile(lat, lon float64, zoom int) (x, y int) {
-	n := math.Pow(2, float64(zoom))
-	x = int((lon + 180.0) / 360.0 * n)
-	latRad := lat * math.Pi / 180.0
-	y = int((1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n)
-
-	// Clamp to valid range
-	maxTile := int(n) - 1
-	if x < 0 {
-		x = 0
-	}
-	if x > maxTile {
-		x = maxTile
-	}
-	if y < 0 {
-		y = 0
-	}
-	if y > maxTile {
-		y = maxTile
-	}
-
-	return x, y
+	return tilemath.LatLonToXYZ(lat, lon, zoom)
 }
 
-// TileToLatLon converts tile coordinates to latitude/longitude (returns center of tile)
+// TileToLatLon converts tile coordinates to latitude/longitude (returns the top-left corner of the tile)
 func TileToLatLon(x, y, zoom int) (lat, lon float64) {
-	n := math.Pow(2, float64(zoom))
-	lon = float64(x)/n*360.0 - 180.0
-	latRad := math.Atan(math.Sinh(math.Pi * (1 - 2*float64(y)/n)))
-	lat = latRad * 180.0 / math.Pi
-	return lat, lon
+	return tilemath.XYZToLatLon(float64(x), float64(y), zoom)
 }
 
 // EstimateTileCount estimates the number of tiles needed for a bbox at a given zoom
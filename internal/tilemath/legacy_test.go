@@ -0,0 +1,83 @@
+package tilemath
+
+import "math"
+
+// Reference copies of the conversions the packages carried before they were consolidated
+// here, so the tests pin the outputs callers relied on
+
+// legacyLatLonToTile is taskqueue.LatLonToTile (also GenerateQuadkey's tile lookup)
+func legacyLatLonToTile(lat, lon float64, zoom int) (x, y int) {
+	n := math.Pow(2, float64(zoom))
+	x = int((lon + 180.0) / 360.0 * n)
+	latRad := lat * math.Pi / 180.0
+	y = int((1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n)
+	maxTile := int(n) - 1
+	return clamp(x, 0, maxTile), clamp(y, 0, maxTile)
+}
+
+// legacyTileToLatLon is taskqueue.TileToLatLon
+func legacyTileToLatLon(x, y, zoom int) (lat, lon float64) {
+	n := math.Pow(2, float64(zoom))
+	lon = float64(x)/n*360.0 - 180.0
+	latRad := math.Atan(math.Sinh(math.Pi * (1 - 2*float64(y)/n)))
+	lat = latRad * 180.0 / math.Pi
+	return lat, lon
+}
+
+// legacyGenerateQuadkey is naming.GenerateQuadkey
+func legacyGenerateQuadkey(south, west, north, east float64, zoom int) string {
+	centerLat := (south + north) / 2
+	centerLon := (west + east) / 2
+	n := math.Pow(2, float64(zoom))
+	x := int((centerLon + 180.0) / 360.0 * n)
+	y := int((1.0 - math.Log(math.Tan(centerLat*math.Pi/180.0)+1.0/math.Cos(centerLat*math.Pi/180.0))/math.Pi) / 2.0 * n)
+	key := make([]byte, 0, zoom)
+	for i := zoom; i > 0; i-- {
+		digit := 0
+		mask := 1 << (i - 1)
+		if x&mask != 0 {
+			digit++
+		}
+		if y&mask != 0 {
+			digit += 2
+		}
+		key = append(key, byte('0'+digit))
+	}
+	return string(key)
+}
+
+// legacyGENewTileFromXYZ is googleearth.NewTileFromXYZ (row and column)
+func legacyGENewTileFromXYZ(x, y, z int) (row, col int) {
+	n := float64(int(1) << z)
+	tileX := (float64(x) + 0.5) / n
+	tileY := (float64(y) + 0.5) / n
+	lon := tileX*360.0 - 180.0
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*tileY))) * 180.0 / math.Pi
+
+	numTiles := 1 << z
+	row = int((lat + 180.0) / 360.0 * float64(numTiles))
+	col = int((lon + 180.0) / 360.0 * float64(numTiles))
+	return clamp(row, 0, numTiles-1), clamp(col, 0, numTiles-1)
+}
+
+// legacyGETileToWebMercator is googleearth.TileToWebMercator, with its own equator and
+// latitude clamp constants
+func legacyGETileToWebMercator(row, col, zoom int) (x, y float64) {
+	const equator = 40075016.686
+	numTiles := float64(int(1) << zoom)
+	lat := (float64(row)/numTiles)*360.0 - 180.0
+	lon := (float64(col)/numTiles)*360.0 - 180.0
+	x = lon * equator / 360.0
+	lat = math.Max(-85.051129, math.Min(85.051129, lat))
+	latRad := lat * math.Pi / 180.0
+	y = equator * math.Log(math.Tan(math.Pi/4+latRad/2)) / (2 * math.Pi)
+	return x, y
+}
+
+// legacyEsriTileToWebMercator is esri.TileToWebMercator (top-left corner)
+func legacyEsriTileToWebMercator(col, row, zoom int) (x, y float64) {
+	n := float64(int(1) << zoom)
+	x = (float64(col)/n - 0.5) * Equator
+	y = (0.5 - float64(row)/n) * Equator
+	return x, y
+}
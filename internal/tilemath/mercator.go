@@ -0,0 +1,194 @@
+// Package tilemath converts between WGS84 coordinates, Web Mercator XYZ tiles, Bing-style
+// quadkeys and the Google Earth Plate Carrée quadtree. Every tile scheme in the app goes
+// through these functions so edge-of-world, antimeridian and pole handling is consistent.
+package tilemath
+
+import "math"
+
+const (
+	// Equator is the Earth's circumference at the equator in Web Mercator meters
+	Equator = 40075016.685578
+
+	// MaxLatitude is the latitude where the square Web Mercator world ends (EPSG:3857)
+	MaxLatitude = 85.05112878
+
+	// TileSize is the pixel size of one tile
+	TileSize = 256
+)
+
+// NumTiles returns the number of tiles along one axis at a zoom level
+func NumTiles(zoom int) int {
+	return 1 << zoom
+}
+
+// ClampLatitude limits a latitude to the Web Mercator range
+func ClampLatitude(lat float64) float64 {
+	return math.Max(-MaxLatitude, math.Min(MaxLatitude, lat))
+}
+
+// WrapLongitude normalizes a longitude to [-180, 180). 180 itself maps to -180,
+// so the antimeridian belongs to the first column.
+func WrapLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// CrossesAntimeridian reports whether a longitude range runs east across 180° (west > east)
+func CrossesAntimeridian(west, east float64) bool {
+	return west > east
+}
+
+// LonSpan returns the width in degrees of a longitude range, including ranges that cross
+// the antimeridian (west > east)
+func LonSpan(west, east float64) float64 {
+	if CrossesAntimeridian(west, east) {
+		return east + 360 - west
+	}
+	return east - west
+}
+
+// CenterLongitude returns the middle of a longitude range, wrapped to [-180, 180)
+func CenterLongitude(west, east float64) float64 {
+	return WrapLongitude(west + LonSpan(west, east)/2)
+}
+
+// NormalizeLonRange brings a longitude range from a wrapped map view (e.g. 170..190) into
+// [-180, 180]. Ranges crossing the antimeridian come back with west > east; ranges of
+// 360° or more cover the whole world.
+func NormalizeLonRange(west, east float64) (float64, float64) {
+	if east-west >= 360 {
+		return -180, 180
+	}
+	if west >= -180 && east <= 180 {
+		return west, east
+	}
+	w, e := WrapLongitude(west), WrapLongitude(east)
+	if e == -180 {
+		e = 180 // East edge on the antimeridian
+	}
+	return w, e
+}
+
+// WrapColumn normalizes a tile column to [0, 2^zoom), for grids that cross the antimeridian
+func WrapColumn(col, zoom int) int {
+	n := NumTiles(zoom)
+	col %= n
+	if col < 0 {
+		col += n
+	}
+	return col
+}
+
+// LatLonToMeters converts WGS84 to Web Mercator (EPSG:3857) meters.
+// Latitude is clamped to the Web Mercator range so poles stay finite.
+func LatLonToMeters(lat, lon float64) (x, y float64) {
+	lat = ClampLatitude(lat)
+	x = lon / 360.0 * Equator
+	latRad := lat * math.Pi / 180.0
+	y = math.Log(math.Tan(math.Pi/4+latRad/2)) / (2 * math.Pi) * Equator
+	return x, y
+}
+
+// MetersToLatLon converts Web Mercator (EPSG:3857) meters to WGS84
+func MetersToLatLon(x, y float64) (lat, lon float64) {
+	lon = x / Equator * 360.0
+	lat = math.Atan(math.Sinh(y/Equator*2*math.Pi)) * 180.0 / math.Pi
+	return lat, lon
+}
+
+// LatLonToXYZFrac returns the fractional XYZ tile position of a WGS84 coordinate
+// (row 0 at the north edge). Values are not clamped or wrapped.
+func LatLonToXYZFrac(lat, lon float64, zoom int) (x, y float64) {
+	mx, my := LatLonToMeters(lat, lon)
+	n := float64(NumTiles(zoom))
+	x = (0.5 + mx/Equator) * n
+	y = (0.5 - my/Equator) * n
+	return x, y
+}
+
+// LatLonToXYZ returns the XYZ tile containing a WGS84 coordinate, clamped to the grid
+func LatLonToXYZ(lat, lon float64, zoom int) (x, y int) {
+	fx, fy := LatLonToXYZFrac(lat, lon, zoom)
+	maxTile := NumTiles(zoom) - 1
+	return clamp(int(math.Floor(fx)), 0, maxTile), clamp(int(math.Floor(fy)), 0, maxTile)
+}
+
+// XYZToMeters returns the Web Mercator position of a (fractional) XYZ tile corner.
+// Integer arguments give the top-left corner of the tile.
+func XYZToMeters(x, y float64, zoom int) (mx, my float64) {
+	n := float64(NumTiles(zoom))
+	mx = (x/n - 0.5) * Equator
+	my = (0.5 - y/n) * Equator
+	return mx, my
+}
+
+// XYZToLatLon returns the WGS84 position of a (fractional) XYZ tile corner
+func XYZToLatLon(x, y float64, zoom int) (lat, lon float64) {
+	n := float64(NumTiles(zoom))
+	lon = x/n*360.0 - 180.0
+	lat = math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180.0 / math.Pi
+	return lat, lon
+}
+
+// XYZBounds returns the WGS84 bounds of an XYZ tile (south, west, north, east)
+func XYZBounds(x, y, zoom int) (south, west, north, east float64) {
+	north, west = XYZToLatLon(float64(x), float64(y), zoom)
+	south, east = XYZToLatLon(float64(x+1), float64(y+1), zoom)
+	return south, west, north, east
+}
+
+// XYZMeterBounds returns the Web Mercator bounds of an XYZ tile (minX, minY, maxX, maxY)
+func XYZMeterBounds(x, y, zoom int) (minX, minY, maxX, maxY float64) {
+	minX, maxY = XYZToMeters(float64(x), float64(y), zoom)
+	maxX, minY = XYZToMeters(float64(x+1), float64(y+1), zoom)
+	return minX, minY, maxX, maxY
+}
+
+// XYZRange returns the inclusive XYZ tile range covering a WGS84 bounding box, clamped to
+// the grid. Latitudes beyond the Web Mercator range fall into the first or last row.
+// When the box crosses the antimeridian (west > east) maxX continues past the last
+// column; wrap each column with WrapColumn before requesting it.
+func XYZRange(south, west, north, east float64, zoom int) (minX, minY, maxX, maxY int) {
+	minX, minY = LatLonToXYZ(north, west, zoom)
+	maxX, maxY = LatLonToXYZ(south, east, zoom)
+	if CrossesAntimeridian(west, east) {
+		maxX = unwrapMaxColumn(minX, maxX, zoom)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// unwrapMaxColumn moves the east column of an antimeridian-crossing range past the last
+// grid column so the range runs west to east, never covering a column twice
+func unwrapMaxColumn(minCol, maxCol, zoom int) int {
+	n := NumTiles(zoom)
+	if maxCol < minCol {
+		maxCol += n
+	}
+	if maxCol-minCol >= n {
+		maxCol = minCol + n - 1
+	}
+	return maxCol
+}
+
+// FlipY converts between XYZ rows (north first) and TMS rows (south first)
+func FlipY(y, zoom int) int {
+	return NumTiles(zoom) - 1 - y
+}
+
+// ResolutionAtZoom returns the ground resolution in meters per pixel at a zoom level and latitude
+func ResolutionAtZoom(zoom int, lat float64) float64 {
+	return Equator * math.Cos(lat*math.Pi/180) / float64(int(TileSize)<<zoom)
+}
+
+func clamp(val, min, max int) int {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
@@ -0,0 +1,196 @@
+package tilemath
+
+import (
+	"math"
+	"testing"
+)
+
+// maxXYZZoom is the highest Web Mercator zoom the app requests (Esri Wayback)
+const maxXYZZoom = 23
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestLatLonToXYZEdges(t *testing.T) {
+	n := NumTiles(maxXYZZoom)
+	tests := []struct {
+		name     string
+		lat, lon float64
+		zoom     int
+		x, y     int
+	}{
+		{"zoom 0 covers the world", 12.3, 45.6, 0, 0, 0},
+		{"zoom 0 north pole", 90, 180, 0, 0, 0},
+		{"zoom 0 south pole", -90, -180, 0, 0, 0},
+		{"west edge", 0, -180, maxXYZZoom, 0, n / 2},
+		{"east edge clamps to the last column", 0, 180, maxXYZZoom, n - 1, n / 2},
+		{"north Mercator limit", MaxLatitude, 0, maxXYZZoom, n / 2, 0},
+		{"south Mercator limit", -MaxLatitude, 0, maxXYZZoom, n / 2, n - 1},
+		{"north of the Mercator limit", 85.0512, 0, maxXYZZoom, n / 2, 0},
+		{"north pole clamps to the first row", 90, 0, maxXYZZoom, n / 2, 0},
+		{"south pole clamps to the last row", -90, 0, maxXYZZoom, n / 2, n - 1},
+	}
+	for _, tt := range tests {
+		x, y := LatLonToXYZ(tt.lat, tt.lon, tt.zoom)
+		if x != tt.x || y != tt.y {
+			t.Errorf("%s: LatLonToXYZ(%v, %v, %d) = (%d, %d), want (%d, %d)", tt.name, tt.lat, tt.lon, tt.zoom, x, y, tt.x, tt.y)
+		}
+	}
+}
+
+func TestXYZRoundTrip(t *testing.T) {
+	for _, zoom := range []int{0, 1, maxXYZZoom} {
+		n := NumTiles(zoom)
+		for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n - 1, n - 1}, {n / 2, n / 2}} {
+			x, y := tile[0], tile[1]
+			lat, lon := XYZToLatLon(float64(x)+0.5, float64(y)+0.5, zoom)
+			if gx, gy := LatLonToXYZ(lat, lon, zoom); gx != x || gy != y {
+				t.Errorf("zoom %d: center of (%d, %d) maps back to (%d, %d)", zoom, x, y, gx, gy)
+			}
+			mx, my := XYZToMeters(float64(x)+0.5, float64(y)+0.5, zoom)
+			mlat, mlon := MetersToLatLon(mx, my)
+			if !almostEqual(mlat, lat, 1e-9) || !almostEqual(mlon, lon, 1e-9) {
+				t.Errorf("zoom %d: (%d, %d) meters give (%v, %v), want (%v, %v)", zoom, x, y, mlat, mlon, lat, lon)
+			}
+		}
+	}
+}
+
+func TestXYZBoundsEdges(t *testing.T) {
+	n := NumTiles(maxXYZZoom)
+
+	south, west, north, east := XYZBounds(0, 0, 0)
+	if !almostEqual(south, -MaxLatitude, 1e-6) || west != -180 || !almostEqual(north, MaxLatitude, 1e-6) || east != 180 {
+		t.Errorf("zoom 0 bounds = (%v, %v, %v, %v), want the whole Mercator world", south, west, north, east)
+	}
+	if _, _, north, _ := XYZBounds(0, 0, maxXYZZoom); !almostEqual(north, MaxLatitude, 1e-6) {
+		t.Errorf("row 0 north edge = %v, want %v", north, MaxLatitude)
+	}
+	if south, _, _, _ := XYZBounds(0, n-1, maxXYZZoom); !almostEqual(south, -MaxLatitude, 1e-6) {
+		t.Errorf("last row south edge = %v, want %v", south, -MaxLatitude)
+	}
+	if _, _, _, east := XYZBounds(n-1, 0, maxXYZZoom); east != 180 {
+		t.Errorf("last column east edge = %v, want 180", east)
+	}
+
+	minX, minY, maxX, maxY := XYZMeterBounds(n-1, 0, maxXYZZoom)
+	if !almostEqual(maxX, Equator/2, 1e-6) || !almostEqual(maxY, Equator/2, 1e-6) || minX >= maxX || minY >= maxY {
+		t.Errorf("top-right tile meter bounds = (%v, %v, %v, %v)", minX, minY, maxX, maxY)
+	}
+}
+
+func TestLatLonToMetersPoles(t *testing.T) {
+	for _, lat := range []float64{90, -90, MaxLatitude, -MaxLatitude} {
+		_, y := LatLonToMeters(lat, 0)
+		if math.IsInf(y, 0) || math.IsNaN(y) || !almostEqual(math.Abs(y), Equator/2, 1e-3) {
+			t.Errorf("LatLonToMeters(%v, 0) y = %v, want ±%v", lat, y, Equator/2)
+		}
+	}
+	if x, _ := LatLonToMeters(0, 180); !almostEqual(x, Equator/2, 1e-6) {
+		t.Errorf("LatLonToMeters(0, 180) x = %v, want %v", x, Equator/2)
+	}
+	if x, _ := LatLonToMeters(0, -180); !almostEqual(x, -Equator/2, 1e-6) {
+		t.Errorf("LatLonToMeters(0, -180) x = %v, want %v", x, -Equator/2)
+	}
+}
+
+func TestAntimeridian(t *testing.T) {
+	for _, tt := range []struct{ in, want float64 }{
+		{180, -180}, {-180, -180}, {190, -170}, {-190, 170}, {540, -180}, {0, 0},
+	} {
+		if got := WrapLongitude(tt.in); got != tt.want {
+			t.Errorf("WrapLongitude(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if w, e := NormalizeLonRange(170, 190); w != 170 || e != -170 {
+		t.Errorf("NormalizeLonRange(170, 190) = (%v, %v), want (170, -170)", w, e)
+	}
+	if w, e := NormalizeLonRange(-200, 200); w != -180 || e != 180 {
+		t.Errorf("NormalizeLonRange(-200, 200) = (%v, %v), want the whole world", w, e)
+	}
+	if span := LonSpan(170, -170); span != 20 {
+		t.Errorf("LonSpan(170, -170) = %v, want 20", span)
+	}
+	if c := CenterLongitude(170, -170); c != -180 {
+		t.Errorf("CenterLongitude(170, -170) = %v, want -180", c)
+	}
+
+	zoom := 4
+	n := NumTiles(zoom)
+	minX, _, maxX, _ := XYZRange(-10, 170, 10, -170, zoom)
+	if minX != n-1 || maxX != n {
+		t.Errorf("antimeridian XYZRange columns = %d..%d, want %d..%d", minX, maxX, n-1, n)
+	}
+	if col := WrapColumn(maxX, zoom); col != 0 {
+		t.Errorf("WrapColumn(%d) = %d, want 0", maxX, col)
+	}
+	if col := WrapColumn(-1, zoom); col != n-1 {
+		t.Errorf("WrapColumn(-1) = %d, want %d", col, n-1)
+	}
+	// A range wrapping most of the way round covers each column once
+	minX, _, maxX, _ = XYZRange(-10, 10, 10, -10, zoom)
+	if maxX-minX != n-1 {
+		t.Errorf("full-wrap XYZRange spans %d columns, want %d", maxX-minX+1, n)
+	}
+}
+
+// TestLegacyXYZ pins the taskqueue LatLonToTile/TileToLatLon outputs. TileToLatLon always
+// returned the top-left corner; only its doc comment (which said center) was corrected.
+func TestLegacyXYZ(t *testing.T) {
+	for _, zoom := range []int{0, 1, 5, 12, maxXYZZoom} {
+		for lat := -85.0; lat <= 85; lat += 8.5 {
+			for lon := -180.0; lon < 180; lon += 11.25 {
+				wx, wy := legacyLatLonToTile(lat, lon, zoom)
+				if x, y := LatLonToXYZ(lat, lon, zoom); x != wx || y != wy {
+					t.Errorf("LatLonToXYZ(%v, %v, %d) = (%d, %d), legacy (%d, %d)", lat, lon, zoom, x, y, wx, wy)
+				}
+			}
+		}
+		n := NumTiles(zoom)
+		for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n / 2, n / 3}} {
+			wlat, wlon := legacyTileToLatLon(tile[0], tile[1], zoom)
+			lat, lon := XYZToLatLon(float64(tile[0]), float64(tile[1]), zoom)
+			if !almostEqual(lat, wlat, 1e-9) || !almostEqual(lon, wlon, 1e-9) {
+				t.Errorf("XYZToLatLon(%v, %d) = (%v, %v), legacy (%v, %v)", tile, zoom, lat, lon, wlat, wlon)
+			}
+		}
+	}
+
+	// Edges the legacy conversion got the same way through its clamp
+	for _, p := range [][2]float64{{85.0512, 0}, {90, 0}, {0, 180}, {0, -180}} {
+		wx, wy := legacyLatLonToTile(p[0], p[1], maxXYZZoom)
+		if x, y := LatLonToXYZ(p[0], p[1], maxXYZZoom); x != wx || y != wy {
+			t.Errorf("LatLonToXYZ(%v, %v) = (%d, %d), legacy (%d, %d)", p[0], p[1], x, y, wx, wy)
+		}
+	}
+}
+
+// TestLegacyEsriMeters pins esri.TileToWebMercator, the top-left corner of the tile
+func TestLegacyEsriMeters(t *testing.T) {
+	for _, zoom := range []int{0, 7, maxXYZZoom} {
+		n := NumTiles(zoom)
+		for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n - 1, n - 1}, {n, n}} {
+			wx, wy := legacyEsriTileToWebMercator(tile[0], tile[1], zoom)
+			if x, y := XYZToMeters(float64(tile[0]), float64(tile[1]), zoom); x != wx || y != wy {
+				t.Errorf("XYZToMeters(%v, %d) = (%v, %v), legacy (%v, %v)", tile, zoom, x, y, wx, wy)
+			}
+		}
+	}
+}
+
+func TestFlipYAndResolution(t *testing.T) {
+	if y := FlipY(0, maxXYZZoom); y != NumTiles(maxXYZZoom)-1 {
+		t.Errorf("FlipY(0) = %d", y)
+	}
+	if y := FlipY(FlipY(5, 4), 4); y != 5 {
+		t.Errorf("FlipY is not its own inverse: %d", y)
+	}
+	if r := ResolutionAtZoom(0, 0); !almostEqual(r, Equator/TileSize, 1e-9) {
+		t.Errorf("ResolutionAtZoom(0, 0) = %v", r)
+	}
+	if r := ResolutionAtZoom(10, 90); !almostEqual(r, 0, 1e-9) {
+		t.Errorf("ResolutionAtZoom at the pole = %v, want ~0", r)
+	}
+}
@@ -0,0 +1,101 @@
+package tilemath
+
+import (
+	"fmt"
+	"math"
+)
+
+// Google Earth tiles use a Plate Carrée quadtree where both axes span -180..+180 degrees:
+// rows count from the south (row 0 at -180), and real latitudes (-90..+90) only cover the
+// middle half of the rows. Quadtree paths start with '0' and have one digit per level:
+//
+//	|-----|-----|
+//	|  3  |  2  |
+//	|-----|-----|
+//	|  0  |  1  |
+//	|-----|-----|
+
+// LatLonToGEFrac returns the fractional Google Earth row/column of a WGS84 coordinate
+func LatLonToGEFrac(lat, lon float64, level int) (row, col float64) {
+	n := float64(NumTiles(level))
+	row = (lat + 180.0) / 360.0 * n
+	col = (lon + 180.0) / 360.0 * n
+	return row, col
+}
+
+// LatLonToGE returns the Google Earth tile containing a WGS84 coordinate, clamped to the grid
+func LatLonToGE(lat, lon float64, level int) (row, col int) {
+	fr, fc := LatLonToGEFrac(lat, lon, level)
+	maxTile := NumTiles(level) - 1
+	return clamp(int(math.Floor(fr)), 0, maxTile), clamp(int(math.Floor(fc)), 0, maxTile)
+}
+
+// GEToDegrees converts a (fractional) Google Earth row or column to degrees
+func GEToDegrees(rowCol float64, level int) float64 {
+	return rowCol/float64(NumTiles(level))*360.0 - 180.0
+}
+
+// GEBounds returns the WGS84 bounds of a Google Earth tile (south, west, north, east)
+func GEBounds(row, col, level int) (south, west, north, east float64) {
+	south = GEToDegrees(float64(row), level)
+	west = GEToDegrees(float64(col), level)
+	north = GEToDegrees(float64(row+1), level)
+	east = GEToDegrees(float64(col+1), level)
+	return south, west, north, east
+}
+
+// GERange returns the inclusive Google Earth tile range covering a WGS84 bounding box.
+// When the box crosses the antimeridian (west > east) maxCol continues past the last
+// column; wrap each column with WrapColumn before requesting it.
+func GERange(south, west, north, east float64, level int) (minRow, minCol, maxRow, maxCol int) {
+	minRow, minCol = LatLonToGE(south, west, level)
+	maxRow, maxCol = LatLonToGE(north, east, level)
+	if CrossesAntimeridian(west, east) {
+		maxCol = unwrapMaxColumn(minCol, maxCol, level)
+	}
+	return minRow, minCol, maxRow, maxCol
+}
+
+// GEPath returns the quadtree path of a Google Earth tile
+func GEPath(row, col, level int) string {
+	chars := make([]byte, level+1)
+	r, c := row, col
+	for i := level; i >= 0; i-- {
+		rowBit := r & 1
+		colBit := c & 1
+		r >>= 1
+		c >>= 1
+		chars[i] = byte((rowBit << 1) | (rowBit ^ colBit) + '0')
+	}
+	return string(chars)
+}
+
+// GEPathToRowCol parses a Google Earth quadtree path
+func GEPathToRowCol(path string) (row, col, level int, err error) {
+	if len(path) == 0 || path[0] != '0' {
+		return 0, 0, 0, fmt.Errorf("invalid quadtree path: must start with '0'")
+	}
+	for i := 0; i < len(path); i++ {
+		cell := int(path[i] - '0')
+		if cell < 0 || cell > 3 {
+			return 0, 0, 0, fmt.Errorf("invalid quadtree path character: %c", path[i])
+		}
+		r := cell >> 1
+		c := r ^ (cell & 1)
+		row = (row << 1) | r
+		col = (col << 1) | c
+	}
+	return row, col, len(path) - 1, nil
+}
+
+// GEToMeters returns the Web Mercator position of a Google Earth tile corner (bottom-left
+// for integer arguments). Latitudes beyond the Web Mercator range are clamped.
+func GEToMeters(row, col, level int) (x, y float64) {
+	return LatLonToMeters(GEToDegrees(float64(row), level), GEToDegrees(float64(col), level))
+}
+
+// XYZToGE returns the Google Earth tile under the center of an XYZ tile at the same level
+func XYZToGE(x, y, zoom int) (row, col int) {
+	lat, lon := XYZToLatLon(float64(x)+0.5, float64(y)+0.5, zoom)
+	return LatLonToGE(lat, lon, zoom)
+}
@@ -0,0 +1,136 @@
+package tilemath
+
+import "testing"
+
+// maxGELevel is the deepest Google Earth quadtree level (googleearth.MaxLevel)
+const maxGELevel = 30
+
+func TestGEPathRoundTrip(t *testing.T) {
+	if path := GEPath(0, 0, 0); path != "0" {
+		t.Errorf("GEPath(0, 0, 0) = %q, want \"0\"", path)
+	}
+
+	for _, level := range []int{0, 1, 2, maxXYZZoom, maxGELevel} {
+		n := NumTiles(level)
+		for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n - 1, n - 1}, {n / 4, n / 2}, {3 * n / 4, n/2 + 1}} {
+			row, col := tile[0], min(tile[1], n-1)
+			path := GEPath(row, col, level)
+			if len(path) != level+1 || path[0] != '0' {
+				t.Errorf("GEPath(%d, %d, %d) = %q", row, col, level, path)
+			}
+			gr, gc, gl, err := GEPathToRowCol(path)
+			if err != nil || gr != row || gc != col || gl != level {
+				t.Errorf("GEPathToRowCol(%q) = (%d, %d, %d, %v), want (%d, %d, %d)", path, gr, gc, gl, err, row, col, level)
+			}
+		}
+	}
+
+	// Quadrants at level 1: 0 bottom-left, 1 bottom-right, 2 top-right, 3 top-left
+	for _, tt := range []struct {
+		row, col int
+		want     string
+	}{
+		{0, 0, "00"}, {0, 1, "01"}, {1, 1, "02"}, {1, 0, "03"},
+	} {
+		if path := GEPath(tt.row, tt.col, 1); path != tt.want {
+			t.Errorf("GEPath(%d, %d, 1) = %q, want %q", tt.row, tt.col, path, tt.want)
+		}
+	}
+
+	for _, path := range []string{"", "1", "014"} {
+		if _, _, _, err := GEPathToRowCol(path); err == nil {
+			t.Errorf("GEPathToRowCol(%q) accepted an invalid path", path)
+		}
+	}
+}
+
+func TestLatLonToGEEdges(t *testing.T) {
+	n := NumTiles(maxGELevel)
+	tests := []struct {
+		name     string
+		lat, lon float64
+		level    int
+		row, col int
+	}{
+		{"level 0 is one tile", 45, 90, 0, 0, 0},
+		{"level 0 poles", 90, 180, 0, 0, 0},
+		{"west edge", 0, -180, maxGELevel, n / 2, 0},
+		{"east edge clamps to the last column", 0, 180, maxGELevel, n / 2, n - 1},
+		// Latitudes cover the middle half of the rows
+		{"south pole", -90, 0, maxGELevel, n / 4, n / 2},
+		{"north pole", 90, 0, maxGELevel, 3 * n / 4, n / 2},
+		{"south Mercator limit", -MaxLatitude, 0, maxGELevel, int(float64(n) * (180 - MaxLatitude) / 360), n / 2},
+	}
+	for _, tt := range tests {
+		row, col := LatLonToGE(tt.lat, tt.lon, tt.level)
+		if row != tt.row || col != tt.col {
+			t.Errorf("%s: LatLonToGE(%v, %v, %d) = (%d, %d), want (%d, %d)", tt.name, tt.lat, tt.lon, tt.level, row, col, tt.row, tt.col)
+		}
+	}
+}
+
+func TestGEBoundsEdges(t *testing.T) {
+	if south, west, north, east := GEBounds(0, 0, 0); south != -180 || west != -180 || north != 180 || east != 180 {
+		t.Errorf("level 0 bounds = (%v, %v, %v, %v), want ±180", south, west, north, east)
+	}
+	n := NumTiles(maxGELevel)
+	if _, _, _, east := GEBounds(0, n-1, maxGELevel); east != 180 {
+		t.Errorf("last column east edge = %v, want 180", east)
+	}
+	if south, _, _, _ := GEBounds(3*n/4, 0, maxGELevel); south != 90 {
+		t.Errorf("row 3n/4 south edge = %v, want 90", south)
+	}
+
+	// Antimeridian ranges continue past the last column
+	level := 5
+	m := NumTiles(level)
+	_, minCol, _, maxCol := GERange(-10, 170, 10, -170, level)
+	if minCol != m-1 || maxCol != m {
+		t.Errorf("antimeridian GERange columns = %d..%d, want %d..%d", minCol, maxCol, m-1, m)
+	}
+}
+
+// TestLegacyXYZToGE pins googleearth.NewTileFromXYZ: the GE tile under the XYZ tile center
+func TestLegacyXYZToGE(t *testing.T) {
+	for zoom := 0; zoom <= 6; zoom++ {
+		n := NumTiles(zoom)
+		for x := 0; x < n; x++ {
+			for y := 0; y < n; y++ {
+				wr, wc := legacyGENewTileFromXYZ(x, y, zoom)
+				if row, col := XYZToGE(x, y, zoom); row != wr || col != wc {
+					t.Errorf("XYZToGE(%d, %d, %d) = (%d, %d), legacy (%d, %d)", x, y, zoom, row, col, wr, wc)
+				}
+			}
+		}
+	}
+	n := NumTiles(maxXYZZoom)
+	for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n - 1, n - 1}, {n / 2, n / 2}} {
+		wr, wc := legacyGENewTileFromXYZ(tile[0], tile[1], maxXYZZoom)
+		if row, col := XYZToGE(tile[0], tile[1], maxXYZZoom); row != wr || col != wc {
+			t.Errorf("XYZToGE(%v, %d) = (%d, %d), legacy (%d, %d)", tile, maxXYZZoom, row, col, wr, wc)
+		}
+	}
+}
+
+// TestLegacyGEToMeters pins googleearth.TileToWebMercator, the bottom-left corner of the
+// tile (its doc comment said top-left). The legacy equator (40075016.686 m) and clamp
+// latitude (85.051129°) were rounded: positions agree within a millimetre, and within
+// 0.3 m on rows clamped to the Mercator limit.
+func TestLegacyGEToMeters(t *testing.T) {
+	for _, level := range []int{0, 3, 12, maxXYZZoom} {
+		n := NumTiles(level)
+		for _, tile := range [][2]int{{0, 0}, {n / 4, 0}, {n / 2, n / 2}, {3 * n / 4, n - 1}, {n - 1, n - 1}, {5 * n / 8, n / 3}} {
+			row, col := tile[0], tile[1]
+			wx, wy := legacyGETileToWebMercator(row, col, level)
+			x, y := GEToMeters(row, col, level)
+			lat := GEToDegrees(float64(row), level)
+			tolerance := 1e-3
+			if lat >= 85.051128 || lat <= -85.051128 {
+				tolerance = 0.3
+			}
+			if !almostEqual(x, wx, 1e-3) || !almostEqual(y, wy, tolerance) {
+				t.Errorf("GEToMeters(%d, %d, %d) = (%v, %v), legacy (%v, %v)", row, col, level, x, y, wx, wy)
+			}
+		}
+	}
+}
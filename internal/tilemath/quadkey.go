@@ -0,0 +1,44 @@
+package tilemath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quadkey returns the Bing-style quadkey of an XYZ tile (one digit per zoom level)
+func Quadkey(x, y, zoom int) string {
+	var quadkey strings.Builder
+	for i := zoom; i > 0; i-- {
+		digit := 0
+		mask := 1 << (i - 1)
+		if x&mask != 0 {
+			digit++
+		}
+		if y&mask != 0 {
+			digit += 2
+		}
+		quadkey.WriteByte(byte('0' + digit))
+	}
+	return quadkey.String()
+}
+
+// QuadkeyToXYZ parses a Bing-style quadkey; the empty quadkey is the zoom 0 tile
+func QuadkeyToXYZ(quadkey string) (x, y, zoom int, err error) {
+	zoom = len(quadkey)
+	for i := 0; i < zoom; i++ {
+		mask := 1 << (zoom - 1 - i)
+		switch quadkey[i] {
+		case '0':
+		case '1':
+			x |= mask
+		case '2':
+			y |= mask
+		case '3':
+			x |= mask
+			y |= mask
+		default:
+			return 0, 0, 0, fmt.Errorf("invalid quadkey digit: %c", quadkey[i])
+		}
+	}
+	return x, y, zoom, nil
+}
@@ -0,0 +1,77 @@
+package tilemath
+
+import "testing"
+
+func TestQuadkeyRoundTrip(t *testing.T) {
+	if key := Quadkey(0, 0, 0); key != "" {
+		t.Errorf("Quadkey(0, 0, 0) = %q, want empty", key)
+	}
+	if x, y, zoom, err := QuadkeyToXYZ(""); err != nil || x != 0 || y != 0 || zoom != 0 {
+		t.Errorf("QuadkeyToXYZ(\"\") = (%d, %d, %d, %v)", x, y, zoom, err)
+	}
+
+	for _, zoom := range []int{1, 2, maxXYZZoom} {
+		n := NumTiles(zoom)
+		for _, tile := range [][2]int{{0, 0}, {n - 1, 0}, {0, n - 1}, {n - 1, n - 1}, {n / 2, n/2 - 1}} {
+			key := Quadkey(tile[0], tile[1], zoom)
+			if len(key) != zoom {
+				t.Errorf("Quadkey(%v, %d) = %q, want %d digits", tile, zoom, key, zoom)
+			}
+			x, y, z, err := QuadkeyToXYZ(key)
+			if err != nil || x != tile[0] || y != tile[1] || z != zoom {
+				t.Errorf("QuadkeyToXYZ(%q) = (%d, %d, %d, %v), want (%d, %d, %d)", key, x, y, z, err, tile[0], tile[1], zoom)
+			}
+		}
+	}
+
+	// Known keys: the east edge is all 1s, the bottom-right corner all 3s
+	n := NumTiles(3)
+	for _, tt := range []struct {
+		x, y int
+		want string
+	}{
+		{0, 0, "000"}, {n - 1, 0, "111"}, {0, n - 1, "222"}, {n - 1, n - 1, "333"}, {3, 5, "213"},
+	} {
+		if key := Quadkey(tt.x, tt.y, 3); key != tt.want {
+			t.Errorf("Quadkey(%d, %d, 3) = %q, want %q", tt.x, tt.y, key, tt.want)
+		}
+	}
+
+	if _, _, _, err := QuadkeyToXYZ("0124"); err == nil {
+		t.Error("QuadkeyToXYZ accepted an invalid digit")
+	}
+}
+
+// TestLegacyGenerateQuadkey pins naming.GenerateQuadkey inside the Web Mercator world, and
+// the edges where the consolidation changed it: a center on lon 180 now lies in the last
+// column (the legacy key wrapped to column 0), and centers beyond ±85.0511° clamp to the
+// edge rows (the legacy key overflowed the row bits).
+func TestLegacyGenerateQuadkey(t *testing.T) {
+	generate := func(south, west, north, east float64, zoom int) string {
+		x, y := LatLonToXYZ((south+north)/2, (west+east)/2, zoom)
+		return Quadkey(x, y, zoom)
+	}
+
+	for _, zoom := range []int{1, 8, 16, maxXYZZoom} {
+		for lat := -84.0; lat <= 84; lat += 12 {
+			for lon := -179.0; lon < 180; lon += 17 {
+				want := legacyGenerateQuadkey(lat-0.5, lon-0.5, lat+0.5, lon+0.5, zoom)
+				if got := generate(lat-0.5, lon-0.5, lat+0.5, lon+0.5, zoom); got != want {
+					t.Errorf("quadkey around (%v, %v) at zoom %d = %q, legacy %q", lat, lon, zoom, got, want)
+				}
+			}
+		}
+	}
+
+	zoom := 4
+	n := NumTiles(zoom)
+	if got, want := generate(-1, 179, 1, 181, zoom), Quadkey(n-1, n/2, zoom); got != want {
+		t.Errorf("quadkey centered on lon 180 = %q, want last column %q", got, want)
+	}
+	if got, want := generate(88, 0, 90, 1, zoom), Quadkey(n/2, 0, zoom); got != want {
+		t.Errorf("quadkey centered at lat 89 = %q, want first row %q", got, want)
+	}
+	if got, want := generate(-90, 0, -88, 1, zoom), Quadkey(n/2, n-1, zoom); got != want {
+		t.Errorf("quadkey centered at lat -89 = %q, want last row %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxMetadataSize bounds the checksum and signature downloads
+const maxMetadataSize = 1 << 20
+
+// Download fetches the archive of release into the staging directory and verifies it
+// against the release's SHA256SUMS and their signature. Builds without a signing key
+// cannot download updates. On success the archive is staged for Install.
+func (u *Updater) Download(ctx context.Context, release *Release, onProgress func(Progress)) error {
+	if release == nil || release.assetURL == "" {
+		return fmt.Errorf("no update to download")
+	}
+	if u.publicKey == nil {
+		return errNoSigningKey
+	}
+
+	sums, err := u.fetch(ctx, release.checksumsURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksums: %w", err)
+	}
+	if release.signatureURL == "" {
+		return fmt.Errorf("release %s is not signed", release.Version)
+	}
+	sig, err := u.fetch(ctx, release.signatureURL)
+	if err != nil {
+		return fmt.Errorf("failed to download signature: %w", err)
+	}
+	if err := verifySignature(u.publicKey, sums, sig); err != nil {
+		return err
+	}
+	want, err := checksumFor(sums, release.AssetName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(u.stagingDir, 0755); err != nil {
+		return fmt.Errorf("failed to create update directory: %w", err)
+	}
+	archivePath := filepath.Join(u.stagingDir, release.AssetName)
+	partPath := archivePath + ".part"
+
+	got, err := u.downloadFile(ctx, release.assetURL, partPath, release.AssetSize, onProgress)
+	if err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	if !strings.EqualFold(got, want) {
+		os.Remove(partPath)
+		return fmt.Errorf("checksum mismatch for %s (expected %s, got %s)", release.AssetName, want, got)
+	}
+	if err := os.Rename(partPath, archivePath); err != nil {
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+
+	u.mu.Lock()
+	u.staged = archivePath
+	u.mu.Unlock()
+	return nil
+}
+
+// downloadFile streams url to path and returns the hex sha256 of the content
+func (u *Updater) downloadFile(ctx context.Context, url, path string, size int64, onProgress func(Progress)) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download update: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > 0 {
+		size = resp.ContentLength
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	var downloaded int64
+	buf := make([]byte, 256*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				return "", fmt.Errorf("failed to write update: %w", err)
+			}
+			hash.Write(buf[:n])
+			downloaded += int64(n)
+			if onProgress != nil {
+				onProgress(Progress{Downloaded: downloaded, Total: size})
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to download update: %w", readErr)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write update: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetch downloads a small release asset into memory
+func (u *Updater) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+}
+
+// verifySignature checks the base64 ed25519 signature of the checksum file
+func verifySignature(key ed25519.PublicKey, sums, sigB64 []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigB64)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed update signature")
+	}
+	if !ed25519.Verify(key, sums, sig) {
+		return fmt.Errorf("update signature is invalid")
+	}
+	return nil
+}
+
+// checksumFor finds the hash of name in a sha256sum-format checksum file
+// ("<hex>  <name>" per line, with "*" before the name in binary mode)
+func checksumFor(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		if filepath.Base(file) == name && len(fields[0]) == sha256.Size*2 {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s is not listed in %s", name, ChecksumsAsset)
+}
@@ -0,0 +1,332 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// errNoSigningKey refuses updates in builds without a release signing key: a checksum
+// served next to the archive proves nothing about who built it
+var errNoSigningKey = errors.New("this build cannot verify update signatures; download the new version from the releases page")
+
+// oldSuffix marks the previous installation while it is replaced; it is removed on the
+// next start (a running Windows executable can be renamed but not deleted)
+const oldSuffix = ".old"
+
+// Staged reports whether a verified update is ready to install
+func (u *Updater) Staged() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.staged != ""
+}
+
+// Install replaces the running installation with the staged update and returns the path
+// to launch the new version with (see Relaunch). The running process keeps working from
+// the renamed previous installation until it exits.
+func (u *Updater) Install() (string, error) {
+	if u.publicKey == nil {
+		return "", errNoSigningKey
+	}
+
+	u.mu.Lock()
+	archivePath := u.staged
+	u.mu.Unlock()
+	if archivePath == "" {
+		return "", fmt.Errorf("no update has been downloaded")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running app: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", fmt.Errorf("failed to locate the running app: %w", err)
+	}
+
+	extractDir := filepath.Join(u.stagingDir, "extracted")
+	os.RemoveAll(extractDir)
+	if err := extract(archivePath, extractDir); err != nil {
+		return "", fmt.Errorf("failed to unpack update: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	var launch string
+	if runtime.GOOS == "darwin" {
+		launch, err = installBundle(exe, extractDir)
+	} else {
+		launch, err = installBinary(exe, extractDir)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	os.Remove(archivePath)
+	u.mu.Lock()
+	u.staged = ""
+	u.mu.Unlock()
+	return launch, nil
+}
+
+// installBundle swaps the macOS .app bundle containing exe for the one in extractDir
+func installBundle(exe, extractDir string) (string, error) {
+	bundle := filepath.Dir(filepath.Dir(filepath.Dir(exe))) // X.app/Contents/MacOS/exe
+	if !strings.HasSuffix(bundle, ".app") {
+		return "", fmt.Errorf("the app is not running from an application bundle")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(extractDir, "*.app"))
+	if len(matches) != 1 {
+		return "", fmt.Errorf("update archive does not contain an application bundle")
+	}
+
+	if err := swap(bundle, matches[0]); err != nil {
+		return "", err
+	}
+	return bundle, nil
+}
+
+// installBinary swaps exe and the ffmpeg next to it for the ones in extractDir
+func installBinary(exe, extractDir string) (string, error) {
+	entries, err := os.ReadDir(extractDir)
+	if err != nil {
+		return "", err
+	}
+	var newExe, newFFmpeg string
+	for _, e := range entries {
+		switch name := e.Name(); {
+		case strings.HasPrefix(name, "ffmpeg"):
+			newFFmpeg = filepath.Join(extractDir, name)
+		case strings.HasPrefix(name, "imagery-desktop"):
+			newExe = filepath.Join(extractDir, name)
+		}
+	}
+	if newExe == "" {
+		return "", fmt.Errorf("update archive does not contain the app")
+	}
+
+	if err := swap(exe, newExe); err != nil {
+		return "", err
+	}
+	if newFFmpeg != "" {
+		if err := swap(filepath.Join(filepath.Dir(exe), filepath.Base(newFFmpeg)), newFFmpeg); err != nil {
+			log.Printf("[Updater] Failed to update ffmpeg: %v", err)
+		}
+	}
+	return exe, nil
+}
+
+// swap moves the current file or directory at target aside and the replacement into its
+// place, restoring the original if the move fails
+func swap(target, replacement string) error {
+	old := target + oldSuffix
+	os.RemoveAll(old)
+
+	exists := true
+	if err := os.Rename(target, old); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to replace %s (is the folder writable?): %w", filepath.Base(target), err)
+		}
+		exists = false
+	}
+	if err := os.Rename(replacement, target); err != nil {
+		if exists {
+			os.Rename(old, target)
+		}
+		return fmt.Errorf("failed to install %s: %w", filepath.Base(target), err)
+	}
+	return nil
+}
+
+// Relaunch starts the installed version at path (from Install). The caller should quit
+// right after.
+func Relaunch(path string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("open", "-n", path)
+	} else {
+		cmd = exec.Command(path)
+		cmd.Dir = filepath.Dir(path)
+	}
+	return cmd.Start()
+}
+
+// CleanupPrevious removes what an earlier update left behind: the previous installation
+// and unpacked or partial downloads
+func (u *Updater) CleanupPrevious() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return
+	}
+
+	targets := []string{exe, filepath.Join(filepath.Dir(exe), "ffmpeg"), filepath.Join(filepath.Dir(exe), "ffmpeg.exe")}
+	if runtime.GOOS == "darwin" {
+		targets = []string{filepath.Dir(filepath.Dir(filepath.Dir(exe)))}
+	}
+	for _, t := range targets {
+		if _, err := os.Stat(t + oldSuffix); err == nil {
+			if err := os.RemoveAll(t + oldSuffix); err != nil {
+				log.Printf("[Updater] Failed to remove previous version %s: %v", t+oldSuffix, err)
+			}
+		}
+	}
+
+	os.RemoveAll(filepath.Join(u.stagingDir, "extracted"))
+	partials, _ := filepath.Glob(filepath.Join(u.stagingDir, "*.part"))
+	for _, p := range partials {
+		os.Remove(p)
+	}
+}
+
+// extract unpacks a .zip or .tar.gz archive into dir
+func extract(archivePath, dir string) error {
+	if strings.HasSuffix(archivePath, ".zip") {
+		return extractZip(archivePath, dir)
+	}
+	return extractTarGz(archivePath, dir)
+}
+
+func extractZip(archivePath, dir string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(dir, f.Name, f.Mode(), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarGz(archivePath, dir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mode := hdr.FileInfo().Mode()
+		if mode&os.ModeSymlink != 0 {
+			if err := writeEntry(dir, hdr.Name, mode, strings.NewReader(hdr.Linkname)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeEntry(dir, hdr.Name, mode, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeEntry writes one archive entry below dir. Symlink entries carry their target as
+// content. Entries escaping dir, symlinks pointing outside it and writes through an
+// already extracted symlink are rejected.
+func writeEntry(dir, name string, mode os.FileMode, content io.Reader) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if !within(dir, path) {
+		return fmt.Errorf("archive entry %q escapes the target directory", name)
+	}
+	if err := checkNoSymlinks(dir, path); err != nil {
+		return fmt.Errorf("archive entry %q: %w", name, err)
+	}
+
+	switch {
+	case mode.IsDir():
+		return os.MkdirAll(path, 0755)
+	case mode&os.ModeSymlink != 0:
+		target, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		// Bundles link within themselves (e.g. framework versions); anything else is refused
+		linkTarget := filepath.FromSlash(string(target))
+		if filepath.IsAbs(linkTarget) || !within(dir, filepath.Join(filepath.Dir(path), linkTarget)) {
+			return fmt.Errorf("archive symlink %q points outside the target directory", name)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(string(target), path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, content); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// within reports whether path is dir or below it
+func within(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
+
+// checkNoSymlinks fails when path or a folder between dir and path is a symlink, so an
+// entry can never be written through a link an earlier entry created
+func checkNoSymlinks(dir, path string) error {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." {
+		return err
+	}
+	current := dir
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", filepath.Base(current))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package updater checks GitHub releases for new builds of the app, downloads and verifies
+// the archive for the running platform and swaps it in place of the running installation.
+//
+// Every release carries a SHA256SUMS asset listing the hash of each archive, and
+// SHA256SUMS must carry a valid ed25519 signature (SHA256SUMS.sig, base64) from the release
+// signing key embedded in the build, so a compromised download host cannot ship a build.
+// Builds without a key only report new releases.
+package updater
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"imagery-desktop/internal/netproxy"
+)
+
+const (
+	// ReleasesURL lists the app's GitHub releases, newest first
+	ReleasesURL = "https://api.github.com/repos/walkthru-earth/imagery-desktop/releases?per_page=30"
+
+	// ChannelStable only offers full releases
+	ChannelStable = "stable"
+
+	// ChannelBeta also offers pre-releases
+	ChannelBeta = "beta"
+
+	// ChecksumsAsset and SignatureAsset are the verification assets of a release
+	ChecksumsAsset = "SHA256SUMS"
+	SignatureAsset = "SHA256SUMS.sig"
+)
+
+// ValidChannel reports whether channel is a known release channel
+func ValidChannel(channel string) bool {
+	return channel == ChannelStable || channel == ChannelBeta
+}
+
+// Release describes an update offered to the user
+type Release struct {
+	Version     string    `json:"version"` // Without the leading "v"
+	Name        string    `json:"name"`
+	Notes       string    `json:"notes"` // Markdown release notes
+	URL         string    `json:"url"`   // Release page
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"publishedAt"`
+	AssetName   string    `json:"assetName"`
+	AssetSize   int64     `json:"assetSize"`
+
+	assetURL     string
+	checksumsURL string
+	signatureURL string
+}
+
+// Progress reports an update download
+type Progress struct {
+	Downloaded int64 `json:"downloaded"`
+	Total      int64 `json:"total"`
+}
+
+// githubRelease is the subset of the GitHub releases API we need
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
+	} `json:"assets"`
+}
+
+// Updater checks for, downloads and installs updates
+type Updater struct {
+	currentVersion string
+	publicKey      ed25519.PublicKey // nil = updates can be found but not installed
+	stagingDir     string            // Downloaded archives and extracted builds
+	httpClient     *http.Client
+
+	mu     sync.Mutex
+	staged string // Verified archive ready to install
+}
+
+// New creates an updater for the running version. publicKeyB64 is the base64 ed25519
+// release signing key ("" for builds without one, which only check for updates). Archives
+// are staged in stagingDir.
+func New(currentVersion, publicKeyB64, stagingDir string) (*Updater, error) {
+	u := &Updater{
+		currentVersion: strings.TrimPrefix(currentVersion, "v"),
+		stagingDir:     stagingDir,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Minute, // Covers the archive download
+			Transport: netproxy.NewTransport(),
+		},
+	}
+	if publicKeyB64 != "" {
+		key, err := base64.StdEncoding.DecodeString(publicKeyB64)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid update signing key")
+		}
+		u.publicKey = key
+	}
+	return u, nil
+}
+
+// IsDevBuild reports whether the running build is a local development build, which the
+// automatic startup check skips
+func (u *Updater) IsDevBuild() bool {
+	return strings.HasSuffix(u.currentVersion, "-dev")
+}
+
+// Check returns the newest release on channel that is newer than the running version,
+// or nil when the app is up to date
+func (u *Updater) Check(channel string) (*Release, error) {
+	if !ValidChannel(channel) {
+		return nil, fmt.Errorf("unknown update channel %q", channel)
+	}
+
+	req, err := http.NewRequest("GET", ReleasesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check for updates: HTTP %d", resp.StatusCode)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+	return u.pickRelease(releases, channel), nil
+}
+
+// pickRelease returns the newest release on channel that is newer than the running
+// version and has an archive for this platform
+func (u *Updater) pickRelease(releases []githubRelease, channel string) *Release {
+	var best *Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		version := strings.TrimPrefix(r.TagName, "v")
+		if compareVersions(version, u.currentVersion) <= 0 {
+			continue
+		}
+		if best != nil && compareVersions(version, best.Version) <= 0 {
+			continue
+		}
+
+		candidate := &Release{
+			Version:     version,
+			Name:        r.Name,
+			Notes:       r.Body,
+			URL:         r.HTMLURL,
+			Prerelease:  r.Prerelease,
+			PublishedAt: r.PublishedAt,
+		}
+		wantAsset := assetName(version)
+		for _, a := range r.Assets {
+			switch a.Name {
+			case wantAsset:
+				candidate.AssetName, candidate.AssetSize, candidate.assetURL = a.Name, a.Size, a.BrowserDownloadURL
+			case ChecksumsAsset:
+				candidate.checksumsURL = a.BrowserDownloadURL
+			case SignatureAsset:
+				candidate.signatureURL = a.BrowserDownloadURL
+			}
+		}
+		if candidate.assetURL == "" || candidate.checksumsURL == "" {
+			continue // Not built for this platform, or published without checksums
+		}
+		best = candidate
+	}
+	return best
+}
+
+// assetName returns the release archive name for the running platform
+// (see the packaging steps in .github/workflows/release.yaml)
+func assetName(version string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return fmt.Sprintf("imagery-desktop-%s-macos-%s.zip", version, runtime.GOARCH)
+	case "windows":
+		return fmt.Sprintf("imagery-desktop-%s-windows-%s.zip", version, runtime.GOARCH)
+	default:
+		return fmt.Sprintf("imagery-desktop-%s-%s-%s.tar.gz", version, runtime.GOOS, runtime.GOARCH)
+	}
+}
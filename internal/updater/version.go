@@ -0,0 +1,84 @@
+package updater
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two semantic versions (without the leading "v") and returns
+// -1, 0 or 1. A pre-release sorts before its release (1.2.0-beta.2 < 1.2.0), and
+// unparseable versions sort before everything else.
+func compareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+
+	for i := 0; i < 3; i++ {
+		if aCore[i] != bCore[i] {
+			return sign(aCore[i] - bCore[i])
+		}
+	}
+
+	switch {
+	case aPre == "" && bPre == "":
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePrerelease(aPre, bPre)
+}
+
+// splitVersion splits "1.2.3-beta.1+build" into [1 2 3] and "beta.1". Missing or invalid
+// numbers are -1.
+func splitVersion(v string) ([3]int, string) {
+	core := [3]int{-1, -1, -1}
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i] // Build metadata does not affect precedence
+	}
+	pre := ""
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	for i, part := range strings.SplitN(v, ".", 3) {
+		if n, err := strconv.Atoi(part); err == nil && n >= 0 {
+			core[i] = n
+		}
+	}
+	return core, pre
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers: numeric ones
+// numerically and below alphanumeric ones, a shorter list first when all else is equal
+func comparePrerelease(a, b string) int {
+	aIDs, bIDs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return sign(aNum - bNum)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(aIDs) - len(bIDs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
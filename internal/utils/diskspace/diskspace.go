@@ -0,0 +1,7 @@
+// Package diskspace reports the free space of the volume holding a path
+package diskspace
+
+// Free returns the bytes available to the current user on the volume holding path
+func Free(path string) (uint64, error) {
+	return free(path)
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package diskspace
+
+import "errors"
+
+func free(path string) (uint64, error) {
+	return 0, errors.New("free disk space is not available on this system")
+}
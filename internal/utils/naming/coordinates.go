@@ -4,33 +4,15 @@ import (
 	"fmt"
 	"math"
 	"strings"
+
+	"imagery-desktop/internal/tilemath"
 )
 
 // GenerateQuadkey generates a quadkey string for a tile at zoom level z covering a bbox
 // Uses the center tile as reference
 func GenerateQuadkey(south, west, north, east float64, zoom int) string {
-	centerLat := (south + north) / 2
-	centerLon := (west + east) / 2
-
-	// Convert to tile coordinates (Web Mercator)
-	n := math.Pow(2, float64(zoom))
-	x := int((centerLon + 180.0) / 360.0 * n)
-	y := int((1.0 - math.Log(math.Tan(centerLat*math.Pi/180.0)+1.0/math.Cos(centerLat*math.Pi/180.0))/math.Pi) / 2.0 * n)
-
-	// Generate quadkey from x, y, z
-	var quadkey strings.Builder
-	for i := zoom; i > 0; i-- {
-		digit := 0
-		mask := 1 << (i - 1)
-		if (x & mask) != 0 {
-			digit++
-		}
-		if (y & mask) != 0 {
-			digit += 2
-		}
-		quadkey.WriteByte(byte('0' + digit))
-	}
-	return quadkey.String()
+	x, y := tilemath.LatLonToXYZ((south+north)/2, tilemath.CenterLongitude(west, east), zoom)
+	return tilemath.Quadkey(x, y, zoom)
 }
 
 // GenerateBBoxString creates a human-readable bbox string for filenames
@@ -0,0 +1,94 @@
+package naming
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Date format presets for video overlays and filenames
+const (
+	DateFormatISO     = "iso"      // 2024-03-15 (default for filenames)
+	DateFormatDMY     = "dmy"      // 15/03/2024 in overlays, 15-03-2024 in filenames
+	DateFormatMDY     = "mdy"      // 03/15/2024 in overlays, 03-15-2024 in filenames
+	DateFormatLong    = "long"     // Mar 15, 2024 (default for overlays, not for filenames)
+	DateFormatLongDMY = "long_dmy" // 15 Mar 2024 (overlays only)
+)
+
+// isoLayout is the layout dates travel in between the app's components
+const isoLayout = "2006-01-02"
+
+// overlayLayouts and fileLayouts map presets to Go time layouts. Filename layouts avoid
+// '/', and only numeric ones are offered so exports of one area still sort together.
+var (
+	overlayLayouts = map[string]string{
+		DateFormatISO:     isoLayout,
+		DateFormatDMY:     "02/01/2006",
+		DateFormatMDY:     "01/02/2006",
+		DateFormatLong:    "Jan 02, 2006",
+		DateFormatLongDMY: "02 Jan 2006",
+	}
+	fileLayouts = map[string]string{
+		DateFormatISO: isoLayout,
+		DateFormatDMY: "02-01-2006",
+		DateFormatMDY: "01-02-2006",
+	}
+)
+
+// OverlayDateLayout returns the Go time layout of an overlay date format ("" = long)
+func OverlayDateLayout(format string) (string, error) {
+	if format == "" {
+		format = DateFormatLong
+	}
+	layout, ok := overlayLayouts[format]
+	if !ok {
+		return "", fmt.Errorf("overlay date format must be '%s', '%s', '%s', '%s' or '%s'",
+			DateFormatISO, DateFormatDMY, DateFormatMDY, DateFormatLong, DateFormatLongDMY)
+	}
+	return layout, nil
+}
+
+// ValidateFilenameDateFormat checks a filename date format ("" = iso)
+func ValidateFilenameDateFormat(format string) error {
+	if _, ok := fileLayouts[format]; !ok && format != "" {
+		return fmt.Errorf("filename date format must be '%s', '%s' or '%s'", DateFormatISO, DateFormatDMY, DateFormatMDY)
+	}
+	return nil
+}
+
+var (
+	fileFormatMu sync.RWMutex
+	fileLayout   = isoLayout
+)
+
+// SetFilenameDateFormat selects how dates are written in the names generated by this
+// package (thread-safe). Unknown formats fall back to iso.
+func SetFilenameDateFormat(format string) {
+	layout, ok := fileLayouts[format]
+	if !ok {
+		layout = isoLayout
+	}
+	fileFormatMu.Lock()
+	defer fileFormatMu.Unlock()
+	fileLayout = layout
+}
+
+// fileDate rewrites a YYYY-MM-DD date in the filename date format. Other date strings
+// (e.g. custom source time values) are returned unchanged.
+func fileDate(date string) string {
+	fileFormatMu.RLock()
+	layout := fileLayout
+	fileFormatMu.RUnlock()
+	return formatFileDate(date, layout)
+}
+
+func formatFileDate(date, layout string) string {
+	if layout == isoLayout {
+		return date
+	}
+	t, err := time.Parse(isoLayout, date)
+	if err != nil {
+		return date
+	}
+	return t.Format(layout)
+}
@@ -5,8 +5,25 @@ import (
 )
 
 // GenerateGeoTIFFFilename creates a standardized GeoTIFF filename with metadata
-// Format: {source}_{date}_{quadkey}_z{zoom}_{bbox}.tif
+// Format: {source}_{date}_{quadkey}_z{zoom}_{bbox}.tif (date in the filename date format)
 func GenerateGeoTIFFFilename(source, date string, south, west, north, east float64, zoom int) string {
+	return geoTIFFFilename(source, fileDate(date), south, west, north, east, zoom)
+}
+
+// GeoTIFFFilenameVariants returns the GeoTIFF filename of an export under every filename
+// date format, the current one first, to find exports made before the format changed
+func GeoTIFFFilenameVariants(source, date string, south, west, north, east float64, zoom int) []string {
+	names := []string{GenerateGeoTIFFFilename(source, date, south, west, north, east, zoom)}
+	for _, layout := range []string{isoLayout, fileLayouts[DateFormatDMY], fileLayouts[DateFormatMDY]} {
+		name := geoTIFFFilename(source, formatFileDate(date, layout), south, west, north, east, zoom)
+		if name != names[0] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func geoTIFFFilename(source, date string, south, west, north, east float64, zoom int) string {
 	quadkey := GenerateQuadkey(south, west, north, east, zoom)
 
 	// Short bbox representation for filename
@@ -20,7 +37,13 @@ func GenerateGeoTIFFFilename(source, date string, south, west, north, east float
 }
 
 // GenerateTilesDirName creates a standardized tiles directory name
-// Format: {source}_{date}_z{zoom}_tiles
+// Format: {source}_{date}_z{zoom}_tiles (date in the filename date format)
 func GenerateTilesDirName(source, date string, zoom int) string {
-	return fmt.Sprintf("%s_%s_z%d_tiles", source, date, zoom)
+	return fmt.Sprintf("%s_%s_z%d_tiles", source, fileDate(date), zoom)
+}
+
+// GenerateDeltaManifestName creates a standardized name for an incremental archive manifest
+// Format: {source}_{date}_z{zoom}_delta.json (date in the filename date format)
+func GenerateDeltaManifestName(source, date string, zoom int) string {
+	return fmt.Sprintf("%s_%s_z%d_delta.json", source, fileDate(date), zoom)
 }
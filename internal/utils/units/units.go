@@ -0,0 +1,55 @@
+// Package units formats distances and ground resolutions in the user's unit system.
+package units
+
+import (
+	"fmt"
+)
+
+// Unit systems
+const (
+	Metric   = "metric"   // Meters and kilometers (default)
+	Imperial = "imperial" // Feet and miles
+)
+
+const (
+	metersPerFoot = 0.3048
+	metersPerMile = 1609.344
+	kmPerMile     = metersPerMile / 1000
+)
+
+// Validate checks a unit system ("" = metric)
+func Validate(system string) error {
+	switch system {
+	case "", Metric, Imperial:
+		return nil
+	}
+	return fmt.Errorf("unit system must be '%s' or '%s'", Metric, Imperial)
+}
+
+// FormatResolution formats a ground resolution given in meters per pixel
+func FormatResolution(metersPerPixel float64, system string) string {
+	if system == Imperial {
+		return fmt.Sprintf("%.2f ft/px", metersPerPixel/metersPerFoot)
+	}
+	return fmt.Sprintf("%.2f m/px", metersPerPixel)
+}
+
+// FormatDistance formats a distance given in meters: meters or feet below 1 km (or 1 mile),
+// kilometers or miles above
+func FormatDistance(meters float64, system string) string {
+	if system == Imperial {
+		if meters < metersPerMile {
+			return fmt.Sprintf("%.0f ft", meters/metersPerFoot)
+		}
+		return fmt.Sprintf("%.2f mi", meters/metersPerMile)
+	}
+	if meters < 1000 {
+		return fmt.Sprintf("%.0f m", meters)
+	}
+	return fmt.Sprintf("%.2f km", meters/1000)
+}
+
+// MilesToKm converts miles to kilometers
+func MilesToKm(miles float64) float64 {
+	return miles * kmPerMile
+}
@@ -0,0 +1,152 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// pngSignature starts every PNG (and APNG) file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// pngChunk is one length-type-data-CRC chunk of a PNG stream
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// encodeAPNG writes frames as a looping animated PNG, each shown for its delay in
+// hundredths of a second. Frames are encoded with image/png and their image data re-wrapped in
+// APNG frame chunks, so every frame must share the first frame's size.
+func encodeAPNG(w io.Writer, frames []image.Image, delays []int) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	size := frames[0].Bounds().Size()
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+
+	seq := uint32(0)
+	for i, frame := range frames {
+		if frame.Bounds().Size() != size {
+			return fmt.Errorf("frame %d is %v, expected %v", i, frame.Bounds().Size(), size)
+		}
+
+		chunks, err := pngChunks(opaqueFrame(frame))
+		if err != nil {
+			return fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+
+		if i == 0 {
+			// IHDR, then the animation control chunk before any image data
+			if err := writeChunk(w, chunks[0]); err != nil {
+				return err
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:4], uint32(len(frames)))
+			binary.BigEndian.PutUint32(actl[4:8], 0) // Loop forever
+			if err := writeChunk(w, pngChunk{"acTL", actl}); err != nil {
+				return err
+			}
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(size.X))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(size.Y))
+		// x/y offsets (8 bytes) stay zero: every frame covers the full canvas
+		binary.BigEndian.PutUint16(fctl[20:22], uint16(delays[i]))
+		binary.BigEndian.PutUint16(fctl[22:24], 100)
+		// dispose_op and blend_op stay 0 (none, source)
+		if err := writeChunk(w, pngChunk{"fcTL", fctl}); err != nil {
+			return err
+		}
+		seq++
+
+		for _, chunk := range chunks {
+			if chunk.typ != "IDAT" {
+				continue
+			}
+			if i == 0 {
+				// The first frame doubles as the default image for non-APNG viewers
+				if err := writeChunk(w, chunk); err != nil {
+					return err
+				}
+				continue
+			}
+			fdat := make([]byte, 4+len(chunk.data))
+			binary.BigEndian.PutUint32(fdat[0:4], seq)
+			copy(fdat[4:], chunk.data)
+			if err := writeChunk(w, pngChunk{"fdAT", fdat}); err != nil {
+				return err
+			}
+			seq++
+		}
+	}
+
+	return writeChunk(w, pngChunk{"IEND", nil})
+}
+
+// opaqueFrame flattens a frame onto black RGBA so every frame encodes with the same
+// color type (8-bit RGB) as the first
+func opaqueFrame(img image.Image) image.Image {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
+
+// pngChunks encodes an image as PNG and splits the stream into its chunks
+func pngChunks(img image.Image) ([]pngChunk, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	data := buf.Bytes()[len(pngSignature):]
+	var chunks []pngChunk
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data[0:4])
+		if uint32(len(data)) < 12+length {
+			return nil, fmt.Errorf("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" {
+		return nil, fmt.Errorf("PNG stream does not start with IHDR")
+	}
+	return chunks, nil
+}
+
+// writeChunk writes one PNG chunk with its length and CRC
+func writeChunk(w io.Writer, chunk pngChunk) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(chunk.data)))
+	copy(header[4:8], chunk.typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:8])
+	crc.Write(chunk.data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, part := range [][]byte{header, chunk.data, footer} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dateCue is the span of a video showing one date, used for MP4 chapters and .srt
+// subtitle sidecars
+type dateCue struct {
+	start, end time.Duration
+	text       string
+}
+
+// dateCues returns the span of each date shown by frames, consecutive frames with the
+// same text sharing a cue. With fps > 0 durations are rounded to whole frames, as the
+// H.264 export repeats frames to time them.
+func (e *Exporter) dateCues(frames []Frame, fps int) []dateCue {
+	var cues []dateCue
+	var at time.Duration
+	for _, frame := range frames {
+		seconds := e.frameDuration(frame)
+		if fps > 0 {
+			seconds = math.Max(1, math.Round(seconds*float64(fps))) / float64(fps)
+		}
+		end := at + time.Duration(seconds*float64(time.Second))
+
+		text := e.dateText(frame.Date, frame.Label)
+		if n := len(cues); n > 0 && cues[n-1].text == text {
+			cues[n-1].end = end
+		} else {
+			cues = append(cues, dateCue{start: at, end: end, text: text})
+		}
+		at = end
+	}
+	return cues
+}
+
+// summary describes the dates of cues for the container comment, e.g.
+// "12 dates, Mar 15, 2014 to Jun 02, 2023"
+func summary(cues []dateCue) string {
+	switch len(cues) {
+	case 0:
+		return ""
+	case 1:
+		return cues[0].text
+	}
+	return fmt.Sprintf("%d dates, %s to %s", len(cues), cues[0].text, cues[len(cues)-1].text)
+}
+
+// writeChapters writes cues as chapters of an FFmpeg metadata file (read with
+// -map_chapters), so players list one chapter per date
+func writeChapters(path string, cues []dateCue) error {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, cue := range cues {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\nEND=%d\n", cue.start.Milliseconds(), cue.end.Milliseconds())
+		fmt.Fprintf(&b, "title=%s\n", ffmetadataEscape(cue.text))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write chapters: %w", err)
+	}
+	return nil
+}
+
+// ffmetadataEscape escapes the characters special to FFmpeg metadata files
+func ffmetadataEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '=', ';', '#', '\\', '\n':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SubtitlePath returns the .srt sidecar path of a video export
+func SubtitlePath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".srt"
+}
+
+// writeSRT writes cues as a SubRip subtitle file, an alternative to burned-in dates
+// that players can toggle
+func writeSRT(path string, cues []dateCue) error {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(cue.start), srtTime(cue.end), cue.text)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
+	return nil
+}
+
+// srtTime formats d as HH:MM:SS,mmm
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,165 @@
+package video
+
+import (
+	"math"
+)
+
+// Estimate is the expected outcome of exporting a timelapse with one preset, computed
+// without loading imagery or encoding anything
+type Estimate struct {
+	Preset        string  `json:"preset"`
+	Label         string  `json:"label"`
+	Format        string  `json:"format"` // Actual output format ("avi" when MP4 falls back without FFmpeg)
+	Width         int     `json:"width"`  // Output resolution (after GIF scaling)
+	Height        int     `json:"height"`
+	CropWidth     int     `json:"cropWidth"` // Part of the source imagery in view, in source pixels
+	CropHeight    int     `json:"cropHeight"`
+	Frames        int     `json:"frames"`        // Imagery frames (one per date)
+	EncodedFrames int     `json:"encodedFrames"` // Frames in the file (MP4/AVI repeat frames to hold each date)
+	Duration      float64 `json:"duration"`      // Clip length in seconds (0 for image sequences)
+	SizeBytes     int64   `json:"sizeBytes"`     // Approximate file size (total for image sequences)
+}
+
+// Approximate compressed size of satellite imagery frames, in bits per pixel. Aerial
+// imagery is detailed and noisy, so these sit well above figures for typical video.
+const (
+	bppPNG        = 12.0  // Lossless PNG frames (APNG, PNG sequences)
+	bppGIF        = 4.5   // 256-colour LZW
+	bppRepeat     = 0.002 // H.264 frame repeating the previous one
+	audioBitrate  = 192000
+	h264FrameRate = 30 // Matches the frame rate ExportTimelapse encodes with
+)
+
+// EstimateExport estimates the export of dates with opts for each preset in opts.Presets
+// (opts.Preset when empty), given the pixel size of the source imagery. Duplicate frame
+// removal is not predicted, so dedupe can only make the result shorter and smaller.
+func EstimateExport(sourceWidth, sourceHeight int, dates []DateInfo, opts TimelapseOptions) []Estimate {
+	presets := opts.Presets
+	if len(presets) == 0 {
+		presets = []string{opts.Preset}
+	}
+	_, hasFFmpeg := CheckFFmpeg()
+
+	estimates := make([]Estimate, 0, len(presets))
+	for _, id := range presets {
+		preset := parsePreset(id)
+		width, height := opts.Width, opts.Height
+		if preset != PresetCustom {
+			width, height = GetPresetDimensions(preset)
+		}
+
+		e := &Exporter{options: &ExportOptions{
+			Width:        width,
+			Height:       height,
+			FrameRate:    h264FrameRate,
+			FrameDelay:   opts.FrameDelay,
+			OutputFormat: opts.OutputFormat,
+			Quality:      opts.Quality,
+			ImageFormat:  opts.ImageFormat,
+		}}
+		frames := make([]Frame, len(dates))
+		for i, d := range dates {
+			frames[i].Duration = opts.FrameDurations[d.Date]
+		}
+
+		est := Estimate{
+			Preset: id,
+			Label:  GetPresetLabel(preset),
+			Format: opts.OutputFormat,
+			Width:  width,
+			Height: height,
+			Frames: len(frames),
+		}
+		if est.Format == "mp4" && !hasFFmpeg {
+			est.Format = "avi"
+		}
+
+		// The exporter scales the source to fill the output, so the visible part of the
+		// source has the output's aspect ratio
+		if sourceWidth > 0 && sourceHeight > 0 {
+			scale := math.Max(float64(width)/float64(sourceWidth), float64(height)/float64(sourceHeight))
+			est.CropWidth = int(math.Round(float64(width) / scale))
+			est.CropHeight = int(math.Round(float64(height) / scale))
+		}
+
+		e.estimateTiming(&est, frames)
+		e.estimateSize(&est, opts)
+		estimates = append(estimates, est)
+	}
+	return estimates
+}
+
+// estimateTiming fills in the encoded frame count and duration the way each encoder
+// times frames
+func (e *Exporter) estimateTiming(est *Estimate, frames []Frame) {
+	switch est.Format {
+	case "mp4":
+		for _, f := range frames {
+			est.EncodedFrames += int(math.Max(1, math.Round(e.frameDuration(f)*float64(e.options.FrameRate))))
+		}
+		est.Duration = float64(est.EncodedFrames) / float64(e.options.FrameRate)
+	case "avi":
+		if hasVariableTiming(frames) {
+			for _, f := range frames {
+				est.EncodedFrames += int(math.Max(1, math.Round(e.frameDuration(f)*mjpegVariableFPS)))
+			}
+			est.Duration = float64(est.EncodedFrames) / mjpegVariableFPS
+		} else {
+			fps := math.Min(30, math.Max(1, math.Floor(1/e.options.FrameDelay)))
+			est.EncodedFrames = len(frames)
+			est.Duration = float64(len(frames)) / fps
+		}
+	case "images":
+		est.EncodedFrames = len(frames)
+	default: // gif, apng, webp: one frame per date with its own delay
+		est.EncodedFrames = len(frames)
+		for _, delay := range e.frameDelays(frames) {
+			est.Duration += float64(delay) / 100
+		}
+	}
+}
+
+// estimateSize fills in the approximate file size (and the GIF resolution)
+func (e *Exporter) estimateSize(est *Estimate, opts TimelapseOptions) {
+	quality := float64(e.options.Quality) / 100
+	pixels := float64(est.Width * est.Height)
+	frames := float64(est.Frames)
+
+	var bits float64
+	switch est.Format {
+	case "mp4":
+		// CRF encodes: each new date costs about an intra frame, repeats almost nothing
+		bits = frames*pixels*(0.05+0.6*quality*quality) + float64(est.EncodedFrames-est.Frames)*pixels*bppRepeat
+		if opts.AudioPath != "" {
+			bits += est.Duration * audioBitrate
+		}
+	case "avi":
+		bits = float64(est.EncodedFrames) * pixels * jpegBitsPerPixel(quality)
+	case "gif":
+		if opts.GIFScale > 0 && opts.GIFScale < 1 {
+			est.Width = int(float64(est.Width) * opts.GIFScale)
+			est.Height = int(float64(est.Height) * opts.GIFScale)
+			pixels = float64(est.Width * est.Height)
+		}
+		bits = frames * pixels * bppGIF
+		if limit := opts.GIFMaxSizeMB * 1024 * 1024 * 8; limit > 0 && bits > limit {
+			bits = limit // Frames shrink until the GIF fits
+		}
+	case "apng":
+		bits = frames * pixels * bppPNG
+	case "webp":
+		bits = frames * pixels * (0.3 + 1.5*quality*quality)
+	case "images":
+		if opts.ImageFormat == "jpeg" {
+			bits = frames * pixels * jpegBitsPerPixel(quality)
+		} else {
+			bits = frames * pixels * bppPNG
+		}
+	}
+	est.SizeBytes = int64(bits / 8)
+}
+
+// jpegBitsPerPixel approximates the JPEG size of aerial imagery at quality (0-1)
+func jpegBitsPerPixel(quality float64) float64 {
+	return 0.5 + 3*quality*quality
+}
@@ -11,14 +11,18 @@ import (
 	"image/png"
 	"io"
 	"log"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/icza/mjpeg"
+	xdraw "golang.org/x/image/draw"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
@@ -100,11 +104,14 @@ type ExportOptions struct {
 	CropY float64
 
 	// Spotlight area (pixel coordinates in source image) - for grayout effect
-	SpotlightX      int
-	SpotlightY      int
-	SpotlightWidth  int
-	SpotlightHeight int
-	UseSpotlight    bool
+	SpotlightX       int
+	SpotlightY       int
+	SpotlightWidth   int
+	SpotlightHeight  int
+	UseSpotlight     bool
+	SpotlightShape   string            // "rectangle" (default), "rounded", "circle" or "ellipse"
+	SpotlightFeather int               // Width in pixels of the fade from the spotlight into the grayed area
+	ExtraSpotlights  []image.Rectangle // Further regions (source pixels), highlighted in place
 
 	// Overlay
 	OverlayOpacity float64 // 0.0 to 1.0 (0 = transparent, 1 = opaque)
@@ -120,6 +127,23 @@ type ExportOptions struct {
 	DateFontPath    string // Path to font file (optional if DateFontData is provided)
 	DateFontData    []byte // Embedded font data (TTF/OTF)
 
+	// Fonts (TTF/OTF/TTC) drawing the characters the date font lacks, e.g. CJK or Arabic
+	// place names, tried before the system's fallback fonts
+	FallbackFontPaths []string
+
+	// Title overlay: a location title centered at the top of the frame, drawn with the
+	// date font ("" = none)
+	TitleText string
+
+	// Info overlay: one line of context (e.g. center coordinates, resolution and source)
+	// in a corner, at a smaller size than the date ("" = none)
+	InfoText     string
+	InfoPosition string // "top-left" (default), "top-right", "bottom-left", "bottom-right"
+
+	// Timeline bar: year counter and progress bar along the bottom, advancing smoothly
+	// between frame dates in MP4 exports
+	ShowTimelineBar bool
+
 	// Logo overlay
 	ShowLogo     bool
 	LogoPosition string // "top-left", "top-right", "bottom-left", "bottom-right"
@@ -129,13 +153,33 @@ type ExportOptions struct {
 	// Video settings
 	FrameRate    int     // FPS (e.g., 30, 24, 15)
 	FrameDelay   float64 // Seconds between frames (e.g., 0.5 = 2 images per second)
-	OutputFormat string  // "mp4", "gif", "avi"
+	OutputFormat string  // "mp4", "gif", "avi", "apng", "webp", "images"
 	Quality      int     // 0-100 (for lossy formats)
 	UseH264      bool    // Try to use H.264 encoding via FFmpeg
 
-	// Metadata
+	// GIF settings
+	GIFScale    float64 // Downscale factor for GIF frames (0 or 1 = full size)
+	GIFMaxBytes int64   // Target maximum GIF file size; frames shrink until it fits (0 = no limit)
+
+	// Audio settings
+	AudioPath string // Music file (mp3/aac/wav...) muxed into MP4 exports; requires FFmpeg
+
+	// Image sequence settings
+	ImageFormat string // "png" (default) or "jpeg" for the "images" output format
+
+	// FFmpeg settings
+	FFmpegTimeout   time.Duration // Encoding timeout (0 = scaled to frame count and resolution)
+	FFmpegExtraArgs []string      // Appended to the output options of FFmpeg encodes (advanced)
+
+	// Container metadata of MP4 exports (each omitted when empty; the description
+	// defaults to a summary of the dates). MP4s also get a chapter per date.
 	Title       string
 	Description string
+	Artist      string
+
+	// Subtitles writes the frame dates to a .srt sidecar next to the video (all formats
+	// but image sequences), an alternative to burning them in with ShowDateOverlay
+	Subtitles bool
 }
 
 // DefaultExportOptions returns sensible defaults
@@ -168,8 +212,10 @@ func DefaultExportOptions() *ExportOptions {
 
 // Frame represents a single frame in the timelapse
 type Frame struct {
-	Image *image.RGBA
-	Date  time.Time
+	Image    *image.RGBA
+	Date     time.Time
+	Label    string  // Optional text appended to the date overlay (e.g. source name)
+	Duration float64 // Seconds on screen (0 = the FrameDelay option)
 }
 
 // Exporter handles video export operations
@@ -177,6 +223,14 @@ type Exporter struct {
 	options    *ExportOptions
 	font       font.Face
 	ffmpegPath string
+
+	// Overlay fonts, and faces at the reduced sizes used to fit long text in the frame
+	fonts       *fontSet
+	fittedFaces map[float64]font.Face
+
+	// Date range covered by the timeline bar (first and last frame)
+	timelineStart time.Time
+	timelineEnd   time.Time
 }
 
 // CheckFFmpeg checks if FFmpeg is available - first checks bundled, then system
@@ -291,8 +345,8 @@ func NewExporter(opts *ExportOptions) (*Exporter, error) {
 		options: opts,
 	}
 
-	// Check for FFmpeg if H.264 is requested
-	if opts.UseH264 {
+	// Check for FFmpeg if H.264 or WebP is requested
+	if opts.UseH264 || opts.OutputFormat == "webp" {
 		path, found := CheckFFmpeg()
 		if found {
 			e.ffmpegPath = path
@@ -303,7 +357,7 @@ func NewExporter(opts *ExportOptions) (*Exporter, error) {
 	}
 
 	// Load font if date overlay is enabled
-	if opts.ShowDateOverlay && (opts.DateFontPath != "" || len(opts.DateFontData) > 0) {
+	if (opts.ShowDateOverlay || opts.ShowTimelineBar || opts.TitleText != "" || opts.InfoText != "") && (opts.DateFontPath != "" || len(opts.DateFontData) > 0) {
 		if err := e.loadFont(); err != nil {
 			log.Printf("[VideoExport] Warning: failed to load font: %v", err)
 			// Don't fail - continue without date overlay
@@ -344,21 +398,76 @@ func (e *Exporter) loadFont() error {
 		return fmt.Errorf("failed to parse font: %w", err)
 	}
 
-	face, err := opentype.NewFace(f, &opentype.FaceOptions{
-		Size:    e.options.DateFontSize,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
+	// Characters the font lacks are drawn with fallback fonts, parsed when first needed
+	fallbacks := append(append([]string{}, e.options.FallbackFontPaths...), systemFallbackFonts()...)
+	e.fonts = newFontSet(f, fallbacks)
+	face, err := e.fonts.face(e.options.DateFontSize)
 	if err != nil {
 		return fmt.Errorf("failed to create font face: %w", err)
 	}
 
 	e.font = face
+	e.fittedFaces = make(map[float64]font.Face)
 	return nil
 }
 
+// minFittedFontSize is the smallest size text is shrunk to when fitting the frame
+const minFittedFontSize = 8
+
+// fittingFace returns a face drawing text (already laid out) no wider than maxWidth:
+// the overlay font at size, or a smaller size for long titles and CJK labels
+func (e *Exporter) fittingFace(text string, size float64, maxWidth int) font.Face {
+	face := e.faceOfSize(size)
+	bounds, _ := font.BoundString(face, text)
+	width := (bounds.Max.X - bounds.Min.X).Ceil()
+	if width <= maxWidth || width == 0 {
+		return face
+	}
+	// Half-point steps keep the number of faces created small; hinting may round the
+	// scaled glyphs wider, hence the margin
+	fitted := math.Floor(size*float64(maxWidth)/float64(width)*0.97*2) / 2
+	if fitted < minFittedFontSize {
+		fitted = minFittedFontSize
+	}
+	return e.faceOfSize(fitted)
+}
+
+// faceOfSize returns the overlay face at size points, creating it on first use
+func (e *Exporter) faceOfSize(size float64) font.Face {
+	if size == e.options.DateFontSize {
+		return e.font
+	}
+	if face, ok := e.fittedFaces[size]; ok {
+		return face
+	}
+	face, err := e.fonts.face(size)
+	if err != nil {
+		log.Printf("[VideoExport] Failed to create %.1fpt font face: %v", size, err)
+		return e.font
+	}
+	e.fittedFaces[size] = face
+	return face
+}
+
 // ProcessFrame processes a single frame: crops, applies spotlight, adds date
-func (e *Exporter) ProcessFrame(sourceImage image.Image, date time.Time) (*image.RGBA, error) {
+// label is appended to the date overlay when non-empty
+func (e *Exporter) ProcessFrame(sourceImage image.Image, date time.Time, label string) (*image.RGBA, error) {
+	output, err := e.processBase(sourceImage, date, label)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Add the timeline bar at this frame's date
+	if e.options.ShowTimelineBar {
+		e.drawTimelineBar(output, date)
+	}
+
+	return output, nil
+}
+
+// processBase renders a frame with its crop, spotlight, date and logo but no timeline
+// bar, so encoders can redraw the bar at interpolated dates
+func (e *Exporter) processBase(sourceImage image.Image, date time.Time, label string) (*image.RGBA, error) {
 	opts := e.options
 
 	// Create output image
@@ -371,14 +480,21 @@ func (e *Exporter) ProcessFrame(sourceImage image.Image, date time.Time) (*image
 
 		// Then draw the spotlight area at full brightness
 		e.drawSpotlightArea(output, sourceImage)
+		e.drawExtraSpotlights(output, sourceImage)
 	} else {
 		// Just resize/crop the source image to fit output dimensions
 		e.resizeAndDrawImage(output, sourceImage)
 	}
 
-	// Step 2: Add date overlay if enabled
+	// Step 2: Add date, title and info overlays if enabled
 	if opts.ShowDateOverlay && e.font != nil {
-		e.drawDateOverlay(output, date)
+		e.drawDateOverlay(output, date, label)
+	}
+	if opts.TitleText != "" && e.font != nil {
+		e.drawTitleOverlay(output)
+	}
+	if opts.InfoText != "" && e.font != nil {
+		e.drawInfoOverlay(output)
 	}
 
 	// Step 3: Add logo overlay if enabled
@@ -436,7 +552,8 @@ func (e *Exporter) drawGrayedImage(dst *image.RGBA, src image.Image) {
 	}
 }
 
-// drawSpotlightArea draws the spotlight area at full brightness
+// drawSpotlightArea draws the spotlight area at full brightness, masked to SpotlightShape
+// and faded over SpotlightFeather pixels at its edge
 func (e *Exporter) drawSpotlightArea(dst *image.RGBA, src image.Image) {
 	opts := e.options
 
@@ -455,8 +572,11 @@ func (e *Exporter) drawSpotlightArea(dst *image.RGBA, src image.Image) {
 			dstPy := dstY + dy
 
 			if dstPx >= 0 && dstPx < opts.Width && dstPy >= 0 && dstPy < opts.Height {
-				c := src.At(sx, sy)
-				dst.Set(dstPx, dstPy, c)
+				alpha := spotlightAlpha(opts.SpotlightShape, float64(opts.SpotlightFeather),
+					float64(dx)+0.5, float64(dy)+0.5, float64(opts.SpotlightWidth), float64(opts.SpotlightHeight))
+				if alpha > 0 {
+					blendSpotlight(dst, dstPx, dstPy, src.At(sx, sy), alpha)
+				}
 			}
 		}
 	}
@@ -518,19 +638,31 @@ func (e *Exporter) resizeAndDrawImage(dst *image.RGBA, src image.Image) {
 	}
 }
 
-// drawDateOverlay draws the date text on the frame
-func (e *Exporter) drawDateOverlay(dst *image.RGBA, date time.Time) {
+// dateText returns the text shown for a frame's date: the date in the overlay format and
+// the optional label
+func (e *Exporter) dateText(date time.Time, label string) string {
+	text := date.Format(e.options.DateFormat)
+	if label != "" {
+		text += " · " + label
+	}
+	return text
+}
+
+// drawDateOverlay draws the date text (and optional label) on the frame
+func (e *Exporter) drawDateOverlay(dst *image.RGBA, date time.Time, label string) {
 	if e.font == nil {
 		return
 	}
 
-	dateStr := date.Format(e.options.DateFormat)
+	padding := 20
+	dateStr := layoutText(e.dateText(date, label))
+	face := e.fittingFace(dateStr, e.options.DateFontSize, e.options.Width-2*padding)
 
 	// Measure text
 	drawer := &font.Drawer{
 		Dst:  dst,
 		Src:  image.NewUniform(e.options.DateColor),
-		Face: e.font,
+		Face: face,
 	}
 
 	bounds, _ := drawer.BoundString(dateStr)
@@ -539,7 +671,6 @@ func (e *Exporter) drawDateOverlay(dst *image.RGBA, date time.Time) {
 
 	// Calculate position
 	var x, y int
-	padding := 20
 
 	switch e.options.DatePosition {
 	case "top-left":
@@ -567,7 +698,7 @@ func (e *Exporter) drawDateOverlay(dst *image.RGBA, date time.Time) {
 		shadowDrawer := &font.Drawer{
 			Dst:  dst,
 			Src:  image.NewUniform(color.RGBA{0, 0, 0, 180}),
-			Face: e.font,
+			Face: face,
 			Dot:  fixed.P(x+2, y+2),
 		}
 		shadowDrawer.DrawString(dateStr)
@@ -578,6 +709,138 @@ func (e *Exporter) drawDateOverlay(dst *image.RGBA, date time.Time) {
 	drawer.DrawString(dateStr)
 }
 
+// drawTitleOverlay draws the location title centered at the top of the frame, a quarter
+// larger than the date and shrunk as needed to fit the frame width
+func (e *Exporter) drawTitleOverlay(dst *image.RGBA) {
+	padding := 20
+	title := layoutText(e.options.TitleText)
+	face := e.fittingFace(title, e.options.DateFontSize*1.25, e.options.Width-2*padding)
+
+	bounds, _ := font.BoundString(face, title)
+	x := (e.options.Width - (bounds.Max.X - bounds.Min.X).Ceil()) / 2
+	y := padding + face.Metrics().Ascent.Ceil()
+
+	if e.options.DateShadow {
+		shadowDrawer := &font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(color.RGBA{0, 0, 0, 180}),
+			Face: face,
+			Dot:  fixed.P(x+2, y+2),
+		}
+		shadowDrawer.DrawString(title)
+	}
+
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(e.options.DateColor),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(title)
+}
+
+// drawInfoOverlay draws the info line in its corner at 60% of the date size, shrunk as
+// needed to fit the frame width
+func (e *Exporter) drawInfoOverlay(dst *image.RGBA) {
+	padding := 20
+	info := layoutText(e.options.InfoText)
+	face := e.fittingFace(info, e.options.DateFontSize*0.6, e.options.Width-2*padding)
+
+	bounds, _ := font.BoundString(face, info)
+	textWidth := (bounds.Max.X - bounds.Min.X).Ceil()
+	ascent := face.Metrics().Ascent.Ceil()
+
+	var x, y int
+	switch e.options.InfoPosition {
+	case "top-right":
+		x = e.options.Width - textWidth - padding
+		y = padding + ascent
+	case "bottom-left":
+		x = padding
+		y = e.options.Height - padding
+	case "bottom-right":
+		x = e.options.Width - textWidth - padding
+		y = e.options.Height - padding
+	default: // top-left
+		x = padding
+		y = padding + ascent
+	}
+
+	if e.options.DateShadow {
+		shadowDrawer := &font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(color.RGBA{0, 0, 0, 180}),
+			Face: face,
+			Dot:  fixed.P(x+2, y+2),
+		}
+		shadowDrawer.DrawString(info)
+	}
+
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(e.options.DateColor),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(info)
+}
+
+// drawTimelineBar draws a progress bar along the bottom of the frame, filled up to date
+// within the export's date range, with the year as a counter above the fill edge
+func (e *Exporter) drawTimelineBar(dst *image.RGBA, date time.Time) {
+	width, height := e.options.Width, e.options.Height
+	margin := width / 20
+	barHeight := height / 120
+	if barHeight < 4 {
+		barHeight = 4
+	}
+	barY := height - margin/2 - barHeight
+
+	progress := 1.0
+	if span := e.timelineEnd.Sub(e.timelineStart); span > 0 {
+		progress = math.Max(0, math.Min(1, float64(date.Sub(e.timelineStart))/float64(span)))
+	}
+	fillX := margin + int(progress*float64(width-2*margin))
+
+	track := image.Rect(margin, barY, width-margin, barY+barHeight)
+	draw.Draw(dst, track, image.NewUniform(color.RGBA{0, 0, 0, 120}), image.Point{}, draw.Over)
+	fill := image.Rect(margin, barY, fillX, barY+barHeight)
+	draw.Draw(dst, fill, image.NewUniform(e.options.DateColor), image.Point{}, draw.Over)
+
+	if e.font == nil {
+		return
+	}
+
+	// Year counter centered over the fill edge, kept inside the bar's extent
+	year := strconv.Itoa(date.Year())
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(e.options.DateColor),
+		Face: e.font,
+	}
+	textWidth := drawer.MeasureString(year).Ceil()
+	x := fillX - textWidth/2
+	if x < margin {
+		x = margin
+	}
+	if x > width-margin-textWidth {
+		x = width - margin - textWidth
+	}
+	y := barY - barHeight
+
+	if e.options.DateShadow {
+		shadowDrawer := &font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(color.RGBA{0, 0, 0, 180}),
+			Face: e.font,
+			Dot:  fixed.P(x+2, y+2),
+		}
+		shadowDrawer.DrawString(year)
+	}
+	drawer.Dot = fixed.P(x, y)
+	drawer.DrawString(year)
+}
+
 // drawLogoOverlay draws the logo on the frame
 func (e *Exporter) drawLogoOverlay(dst *image.RGBA) {
 	if e.options.LogoImage == nil {
@@ -678,10 +941,89 @@ func (e *Exporter) drawLogoOverlay(dst *image.RGBA) {
 	}
 }
 
+// frameDuration returns how long a frame stays on screen, in seconds
+func (e *Exporter) frameDuration(frame Frame) float64 {
+	if frame.Duration > 0 {
+		return frame.Duration
+	}
+	return e.options.FrameDelay
+}
+
+// frameDelays returns each frame's duration in 100ths of a second (GIF/APNG units)
+func (e *Exporter) frameDelays(frames []Frame) []int {
+	delays := make([]int, len(frames))
+	for i, frame := range frames {
+		delays[i] = int(math.Round(e.frameDuration(frame) * 100))
+		if delays[i] < 1 {
+			delays[i] = 1
+		}
+	}
+	return delays
+}
+
+// hasVariableTiming reports whether any frame overrides the FrameDelay option
+func hasVariableTiming(frames []Frame) bool {
+	for _, frame := range frames {
+		if frame.Duration > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FileExtension returns the file extension (without dot) for an output format.
+// APNG files use .png so browsers and image viewers recognize them; image sequences
+// are written to a directory and have none.
+func FileExtension(format string) string {
+	switch format {
+	case "apng":
+		return "png"
+	case "images":
+		return ""
+	default:
+		return format
+	}
+}
+
 // ExportVideo creates a video from processed frames
 func (e *Exporter) ExportVideo(frames []Frame, outputPath string) error {
 	opts := e.options
 
+	if len(frames) > 0 {
+		e.timelineStart, e.timelineEnd = frames[0].Date, frames[len(frames)-1].Date
+	}
+
+	if opts.AudioPath != "" {
+		if opts.OutputFormat != "mp4" {
+			log.Printf("[VideoExport] Audio tracks are only added to MP4 exports, ignoring %s", opts.AudioPath)
+		} else if _, err := os.Stat(opts.AudioPath); err != nil {
+			return fmt.Errorf("audio track not found: %w", err)
+		}
+	}
+
+	if err := e.exportFormat(frames, outputPath); err != nil {
+		return err
+	}
+
+	if opts.Subtitles && opts.OutputFormat != "images" {
+		fps := 0 // Other formats time frames exactly (or approximate them regardless)
+		if opts.OutputFormat == "mp4" && e.ffmpegPath != "" && opts.UseH264 {
+			fps = opts.FrameRate
+		}
+		srtPath := SubtitlePath(outputPath)
+		if err := writeSRT(srtPath, e.dateCues(frames, fps)); err != nil {
+			log.Printf("[VideoExport] Warning: %v", err)
+		} else {
+			log.Printf("[VideoExport] Subtitles written: %s", srtPath)
+		}
+	}
+	return nil
+}
+
+// exportFormat writes frames to outputPath in the output format
+func (e *Exporter) exportFormat(frames []Frame, outputPath string) error {
+	opts := e.options
+
 	switch opts.OutputFormat {
 	case "mp4":
 		if e.ffmpegPath != "" && opts.UseH264 {
@@ -690,13 +1032,22 @@ func (e *Exporter) ExportVideo(frames []Frame, outputPath string) error {
 		// Fallback to MJPEG AVI
 		aviPath := strings.TrimSuffix(outputPath, ".mp4") + ".avi"
 		log.Printf("[VideoExport] FFmpeg not available, falling back to MJPEG AVI: %s", aviPath)
+		if opts.AudioPath != "" {
+			log.Printf("[VideoExport] Warning: audio track needs FFmpeg and was not added")
+		}
 		return e.exportMotionJPEG(frames, aviPath)
 	case "avi":
 		return e.exportMotionJPEG(frames, outputPath)
 	case "gif":
 		return e.exportGIF(frames, outputPath)
+	case "apng":
+		return e.exportAPNG(frames, outputPath)
+	case "webp":
+		return e.exportWebP(frames, outputPath)
+	case "images":
+		return e.exportImageSequence(frames, outputPath)
 	default:
-		return fmt.Errorf("unsupported output format: %s (supported: mp4, avi, gif)", opts.OutputFormat)
+		return fmt.Errorf("unsupported output format: %s (supported: mp4, avi, gif, apng, webp, images)", opts.OutputFormat)
 	}
 }
 
@@ -718,14 +1069,8 @@ func (e *Exporter) exportH264(frames []Frame, outputPath string) error {
 
 	log.Printf("[VideoExport] Temp directory created: %s", tempDir)
 
-	// Calculate how many times to duplicate each frame based on frame delay
-	duplicateCount := int(e.options.FrameDelay * float64(e.options.FrameRate))
-	if duplicateCount < 1 {
-		duplicateCount = 1
-	}
-
-	log.Printf("[VideoExport] Frame duplication count: %d (frameDelay=%.2f, frameRate=%d)",
-		duplicateCount, e.options.FrameDelay, e.options.FrameRate)
+	log.Printf("[VideoExport] Frame duplication from frame durations (frameDelay=%.2f, frameRate=%d, variable=%v)",
+		e.options.FrameDelay, e.options.FrameRate, hasVariableTiming(frames))
 
 	// Process and save frames as PNG with date/logo overlays
 	// ProcessFrame handles resizing, cropping, and adding overlays
@@ -733,14 +1078,32 @@ func (e *Exporter) exportH264(frames []Frame, outputPath string) error {
 	for i, frame := range frames {
 		log.Printf("[VideoExport] Processing frame %d/%d", i+1, len(frames))
 
-		// Process frame to add date/logo overlays and resize to target dimensions
-		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date)
+		// Process frame to add date/logo overlays and resize to target dimensions.
+		// The timeline bar is drawn per duplicate below so it advances smoothly.
+		baseFrame, err := e.processBase(frame.Image, frame.Date, frame.Label)
 		if err != nil {
 			return fmt.Errorf("failed to process frame %d: %w", i, err)
 		}
 
-		// Duplicate frame for proper timing
+		// Duplicate frame for proper timing based on its duration
+		duplicateCount := int(math.Round(e.frameDuration(frame) * float64(e.options.FrameRate)))
+		if duplicateCount < 1 {
+			duplicateCount = 1
+		}
 		for d := 0; d < duplicateCount; d++ {
+			processedFrame := baseFrame
+			if e.options.ShowTimelineBar {
+				// Interpolate toward the next frame's date across the duplicates
+				date := frame.Date
+				if i+1 < len(frames) {
+					gap := float64(frames[i+1].Date.Sub(frame.Date))
+					date = date.Add(time.Duration(gap * float64(d) / float64(duplicateCount)))
+				}
+				processedFrame = image.NewRGBA(baseFrame.Bounds())
+				copy(processedFrame.Pix, baseFrame.Pix)
+				e.drawTimelineBar(processedFrame, date)
+			}
+
 			framePath := filepath.Join(tempDir, fmt.Sprintf("frame_%05d.png", frameIndex))
 			f, err := os.Create(framePath)
 			if err != nil {
@@ -782,14 +1145,112 @@ func (e *Exporter) exportH264(frames []Frame, outputPath string) error {
 		"-y",                    // Overwrite output
 		"-framerate", fmt.Sprintf("%d", e.options.FrameRate),
 		"-i", inputPattern,
+	}
+	if e.options.AudioPath != "" {
+		args = append(args, "-stream_loop", "-1", "-i", e.options.AudioPath) // Loop short tracks
+	}
+
+	// One chapter per date, read from a metadata file given as the last input
+	cues := e.dateCues(frames, e.options.FrameRate)
+	chaptersPath := filepath.Join(tempDir, "chapters.txt")
+	if err := writeChapters(chaptersPath, cues); err != nil {
+		return err
+	}
+	chaptersInput := 1
+	if e.options.AudioPath != "" {
+		chaptersInput = 2
+	}
+	args = append(args, "-f", "ffmetadata", "-i", chaptersPath, "-map_chapters", fmt.Sprintf("%d", chaptersInput))
+
+	args = append(args,
 		"-c:v", "libx264",       // H.264 codec
 		"-preset", "medium",     // Encoding speed/quality tradeoff
 		"-crf", fmt.Sprintf("%d", crf),
 		"-pix_fmt", "yuv420p",   // Pixel format for compatibility
 		"-movflags", "+faststart", // Enable streaming
-		outputPath,
+	)
+	if e.options.AudioPath != "" {
+		duration := float64(frameIndex) / float64(e.options.FrameRate)
+		args = append(args, audioOutputArgs(duration)...)
+	}
+	args = append(args, e.metadataArgs(summary(cues))...)
+	if err := e.runFFmpeg(args, outputPath, frameIndex); err != nil {
+		return err
 	}
 
+	// Verify output file exists and has content
+	if info, err := os.Stat(outputPath); err != nil {
+		return fmt.Errorf("output file not created: %w", err)
+	} else if info.Size() == 0 {
+		return fmt.Errorf("output file is empty")
+	} else {
+		log.Printf("[VideoExport] Output file size: %d bytes", info.Size())
+	}
+
+	log.Printf("[VideoExport] H.264 video exported successfully: %s", outputPath)
+	return nil
+}
+
+// metadataArgs returns the FFmpeg arguments writing the container metadata options, with
+// defaultComment when there is no description
+func (e *Exporter) metadataArgs(defaultComment string) []string {
+	comment := e.options.Description
+	if comment == "" {
+		comment = defaultComment
+	}
+	var args []string
+	for _, field := range []struct{ key, value string }{
+		{"title", e.options.Title},
+		{"artist", e.options.Artist},
+		{"comment", comment},
+	} {
+		if field.value != "" {
+			args = append(args, "-metadata", field.key+"="+field.value)
+		}
+	}
+	return args
+}
+
+// audioFadeOut is the fade-out length at the end of the audio track, in seconds
+const audioFadeOut = 2.0
+
+// audioOutputArgs returns the FFmpeg output arguments for a music track given as input 1
+// (looped with -stream_loop): AAC, trimmed to the video duration and faded out at the end
+func audioOutputArgs(duration float64) []string {
+	fade := math.Min(audioFadeOut, duration/2)
+	return []string{
+		"-map", "0:v:0",
+		"-map", "1:a:0",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		"-af", fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", duration-fade, fade),
+		"-t", fmt.Sprintf("%.3f", duration), // Trim long tracks
+	}
+}
+
+const (
+	// minFFmpegTimeout is the shortest automatic encoding timeout
+	minFFmpegTimeout = 5 * time.Minute
+
+	// ffmpegTimePerMegapixel is the automatic timeout added per output frame and megapixel,
+	// generous for libx264 -preset medium on a slow machine (a 4K frame gets about 4s)
+	ffmpegTimePerMegapixel = 500 * time.Millisecond
+)
+
+// encodeTimeout returns how long an FFmpeg encode of frames output frames may take: the
+// FFmpegTimeout option, or a timeout scaled to the frame count and resolution
+func (e *Exporter) encodeTimeout(frames int) time.Duration {
+	if e.options.FFmpegTimeout > 0 {
+		return e.options.FFmpegTimeout
+	}
+	megapixels := float64(e.options.Width*e.options.Height) / 1e6
+	return minFFmpegTimeout + time.Duration(float64(frames)*megapixels*float64(ffmpegTimePerMegapixel))
+}
+
+// runFFmpeg runs FFmpeg with args, the extra arguments from the options and outputPath,
+// failing after the encode timeout for frames output frames
+func (e *Exporter) runFFmpeg(args []string, outputPath string, frames int) error {
+	args = append(append(args, e.options.FFmpegExtraArgs...), outputPath)
 	log.Printf("[VideoExport] Running FFmpeg: %s %v", e.ffmpegPath, args)
 
 	cmd := exec.Command(e.ffmpegPath, args...)
@@ -811,8 +1272,9 @@ func (e *Exporter) exportH264(frames []Frame, outputPath string) error {
 		done <- cmd.Wait()
 	}()
 
-	// 5 minute timeout for video encoding
-	timeout := time.After(5 * time.Minute)
+	limit := e.encodeTimeout(frames)
+	log.Printf("[VideoExport] FFmpeg timeout: %s for %d frames", limit.Round(time.Second), frames)
+	timeout := time.After(limit)
 
 	select {
 	case err := <-done:
@@ -823,24 +1285,54 @@ func (e *Exporter) exportH264(frames []Frame, outputPath string) error {
 	case <-timeout:
 		// Kill the process if it times out
 		cmd.Process.Kill()
-		log.Printf("[VideoExport] FFmpeg timed out after 5 minutes")
+		log.Printf("[VideoExport] FFmpeg timed out after %s", limit.Round(time.Second))
 		log.Printf("[VideoExport] FFmpeg stderr so far: %s", stderr.String())
-		return fmt.Errorf("FFmpeg encoding timed out after 5 minutes")
-	}
-
-	// Verify output file exists and has content
-	if info, err := os.Stat(outputPath); err != nil {
-		return fmt.Errorf("output file not created: %w", err)
-	} else if info.Size() == 0 {
-		return fmt.Errorf("output file is empty")
-	} else {
-		log.Printf("[VideoExport] Output file size: %d bytes", info.Size())
+		return fmt.Errorf("FFmpeg encoding timed out after %s (raise the FFmpeg timeout in settings)", limit.Round(time.Second))
 	}
 
-	log.Printf("[VideoExport] H.264 video exported successfully: %s", outputPath)
 	return nil
 }
 
+// SplitArgs splits a settings string of extra FFmpeg arguments into arguments. Arguments
+// are separated by spaces; single or double quotes group an argument containing spaces.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in FFmpeg arguments")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// mjpegVariableFPS is the AVI frame rate used when frames have individual durations
+const mjpegVariableFPS = 10
+
 // exportMotionJPEG creates an AVI file with Motion JPEG codec (compatible, plays everywhere)
 func (e *Exporter) exportMotionJPEG(frames []Frame, outputPath string) error {
 	if len(frames) == 0 {
@@ -862,6 +1354,11 @@ func (e *Exporter) exportMotionJPEG(frames []Frame, outputPath string) error {
 	if effectiveFPS > 30 {
 		effectiveFPS = 30
 	}
+	// Per-frame durations are approximated by repeating frames at a fixed rate
+	variable := hasVariableTiming(frames)
+	if variable {
+		effectiveFPS = mjpegVariableFPS
+	}
 
 	// Create MJPEG writer
 	writer, err := mjpeg.New(outputPath, int32(e.options.Width), int32(e.options.Height), int32(effectiveFPS))
@@ -872,7 +1369,7 @@ func (e *Exporter) exportMotionJPEG(frames []Frame, outputPath string) error {
 
 	// Process and write each frame
 	for i, frame := range frames {
-		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date)
+		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date, frame.Label)
 		if err != nil {
 			return fmt.Errorf("failed to process frame %d: %w", i, err)
 		}
@@ -883,9 +1380,15 @@ func (e *Exporter) exportMotionJPEG(frames []Frame, outputPath string) error {
 			return fmt.Errorf("failed to encode frame %d as JPEG: %w", i, err)
 		}
 
-		// Add frame to video
-		if err := writer.AddFrame(buf.Bytes()); err != nil {
-			return fmt.Errorf("failed to add frame %d: %w", i, err)
+		// Add frame to video, repeated to fill its duration when timing varies
+		repeats := 1
+		if variable {
+			repeats = int(math.Max(1, math.Round(e.frameDuration(frame)*mjpegVariableFPS)))
+		}
+		for r := 0; r < repeats; r++ {
+			if err := writer.AddFrame(buf.Bytes()); err != nil {
+				return fmt.Errorf("failed to add frame %d: %w", i, err)
+			}
 		}
 	}
 
@@ -893,59 +1396,262 @@ func (e *Exporter) exportMotionJPEG(frames []Frame, outputPath string) error {
 	return nil
 }
 
-// exportGIF creates an animated GIF
+const (
+	// gifMaxSizeAttempts caps the re-encodes spent shrinking a GIF toward GIFMaxBytes
+	gifMaxSizeAttempts = 4
+
+	// gifMinWidth is the narrowest frame width the size cap may shrink a GIF to
+	gifMinWidth = 240
+)
+
+// exportGIF creates an animated GIF sharing one adaptive palette across all frames.
+// Frames are downscaled by GIFScale, then further until the file fits GIFMaxBytes.
 func (e *Exporter) exportGIF(frames []Frame, outputPath string) error {
 	if len(frames) == 0 {
 		return fmt.Errorf("no frames to export")
 	}
 
+	delays := e.frameDelays(frames)
+
 	// Process all frames
-	palettedImages := make([]*image.Paletted, 0, len(frames))
-	delays := make([]int, 0, len(frames))
+	processed := make([]image.Image, 0, len(frames))
+	for i, frame := range frames {
+		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date, frame.Label)
+		if err != nil {
+			return fmt.Errorf("failed to process frame %d: %w", i, err)
+		}
+		processed = append(processed, processedFrame)
+	}
 
-	// Calculate delay in 100ths of a second
-	delay := int(e.options.FrameDelay * 100)
-	if delay < 1 {
-		delay = 1
+	scale := e.options.GIFScale
+	if scale <= 0 || scale > 1 {
+		scale = 1
 	}
 
-	for i, frame := range frames {
-		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date)
+	var data []byte
+	for attempt := 0; ; attempt++ {
+		var err error
+		data, err = encodeGIF(processed, scale, delays)
 		if err != nil {
-			return fmt.Errorf("failed to process frame %d: %w", i, err)
+			return err
+		}
+
+		limit := e.options.GIFMaxBytes
+		if limit <= 0 || int64(len(data)) <= limit {
+			break
+		}
+		// GIF size grows roughly with pixel count; aim a little under the limit
+		next := scale * math.Sqrt(float64(limit)/float64(len(data))) * 0.95
+		if attempt == gifMaxSizeAttempts || float64(e.options.Width)*next < gifMinWidth {
+			log.Printf("[VideoExport] GIF is %.1f MB, over the %.1f MB target at the smallest allowed size",
+				float64(len(data))/(1024*1024), float64(limit)/(1024*1024))
+			break
+		}
+		log.Printf("[VideoExport] GIF is %.1f MB, over the %.1f MB target; downscaling frames to %.0f%%",
+			float64(len(data))/(1024*1024), float64(limit)/(1024*1024), next*100)
+		scale = next
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	log.Printf("[VideoExport] GIF exported (%.1f MB): %s", float64(len(data))/(1024*1024), outputPath)
+	return nil
+}
+
+// encodeGIF scales the frames, dithers them onto a shared adaptive palette and encodes
+// the animation with per-frame delays in 100ths of a second
+func encodeGIF(frames []image.Image, scale float64, delays []int) ([]byte, error) {
+	scaled := frames
+	if scale < 1 {
+		scaled = make([]image.Image, len(frames))
+		for i, frame := range frames {
+			bounds := frame.Bounds()
+			width := int(math.Max(1, math.Round(float64(bounds.Dx())*scale)))
+			height := int(math.Max(1, math.Round(float64(bounds.Dy())*scale)))
+			dst := image.NewRGBA(image.Rect(0, 0, width, height))
+			xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), frame, bounds, xdraw.Src, nil)
+			scaled[i] = dst
 		}
+	}
 
-		// Convert to paletted image for GIF
-		bounds := processedFrame.Bounds()
-		palettedImg := image.NewPaletted(bounds, nil)
+	palette := adaptivePalette(scaled, 256)
+
+	palettedImages := make([]*image.Paletted, 0, len(scaled))
+	for _, frame := range scaled {
+		bounds := frame.Bounds()
+		palettedImg := image.NewPaletted(bounds, palette)
 
 		// Use Floyd-Steinberg dithering for better quality
-		draw.FloydSteinberg.Draw(palettedImg, bounds, processedFrame, image.Point{})
+		draw.FloydSteinberg.Draw(palettedImg, bounds, frame, bounds.Min)
 
 		palettedImages = append(palettedImages, palettedImg)
-		delays = append(delays, delay)
 	}
 
-	// Create output file
+	// The shared palette is written once as the global color table
+	var buf bytes.Buffer
+	bounds := scaled[0].Bounds()
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image: palettedImages,
+		Delay: delays,
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportAPNG creates a looping animated PNG: lossless and full color, with browser support
+// everywhere GIF works
+func (e *Exporter) exportAPNG(frames []Frame, outputPath string) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+
+	processed := make([]image.Image, 0, len(frames))
+	for i, frame := range frames {
+		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date, frame.Label)
+		if err != nil {
+			return fmt.Errorf("failed to process frame %d: %w", i, err)
+		}
+		processed = append(processed, processedFrame)
+	}
+
 	f, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer f.Close()
 
-	// Encode as animated GIF
-	return gif.EncodeAll(f, &gif.GIF{
-		Image: palettedImages,
-		Delay: delays,
-		Config: image.Config{
-			Width:  e.options.Width,
-			Height: e.options.Height,
-		},
-	})
+	if err := encodeAPNG(f, processed, e.frameDelays(frames)); err != nil {
+		return fmt.Errorf("failed to encode APNG: %w", err)
+	}
+
+	log.Printf("[VideoExport] APNG exported: %s", outputPath)
+	return nil
+}
+
+// exportWebP creates a looping animated WebP using FFmpeg's libwebp encoder
+func (e *Exporter) exportWebP(frames []Frame, outputPath string) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	if e.ffmpegPath == "" {
+		return fmt.Errorf("animated WebP export requires FFmpeg, which was not found")
+	}
+
+	tempDir, err := os.MkdirTemp("", "timelapse_frames_*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// One PNG per frame, listed with its duration for FFmpeg's concat demuxer
+	var list strings.Builder
+	for i, frame := range frames {
+		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date, frame.Label)
+		if err != nil {
+			return fmt.Errorf("failed to process frame %d: %w", i, err)
+		}
+
+		name := fmt.Sprintf("frame_%05d.png", i)
+		f, err := os.Create(filepath.Join(tempDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to create frame file: %w", err)
+		}
+		if err := png.Encode(f, processedFrame); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+		f.Close()
+
+		fmt.Fprintf(&list, "file '%s'\nduration %.3f\n", name, e.frameDuration(frame))
+	}
+	// The concat demuxer ignores the last duration unless the last file is repeated
+	fmt.Fprintf(&list, "file 'frame_%05d.png'\n", len(frames)-1)
+
+	listPath := filepath.Join(tempDir, "frames.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write frame list: %w", err)
+	}
+
+	args := []string{
+		"-y",
+		"-f", "concat",
+		"-i", listPath,
+		"-vsync", "vfr", // Keep each frame's duration
+		"-c:v", "libwebp",
+		"-quality", fmt.Sprintf("%d", e.options.Quality),
+		"-loop", "0", // Loop forever
+		"-an",
+	}
+	if err := e.runFFmpeg(args, outputPath, len(frames)); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(outputPath); err != nil {
+		return fmt.Errorf("output file not created: %w", err)
+	} else if info.Size() == 0 {
+		return fmt.Errorf("output file is empty")
+	}
+
+	log.Printf("[VideoExport] Animated WebP exported: %s", outputPath)
+	return nil
+}
+
+// exportImageSequence writes each processed frame (cropped, with overlays) as a numbered
+// image in the outputDir directory, for assembling videos in other editors
+func (e *Exporter) exportImageSequence(frames []Frame, outputDir string) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to export")
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	ext := "png"
+	if e.options.ImageFormat == "jpeg" || e.options.ImageFormat == "jpg" {
+		ext = "jpg"
+	}
+	quality := e.options.Quality
+	if quality <= 0 {
+		quality = jpeg.DefaultQuality
+	}
+
+	for i, frame := range frames {
+		processedFrame, err := e.ProcessFrame(frame.Image, frame.Date, frame.Label)
+		if err != nil {
+			return fmt.Errorf("failed to process frame %d: %w", i, err)
+		}
+
+		f, err := os.Create(filepath.Join(outputDir, fmt.Sprintf("frame_%05d.%s", i+1, ext)))
+		if err != nil {
+			return fmt.Errorf("failed to create frame file: %w", err)
+		}
+		if ext == "jpg" {
+			err = jpeg.Encode(f, processedFrame, &jpeg.Options{Quality: quality})
+		} else {
+			err = png.Encode(f, processedFrame)
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+	}
+
+	log.Printf("[VideoExport] %d frames exported to: %s", len(frames), outputDir)
+	return nil
 }
 
 // Close releases resources
 func (e *Exporter) Close() error {
+	for _, face := range e.fittedFaces {
+		face.Close()
+	}
 	if e.font != nil {
 		return e.font.Close()
 	}
@@ -0,0 +1,98 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RequiredEncoders are the FFmpeg encoders exports use: H.264 for MP4 and WebP animations
+var RequiredEncoders = []string{"libx264", "libwebp"}
+
+// hardwareEncoders are the GPU H.264/HEVC encoders looked for in the FFmpeg build
+var hardwareEncoders = []string{
+	"h264_nvenc", "hevc_nvenc", // NVIDIA
+	"h264_qsv", "hevc_qsv", // Intel Quick Sync
+	"h264_amf", "hevc_amf", // AMD
+	"h264_videotoolbox", "hevc_videotoolbox", // macOS
+	"h264_vaapi", "hevc_vaapi", // Linux VA-API
+	"h264_mf", "hevc_mf", // Windows Media Foundation
+}
+
+// ffmpegInspectTimeout bounds each FFmpeg query
+const ffmpegInspectTimeout = 10 * time.Second
+
+var ffmpegVersionRe = regexp.MustCompile(`ffmpeg version (\S+)`)
+
+// FFmpegInfo describes the FFmpeg build exports run with
+type FFmpegInfo struct {
+	Path             string   `json:"path"`
+	Version          string   `json:"version"`
+	MissingEncoders  []string `json:"missingEncoders"`  // RequiredEncoders the build lacks
+	HardwareEncoders []string `json:"hardwareEncoders"` // GPU encoders compiled in; a driver and device are still needed to use them
+	HWAccels         []string `json:"hwAccels"`         // Hardware acceleration methods compiled in
+}
+
+// InspectFFmpeg finds FFmpeg (see CheckFFmpeg) and reports its version, which required
+// encoders it lacks and which hardware encoders and acceleration methods it was built with
+func InspectFFmpeg(ctx context.Context) (*FFmpegInfo, error) {
+	path, ok := CheckFFmpeg()
+	if !ok {
+		return nil, fmt.Errorf("FFmpeg not found")
+	}
+	info := &FFmpegInfo{Path: path, MissingEncoders: []string{}, HardwareEncoders: []string{}, HWAccels: []string{}}
+
+	out, err := runFFmpegQuery(ctx, path, "-version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run FFmpeg: %w", err)
+	}
+	if m := ffmpegVersionRe.FindSubmatch(out); m != nil {
+		info.Version = string(m[1])
+	}
+
+	out, err = runFFmpegQuery(ctx, path, "-encoders")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list FFmpeg encoders: %w", err)
+	}
+	encoders := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// " V....D libx264   libx264 H.264 / AVC / MPEG-4 AVC ..."
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && len(fields[0]) == 6 {
+			encoders[fields[1]] = true
+		}
+	}
+	for _, name := range RequiredEncoders {
+		if !encoders[name] {
+			info.MissingEncoders = append(info.MissingEncoders, name)
+		}
+	}
+	for _, name := range hardwareEncoders {
+		if encoders[name] {
+			info.HardwareEncoders = append(info.HardwareEncoders, name)
+		}
+	}
+
+	// Older builds without -hwaccels still report the rest
+	if out, err := runFFmpegQuery(ctx, path, "-hwaccels"); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasSuffix(line, ":") {
+				info.HWAccels = append(info.HWAccels, line)
+			}
+		}
+	}
+	return info, nil
+}
+
+func runFFmpegQuery(ctx context.Context, path, query string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ffmpegInspectTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, path, "-hide_banner", query).Output()
+}
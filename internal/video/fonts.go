@@ -0,0 +1,219 @@
+package video
+
+import (
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontSet is the overlay font followed by fallback fonts for the characters it lacks
+// (e.g. CJK or Arabic place names). Fallback files are parsed only when a character
+// missing from the fonts loaded so far is drawn.
+type fontSet struct {
+	fonts   []*opentype.Font
+	pending []string     // Fallback font files not parsed yet, in order of preference
+	covers  map[rune]int // Index in fonts of the font drawing a rune (-1 = none)
+	buf     sfnt.Buffer
+}
+
+// newFontSet returns a font set drawing with primary, then the fonts in fallbackPaths
+func newFontSet(primary *opentype.Font, fallbackPaths []string) *fontSet {
+	return &fontSet{
+		fonts:   []*opentype.Font{primary},
+		pending: fallbackPaths,
+		covers:  make(map[rune]int),
+	}
+}
+
+// fontFor returns the index of the first font with a glyph for r, parsing pending
+// fallback fonts as needed (-1 when no font has one)
+func (s *fontSet) fontFor(r rune) int {
+	if i, ok := s.covers[r]; ok {
+		return i
+	}
+	index := -1
+	for i := 0; index < 0 && i < len(s.fonts); i++ {
+		if s.hasGlyph(s.fonts[i], r) {
+			index = i
+		}
+	}
+	for index < 0 && len(s.pending) > 0 {
+		path := s.pending[0]
+		s.pending = s.pending[1:]
+		f, err := parseFontFile(path)
+		if err != nil {
+			log.Printf("[VideoExport] Skipping fallback font %s: %v", path, err)
+			continue
+		}
+		log.Printf("[VideoExport] Loaded fallback font: %s", path)
+		s.fonts = append(s.fonts, f)
+		if s.hasGlyph(f, r) {
+			index = len(s.fonts) - 1
+		}
+	}
+	s.covers[r] = index
+	return index
+}
+
+func (s *fontSet) hasGlyph(f *opentype.Font, r rune) bool {
+	i, err := f.GlyphIndex(&s.buf, r)
+	return err == nil && i != 0
+}
+
+// parseFontFile parses a TTF/OTF file, or the first font of a TTC collection
+func parseFontFile(path string) (*opentype.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".ttc") {
+		c, err := opentype.ParseCollection(data)
+		if err != nil {
+			return nil, err
+		}
+		return c.Font(0)
+	}
+	return opentype.Parse(data)
+}
+
+// CheckFontFile reports whether path is a font the overlays can draw with
+func CheckFontFile(path string) error {
+	_, err := parseFontFile(path)
+	return err
+}
+
+// face returns a font.Face of the set at size points
+func (s *fontSet) face(size float64) (*overlayFace, error) {
+	primary, err := newSizedFace(s.fonts[0], size)
+	if err != nil {
+		return nil, err
+	}
+	return &overlayFace{set: s, size: size, faces: []font.Face{primary}}, nil
+}
+
+func newSizedFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// overlayFace draws each rune with the first font of its set that has a glyph for it.
+// Like the faces it wraps, it is not safe for concurrent use.
+type overlayFace struct {
+	set   *fontSet
+	size  float64
+	faces []font.Face // Faces of set.fonts (fallbacks created on first use)
+}
+
+// faceFor returns the face drawing r (the primary face when no font has a glyph)
+func (f *overlayFace) faceFor(r rune) font.Face {
+	i := f.set.fontFor(r)
+	if i < 0 {
+		i = 0
+	}
+	return f.faceAt(i)
+}
+
+func (f *overlayFace) faceAt(i int) font.Face {
+	for len(f.faces) <= i {
+		f.faces = append(f.faces, nil)
+	}
+	if f.faces[i] == nil {
+		face, err := newSizedFace(f.set.fonts[i], f.size)
+		if err != nil {
+			log.Printf("[VideoExport] Failed to create fallback font face: %v", err)
+			return f.faces[0]
+		}
+		f.faces[i] = face
+	}
+	return f.faces[i]
+}
+
+func (f *overlayFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faceFor(r).Glyph(dot, r)
+}
+
+func (f *overlayFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphBounds(r)
+}
+
+func (f *overlayFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphAdvance(r)
+}
+
+// Kern kerns pairs drawn with the same font only
+func (f *overlayFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.faceFor(r0)
+	if face != f.faceFor(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+// Metrics returns the primary font's metrics, so lines keep their height whatever
+// fonts the text falls back to
+func (f *overlayFace) Metrics() font.Metrics {
+	return f.faceAt(0).Metrics()
+}
+
+func (f *overlayFace) Close() error {
+	for _, face := range f.faces {
+		if face != nil {
+			face.Close()
+		}
+	}
+	return nil
+}
+
+// systemFallbackFonts returns the installed fonts tried, in order, for characters the
+// overlay font lacks: CJK fonts first, then fonts covering Arabic and Hebrew
+func systemFallbackFonts() []string {
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		windir := os.Getenv("WINDIR")
+		if windir == "" {
+			windir = `C:\Windows`
+		}
+		for _, name := range []string{"msyh.ttc", "simsun.ttc", "msgothic.ttc", "malgun.ttf", "arial.ttf", "tahoma.ttf", "segoeui.ttf"} {
+			candidates = append(candidates, filepath.Join(windir, "Fonts", name))
+		}
+	case "darwin":
+		candidates = []string{
+			"/System/Library/Fonts/Hiragino Sans GB.ttc",
+			"/System/Library/Fonts/AppleSDGothicNeo.ttc",
+			"/System/Library/Fonts/Supplemental/Arial Unicode.ttf",
+			"/Library/Fonts/Arial Unicode.ttf",
+			"/System/Library/Fonts/Supplemental/Tahoma.ttf",
+		}
+	default:
+		candidates = []string{
+			"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+			"/usr/share/fonts/truetype/noto/NotoNaskhArabic-Regular.ttf",
+			"/usr/share/fonts/truetype/noto/NotoSansArabic-Regular.ttf",
+			"/usr/share/fonts/truetype/noto/NotoSansHebrew-Regular.ttf",
+			"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		}
+	}
+
+	var found []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	return found
+}
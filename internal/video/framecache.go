@@ -0,0 +1,114 @@
+package video
+
+import (
+	"container/list"
+	"image"
+	"image/draw"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultFrameCacheBytes bounds the decoded frames kept in memory between exports
+const DefaultFrameCacheBytes = 1 << 30
+
+// FrameCache keeps decoded frames in memory, least recently used first out, so exporting
+// several presets or re-exporting a task decodes each GeoTIFF/PNG once. An entry is only
+// used while the file's modification time and size are unchanged. Cached images are
+// shared and must not be modified.
+type FrameCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // Front = most recently used
+	entries map[string]*list.Element
+}
+
+type frameCacheEntry struct {
+	path     string
+	modTime  time.Time
+	fileSize int64
+	img      *image.RGBA
+}
+
+// NewFrameCache creates a cache holding up to maxBytes of decoded pixels
+func NewFrameCache(maxBytes int64) *FrameCache {
+	return &FrameCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Load returns the decoded frame at path from the cache (cached true), or decodes it with
+// load (as RGBA) and caches it
+func (c *FrameCache) Load(path string, load ImageLoader) (img *image.RGBA, cached bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[path]; ok {
+		entry := el.Value.(*frameCacheEntry)
+		if entry.modTime.Equal(info.ModTime()) && entry.fileSize == info.Size() {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			return entry.img, true, nil
+		}
+		c.remove(el) // The file changed since it was cached
+	}
+	c.mu.Unlock()
+
+	decoded, err := load(path)
+	if err != nil {
+		return nil, false, err
+	}
+	rgba, ok := decoded.(*image.RGBA)
+	if !ok {
+		bounds := decoded.Bounds()
+		rgba = image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, decoded, bounds.Min, draw.Src)
+	}
+
+	c.put(&frameCacheEntry{path: path, modTime: info.ModTime(), fileSize: info.Size(), img: rgba})
+	return rgba, false, nil
+}
+
+// put adds an entry, evicting the least recently used ones to stay within maxBytes.
+// Frames larger than the whole cache are not kept.
+func (c *FrameCache) put(entry *frameCacheEntry) {
+	bytes := int64(len(entry.img.Pix))
+	if bytes > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[entry.path]; ok {
+		c.remove(el) // Loaded concurrently by another export
+	}
+	for c.size+bytes > c.maxBytes && c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+	c.entries[entry.path] = c.order.PushFront(entry)
+	c.size += bytes
+}
+
+// remove drops an entry; the caller holds mu
+func (c *FrameCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*frameCacheEntry)
+	delete(c.entries, entry.path)
+	c.size -= int64(len(entry.img.Pix))
+}
+
+// Clear drops every cached frame
+func (c *FrameCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.size = 0
+}
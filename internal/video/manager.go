@@ -1,16 +1,23 @@
 package video
 
 import (
+	"context"
 	"fmt"
 	"image"
-	"image/draw"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"imagery-desktop/internal/common"
+	"imagery-desktop/internal/downloads"
+	"imagery-desktop/internal/tilemath"
 	"imagery-desktop/internal/utils/naming"
+	"imagery-desktop/internal/utils/units"
+	"imagery-desktop/pkg/geotiff"
 )
 
 // BoundingBox represents geographic bounds (using same structure as downloads package)
@@ -23,9 +30,10 @@ type BoundingBox struct {
 
 // DateInfo contains date information for timelapse frames
 type DateInfo struct {
-	Date    string `json:"date"`    // Human-readable date (YYYY-MM-DD)
-	HexDate string `json:"hexDate"` // Hex date for Google API (optional)
-	Epoch   int    `json:"epoch"`   // Primary epoch from protobuf (optional)
+	Date    string `json:"date"`             // Human-readable date (YYYY-MM-DD)
+	HexDate string `json:"hexDate"`          // Hex date for Google API (optional)
+	Epoch   int    `json:"epoch"`            // Primary epoch from protobuf (optional)
+	Source  string `json:"source,omitempty"` // Per-frame source when exporting a mixed-source timelapse
 }
 
 // TimelapseOptions contains all options for timelapse video export
@@ -37,14 +45,21 @@ type TimelapseOptions struct {
 	Presets []string `json:"presets,omitempty"` // Multiple presets for batch export
 
 	// Crop position (0.0-1.0, where 0.5 is center)
-	CropX float64 `json:"cropX"` // 0=left, 0.5=center, 1=right
-	CropY float64 `json:"cropY"` // 0=top, 0.5=center, 1=bottom
+	CropX    float64 `json:"cropX"`    // 0=left, 0.5=center, 1=right
+	CropY    float64 `json:"cropY"`    // 0=top, 0.5=center, 1=bottom
+	AutoCrop bool    `json:"autoCrop"` // Center the crop on the most-changed region (overrides CropX/CropY)
 
 	// Spotlight area (geographic coordinates)
 	SpotlightEnabled   bool    `json:"spotlightEnabled"`
 	SpotlightCenterLat float64 `json:"spotlightCenterLat"`
 	SpotlightCenterLon float64 `json:"spotlightCenterLon"`
 	SpotlightRadiusKm  float64 `json:"spotlightRadiusKm"`
+	SpotlightUnit      string  `json:"spotlightUnit,omitempty"` // Unit of SpotlightRadiusKm and the extra regions' RadiusKm: "km" (default) or "mi"
+
+	// Spotlight shape and extra regions
+	SpotlightShape   string            `json:"spotlightShape"`            // "rectangle" (default), "rounded", "circle", "ellipse"
+	SpotlightFeather int               `json:"spotlightFeather"`          // Edge fade into the grayed area, in output pixels
+	ExtraSpotlights  []SpotlightRegion `json:"extraSpotlights,omitempty"` // Further regions highlighted in place
 
 	// Overlay
 	OverlayOpacity float64 `json:"overlayOpacity"` // 0.0 to 1.0
@@ -52,7 +67,12 @@ type TimelapseOptions struct {
 	// Date overlay
 	ShowDateOverlay bool    `json:"showDateOverlay"`
 	DateFontSize    float64 `json:"dateFontSize"`
-	DatePosition    string  `json:"datePosition"` // "top-left", "top-right", "bottom-left", "bottom-right"
+	DatePosition    string  `json:"datePosition"`              // "top-left", "top-right", "bottom-left", "bottom-right"
+	ShowTimelineBar bool    `json:"showTimelineBar"`           // Year counter and progress bar along the bottom
+	Title           string  `json:"title,omitempty"`           // Location title drawn at the top, in any script ("" = none)
+	Subtitles       bool    `json:"subtitles,omitempty"`       // Also write the dates as a .srt subtitle sidecar
+	ShowInfoOverlay bool    `json:"showInfoOverlay,omitempty"` // Center coordinates, resolution and source in a corner
+	InfoPosition    string  `json:"infoPosition,omitempty"`    // "top-left" (default), "top-right", "bottom-left", "bottom-right"
 
 	// Logo overlay
 	ShowLogo     bool   `json:"showLogo"`
@@ -60,8 +80,24 @@ type TimelapseOptions struct {
 
 	// Video settings
 	FrameDelay   float64 `json:"frameDelay"`   // Seconds between frames
-	OutputFormat string  `json:"outputFormat"` // "mp4", "gif"
+	OutputFormat string  `json:"outputFormat"` // "mp4", "gif", "apng", "webp", "images"
+	ImageFormat  string  `json:"imageFormat"`  // "png" or "jpeg" frames for the "images" format
 	Quality      int     `json:"quality"`      // 0-100
+	AudioPath    string  `json:"audioPath"`    // Music file muxed into MP4 exports (looped/trimmed, faded out)
+
+	// Per-frame timing (e.g. hold the most recent date longer)
+	FrameDurations map[string]float64 `json:"frameDurations,omitempty"` // Seconds per date (YYYY-MM-DD), overriding FrameDelay
+
+	// Duplicate frame removal
+	DedupeFrames    bool `json:"dedupeFrames"`              // Drop consecutive frames with near-identical imagery
+	DedupeThreshold *int `json:"dedupeThreshold,omitempty"` // Max perceptual hash distance (0-64) treated as identical (0 = exact only, unset = default)
+
+	// Frame registration
+	StabilizeFrames bool `json:"stabilizeFrames"` // Align frames to the first by phase correlation to remove jitter
+
+	// GIF size control
+	GIFScale     float64 `json:"gifScale"`     // Downscale GIF frames (0-1, 0 = full size)
+	GIFMaxSizeMB float64 `json:"gifMaxSizeMB"` // Shrink frames until the GIF fits (0 = no limit)
 }
 
 // SpotlightPixels represents pixel coordinates for spotlight area
@@ -72,8 +108,9 @@ type SpotlightPixels struct {
 	Height int
 }
 
-// ProgressCallback is called during video export to report progress
-type ProgressCallback func(current, total int, percent int, status string)
+// ProgressCallback is called during video export to report progress (OperationID set
+// when the export runs as an operation)
+type ProgressCallback func(progress downloads.DownloadProgress)
 
 // LogCallback is called to emit log messages
 type LogCallback func(message string)
@@ -96,6 +133,13 @@ type Manager struct {
 	imageLoader          ImageLoader
 	logoLoader           LogoLoader
 	spotlightCalculator  SpotlightCalculator
+	ffmpegTimeout        time.Duration // 0 = scaled to frame count and resolution
+	ffmpegExtraArgs      []string
+	dateLayout           string      // Layout of the burned-in date ("" = DefaultExportOptions)
+	fallbackFonts        []string    // Fonts for overlay characters the date font lacks
+	unitSystem           string      // Units of the info overlay's resolution ("" = metric)
+	frameCache           *FrameCache // Decoded frames shared by every export of the session
+	mu                   sync.Mutex // Guards downloadPath, the FFmpeg settings, dateLayout, fallbackFonts and unitSystem
 }
 
 // Config holds configuration for the video Manager
@@ -107,6 +151,8 @@ type Config struct {
 	ImageLoader         ImageLoader
 	LogoLoader          LogoLoader
 	SpotlightCalculator SpotlightCalculator
+	FFmpegTimeout       time.Duration // Encoding timeout (0 = scaled to frame count and resolution)
+	FFmpegExtraArgs     []string      // Appended to the output options of FFmpeg encodes
 }
 
 // NewManager creates a new video export manager
@@ -119,47 +165,163 @@ func NewManager(cfg Config) *Manager {
 		imageLoader:         cfg.ImageLoader,
 		logoLoader:          cfg.LogoLoader,
 		spotlightCalculator: cfg.SpotlightCalculator,
+		ffmpegTimeout:       cfg.FFmpegTimeout,
+		ffmpegExtraArgs:     cfg.FFmpegExtraArgs,
+		frameCache:          NewFrameCache(DefaultFrameCacheBytes),
 	}
 }
 
-// SetDownloadPath updates the download path (for task-specific exports)
+// SetDownloadPath updates the default download path, used when the operation in the
+// context has no output directory (thread-safe)
 func (m *Manager) SetDownloadPath(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.downloadPath = path
 }
 
-// GetDownloadPath returns the current download path
+// GetDownloadPath returns the default download path (thread-safe)
 func (m *Manager) GetDownloadPath() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.downloadPath
 }
 
-// emitLog sends a log message via callback if available
-func (m *Manager) emitLog(message string) {
-	if m.logCallback != nil {
-		m.logCallback(message)
-	} else {
-		log.Println(message)
+// SetFFmpegOptions updates the encoding timeout (0 = automatic) and the extra FFmpeg
+// arguments used by later exports (thread-safe)
+func (m *Manager) SetFFmpegOptions(timeout time.Duration, extraArgs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ffmpegTimeout = timeout
+	m.ffmpegExtraArgs = extraArgs
+}
+
+// SetOverlayDateLayout sets the Go time layout of the burned-in date for later exports
+// ("" = "Jan 02, 2006") (thread-safe)
+func (m *Manager) SetOverlayDateLayout(layout string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dateLayout = layout
+}
+
+// SetOverlayFallbackFonts sets the font files tried, before the system's, for overlay
+// characters the date font lacks (thread-safe)
+func (m *Manager) SetOverlayFallbackFonts(paths []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallbackFonts = paths
+}
+
+// SetUnitSystem sets the unit system of the info overlay's resolution (thread-safe)
+func (m *Manager) SetUnitSystem(system string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unitSystem = system
+}
+
+// infoText returns the info overlay line of an export: the area's center, the zoom and
+// its ground resolution there, and the imagery source
+func (m *Manager) infoText(bbox BoundingBox, zoom int, source string) string {
+	m.mu.Lock()
+	system := m.unitSystem
+	m.mu.Unlock()
+
+	lat := (bbox.South + bbox.North) / 2
+	lon := (bbox.West + bbox.East) / 2
+	if bbox.West > bbox.East { // Crosses the antimeridian
+		lon += 180
+		if lon > 180 {
+			lon -= 360
+		}
+	}
+	ns, ew := "N", "E"
+	if lat < 0 {
+		ns = "S"
+	}
+	if lon < 0 {
+		ew = "W"
+	}
+	return fmt.Sprintf("%.5f°%s, %.5f°%s · z%d · %s · %s", math.Abs(lat), ns, math.Abs(lon), ew,
+		zoom, units.FormatResolution(tilemath.ResolutionAtZoom(zoom, lat), system), common.ProviderDisplayName(source))
+}
+
+// overlayDateLayout returns the layout of the burned-in date
+func (m *Manager) overlayDateLayout() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.dateLayout == "" {
+		return DefaultExportOptions().DateFormat
+	}
+	return m.dateLayout
+}
+
+// emitLog sends a log message via callback if available (logged otherwise), and to the
+// operation in ctx
+func (m *Manager) emitLog(ctx context.Context, message string) {
+	callback := m.logCallback
+	if callback == nil {
+		callback = func(message string) { log.Println(message) }
 	}
+	downloads.ReportLog(ctx, callback, message)
 }
 
-// emitProgress sends progress update via callback if available
-func (m *Manager) emitProgress(current, total, percent int, status string) {
+// emitProgress sends progress update via callback if available, and to the operation in ctx
+func (m *Manager) emitProgress(ctx context.Context, current, total, percent int, status string) {
+	progress := downloads.DownloadProgress{Downloaded: current, Total: total, Percent: percent, Status: status}
+	op := downloads.OperationFrom(ctx)
+	if op != nil {
+		progress.OperationID = op.ID
+	}
 	if m.progressCallback != nil {
-		m.progressCallback(current, total, percent, status)
+		m.progressCallback(progress)
+	}
+	if op != nil && op.OnProgress != nil {
+		op.OnProgress(progress)
 	}
 }
 
-// ExportTimelapse exports a timelapse video from downloaded imagery
-func (m *Manager) ExportTimelapse(bbox BoundingBox, zoom int, dates []DateInfo, source string, opts TimelapseOptions) error {
-	return m.exportTimelapseInternal(bbox, zoom, dates, source, opts, true)
+// frameFile returns the file to load the frame of the GeoTIFF export at tifPath from:
+// the GeoTIFF, the VRT index of a split export, or a PNG sidecar. tifPath is returned
+// when none exists.
+func frameFile(tifPath string) string {
+	base := strings.TrimSuffix(tifPath, ".tif")
+	for _, path := range []string{tifPath, base + ".vrt", base + ".png"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return tifPath
 }
 
-// ExportTimelapseNoOpen exports a timelapse video without opening the folder (for batch exports)
-func (m *Manager) ExportTimelapseNoOpen(bbox BoundingBox, zoom int, dates []DateInfo, source string, opts TimelapseOptions) error {
-	return m.exportTimelapseInternal(bbox, zoom, dates, source, opts, false)
+// decodeFrame decodes the image at path with the image loader, or the standard decoders
+// when there is none
+func (m *Manager) decodeFrame(path string) (image.Image, error) {
+	if m.imageLoader != nil {
+		return m.imageLoader(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
 }
 
-// exportTimelapseInternal is the internal implementation with option to skip opening folder
-func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []DateInfo, source string, opts TimelapseOptions, openFolder bool) error {
+// parsePreset returns the preset with the given ID, PresetCustom for unknown IDs
+func parsePreset(id string) SocialMediaPreset {
+	switch preset := SocialMediaPreset(id); preset {
+	case PresetInstagramSquare, PresetInstagramPortrait, PresetInstagramStory, PresetInstagramReel,
+		PresetTikTok, PresetYouTube, PresetYouTubeShorts, PresetTwitter, PresetFacebook:
+		return preset
+	default:
+		return PresetCustom
+	}
+}
+
+// ExportTimelapse exports a timelapse video from downloaded imagery. Frames are read from,
+// and the video written under, the output directory of the operation in ctx (the download
+// path if there is none).
+func (m *Manager) ExportTimelapse(ctx context.Context, bbox BoundingBox, zoom int, dates []DateInfo, source string, opts TimelapseOptions) error {
 	log.Printf("=== ExportTimelapse CALLED ===")
 	log.Printf("Parameters: bbox=%+v, zoom=%d, source=%s, dateCount=%d", bbox, zoom, source, len(dates))
 	log.Printf("Options: %+v", opts)
@@ -171,38 +333,16 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 
 	log.Printf("[VideoExport] Starting timelapse video export for %d dates", len(dates))
 	log.Printf("[VideoExport] Source: %s, Zoom: %d", source, zoom)
-	m.emitLog(fmt.Sprintf("Starting timelapse video export for %d dates", len(dates)))
-	m.emitLog(fmt.Sprintf("Source: %s, Zoom: %d", source, zoom))
+	m.emitLog(ctx, fmt.Sprintf("Starting timelapse video export for %d dates", len(dates)))
+	m.emitLog(ctx, fmt.Sprintf("Source: %s, Zoom: %d", source, zoom))
 
 	// Get download directory
-	downloadDir := m.downloadPath
+	downloadDir := downloads.OutputDir(ctx, m.GetDownloadPath())
 	log.Printf("[VideoExport] Download directory: %s", downloadDir)
-	m.emitLog(fmt.Sprintf("Download directory: %s", downloadDir))
+	m.emitLog(ctx, fmt.Sprintf("Download directory: %s", downloadDir))
 
 	// Prepare video export options
-	var preset SocialMediaPreset
-	switch opts.Preset {
-	case "instagram_square":
-		preset = PresetInstagramSquare
-	case "instagram_portrait":
-		preset = PresetInstagramPortrait
-	case "instagram_story":
-		preset = PresetInstagramStory
-	case "instagram_reel":
-		preset = PresetInstagramReel
-	case "tiktok":
-		preset = PresetTikTok
-	case "youtube":
-		preset = PresetYouTube
-	case "youtube_shorts":
-		preset = PresetYouTubeShorts
-	case "twitter":
-		preset = PresetTwitter
-	case "facebook":
-		preset = PresetFacebook
-	default:
-		preset = PresetCustom
-	}
+	preset := parsePreset(opts.Preset)
 
 	// Get dimensions from preset or custom
 	width, height := opts.Width, opts.Height
@@ -219,29 +359,54 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 	}
 
 	exportOpts := &ExportOptions{
-		Width:           width,
-		Height:          height,
-		Preset:          preset,
-		CropX:           cropX,
-		CropY:           cropY,
-		UseSpotlight:    opts.SpotlightEnabled,
-		OverlayOpacity:  opts.OverlayOpacity,
-		OverlayColor:    DefaultExportOptions().OverlayColor, // Use default black
-		ShowDateOverlay: opts.ShowDateOverlay,
-		DateFontSize:    opts.DateFontSize,
-		DatePosition:    opts.DatePosition,
-		DateColor:       DefaultExportOptions().DateColor, // Use default white
-		DateShadow:      true,
-		DateFormat:      "Jan 02, 2006",
-		DateFontData:    m.dateFontData, // Use embedded Arial Unicode font
-		ShowLogo:        opts.ShowLogo,
-		LogoPosition:    opts.LogoPosition,
-		LogoScale:       0.6,
-		FrameRate:       30,
-		FrameDelay:      opts.FrameDelay,
-		OutputFormat:    opts.OutputFormat,
-		Quality:         opts.Quality,
-		UseH264:         true, // Try to use H.264 if FFmpeg is available
+		Width:            width,
+		Height:           height,
+		Preset:           preset,
+		CropX:            cropX,
+		CropY:            cropY,
+		UseSpotlight:     opts.SpotlightEnabled,
+		SpotlightShape:   opts.SpotlightShape,
+		SpotlightFeather: opts.SpotlightFeather,
+		OverlayOpacity:   opts.OverlayOpacity,
+		OverlayColor:     DefaultExportOptions().OverlayColor, // Use default black
+		ShowDateOverlay:  opts.ShowDateOverlay,
+		ShowTimelineBar:  opts.ShowTimelineBar,
+		TitleText:        opts.Title,
+		DateFontSize:     opts.DateFontSize,
+		DatePosition:     opts.DatePosition,
+		DateColor:        DefaultExportOptions().DateColor, // Use default white
+		DateShadow:       true,
+		DateFormat:       m.overlayDateLayout(),
+		DateFontData:     m.dateFontData, // Use embedded Arial Unicode font
+		ShowLogo:         opts.ShowLogo,
+		LogoPosition:     opts.LogoPosition,
+		LogoScale:        0.6,
+		FrameRate:        30,
+		FrameDelay:       opts.FrameDelay,
+		OutputFormat:     opts.OutputFormat,
+		Quality:          opts.Quality,
+		GIFScale:         opts.GIFScale,
+		GIFMaxBytes:      int64(opts.GIFMaxSizeMB * 1024 * 1024),
+		ImageFormat:      opts.ImageFormat,
+		AudioPath:        opts.AudioPath,
+		UseH264:          true, // Try to use H.264 if FFmpeg is available
+	}
+	m.mu.Lock()
+	exportOpts.FFmpegTimeout, exportOpts.FFmpegExtraArgs = m.ffmpegTimeout, m.ffmpegExtraArgs
+	exportOpts.FallbackFontPaths = m.fallbackFonts
+	m.mu.Unlock()
+
+	// Describe the export in the container: titled after the project, or else the title
+	// overlay
+	exportMeta := downloads.Metadata(ctx)
+	exportOpts.Title, exportOpts.Artist = exportMeta.Project, exportMeta.Operator
+	if exportOpts.Title == "" {
+		exportOpts.Title = opts.Title
+	}
+	exportOpts.Description = exportMeta.Notes
+	exportOpts.Subtitles = opts.Subtitles
+	if opts.ShowInfoOverlay {
+		exportOpts.InfoText, exportOpts.InfoPosition = m.infoText(bbox, zoom, source), opts.InfoPosition
 	}
 
 	// Load logo image if enabled
@@ -257,7 +422,7 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 
 	// If spotlight is enabled, calculate pixel coordinates from geographic coordinates
 	if opts.SpotlightEnabled {
-		m.emitLog("Spotlight mode enabled - will calculate coordinates from first frame")
+		m.emitLog(ctx, "Spotlight mode enabled - will calculate coordinates from first frame")
 	}
 
 	// Create video exporter
@@ -272,68 +437,96 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 
 	// Load frames from GeoTIFFs
 	frames := make([]Frame, 0, len(dates))
+
+	// Perceptual hash of the last kept frame, for duplicate frame removal
+	dedupeThreshold := DefaultDedupeThreshold
+	if opts.DedupeThreshold != nil {
+		dedupeThreshold = max(0, min(64, *opts.DedupeThreshold))
+	}
+	var lastHash uint64
+	droppedFrames := 0
 	log.Printf("[VideoExport] Starting frame loading loop for %d dates", len(dates))
 
 	for i, dateInfo := range dates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		log.Printf("[VideoExport] Processing date %d/%d: %s", i+1, len(dates), dateInfo.Date)
-		m.emitProgress(i, len(dates), (i*100)/len(dates), fmt.Sprintf("Loading frame %d/%d: %s", i+1, len(dates), dateInfo.Date))
+		m.emitProgress(ctx, i, len(dates), (i*100)/len(dates), fmt.Sprintf("Loading frame %d/%d: %s", i+1, len(dates), dateInfo.Date))
 
 		// Construct GeoTIFF path using same generateGeoTIFFFilename function as downloads
 		// Provider constants now match filename prefixes directly
-		filename := naming.GenerateGeoTIFFFilename(source, dateInfo.Date, bbox.South, bbox.West, bbox.North, bbox.East, zoom)
-		basePath := filepath.Join(downloadDir, filename)
-
-		// Try loading PNG first (created as sidecar for better compatibility)
-		imagePath := strings.TrimSuffix(basePath, ".tif") + ".png"
-		if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-			// Fallback to GeoTIFF if PNG not found
-			imagePath = basePath
+		// Mixed-source timelapses carry the source per date and label each frame with it
+		frameSource := source
+		frameLabel := ""
+		if source == common.ProviderMixed {
+			frameSource = dateInfo.Source
+			frameLabel = common.ProviderDisplayName(frameSource)
+		}
+		// Exports named under an earlier filename date format are found too
+		filenames := naming.GeoTIFFFilenameVariants(frameSource, dateInfo.Date, bbox.South, bbox.West, bbox.North, bbox.East, zoom)
+
+		// Decode the GeoTIFF directly, the VRT of a split export, or a PNG sidecar
+		// (optional, written by earlier versions for every export)
+		imagePath := frameFile(filepath.Join(downloadDir, filenames[0]))
+		for _, filename := range filenames[1:] {
+			if _, err := os.Stat(imagePath); err == nil {
+				break
+			}
+			imagePath = frameFile(filepath.Join(downloadDir, filename))
 		}
 
 		log.Printf("[VideoExport] Looking for frame: %s", imagePath)
-		m.emitLog(fmt.Sprintf("Looking for frame: %s", imagePath))
+		m.emitLog(ctx, fmt.Sprintf("Looking for frame: %s", imagePath))
 
 		// Check if file exists
 		if _, err := os.Stat(imagePath); os.IsNotExist(err) {
 			log.Printf("[VideoExport] ❌ Frame not found for %s: %s", dateInfo.Date, imagePath)
-			m.emitLog(fmt.Sprintf("❌ Frame not found for %s: %s", dateInfo.Date, imagePath))
+			m.emitLog(ctx, fmt.Sprintf("❌ Frame not found for %s: %s", dateInfo.Date, imagePath))
 			continue
 		}
 
 		log.Printf("[VideoExport] ✅ Found frame for %s", dateInfo.Date)
-		m.emitLog(fmt.Sprintf("✅ Found frame for %s", dateInfo.Date))
+		m.emitLog(ctx, fmt.Sprintf("✅ Found frame for %s", dateInfo.Date))
 
-		// Load image using provided loader
-		log.Printf("[VideoExport] Attempting to load image from: %s", imagePath)
-		var img image.Image
-		if m.imageLoader != nil {
-			img, err = m.imageLoader(imagePath)
-		} else {
-			// Fallback to direct file loading if no loader provided
-			f, openErr := os.Open(imagePath)
-			if openErr != nil {
-				err = openErr
+		// Credit the imagery providers recorded at download (Google Earth exports) next to the date
+		if meta, err := geotiff.ReadAuxMetadata(imagePath); err == nil && len(meta.Providers) > 0 {
+			credit := strings.Join(meta.Providers, ", ")
+			if frameLabel != "" {
+				frameLabel += " · " + credit
 			} else {
-				defer f.Close()
-				img, _, err = image.Decode(f)
+				frameLabel = credit
 			}
 		}
 
+		// Load image using provided loader (decoded frames are reused across exports)
+		log.Printf("[VideoExport] Attempting to load image from: %s", imagePath)
+		rgba, cached, err := m.frameCache.Load(imagePath, m.decodeFrame)
 		if err != nil {
 			log.Printf("[VideoExport] ❌ ERROR: Failed to load image for %s: %v", dateInfo.Date, err)
-			m.emitLog(fmt.Sprintf("Failed to load image for %s: %v", dateInfo.Date, err))
+			m.emitLog(ctx, fmt.Sprintf("Failed to load image for %s: %v", dateInfo.Date, err))
 			continue
 		}
-		log.Printf("[VideoExport] ✅ Successfully loaded image for %s", dateInfo.Date)
-
-		// Convert to RGBA if needed
-		var rgba *image.RGBA
-		if rgbaImg, ok := img.(*image.RGBA); ok {
-			rgba = rgbaImg
+		if cached {
+			log.Printf("[VideoExport] ✅ Reused decoded frame for %s", dateInfo.Date)
 		} else {
-			bounds := img.Bounds()
-			rgba = image.NewRGBA(bounds)
-			draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+			log.Printf("[VideoExport] ✅ Successfully loaded image for %s", dateInfo.Date)
+		}
+
+		// Drop frames with the same imagery as the previous kept frame
+		// (Wayback releases frequently repeat the same capture for a location)
+		if opts.DedupeFrames {
+			hash := DHash(rgba)
+			if len(frames) > 0 {
+				if distance := HashDistance(hash, lastHash); distance <= dedupeThreshold {
+					prevDate := frames[len(frames)-1].Date.Format("2006-01-02")
+					log.Printf("[VideoExport] Dropping frame %s: near-identical to %s (hash distance %d)", dateInfo.Date, prevDate, distance)
+					m.emitLog(ctx, fmt.Sprintf("Dropping frame %s: near-identical to %s", dateInfo.Date, prevDate))
+					droppedFrames++
+					continue
+				}
+			}
+			lastHash = hash
 		}
 
 		// Calculate spotlight coordinates from geographic coordinates on first frame
@@ -341,50 +534,82 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 			spotlightPixels := m.spotlightCalculator(
 				bbox, zoom,
 				opts.SpotlightCenterLat, opts.SpotlightCenterLon,
-				opts.SpotlightRadiusKm,
+				spotlightRadiusKm(opts.SpotlightRadiusKm, opts.SpotlightUnit),
 				rgba.Bounds(),
 			)
 			exportOpts.SpotlightX = spotlightPixels.X
 			exportOpts.SpotlightY = spotlightPixels.Y
 			exportOpts.SpotlightWidth = spotlightPixels.Width
 			exportOpts.SpotlightHeight = spotlightPixels.Height
-			m.emitLog(fmt.Sprintf("Spotlight area: x=%d y=%d w=%d h=%d",
+
+			for _, region := range opts.ExtraSpotlights {
+				pixels := m.spotlightCalculator(bbox, zoom, region.CenterLat, region.CenterLon, spotlightRadiusKm(region.RadiusKm, opts.SpotlightUnit), rgba.Bounds())
+				exportOpts.ExtraSpotlights = append(exportOpts.ExtraSpotlights,
+					image.Rect(pixels.X, pixels.Y, pixels.X+pixels.Width, pixels.Y+pixels.Height))
+			}
+			m.emitLog(ctx, fmt.Sprintf("Spotlight area: x=%d y=%d w=%d h=%d",
 				spotlightPixels.X, spotlightPixels.Y, spotlightPixels.Width, spotlightPixels.Height))
 		}
 
 		// Parse date
 		parsedDate, err := time.Parse("2006-01-02", dateInfo.Date)
 		if err != nil {
-			m.emitLog(fmt.Sprintf("Failed to parse date %s: %v", dateInfo.Date, err))
+			m.emitLog(ctx, fmt.Sprintf("Failed to parse date %s: %v", dateInfo.Date, err))
 			parsedDate = time.Now()
 		}
 
 		frames = append(frames, Frame{
-			Image: rgba,
-			Date:  parsedDate,
+			Image:    rgba,
+			Date:     parsedDate,
+			Label:    frameLabel,
+			Duration: opts.FrameDurations[dateInfo.Date],
 		})
 	}
 
 	log.Printf("[VideoExport] Total frames loaded: %d", len(frames))
-	m.emitLog(fmt.Sprintf("Total frames loaded: %d", len(frames)))
+	m.emitLog(ctx, fmt.Sprintf("Total frames loaded: %d", len(frames)))
+	if droppedFrames > 0 {
+		m.emitLog(ctx, fmt.Sprintf("Dropped %d duplicate frames", droppedFrames))
+	}
 
 	if len(frames) == 0 {
 		log.Printf("[VideoExport] ❌ ERROR: No frames loaded - ensure GeoTIFFs are downloaded first")
-		m.emitLog("❌ ERROR: No frames loaded - ensure GeoTIFFs are downloaded first")
+		m.emitLog(ctx, "❌ ERROR: No frames loaded - ensure GeoTIFFs are downloaded first")
 		return fmt.Errorf("no frames loaded - ensure GeoTIFFs are downloaded first")
 	}
 
+	if opts.StabilizeFrames {
+		m.emitLog(ctx, "Aligning frames to the first date...")
+		shifts := StabilizeFrames(frames)
+		for i, shift := range shifts {
+			if math.Abs(shift.X) >= 0.1 || math.Abs(shift.Y) >= 0.1 {
+				log.Printf("[VideoExport] Frame %s shifted by (%.2f, %.2f) px", frames[i].Date.Format("2006-01-02"), -shift.X, -shift.Y)
+			}
+		}
+	}
+
+	if opts.AutoCrop && !opts.SpotlightEnabled {
+		if cropX, cropY, ok := ChangeCenteredCrop(frames, width, height); ok {
+			exportOpts.CropX, exportOpts.CropY = cropX, cropY
+			m.emitLog(ctx, fmt.Sprintf("Auto-crop centered on the most-changed region (x=%.2f, y=%.2f)", cropX, cropY))
+		} else {
+			m.emitLog(ctx, "Auto-crop found no change to center on, keeping the crop position")
+		}
+	}
+
 	log.Printf("[VideoExport] ✅ Loaded %d frames successfully, starting video encoding...", len(frames))
-	m.emitLog(fmt.Sprintf("✅ Loaded %d frames successfully, starting video encoding...", len(frames)))
+	m.emitLog(ctx, fmt.Sprintf("✅ Loaded %d frames successfully, starting video encoding...", len(frames)))
 
 	// Generate output filename
-	outputFilename := fmt.Sprintf("%s_timelapse_%s_to_%s_%s.%s",
+	outputFilename := fmt.Sprintf("%s_timelapse_%s_to_%s_%s",
 		source,
 		dates[0].Date,
 		dates[len(dates)-1].Date,
 		opts.Preset,
-		opts.OutputFormat,
 	)
+	if ext := FileExtension(opts.OutputFormat); ext != "" {
+		outputFilename += "." + ext
+	}
 	outputPath := filepath.Join(downloadDir, "timelapse_exports", outputFilename)
 
 	// Create output directory
@@ -393,16 +618,16 @@ func (m *Manager) exportTimelapseInternal(bbox BoundingBox, zoom int, dates []Da
 	}
 
 	// Export video
-	m.emitProgress(len(frames), len(frames), 99, "Encoding video...")
+	m.emitProgress(ctx, len(frames), len(frames), 99, "Encoding video...")
 
 	if err := exporter.ExportVideo(frames, outputPath); err != nil {
 		return fmt.Errorf("failed to export video: %w", err)
 	}
 
-	m.emitLog(fmt.Sprintf("Video exported successfully: %s", outputPath))
+	m.emitLog(ctx, fmt.Sprintf("Video exported successfully: %s", outputPath))
 
 	// Emit completion
-	m.emitProgress(len(frames), len(frames), 100, fmt.Sprintf("Video export complete: %s", filepath.Base(outputPath)))
+	m.emitProgress(ctx, len(frames), len(frames), 100, fmt.Sprintf("Video export complete: %s", filepath.Base(outputPath)))
 
 	return nil
 }
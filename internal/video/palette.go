@@ -0,0 +1,113 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+const (
+	// paletteSampleFrames is how many frames (evenly spaced) feed the shared GIF palette
+	paletteSampleFrames = 8
+
+	// paletteSamplesPerFrame caps the pixels sampled from each of those frames
+	paletteSamplesPerFrame = 65536
+)
+
+// colorBox is a set of sampled colors covered by one palette entry
+type colorBox struct {
+	pixels  [][3]uint8
+	channel int // RGB channel with the largest range
+	spread  int // Range of that channel
+}
+
+// newColorBox creates a box and measures its widest channel
+func newColorBox(pixels [][3]uint8) colorBox {
+	box := colorBox{pixels: pixels}
+	box.channel, box.spread = box.widestChannel()
+	return box
+}
+
+// widestChannel returns the RGB channel with the largest range in the box and that range
+func (b colorBox) widestChannel() (channel int, spread int) {
+	for c := 0; c < 3; c++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, p := range b.pixels {
+			if p[c] < lo {
+				lo = p[c]
+			}
+			if p[c] > hi {
+				hi = p[c]
+			}
+		}
+		if int(hi)-int(lo) > spread {
+			channel, spread = c, int(hi)-int(lo)
+		}
+	}
+	return channel, spread
+}
+
+// average returns the mean color of the box
+func (b colorBox) average() color.RGBA {
+	var sum [3]int
+	for _, p := range b.pixels {
+		sum[0] += int(p[0])
+		sum[1] += int(p[1])
+		sum[2] += int(p[2])
+	}
+	n := len(b.pixels)
+	return color.RGBA{R: uint8(sum[0] / n), G: uint8(sum[1] / n), B: uint8(sum[2] / n), A: 255}
+}
+
+// adaptivePalette builds one palette of up to maxColors for a whole animation with median cut
+// over pixels sampled from evenly spaced frames. Sharing it across frames keeps colors stable
+// between frames and lets the GIF store a single global color table.
+func adaptivePalette(frames []image.Image, maxColors int) color.Palette {
+	step := 1
+	if len(frames) > paletteSampleFrames {
+		step = len(frames) / paletteSampleFrames
+	}
+
+	var samples [][3]uint8
+	for i := 0; i < len(frames); i += step {
+		bounds := frames[i].Bounds()
+		stride := 1
+		if area := bounds.Dx() * bounds.Dy(); area > paletteSamplesPerFrame {
+			stride = area / paletteSamplesPerFrame
+		}
+		for n := 0; n < bounds.Dx()*bounds.Dy(); n += stride {
+			x, y := bounds.Min.X+n%bounds.Dx(), bounds.Min.Y+n/bounds.Dx()
+			r, g, b, _ := frames[i].At(x, y).RGBA()
+			samples = append(samples, [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+		}
+	}
+	if len(samples) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	// Repeatedly split the box with the widest channel range at its median
+	boxes := []colorBox{newColorBox(samples)}
+	for len(boxes) < maxColors {
+		best := -1
+		for i, box := range boxes {
+			if box.spread > 0 && (best < 0 || box.spread > boxes[best].spread) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break // Every box holds a single color
+		}
+
+		pixels, channel := boxes[best].pixels, boxes[best].channel
+		sort.Slice(pixels, func(a, b int) bool { return pixels[a][channel] < pixels[b][channel] })
+		mid := len(pixels) / 2
+		boxes[best] = newColorBox(pixels[:mid])
+		boxes = append(boxes, newColorBox(pixels[mid:]))
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		palette = append(palette, box.average())
+	}
+	return palette
+}
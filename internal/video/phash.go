@@ -0,0 +1,69 @@
+package video
+
+import (
+	"image"
+	"math/bits"
+)
+
+// DefaultDedupeThreshold is the maximum dHash Hamming distance (out of 64 bits) at which two
+// consecutive frames are treated as the same imagery. Re-encoded copies of one capture
+// typically differ by 0-3 bits; real changes differ by well over 10. The hash covers the
+// whole frame, so a change to a small part of the area can fall within it; a threshold of
+// 0 drops exact repeats only.
+const DefaultDedupeThreshold = 5
+
+// DHash computes a 64-bit difference hash of img: the image is reduced to a 9x8 grid of
+// average luminance and each bit records whether a cell is brighter than its right neighbour.
+// The hash ignores scale, re-compression and small color shifts, so repeated Wayback releases
+// of the same capture hash alike.
+func DHash(img *image.RGBA) uint64 {
+	const cols, rows = 9, 8
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+
+	// Average luminance per grid cell (box filter over every pixel)
+	var sum [rows][cols]uint64
+	var count [rows][cols]uint64
+	for y := 0; y < h; y++ {
+		r := y * rows / h
+		off := img.PixOffset(b.Min.X, b.Min.Y+y)
+		for x := 0; x < w; x++ {
+			c := x * cols / w
+			p := img.Pix[off : off+3 : off+3]
+			// ITU-R BT.601 luma, scaled by 1000
+			sum[r][c] += 299*uint64(p[0]) + 587*uint64(p[1]) + 114*uint64(p[2])
+			count[r][c]++
+			off += 4
+		}
+	}
+
+	var hash uint64
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols-1; c++ {
+			left := cellAverage(sum[r][c], count[r][c])
+			right := cellAverage(sum[r][c+1], count[r][c+1])
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// cellAverage returns sum/count, or 0 for an empty cell (images narrower than the grid)
+func cellAverage(sum, count uint64) uint64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / count
+}
+
+// HashDistance returns the number of differing bits between two hashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,48 @@
+package video
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+var (
+	probeDurationRe = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+	probeSizeRe     = regexp.MustCompile(`Video: .*?, (\d{2,5})x(\d{2,5})`)
+)
+
+// VideoInfo describes an encoded video or animation
+type VideoInfo struct {
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Duration float64 `json:"duration"` // Seconds
+}
+
+// ProbeVideo reads the resolution and duration of a video file from FFmpeg's stream summary
+func ProbeVideo(path string) (VideoInfo, error) {
+	ffmpegPath, ok := CheckFFmpeg()
+	if !ok {
+		return VideoInfo{}, fmt.Errorf("FFmpeg not found")
+	}
+
+	// Without an output file FFmpeg prints the input summary and exits non-zero, so the
+	// exit status is ignored and only the output is parsed
+	out, _ := exec.Command(ffmpegPath, "-hide_banner", "-i", path).CombinedOutput()
+
+	var info VideoInfo
+	if m := probeDurationRe.FindSubmatch(out); m != nil {
+		h, _ := strconv.Atoi(string(m[1]))
+		min, _ := strconv.Atoi(string(m[2]))
+		sec, _ := strconv.ParseFloat(string(m[3]), 64)
+		info.Duration = float64(h*3600+min*60) + sec
+	}
+	if m := probeSizeRe.FindSubmatch(out); m != nil {
+		info.Width, _ = strconv.Atoi(string(m[1]))
+		info.Height, _ = strconv.Atoi(string(m[2]))
+	}
+	if info.Width == 0 && info.Duration == 0 {
+		return VideoInfo{}, fmt.Errorf("FFmpeg could not read %s", path)
+	}
+	return info, nil
+}
@@ -0,0 +1,192 @@
+package video
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"math/cmplx"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// registrationWindow is the side of the square (power of two) correlated between frames
+const registrationWindow = 512
+
+// Shift is a sub-pixel translation of a frame relative to the reference frame
+type Shift struct {
+	X, Y float64
+}
+
+// StabilizeFrames aligns every frame to the first by phase correlation over a central
+// window, then shifts each frame back by its (sub-pixel) offset to remove jitter from
+// small georeferencing differences between dates. Frames whose size differs from the
+// first, or whose offset exceeds a quarter of the window (an unreliable match), are left
+// untouched. Returns the shift measured for each frame.
+func StabilizeFrames(frames []Frame) []Shift {
+	shifts := make([]Shift, len(frames))
+	if len(frames) < 2 {
+		return shifts
+	}
+
+	bounds := frames[0].Image.Bounds()
+	n := registrationWindow
+	for n > bounds.Dx() || n > bounds.Dy() {
+		n /= 2
+	}
+	if n < 32 {
+		return shifts // Too small to correlate meaningfully
+	}
+	window := image.Rect(0, 0, n, n).Add(bounds.Min).Add(image.Pt((bounds.Dx()-n)/2, (bounds.Dy()-n)/2))
+
+	reference := spectrum(frames[0].Image, window)
+	for i := 1; i < len(frames); i++ {
+		if frames[i].Image.Bounds() != bounds {
+			continue
+		}
+		shift, ok := phaseCorrelate(reference, spectrum(frames[i].Image, window), n)
+		if !ok || math.Abs(shift.X) > float64(n)/4 || math.Abs(shift.Y) > float64(n)/4 {
+			continue
+		}
+		shifts[i] = shift
+		if math.Abs(shift.X) >= 0.1 || math.Abs(shift.Y) >= 0.1 {
+			frames[i].Image = translate(frames[i].Image, shift)
+		}
+	}
+	return shifts
+}
+
+// spectrum returns the 2D FFT of the Hann-windowed luminance of img inside window
+func spectrum(img *image.RGBA, window image.Rectangle) []complex128 {
+	n := window.Dx()
+	hann := make([]float64, n)
+	for i := range hann {
+		hann[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+
+	data := make([]complex128, n*n)
+	for y := 0; y < n; y++ {
+		row := img.Pix[img.PixOffset(window.Min.X, window.Min.Y+y):]
+		for x := 0; x < n; x++ {
+			p := row[x*4 : x*4+3]
+			lum := 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+			data[y*n+x] = complex(lum*hann[x]*hann[y], 0)
+		}
+	}
+	fft2(data, n, false)
+	return data
+}
+
+// phaseCorrelate returns the translation of the frame with spectrum b relative to the
+// reference spectrum a, refined to sub-pixel precision around the correlation peak
+func phaseCorrelate(a, b []complex128, n int) (Shift, bool) {
+	cross := make([]complex128, len(a))
+	for i := range a {
+		c := a[i] * cmplx.Conj(b[i])
+		if mag := cmplx.Abs(c); mag > 1e-9 {
+			cross[i] = c / complex(mag, 0)
+		}
+	}
+	fft2(cross, n, true)
+
+	peak, peakX, peakY := -1.0, 0, 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if v := real(cross[y*n+x]); v > peak {
+				peak, peakX, peakY = v, x, y
+			}
+		}
+	}
+	if peak <= 0 {
+		return Shift{}, false
+	}
+
+	at := func(x, y int) float64 { return real(cross[((y+n)%n)*n+(x+n)%n]) }
+	subX := parabolicPeak(at(peakX-1, peakY), peak, at(peakX+1, peakY))
+	subY := parabolicPeak(at(peakX, peakY-1), peak, at(peakX, peakY+1))
+
+	// The peak sits at minus the frame's offset, wrapped into [0, n)
+	dx, dy := float64(peakX)+subX, float64(peakY)+subY
+	if dx > float64(n)/2 {
+		dx -= float64(n)
+	}
+	if dy > float64(n)/2 {
+		dy -= float64(n)
+	}
+	return Shift{X: -dx, Y: -dy}, true
+}
+
+// parabolicPeak returns the sub-sample offset (-0.5 to 0.5) of the vertex of the parabola
+// through three samples around a maximum
+func parabolicPeak(left, center, right float64) float64 {
+	denom := left - 2*center + right
+	if denom >= 0 {
+		return 0
+	}
+	return math.Max(-0.5, math.Min(0.5, 0.5*(left-right)/denom))
+}
+
+// translate returns img moved by -shift so it lines up with the reference frame.
+// Edges uncovered by the move keep the original pixels rather than going black.
+func translate(img *image.RGBA, shift Shift) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	s2d := f64.Aff3{1, 0, -shift.X, 0, 1, -shift.Y}
+	xdraw.BiLinear.Transform(out, s2d, img, bounds, xdraw.Src, nil)
+	return out
+}
+
+// fft2 transforms an n x n row-major grid in place (n a power of two)
+func fft2(data []complex128, n int, inverse bool) {
+	column := make([]complex128, n)
+	for y := 0; y < n; y++ {
+		fft(data[y*n:(y+1)*n], inverse)
+	}
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			column[y] = data[y*n+x]
+		}
+		fft(column, inverse)
+		for y := 0; y < n; y++ {
+			data[y*n+x] = column[y]
+		}
+	}
+}
+
+// fft is an in-place iterative radix-2 FFT; the inverse is scaled by 1/n
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+
+	if inverse {
+		for i := range a {
+			a[i] /= complex(float64(n), 0)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package video
+
+import (
+	"image"
+	"math"
+)
+
+// smartCropGrid is the number of cells along the longer side of the change map
+const smartCropGrid = 128
+
+// ChangeCenteredCrop returns the CropX/CropY (0-1) that place a width x height output over
+// the region with the most change between consecutive frames. Change is accumulated as the
+// absolute luminance difference of each cell of a coarse grid. ok is false when the output
+// shows the whole frame anyway or the frames never change.
+func ChangeCenteredCrop(frames []Frame, width, height int) (cropX, cropY float64, ok bool) {
+	if len(frames) < 2 || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+
+	bounds := frames[0].Image.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	cell := int(math.Ceil(float64(max(srcW, srcH)) / smartCropGrid))
+	cols, rows := (srcW+cell-1)/cell, (srcH+cell-1)/cell
+
+	// Crop window in source pixels, matching the fill scaling of resizeAndDrawImage
+	scale := math.Max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	windowW, windowH := float64(width)/scale, float64(height)/scale
+	windowCols := int(math.Round(windowW / float64(cell)))
+	windowRows := int(math.Round(windowH / float64(cell)))
+	if windowCols >= cols && windowRows >= rows {
+		return 0, 0, false
+	}
+	windowCols, windowRows = min(max(windowCols, 1), cols), min(max(windowRows, 1), rows)
+
+	// Accumulate per-cell luminance change between consecutive frames
+	change := make([]float64, cols*rows)
+	var prev []float64
+	for _, frame := range frames {
+		if frame.Image.Bounds().Size() != bounds.Size() {
+			continue // Frames of another size can't be compared cell by cell
+		}
+		lum := cellLuminance(frame.Image, cell, cols, rows)
+		if prev != nil {
+			for i := range lum {
+				change[i] += math.Abs(lum[i] - prev[i])
+			}
+		}
+		prev = lum
+	}
+
+	// Summed-area table so every window position sums in constant time
+	sat := make([]float64, (cols+1)*(rows+1))
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			sat[(y+1)*(cols+1)+x+1] = change[y*cols+x] + sat[y*(cols+1)+x+1] + sat[(y+1)*(cols+1)+x] - sat[y*(cols+1)+x]
+		}
+	}
+
+	best, bestX, bestY := 0.0, 0, 0
+	for y := 0; y+windowRows <= rows; y++ {
+		for x := 0; x+windowCols <= cols; x++ {
+			x2, y2 := x+windowCols, y+windowRows
+			sum := sat[y2*(cols+1)+x2] - sat[y*(cols+1)+x2] - sat[y2*(cols+1)+x] + sat[y*(cols+1)+x]
+			if sum > best {
+				best, bestX, bestY = sum, x, y
+			}
+		}
+	}
+	if best == 0 {
+		return 0, 0, false
+	}
+
+	// Window left/top edge -> fraction of the croppable range
+	cropX, cropY = 0.5, 0.5
+	if slack := float64(srcW) - windowW; slack > 0 {
+		cropX = math.Max(0, math.Min(1, float64(bestX*cell)/slack))
+	}
+	if slack := float64(srcH) - windowH; slack > 0 {
+		cropY = math.Max(0, math.Min(1, float64(bestY*cell)/slack))
+	}
+	return cropX, cropY, true
+}
+
+// cellLuminance returns the mean luminance (0-255) of each cell of the grid
+func cellLuminance(img *image.RGBA, cell, cols, rows int) []float64 {
+	sums := make([]float64, cols*rows)
+	counts := make([]int, cols*rows)
+	bounds := img.Bounds()
+	for y := 0; y < bounds.Dy(); y += 2 { // Every other row and column is plenty for a coarse map
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < bounds.Dx(); x += 2 {
+			p := row[x*4 : x*4+3]
+			i := (y/cell)*cols + x/cell
+			sums[i] += 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+			counts[i]++
+		}
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= float64(counts[i])
+		}
+	}
+	return sums
+}
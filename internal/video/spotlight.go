@@ -0,0 +1,116 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"imagery-desktop/internal/utils/units"
+)
+
+// Spotlight shapes
+const (
+	SpotlightRectangle = "rectangle"
+	SpotlightRounded   = "rounded"
+	SpotlightCircle    = "circle"
+	SpotlightEllipse   = "ellipse"
+)
+
+// SpotlightRegion is an additional spotlight area in geographic coordinates
+type SpotlightRegion struct {
+	CenterLat float64 `json:"centerLat"`
+	CenterLon float64 `json:"centerLon"`
+	RadiusKm  float64 `json:"radiusKm"`
+}
+
+// spotlightRadiusKm converts a spotlight radius entered in unit ("mi", otherwise km) to km
+func spotlightRadiusKm(radius float64, unit string) float64 {
+	if unit == "mi" {
+		return units.MilesToKm(radius)
+	}
+	return radius
+}
+
+// spotlightAlpha returns how much of the spotlight shows at (x, y) inside a w x h region:
+// 1 well inside the shape, 0 outside it, ramping linearly over feather pixels at the edge
+func spotlightAlpha(shape string, feather, x, y, w, h float64) float64 {
+	// Inside distance to the shape edge (negative outside)
+	var d float64
+	switch shape {
+	case SpotlightCircle:
+		r := math.Min(w, h) / 2
+		d = r - math.Hypot(x-w/2, y-h/2)
+	case SpotlightEllipse:
+		rx, ry := w/2, h/2
+		k := math.Hypot((x-rx)/rx, (y-ry)/ry)
+		d = (1 - k) * math.Min(rx, ry)
+	case SpotlightRounded:
+		r := math.Min(w, h) / 5
+		// Distance to the inner rectangle inset by r, then subtract from r
+		qx := math.Max(math.Abs(x-w/2)-(w/2-r), 0)
+		qy := math.Max(math.Abs(y-h/2)-(h/2-r), 0)
+		inner := math.Min(math.Min(x, w-x), math.Min(y, h-y))
+		if qx > 0 && qy > 0 {
+			d = r - math.Hypot(qx, qy)
+		} else {
+			d = inner
+		}
+	default:
+		d = math.Min(math.Min(x, w-x), math.Min(y, h-y))
+	}
+
+	if d <= 0 {
+		return 0
+	}
+	if feather <= 0 || d >= feather {
+		return 1
+	}
+	return d / feather
+}
+
+// blendSpotlight mixes the full-color source pixel c over the grayed pixel at (x, y)
+func blendSpotlight(dst *image.RGBA, x, y int, c color.Color, alpha float64) {
+	if alpha >= 1 {
+		dst.Set(x, y, c)
+		return
+	}
+	r, g, b, a := c.RGBA()
+	gr, gg, gb, ga := dst.At(x, y).RGBA()
+	mix := func(top, bottom uint32) uint16 {
+		return uint16(float64(top)*alpha + float64(bottom)*(1-alpha))
+	}
+	dst.Set(x, y, color.RGBA64{R: mix(r, gr), G: mix(g, gg), B: mix(b, gb), A: mix(a, ga)})
+}
+
+// drawExtraSpotlights restores full color inside each extra region, in place on the grayed
+// image drawn by drawGrayedImage (same fit scaling)
+func (e *Exporter) drawExtraSpotlights(dst *image.RGBA, src image.Image) {
+	if len(e.options.ExtraSpotlights) == 0 {
+		return
+	}
+
+	bounds := src.Bounds()
+	dstBounds := dst.Bounds()
+	scale := math.Min(float64(dstBounds.Dx())/float64(bounds.Dx()), float64(dstBounds.Dy())/float64(bounds.Dy()))
+	feather := float64(e.options.SpotlightFeather)
+
+	for _, region := range e.options.ExtraSpotlights {
+		// Region in output pixels
+		minX, minY := float64(region.Min.X)*scale, float64(region.Min.Y)*scale
+		w, h := float64(region.Dx())*scale, float64(region.Dy())*scale
+		area := image.Rect(int(minX), int(minY), int(math.Ceil(minX+w)), int(math.Ceil(minY+h))).Intersect(dstBounds)
+
+		for dy := area.Min.Y; dy < area.Max.Y; dy++ {
+			for dx := area.Min.X; dx < area.Max.X; dx++ {
+				alpha := spotlightAlpha(e.options.SpotlightShape, feather, float64(dx)-minX+0.5, float64(dy)-minY+0.5, w, h)
+				if alpha == 0 {
+					continue
+				}
+				sx, sy := int(float64(dx)/scale), int(float64(dy)/scale)
+				if sx >= bounds.Min.X && sx < bounds.Max.X && sy >= bounds.Min.Y && sy < bounds.Max.Y {
+					blendSpotlight(dst, dx, dy, src.At(sx, sy), alpha)
+				}
+			}
+		}
+	}
+}
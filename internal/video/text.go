@@ -0,0 +1,346 @@
+package video
+
+import "unicode"
+
+// Overlay text layout: the font drawer places glyphs left to right, one per rune, so
+// Arabic letters are converted to their contextual presentation forms and right-to-left
+// runs are reordered into display order before drawing
+
+// joining is how an Arabic letter connects to its neighbours
+type joining int
+
+const (
+	joinNone  joining = iota // Never joins (hamza)
+	joinRight                // Joins the preceding letter only (alef, dal, reh, waw...)
+	joinDual                 // Joins both sides (beh, seen, lam...)
+)
+
+// arabicLetter holds the isolated presentation form of a letter; its final, initial and
+// medial forms follow it in that order (initial and medial only for dual-joining letters)
+type arabicLetter struct {
+	isolated rune
+	join     joining
+}
+
+// Presentation form offsets from the isolated form
+const (
+	formIsolated = 0
+	formFinal    = 1
+	formInitial  = 2
+	formMedial   = 3
+)
+
+var arabicLetters = map[rune]arabicLetter{
+	0x0621: {0xFE80, joinNone},  // hamza
+	0x0622: {0xFE81, joinRight}, // alef with madda
+	0x0623: {0xFE83, joinRight}, // alef with hamza above
+	0x0624: {0xFE85, joinRight}, // waw with hamza
+	0x0625: {0xFE87, joinRight}, // alef with hamza below
+	0x0626: {0xFE89, joinDual},  // yeh with hamza
+	0x0627: {0xFE8D, joinRight}, // alef
+	0x0628: {0xFE8F, joinDual},  // beh
+	0x0629: {0xFE93, joinRight}, // teh marbuta
+	0x062A: {0xFE95, joinDual},  // teh
+	0x062B: {0xFE99, joinDual},  // theh
+	0x062C: {0xFE9D, joinDual},  // jeem
+	0x062D: {0xFEA1, joinDual},  // hah
+	0x062E: {0xFEA5, joinDual},  // khah
+	0x062F: {0xFEA9, joinRight}, // dal
+	0x0630: {0xFEAB, joinRight}, // thal
+	0x0631: {0xFEAD, joinRight}, // reh
+	0x0632: {0xFEAF, joinRight}, // zain
+	0x0633: {0xFEB1, joinDual},  // seen
+	0x0634: {0xFEB5, joinDual},  // sheen
+	0x0635: {0xFEB9, joinDual},  // sad
+	0x0636: {0xFEBD, joinDual},  // dad
+	0x0637: {0xFEC1, joinDual},  // tah
+	0x0638: {0xFEC5, joinDual},  // zah
+	0x0639: {0xFEC9, joinDual},  // ain
+	0x063A: {0xFECD, joinDual},  // ghain
+	0x0641: {0xFED1, joinDual},  // feh
+	0x0642: {0xFED5, joinDual},  // qaf
+	0x0643: {0xFED9, joinDual},  // kaf
+	0x0644: {0xFEDD, joinDual},  // lam
+	0x0645: {0xFEE1, joinDual},  // meem
+	0x0646: {0xFEE5, joinDual},  // noon
+	0x0647: {0xFEE9, joinDual},  // heh
+	0x0648: {0xFEED, joinRight}, // waw
+	0x0649: {0xFEEF, joinRight}, // alef maksura (no initial/medial presentation forms)
+	0x064A: {0xFEF1, joinDual},  // yeh
+	0x0671: {0xFB50, joinRight}, // alef wasla
+	0x067E: {0xFB56, joinDual},  // peh (Persian, Urdu)
+	0x0686: {0xFB7A, joinDual},  // tcheh
+	0x0698: {0xFB8A, joinRight}, // jeh
+	0x06A9: {0xFB8E, joinDual},  // keheh
+	0x06AF: {0xFB92, joinDual},  // gaf
+	0x06CC: {0xFBFC, joinDual},  // farsi yeh
+}
+
+// lamAlef maps the alef following a lam to the isolated form of their ligature (the
+// final form follows it)
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640 // Connects on both sides and is drawn as is
+)
+
+// isTransparent reports whether r is a combining mark, which is skipped when finding the
+// letters a letter joins
+func isTransparent(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+// joinsForward reports whether r connects to the letter after it
+func joinsForward(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	l, ok := arabicLetters[r]
+	return ok && l.join == joinDual
+}
+
+// joinsBack reports whether r connects to the letter before it
+func joinsBack(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	l, ok := arabicLetters[r]
+	return ok && l.join != joinNone
+}
+
+// shapeArabic replaces Arabic letters with the presentation forms matching their position
+// in the word, and lam-alef pairs with their ligature. Other text is unchanged.
+func shapeArabic(text []rune) []rune {
+	out := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		letter, ok := arabicLetters[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+
+		prev, next := -1, -1
+		for j := i - 1; j >= 0; j-- {
+			if !isTransparent(text[j]) {
+				prev = j
+				break
+			}
+		}
+		for j := i + 1; j < len(text); j++ {
+			if !isTransparent(text[j]) {
+				next = j
+				break
+			}
+		}
+		joinedBefore := prev >= 0 && joinsForward(text[prev]) && letter.join != joinNone
+
+		if r == arabicLam && next == i+1 {
+			if ligature, ok := lamAlef[text[next]]; ok {
+				if joinedBefore {
+					ligature += formFinal
+				}
+				out = append(out, ligature)
+				i = next
+				continue
+			}
+		}
+
+		joinedAfter := next >= 0 && letter.join == joinDual && joinsBack(text[next])
+		form := formIsolated
+		switch {
+		case joinedBefore && joinedAfter:
+			form = formMedial
+		case joinedBefore:
+			form = formFinal
+		case joinedAfter:
+			form = formInitial
+		}
+		out = append(out, letter.isolated+rune(form))
+	}
+	return out
+}
+
+// Bidirectional classes used by visualOrder (a subset of the Unicode bidi algorithm
+// sufficient for single-line overlay text)
+type bidiClass int
+
+const (
+	bidiNeutral bidiClass = iota
+	bidiLTR
+	bidiRTL
+	bidiNumber
+)
+
+func classify(r rune) bidiClass {
+	switch {
+	case r >= 0x0590 && r <= 0x08FF, r >= 0xFB1D && r <= 0xFDFF, r >= 0xFE70 && r <= 0xFEFF:
+		if unicode.IsDigit(r) {
+			return bidiNumber // Arabic-Indic digits
+		}
+		if unicode.IsLetter(r) || isTransparent(r) {
+			return bidiRTL
+		}
+		return bidiNeutral
+	case unicode.IsDigit(r):
+		return bidiNumber
+	case unicode.IsLetter(r):
+		return bidiLTR
+	}
+	return bidiNeutral
+}
+
+// mirrored maps brackets to their mirror image, used inside right-to-left runs
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+	'<': '>', '>': '<', '«': '»', '»': '«',
+}
+
+// visualOrder returns text in the order its characters are displayed left to right. The
+// paragraph direction follows the first strong letter; numbers keep their digit order
+// inside right-to-left text, and spaces and punctuation take the direction of the
+// letters around them.
+func visualOrder(text []rune) []rune {
+	classes := make([]bidiClass, len(text))
+	first := bidiNeutral
+	for i, r := range text {
+		classes[i] = classify(r)
+		if first == bidiNeutral && (classes[i] == bidiLTR || classes[i] == bidiRTL) {
+			first = classes[i]
+		}
+	}
+	base := bidiLTR
+	if first == bidiRTL {
+		base = bidiRTL
+	}
+	if base == bidiLTR && !contains(classes, bidiRTL) {
+		return text
+	}
+
+	// Numbers following left-to-right letters (or starting left-to-right text) are
+	// left-to-right; elsewhere they count as right-to-left when resolving neutrals
+	resolved := make([]bidiClass, len(classes))
+	strong := base
+	for i, c := range classes {
+		switch c {
+		case bidiLTR, bidiRTL:
+			strong = c
+			resolved[i] = c
+		case bidiNumber:
+			if strong == bidiLTR {
+				resolved[i] = bidiLTR
+			} else {
+				resolved[i] = bidiNumber
+			}
+		default:
+			resolved[i] = bidiNeutral
+		}
+	}
+
+	// Neutrals between text of one direction take that direction, others the paragraph's
+	direction := func(c bidiClass) bidiClass {
+		if c == bidiNumber {
+			return bidiRTL
+		}
+		return c
+	}
+	for i := 0; i < len(resolved); {
+		if resolved[i] != bidiNeutral {
+			i++
+			continue
+		}
+		end := i
+		for end < len(resolved) && resolved[end] == bidiNeutral {
+			end++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = direction(resolved[i-1])
+		}
+		if end < len(resolved) {
+			after = direction(resolved[end])
+		}
+		dir := base
+		if before == after {
+			dir = before
+		}
+		for j := i; j < end; j++ {
+			resolved[j] = dir
+		}
+		i = end
+	}
+
+	// Embedding levels: even levels run left to right, odd right to left
+	levels := make([]int, len(resolved))
+	baseLevel := 0
+	if base == bidiRTL {
+		baseLevel = 1
+	}
+	maxLevel := baseLevel
+	for i, c := range resolved {
+		switch {
+		case c == bidiRTL:
+			levels[i] = baseLevel | 1
+		case c == bidiNumber:
+			levels[i] = (baseLevel | 1) + 1
+		case baseLevel == 1:
+			levels[i] = 2
+		default:
+			levels[i] = 0
+		}
+		if levels[i] > maxLevel {
+			maxLevel = levels[i]
+		}
+	}
+
+	out := make([]rune, len(text))
+	copy(out, text)
+	for i, level := range levels {
+		if level%2 == 1 {
+			if m, ok := mirrored[out[i]]; ok {
+				out[i] = m
+			}
+		}
+	}
+
+	// From the highest level down to the lowest odd one, reverse every run at or above it
+	lowestOdd := baseLevel | 1
+	for level := maxLevel; level >= lowestOdd; level-- {
+		for i := 0; i < len(out); {
+			if levels[i] < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(out) && levels[end] >= level {
+				end++
+			}
+			for l, r := i, end-1; l < r; l, r = l+1, r-1 {
+				out[l], out[r] = out[r], out[l]
+				levels[l], levels[r] = levels[r], levels[l]
+			}
+			i = end
+		}
+	}
+	return out
+}
+
+func contains(classes []bidiClass, class bidiClass) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutText returns text shaped and in display order, ready for a font.Drawer
+func layoutText(text string) string {
+	return string(visualOrder(shapeArabic([]rune(text))))
+}
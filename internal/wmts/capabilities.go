@@ -6,8 +6,17 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
+
+	"imagery-desktop/internal/netproxy"
 )
 
+// httpClient fetches capabilities through the app's proxy settings
+var httpClient = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: netproxy.NewTransport(),
+}
+
 // WMTS XML structures for parsing capabilities
 type Capabilities struct {
 	XMLName xml.Name `xml:"Capabilities"`
@@ -48,7 +57,7 @@ type LayerInfo struct {
 
 // FetchCapabilities fetches and parses WMTS capabilities from URL
 func FetchCapabilities(url string) (*Capabilities, error) {
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch capabilities: %w", err)
 	}
@@ -110,6 +119,7 @@ func ConvertTemplateToXYZ(template string) string {
 	result := strings.ReplaceAll(template, "{TileMatrix}", "{z}")
 	result = strings.ReplaceAll(result, "{TileCol}", "{x}")
 	result = strings.ReplaceAll(result, "{TileRow}", "{y}")
+	result = strings.ReplaceAll(result, "{Time}", "{time}") // Time dimension, filled from CustomSource.Times
 	return result
 }
 
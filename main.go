@@ -2,6 +2,8 @@ package main
 
 import (
 	"embed"
+	"errors"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -9,6 +11,9 @@ import (
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/options/mac"
+
+	"imagery-desktop/internal/singleinstance"
 )
 
 //go:embed all:frontend/dist
@@ -56,8 +61,26 @@ func main() {
 	// Also print to console for user awareness
 	println("Debug logs:", logPath)
 
+	// Only one instance may use the task queue and tile cache. A second launch hands its
+	// arguments to the running instance and exits before touching either.
+	instance, err := singleinstance.Acquire(appDir)
+	if errors.Is(err, singleinstance.ErrAlreadyRunning) {
+		log.Printf("Another instance is running, handed over arguments %v", os.Args[1:])
+		println("Imagery Desktop is already running")
+		return
+	}
+	if err != nil {
+		log.Printf("Single-instance lock unavailable: %v", err)
+	} else {
+		defer instance.Close()
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
+	app.instance = instance
+
+	// Also tee log output into the running task's log file
+	log.SetOutput(io.MultiWriter(logFile, app.taskQueue.TaskLog()))
 
 	// Enable dev mode based on environment or debug detection
 	// Set DEV_MODE=1 environment variable when running in development
@@ -73,6 +96,11 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnBeforeClose:    app.beforeClose,
+		Mac: &mac.Options{
+			// macOS delivers walkthru:// links as events instead of arguments
+			OnUrlOpen: app.openDeepLink,
+		},
 		Bind: []interface{}{
 			app,
 		},
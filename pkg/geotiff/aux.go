@@ -0,0 +1,189 @@
+package geotiff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Footprint is the extent of a gap in an export (a tile that failed to download or decode),
+// in the raster's CRS units
+type Footprint struct {
+	Col  int // Grid cell column within the export
+	Row  int // Grid cell row within the export (0 = top)
+	MinX float64
+	MinY float64
+	MaxX float64
+	MaxY float64
+}
+
+// WKT returns the footprint as a closed WKT polygon
+func (f Footprint) WKT() string {
+	return fmt.Sprintf("POLYGON ((%.4f %.4f, %.4f %.4f, %.4f %.4f, %.4f %.4f, %.4f %.4f))",
+		f.MinX, f.MinY, f.MaxX, f.MinY, f.MaxX, f.MaxY, f.MinX, f.MaxY, f.MinX, f.MinY)
+}
+
+// MissingFootprints scans img in cell x cell blocks and returns the footprint of every block
+// with no opaque pixels. Stitched exports start fully transparent, so these are the tiles
+// that never got drawn. pixelHeight may be positive or negative; rows always step down.
+func MissingFootprints(img *image.RGBA, cell int, originX, originY, pixelWidth, pixelHeight float64) []Footprint {
+	if cell <= 0 {
+		return nil
+	}
+
+	stepY := pixelHeight
+	if stepY > 0 {
+		stepY = -stepY
+	}
+
+	b := img.Bounds()
+	var missing []Footprint
+	for row, y0 := 0, b.Min.Y; y0 < b.Max.Y; row, y0 = row+1, y0+cell {
+		y1 := min(y0+cell, b.Max.Y)
+		for col, x0 := 0, b.Min.X; x0 < b.Max.X; col, x0 = col+1, x0+cell {
+			x1 := min(x0+cell, b.Max.X)
+			if !blockEmpty(img, x0, y0, x1, y1) {
+				continue
+			}
+			missing = append(missing, Footprint{
+				Col:  col,
+				Row:  row,
+				MinX: originX + float64(x0-b.Min.X)*pixelWidth,
+				MaxX: originX + float64(x1-b.Min.X)*pixelWidth,
+				MinY: originY + float64(y1-b.Min.Y)*stepY,
+				MaxY: originY + float64(y0-b.Min.Y)*stepY,
+			})
+		}
+	}
+	return missing
+}
+
+// blockEmpty reports whether every pixel in [x0,x1) x [y0,y1) is fully transparent
+func blockEmpty(img *image.RGBA, x0, y0, x1, y1 int) bool {
+	for y := y0; y < y1; y++ {
+		off := img.PixOffset(x0, y)
+		for x := x0; x < x1; x++ {
+			if img.Pix[off+3] != 0 {
+				return false
+			}
+			off += 4
+		}
+	}
+	return true
+}
+
+// AuxMetadata describes an export in a GDAL PAM (.aux.xml) sidecar
+type AuxMetadata struct {
+	Source     string
+	Date       string
+	AppVersion string // Omitted from the sidecar when empty
+	EPSG       int
+	Providers  []string    // Imagery provider credits, most common first (omitted when empty)
+	Missing    []Footprint // Recorded in the MISSING_TILES domain as WKT polygons
+
+	// Free-text description of the export (each omitted when empty)
+	Project  string
+	Operator string
+	Notes    string
+}
+
+// providersSeparator joins provider credits in the Imagery_Providers item
+const providersSeparator = "; "
+
+// WriteAuxMetadata writes the .aux.xml sidecar next to rasterPath (a GeoTIFF or VRT).
+// GDAL and QGIS read it automatically, so mosaicking tools can see where an export has gaps.
+func WriteAuxMetadata(rasterPath string, meta AuxMetadata) error {
+	var buf bytes.Buffer
+	buf.WriteString("<PAMDataset>\n")
+	buf.WriteString("  <Metadata domain=\"IMAGE_STRUCTURE\">\n")
+	buf.WriteString("    <MDI key=\"COMPRESSION\">NONE</MDI>\n")
+	buf.WriteString("    <MDI key=\"INTERLEAVE\">PIXEL</MDI>\n")
+	buf.WriteString("  </Metadata>\n")
+	buf.WriteString("  <Metadata domain=\"\">\n")
+	fmt.Fprintf(&buf, "    <MDI key=\"Source\">%s</MDI>\n", xmlEscape(meta.Source))
+	fmt.Fprintf(&buf, "    <MDI key=\"Date\">%s</MDI>\n", xmlEscape(meta.Date))
+	fmt.Fprintf(&buf, "    <MDI key=\"CRS\">EPSG:%d</MDI>\n", meta.EPSG)
+	if meta.AppVersion != "" {
+		fmt.Fprintf(&buf, "    <MDI key=\"Generated_By\">WalkThru Earth Imagery Desktop v%s</MDI>\n", xmlEscape(meta.AppVersion))
+	}
+	if len(meta.Providers) > 0 {
+		fmt.Fprintf(&buf, "    <MDI key=\"Imagery_Providers\">%s</MDI>\n", xmlEscape(strings.Join(meta.Providers, providersSeparator)))
+	}
+	for _, item := range []struct{ key, value string }{
+		{"Project", meta.Project},
+		{"Operator", meta.Operator},
+		{"Notes", meta.Notes},
+	} {
+		if item.value != "" {
+			fmt.Fprintf(&buf, "    <MDI key=\"%s\">%s</MDI>\n", item.key, xmlEscape(item.value))
+		}
+	}
+	buf.WriteString("  </Metadata>\n")
+
+	if len(meta.Missing) > 0 {
+		buf.WriteString("  <Metadata domain=\"MISSING_TILES\">\n")
+		fmt.Fprintf(&buf, "    <MDI key=\"COUNT\">%d</MDI>\n", len(meta.Missing))
+		for _, f := range meta.Missing {
+			fmt.Fprintf(&buf, "    <MDI key=\"TILE_C%d_R%d\">%s</MDI>\n", f.Col, f.Row, f.WKT())
+		}
+		buf.WriteString("  </Metadata>\n")
+	}
+	buf.WriteString("</PAMDataset>\n")
+
+	if err := os.WriteFile(rasterPath+".aux.xml", buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata sidecar: %w", err)
+	}
+	return nil
+}
+
+// ReadAuxMetadata reads the default-domain items (source, date, CRS, imagery providers and
+// the export's description) of the .aux.xml sidecar next to rasterPath. Missing-tile footprints are not read back.
+func ReadAuxMetadata(rasterPath string) (AuxMetadata, error) {
+	data, err := os.ReadFile(rasterPath + ".aux.xml")
+	if err != nil {
+		return AuxMetadata{}, err
+	}
+
+	var pam struct {
+		Metadata []struct {
+			Domain string `xml:"domain,attr"`
+			Items  []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"MDI"`
+		} `xml:"Metadata"`
+	}
+	if err := xml.Unmarshal(data, &pam); err != nil {
+		return AuxMetadata{}, fmt.Errorf("failed to parse metadata sidecar: %w", err)
+	}
+
+	var meta AuxMetadata
+	for _, md := range pam.Metadata {
+		if md.Domain != "" {
+			continue
+		}
+		for _, item := range md.Items {
+			switch item.Key {
+			case "Source":
+				meta.Source = item.Value
+			case "Date":
+				meta.Date = item.Value
+			case "CRS":
+				meta.EPSG, _ = strconv.Atoi(strings.TrimPrefix(item.Value, "EPSG:"))
+			case "Imagery_Providers":
+				meta.Providers = strings.Split(item.Value, providersSeparator)
+			case "Project":
+				meta.Project = item.Value
+			case "Operator":
+				meta.Operator = item.Value
+			case "Notes":
+				meta.Notes = item.Value
+			}
+		}
+	}
+	return meta, nil
+}
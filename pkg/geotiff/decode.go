@@ -0,0 +1,195 @@
+package geotiff
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported is returned by Decode for TIFF layouts it does not read directly
+// (compression, tiles, planar bands, other bit depths); Load falls back to the
+// general-purpose TIFF decoder for those
+var ErrUnsupported = errors.New("unsupported TIFF layout")
+
+// decodeBlockRows is how many rows Decode reads at once
+const decodeBlockRows = 256
+
+// Decode reads the full-resolution image of an uncompressed, strip-organised 8-bit
+// RGB/RGBA or grayscale TIFF or BigTIFF, the layout Encode writes by default. Overview IFDs are skipped.
+// Pixels are copied straight into the result, which is much faster and lighter than the
+// general-purpose decoder for the large exports this app produces.
+func Decode(r io.ReaderAt) (*image.RGBA, error) {
+	tags, err := readFirstIFD(r)
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(tags.uint(TagType_ImageWidth))
+	height := int(tags.uint(TagType_ImageLength))
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid TIFF dimensions %dx%d", width, height)
+	}
+	samples := int(tags.uint(TagType_SamplesPerPixel))
+	if samples == 0 {
+		samples = 1
+	}
+	compression := tags.uint(TagType_Compression)
+	planar := tags.uint(TagType_PlanarConfiguration)
+	photometric := tags.uint(TagType_PhotometricInterpretation)
+
+	if (compression != 0 && compression != 1) || (planar != 0 && planar != 1) {
+		return nil, ErrUnsupported
+	}
+	for _, bits := range tags.shorts(TagType_BitsPerSample) {
+		if bits != 8 {
+			return nil, ErrUnsupported
+		}
+	}
+	switch {
+	case photometric == 2 && (samples == 3 || samples == 4): // RGB, RGBA
+	case photometric <= 1 && (samples == 1 || samples == 2): // Grayscale (0 = white is zero)
+	default:
+		return nil, ErrUnsupported
+	}
+	// ExtraSamples 1 = associated (premultiplied) alpha, 2 = unassociated alpha
+	premultiplied := tags.uint(TagType_ExtraSamples) == 1
+
+	offsets := tags.uints(TagType_StripOffsets)
+	counts := tags.uints(TagType_StripByteCounts)
+	if len(offsets) == 0 || len(offsets) != len(counts) {
+		return nil, ErrUnsupported // Tiled, or no strips
+	}
+	rowsPerStrip := int(tags.uint(TagType_RowsPerStrip))
+	if rowsPerStrip <= 0 || rowsPerStrip > height {
+		rowsPerStrip = height
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rowBytes := width * samples
+	block := make([]byte, rowBytes*min(rowsPerStrip, decodeBlockRows))
+	for y := 0; y < height; {
+		// Read as many rows of the current strip as fit in the block
+		strip := y / rowsPerStrip
+		if strip >= len(offsets) {
+			return nil, fmt.Errorf("TIFF strip %d is missing", strip)
+		}
+		rows := min(rowsPerStrip-y%rowsPerStrip, height-y, decodeBlockRows)
+		offset := int64(offsets[strip]) + int64(y%rowsPerStrip)*int64(rowBytes)
+		if _, err := r.ReadAt(block[:rows*rowBytes], offset); err != nil {
+			return nil, fmt.Errorf("failed to read TIFF rows %d-%d: %w", y, y+rows-1, err)
+		}
+
+		for i := 0; i < rows; i++ {
+			row := block[i*rowBytes : (i+1)*rowBytes]
+			dst := img.Pix[(y+i)*img.Stride : (y+i)*img.Stride+width*4]
+			for x := 0; x < width; x++ {
+				s := row[x*samples : (x+1)*samples]
+				d := dst[x*4 : x*4+4]
+				switch samples {
+				case 1, 2:
+					v := s[0]
+					if photometric == 0 {
+						v = 255 - v
+					}
+					d[0], d[1], d[2] = v, v, v
+				default:
+					d[0], d[1], d[2] = s[0], s[1], s[2]
+				}
+
+				a := uint8(255)
+				if samples == 2 || samples == 4 {
+					a = s[samples-1]
+				}
+				d[3] = a
+				if a != 255 && !premultiplied {
+					// image.RGBA holds premultiplied color
+					d[0] = uint8(uint16(d[0]) * uint16(a) / 255)
+					d[1] = uint8(uint16(d[1]) * uint16(a) / 255)
+					d[2] = uint8(uint16(d[2]) * uint16(a) / 255)
+				}
+			}
+		}
+		y += rows
+	}
+	return img, nil
+}
+
+// decodeFile decodes the TIFF at path with Decode, falling back to the general-purpose
+// decoder for layouts Decode does not handle
+func decodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := Decode(f)
+	if err == nil {
+		return img, nil
+	}
+	if !errors.Is(err, ErrUnsupported) {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	std, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return std, nil
+}
+
+// vrtDataset is the subset of a GDAL VRT written by WriteVRT that LoadImage needs
+type vrtDataset struct {
+	Width  int `xml:"rasterXSize,attr"`
+	Height int `xml:"rasterYSize,attr"`
+	Bands  []struct {
+		Sources []struct {
+			Filename string `xml:"SourceFilename"`
+			DstRect  struct {
+				XOff int `xml:"xOff,attr"`
+				YOff int `xml:"yOff,attr"`
+			} `xml:"DstRect"`
+		} `xml:"SimpleSource"`
+	} `xml:"VRTRasterBand"`
+}
+
+// LoadImage decodes the image of a GeoTIFF, or of a split export's VRT by assembling its
+// parts (see SaveSplit), without reading the georeferencing
+func LoadImage(path string) (image.Image, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".vrt") {
+		return decodeFile(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vrt vrtDataset
+	if err := xml.Unmarshal(data, &vrt); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if vrt.Width <= 0 || vrt.Height <= 0 || len(vrt.Bands) == 0 {
+		return nil, fmt.Errorf("%s is not a mosaic VRT", path)
+	}
+
+	// Every band references the same parts; the first band's sources place them
+	mosaic := image.NewRGBA(image.Rect(0, 0, vrt.Width, vrt.Height))
+	for _, src := range vrt.Bands[0].Sources {
+		part, err := decodeFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(src.Filename)))
+		if err != nil {
+			return nil, err
+		}
+		at := image.Pt(src.DstRect.XOff, src.DstRect.YOff)
+		draw.Draw(mosaic, part.Bounds().Sub(part.Bounds().Min).Add(at), part, part.Bounds().Min, draw.Src)
+	}
+	return mosaic, nil
+}
@@ -0,0 +1,94 @@
+package geotiff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+const (
+	DataType_Float = 11
+
+	TagType_SampleFormat = 339
+	TagType_GDALNoData   = 42113 // GDAL_NODATA (ASCII)
+
+	SampleFormat_IEEEFP = 3
+)
+
+// EncodeFloat32 writes a single-band float32 raster to w as an uncompressed TIFF.
+// data is row-major with len(data) == width*height.
+// extraTags follows the same conventions as Encode.
+func EncodeFloat32(w io.Writer, data []float32, width, height int, extraTags map[uint16]interface{}) error {
+	if len(data) != width*height {
+		return fmt.Errorf("raster size mismatch: got %d values for %dx%d", len(data), width, height)
+	}
+
+	pixelData := new(bytes.Buffer)
+	pixelData.Grow(len(data) * 4)
+	for _, v := range data {
+		pixelData.Write(enc32(math.Float32bits(v)))
+	}
+	pixels := pixelData.Bytes()
+
+	var entries []ifdEntry
+	addEntry := func(tag uint16, datatype uint16, count uint32, data []byte) {
+		entries = append(entries, ifdEntry{tag, datatype, count, data})
+	}
+
+	// Width/height as LONG so large DEMs are not truncated
+	addEntry(TagType_ImageWidth, DataType_Long, 1, enc32(uint32(width)))
+	addEntry(TagType_ImageLength, DataType_Long, 1, enc32(uint32(height)))
+	addEntry(TagType_BitsPerSample, DataType_Short, 1, enc16(32))
+	addEntry(TagType_Compression, DataType_Short, 1, enc16(1))               // None
+	addEntry(TagType_PhotometricInterpretation, DataType_Short, 1, enc16(1)) // BlackIsZero
+	addEntry(TagType_SamplesPerPixel, DataType_Short, 1, enc16(1))
+	addEntry(TagType_RowsPerStrip, DataType_Long, 1, enc32(uint32(height)))
+	addEntry(TagType_SampleFormat, DataType_Short, 1, enc16(SampleFormat_IEEEFP))
+
+	// Placeholders filled in by writeIFDAndData
+	addEntry(TagType_StripOffsets, DataType_Long, 1, make([]byte, 4))
+	addEntry(TagType_StripByteCounts, DataType_Long, 1, make([]byte, 4))
+
+	entries, err := appendExtraTags(entries, extraTags)
+	if err != nil {
+		return err
+	}
+
+	return writeIFDAndData(w, entries, pixels)
+}
+
+// SaveDEMAsGeoTIFF saves a float32 elevation raster as a georeferenced TIFF.
+// epsg selects the CRS: 4326 (origin/pixel size in degrees) or 3857 (meters).
+// nodata is written as the GDAL_NODATA tag so GIS tools mask empty cells.
+func SaveDEMAsGeoTIFF(data []float32, width, height int, outputPath string, originX, originY, pixelWidth, pixelHeight float64, epsg int, nodata float32) error {
+	extraTags := make(map[uint16]interface{})
+
+	geoKeys, err := GeoKeyDirectory(epsg)
+	if err != nil {
+		return fmt.Errorf("DEM: %w", err)
+	}
+	extraTags[TagType_GeoKeyDirectoryTag] = geoKeys
+
+	scaleY := pixelHeight
+	if scaleY < 0 {
+		scaleY = -scaleY
+	}
+	extraTags[TagType_ModelPixelScaleTag] = []float64{pixelWidth, scaleY, 0.0}
+	extraTags[TagType_ModelTiepointTag] = []float64{0.0, 0.0, 0.0, originX, originY, 0.0}
+	extraTags[TagType_GDALNoData] = strconv.FormatFloat(float64(nodata), 'f', -1, 32)
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	if err := EncodeFloat32(f, data, width, height, extraTags); err != nil {
+		return fmt.Errorf("failed to encode DEM GeoTIFF: %w", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"io"
 	"math"
 	"os"
@@ -15,26 +16,36 @@ import (
 // Defining minimal set is safer to avoid dependency weirdness if we only need a few.
 
 const (
-	DataType_Byte     = 1
-	DataType_ASCII    = 2
-	DataType_Short    = 3
-	DataType_Long     = 4
-	DataType_Rational = 5
-	DataType_Double   = 12
-	DataType_IFD      = 13
-
+	DataType_Byte      = 1
+	DataType_ASCII     = 2
+	DataType_Short     = 3
+	DataType_Long      = 4
+	DataType_Rational  = 5
+	DataType_Undefined = 7
+	DataType_Double    = 12
+	DataType_IFD       = 13
+	DataType_Long8     = 16 // BigTIFF only
+	DataType_IFD8      = 18 // BigTIFF only
+
+	TagType_NewSubfileType            = 254
 	TagType_ImageWidth                = 256
 	TagType_ImageLength               = 257
 	TagType_BitsPerSample             = 258
 	TagType_Compression               = 259
 	TagType_PhotometricInterpretation = 262
+	TagType_ImageDescription          = 270
 	TagType_StripOffsets              = 273
 	TagType_SamplesPerPixel           = 277
 	TagType_RowsPerStrip              = 278
 	TagType_StripByteCounts           = 279
 	TagType_XResolution               = 282
 	TagType_YResolution               = 283
+	TagType_PlanarConfiguration       = 284
 	TagType_ResolutionUnit            = 296
+	TagType_Artist                    = 315
+	TagType_ExtraSamples              = 338
+	TagType_Copyright                 = 33432
+	TagType_ICCProfile                = 34675
 
 	// GeoTIFF Tags
 	TagType_ModelPixelScaleTag = 33550
@@ -59,103 +70,375 @@ func (d byTag) Len() int           { return len(d) }
 func (d byTag) Less(i, j int) bool { return d[i].tag < d[j].tag }
 func (d byTag) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
 
-// Encode writes the image m to w as an uncompressed RGBA TIFF.
+// EncodeOptions controls optional encoding features
+type EncodeOptions struct {
+	// Overviews lists reduction factors (2, 4, 8...) to embed as internal overviews.
+	// GIS software reads these instead of the full image when zoomed out.
+	Overviews []int
+
+	// Alpha writes a fourth, unassociated alpha band (ExtraSamples = 2) so masked or
+	// missing areas stay transparent. Without it the image is written as plain RGB.
+	Alpha bool
+
+	// EPSG selects the CRS of the origin and pixel size: 3857 (meters, the default)
+	// or 4326 (degrees, for polar areas outside Web Mercator)
+	EPSG int
+
+	// Gray writes a single gray band (plus alpha when Alpha is set) instead of RGB.
+	// Implied for *image.Gray and *image.Gray16 images.
+	Gray bool
+
+	// BitsPerSample is 8 (the default) or 16. 16 is implied for *image.Gray16,
+	// *image.RGBA64 and *image.NRGBA64 images so their full precision is kept.
+	BitsPerSample int
+
+	// Copyright is written as the TIFF Copyright tag (imagery provider credits) when set
+	Copyright string
+
+	// Description is written as the TIFF ImageDescription tag when set, replacing one
+	// given in extraTags
+	Description string
+
+	// Artist is written as the TIFF Artist tag (who made the export) when set
+	Artist string
+}
+
+// sampleLayout is how the pixels of an image are stored in the TIFF
+type sampleLayout struct {
+	gray  bool // One gray band instead of R, G, B
+	alpha bool // Trailing unassociated alpha band
+	bits  int  // 8 or 16 bits per sample
+}
+
+// layoutFor picks the sample layout for m from opts and the image type
+func layoutFor(m image.Image, opts *EncodeOptions) (sampleLayout, error) {
+	l := sampleLayout{bits: 8}
+	switch m.(type) {
+	case *image.Gray:
+		l.gray = true
+	case *image.Gray16:
+		l.gray, l.bits = true, 16
+	case *image.RGBA64, *image.NRGBA64:
+		l.bits = 16
+	}
+	if opts == nil {
+		return l, nil
+	}
+
+	l.alpha = opts.Alpha
+	l.gray = l.gray || opts.Gray
+	switch opts.BitsPerSample {
+	case 0:
+	case 8, 16:
+		l.bits = opts.BitsPerSample
+	default:
+		return l, fmt.Errorf("unsupported bits per sample: %d (must be 8 or 16)", opts.BitsPerSample)
+	}
+	return l, nil
+}
+
+// samples returns the number of samples per pixel
+func (l sampleLayout) samples() int {
+	n := 3
+	if l.gray {
+		n = 1
+	}
+	if l.alpha {
+		n++
+	}
+	return n
+}
+
+// Encode writes the image m to w as an uncompressed RGB TIFF tagged with an sRGB ICC profile,
+// or as a grayscale and/or 16-bit TIFF for those image types (see EncodeOptions).
 // extraTags is a map of TagID -> value.
 // Supported value types: []uint16 (SHORT), []float64 (DOUBLE), string (ASCII).
 func Encode(w io.Writer, m image.Image, extraTags map[uint16]interface{}) error {
-	bounds := m.Bounds()
-	width, height := bounds.Dx(), bounds.Dy()
+	return EncodeWithOptions(w, m, extraTags, nil)
+}
 
-	// 1. Write Header
-	// LittleEndian (II), Version 42 (0x2A), First IFD Offset (8)
-	header := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00}
-	if _, err := w.Write(header); err != nil {
+// HasTransparency reports whether any pixel of m is not fully opaque.
+// Callers use it to decide whether an export needs an alpha band.
+func HasTransparency(m image.Image) bool {
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := m.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EncodeWithOptions writes the image m to w like Encode, with optional internal overviews
+// and alpha band.
+// Overviews are written as reduced-resolution IFDs (NewSubfileType = 1) chained after
+// the full-resolution image, which is how GDAL stores internal overviews.
+// Output that would pass the 4 GB limit of classic TIFF is written as BigTIFF.
+func EncodeWithOptions(w io.Writer, m image.Image, extraTags map[uint16]interface{}, opts *EncodeOptions) error {
+	layout, err := layoutFor(m, opts)
+	if err != nil {
 		return err
 	}
 
-	// 2. Prepare Image Data (Uncompressed RGBA)
-	// We assume RGBA for simplicity as per requirements (merged tiles are likely RGBA).
-	// If input is not RGBA, we convert.
-	// TIFF RGBA: 8 bits per sample, 4 samples (R,G,B,A).
+	entries, pixels := imageIFD(m, layout)
+	if !layout.gray {
+		// The sRGB profile describes RGB data; gray bands are left untagged
+		entries = append(entries, ifdEntry{TagType_ICCProfile, DataType_Undefined, uint32(len(SRGBProfile())), SRGBProfile()})
+	}
 
-	// Buffer for pixel data
-	pixelData := new(bytes.Buffer)
+	// Extra Tags (GeoTags)
+	entries, err = appendExtraTags(entries, extraTags)
+	if err != nil {
+		return err
+	}
+	if opts != nil && opts.Copyright != "" {
+		entries = append(entries, asciiEntry(TagType_Copyright, opts.Copyright))
+	}
+	if opts != nil && opts.Description != "" {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.tag != TagType_ImageDescription {
+				kept = append(kept, e)
+			}
+		}
+		entries = append(kept, asciiEntry(TagType_ImageDescription, opts.Description))
+	}
+	if opts != nil && opts.Artist != "" {
+		entries = append(entries, asciiEntry(TagType_Artist, opts.Artist))
+	}
 
-	// Write pixels
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, a := m.At(x, y).RGBA()
-			// RGBA() returns 16-bit values. Convert to 8-bit.
-			pixelData.WriteByte(uint8(r >> 8))
-			pixelData.WriteByte(uint8(g >> 8))
-			pixelData.WriteByte(uint8(b >> 8))
-			pixelData.WriteByte(uint8(a >> 8))
+	ifds := []tiffIFD{{entries, pixels}}
+
+	if opts != nil && len(opts.Overviews) > 0 {
+		for _, ov := range buildOverviews(m, opts.Overviews, layout.bits == 16) {
+			ovEntries, ovPixels := imageIFD(ov, layout)
+			// NewSubfileType: 1 = reduced-resolution version of another image in this file
+			ovEntries = append(ovEntries, ifdEntry{TagType_NewSubfileType, DataType_Long, 1, enc32(1)})
+			ifds = append(ifds, tiffIFD{ovEntries, ovPixels})
 		}
 	}
 
-	pixels := pixelData.Bytes()
-	imageLen := uint32(len(pixels))
+	return writeIFDs(w, ifds)
+}
+
+// asciiEntry returns a NUL-terminated ASCII tag entry
+func asciiEntry(tag uint16, value string) ifdEntry {
+	return ifdEntry{tag, DataType_ASCII, uint32(len(value) + 1), append([]byte(value), 0)}
+}
 
-	// 3. Write Image Data immediately after header?
-	// Header says First IFD is at 8. So we write IFD at 8.
-	// Then pixels come after IFD (or before? Standard allows anywhere).
-	// Usually: Header -> IFD -> Pixels.
-	// Let's write IFD at offset 8.
+// imageIFD builds the baseline IFD entries and pixel strip for an image stored with layout:
+// RGB or gray, optionally followed by an unassociated alpha band, at 8 or 16 bits per sample
+func imageIFD(m image.Image, layout sampleLayout) ([]ifdEntry, []byte) {
+	bounds := m.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	samples := layout.samples()
 
-	// We need to know IFD size to determine Pixel Offset.
-	// IFD Size = 2 (count) + 12*entries + 4 (next offset).
-	// + Data for values > 4 bytes.
+	// Uncompressed, chunky (interleaved) samples
+	pixels := make([]byte, 0, width*height*samples*layout.bits/8)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixels = appendSamples(pixels, m.At(x, y), layout)
+		}
+	}
 
-	// Let's prepare entries first.
+	// Pixels are written after the IFD and its out-of-line values
 	var entries []ifdEntry
 
 	addEntry := func(tag uint16, datatype uint16, count uint32, data []byte) {
 		entries = append(entries, ifdEntry{tag, datatype, count, data})
 	}
 
+	photometric := uint16(2) // RGB
+	if layout.gray {
+		photometric = 1 // BlackIsZero
+	}
+
+	// SHORT covers most sizes; larger dimensions need LONG
+	addDimension := func(tag uint16, v int) {
+		if v > math.MaxUint16 {
+			addEntry(tag, DataType_Long, 1, enc32(uint32(v)))
+		} else {
+			addEntry(tag, DataType_Short, 1, enc16(uint16(v)))
+		}
+	}
+
 	// Standard Tags
-	addEntry(TagType_ImageWidth, DataType_Short, 1, enc16(uint16(width)))
-	addEntry(TagType_ImageLength, DataType_Short, 1, enc16(uint16(height)))
-	addEntry(TagType_BitsPerSample, DataType_Short, 4, enc16s([]uint16{8, 8, 8, 8}))
-	addEntry(TagType_Compression, DataType_Short, 1, enc16(1))               // None
-	addEntry(TagType_PhotometricInterpretation, DataType_Short, 1, enc16(2)) // RGB
-	addEntry(TagType_SamplesPerPixel, DataType_Short, 1, enc16(4))
-	addEntry(TagType_RowsPerStrip, DataType_Short, 1, enc16(uint16(height)))
+	addDimension(TagType_ImageWidth, width)
+	addDimension(TagType_ImageLength, height)
+	bits := make([]uint16, samples)
+	for i := range bits {
+		bits[i] = uint16(layout.bits)
+	}
+	addEntry(TagType_BitsPerSample, DataType_Short, uint32(samples), enc16s(bits))
+	addEntry(TagType_Compression, DataType_Short, 1, enc16(1)) // None
+	addEntry(TagType_PhotometricInterpretation, DataType_Short, 1, enc16(photometric))
+	addEntry(TagType_SamplesPerPixel, DataType_Short, 1, enc16(uint16(samples)))
+	addDimension(TagType_RowsPerStrip, height)
 	addEntry(TagType_XResolution, DataType_Rational, 1, encRational(72, 1))
 	addEntry(TagType_YResolution, DataType_Rational, 1, encRational(72, 1))
 	addEntry(TagType_ResolutionUnit, DataType_Short, 1, enc16(2)) // Inch
+	if layout.alpha {
+		addEntry(TagType_ExtraSamples, DataType_Short, 1, enc16(2)) // Unassociated alpha
+	}
 
 	// Placeholder for StripOffsets and StripByteCounts (calculated later)
 	// We'll update them once we know where pixels start.
 	addEntry(TagType_StripOffsets, DataType_Long, 1, make([]byte, 4))
 	addEntry(TagType_StripByteCounts, DataType_Long, 1, make([]byte, 4)) // we know count though
 
-	// Extra Tags (GeoTags)
+	return entries, pixels
+}
+
+// appendSamples appends the samples of one pixel to dst in the byte order of the file
+func appendSamples(dst []byte, c color.Color, layout sampleLayout) []byte {
+	var v [4]uint32
+	if layout.alpha {
+		// Unassociated alpha stores straight (non-premultiplied) color
+		n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+		v = [4]uint32{uint32(n.R), uint32(n.G), uint32(n.B), uint32(n.A)}
+	} else {
+		v[0], v[1], v[2], _ = c.RGBA()
+	}
+
+	samples := v[:layout.samples()]
+	if layout.gray {
+		// Same luma weights as color.Gray16Model
+		v[0], v[1] = (19595*v[0]+38470*v[1]+7471*v[2]+1<<15)>>16, v[3]
+	}
+
+	for _, s := range samples {
+		if layout.bits == 16 {
+			dst = enc.AppendUint16(dst, uint16(s))
+		} else {
+			dst = append(dst, uint8(s>>8))
+		}
+	}
+	return dst
+}
+
+// appendExtraTags converts caller-supplied tag values into IFD entries.
+// Supported value types: []uint16 (SHORT), []float64 (DOUBLE), string (ASCII).
+func appendExtraTags(entries []ifdEntry, extraTags map[uint16]interface{}) ([]ifdEntry, error) {
 	for tag, val := range extraTags {
 		switch v := val.(type) {
 		case []uint16:
-			addEntry(tag, DataType_Short, uint32(len(v)), enc16s(v))
+			entries = append(entries, ifdEntry{tag, DataType_Short, uint32(len(v)), enc16s(v)})
 		case []float64:
-			addEntry(tag, DataType_Double, uint32(len(v)), encDoubles(v))
+			entries = append(entries, ifdEntry{tag, DataType_Double, uint32(len(v)), encDoubles(v)})
 		case string:
 			// ASCII needs null terminator
 			b := append([]byte(v), 0)
-			addEntry(tag, DataType_ASCII, uint32(len(b)), b)
+			entries = append(entries, ifdEntry{tag, DataType_ASCII, uint32(len(b)), b})
 		default:
-			return fmt.Errorf("unsupported tag value type for tag %d", tag)
+			return nil, fmt.Errorf("unsupported tag value type for tag %d", tag)
+		}
+	}
+	return entries, nil
+}
+
+// tiffIFD is one image file directory and its pixel strip
+type tiffIFD struct {
+	entries []ifdEntry
+	pixels  []byte
+}
+
+// bigTIFFThreshold is the file size above which BigTIFF is written: classic TIFF offsets
+// are 32-bit, so nothing may start past 4 GB
+const bigTIFFThreshold = math.MaxUint32
+
+// writeIFDAndData writes the TIFF header and a single IFD followed by its out-of-line values
+// and the pixel strip.
+// entries must contain StripOffsets and StripByteCounts placeholders; they are filled in here.
+func writeIFDAndData(w io.Writer, entries []ifdEntry, pixels []byte) error {
+	return writeIFDs(w, []tiffIFD{{entries, pixels}})
+}
+
+// writeIFDs writes the TIFF header and a chain of IFDs, each followed by its out-of-line
+// values and pixel strip. Subsequent IFDs (e.g. overviews) start on a word boundary.
+// The file is a BigTIFF (version 43, 8-byte offsets) when its estimated size needs one.
+func writeIFDs(w io.Writer, ifds []tiffIFD) error {
+	return writeTIFF(w, ifds, estimateFileSize(ifds) > bigTIFFThreshold)
+}
+
+// estimateFileSize returns the size of ifds written as a classic TIFF, rounded up
+func estimateFileSize(ifds []tiffIFD) uint64 {
+	size := uint64(8)
+	for _, ifd := range ifds {
+		size += uint64(2+12*len(ifd.entries)+4) + uint64(len(ifd.pixels)) + 1
+		for _, e := range ifd.entries {
+			if len(e.data) > 4 {
+				size += uint64(len(e.data))
+			}
+		}
+	}
+	return size
+}
+
+// tiffFormat holds the field sizes that differ between classic TIFF and BigTIFF
+type tiffFormat struct {
+	big       bool
+	countSize int // IFD entry count
+	entrySize int
+	valueSize int // Inline value/offset field, also the size of offsets
+}
+
+var (
+	classicFormat = tiffFormat{big: false, countSize: 2, entrySize: 12, valueSize: 4}
+	bigFormat     = tiffFormat{big: true, countSize: 8, entrySize: 20, valueSize: 8}
+)
+
+// writeTIFF writes the header then ifds as a classic TIFF or, when big is set, a BigTIFF
+func writeTIFF(w io.Writer, ifds []tiffIFD, big bool) error {
+	format := classicFormat
+	// LittleEndian (II), Version 42 (0x2A), First IFD Offset (8)
+	header := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00}
+	if big {
+		format = bigFormat
+		// LittleEndian (II), Version 43 (0x2B), offset size 8, reserved, First IFD Offset (16)
+		header = append([]byte{'I', 'I', 0x2B, 0x00, 0x08, 0x00, 0x00, 0x00}, enc64(16)...)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	offset := uint64(len(header))
+	for i, ifd := range ifds {
+		last := i == len(ifds)-1
+		end, err := writeIFD(w, format, offset, ifd.entries, ifd.pixels, last)
+		if err != nil {
+			return err
+		}
+		offset = end
+		if !last && offset%2 != 0 {
+			// IFDs must begin on a word boundary
+			if _, err := w.Write([]byte{0}); err != nil {
+				return err
+			}
+			offset++
 		}
 	}
+	return nil
+}
+
+// writeIFD writes one IFD at offset (the writer must be positioned there).
+// When last is false the next-IFD pointer is set to the end of this IFD's pixel data
+// (rounded up to a word boundary). Returns the offset just past the written data.
+func writeIFD(w io.Writer, format tiffFormat, offset uint64, entries []ifdEntry, pixels []byte, last bool) (uint64, error) {
+	imageLen := uint64(len(pixels))
 
 	sort.Sort(byTag(entries))
 
 	// Calculate offsets
-	// Header: 8 bytes
-	// IFD Start: 8
-	// IFD Entries: 2 + 12*N + 4
-	ifdSize := 2 + 12*len(entries) + 4
+	// IFD Entries: count + entries + next IFD offset
+	ifdSize := format.countSize + format.entrySize*len(entries) + format.valueSize
 
-	// Value Data Area (for values > 4 bytes) starts after IFD Table
-	valueDataOffset := 8 + ifdSize
+	// Value Data Area (for values that don't fit inline) starts after IFD Table
+	valueDataOffset := offset + uint64(ifdSize)
 
 	// We collect all "large" data to write it sequentially
 	var largeDataBuf bytes.Buffer
@@ -164,7 +447,7 @@ func Encode(w io.Writer, m image.Image, extraTags map[uint16]interface{}) error
 	for i := range entries {
 		e := &entries[i]
 		dataLen := len(e.data)
-		if dataLen <= 4 {
+		if dataLen <= format.valueSize {
 			// Fits in value field, pad with zeros
 			// Right-padded? No, TIFF value is left-aligned in the 4 bytes?
 			// "If the value fits into 4 bytes, the value is stored in the Value Offset."
@@ -175,73 +458,89 @@ func Encode(w io.Writer, m image.Image, extraTags map[uint16]interface{}) error
 			// So we leave e.data as is, and when writing we check length.
 		} else {
 			// Does not fit, needs to go to data area
-			currentOffset := uint32(valueDataOffset + largeDataBuf.Len())
+			currentOffset := valueDataOffset + uint64(largeDataBuf.Len())
 			// The Entry ValueOffset field will hold this offset.
 			// We temporarily store the offset in e.data? No, e.data holds the actual data.
 			// We need a way to mark it.
 			// We'll write the data to largeDataBuf now.
 			largeDataBuf.Write(e.data)
 			// And replace e.data with the offset
-			e.data = enc32(currentOffset)
+			e.data = format.encOffset(currentOffset)
 		}
 	}
 
 	// Now we know the end of Value Data Area.
-	pixelsOffset := uint32(valueDataOffset + largeDataBuf.Len())
+	pixelsOffset := valueDataOffset + uint64(largeDataBuf.Len())
+	end := pixelsOffset + imageLen
 
-	// Update StripOffsets
+	// Update StripOffsets (only 1 strip, so 1 offset) and StripByteCounts
 	for i := range entries {
-		if entries[i].tag == TagType_StripOffsets {
-			entries[i].data = enc32(pixelsOffset) // Only 1 strip, so 1 offset.
-			// If fits in 4 bytes (it does), we are good.
-		}
-		if entries[i].tag == TagType_StripByteCounts {
-			entries[i].data = enc32(imageLen)
+		switch entries[i].tag {
+		case TagType_StripOffsets:
+			entries[i].datatype, entries[i].data = format.offsetType(), format.encOffset(pixelsOffset)
+		case TagType_StripByteCounts:
+			entries[i].datatype, entries[i].data = format.offsetType(), format.encOffset(imageLen)
 		}
 	}
 
 	// 4. Write IFD
 	// Count
-	if err := binary.Write(w, enc, uint16(len(entries))); err != nil {
-		return err
+	if _, err := w.Write(format.encOffset(uint64(len(entries)))[:format.countSize]); err != nil {
+		return 0, err
 	}
 
 	// Entries
 	for _, e := range entries {
-		if err := binary.Write(w, enc, e.tag); err != nil {
-			return err
-		}
-		if err := binary.Write(w, enc, e.datatype); err != nil {
-			return err
-		}
-		if err := binary.Write(w, enc, e.count); err != nil {
-			return err
-		}
-
-		// Offset/Value field (4 bytes)
-		var val [4]byte
-		copy(val[:], e.data) // Helper to copy available bytes (2 or 4) to 4-byte array
-		if _, err := w.Write(val[:]); err != nil {
-			return err
+		entry := make([]byte, 4, format.entrySize)
+		enc.PutUint16(entry[0:2], e.tag)
+		enc.PutUint16(entry[2:4], e.datatype)
+		entry = append(entry, format.encOffset(uint64(e.count))...)
+
+		// Offset/Value field, left-justified
+		val := make([]byte, format.valueSize)
+		copy(val, e.data)
+		entry = append(entry, val...)
+		if _, err := w.Write(entry); err != nil {
+			return 0, err
 		}
 	}
 
-	// Next IFD Offset (0)
-	if err := binary.Write(w, enc, uint32(0)); err != nil {
-		return err
+	// Next IFD Offset (0 for the last IFD)
+	nextIFD := uint64(0)
+	if !last {
+		nextIFD = end + end%2
+	}
+	if _, err := w.Write(format.encOffset(nextIFD)); err != nil {
+		return 0, err
 	}
 
 	// 5. Write Large Data
 	if _, err := largeDataBuf.WriteTo(w); err != nil {
-		return err
+		return 0, err
 	}
 
 	// 6. Write Pixels
 	if _, err := w.Write(pixels); err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return end, nil
+}
+
+// encOffset encodes an offset, byte count or entry count in the format's offset size
+func (f tiffFormat) encOffset(v uint64) []byte {
+	if f.big {
+		return enc64(v)
+	}
+	return enc32(uint32(v))
+}
+
+// offsetType is the data type of strip offsets and byte counts
+func (f tiffFormat) offsetType() uint16 {
+	if f.big {
+		return DataType_Long8
+	}
+	return DataType_Long
 }
 
 // Helpers
@@ -258,6 +557,12 @@ func enc32(v uint32) []byte {
 	return b
 }
 
+func enc64(v uint64) []byte {
+	b := make([]byte, 8)
+	enc.PutUint64(b, v)
+	return b
+}
+
 func enc16s(vs []uint16) []byte {
 	b := make([]byte, 2*len(vs))
 	for i, v := range vs {
@@ -293,6 +598,11 @@ func hostFloat64ToUint64(f float64) uint64 {
 // This function creates a GeoTIFF with EPSG:3857 (Web Mercator) projection
 // and optional metadata sidecar file for source and date information.
 func SaveAsGeoTIFFWithMetadata(img image.Image, outputPath string, originX, originY, pixelWidth, pixelHeight float64, source, date string, appVersion string) error {
+	return SaveAsGeoTIFFWithOptions(img, outputPath, originX, originY, pixelWidth, pixelHeight, source, date, appVersion, nil)
+}
+
+// SaveAsGeoTIFFWithOptions is SaveAsGeoTIFFWithMetadata with encoding options (e.g. overviews)
+func SaveAsGeoTIFFWithOptions(img image.Image, outputPath string, originX, originY, pixelWidth, pixelHeight float64, source, date string, appVersion string, opts *EncodeOptions) error {
 	// Import required packages
 	// os is needed for Create and WriteFile
 	// fmt is needed for error wrapping
@@ -305,24 +615,23 @@ func SaveAsGeoTIFFWithMetadata(img image.Image, outputPath string, originX, orig
 	}
 	defer f.Close()
 
-	// Define GeoKeys (EPSG:3857 Web Mercator)
+	// Define GeoKeys (EPSG:3857 Web Mercator unless opts selects another CRS)
+	epsg := 3857
+	if opts != nil && opts.EPSG != 0 {
+		epsg = opts.EPSG
+	}
 	extraTags := make(map[uint16]interface{})
 
 	// Tag 34735: GeoKeyDirectoryTag (SHORT)
-	// Version=1, Revision=1, Minor=0, Keys=3
-	// 1024 (GTModelType) = 1 (Projected CRS)
-	// 1025 (GTRasterType) = 1 (PixelIsArea - pixel represents area, not point)
-	// 3072 (ProjectedCSType) = 3857 (WGS 84 / Pseudo-Mercator - EPSG:3857)
-	extraTags[TagType_GeoKeyDirectoryTag] = []uint16{
-		1, 1, 0, 3,
-		1024, 0, 1, 1,    // GTModelTypeGeoKey: Projected
-		1025, 0, 1, 1,    // GTRasterTypeGeoKey: PixelIsArea
-		3072, 0, 1, 3857, // ProjectedCSTypeGeoKey: EPSG:3857
+	geoKeys, err := GeoKeyDirectory(epsg)
+	if err != nil {
+		return err
 	}
+	extraTags[TagType_GeoKeyDirectoryTag] = geoKeys
 
 	// Tag 33550: ModelPixelScaleTag (DOUBLE)
 	// ScaleX, ScaleY, ScaleZ
-	// Pixel dimensions in the model space (meters for EPSG:3857)
+	// Pixel dimensions in the model space (meters for EPSG:3857, degrees for EPSG:4326)
 	// ScaleY is typically abs(pixelHeight) as it represents magnitude
 	scaleY := pixelHeight
 	if scaleY < 0 {
@@ -336,32 +645,42 @@ func SaveAsGeoTIFFWithMetadata(img image.Image, outputPath string, originX, orig
 	extraTags[TagType_ModelTiepointTag] = []float64{0.0, 0.0, 0.0, originX, originY, 0.0}
 
 	// Encode as GeoTIFF with metadata
-	if err := Encode(f, img, extraTags); err != nil {
+	if err := EncodeWithOptions(f, img, extraTags, opts); err != nil {
 		return fmt.Errorf("failed to encode GeoTIFF: %w", err)
 	}
 
 	// Also write a metadata sidecar file (.aux.xml) for complete metadata
 	if source != "" && date != "" && appVersion != "" {
-		auxPath := outputPath + ".aux.xml"
-		auxContent := fmt.Sprintf(`<PAMDataset>
-  <Metadata domain="IMAGE_STRUCTURE">
-    <MDI key="COMPRESSION">NONE</MDI>
-    <MDI key="INTERLEAVE">PIXEL</MDI>
-  </Metadata>
-  <Metadata domain="">
-    <MDI key="Source">%s</MDI>
-    <MDI key="Date">%s</MDI>
-    <MDI key="CRS">EPSG:3857</MDI>
-    <MDI key="Generated_By">WalkThru Earth Imagery Desktop v%s</MDI>
-  </Metadata>
-</PAMDataset>
-`, source, date, appVersion)
-		if err := os.WriteFile(auxPath, []byte(auxContent), 0644); err != nil {
-			// Don't fail on sidecar write errors, just log
-			// Note: log package needs to be imported
-			_ = err // Ignore error for now, caller can check
-		}
+		// Don't fail on sidecar write errors, the GeoTIFF itself is complete
+		_ = WriteAuxMetadata(outputPath, AuxMetadata{Source: source, Date: date, AppVersion: appVersion, EPSG: epsg})
 	}
 
 	return nil
 }
+
+// GeoKeyDirectory returns the GeoKeyDirectoryTag for a supported CRS:
+// 3857 (WGS 84 / Pseudo-Mercator, meters) or 4326 (WGS 84 geographic, degrees)
+func GeoKeyDirectory(epsg int) ([]uint16, error) {
+	// Version=1, Revision=1, Minor=0, Keys=3
+	// 1025 (GTRasterType) = 1 (PixelIsArea - pixel represents area, not point)
+	switch epsg {
+	case 4326:
+		// 1024 (GTModelType) = 2 (Geographic), 2048 (GeographicType) = 4326
+		return []uint16{
+			1, 1, 0, 3,
+			1024, 0, 1, 2, // GTModelTypeGeoKey: Geographic
+			1025, 0, 1, 1, // GTRasterTypeGeoKey: PixelIsArea
+			2048, 0, 1, 4326, // GeographicTypeGeoKey: EPSG:4326
+		}, nil
+	case 3857:
+		// 1024 (GTModelType) = 1 (Projected), 3072 (ProjectedCSType) = 3857
+		return []uint16{
+			1, 1, 0, 3,
+			1024, 0, 1, 1, // GTModelTypeGeoKey: Projected
+			1025, 0, 1, 1, // GTRasterTypeGeoKey: PixelIsArea
+			3072, 0, 1, 3857, // ProjectedCSTypeGeoKey: EPSG:3857
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported EPSG code: %d (supported: 4326, 3857)", epsg)
+	}
+}
@@ -0,0 +1,143 @@
+package geotiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+var (
+	srgbProfileOnce sync.Once
+	srgbProfile     []byte
+)
+
+// SRGBProfile returns a compact ICC v2 sRGB display profile.
+// Viewers that color-manage TIFFs without an embedded profile may assume a different
+// color space, which is why untagged exports can look slightly off-color.
+func SRGBProfile() []byte {
+	srgbProfileOnce.Do(func() {
+		srgbProfile = buildSRGBProfile()
+	})
+	return srgbProfile
+}
+
+// iccTag is a tag signature and its encoded data
+type iccTag struct {
+	sig  string
+	data []byte
+}
+
+// buildSRGBProfile assembles the sRGB profile: D50-adapted primaries, D50 white point and
+// the sRGB transfer curve sampled as a 1024-entry table
+func buildSRGBProfile() []byte {
+	trc := iccCurve(1024, func(v float64) float64 {
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	})
+
+	tags := []iccTag{
+		{"desc", iccDesc("sRGB IEC61966-2.1")},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(0.9642, 1.0, 0.8249)},
+		{"rXYZ", iccXYZ(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", iccXYZ(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", iccXYZ(0.1431, 0.0606, 0.7141)},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	// Layout: 128-byte header, tag count + 12-byte entries, then 4-byte aligned tag data.
+	// Identical data (the three TRCs) is stored once and shared.
+	tableSize := 4 + 12*len(tags)
+	offset := 128 + tableSize
+
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	written := make(map[string]uint32)
+	for _, t := range tags {
+		key := string(t.data)
+		tagOffset, shared := written[key]
+		if !shared {
+			tagOffset = uint32(offset + data.Len())
+			written[key] = tagOffset
+			data.Write(t.data)
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, tagOffset)
+		binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+	}
+
+	size := 128 + table.Len() + data.Len()
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // Version 2.1
+	copy(header[12:], "mntr")                          // Display device class
+	copy(header[16:], "RGB ")                          // Data color space
+	copy(header[20:], "XYZ ")                          // Profile connection space
+	binary.BigEndian.PutUint16(header[24:], 2000)      // Creation date (fixed for reproducible output)
+	binary.BigEndian.PutUint16(header[26:], 1)
+	binary.BigEndian.PutUint16(header[28:], 1)
+	copy(header[36:], "acsp")
+	binary.BigEndian.PutUint32(header[64:], 0)         // Perceptual rendering intent
+	copy(header[68:], iccXYZ(0.9642, 1.0, 0.8249)[8:]) // PCS illuminant (D50)
+
+	out := make([]byte, 0, size)
+	out = append(out, header...)
+	out = append(out, table.Bytes()...)
+	out = append(out, data.Bytes()...)
+	return out
+}
+
+// iccS15Fixed16 encodes a value as an ICC s15Fixed16Number
+func iccS15Fixed16(v float64) uint32 {
+	return uint32(int32(math.Round(v * 65536)))
+}
+
+// iccXYZ encodes an XYZType tag
+func iccXYZ(x, y, z float64) []byte {
+	b := make([]byte, 20)
+	copy(b, "XYZ ")
+	binary.BigEndian.PutUint32(b[8:], iccS15Fixed16(x))
+	binary.BigEndian.PutUint32(b[12:], iccS15Fixed16(y))
+	binary.BigEndian.PutUint32(b[16:], iccS15Fixed16(z))
+	return b
+}
+
+// iccCurve encodes a curveType tag by sampling f over [0, 1]
+func iccCurve(n int, f func(float64) float64) []byte {
+	b := make([]byte, 12+2*n)
+	copy(b, "curv")
+	binary.BigEndian.PutUint32(b[8:], uint32(n))
+	for i := 0; i < n; i++ {
+		v := f(float64(i) / float64(n-1))
+		binary.BigEndian.PutUint16(b[12+2*i:], uint16(math.Round(v*65535)))
+	}
+	return b
+}
+
+// iccText encodes a textType tag
+func iccText(s string) []byte {
+	b := make([]byte, 8, 8+len(s)+1)
+	copy(b, "text")
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+// iccDesc encodes a v2 textDescriptionType tag (ASCII only, empty Unicode/ScriptCode parts)
+func iccDesc(s string) []byte {
+	b := make([]byte, 12, 12+len(s)+1+4+4+2+1+67)
+	copy(b, "desc")
+	binary.BigEndian.PutUint32(b[8:], uint32(len(s)+1))
+	b = append(b, s...)
+	b = append(b, 0)
+	b = append(b, make([]byte, 4+4+2+1+67)...) // Unicode lang/count, ScriptCode code/count/data
+	return b
+}
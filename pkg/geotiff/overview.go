@@ -0,0 +1,156 @@
+package geotiff
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// minOverviewSize stops overview generation once a level would be smaller than this (px)
+// Matches GDAL's default overview block size
+const minOverviewSize = 256
+
+// DefaultOverviewLevels returns the power-of-two reduction factors (2, 4, 8...) to build
+// for an image, stopping once the overview's larger side would drop below 256 pixels.
+func DefaultOverviewLevels(width, height int) []int {
+	size := width
+	if height > size {
+		size = height
+	}
+
+	var levels []int
+	for factor := 2; size/factor >= minOverviewSize; factor *= 2 {
+		levels = append(levels, factor)
+	}
+	return levels
+}
+
+// buildOverviews returns box-filtered reductions of m for each factor (ascending, >1),
+// at 16 bits per channel when deep is set and 8 otherwise.
+// Each level is derived from the previous one when the factors divide evenly, so a
+// 2/4/8 pyramid only reads the full-resolution image once.
+func buildOverviews(m image.Image, factors []int, deep bool) []image.Image {
+	sorted := make([]int, 0, len(factors))
+	seen := make(map[int]bool)
+	for _, f := range factors {
+		if f > 1 && !seen[f] {
+			seen[f] = true
+			sorted = append(sorted, f)
+		}
+	}
+	sort.Ints(sorted)
+
+	// Both pixel formats hold four channels; 16-bit ones take two bytes each
+	var base image.Image
+	downsample := func(src image.Image, k int) image.Image { return downsampleBox(src.(*image.RGBA), k) }
+	if deep {
+		base = toRGBA64(m)
+		downsample = func(src image.Image, k int) image.Image { return downsampleBox64(src.(*image.RGBA64), k) }
+	} else {
+		base = toRGBA(m)
+	}
+	prev, prevFactor := base, 1
+
+	var overviews []image.Image
+	for _, factor := range sorted {
+		src, ratio := base, factor
+		if factor%prevFactor == 0 {
+			src, ratio = prev, factor/prevFactor
+		}
+
+		ov := downsample(src, ratio)
+		if ov.Bounds().Dx() == 0 || ov.Bounds().Dy() == 0 {
+			break
+		}
+		overviews = append(overviews, ov)
+		prev, prevFactor = ov, factor
+	}
+	return overviews
+}
+
+// downsampleBox reduces src by an integer factor, averaging each k x k block
+// Edge blocks that extend past the image average only the pixels that exist
+func downsampleBox(src *image.RGBA, k int) *image.RGBA {
+	b := src.Bounds()
+	w := (b.Dx() + k - 1) / k
+	h := (b.Dy() + k - 1) / k
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum [4]int
+			n := 0
+			for sy := y * k; sy < (y+1)*k && sy < b.Dy(); sy++ {
+				off := src.PixOffset(b.Min.X+x*k, b.Min.Y+sy)
+				for sx := x * k; sx < (x+1)*k && sx < b.Dx(); sx++ {
+					sum[0] += int(src.Pix[off])
+					sum[1] += int(src.Pix[off+1])
+					sum[2] += int(src.Pix[off+2])
+					sum[3] += int(src.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(sum[0] / n)
+			dst.Pix[i+1] = uint8(sum[1] / n)
+			dst.Pix[i+2] = uint8(sum[2] / n)
+			dst.Pix[i+3] = uint8(sum[3] / n)
+		}
+	}
+	return dst
+}
+
+// downsampleBox64 is downsampleBox for 16-bit channels
+func downsampleBox64(src *image.RGBA64, k int) *image.RGBA64 {
+	b := src.Bounds()
+	w := (b.Dx() + k - 1) / k
+	h := (b.Dy() + k - 1) / k
+	dst := image.NewRGBA64(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum [4]int
+			n := 0
+			for sy := y * k; sy < (y+1)*k && sy < b.Dy(); sy++ {
+				off := src.PixOffset(b.Min.X+x*k, b.Min.Y+sy)
+				for sx := x * k; sx < (x+1)*k && sx < b.Dx(); sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[off+2*c])<<8 | int(src.Pix[off+2*c+1])
+					}
+					off += 8
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				v := sum[c] / n
+				dst.Pix[i+2*c] = uint8(v >> 8)
+				dst.Pix[i+2*c+1] = uint8(v)
+			}
+		}
+	}
+	return dst
+}
+
+// toRGBA returns m as *image.RGBA, converting only when necessary
+func toRGBA(m image.Image) *image.RGBA {
+	if rgba, ok := m.(*image.RGBA); ok {
+		return rgba
+	}
+	b := m.Bounds()
+	rgba := image.NewRGBA(b)
+	draw.Draw(rgba, b, m, b.Min, draw.Src)
+	return rgba
+}
+
+// toRGBA64 returns m as *image.RGBA64, converting only when necessary
+func toRGBA64(m image.Image) *image.RGBA64 {
+	if rgba, ok := m.(*image.RGBA64); ok {
+		return rgba
+	}
+	b := m.Bounds()
+	rgba := image.NewRGBA64(b)
+	draw.Draw(rgba, b, m, b.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,258 @@
+package geotiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+
+	_ "golang.org/x/image/tiff" // Register the TIFF decoder Load falls back to
+)
+
+// Georeference is the placement of a GeoTIFF read back from disk
+type Georeference struct {
+	Width       int
+	Height      int
+	OriginX     float64 // World X of the top-left corner
+	OriginY     float64 // World Y of the top-left corner
+	PixelWidth  float64
+	PixelHeight float64 // Negative: Y decreases going down
+	EPSG        int     // 0 when the GeoKeys name no EPSG code
+}
+
+// ReadGeoreference reads the size, origin, pixel size and EPSG code of a GeoTIFF without
+// decoding its pixels. Only north-up rasters (tiepoint + pixel scale) are supported,
+// which covers every GeoTIFF this app writes.
+func ReadGeoreference(path string) (Georeference, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Georeference{}, err
+	}
+	defer f.Close()
+
+	tags, err := readFirstIFD(f)
+	if err != nil {
+		return Georeference{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	geo := Georeference{
+		Width:  int(tags.uint(TagType_ImageWidth)),
+		Height: int(tags.uint(TagType_ImageLength)),
+	}
+
+	scale := tags.doubles(TagType_ModelPixelScaleTag)
+	tiepoint := tags.doubles(TagType_ModelTiepointTag)
+	if len(scale) < 2 || len(tiepoint) < 6 {
+		return Georeference{}, fmt.Errorf("%s has no GeoTIFF georeferencing", path)
+	}
+	geo.PixelWidth = scale[0]
+	geo.PixelHeight = -scale[1]
+	geo.OriginX = tiepoint[3] - tiepoint[0]*geo.PixelWidth
+	geo.OriginY = tiepoint[4] - tiepoint[1]*geo.PixelHeight
+
+	// GeoKeyDirectory: 4-value header then (key, location, count, value) entries
+	keys := tags.shorts(TagType_GeoKeyDirectoryTag)
+	for i := 4; i+3 < len(keys); i += 4 {
+		if keys[i+1] != 0 {
+			continue // Value stored in another tag
+		}
+		switch keys[i] {
+		case 3072, 2048: // ProjectedCSTypeGeoKey, GeographicTypeGeoKey
+			if geo.EPSG == 0 || keys[i] == 3072 {
+				geo.EPSG = int(keys[i+3])
+			}
+		}
+	}
+
+	return geo, nil
+}
+
+// Load decodes a GeoTIFF's image and reads its georeferencing
+func Load(path string) (image.Image, Georeference, error) {
+	geo, err := ReadGeoreference(path)
+	if err != nil {
+		return nil, Georeference{}, err
+	}
+
+	img, err := decodeFile(path)
+	if err != nil {
+		return nil, Georeference{}, err
+	}
+	return img, geo, nil
+}
+
+// ifdTags holds the raw values of the tags in one IFD
+type ifdTags struct {
+	order  binary.ByteOrder
+	values map[uint16]tagValue
+}
+
+type tagValue struct {
+	datatype uint16
+	count    uint32
+	data     []byte
+}
+
+// readFirstIFD reads the header of a classic TIFF or BigTIFF and then its first IFD
+func readFirstIFD(r io.ReaderAt) (ifdTags, error) {
+	header := make([]byte, 16)
+	if _, err := r.ReadAt(header[:8], 0); err != nil {
+		return ifdTags{}, fmt.Errorf("failed to read TIFF header: %w", err)
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return ifdTags{}, fmt.Errorf("not a TIFF file")
+	}
+
+	switch order.Uint16(header[2:4]) {
+	case 42:
+		return readIFD(r, order, int64(order.Uint32(header[4:8])), classicFormat)
+	case 43:
+		// BigTIFF: offset size (always 8), reserved, then an 8-byte first IFD offset
+		if _, err := r.ReadAt(header[8:16], 8); err != nil {
+			return ifdTags{}, fmt.Errorf("failed to read BigTIFF header: %w", err)
+		}
+		if order.Uint16(header[4:6]) != 8 {
+			return ifdTags{}, fmt.Errorf("unsupported BigTIFF offset size %d", order.Uint16(header[4:6]))
+		}
+		return readIFD(r, order, int64(order.Uint64(header[8:16])), bigFormat)
+	default:
+		return ifdTags{}, fmt.Errorf("unknown TIFF version %d", order.Uint16(header[2:4]))
+	}
+}
+
+// readIFD reads every entry of the IFD at offset, loading out-of-line values
+func readIFD(r io.ReaderAt, order binary.ByteOrder, offset int64, format tiffFormat) (ifdTags, error) {
+	tags := ifdTags{order: order, values: make(map[uint16]tagValue)}
+
+	countBuf := make([]byte, format.countSize)
+	if _, err := r.ReadAt(countBuf, offset); err != nil {
+		return tags, fmt.Errorf("failed to read IFD: %w", err)
+	}
+	n := int(format.readOffset(order, countBuf))
+	if n > 1<<16 {
+		return tags, fmt.Errorf("invalid IFD entry count %d", n)
+	}
+
+	entries := make([]byte, n*format.entrySize)
+	if _, err := r.ReadAt(entries, offset+int64(format.countSize)); err != nil {
+		return tags, fmt.Errorf("failed to read IFD entries: %w", err)
+	}
+
+	for i := 0; i < n; i++ {
+		e := entries[i*format.entrySize : (i+1)*format.entrySize]
+		tag := order.Uint16(e[0:2])
+		datatype := order.Uint16(e[2:4])
+		count := format.readOffset(order, e[4:4+format.valueSize])
+		value := e[4+format.valueSize:]
+
+		size := int64(0)
+		if count <= 1<<20 {
+			size = typeSize(datatype) * int64(count)
+		}
+		if size <= 0 || size > 1<<20 {
+			continue // Unknown type, or pixel-sized data (strip tables, ICC) we don't need
+		}
+
+		data := make([]byte, size)
+		if size <= int64(format.valueSize) {
+			copy(data, value[:size])
+		} else if _, err := r.ReadAt(data, int64(format.readOffset(order, value))); err != nil {
+			return tags, fmt.Errorf("failed to read tag %d: %w", tag, err)
+		}
+		tags.values[tag] = tagValue{datatype: datatype, count: uint32(count), data: data}
+	}
+	return tags, nil
+}
+
+// readOffset decodes an offset or count field of the format's offset size
+func (f tiffFormat) readOffset(order binary.ByteOrder, b []byte) uint64 {
+	switch len(b) {
+	case 2:
+		return uint64(order.Uint16(b))
+	case 4:
+		return uint64(order.Uint32(b))
+	default:
+		return order.Uint64(b)
+	}
+}
+
+// typeSize returns the byte size of one value of a TIFF data type (0 if unknown)
+func typeSize(datatype uint16) int64 {
+	switch datatype {
+	case DataType_Byte, DataType_ASCII, DataType_Undefined:
+		return 1
+	case DataType_Short:
+		return 2
+	case DataType_Long, DataType_IFD:
+		return 4
+	case DataType_Rational, DataType_Double, DataType_Long8, DataType_IFD8:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// uint returns the first value of a SHORT, LONG or LONG8 tag
+func (t ifdTags) uint(tag uint16) uint64 {
+	values := t.uints(tag)
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0]
+}
+
+// uints returns the values of a SHORT, LONG or LONG8 tag
+func (t ifdTags) uints(tag uint16) []uint64 {
+	v, ok := t.values[tag]
+	if !ok {
+		return nil
+	}
+	out := make([]uint64, v.count)
+	for i := range out {
+		switch v.datatype {
+		case DataType_Short:
+			out[i] = uint64(t.order.Uint16(v.data[i*2:]))
+		case DataType_Long:
+			out[i] = uint64(t.order.Uint32(v.data[i*4:]))
+		case DataType_Long8:
+			out[i] = t.order.Uint64(v.data[i*8:])
+		default:
+			return nil
+		}
+	}
+	return out
+}
+
+// shorts returns the values of a SHORT tag
+func (t ifdTags) shorts(tag uint16) []uint16 {
+	v, ok := t.values[tag]
+	if !ok || v.datatype != DataType_Short {
+		return nil
+	}
+	out := make([]uint16, v.count)
+	for i := range out {
+		out[i] = t.order.Uint16(v.data[i*2:])
+	}
+	return out
+}
+
+// doubles returns the values of a DOUBLE tag
+func (t ifdTags) doubles(tag uint16) []float64 {
+	v, ok := t.values[tag]
+	if !ok || v.datatype != DataType_Double {
+		return nil
+	}
+	out := make([]float64, v.count)
+	for i := range out {
+		out[i] = math.Float64frombits(t.order.Uint64(v.data[i*8:]))
+	}
+	return out
+}
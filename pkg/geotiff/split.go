@@ -0,0 +1,170 @@
+package geotiff
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxDimension is the default maximum width/height of a single GeoTIFF part.
+// Larger exports are split into a grid of parts referenced by a VRT index.
+const DefaultMaxDimension = 30000
+
+// maxEncodableDimension is the largest width/height Encode can represent (SHORT tags)
+const maxEncodableDimension = 65535
+
+// partAlignment keeps part edges on tile boundaries
+const partAlignment = 256
+
+// SavePartFunc writes one georeferenced part of a split export.
+// It receives the part's sub-image, output path and top-left world coordinates.
+type SavePartFunc func(part image.Image, outputPath string, originX, originY float64) error
+
+// VRTSource is one GeoTIFF part referenced by a VRT index
+type VRTSource struct {
+	Filename string // Relative to the VRT file
+	XOff     int
+	YOff     int
+	Width    int
+	Height   int
+}
+
+// SaveSplit writes img through save, splitting it into an N x M grid of parts when either
+// dimension exceeds maxDim. Parts are named "<base>_part_r{row}_c{col}.tif" and indexed by
+// a GDAL VRT at "<base>.vrt" so GIS tools can open the export as a single layer.
+// Images within the limit are passed straight to save at outputPath.
+// pixelHeight may be positive or negative; parts always step down the image.
+// bands must match what save writes (3 for RGB, 4 with alpha) so the VRT describes the parts.
+// Returns the paths written (the VRT first when split).
+func SaveSplit(img image.Image, outputPath string, originX, originY, pixelWidth, pixelHeight float64, epsg, bands, maxDim int, save SavePartFunc) ([]string, error) {
+	if maxDim <= 0 || maxDim > maxEncodableDimension {
+		maxDim = DefaultMaxDimension
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDim && height <= maxDim {
+		if err := save(img, outputPath, originX, originY); err != nil {
+			return nil, err
+		}
+		return []string{outputPath}, nil
+	}
+
+	sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("image type %T does not support splitting", img)
+	}
+
+	colEdges := splitEdges(width, maxDim)
+	rowEdges := splitEdges(height, maxDim)
+
+	stepY := pixelHeight
+	if stepY > 0 {
+		stepY = -stepY
+	}
+
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	paths := []string{base + ".vrt"}
+	var sources []VRTSource
+
+	for r := 0; r < len(rowEdges)-1; r++ {
+		for c := 0; c < len(colEdges)-1; c++ {
+			x0, x1 := colEdges[c], colEdges[c+1]
+			y0, y1 := rowEdges[r], rowEdges[r+1]
+
+			partPath := fmt.Sprintf("%s_part_r%d_c%d.tif", base, r, c)
+			rect := image.Rect(bounds.Min.X+x0, bounds.Min.Y+y0, bounds.Min.X+x1, bounds.Min.Y+y1)
+			partOriginX := originX + float64(x0)*pixelWidth
+			partOriginY := originY + float64(y0)*stepY
+
+			if err := save(sub.SubImage(rect), partPath, partOriginX, partOriginY); err != nil {
+				return paths[1:], fmt.Errorf("failed to save part r%d c%d: %w", r, c, err)
+			}
+
+			paths = append(paths, partPath)
+			sources = append(sources, VRTSource{
+				Filename: filepath.Base(partPath),
+				XOff:     x0,
+				YOff:     y0,
+				Width:    x1 - x0,
+				Height:   y1 - y0,
+			})
+		}
+	}
+
+	if err := WriteVRT(paths[0], width, height, originX, originY, pixelWidth, stepY, epsg, bands, sources); err != nil {
+		return paths[1:], err
+	}
+
+	return paths, nil
+}
+
+// splitEdges divides size into the fewest equal-ish spans no larger than maxDim,
+// with inner edges aligned to tile boundaries where possible.
+// Returns the span edges including 0 and size.
+func splitEdges(size, maxDim int) []int {
+	for n := (size + maxDim - 1) / maxDim; ; n++ {
+		edges := []int{0}
+		valid := true
+		for i := 1; i <= n; i++ {
+			edge := size * i / n
+			if i < n && edge-edge%partAlignment > edges[i-1] {
+				edge -= edge % partAlignment
+			}
+			if edge-edges[i-1] > maxDim || edge <= edges[i-1] {
+				valid = false
+				break
+			}
+			edges = append(edges, edge)
+		}
+		if valid {
+			return edges
+		}
+	}
+}
+
+// WriteVRT writes a GDAL VRT mosaic of GeoTIFF parts.
+// pixelHeight should be negative for north-up images. bands is 3 (RGB) or 4 (RGBA).
+func WriteVRT(path string, width, height int, originX, originY, pixelWidth, pixelHeight float64, epsg, bands int, sources []VRTSource) error {
+	colorInterp := []string{"Red", "Green", "Blue", "Alpha"}
+	if bands < 1 || bands > len(colorInterp) {
+		return fmt.Errorf("unsupported band count for VRT: %d", bands)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<VRTDataset rasterXSize=\"%d\" rasterYSize=\"%d\">\n", width, height)
+	fmt.Fprintf(&buf, "  <SRS>EPSG:%d</SRS>\n", epsg)
+	fmt.Fprintf(&buf, "  <GeoTransform>%.10f, %.10f, 0.0, %.10f, 0.0, %.10f</GeoTransform>\n",
+		originX, pixelWidth, originY, pixelHeight)
+
+	for b := 1; b <= bands; b++ {
+		fmt.Fprintf(&buf, "  <VRTRasterBand dataType=\"Byte\" band=\"%d\">\n", b)
+		fmt.Fprintf(&buf, "    <ColorInterp>%s</ColorInterp>\n", colorInterp[b-1])
+		for _, s := range sources {
+			buf.WriteString("    <SimpleSource>\n")
+			fmt.Fprintf(&buf, "      <SourceFilename relativeToVRT=\"1\">%s</SourceFilename>\n", xmlEscape(s.Filename))
+			fmt.Fprintf(&buf, "      <SourceBand>%d</SourceBand>\n", b)
+			fmt.Fprintf(&buf, "      <SrcRect xOff=\"0\" yOff=\"0\" xSize=\"%d\" ySize=\"%d\" />\n", s.Width, s.Height)
+			fmt.Fprintf(&buf, "      <DstRect xOff=\"%d\" yOff=\"%d\" xSize=\"%d\" ySize=\"%d\" />\n", s.XOff, s.YOff, s.Width, s.Height)
+			buf.WriteString("    </SimpleSource>\n")
+		}
+		buf.WriteString("  </VRTRasterBand>\n")
+	}
+	buf.WriteString("</VRTDataset>\n")
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write VRT: %w", err)
+	}
+	return nil
+}
+
+// xmlEscape escapes the characters that are significant in XML text
+func xmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+	return r.Replace(s)
+}
@@ -0,0 +1,75 @@
+package webp
+
+// boolEncoder is the boolean entropy encoder of RFC 6386 section 7.3
+type boolEncoder struct {
+	buf      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// putBit writes bit with a probability of prob/256 of being false
+func (e *boolEncoder) putBit(bit bool, prob uint8) {
+	split := 1 + ((e.rng-1)*uint32(prob))>>8
+	if bit {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			e.carry()
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.buf = append(e.buf, byte(e.bottom>>24))
+			e.bottom &= 1<<24 - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+// putUint writes the n low bits of v, most significant first, with probability 1/2
+func (e *boolEncoder) putUint(v uint32, n int) {
+	for n > 0 {
+		n--
+		e.putBit(v&(1<<n) != 0, 128)
+	}
+}
+
+// carry propagates an overflow of bottom into the bytes already written
+func (e *boolEncoder) carry() {
+	i := len(e.buf) - 1
+	for i >= 0 && e.buf[i] == 255 {
+		e.buf[i] = 0
+		i--
+	}
+	if i >= 0 {
+		e.buf[i]++
+	}
+}
+
+// finish flushes the remaining state and returns the encoded bytes
+func (e *boolEncoder) finish() []byte {
+	c := e.bitCount
+	v := e.bottom
+	if v&(1<<(32-c)) != 0 {
+		e.carry()
+	}
+	v <<= c & 7
+	for c >>= 3; c > 0; c-- {
+		v <<= 8
+	}
+	for i := 0; i < 4; i++ {
+		e.buf = append(e.buf, byte(v>>24))
+		v <<= 8
+	}
+	return e.buf
+}
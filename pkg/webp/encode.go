@@ -0,0 +1,724 @@
+// Package webp encodes images as lossy WebP (a VP8 key frame in a RIFF container)
+//
+// The encoder is deliberately simple: every macroblock uses whole-block (16x16) luma
+// prediction with the mode chosen by distortion, no segmentation and the default token
+// probabilities. That keeps it fast enough to encode preview tiles on the fly while still
+// beating JPEG on size at similar quality. Alpha is not encoded.
+//
+// Y, U and V are written in limited (studio) range, 16-235 for luma, as VP8 decoders in
+// browsers expect. A decoder that treats them as full range, such as the image.YCbCr
+// conversion of golang.org/x/image/webp, shows white as 235 and black as 16.
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// DefaultQuality is the quality used when Options is nil
+const DefaultQuality = 80
+
+// maxDimension is the largest width or height a VP8 frame can hold
+const maxDimension = 16383
+
+// Options are the encoding parameters
+type Options struct {
+	Quality int // 0-100, higher is better quality and larger files
+}
+
+// Intra prediction modes, in the order of the decoder's mode tree
+const (
+	predDC = iota
+	predV
+	predH
+	predTM
+)
+
+// Rounding biases of the quantizer in 1/256 of a step; below half a step so small
+// coefficients fall to zero (cheaper) rather than round up
+const (
+	biasDC = 96
+	biasAC = 110
+)
+
+// Encode writes m to w as a lossy WebP image, in limited-range Y'CbCr (see the package doc)
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	quality := DefaultQuality
+	if o != nil {
+		quality = max(0, min(100, o.Quality))
+	}
+
+	b := m.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return errors.New("webp: empty image")
+	}
+	if b.Dx() > maxDimension || b.Dy() > maxDimension {
+		return errors.New("webp: image is too large")
+	}
+
+	e := newEncoder(b.Dx(), b.Dy(), qualityToIndex(quality))
+	e.loadImage(m)
+	frame := e.encodeFrame()
+
+	// RIFF container with a single "VP8 " chunk
+	pad := len(frame) & 1
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+len(frame)+pad))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8 ")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(frame)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(frame); err != nil {
+		return err
+	}
+	if pad != 0 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qualityToIndex maps a 0-100 quality to a quantizer index (0-127) the way libwebp does
+// without segments, so qualities compare with other WebP encoders
+func qualityToIndex(quality int) int {
+	c := float64(quality) / 100
+	if c < 0.75 {
+		c *= 2.0 / 3.0
+	} else {
+		c = 2*c - 1
+	}
+	return max(0, min(127, int(127*(1-math.Cbrt(c)))))
+}
+
+// quantizer holds the DC and AC step sizes of one coefficient type
+type quantizer struct {
+	dc, ac int32
+}
+
+// quantize returns the quantized level of coefficient c at position i (0 is DC)
+func (q quantizer) quantize(c int32, i int) int32 {
+	step, bias := q.ac, int32(biasAC)
+	if i == 0 {
+		step, bias = q.dc, biasDC
+	}
+	sign := c < 0
+	if sign {
+		c = -c
+	}
+	level := (c + step*bias>>8) / step
+	if level > 2047 {
+		level = 2047
+	}
+	if sign {
+		return -level
+	}
+	return level
+}
+
+// dequantize returns the coefficient value of level at position i
+func (q quantizer) dequantize(level int32, i int) int32 {
+	if i == 0 {
+		return level * q.dc
+	}
+	return level * q.ac
+}
+
+// encoder holds the frame being encoded. Source and reconstructed planes are padded
+// to whole macroblocks.
+type encoder struct {
+	width, height int
+	mbw, mbh      int
+	qIndex        int
+	y1, y2, uv    quantizer
+
+	yStride, cStride int
+	srcY, srcU, srcV []uint8
+	recY, recU, recV []uint8
+
+	// Non-zero flags of the blocks bordering the current macroblock, used as token contexts
+	topNZ            []uint8 // 4 luma + 2 U + 2 V + 1 Y2 per macroblock column
+	leftNZ           [9]uint8
+	header, residual *boolEncoder
+}
+
+func newEncoder(width, height, qIndex int) *encoder {
+	e := &encoder{
+		width:  width,
+		height: height,
+		mbw:    (width + 15) / 16,
+		mbh:    (height + 15) / 16,
+		qIndex: qIndex,
+	}
+	e.yStride = e.mbw * 16
+	e.cStride = e.mbw * 8
+	e.srcY = make([]uint8, e.yStride*e.mbh*16)
+	e.srcU = make([]uint8, e.cStride*e.mbh*8)
+	e.srcV = make([]uint8, e.cStride*e.mbh*8)
+	e.recY = make([]uint8, len(e.srcY))
+	e.recU = make([]uint8, len(e.srcU))
+	e.recV = make([]uint8, len(e.srcV))
+	e.topNZ = make([]uint8, e.mbw*9)
+
+	// Dequantization factors as derived by the decoder (section 9.6)
+	e.y1 = quantizer{int32(dequantTableDC[qIndex]), int32(dequantTableAC[qIndex])}
+	e.y2 = quantizer{int32(dequantTableDC[qIndex]) * 2, max(8, int32(dequantTableAC[qIndex])*155/100)}
+	e.uv = quantizer{int32(dequantTableDC[min(qIndex, 117)]), int32(dequantTableAC[qIndex])}
+	return e
+}
+
+// loadImage converts m to limited-range YCbCr 4:2:0, replicating the edge pixels into
+// the macroblock padding
+func (e *encoder) loadImage(m image.Image) {
+	b := m.Bounds()
+	padW, padH := e.mbw*16, e.mbh*16
+	r := make([]int32, padW*padH)
+	g := make([]int32, padW*padH)
+	bl := make([]int32, padW*padH)
+
+	for y := 0; y < padH; y++ {
+		sy := b.Min.Y + min(y, e.height-1)
+		for x := 0; x < padW; x++ {
+			sx := b.Min.X + min(x, e.width-1)
+			c := color.NRGBAModel.Convert(m.At(sx, sy)).(color.NRGBA)
+			i := y*padW + x
+			r[i], g[i], bl[i] = int32(c.R), int32(c.G), int32(c.B)
+		}
+	}
+
+	for i := range e.srcY {
+		e.srcY[i] = uint8((16839*r[i] + 33059*g[i] + 6420*bl[i] + 16<<16 + 1<<15) >> 16)
+	}
+	for y := 0; y < padH/2; y++ {
+		for x := 0; x < padW/2; x++ {
+			i := 2*y*padW + 2*x
+			sr := r[i] + r[i+1] + r[i+padW] + r[i+padW+1]
+			sg := g[i] + g[i+1] + g[i+padW] + g[i+padW+1]
+			sb := bl[i] + bl[i+1] + bl[i+padW] + bl[i+padW+1]
+			e.srcU[y*e.cStride+x] = clipUV(-9719*sr - 19081*sg + 28800*sb)
+			e.srcV[y*e.cStride+x] = clipUV(28800*sr - 24116*sg - 4684*sb)
+		}
+	}
+}
+
+// clipUV scales a chroma sum of four pixels to 8 bits
+func clipUV(v int32) uint8 {
+	v = (v + 1<<17 + 128<<18) >> 18
+	return uint8(max(0, min(255, v)))
+}
+
+// encodeFrame encodes all macroblocks and returns the VP8 frame
+func (e *encoder) encodeFrame() []byte {
+	e.header = newBoolEncoder()
+	e.residual = newBoolEncoder()
+	e.writeFrameHeader()
+
+	for mby := 0; mby < e.mbh; mby++ {
+		e.leftNZ = [9]uint8{}
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			e.encodeMacroblock(mbx, mby)
+		}
+	}
+
+	first := e.header.finish()
+	rest := e.residual.finish()
+
+	frame := make([]byte, 10, 10+len(first)+len(rest))
+	// Frame tag: key frame, version 0, shown, first partition size (section 9.1)
+	tag := uint32(1)<<4 | uint32(len(first))<<5
+	frame[0], frame[1], frame[2] = byte(tag), byte(tag>>8), byte(tag>>16)
+	frame[3], frame[4], frame[5] = 0x9d, 0x01, 0x2a
+	binary.LittleEndian.PutUint16(frame[6:8], uint16(e.width))
+	binary.LittleEndian.PutUint16(frame[8:10], uint16(e.height))
+	frame = append(frame, first...)
+	return append(frame, rest...)
+}
+
+// writeFrameHeader writes the key frame header (section 9.2 to 9.11)
+func (e *encoder) writeFrameHeader() {
+	h := e.header
+	h.putUint(0, 1) // Color space
+	h.putUint(0, 1) // Clamping required
+	h.putUint(0, 1) // No segmentation
+	h.putUint(0, 1) // Normal loop filter
+	h.putUint(uint32(filterLevel(e.qIndex)), 6)
+	h.putUint(0, 3) // Sharpness
+	h.putUint(0, 1) // No loop filter deltas
+	h.putUint(0, 2) // One token partition
+	h.putUint(uint32(e.qIndex), 7)
+	for i := 0; i < 5; i++ {
+		h.putUint(0, 1) // No quantizer deltas
+	}
+	h.putUint(0, 1) // Keep the default token probabilities after this frame
+	for i := range tokenProbUpdateProb {
+		for j := range tokenProbUpdateProb[i] {
+			for k := range tokenProbUpdateProb[i][j] {
+				for l := range tokenProbUpdateProb[i][j][k] {
+					h.putBit(false, tokenProbUpdateProb[i][j][k][l])
+				}
+			}
+		}
+	}
+	h.putUint(0, 1) // Coefficients are coded for every macroblock
+}
+
+// filterLevel picks a loop filter strength that grows with the quantizer step so
+// block edges are smoothed in proportion to the artifacts they show
+func filterLevel(qIndex int) int {
+	return min(63, int(dequantTableAC[qIndex])/4+qIndex/8)
+}
+
+// edges holds the reconstructed samples bordering a block, substituted as the decoder
+// does on the frame edges (section 12.2)
+type edges struct {
+	top, left       [16]int32
+	topLeft         int32
+	hasTop, hasLeft bool
+}
+
+// blockEdges returns the edges of the size x size block at (x, y) of plane
+func blockEdges(plane []uint8, stride, x, y, size int) edges {
+	ed := edges{hasTop: y > 0, hasLeft: x > 0}
+	for i := 0; i < size; i++ {
+		ed.top[i], ed.left[i] = 127, 129
+		if ed.hasTop {
+			ed.top[i] = int32(plane[(y-1)*stride+x+i])
+		}
+		if ed.hasLeft {
+			ed.left[i] = int32(plane[(y+i)*stride+x-1])
+		}
+	}
+	switch {
+	case !ed.hasTop:
+		ed.topLeft = 127
+	case !ed.hasLeft:
+		ed.topLeft = 129
+	default:
+		ed.topLeft = int32(plane[(y-1)*stride+x-1])
+	}
+	return ed
+}
+
+// predict fills pred (size x size, row major) using mode
+func predict(pred []int32, ed *edges, mode, size int) {
+	switch mode {
+	case predDC:
+		shift := 3
+		if size == 16 {
+			shift = 4
+		}
+		var sum int32
+		var dc int32 = 128
+		switch {
+		case ed.hasTop && ed.hasLeft:
+			for i := 0; i < size; i++ {
+				sum += ed.top[i] + ed.left[i]
+			}
+			dc = (sum + int32(size)) >> (shift + 1)
+		case ed.hasTop:
+			for i := 0; i < size; i++ {
+				sum += ed.top[i]
+			}
+			dc = (sum + int32(size/2)) >> shift
+		case ed.hasLeft:
+			for i := 0; i < size; i++ {
+				sum += ed.left[i]
+			}
+			dc = (sum + int32(size/2)) >> shift
+		}
+		for i := range pred[:size*size] {
+			pred[i] = dc
+		}
+	case predV:
+		for j := 0; j < size; j++ {
+			copy(pred[j*size:(j+1)*size], ed.top[:size])
+		}
+	case predH:
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				pred[j*size+i] = ed.left[j]
+			}
+		}
+	case predTM:
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				pred[j*size+i] = max(0, min(255, ed.left[j]+ed.top[i]-ed.topLeft))
+			}
+		}
+	}
+}
+
+// bestMode returns the prediction mode with the lowest sum of squared errors over the
+// blocks of planes (one luma plane, or both chroma planes)
+func bestMode(src [][]uint8, eds []edges, stride, x, y, size int, preds [][4][]int32) int {
+	best, bestErr := predDC, int64(math.MaxInt64)
+	for mode := predDC; mode <= predTM; mode++ {
+		var sse int64
+		for p := range src {
+			predict(preds[p][mode], &eds[p], mode, size)
+			for j := 0; j < size; j++ {
+				row := src[p][(y+j)*stride+x:]
+				for i := 0; i < size; i++ {
+					d := int64(row[i]) - int64(preds[p][mode][j*size+i])
+					sse += d * d
+				}
+			}
+		}
+		if sse < bestErr {
+			best, bestErr = mode, sse
+		}
+	}
+	return best
+}
+
+// encodeMacroblock chooses the prediction modes, codes the residuals and reconstructs
+// the macroblock exactly as the decoder will
+func (e *encoder) encodeMacroblock(mbx, mby int) {
+	x, y := mbx*16, mby*16
+	cx, cy := mbx*8, mby*8
+
+	// Luma: 16x16 prediction
+	var lumaPreds [1][4][]int32
+	for m := range lumaPreds[0] {
+		lumaPreds[0][m] = make([]int32, 256)
+	}
+	lumaEdges := []edges{blockEdges(e.recY, e.yStride, x, y, 16)}
+	yMode := bestMode([][]uint8{e.srcY}, lumaEdges, e.yStride, x, y, 16, lumaPreds[:])
+	yPred := lumaPreds[0][yMode]
+
+	var yCoeffs [16][16]int32
+	var dcs [16]int32
+	for n := 0; n < 16; n++ {
+		bx, by := (n%4)*4, (n/4)*4
+		yCoeffs[n] = forwardDCT(e.srcY[(y+by)*e.yStride+x+bx:], e.yStride, yPred[by*16+bx:], 16)
+		dcs[n] = yCoeffs[n][0]
+	}
+	y2 := forwardWHT(dcs)
+	var y2Levels [16]int32
+	for i := range y2 {
+		y2Levels[i] = e.y2.quantize(y2[i], i)
+	}
+	var yLevels [16][16]int32
+	for n := range yCoeffs {
+		for i := 1; i < 16; i++ {
+			yLevels[n][i] = e.y1.quantize(yCoeffs[n][i], i)
+		}
+	}
+
+	// Chroma: 8x8 prediction shared by U and V
+	var chromaPreds [2][4][]int32
+	for p := range chromaPreds {
+		for m := range chromaPreds[p] {
+			chromaPreds[p][m] = make([]int32, 64)
+		}
+	}
+	chromaEdges := []edges{
+		blockEdges(e.recU, e.cStride, cx, cy, 8),
+		blockEdges(e.recV, e.cStride, cx, cy, 8),
+	}
+	uvMode := bestMode([][]uint8{e.srcU, e.srcV}, chromaEdges, e.cStride, cx, cy, 8, chromaPreds[:])
+
+	var uvLevels [2][4][16]int32
+	for p, src := range [][]uint8{e.srcU, e.srcV} {
+		pred := chromaPreds[p][uvMode]
+		for n := 0; n < 4; n++ {
+			bx, by := (n%2)*4, (n/2)*4
+			coeffs := forwardDCT(src[(cy+by)*e.cStride+cx+bx:], e.cStride, pred[by*8+bx:], 8)
+			for i := range coeffs {
+				uvLevels[p][n][i] = e.uv.quantize(coeffs[i], i)
+			}
+		}
+	}
+
+	// Modes go to the first partition (section 11.2)
+	h := e.header
+	h.putBit(true, 145) // 16x16 luma prediction
+	switch yMode {
+	case predDC:
+		h.putBit(false, 156)
+		h.putBit(false, 163)
+	case predV:
+		h.putBit(false, 156)
+		h.putBit(true, 163)
+	case predH:
+		h.putBit(true, 156)
+		h.putBit(false, 128)
+	case predTM:
+		h.putBit(true, 156)
+		h.putBit(true, 128)
+	}
+	h.putBit(uvMode != predDC, 142)
+	if uvMode != predDC {
+		h.putBit(uvMode != predV, 114)
+		if uvMode != predV {
+			h.putBit(uvMode == predTM, 183)
+		}
+	}
+
+	// Residual tokens in decoding order: Y2, luma, U, V (section 13)
+	top := e.topNZ[mbx*9 : mbx*9+9]
+	nz := e.writeCoeffs(planeY2, top[8]+e.leftNZ[8], &y2Levels, 0)
+	top[8], e.leftNZ[8] = nz, nz
+	for n := 0; n < 16; n++ {
+		bx, by := n%4, n/4
+		nz := e.writeCoeffs(planeY1WithY2, top[bx]+e.leftNZ[by], &yLevels[n], 1)
+		top[bx], e.leftNZ[by] = nz, nz
+	}
+	for p := 0; p < 2; p++ {
+		for n := 0; n < 4; n++ {
+			bx, by := 4+2*p+n%2, 4+2*p+n/2
+			nz := e.writeCoeffs(planeUV, top[bx]+e.leftNZ[by], &uvLevels[p][n], 0)
+			top[bx], e.leftNZ[by] = nz, nz
+		}
+	}
+
+	// Reconstruction
+	var y2Deq [16]int32
+	for i := range y2Levels {
+		y2Deq[i] = e.y2.dequantize(y2Levels[i], i)
+	}
+	dcs = inverseWHT(y2Deq)
+	for n := 0; n < 16; n++ {
+		var deq [16]int32
+		deq[0] = dcs[n]
+		for i := 1; i < 16; i++ {
+			deq[i] = e.y1.dequantize(yLevels[n][i], i)
+		}
+		bx, by := (n%4)*4, (n/4)*4
+		inverseDCT(e.recY[(y+by)*e.yStride+x+bx:], e.yStride, yPred[by*16+bx:], 16, &deq)
+	}
+	for p, rec := range [][]uint8{e.recU, e.recV} {
+		pred := chromaPreds[p][uvMode]
+		for n := 0; n < 4; n++ {
+			var deq [16]int32
+			for i := range deq {
+				deq[i] = e.uv.dequantize(uvLevels[p][n][i], i)
+			}
+			bx, by := (n%2)*4, (n/2)*4
+			inverseDCT(rec[(cy+by)*e.cStride+cx+bx:], e.cStride, pred[by*8+bx:], 8, &deq)
+		}
+	}
+}
+
+// writeCoeffs writes the tokens of one block's levels starting at zigzag position first
+// (section 13.2) and returns 1 if any level is non-zero, which is the context of the
+// neighbouring blocks
+func (e *encoder) writeCoeffs(plane int, ctx uint8, levels *[16]int32, first int) uint8 {
+	r := e.residual
+	probs := &defaultTokenProb[plane]
+
+	last := -1
+	for i := first; i < 16; i++ {
+		if levels[zigzag[i]] != 0 {
+			last = i
+		}
+	}
+
+	p := &probs[bands[first]][ctx]
+	if last < 0 {
+		r.putBit(false, p[0]) // End of block
+		return 0
+	}
+	r.putBit(true, p[0])
+
+	for i := first; i <= last; i++ {
+		v := levels[zigzag[i]]
+		if v == 0 {
+			r.putBit(false, p[1])
+			p = &probs[bands[i+1]][0]
+			continue
+		}
+		r.putBit(true, p[1])
+
+		abs := v
+		if abs < 0 {
+			abs = -abs
+		}
+		if abs == 1 {
+			r.putBit(false, p[2])
+		} else {
+			r.putBit(true, p[2])
+			writeLargeValue(r, p, abs)
+		}
+		if abs == 1 {
+			p = &probs[bands[i+1]][1]
+		} else {
+			p = &probs[bands[i+1]][2]
+		}
+		r.putBit(v < 0, 128)
+
+		if i == 15 {
+			break
+		}
+		r.putBit(i < last, p[0])
+	}
+	return 1
+}
+
+// writeLargeValue writes a level of 2 or more through the token tree and, for the
+// categories, its extra bits
+func writeLargeValue(r *boolEncoder, p *[nProb]uint8, v int32) {
+	switch {
+	case v <= 4:
+		r.putBit(false, p[3])
+		if v == 2 {
+			r.putBit(false, p[4])
+		} else {
+			r.putBit(true, p[4])
+			r.putBit(v == 4, p[5])
+		}
+	case v <= 10:
+		r.putBit(true, p[3])
+		r.putBit(false, p[6])
+		if v <= 6 {
+			r.putBit(false, p[7])
+			r.putBit(v == 6, 159)
+		} else {
+			r.putBit(true, p[7])
+			r.putBit((v-7)&2 != 0, 165)
+			r.putBit((v-7)&1 != 0, 145)
+		}
+	default:
+		r.putBit(true, p[3])
+		r.putBit(true, p[6])
+		cat := 3
+		for cat > 0 && v < 3+int32(8<<cat) {
+			cat--
+		}
+		r.putBit(cat >= 2, p[8])
+		r.putBit(cat&1 != 0, p[9+cat/2])
+		extra := v - 3 - int32(8<<cat)
+		tab := &cat3456[cat]
+		n := 0
+		for tab[n] != 0 {
+			n++
+		}
+		for i := 0; i < n; i++ {
+			r.putBit(extra&(1<<(n-1-i)) != 0, tab[i])
+		}
+	}
+}
+
+// forwardDCT returns the transform of the 4x4 residual src - pred (libwebp's integer
+// approximation of section 14.3's inverse)
+func forwardDCT(src []uint8, srcStride int, pred []int32, predStride int) [16]int32 {
+	var tmp, out [16]int32
+	for i := 0; i < 4; i++ {
+		s, p := src[i*srcStride:], pred[i*predStride:]
+		d0 := int32(s[0]) - p[0]
+		d1 := int32(s[1]) - p[1]
+		d2 := int32(s[2]) - p[2]
+		d3 := int32(s[3]) - p[3]
+		a0, a1, a2, a3 := d0+d3, d1+d2, d1-d2, d0-d3
+		tmp[0+i*4] = (a0 + a1) * 8
+		tmp[1+i*4] = (a2*2217 + a3*5352 + 1812) >> 9
+		tmp[2+i*4] = (a0 - a1) * 8
+		tmp[3+i*4] = (a3*2217 - a2*5352 + 937) >> 9
+	}
+	for i := 0; i < 4; i++ {
+		a0 := tmp[0+i] + tmp[12+i]
+		a1 := tmp[4+i] + tmp[8+i]
+		a2 := tmp[4+i] - tmp[8+i]
+		a3 := tmp[0+i] - tmp[12+i]
+		out[0+i] = (a0 + a1 + 7) >> 4
+		out[4+i] = (a2*2217 + a3*5352 + 12000) >> 16
+		if a3 != 0 {
+			out[4+i]++
+		}
+		out[8+i] = (a0 - a1 + 7) >> 4
+		out[12+i] = (a3*2217 - a2*5352 + 51000) >> 16
+	}
+	return out
+}
+
+// inverseDCT adds the inverse transform of coeffs to pred and stores the result in dst
+// (section 14.3)
+func inverseDCT(dst []uint8, dstStride int, pred []int32, predStride int, coeffs *[16]int32) {
+	const (
+		c1 = 85627 // 65536 * cos(pi/8) * sqrt(2)
+		c2 = 35468 // 65536 * sin(pi/8) * sqrt(2)
+	)
+	var m [4][4]int32
+	for i := 0; i < 4; i++ {
+		a := coeffs[i] + coeffs[8+i]
+		b := coeffs[i] - coeffs[8+i]
+		c := (coeffs[4+i]*c2)>>16 - (coeffs[12+i]*c1)>>16
+		d := (coeffs[4+i]*c1)>>16 + (coeffs[12+i]*c2)>>16
+		m[i][0] = a + d
+		m[i][1] = b + c
+		m[i][2] = b - c
+		m[i][3] = a - d
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a := dc + m[2][j]
+		b := dc - m[2][j]
+		c := (m[1][j]*c2)>>16 - (m[3][j]*c1)>>16
+		d := (m[1][j]*c1)>>16 + (m[3][j]*c2)>>16
+		row, p := dst[j*dstStride:], pred[j*predStride:]
+		row[0] = uint8(max(0, min(255, p[0]+(a+d)>>3)))
+		row[1] = uint8(max(0, min(255, p[1]+(b+c)>>3)))
+		row[2] = uint8(max(0, min(255, p[2]+(b-c)>>3)))
+		row[3] = uint8(max(0, min(255, p[3]+(a-d)>>3)))
+	}
+}
+
+// forwardWHT returns the Walsh-Hadamard transform of the 16 luma DC coefficients
+func forwardWHT(in [16]int32) [16]int32 {
+	var tmp, out [16]int32
+	for i := 0; i < 4; i++ {
+		a0 := in[4*i+0] + in[4*i+2]
+		a1 := in[4*i+1] + in[4*i+3]
+		a2 := in[4*i+1] - in[4*i+3]
+		a3 := in[4*i+0] - in[4*i+2]
+		tmp[0+i*4] = a0 + a1
+		tmp[1+i*4] = a3 + a2
+		tmp[2+i*4] = a3 - a2
+		tmp[3+i*4] = a0 - a1
+	}
+	for i := 0; i < 4; i++ {
+		a0 := tmp[0+i] + tmp[8+i]
+		a1 := tmp[4+i] + tmp[12+i]
+		a2 := tmp[4+i] - tmp[12+i]
+		a3 := tmp[0+i] - tmp[8+i]
+		out[0+i] = (a0 + a1) >> 1
+		out[4+i] = (a3 + a2) >> 1
+		out[8+i] = (a3 - a2) >> 1
+		out[12+i] = (a0 - a1) >> 1
+	}
+	return out
+}
+
+// inverseWHT returns the 16 luma DC coefficients from the Y2 block (section 14.3)
+func inverseWHT(in [16]int32) [16]int32 {
+	var m, out [16]int32
+	for i := 0; i < 4; i++ {
+		a0 := in[0+i] + in[12+i]
+		a1 := in[4+i] + in[8+i]
+		a2 := in[4+i] - in[8+i]
+		a3 := in[0+i] - in[12+i]
+		m[0+i] = a0 + a1
+		m[8+i] = a0 - a1
+		m[4+i] = a3 + a2
+		m[12+i] = a3 - a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[0+i*4] + 3
+		a0 := dc + m[3+i*4]
+		a1 := m[1+i*4] + m[2+i*4]
+		a2 := m[1+i*4] - m[2+i*4]
+		a3 := dc - m[3+i*4]
+		out[4*i+0] = (a0 + a1) >> 3
+		out[4*i+1] = (a3 + a2) >> 3
+		out[4*i+2] = (a0 - a1) >> 3
+		out[4*i+3] = (a3 - a2) >> 3
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+// testImage is a deterministic mix of gradients, edges and texture, the kind of content
+// imagery tiles have
+func testImage(w, h int) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r := uint8(x * 255 / max(1, w-1))
+			g := uint8(y * 255 / max(1, h-1))
+			b := uint8(128 + 60*math.Sin(float64(x)/5)*math.Cos(float64(y)/7))
+			if (x/24+y/24)%2 == 0 {
+				r, b = b, r
+			}
+			m.SetNRGBA(x, y, color.NRGBA{r, g, b, 255})
+		}
+	}
+	return m
+}
+
+// studioYCbCr converts a pixel to limited-range BT.601 Y'CbCr, the range the encoder writes
+func studioYCbCr(c color.NRGBA) (y, cb, cr float64) {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	y = 16 + (65.738*r+129.057*g+25.064*b)/256
+	cb = 128 + (-37.945*r-74.494*g+112.439*b)/256
+	cr = 128 + (112.439*r-94.154*g-18.285*b)/256
+	return y, cb, cr
+}
+
+// psnr returns the peak signal-to-noise ratio of a plane in dB (+Inf when identical)
+func psnr(sumSquares float64, n int) float64 {
+	if sumSquares == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/(sumSquares/float64(n)))
+}
+
+// roundTrip encodes m and decodes it with golang.org/x/image/webp
+func roundTrip(t *testing.T, m image.Image, o *Options) (*image.YCbCr, int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, m, o); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	size := buf.Len()
+	decoded, err := xwebp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	ycc, ok := decoded.(*image.YCbCr)
+	if !ok {
+		t.Fatalf("decoded a %T, want *image.YCbCr", decoded)
+	}
+	return ycc, size
+}
+
+// planePSNR compares the decoded luma and chroma planes with the source in studio range
+func planePSNR(src *image.NRGBA, ycc *image.YCbCr) (lumaPSNR, chromaPSNR float64) {
+	b := src.Bounds()
+	var lumaErr, chromaErr float64
+	chromaCount := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			want, _, _ := studioYCbCr(src.NRGBAAt(x, y))
+			d := want - float64(ycc.Y[ycc.YOffset(x, y)])
+			lumaErr += d * d
+		}
+	}
+	// Chroma is the average of each 2x2 block, with the edge pixels replicated into
+	// blocks the image only partly covers, as the encoder pads them
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x += 2 {
+			var cb, cr float64
+			for _, p := range [][2]int{{x, y}, {x + 1, y}, {x, y + 1}, {x + 1, y + 1}} {
+				_, pb, pr := studioYCbCr(src.NRGBAAt(min(p[0], b.Max.X-1), min(p[1], b.Max.Y-1)))
+				cb += pb / 4
+				cr += pr / 4
+			}
+			i := ycc.COffset(x, y)
+			db, dr := cb-float64(ycc.Cb[i]), cr-float64(ycc.Cr[i])
+			chromaErr += db*db + dr*dr
+			chromaCount += 2
+		}
+	}
+	lumaPSNR = psnr(lumaErr, b.Dx()*b.Dy())
+	chromaPSNR = psnr(chromaErr, chromaCount)
+	return lumaPSNR, chromaPSNR
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	// PSNR floors per quality, in dB, for the luma and chroma planes. Quality 0 is coarse
+	// on thin strips, where most pixels sit on a block edge.
+	floors := map[int][2]float64{0: {18, 16}, 50: {34, 33}, 80: {38, 38}, 100: {50, 50}}
+
+	for _, size := range [][2]int{{1, 1}, {2, 2}, {15, 15}, {16, 16}, {17, 33}, {255, 3}, {3, 255}, {256, 256}} {
+		src := testImage(size[0], size[1])
+		for quality, floor := range floors {
+			ycc, _ := roundTrip(t, src, &Options{Quality: quality})
+			if got := ycc.Bounds().Size(); got.X != size[0] || got.Y != size[1] {
+				t.Errorf("%dx%d q%d: decoded size %v", size[0], size[1], quality, got)
+				continue
+			}
+			luma, chroma := planePSNR(src, ycc)
+			if luma < floor[0] || chroma < floor[1] {
+				t.Errorf("%dx%d q%d: PSNR luma %.1f dB, chroma %.1f dB, want at least %.0f and %.0f",
+					size[0], size[1], quality, luma, chroma, floor[0], floor[1])
+			}
+		}
+	}
+}
+
+func TestEncodeQuality(t *testing.T) {
+	src := testImage(256, 256)
+
+	// Out-of-range qualities clamp, nil options use DefaultQuality
+	_, low := roundTrip(t, src, &Options{Quality: 0})
+	_, below := roundTrip(t, src, &Options{Quality: -20})
+	_, high := roundTrip(t, src, &Options{Quality: 100})
+	_, above := roundTrip(t, src, &Options{Quality: 150})
+	_, def := roundTrip(t, src, nil)
+	_, explicit := roundTrip(t, src, &Options{Quality: DefaultQuality})
+	if below != low || above != high || def != explicit {
+		t.Errorf("sizes q-20 %d, q0 %d, q150 %d, q100 %d, nil %d, q%d %d", below, low, above, high, def, DefaultQuality, explicit)
+	}
+	if low >= high {
+		t.Errorf("quality 0 is %d bytes, quality 100 is %d, want smaller", low, high)
+	}
+
+	prevLuma := 0.0
+	for _, quality := range []int{0, 25, 50, 75, 100} {
+		ycc, _ := roundTrip(t, src, &Options{Quality: quality})
+		luma, _ := planePSNR(src, ycc)
+		if luma+0.5 < prevLuma {
+			t.Errorf("quality %d PSNR %.1f dB is below the previous quality's %.1f dB", quality, luma, prevLuma)
+		}
+		prevLuma = luma
+	}
+}
+
+// TestEncodeStudioRange checks Y/U/V are written in limited range: a full-range decoder
+// such as image.YCbCr's conversion shows white as 235 and black as 16
+func TestEncodeStudioRange(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		c    color.NRGBA
+		y    uint8
+	}{
+		{"white", color.NRGBA{255, 255, 255, 255}, 235},
+		{"black", color.NRGBA{0, 0, 0, 255}, 16},
+	} {
+		src := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+		for i := 0; i < len(src.Pix); i += 4 {
+			src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = tt.c.R, tt.c.G, tt.c.B, tt.c.A
+		}
+		ycc, _ := roundTrip(t, src, &Options{Quality: 100})
+		for _, y := range ycc.Y {
+			if diff := int(y) - int(tt.y); diff < -1 || diff > 1 {
+				t.Errorf("%s: decoded Y = %d, want %d", tt.name, y, tt.y)
+				break
+			}
+		}
+		for i := range ycc.Cb {
+			if ycc.Cb[i] < 127 || ycc.Cb[i] > 129 || ycc.Cr[i] < 127 || ycc.Cr[i] > 129 {
+				t.Errorf("%s: decoded chroma (%d, %d), want neutral 128", tt.name, ycc.Cb[i], ycc.Cr[i])
+				break
+			}
+		}
+	}
+}
+
+func TestEncodeOffsetBounds(t *testing.T) {
+	// Images whose bounds do not start at the origin encode their own pixels
+	src := testImage(48, 40)
+	sub := src.SubImage(image.Rect(5, 7, 38, 40)).(*image.NRGBA)
+	ycc, _ := roundTrip(t, sub, &Options{Quality: 90})
+	if got := ycc.Bounds().Size(); got != sub.Bounds().Size() {
+		t.Fatalf("decoded size %v, want %v", got, sub.Bounds().Size())
+	}
+	shifted := image.NewNRGBA(image.Rect(0, 0, 33, 33))
+	for y := 0; y < 33; y++ {
+		for x := 0; x < 33; x++ {
+			shifted.SetNRGBA(x, y, sub.NRGBAAt(x+5, y+7))
+		}
+	}
+	if luma, _ := planePSNR(shifted, ycc); luma < 36 {
+		t.Errorf("offset image PSNR %.1f dB, want at least 36", luma)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 0, 10)), nil); err == nil {
+		t.Error("Encode accepted an empty image")
+	}
+	if err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, maxDimension+1, 1)), nil); err == nil {
+		t.Error("Encode accepted an image wider than a VP8 frame")
+	}
+}